@@ -286,6 +286,16 @@ type ClusterInstallationStatus struct {
 	// that are running with the desired image.
 	// +optional
 	UpdatedReplicas int32 `json:"updatedReplicas,omitempty"`
+	// Number of pods the Mattermost deployment should run
+	// +optional
+	DesiredReplicas int32 `json:"desiredReplicas,omitempty"`
+	// The last error of the reconciliation or of the health check of the
+	// Mattermost instance, kept until it is stable again
+	// +optional
+	LastError *LastErrorStatus `json:"lastError,omitempty"`
+	// The generation of the ClusterInstallation observed by the operator
+	// +optional
+	ObservedGeneration int64 `json:"observedGeneration,omitempty"`
 	// The name of the blue deployment in BlueGreen
 	// +optional
 	BlueName string `json:"blueName,omitempty"`
@@ -296,11 +306,49 @@ type ClusterInstallationStatus struct {
 	// The status of migration to Mattermost CR.
 	// +optional
 	Migration *MigrationStatus `json:"migration,omitempty"`
+
+	// Conditions report the Ready, Progressing and Error conditions of the
+	// Mattermost instance
+	// +optional
+	// +listType=map
+	// +listMapKey=type
+	Conditions []metav1.Condition `json:"conditions,omitempty"`
+}
+
+// LastErrorStatus defines the last error of a ClusterInstallation.
+type LastErrorStatus struct {
+	// The reason of the condition reporting the error
+	// +optional
+	Reason string `json:"reason,omitempty"`
+	// The message of the error
+	// +optional
+	Message string `json:"message,omitempty"`
+	// The time when the error first occurred
+	// +optional
+	Time *metav1.Time `json:"time,omitempty"`
 }
 
+const (
+	// ReadyCondition is the type of the condition reporting that all the
+	// Mattermost pods run the requested image and are ready, the condition
+	// of the stable state.
+	ReadyCondition = "Ready"
+	// ProgressingCondition is the type of the condition reporting that the
+	// changes of the ClusterInstallation are being rolled out, the condition
+	// of the reconciling state.
+	ProgressingCondition = "Progressing"
+	// ErrorCondition is the type of the condition reporting that the last
+	// reconciliation of the ClusterInstallation failed.
+	ErrorCondition = "Error"
+)
+
 type MigrationStatus struct {
 	Status string `json:"status,omitempty"`
 	Error  string `json:"error,omitempty"`
+	// RolledBack is set when the migrated Mattermost failed its verification
+	// and the migration was rolled back, Error holding the reason.
+	// +optional
+	RolledBack bool `json:"rolledBack,omitempty"`
 }
 
 // +genclient
@@ -314,6 +362,12 @@ type MigrationStatus struct {
 // +kubebuilder:printcolumn:priority=0,name="Image",type=string,JSONPath=".status.image",description="Image of Mattermost"
 // +kubebuilder:printcolumn:priority=0,name="Version",type=string,JSONPath=".status.version",description="Version of Mattermost"
 // +kubebuilder:printcolumn:priority=0,name="Endpoint",type=string,JSONPath=".status.endpoint",description="Endpoint"
+// +kubebuilder:printcolumn:priority=0,name="Ready",type=integer,JSONPath=".status.updatedReplicas",description="Ready pods running the desired image"
+// +kubebuilder:printcolumn:priority=0,name="Desired",type=integer,JSONPath=".status.desiredReplicas",description="Desired pods"
+// +kubebuilder:printcolumn:priority=0,name="Last Error",type=string,JSONPath=".status.lastError.reason",description="Reason of the last error"
+// +kubebuilder:printcolumn:priority=0,name="Error Age",type=date,JSONPath=".status.lastError.time",description="Time of the last error"
+// +kubebuilder:printcolumn:priority=1,name="Error Message",type=string,JSONPath=".status.lastError.message",description="Message of the last error"
+// +kubebuilder:printcolumn:priority=0,name="Age",type=date,JSONPath=".metadata.creationTimestamp"
 type ClusterInstallation struct {
 	metav1.TypeMeta   `json:",inline"`
 	metav1.ObjectMeta `json:"metadata,omitempty"`
@@ -9,6 +9,7 @@ package v1alpha1
 
 import (
 	"k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	runtime "k8s.io/apimachinery/pkg/runtime"
 )
 
@@ -210,11 +211,23 @@ func (in *ClusterInstallationSpec) DeepCopy() *ClusterInstallationSpec {
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *ClusterInstallationStatus) DeepCopyInto(out *ClusterInstallationStatus) {
 	*out = *in
+	if in.LastError != nil {
+		in, out := &in.LastError, &out.LastError
+		*out = new(LastErrorStatus)
+		(*in).DeepCopyInto(*out)
+	}
 	if in.Migration != nil {
 		in, out := &in.Migration, &out.Migration
 		*out = new(MigrationStatus)
 		**out = **in
 	}
+	if in.Conditions != nil {
+		in, out := &in.Conditions, &out.Conditions
+		*out = make([]metav1.Condition, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
 }
 
 // DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ClusterInstallationStatus.
@@ -274,6 +287,25 @@ func (in *ElasticSearch) DeepCopy() *ElasticSearch {
 	return out
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *LastErrorStatus) DeepCopyInto(out *LastErrorStatus) {
+	*out = *in
+	if in.Time != nil {
+		in, out := &in.Time, &out.Time
+		*out = (*in).DeepCopy()
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new LastErrorStatus.
+func (in *LastErrorStatus) DeepCopy() *LastErrorStatus {
+	if in == nil {
+		return nil
+	}
+	out := new(LastErrorStatus)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *MattermostRestoreDB) DeepCopyInto(out *MattermostRestoreDB) {
 	*out = *in
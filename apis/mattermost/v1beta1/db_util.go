@@ -22,6 +22,18 @@ func (db *Database) IsExternal() bool {
 	return db.External != nil && db.External.Secret != ""
 }
 
+// Backend returns the backend of the Database, 'external' or the type of the
+// operator managed database.
+func (db *Database) Backend() string {
+	if db.IsExternal() {
+		return "external"
+	}
+	if db.OperatorManaged == nil || db.OperatorManaged.Type == "" {
+		return DefaultMattermostDatabaseType
+	}
+	return db.OperatorManaged.Type
+}
+
 func (db *Database) ensureDefault() {
 	if db.OperatorManaged == nil {
 		db.OperatorManaged = &OperatorManagedDatabase{}
@@ -25,6 +25,15 @@ func (fs *FileStore) IsExternal() bool {
 	return fs.External != nil && fs.External.URL != ""
 }
 
+// Backend returns the backend of the FileStore, 'external' or
+// 'operatorManaged'.
+func (fs *FileStore) Backend() string {
+	if fs.IsExternal() {
+		return "external"
+	}
+	return "operatorManaged"
+}
+
 func (fs *FileStore) ensureDefault() {
 	if fs.OperatorManaged == nil {
 		fs.OperatorManaged = &OperatorManagedMinio{}
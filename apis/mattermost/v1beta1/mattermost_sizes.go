@@ -33,6 +33,23 @@ func (mm *Mattermost) SetReplicasAndResourcesFromSize() error {
 	return nil
 }
 
+// SetReplicasAndResourcesFromAutoSize overrides the replicas and resources
+// with the ones of the size chosen by auto-sizing, kept in the status. They
+// are only applied in memory, the spec keeps the values set by the user.
+func (mm *Mattermost) SetReplicasAndResourcesFromAutoSize(status *AutoSizingStatus) error {
+	if status == nil || status.Size == "" {
+		return nil
+	}
+
+	size, err := mattermostv1alpha1.GetClusterSize(status.Size)
+	if err != nil {
+		return err
+	}
+	mm.overrideReplicasAndResourcesFromSize(size)
+
+	return nil
+}
+
 func (mm *Mattermost) setDefaultReplicasAndResources() {
 	mm.Spec.Size = ""
 
@@ -45,6 +62,7 @@ func (mm *Mattermost) setDefaultReplicasAndResources() {
 
 	mm.Spec.FileStore.SetDefaultReplicasAndResources()
 	mm.Spec.Database.SetDefaultReplicasAndResources()
+	mm.limitReplicasToEdition()
 }
 
 func (mm *Mattermost) overrideReplicasAndResourcesFromSize(size mattermostv1alpha1.ClusterInstallationSize) {
@@ -54,4 +72,13 @@ func (mm *Mattermost) overrideReplicasAndResourcesFromSize(size mattermostv1alph
 	mm.Spec.Scheduling.Resources = size.App.Resources
 	mm.Spec.FileStore.OverrideReplicasAndResourcesFromSize(size)
 	mm.Spec.Database.OverrideReplicasAndResourcesFromSize(size)
+	mm.limitReplicasToEdition()
+}
+
+// limitReplicasToEdition runs a single replica of the Team Edition, which
+// does not support clustering.
+func (mm *Mattermost) limitReplicasToEdition() {
+	if mm.TeamEdition() && mm.Spec.Replicas != nil && *mm.Spec.Replicas > 1 {
+		mm.Spec.Replicas = utils.NewInt32(1)
+	}
 }
@@ -157,6 +157,139 @@ func TestMattermost(t *testing.T) {
 		assert.Contains(t, mm.GetImageName(), mm.Spec.Version)
 		assert.Equal(t, mm.GetImageName(), fmt.Sprintf("%s@%s", mm.Spec.Image, mm.Spec.Version))
 	})
+
+	t.Run("pinned digest", func(t *testing.T) {
+		digest := "sha256:dd15a51ac7dafd213744d1ef23394e7532f71a90f477c969b94600e46da5a0cf"
+		pinned := mm.DeepCopy()
+		pinned.Spec.Version = "5.37.1"
+		pinned.Spec.ImageVerification = &ImageVerification{Enabled: true}
+		pinned.Status.ImageVerification = &ImageVerificationStatus{
+			Image:  fmt.Sprintf("%s:5.37.1", mm.Spec.Image),
+			Digest: digest,
+			State:  ImageVerificationPinned,
+		}
+		assert.Equal(t, fmt.Sprintf("%s@%s", mm.Spec.Image, digest), pinned.GetImageName())
+
+		pinned.Status.ImageVerification.State = ImageVerificationFailed
+		assert.Equal(t, fmt.Sprintf("%s:5.37.1", mm.Spec.Image), pinned.GetImageName())
+
+		pinned.Status.ImageVerification.State = ImageVerificationVerified
+		pinned.Spec.Version = "5.38.0"
+		assert.Equal(t, fmt.Sprintf("%s:5.38.0", mm.Spec.Image), pinned.GetImageName())
+	})
+
+	t.Run("image registry", func(t *testing.T) {
+		mirrored := mm.DeepCopy()
+		mirrored.Spec.Version = "5.37.1"
+		mirrored.Spec.ImageRegistry = "registry.example.com/mirror/"
+		assert.Equal(t, fmt.Sprintf("registry.example.com/mirror/%s:5.37.1", mm.Spec.Image), mirrored.GetImageName())
+	})
+}
+
+func TestMattermost_ImageVariant(t *testing.T) {
+	newMattermost := func(variant ImageVariant) *Mattermost {
+		return &Mattermost{Spec: MattermostSpec{
+			IngressName:  "test-mm.com",
+			Version:      "5.37.1",
+			ImageVariant: variant,
+		}}
+	}
+
+	t.Run("default", func(t *testing.T) {
+		mm := newMattermost("")
+		require.NoError(t, mm.SetDefaults())
+		assert.Equal(t, DefaultMattermostImage+":5.37.1", mm.GetImageName())
+		assert.Equal(t, []string{"mattermost"}, mm.GetMattermostCommand())
+	})
+
+	t.Run("fips", func(t *testing.T) {
+		mm := newMattermost(ImageVariantFIPS)
+		require.NoError(t, mm.SetDefaults())
+		assert.Equal(t, DefaultMattermostFIPSImage+":5.37.1", mm.GetImageName())
+	})
+
+	t.Run("ubi", func(t *testing.T) {
+		mm := newMattermost(ImageVariantUBI)
+		require.NoError(t, mm.SetDefaults())
+		assert.Equal(t, DefaultMattermostImage+":5.37.1-ubi", mm.GetImageName())
+		assert.Equal(t, []string{"/opt/mattermost/bin/mattermost"}, mm.GetMattermostCommand())
+
+		mm.Spec.Version = "5.38.0-ubi"
+		assert.Equal(t, DefaultMattermostImage+":5.38.0-ubi", mm.GetImageName())
+		assert.Equal(t, DefaultMattermostImage+":5.36.0-ubi", mm.GetAppDeploymentImageName(AppDeployment{Image: DefaultMattermostImage, Version: "5.36.0"}))
+	})
+
+	t.Run("switch variant of defaulted image", func(t *testing.T) {
+		mm := newMattermost("")
+		require.NoError(t, mm.SetDefaults())
+
+		mm.Spec.ImageVariant = ImageVariantFIPS
+		require.NoError(t, mm.SetDefaults())
+		assert.Equal(t, DefaultMattermostFIPSImage, mm.Spec.Image)
+
+		mm.Spec.ImageVariant = ""
+		require.NoError(t, mm.SetDefaults())
+		assert.Equal(t, DefaultMattermostImage, mm.Spec.Image)
+	})
+
+	t.Run("custom image", func(t *testing.T) {
+		mm := newMattermost(ImageVariantFIPS)
+		mm.Spec.Image = "registry.example.com/mattermost"
+		require.NoError(t, mm.SetDefaults())
+		assert.Equal(t, "registry.example.com/mattermost:5.37.1", mm.GetImageName())
+	})
+}
+
+func TestImageWithRegistry(t *testing.T) {
+	for _, tc := range []struct {
+		image    string
+		registry string
+		expected string
+	}{
+		{image: "postgres:13", registry: "", expected: "postgres:13"},
+		{image: "postgres:13", registry: "registry.example.com", expected: "registry.example.com/postgres:13"},
+		{image: "minio/mc:latest", registry: "registry.example.com/mirror", expected: "registry.example.com/mirror/minio/mc:latest"},
+		{image: "quay.io/minio/mc@sha256:3c37", registry: "registry.example.com/mirror", expected: "registry.example.com/mirror/minio/mc@sha256:3c37"},
+		{image: "localhost:5000/minio/mc", registry: "registry.example.com", expected: "registry.example.com/minio/mc"},
+		{image: "registry.example.com/mirror/minio/mc", registry: "registry.example.com/mirror", expected: "registry.example.com/mirror/minio/mc"},
+	} {
+		t.Run(tc.image, func(t *testing.T) {
+			assert.Equal(t, tc.expected, ImageWithRegistry(tc.image, tc.registry))
+		})
+	}
+}
+
+func TestMattermost_UtilityImages(t *testing.T) {
+	mm := &Mattermost{}
+	assert.Equal(t, DefaultCurlImage, mm.Spec.UtilityImages.GetCurl())
+	assert.Equal(t, DefaultPostgresImage, mm.Spec.UtilityImages.GetPostgres())
+	assert.Equal(t, DefaultMinioClientImage, mm.Spec.UtilityImages.GetMinioClient())
+
+	mm.SetUtilityImageDefaults(UtilityImages{})
+	assert.Nil(t, mm.Spec.UtilityImages)
+
+	mm.Spec.UtilityImages = &UtilityImages{Curl: "registry.example.com/curl:8.4.0"}
+	mm.SetUtilityImageDefaults(UtilityImages{
+		Curl:     "mirror.example.com/curl:latest",
+		Postgres: "mirror.example.com/postgres:13",
+	})
+	assert.Equal(t, "registry.example.com/curl:8.4.0", mm.Spec.UtilityImages.GetCurl())
+	assert.Equal(t, "mirror.example.com/postgres:13", mm.Spec.UtilityImages.GetPostgres())
+	assert.Equal(t, DefaultMinioClientImage, mm.Spec.UtilityImages.GetMinioClient())
+}
+
+func TestMattermost_GetImagePullSecrets(t *testing.T) {
+	mm := &Mattermost{
+		ObjectMeta: metav1.ObjectMeta{Name: "foo"},
+		Spec: MattermostSpec{
+			ImagePullSecrets: []corev1.LocalObjectReference{{Name: "registry"}},
+		},
+	}
+	assert.Equal(t, []corev1.LocalObjectReference{{Name: "registry"}}, mm.GetImagePullSecrets())
+
+	mm.Spec.ECRCredentials = &ECRCredentials{Enabled: true}
+	assert.Equal(t, []corev1.LocalObjectReference{{Name: "registry"}, {Name: "foo-ecr-credentials"}}, mm.GetImagePullSecrets())
+	assert.Len(t, mm.Spec.ImagePullSecrets, 1)
 }
 
 func TestOtherUtils(t *testing.T) {
@@ -174,7 +307,7 @@ func TestOtherUtils(t *testing.T) {
 	}
 
 	t.Run("get image name", func(t *testing.T) {
-		assert.Equal(t, "mattermost/mattermost-enterprise-edition:5.19.1", mm.GetImageName())
+		assert.Equal(t, "mattermost/mattermost-enterprise-edition:5.37.1", mm.GetImageName())
 
 		mm.Spec.Version = "sha256:3c37"
 
@@ -209,3 +342,145 @@ func TestOtherUtils(t *testing.T) {
 		assert.Equal(t, MattermostAppContainerName, container.Name)
 	})
 }
+
+func TestMattermost_Edition(t *testing.T) {
+	newMattermost := func() *Mattermost {
+		return &Mattermost{Spec: MattermostSpec{
+			IngressName: "test-mm.com",
+			Version:     "5.37.1",
+			Edition:     EditionTeam,
+		}}
+	}
+
+	t.Run("team", func(t *testing.T) {
+		mm := newMattermost()
+		require.NoError(t, mm.SetDefaults())
+		assert.Equal(t, DefaultMattermostTeamImage+":5.37.1", mm.GetImageName())
+
+		require.NoError(t, mm.SetReplicasAndResourcesFromSize())
+		assert.Equal(t, int32(1), *mm.Spec.Replicas)
+
+		mm.Spec.Size = "5000users"
+		require.NoError(t, mm.SetReplicasAndResourcesFromSize())
+		assert.Equal(t, int32(1), *mm.Spec.Replicas)
+	})
+
+	t.Run("team ubi", func(t *testing.T) {
+		mm := newMattermost()
+		mm.Spec.ImageVariant = ImageVariantUBI
+		require.NoError(t, mm.SetDefaults())
+		assert.Equal(t, DefaultMattermostTeamImage+":5.37.1-ubi", mm.GetImageName())
+	})
+
+	t.Run("switch to enterprise", func(t *testing.T) {
+		mm := newMattermost()
+		require.NoError(t, mm.SetDefaults())
+
+		mm.Spec.Edition = EditionEnterprise
+		require.NoError(t, mm.SetDefaults())
+		assert.Equal(t, DefaultMattermostImage, mm.Spec.Image)
+	})
+
+	for _, tc := range []struct {
+		name   string
+		modify func(mm *Mattermost)
+	}{
+		{name: "fips", modify: func(mm *Mattermost) { mm.Spec.ImageVariant = ImageVariantFIPS }},
+		{name: "license", modify: func(mm *Mattermost) { mm.Spec.LicenseSecret = "license" }},
+		{name: "replicas", modify: func(mm *Mattermost) { mm.Spec.Replicas = utils.NewInt32(2) }},
+	} {
+		t.Run("team with "+tc.name, func(t *testing.T) {
+			mm := newMattermost()
+			tc.modify(mm)
+			require.Error(t, mm.SetDefaults())
+		})
+	}
+}
+
+func TestMattermost_Clustering(t *testing.T) {
+	newMattermost := func() *Mattermost {
+		return &Mattermost{Spec: MattermostSpec{
+			IngressName:   "test-mm.com",
+			Replicas:      utils.NewInt32(2),
+			LicenseSecret: "license",
+		}}
+	}
+
+	t.Run("enabled", func(t *testing.T) {
+		mm := newMattermost()
+		require.NoError(t, mm.SetDefaults())
+		assert.True(t, mm.ClusteringEnabled())
+	})
+
+	t.Run("single replica", func(t *testing.T) {
+		mm := newMattermost()
+		mm.Spec.Replicas = utils.NewInt32(1)
+		assert.False(t, mm.ClusteringEnabled())
+	})
+
+	t.Run("no license", func(t *testing.T) {
+		mm := newMattermost()
+		mm.Spec.LicenseSecret = ""
+		assert.False(t, mm.ClusteringSupported())
+		assert.False(t, mm.ClusteringEnabled())
+	})
+
+	t.Run("clustering disabled in env", func(t *testing.T) {
+		mm := newMattermost()
+		mm.Spec.MattermostEnv = []corev1.EnvVar{{Name: "MM_CLUSTERSETTINGS_ENABLE", Value: "false"}}
+		require.Error(t, mm.SetDefaults())
+
+		mm.Spec.Replicas = utils.NewInt32(1)
+		require.NoError(t, mm.SetDefaults())
+	})
+}
+
+func TestMattermost_Standby(t *testing.T) {
+	newMattermost := func() *Mattermost {
+		return &Mattermost{Spec: MattermostSpec{
+			IngressName: "test-mm.com",
+			Database:    Database{External: &ExternalDatabase{Secret: "db"}},
+			FileStore:   FileStore{External: &ExternalFileStore{URL: "s3.amazonaws.com", Bucket: "mm", Secret: "s3"}},
+			Standby:     &Standby{},
+		}}
+	}
+
+	t.Run("standby", func(t *testing.T) {
+		mm := newMattermost()
+		require.NoError(t, mm.SetDefaults())
+		assert.True(t, mm.StandbyEnabled())
+
+		mm.Spec.Standby.Promoted = true
+		assert.False(t, mm.StandbyEnabled())
+	})
+
+	t.Run("operator managed database", func(t *testing.T) {
+		mm := newMattermost()
+		mm.Spec.Database = Database{OperatorManaged: &OperatorManagedDatabase{Type: "mysql"}}
+		require.Error(t, mm.SetDefaults())
+	})
+
+	t.Run("blue green", func(t *testing.T) {
+		mm := newMattermost()
+		mm.Spec.BlueGreen = &BlueGreen{Enabled: true}
+		require.Error(t, mm.SetDefaults())
+	})
+}
+
+func TestMattermost_UpdateCheckDisabled(t *testing.T) {
+	mm := &Mattermost{}
+	assert.Nil(t, mm.GetUpdateCheckJob())
+	assert.False(t, mm.UpdateCheckDisabled())
+
+	mm.Spec.Jobs = &Jobs{UpdateCheck: &UpdateCheckJob{}}
+	assert.False(t, mm.UpdateCheckDisabled())
+
+	mm.Spec.Jobs.UpdateCheck.Disabled = true
+	assert.True(t, mm.UpdateCheckDisabled())
+
+	t.Run("in-place update", func(t *testing.T) {
+		mm := &Mattermost{Spec: MattermostSpec{UpdatePolicy: &UpdatePolicy{SkipVersionCheckJob: true}}}
+		assert.True(t, mm.InPlaceUpdateEnabled())
+		assert.True(t, mm.UpdateCheckDisabled())
+	})
+}
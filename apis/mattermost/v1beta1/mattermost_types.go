@@ -33,13 +33,58 @@ type MattermostSpec struct {
 	// values regardless if set by Size or manually.
 	// +optional
 	Size string `json:"size,omitempty"`
+	// AutoSizing defines the automatic sizing of the Mattermost from its
+	// active users. The computed size is applied as Size, overriding the
+	// replicas and resources, and reported in the status.
+	// +optional
+	AutoSizing *AutoSizing `json:"autoSizing,omitempty"`
+	// TemplateRef defines the name of the MattermostTemplate whose values
+	// are merged under the ones of the Mattermost. The values set by the
+	// Mattermost take precedence.
+	// +optional
+	TemplateRef string `json:"templateRef,omitempty"`
 
 	// Image defines the Mattermost Docker image.
 	Image string `json:"image,omitempty"`
 	// Version defines the Mattermost Docker image version.
+	// +kubebuilder:validation:MaxLength=128
 	Version string `json:"version,omitempty"`
+	// Edition defines the Mattermost edition selecting the default image:
+	// 'team' for Mattermost Team Edition, 'enterprise' for Mattermost
+	// Enterprise Edition. The Team Edition does not support licenses and
+	// clustering, it runs a single replica. Defaults to enterprise.
+	// +kubebuilder:validation:Enum=team;enterprise
+	// +optional
+	Edition Edition `json:"edition,omitempty"`
+	// ImageVariant defines the variant of the Mattermost image: 'fips' for
+	// the FIPS 140-2 compliant image, 'ubi' for the image based on the Red Hat
+	// Universal Base Image. The variant selects the default image, the tags
+	// matching Version and the entrypoint, so that the default version and
+	// automatic upgrades keep working.
+	// +kubebuilder:validation:Enum=fips;ubi
+	// +optional
+	ImageVariant ImageVariant `json:"imageVariant,omitempty"`
+	// ImageRegistry defines the registry, optionally followed by a path,
+	// replacing the registry of all images created by the Operator, ie
+	// registry.example.com/mirror pulls postgres:13 from
+	// registry.example.com/mirror/postgres:13. Defaults to the image
+	// registry configured for the Operator.
+	// +optional
+	ImageRegistry string `json:"imageRegistry,omitempty"`
+	// UtilityImages overrides the images of the utility containers created
+	// by the Operator, such as the init containers waiting for the database
+	// and MinIO. Defaults to the utility images configured for the Operator.
+	// +optional
+	UtilityImages *UtilityImages `json:"utilityImages,omitempty"`
+	// UtilityResources defines the resources of the init containers and of
+	// the job containers created by the Operator not running Mattermost.
+	// Defaults to requests of 50m CPU and 64Mi memory, and limits of 500m
+	// CPU and 512Mi memory.
+	// +optional
+	UtilityResources *v1.ResourceRequirements `json:"utilityResources,omitempty"`
 	// Replicas defines the number of replicas to use for the Mattermost app
-	// servers.
+	// servers. It is the target of the scale subresource, therefore can be
+	// set with kubectl scale or a HorizontalPodAutoscaler.
 	Replicas *int32 `json:"replicas,omitempty"`
 	// Optional environment variables to set in the Mattermost application pods.
 	// +optional
@@ -47,6 +92,12 @@ type MattermostSpec struct {
 	// LicenseSecret is the name of the secret containing a Mattermost license.
 	// +optional
 	LicenseSecret string `json:"licenseSecret,omitempty"`
+	// ClusterReadinessGate holds the pods of clustered Mattermost app servers
+	// unready until they joined the cluster, so that rollouts do not shift
+	// traffic to app servers outside of it. Applies only with clustering
+	// enabled, with a license and more than one replica.
+	// +optional
+	ClusterReadinessGate *ClusterReadinessGate `json:"clusterReadinessGate,omitempty"`
 	// IngressName defines the host to be used when creating the ingress rules.
 	// Deprecated: Use Spec.Ingress.Host instead.
 	// +optional
@@ -93,6 +144,109 @@ type MattermostSpec struct {
 	Database      Database      `json:"database,omitempty"`
 	FileStore     FileStore     `json:"fileStore,omitempty"`
 	ElasticSearch ElasticSearch `json:"elasticSearch,omitempty"`
+	// Proxy defines the outbound HTTP proxy of Mattermost and of the jobs
+	// created by the Operator.
+	// +optional
+	Proxy *Proxy `json:"proxy,omitempty"`
+	// TrustedCABundle defines the ConfigMap with the CA certificates trusted
+	// by Mattermost and the jobs created by the Operator, ie for identity
+	// providers, S3 endpoints or SMTP servers with certificates issued by
+	// internal CAs.
+	// +optional
+	TrustedCABundle *TrustedCABundle `json:"trustedCABundle,omitempty"`
+	// DeletionPolicy defines whether the data of the Mattermost is deleted
+	// with it: 'Delete', the default, deletes the operator managed database
+	// and file store along with their Secrets and volumes, 'Orphan' keeps
+	// them once the Mattermost is deleted. The other resources of the
+	// Mattermost are deleted with it.
+	// +kubebuilder:validation:Enum=Delete;Orphan
+	// +optional
+	DeletionPolicy DeletionPolicy `json:"deletionPolicy,omitempty"`
+
+	// UpgradeSnapshots defines the snapshots of the operator managed database
+	// and file store volumes taken before upgrading Mattermost to a new version.
+	// +optional
+	UpgradeSnapshots *UpgradeSnapshots `json:"upgradeSnapshots,omitempty"`
+	// UpgradeRollback defines the automatic rollback of Mattermost upgrades
+	// whose pods do not pass the health checks.
+	// +optional
+	UpgradeRollback *UpgradeRollback `json:"upgradeRollback,omitempty"`
+	// SelfHealing defines the automatic rolling restart of Mattermost when
+	// its application health checks fail repeatedly.
+	// +optional
+	SelfHealing *SelfHealing `json:"selfHealing,omitempty"`
+	// Notifications defines the webhook notified of the upgrades, failures
+	// and health degradation of the Mattermost, overriding the webhook of
+	// the operator.
+	// +optional
+	Notifications *Notifications `json:"notifications,omitempty"`
+	// Monitoring defines the ServiceMonitor and the PodMonitors generated
+	// for the metrics endpoints of Mattermost and of its operator managed
+	// database and file store, so that the Prometheus Operator scrapes them.
+	// +optional
+	Monitoring *Monitoring `json:"monitoring,omitempty"`
+	// RollbackTo restores the image, version, environment and ingress
+	// settings of a revision of the revision history, listed in the
+	// '<name>-revisions' Secret, and is cleared once restored.
+	// +kubebuilder:validation:Minimum=1
+	// +optional
+	RollbackTo *int64 `json:"rollbackTo,omitempty"`
+	// VeleroBackups defines the Velero backup hooks and annotations added to
+	// the operator managed database and file store, so that cluster-level
+	// Velero backups of the installation are consistent.
+	// +optional
+	VeleroBackups *VeleroBackups `json:"veleroBackups,omitempty"`
+	// Jobs defines the one-off jobs run for the Mattermost installation.
+	// +optional
+	Jobs *Jobs `json:"jobs,omitempty"`
+	// BlueGreen defines the blue and green deployments of Mattermost, which
+	// allow a new version to be staged behind a test hostname before the
+	// production traffic is switched to it.
+	// +optional
+	BlueGreen *BlueGreen `json:"blueGreen,omitempty"`
+	// Canary defines a canary deployment of Mattermost receiving a share of
+	// the traffic of the Mattermost ingress, so that a new version can be
+	// gradually exposed to a fraction of the users.
+	// +optional
+	Canary *Canary `json:"canary,omitempty"`
+	// UpdatePolicy defines how changes to the Mattermost deployment are
+	// rolled out.
+	// +optional
+	UpdatePolicy *UpdatePolicy `json:"updatePolicy,omitempty"`
+	// ReconcilePolicy defines how the Operator corrects the drift of the
+	// resources it generates for the Mattermost.
+	// +optional
+	ReconcilePolicy *ReconcilePolicy `json:"reconcilePolicy,omitempty"`
+	// Relocation moves the Mattermost to another namespace: the Secrets and
+	// ConfigMaps it references are copied there, a Mattermost with the same
+	// spec is created there, and once it is stable the ingress is switched
+	// to it and this Mattermost is deleted. Only the Mattermosts with an
+	// external database and file store can be relocated.
+	// +optional
+	Relocation *Relocation `json:"relocation,omitempty"`
+	// Standby keeps the Mattermost as the idle copy of an installation of
+	// another cluster, for disaster recovery: its deployment is scaled to
+	// zero and its ingress is not created until it is promoted. It requires
+	// an external database and file store, shared with the active
+	// installation or replicated from its ones.
+	// +optional
+	Standby *Standby `json:"standby,omitempty"`
+	// MaintenanceMode flips the Mattermost into a safe state, ie during the
+	// maintenance or the restore of its database: an announcement banner is
+	// shown to the users, the database sessions can be made read-only and
+	// the ingress can redirect the requests to a maintenance page.
+	// +optional
+	MaintenanceMode *MaintenanceMode `json:"maintenanceMode,omitempty"`
+	// ImageVerification defines the resolution of the Mattermost image tag
+	// to the digest the deployment is pinned to, and the verification of the
+	// image signature before it is rolled out.
+	// +optional
+	ImageVerification *ImageVerification `json:"imageVerification,omitempty"`
+	// ECRCredentials defines the image pull Secret of an Amazon ECR registry
+	// refreshed by the Operator, for clusters where the kubelets cannot
+	// authenticate to ECR.
+	// +optional
+	ECRCredentials *ECRCredentials `json:"ecrCredentials,omitempty"`
 
 	// Advanced settings - it is recommended to leave the default configuration
 	// for below settings, unless a very specific use case arises.
@@ -128,6 +282,39 @@ type Ingress struct {
 	// If empty TLS will not be configured.
 	// +optional
 	TLSSecret string `json:"tlsSecret,omitempty"`
+	// Path defines the path of the Ingress rule routing the requests to
+	// Mattermost. Defaults to "/".
+	// +optional
+	Path string `json:"path,omitempty"`
+	// PathType defines how the path of the Ingress rule is matched.
+	// Defaults to ImplementationSpecific.
+	// +kubebuilder:validation:Enum=Prefix;Exact;ImplementationSpecific
+	// +optional
+	PathType IngressPathType `json:"pathType,omitempty"`
+	// ExtraPaths defines additional paths of the Ingress rule, added after
+	// the Mattermost path, for the ingress controllers which need
+	// specific path semantics.
+	// +optional
+	ExtraPaths []IngressPath `json:"extraPaths,omitempty"`
+}
+
+// IngressPath defines an additional path of the Mattermost Ingress rule.
+type IngressPath struct {
+	// Path defines the path matched by the Ingress rule. It must start with "/".
+	Path string `json:"path"`
+	// PathType defines how the path is matched. Defaults to the path type of
+	// the Ingress.
+	// +kubebuilder:validation:Enum=Prefix;Exact;ImplementationSpecific
+	// +optional
+	PathType IngressPathType `json:"pathType,omitempty"`
+	// Service defines the Service the requests are routed to. Defaults to
+	// the Mattermost Service.
+	// +optional
+	Service string `json:"service,omitempty"`
+	// Port defines the port of the Service the requests are routed to.
+	// Defaults to the Mattermost port 8065.
+	// +optional
+	Port int32 `json:"port,omitempty"`
 }
 
 // Scheduling defines the configuration related to scheduling of the Mattermost pods
@@ -180,6 +367,11 @@ type Database struct {
 	// Can be used to define custom init containers specified in `spec.PodExtensions.InitContainers`.
 	// +optional
 	DisableReadinessCheck bool `json:"disableReadinessCheck,omitempty"`
+	// Set to true to acknowledge changing the database backend of an
+	// existing installation, once its data was migrated to the new database,
+	// ie with a MattermostRestore. The Operator does not migrate the data.
+	// +optional
+	AllowMigration bool `json:"allowMigration,omitempty"`
 }
 
 // ExternalDatabase defines the configuration of the external database that should be used by Mattermost.
@@ -202,6 +394,10 @@ type OperatorManagedDatabase struct {
 	// +optional
 	// +kubebuilder:validation:Pattern=^([+-]?[0-9.]+)([eEinumkKMGTP]*[-+]?[0-9]*)$
 	StorageSize string `json:"storageSize,omitempty"`
+	// Defines the StorageClass of the database volumes. The cluster default
+	// StorageClass is used if not set. Changing it does not affect existing volumes.
+	// +optional
+	StorageClassName string `json:"storageClassName,omitempty"`
 	// Defines the number of database replicas.
 	// For redundancy use at least 2 replicas.
 	// Setting this will override the number of replicas set by 'Size'.
@@ -239,6 +435,21 @@ type FileStore struct {
 	// Defines the configuration of file store managed by Kubernetes operator.
 	// +optional
 	OperatorManaged *OperatorManagedMinio `json:"operatorManaged,omitempty"`
+	// Defines the lifecycle rules applied to the file store bucket.
+	// The rules replace any lifecycle configuration already set on the bucket,
+	// removing them leaves the bucket lifecycle unchanged.
+	// For an external file store the credentials from its secret must allow
+	// managing the bucket lifecycle.
+	// +optional
+	Lifecycle *FileStoreLifecycle `json:"lifecycle,omitempty"`
+	// Defines the external file store the files should be migrated to.
+	// The operator copies all objects to the new file store, verifies that
+	// none is missing or differs and then switches Mattermost to it by
+	// moving this configuration to 'External'. Files uploaded while the
+	// migration runs might not be copied, so it should be done in a
+	// maintenance window. The previous file store is left untouched.
+	// +optional
+	MigrateTo *ExternalFileStore `json:"migrateTo,omitempty"`
 }
 
 // ExternalFileStore defines the configuration of the external file store that should be used by Mattermost.
@@ -250,6 +461,9 @@ type ExternalFileStore struct {
 	// Optionally enter the name of already existing secret.
 	// Secret should have two values: "accesskey" and "secretkey".
 	Secret string `json:"secret,omitempty"`
+	// Defines the server-side encryption applied to objects in the bucket.
+	// +optional
+	ServerSideEncryption *FileStoreEncryption `json:"serverSideEncryption,omitempty"`
 }
 
 // OperatorManagedMinio defines the configuration of a Minio file store managed by Kubernetes Operator.
@@ -258,6 +472,10 @@ type OperatorManagedMinio struct {
 	// +optional
 	// +kubebuilder:validation:Pattern=^([+-]?[0-9.]+)([eEinumkKMGTP]*[-+]?[0-9]*)$
 	StorageSize string `json:"storageSize,omitempty"`
+	// Defines the StorageClass of the Minio volumes. The cluster default
+	// StorageClass is used if not set. Changing it does not affect existing volumes.
+	// +optional
+	StorageClassName string `json:"storageClassName,omitempty"`
 	// Defines the number of Minio replicas.
 	// Supply 1 to run Minio in standalone mode with no redundancy.
 	// Supply 4 or more to run Minio in distributed mode.
@@ -271,6 +489,708 @@ type OperatorManagedMinio struct {
 	Resources v1.ResourceRequirements `json:"resources,omitempty"`
 }
 
+// FileStoreEncryptionMode is the type of server-side encryption of the file store.
+type FileStoreEncryptionMode string
+
+const (
+	// SSES3 encrypts objects with keys managed by the storage provider.
+	SSES3 FileStoreEncryptionMode = "SSE-S3"
+	// SSEKMS encrypts objects with a key stored in a key management service.
+	SSEKMS FileStoreEncryptionMode = "SSE-KMS"
+)
+
+// FileStoreEncryption defines the server-side encryption of the file store bucket.
+type FileStoreEncryption struct {
+	// Defines the encryption mode, either SSE-S3 or SSE-KMS.
+	// +kubebuilder:validation:Enum=SSE-S3;SSE-KMS
+	Mode FileStoreEncryptionMode `json:"mode"`
+	// Defines the ID of the KMS key used with SSE-KMS. The key is set as
+	// the default encryption key of the bucket.
+	// +optional
+	KMSKeyID string `json:"kmsKeyId,omitempty"`
+}
+
+// FileStoreLifecycle defines the lifecycle configuration of the file store bucket.
+type FileStoreLifecycle struct {
+	// Defines the lifecycle rules of the bucket.
+	// +kubebuilder:validation:MinItems=1
+	Rules []FileStoreLifecycleRule `json:"rules"`
+}
+
+// FileStoreLifecycleRule defines a lifecycle rule applied to objects of the file store bucket.
+type FileStoreLifecycleRule struct {
+	// Defines the unique identifier of the rule.
+	// +kubebuilder:validation:Pattern=^[A-Za-z0-9_.-]+$
+	ID string `json:"id"`
+	// Defines the prefix of the objects the rule applies to, ie "temp/".
+	// The rule applies to all objects of the bucket if not set.
+	// +optional
+	Prefix string `json:"prefix,omitempty"`
+	// Defines the number of days after creation when objects are deleted.
+	// +optional
+	// +kubebuilder:validation:Minimum=1
+	ExpirationDays *int32 `json:"expirationDays,omitempty"`
+	// Defines the number of days after creation when objects are transitioned
+	// to the TransitionStorageClass.
+	// +optional
+	// +kubebuilder:validation:Minimum=0
+	TransitionDays *int32 `json:"transitionDays,omitempty"`
+	// Defines the storage class objects are transitioned to, ie GLACIER for
+	// S3 or the name of a remote tier for MinIO.
+	// +optional
+	TransitionStorageClass string `json:"transitionStorageClass,omitempty"`
+}
+
+// UpgradeSnapshots defines the CSI VolumeSnapshots taken before upgrading Mattermost.
+type UpgradeSnapshots struct {
+	// Set to true to take snapshots of the operator managed database and file
+	// store volumes before a new Mattermost version is rolled out. The upgrade
+	// waits until all snapshots are ready to use.
+	Enabled bool `json:"enabled"`
+	// Defines the VolumeSnapshotClass of the snapshots. The default
+	// VolumeSnapshotClass is used if not set.
+	// +optional
+	VolumeSnapshotClassName string `json:"volumeSnapshotClassName,omitempty"`
+}
+
+// UpgradeRollback defines the automatic rollback of failed Mattermost upgrades.
+type UpgradeRollback struct {
+	// Set to true to revert the Mattermost deployment to the previous image
+	// if the pods running the new image do not pass the health checks within
+	// the progress deadline. The rollback is reported with the UpgradeFailed
+	// condition and lasts until the image or version is changed again. It
+	// does not apply to BlueGreen deployments.
+	Enabled bool `json:"enabled"`
+	// Defines how long the pods running the new image have to pass the
+	// health checks after the upgrade started, ie 15m. Defaults to 10m.
+	// +optional
+	ProgressDeadline *metav1.Duration `json:"progressDeadline,omitempty"`
+}
+
+// SelfHealing defines the automatic rolling restart of Mattermost when its
+// application health checks fail repeatedly.
+type SelfHealing struct {
+	// Set to true to restart the Mattermost pods with a rolling restart once
+	// the application health checks failed FailureThreshold times in a row.
+	// The restart is recorded as an Event. It requires the application
+	// health checks of the operator to be enabled.
+	Enabled bool `json:"enabled"`
+	// Defines how many application health checks have to fail in a row
+	// before the pods are restarted. Defaults to 3.
+	// +kubebuilder:validation:Minimum=1
+	// +optional
+	FailureThreshold int32 `json:"failureThreshold,omitempty"`
+	// Defines the minimum time between two restarts, ie 1h. Defaults to
+	// 30m.
+	// +optional
+	Cooloff *metav1.Duration `json:"cooloff,omitempty"`
+}
+
+// Notifications defines the webhook notified of the upgrades, failures and
+// health degradation of the Mattermost.
+type Notifications struct {
+	// Defines the Secret with the 'url' of the incoming webhook notified,
+	// ie of Mattermost or Slack. The webhook of the operator is notified if
+	// not set.
+	// +optional
+	WebhookSecret string `json:"webhookSecret,omitempty"`
+	// Set to true to disable the notifications of the Mattermost, including
+	// to the webhook of the operator.
+	// +optional
+	Disabled bool `json:"disabled,omitempty"`
+}
+
+// Monitoring defines the ServiceMonitor and the PodMonitors generated for the
+// metrics endpoints of Mattermost and of its operator managed database and
+// file store.
+type Monitoring struct {
+	// Set to true to generate PodMonitors for the operator managed database
+	// and file store and, if the license enables performance monitoring, to
+	// expose the metrics port on the Mattermost Service and generate a
+	// ServiceMonitor scraping it. Requires the Prometheus Operator to be
+	// installed.
+	Enabled bool `json:"enabled"`
+	// Defines the interval the metrics are scraped at, ie 30s. Defaults to
+	// the scrape interval of Prometheus.
+	// +optional
+	Interval string `json:"interval,omitempty"`
+	// Defines the relabelings applied to the scraped targets, after the
+	// mattermost_installation and mattermost_component labels are set.
+	// +optional
+	Relabelings []RelabelConfig `json:"relabelings,omitempty"`
+	// Defines the Secret with the 'username' and 'password' Prometheus
+	// authenticates to the metrics endpoint with, ie if it is exposed
+	// through an authenticating proxy.
+	// +optional
+	BasicAuthSecret string `json:"basicAuthSecret,omitempty"`
+	// Defines additional labels of the ServiceMonitor, ie to match the
+	// serviceMonitorSelector of Prometheus.
+	// +optional
+	Labels map[string]string `json:"labels,omitempty"`
+	// Alerts defines the PrometheusRule generated with the alerts of the
+	// Mattermost.
+	// +optional
+	Alerts *MonitoringAlerts `json:"alerts,omitempty"`
+}
+
+// MonitoringAlerts defines the alerts of the Mattermost.
+type MonitoringAlerts struct {
+	// Set to true to generate a PrometheusRule alerting on unavailable
+	// replicas, failing application health checks and, for the operator
+	// managed database and file store, saturated database connections and
+	// offline file store disks. Requires monitoring to be enabled.
+	Enabled bool `json:"enabled"`
+	// Defines how long replicas have to be unavailable before alerting, ie
+	// 5m. Defaults to 10m.
+	// +optional
+	ReplicasUnavailableFor *metav1.Duration `json:"replicasUnavailableFor,omitempty"`
+	// Defines how many application health checks have to fail within 15
+	// minutes before alerting. Defaults to 3.
+	// +kubebuilder:validation:Minimum=1
+	// +optional
+	HealthCheckFailures int32 `json:"healthCheckFailures,omitempty"`
+	// Defines the percentage of the maximum connections of the operator
+	// managed database above which its connections are saturated.
+	// Defaults to 80.
+	// +kubebuilder:validation:Minimum=1
+	// +kubebuilder:validation:Maximum=100
+	// +optional
+	DatabaseConnectionsPercent int32 `json:"databaseConnectionsPercent,omitempty"`
+	// Defines additional labels of the alerts, ie to route them.
+	// +optional
+	Labels map[string]string `json:"labels,omitempty"`
+}
+
+// RelabelConfig defines a relabeling of the ServiceMonitor, see
+// https://prometheus.io/docs/prometheus/latest/configuration/configuration/#relabel_config
+type RelabelConfig struct {
+	// The source labels whose values are selected.
+	// +optional
+	SourceLabels []string `json:"sourceLabels,omitempty"`
+	// Separator placed between the concatenated source label values.
+	// +optional
+	Separator string `json:"separator,omitempty"`
+	// Label to which the resulting value is written in a replace action.
+	// +optional
+	TargetLabel string `json:"targetLabel,omitempty"`
+	// Regular expression against which the extracted value is matched.
+	// +optional
+	Regex string `json:"regex,omitempty"`
+	// Replacement value against which a regex replace is performed.
+	// +optional
+	Replacement string `json:"replacement,omitempty"`
+	// Action to perform based on regex matching. Defaults to replace.
+	// +kubebuilder:validation:Enum=replace;keep;drop;labelmap;labeldrop;labelkeep
+	// +optional
+	Action string `json:"action,omitempty"`
+}
+
+// VeleroVolumeBackupMode defines how Velero backs up the volumes of the
+// operator managed database and file store.
+type VeleroVolumeBackupMode string
+
+const (
+	// VeleroVolumeSnapshot excludes the volumes from pod volume backups, so
+	// that Velero backs them up with volume snapshots.
+	VeleroVolumeSnapshot VeleroVolumeBackupMode = "Snapshot"
+	// VeleroPodVolumeBackup backs up the volumes with Velero pod volume
+	// backups, copying the files of the volumes.
+	VeleroPodVolumeBackup VeleroVolumeBackupMode = "PodVolumeBackup"
+	// VeleroVolumeBackupNone excludes the volumes from Velero backups, ie
+	// when the data is backed up with a MattermostBackup instead.
+	VeleroVolumeBackupNone VeleroVolumeBackupMode = "None"
+)
+
+// VeleroBackups defines the Velero backup hooks and annotations added to the
+// pods and volumes of the operator managed database and file store.
+type VeleroBackups struct {
+	// Set to true to add the Velero backup hooks and annotations. Changing
+	// the hooks restarts the database and file store pods.
+	Enabled bool `json:"enabled"`
+	// Defines how Velero backs up the database and file store volumes, one
+	// of Snapshot, PodVolumeBackup or None. Defaults to Snapshot.
+	// +optional
+	// +kubebuilder:validation:Enum=Snapshot;PodVolumeBackup;None
+	VolumeBackupMode VeleroVolumeBackupMode `json:"volumeBackupMode,omitempty"`
+	// Defines the hooks run in the database pods. If not set, the tables of
+	// the operator managed MySQL database are flushed before the backup.
+	// +optional
+	Database *VeleroHooks `json:"database,omitempty"`
+	// Defines the hooks run in the file store pods. If not set, the file
+	// system buffers of the Minio pods are flushed before the backup.
+	// +optional
+	FileStore *VeleroHooks `json:"fileStore,omitempty"`
+}
+
+// VeleroHooks defines the commands Velero runs in the pods before and after
+// backing them up.
+type VeleroHooks struct {
+	// Defines the command run before the pod is backed up.
+	// +optional
+	PreHook *VeleroHook `json:"preHook,omitempty"`
+	// Defines the command run after the pod is backed up.
+	// +optional
+	PostHook *VeleroHook `json:"postHook,omitempty"`
+}
+
+// VeleroHook defines a command run by Velero in a pod.
+type VeleroHook struct {
+	// Defines the command and its arguments, ie ["/bin/sh", "-c", "sync"].
+	// +kubebuilder:validation:MinItems=1
+	Command []string `json:"command"`
+	// Defines how long Velero waits for the command to complete, ie 1m.
+	// The Velero default is used if not set.
+	// +optional
+	Timeout string `json:"timeout,omitempty"`
+	// Defines whether the backup continues if the command fails, either
+	// Continue or Fail. The Velero default is used if not set.
+	// +optional
+	// +kubebuilder:validation:Enum=Continue;Fail
+	OnError string `json:"onError,omitempty"`
+}
+
+// Jobs defines the one-off jobs run for the Mattermost installation.
+type Jobs struct {
+	// Defines the export of the workspace.
+	// +optional
+	Export *ExportJob `json:"export,omitempty"`
+	// Defines the import of a workspace export archive.
+	// +optional
+	Import *ImportJob `json:"import,omitempty"`
+	// Defines the update check job, running the new Mattermost image once
+	// before the app servers are updated to it.
+	// +optional
+	UpdateCheck *UpdateCheckJob `json:"updateCheck,omitempty"`
+	// Defines the retries and the lifetime of the database setup job.
+	// +optional
+	DatabaseSetup *JobPolicy `json:"databaseSetup,omitempty"`
+}
+
+// JobPolicy defines the retries and the lifetime of a job created by the
+// Operator, so that failed jobs neither retry endlessly nor linger.
+type JobPolicy struct {
+	// Defines the number of retries before the job is failed. Defaults to
+	// the retries of the job.
+	// +kubebuilder:validation:Minimum=0
+	// +optional
+	BackoffLimit *int32 `json:"backoffLimit,omitempty"`
+	// Defines how long the job may run before it is failed.
+	// +kubebuilder:validation:Minimum=1
+	// +optional
+	ActiveDeadlineSeconds *int64 `json:"activeDeadlineSeconds,omitempty"`
+	// Defines how long the finished job is kept before it is deleted.
+	// Finished jobs are kept until the Operator deletes them if not set.
+	// +kubebuilder:validation:Minimum=0
+	// +optional
+	TTLSecondsAfterFinished *int32 `json:"ttlSecondsAfterFinished,omitempty"`
+}
+
+// UpdateCheckJob defines the job checking a new Mattermost image, and
+// running the database migrations, before the app servers are updated to it.
+// It runs with the pod settings of the app servers unless overridden.
+type UpdateCheckJob struct {
+	// Set to true to update the app servers without checking the new image
+	// first, ie in air-gapped clusters where the check does not complete.
+	// +optional
+	Disabled bool `json:"disabled,omitempty"`
+	// Defines the resources of the update check job. Defaults to the
+	// resources of the app servers.
+	// +optional
+	Resources *v1.ResourceRequirements `json:"resources,omitempty"`
+	// Defines the node selector of the update check job. Defaults to the
+	// node selector of the app servers.
+	// +optional
+	NodeSelector map[string]string `json:"nodeSelector,omitempty"`
+	// Defines the tolerations of the update check job. Defaults to the
+	// tolerations of the app servers.
+	// +optional
+	Tolerations []v1.Toleration `json:"tolerations,omitempty"`
+	// Defines additional image pull Secrets of the update check job.
+	// +optional
+	ImagePullSecrets []v1.LocalObjectReference `json:"imagePullSecrets,omitempty"`
+	// Defines the retries and the lifetime of the update check job.
+	JobPolicy `json:",inline"`
+}
+
+// ExportJob defines a bulk export of the workspace uploaded to an S3 bucket.
+type ExportJob struct {
+	// Defines the identifier of the export, a new export runs whenever it
+	// changes. It is part of the name of the export archive, ie 2021-06-01.
+	// +kubebuilder:validation:Pattern=^[A-Za-z0-9_.-]+$
+	ID string `json:"id"`
+	// Set to true to include the file attachments in the export.
+	// +optional
+	IncludeAttachments bool `json:"includeAttachments,omitempty"`
+	// Defines the S3 bucket the export archive is uploaded to.
+	Destination BackupDestination `json:"destination"`
+}
+
+// ImportJob defines a bulk import of a workspace export archive stored in an
+// S3 bucket.
+type ImportJob struct {
+	// Defines the identifier of the import, a new import runs whenever it
+	// changes.
+	// +kubebuilder:validation:Pattern=^[A-Za-z0-9_.-]+$
+	ID string `json:"id"`
+	// Defines the S3 bucket holding the export archive.
+	Source BackupDestination `json:"source"`
+	// Defines the path of the export archive in the source bucket, relative
+	// to the prefix, ie mm-test-2021-06-01.tar.gz.
+	Archive string `json:"archive"`
+}
+
+// BlueGreen defines the blue and green deployments of Mattermost.
+type BlueGreen struct {
+	// Set to true to replace the Mattermost deployment with the blue and
+	// green deployments. Each deployment has its own service and ingress,
+	// the main service and ingress route the traffic to the production
+	// deployment.
+	Enabled bool `json:"enabled"`
+	// Defines the deployment receiving the production traffic, either blue
+	// or green.
+	// +kubebuilder:validation:Enum=blue;green
+	ProductionDeployment string `json:"productionDeployment"`
+	// Defines the blue deployment.
+	Blue AppDeployment `json:"blue"`
+	// Defines the green deployment.
+	Green AppDeployment `json:"green"`
+}
+
+// Canary defines a canary deployment of Mattermost. The traffic is split with
+// the canary annotations of the NGINX ingress controller.
+type Canary struct {
+	// Set to true to run the canary deployment next to the Mattermost
+	// deployment. Requires the Mattermost ingress to be enabled.
+	Enabled bool `json:"enabled"`
+	// Defines the percentage of the requests to the Mattermost ingress
+	// routed to the canary deployment.
+	// +kubebuilder:validation:Minimum=0
+	// +kubebuilder:validation:Maximum=100
+	Weight int32 `json:"weight"`
+	// Defines the canary deployment. Its name defaults to the name of the
+	// Mattermost with a -canary suffix.
+	Deployment AppDeployment `json:"deployment"`
+}
+
+// ReconcilePolicy defines how the Operator corrects the drift of the
+// resources it generates.
+type ReconcilePolicy struct {
+	// IgnoreFields lists the JSONPaths of the fields of the generated
+	// resources the Operator does not correct, ie '.spec.replicas' when an
+	// external autoscaler manages the replicas of the deployment, or
+	// ".metadata.annotations['example.com/key']" for an annotation set by
+	// another controller. The current value of the fields is kept when the
+	// resources are updated. Only object fields are supported, not list
+	// items.
+	// +optional
+	IgnoreFields []string `json:"ignoreFields,omitempty"`
+}
+
+// Relocation defines the namespace a Mattermost is moved to.
+type Relocation struct {
+	// Namespace is the namespace the Mattermost is moved to.
+	Namespace string `json:"namespace"`
+	// DatabaseSecret is the Secret of the target namespace with the
+	// connection string of the database used by the relocated Mattermost,
+	// ie when the database host is a Service of the current namespace. The
+	// database Secret is copied by default.
+	// +optional
+	DatabaseSecret string `json:"databaseSecret,omitempty"`
+	// FileStore is the external file store used by the relocated
+	// Mattermost, with its Secret in the target namespace. The file store
+	// is kept, and its Secret copied, by default.
+	// +optional
+	FileStore *ExternalFileStore `json:"fileStore,omitempty"`
+}
+
+// Standby defines the disaster-recovery standby of a Mattermost.
+type Standby struct {
+	// Promoted activates the standby Mattermost, ie once the active
+	// installation failed: its deployment is scaled to its replicas and its
+	// ingress is created.
+	// +optional
+	Promoted bool `json:"promoted,omitempty"`
+}
+
+// MaintenanceMode defines the maintenance mode of a Mattermost.
+type MaintenanceMode struct {
+	// Enabled flips the Mattermost into maintenance mode.
+	Enabled bool `json:"enabled"`
+	// Message is the text of the announcement banner shown while the
+	// Mattermost is in maintenance mode. Defaults to "Mattermost is under
+	// maintenance, some features may be unavailable."
+	// +optional
+	Message string `json:"message,omitempty"`
+	// AccessTokenSecret is the Secret with the access token of a system
+	// admin, under the 'token' key, the announcement banner is set with
+	// through the admin API. The banner is not set if empty.
+	// +optional
+	AccessTokenSecret string `json:"accessTokenSecret,omitempty"`
+	// ReadOnlyDatabase makes the database sessions of Mattermost read-only,
+	// with the default_transaction_read_only setting of PostgreSQL. Not
+	// supported with MySQL. The Mattermost pods are restarted.
+	// +optional
+	ReadOnlyDatabase bool `json:"readOnlyDatabase,omitempty"`
+	// PageURL is the URL of the maintenance page the ingress redirects the
+	// requests to. The requests are routed to Mattermost if empty.
+	// +optional
+	PageURL string `json:"pageURL,omitempty"`
+}
+
+// UpdatePolicy defines how changes to the Mattermost deployment are rolled out.
+type UpdatePolicy struct {
+	// Window defines the maintenance window in which changes restarting the
+	// Mattermost pods, ie a new version, are applied. Outside of the window
+	// the changes are queued and reported in the status. Changes are applied
+	// immediately if not set.
+	// +optional
+	Window *MaintenanceWindow `json:"window,omitempty"`
+	// Channel is the release channel the Mattermost version is automatically
+	// upgraded in, using the releases feed configured for the operator:
+	// 'patch-only' for the patch releases of the current minor, 'esr' for
+	// Extended Support Releases and 'latest' for all releases. Automatic
+	// upgrades are disabled if not set.
+	// +kubebuilder:validation:Enum=esr;latest;patch-only
+	// +optional
+	Channel UpdateChannel `json:"channel,omitempty"`
+	// Approval defines whether upgrades found in the channel are applied
+	// automatically or wait for ApprovedVersion to be set to the version.
+	// Defaults to Automatic.
+	// +kubebuilder:validation:Enum=Automatic;Manual
+	// +optional
+	Approval UpdateApproval `json:"approval,omitempty"`
+	// ApprovedVersion is the version found in the channel approved for the
+	// upgrade when Approval is Manual.
+	// +optional
+	ApprovedVersion string `json:"approvedVersion,omitempty"`
+	// Set to true to back up the Mattermost installation with a
+	// MattermostBackup before a new image is rolled out. The upgrade waits
+	// for the backup to complete and does not proceed if it fails.
+	// +optional
+	BackupBeforeUpgrade bool `json:"backupBeforeUpgrade,omitempty"`
+	// Defines the S3 bucket the backups taken before upgrades are uploaded
+	// to. Required if BackupBeforeUpgrade is set.
+	// +optional
+	BackupDestination *BackupDestination `json:"backupDestination,omitempty"`
+	// Set to true to upgrade to versions which are not supported by the
+	// support matrix of the operator, ie skipping a release with mandatory
+	// migrations. Unsupported upgrades are only reported in the
+	// UnsupportedUpgrade condition then, otherwise they are rejected.
+	// +optional
+	AllowUnsupportedUpgrades bool `json:"allowUnsupportedUpgrades,omitempty"`
+	// PostUpgradeChecks defines the smoke test run after a new image is
+	// rolled out.
+	// +optional
+	PostUpgradeChecks *PostUpgradeChecks `json:"postUpgradeChecks,omitempty"`
+	// Set to true to roll out new images in place, without running the
+	// update check job first, ie where the extra pod per upgrade is slow or
+	// not allowed. The rolling update keeps the previous pods serving until
+	// the new ones stayed ready for a while, and stalls if they do not.
+	// +optional
+	SkipVersionCheckJob bool `json:"skipVersionCheckJob,omitempty"`
+}
+
+// AutoSizing defines the automatic sizing of Mattermost from the monthly
+// active users reported by the admin API.
+type AutoSizing struct {
+	// Set to true to size Mattermost from its active users.
+	Enabled bool `json:"enabled"`
+	// Defines the Secret with the 'token' of a personal access token of a
+	// system admin, used to query the active users.
+	AccessTokenSecret string `json:"accessTokenSecret"`
+	// Defines the smallest size applied. Defaults to 100users.
+	// +kubebuilder:validation:Enum=100users;1000users;5000users;10000users;25000users
+	// +optional
+	MinSize string `json:"minSize,omitempty"`
+	// Defines the largest size applied. Defaults to 25000users.
+	// +kubebuilder:validation:Enum=100users;1000users;5000users;10000users;25000users
+	// +optional
+	MaxSize string `json:"maxSize,omitempty"`
+	// Defines the percentage of the users of the size above which the next
+	// larger size is applied. Defaults to 80.
+	// +kubebuilder:validation:Minimum=1
+	// +kubebuilder:validation:Maximum=100
+	// +optional
+	ScaleUpThreshold int32 `json:"scaleUpThreshold,omitempty"`
+	// Defines the percentage of the users of the size below which a smaller
+	// size is applied, lower than ScaleUpThreshold. Defaults to 40.
+	// +kubebuilder:validation:Minimum=1
+	// +kubebuilder:validation:Maximum=100
+	// +optional
+	ScaleDownThreshold int32 `json:"scaleDownThreshold,omitempty"`
+	// Defines how often the active users are checked. Defaults to 1h.
+	// +optional
+	CheckInterval *metav1.Duration `json:"checkInterval,omitempty"`
+}
+
+// Edition is a Mattermost edition.
+type Edition string
+
+const (
+	// EditionTeam is Mattermost Team Edition.
+	EditionTeam Edition = "team"
+	// EditionEnterprise is Mattermost Enterprise Edition.
+	EditionEnterprise Edition = "enterprise"
+)
+
+// ImageVariant is a variant of the Mattermost image.
+type ImageVariant string
+
+const (
+	// ImageVariantFIPS is the FIPS 140-2 compliant Mattermost image.
+	ImageVariantFIPS ImageVariant = "fips"
+	// ImageVariantUBI is the Mattermost image based on the Red Hat Universal
+	// Base Image.
+	ImageVariantUBI ImageVariant = "ubi"
+)
+
+// IngressPathType is the path type of an Ingress path.
+type IngressPathType string
+
+const (
+	// IngressPathTypePrefix matches the path by its elements split by "/".
+	IngressPathTypePrefix IngressPathType = "Prefix"
+	// IngressPathTypeExact matches the path exactly.
+	IngressPathTypeExact IngressPathType = "Exact"
+	// IngressPathTypeImplementationSpecific leaves the matching of the path
+	// to the ingress controller.
+	IngressPathTypeImplementationSpecific IngressPathType = "ImplementationSpecific"
+)
+
+// DeletionPolicy defines what happens to the data of a Mattermost once it is
+// deleted.
+type DeletionPolicy string
+
+const (
+	// DeletionPolicyDelete deletes the data of the Mattermost with it.
+	DeletionPolicyDelete DeletionPolicy = "Delete"
+	// DeletionPolicyOrphan keeps the data of the Mattermost once it is
+	// deleted.
+	DeletionPolicyOrphan DeletionPolicy = "Orphan"
+)
+
+// UpdateChannel is a release channel Mattermost is automatically upgraded
+// in.
+type UpdateChannel string
+
+const (
+	// UpdateChannelESR upgrades to Extended Support Releases and their patch
+	// releases.
+	UpdateChannelESR UpdateChannel = "esr"
+	// UpdateChannelLatest upgrades to the latest release.
+	UpdateChannelLatest UpdateChannel = "latest"
+	// UpdateChannelPatchOnly upgrades to the patch releases of the current
+	// minor release.
+	UpdateChannelPatchOnly UpdateChannel = "patch-only"
+)
+
+// UpdateApproval defines how upgrades found in the release channel are
+// approved.
+type UpdateApproval string
+
+const (
+	// UpdateApprovalAutomatic applies the upgrades without approval.
+	UpdateApprovalAutomatic UpdateApproval = "Automatic"
+	// UpdateApprovalManual applies the upgrades once approved.
+	UpdateApprovalManual UpdateApproval = "Manual"
+)
+
+// PostUpgradeChecks defines the Job testing Mattermost after a new image is
+// rolled out.
+type PostUpgradeChecks struct {
+	// Set to true to run the checks once the pods running a new image pass
+	// the health checks. The Mattermost is only reported as stable after the
+	// checks pass. Failed checks roll back the upgrade if UpgradeRollback is
+	// enabled. It does not apply to BlueGreen deployments.
+	Enabled bool `json:"enabled"`
+	// Defines the Secret with the 'username' and 'password' of the account
+	// logging in to Mattermost during the checks. The login is skipped if
+	// not set.
+	// +optional
+	ProbeAccountSecret string `json:"probeAccountSecret,omitempty"`
+	// Defines the channel the probe account posts to, as
+	// 'team-name/channel-name'. Requires ProbeAccountSecret. Nothing is
+	// posted if not set.
+	// +optional
+	HealthChannel string `json:"healthChannel,omitempty"`
+	// Defines the image of the Job running the checks. Defaults to
+	// appropriate/curl:latest.
+	// +optional
+	Image string `json:"image,omitempty"`
+	// Overrides the default checks with a custom command. The URL of the
+	// Mattermost service is available in the MM_SERVICE_URL environment
+	// variable.
+	// +optional
+	Command []string `json:"command,omitempty"`
+}
+
+// ImageVerification defines how the Mattermost image is verified before it
+// is rolled out.
+type ImageVerification struct {
+	// Set to true to resolve the Mattermost image tag to its digest and pin
+	// the deployment to the digest, so that the image does not change if the
+	// tag is moved. The tag is resolved again when the version changes. It
+	// does not apply to BlueGreen deployments.
+	Enabled bool `json:"enabled"`
+	// Defines the Secret with the PEM encoded ECDSA public keys the cosign
+	// signature of the image is verified against, one key per value. The
+	// image is only rolled out if signed by one of the keys. The signature
+	// is not verified if not set.
+	// +optional
+	CosignKeySecret string `json:"cosignKeySecret,omitempty"`
+}
+
+// ECRCredentials defines the image pull Secret of an Amazon ECR registry
+// refreshed by the Operator from ECR authorization tokens.
+type ECRCredentials struct {
+	// Set to true to refresh the image pull Secret of the ECR registry and
+	// reference it in the pods created for the Mattermost. It requires the
+	// ECR credentials controller to be enabled for the Operator.
+	Enabled bool `json:"enabled"`
+	// Defines the AWS region of the ECR registry. Defaults to the region of
+	// the registry of the Mattermost image.
+	// +optional
+	Region string `json:"region,omitempty"`
+	// Defines the Secret with the AWS credentials requesting the ECR
+	// authorization tokens, under the AWS_ACCESS_KEY_ID,
+	// AWS_SECRET_ACCESS_KEY and optional AWS_SESSION_TOKEN keys. Defaults to
+	// the AWS credentials of the Operator.
+	// +optional
+	CredentialsSecret string `json:"credentialsSecret,omitempty"`
+}
+
+// MaintenanceWindow defines when changes restarting the Mattermost pods are
+// applied.
+type MaintenanceWindow struct {
+	// Defines the minutes within the window as a cron expression, ie
+	// '* 2-4 * * 6,0' for Saturdays and Sundays from 2:00 to 4:59.
+	Schedule string `json:"schedule"`
+	// Defines the IANA time zone of the schedule, ie Europe/Berlin. Defaults
+	// to UTC.
+	// +optional
+	TimeZone string `json:"timeZone,omitempty"`
+}
+
+// AppDeployment defines a blue, green or canary Mattermost deployment.
+type AppDeployment struct {
+	// Defines the name of the deployment, its service and its ingress.
+	// Defaults to the name of the Mattermost with a -blue or -green suffix.
+	// +optional
+	Name string `json:"name,omitempty"`
+	// Defines the host of the ingress of the deployment, used to test the
+	// deployment before it receives the production traffic. Defaults to the
+	// ingress host with a blue. or green. prefix. Not used by the canary
+	// deployment.
+	// +optional
+	IngressHost string `json:"ingressHost,omitempty"`
+	// Defines the Mattermost Docker image of the deployment. Defaults to
+	// the image of the Mattermost.
+	// +optional
+	Image string `json:"image,omitempty"`
+	// Defines the Mattermost Docker image version of the deployment.
+	Version string `json:"version"`
+}
+
 // ElasticSearch defines the ElasticSearch configuration for Mattermost.
 type ElasticSearch struct {
 	Host string `json:"host,omitempty"`
@@ -280,6 +1200,64 @@ type ElasticSearch struct {
 	Password string `json:"password,omitempty"`
 }
 
+// Proxy defines the outbound HTTP proxy. Destinations in the cluster are
+// never proxied.
+type Proxy struct {
+	// Defines the URL of the proxy for HTTP requests.
+	// +optional
+	HTTPProxy string `json:"httpProxy,omitempty"`
+	// Defines the URL of the proxy for HTTPS requests.
+	// +optional
+	HTTPSProxy string `json:"httpsProxy,omitempty"`
+	// Defines the comma separated hosts, domains and CIDRs not proxied, in
+	// addition to localhost, the namespace of the Mattermost and the
+	// cluster-local domains.
+	// +optional
+	NoProxy string `json:"noProxy,omitempty"`
+}
+
+// TrustedCABundle defines the ConfigMap with the PEM encoded CA certificates
+// trusted in addition to the system CAs. The bundle is mounted in the system
+// trust directory /etc/ssl/certs, read by Mattermost and other programs
+// written in Go. Pods are not restarted when the ConfigMap changes.
+type TrustedCABundle struct {
+	// Defines the name of the ConfigMap with the CA bundle.
+	ConfigMap string `json:"configMap"`
+	// Defines the key of the CA bundle in the ConfigMap. Defaults to
+	// ca-bundle.crt.
+	// +optional
+	Key string `json:"key,omitempty"`
+}
+
+// ClusterReadinessGate defines the readiness gate of the pods of clustered
+// Mattermost app servers. The Operator queries the cluster status of
+// Mattermost and marks the pods found in it as joined.
+type ClusterReadinessGate struct {
+	// Set to true to hold pods unready until they joined the cluster.
+	Enabled bool `json:"enabled"`
+	// Defines the Secret with the 'token' of a personal access token of a
+	// system admin, used to query the cluster status.
+	AccessTokenSecret string `json:"accessTokenSecret"`
+}
+
+// UtilityImages defines the images, with their tags, of the utility
+// containers created by the Operator.
+type UtilityImages struct {
+	// Curl defines the curl image of the init containers waiting for MySQL
+	// and MinIO. Defaults to appropriate/curl:latest.
+	// +optional
+	Curl string `json:"curl,omitempty"`
+	// Postgres defines the PostgreSQL image of the init container waiting
+	// for an external PostgreSQL database. Defaults to postgres:13.
+	// +optional
+	Postgres string `json:"postgres,omitempty"`
+	// MinioClient defines the MinIO client image configuring the file store
+	// buckets and transferring the files of the jobs. Defaults to
+	// minio/mc:RELEASE.2021-06-13T17-48-22Z.
+	// +optional
+	MinioClient string `json:"minioClient,omitempty"`
+}
+
 // RunningState is the state of the Mattermost instance
 type RunningState string
 
@@ -287,17 +1265,589 @@ type RunningState string
 // Two types of instance running states are implemented: reconciling and stable.
 // If any changes are being made on the mattermost instance, the state will be
 // set to reconciling. If the reconcile loop reaches the end without requeuing
-// then the state will be set to stable.
+// then the state will be set to stable. A standby instance is set to standby
+// once its deployment is scaled to zero.
 const (
 	// Reconciling is the state when the Mattermost instance is being updated
 	Reconciling RunningState = "reconciling"
 	// Stable is the state when the Mattermost instance is fully running
 	Stable RunningState = "stable"
+	// StandingBy is the state when the standby Mattermost instance is idle
+	// until it is promoted
+	StandingBy RunningState = "standby"
+)
+
+// FileStoreMigrationState is the state of the file store migration.
+type FileStoreMigrationState string
+
+const (
+	// FileStoreMigrationSyncing is the state when the files are being copied
+	// and verified
+	FileStoreMigrationSyncing FileStoreMigrationState = "syncing"
+	// FileStoreMigrationCompleted is the state when Mattermost was switched to
+	// the new file store
+	FileStoreMigrationCompleted FileStoreMigrationState = "completed"
+	// FileStoreMigrationFailed is the state when the files could not be
+	// copied or verified
+	FileStoreMigrationFailed FileStoreMigrationState = "failed"
+)
+
+// FileStoreMigrationStatus defines the observed state of the file store migration.
+type FileStoreMigrationStatus struct {
+	// Represents the state of the migration
+	// +optional
+	State FileStoreMigrationState `json:"state,omitempty"`
+	// The URL and bucket of the file store the files are migrated to
+	// +optional
+	Target string `json:"target,omitempty"`
+	// The name of the Job copying the files
+	// +optional
+	JobName string `json:"jobName,omitempty"`
+	// The progress or the result of the migration reported by the Job
+	// +optional
+	Message string `json:"message,omitempty"`
+	// The number of objects copied by the running Job
+	// +optional
+	CopiedObjects int64 `json:"copiedObjects,omitempty"`
+	// The number of objects of the source file store to copy
+	// +optional
+	TotalObjects int64 `json:"totalObjects,omitempty"`
+	// The time when the migration started
+	// +optional
+	StartTime *metav1.Time `json:"startTime,omitempty"`
+	// The time when the migration completed or failed
+	// +optional
+	CompletionTime *metav1.Time `json:"completionTime,omitempty"`
+}
+
+// RelocationPhase is the phase of the relocation of a Mattermost to another
+// namespace.
+type RelocationPhase string
+
+const (
+	// RelocationReplicating is the phase when the Secrets and ConfigMaps
+	// are copied and the relocated Mattermost is created
+	RelocationReplicating RelocationPhase = "replicating"
+	// RelocationWaitingForTarget is the phase when the relocated Mattermost
+	// is rolled out, its ingress disabled
+	RelocationWaitingForTarget RelocationPhase = "waitingForTarget"
+	// RelocationCuttingOver is the phase when the ingress is switched to the
+	// relocated Mattermost, before this Mattermost is deleted
+	RelocationCuttingOver RelocationPhase = "cuttingOver"
+	// RelocationFailed is the phase when the Mattermost cannot be relocated
+	RelocationFailed RelocationPhase = "failed"
+)
+
+// RelocationStatus defines the observed state of the relocation of the
+// Mattermost to another namespace.
+type RelocationStatus struct {
+	// Represents the phase of the relocation
+	// +optional
+	Phase RelocationPhase `json:"phase,omitempty"`
+	// The namespace the Mattermost is moved to
+	// +optional
+	Namespace string `json:"namespace,omitempty"`
+	// The reason the relocation failed
+	// +optional
+	Message string `json:"message,omitempty"`
+	// The time when the relocation started
+	// +optional
+	StartTime *metav1.Time `json:"startTime,omitempty"`
+}
+
+// MaintenanceModeStatus defines the observed state of the maintenance mode
+// of the Mattermost.
+type MaintenanceModeStatus struct {
+	// The time when the maintenance mode was enabled
+	// +optional
+	StartTime *metav1.Time `json:"startTime,omitempty"`
+	// The Secret of the access token the announcement banner was set with,
+	// removed with it once the maintenance mode is disabled
+	// +optional
+	BannerAccessTokenSecret string `json:"bannerAccessTokenSecret,omitempty"`
+	// The error of the last update of the announcement banner
+	// +optional
+	Error string `json:"error,omitempty"`
+}
+
+// ExportState is the state of the workspace export.
+type ExportState string
+
+const (
+	// ExportRunning is the state when the workspace is being exported
+	ExportRunning ExportState = "running"
+	// ExportCompleted is the state when the export archive was uploaded to
+	// the destination
+	ExportCompleted ExportState = "completed"
+	// ExportFailed is the state when the workspace could not be exported
+	ExportFailed ExportState = "failed"
+)
+
+// ExportStatus defines the observed state of the workspace export.
+type ExportStatus struct {
+	// The identifier of the export
+	// +optional
+	ID string `json:"id,omitempty"`
+	// Represents the state of the export
+	// +optional
+	State ExportState `json:"state,omitempty"`
+	// The name of the Job exporting the workspace
+	// +optional
+	JobName string `json:"jobName,omitempty"`
+	// The URL of the uploaded export archive
+	// +optional
+	URL string `json:"url,omitempty"`
+	// The error reported by the Job if the export failed
+	// +optional
+	Message string `json:"message,omitempty"`
+	// The time when the export started
+	// +optional
+	StartTime *metav1.Time `json:"startTime,omitempty"`
+	// The time when the export completed or failed
+	// +optional
+	CompletionTime *metav1.Time `json:"completionTime,omitempty"`
+}
+
+// ImportState is the state of the workspace import.
+type ImportState string
+
+const (
+	// ImportDownloading is the state when the export archive is being
+	// downloaded
+	ImportDownloading ImportState = "downloading"
+	// ImportValidating is the state when the export is being validated
+	ImportValidating ImportState = "validating"
+	// ImportImporting is the state when the export is being imported
+	ImportImporting ImportState = "importing"
+	// ImportCompleted is the state when the export was imported
+	ImportCompleted ImportState = "completed"
+	// ImportFailed is the state when the export could not be imported
+	ImportFailed ImportState = "failed"
+)
+
+// ImportStatus defines the observed state of the workspace import.
+type ImportStatus struct {
+	// The identifier of the import
+	// +optional
+	ID string `json:"id,omitempty"`
+	// Represents the state of the import
+	// +optional
+	State ImportState `json:"state,omitempty"`
+	// The name of the Job importing the workspace
+	// +optional
+	JobName string `json:"jobName,omitempty"`
+	// The URL of the imported export archive
+	// +optional
+	Archive string `json:"archive,omitempty"`
+	// The result of the import or the error reported by the Job if it failed
+	// +optional
+	Message string `json:"message,omitempty"`
+	// The time when the import started
+	// +optional
+	StartTime *metav1.Time `json:"startTime,omitempty"`
+	// The time when the import completed or failed
+	// +optional
+	CompletionTime *metav1.Time `json:"completionTime,omitempty"`
+}
+
+// UpgradeState is the state of an upgrade of the Mattermost image.
+type UpgradeState string
+
+const (
+	// UpgradeInProgress is the state when the new image is being rolled out.
+	UpgradeInProgress UpgradeState = "in-progress"
+	// UpgradeCompleted is the state when the pods running the new image
+	// passed the health checks.
+	UpgradeCompleted UpgradeState = "completed"
+	// UpgradeRolledBack is the state when the pods running the new image did
+	// not pass the health checks within the progress deadline and the
+	// previous image was restored.
+	UpgradeRolledBack UpgradeState = "rolled-back"
+)
+
+const (
+	// UpgradeFailedCondition is the type of the condition reporting that the
+	// last upgrade of the Mattermost image was rolled back.
+	UpgradeFailedCondition = "UpgradeFailed"
+	// UnsupportedUpgradeCondition is the type of the condition reporting
+	// that the requested version is not supported by the support matrix.
+	UnsupportedUpgradeCondition = "UnsupportedUpgrade"
+	// VersionUnsupportedCondition is the type of the condition reporting
+	// that the running version is older than the minimum supported version.
+	VersionUnsupportedCondition = "VersionUnsupported"
+	// ReadyCondition is the type of the condition reporting that all the
+	// Mattermost pods run the requested image and are ready, the condition
+	// of the stable state.
+	ReadyCondition = "Ready"
+	// ProgressingCondition is the type of the condition reporting that the
+	// changes of the Mattermost are being rolled out, the condition of the
+	// reconciling state.
+	ProgressingCondition = "Progressing"
+	// DegradedCondition is the type of the condition reporting that the
+	// Mattermost is no longer healthy although its spec did not change, or
+	// that its last upgrade was rolled back.
+	DegradedCondition = "Degraded"
+	// ErrorCondition is the type of the condition reporting that the last
+	// reconciliation of the Mattermost failed.
+	ErrorCondition = "Error"
+	// ReconcilingCondition is the type of the condition reporting that the
+	// changes of the Mattermost are being rolled out, the abnormal-true
+	// counterpart of the Progressing condition read by kstatus, ie by Flux.
+	ReconcilingCondition = "Reconciling"
+	// StalledCondition is the type of the condition reporting that the
+	// changes of the Mattermost cannot be rolled out, because the
+	// reconciliation failed or the upgrade was rolled back, read by kstatus.
+	StalledCondition = "Stalled"
+	// DatabaseReachableCondition is the type of the condition reporting that
+	// Mattermost reads and writes its database with its configured
+	// credentials.
+	DatabaseReachableCondition = "DatabaseReachable"
+	// FileStoreReachableCondition is the type of the condition reporting
+	// that Mattermost reaches the bucket of its file store with its
+	// configured credentials.
+	FileStoreReachableCondition = "FileStoreReachable"
+	// HealthDegradedCondition is the type of the condition reporting that
+	// the application health checks of Mattermost failed repeatedly.
+	HealthDegradedCondition = "HealthDegraded"
+	// CrashLoopBackOffCondition is the type of the condition reporting that
+	// a container of the Mattermost pods is restarted repeatedly after
+	// crashing.
+	CrashLoopBackOffCondition = "CrashLoopBackOff"
+	// ImagePullBackOffCondition is the type of the condition reporting that
+	// the image of a container of the Mattermost pods cannot be pulled.
+	ImagePullBackOffCondition = "ImagePullBackOff"
+	// UnschedulableCondition is the type of the condition reporting that a
+	// Mattermost pod cannot be scheduled on a node.
+	UnschedulableCondition = "Unschedulable"
+	// PausedCondition is the type of the condition reporting that the
+	// reconciliation of the Mattermost is paused by its paused annotation.
+	PausedCondition = "Paused"
+	// StandbyCondition is the type of the condition reporting that the
+	// Mattermost is a standby, idle until it is promoted.
+	StandbyCondition = "Standby"
+)
+
+// UpgradeStatus defines the status of an upgrade of the Mattermost image.
+type UpgradeStatus struct {
+	// Represents the state of the upgrade
+	// +optional
+	State UpgradeState `json:"state,omitempty"`
+	// The image the Mattermost deployment ran before the upgrade
+	// +optional
+	FromImage string `json:"fromImage,omitempty"`
+	// The image the Mattermost deployment is upgraded to
+	// +optional
+	ToImage string `json:"toImage,omitempty"`
+	// The time when the upgrade started
+	// +optional
+	StartTime *metav1.Time `json:"startTime,omitempty"`
+	// The time when the upgrade completed or was rolled back
+	// +optional
+	CompletionTime *metav1.Time `json:"completionTime,omitempty"`
+}
+
+// PendingUpdateReason is the reason why a change of the Mattermost
+// deployment is queued.
+type PendingUpdateReason string
+
+const (
+	// PendingUpdateMaintenanceWindow is the reason of the changes queued
+	// until the maintenance window opens.
+	PendingUpdateMaintenanceWindow PendingUpdateReason = "MaintenanceWindow"
+	// PendingUpdateWaitingForUpgradeSlot is the reason of the upgrades
+	// queued until fewer installations are upgraded than the operator allows
+	// at the same time.
+	PendingUpdateWaitingForUpgradeSlot PendingUpdateReason = "WaitingForUpgradeSlot"
+)
+
+// PendingUpdateStatus defines a change of the Mattermost deployment queued
+// until the maintenance window opens, or until an upgrade slot is free.
+type PendingUpdateStatus struct {
+	// The reason why the change is queued
+	// +optional
+	Reason PendingUpdateReason `json:"reason,omitempty"`
+	// The image the queued change rolls out
+	// +optional
+	Image string `json:"image,omitempty"`
+	// The image the Mattermost deployment runs until the change is applied
+	// +optional
+	RunningImage string `json:"runningImage,omitempty"`
+	// The time when the change was queued
+	// +optional
+	Since *metav1.Time `json:"since,omitempty"`
+	// The time when the next maintenance window opens
+	// +optional
+	NextWindow *metav1.Time `json:"nextWindow,omitempty"`
+}
+
+// ChannelUpdateState is the state of the upgrade found in the release
+// channel.
+type ChannelUpdateState string
+
+const (
+	// ChannelUpdateAwaitingApproval is the state when the upgrade waits for
+	// approval
+	ChannelUpdateAwaitingApproval ChannelUpdateState = "awaiting-approval"
+	// ChannelUpdateApplied is the state when the version was set to the
+	// upgrade
+	ChannelUpdateApplied ChannelUpdateState = "applied"
+)
+
+// ChannelUpdateStatus defines the last upgrade found in the release channel.
+type ChannelUpdateStatus struct {
+	// The release channel
+	// +optional
+	Channel UpdateChannel `json:"channel,omitempty"`
+	// The version Mattermost is upgraded to
+	// +optional
+	Version string `json:"version,omitempty"`
+	// Represents the state of the upgrade
+	// +optional
+	State ChannelUpdateState `json:"state,omitempty"`
+	// The time when the upgrade was found or applied
+	// +optional
+	LastTransitionTime *metav1.Time `json:"lastTransitionTime,omitempty"`
+}
+
+// ImageVerificationState is the state of the verification of the Mattermost
+// image.
+type ImageVerificationState string
+
+const (
+	// ImageVerificationPinned is the state when the image tag is resolved to
+	// the digest
+	ImageVerificationPinned ImageVerificationState = "pinned"
+	// ImageVerificationVerified is the state when the image tag is resolved
+	// to the digest and its signature is verified
+	ImageVerificationVerified ImageVerificationState = "verified"
+	// ImageVerificationFailed is the state when the image could not be
+	// resolved or its signature could not be verified
+	ImageVerificationFailed ImageVerificationState = "failed"
+)
+
+// ImageVerificationStatus defines the digest the Mattermost image is pinned
+// to.
+type ImageVerificationStatus struct {
+	// The image name, with its tag, resolved
+	// +optional
+	Image string `json:"image,omitempty"`
+	// The digest the image is pinned to
+	// +optional
+	Digest string `json:"digest,omitempty"`
+	// The cosign key Secret, with the hash of its keys, the signature was
+	// verified against
+	// +optional
+	CosignKeys string `json:"cosignKeys,omitempty"`
+	// Represents the state of the verification
+	// +optional
+	State ImageVerificationState `json:"state,omitempty"`
+	// The error reported if the verification failed
+	// +optional
+	Message string `json:"message,omitempty"`
+}
+
+// AvailableUpdateStatus defines the newer Mattermost releases available in
+// the releases feed.
+type AvailableUpdateStatus struct {
+	// The latest patch release of the running minor release
+	// +optional
+	LatestPatch string `json:"latestPatch,omitempty"`
+	// The latest Extended Support Release
+	// +optional
+	LatestESR string `json:"latestESR,omitempty"`
+	// The time when the releases feed was checked
+	// +optional
+	LastCheckTime *metav1.Time `json:"lastCheckTime,omitempty"`
+}
+
+// AutoSizingStatus defines the size computed from the active users.
+type AutoSizingStatus struct {
+	// The size applied from the active users
+	// +optional
+	Size string `json:"size,omitempty"`
+	// The monthly active users at the last check
+	// +optional
+	ActiveUsers int64 `json:"activeUsers,omitempty"`
+	// The time when the active users were last checked
+	// +optional
+	LastCheckTime *metav1.Time `json:"lastCheckTime,omitempty"`
+	// The error of the last check, if it failed
+	// +optional
+	Error string `json:"error,omitempty"`
+}
+
+// PreUpgradeBackupStatus defines the backup taken before the last upgrade.
+type PreUpgradeBackupStatus struct {
+	// The name of the MattermostBackup taking the backup
+	// +optional
+	Name string `json:"name,omitempty"`
+	// The image running before the upgrade
+	// +optional
+	FromImage string `json:"fromImage,omitempty"`
+	// The image Mattermost is upgraded to
+	// +optional
+	ToImage string `json:"toImage,omitempty"`
+	// Represents the state of the backup
+	// +optional
+	State BackupState `json:"state,omitempty"`
+	// The location of the backup in the destination bucket
+	// +optional
+	Location string `json:"location,omitempty"`
+	// The error reported if the backup failed
+	// +optional
+	Message string `json:"message,omitempty"`
+}
+
+// PostUpgradeChecksState is the state of the checks run after an upgrade.
+type PostUpgradeChecksState string
+
+const (
+	// PostUpgradeChecksRunning is the state when the checks are running
+	PostUpgradeChecksRunning PostUpgradeChecksState = "running"
+	// PostUpgradeChecksPassed is the state when the checks passed
+	PostUpgradeChecksPassed PostUpgradeChecksState = "passed"
+	// PostUpgradeChecksFailed is the state when the checks failed
+	PostUpgradeChecksFailed PostUpgradeChecksState = "failed"
+)
+
+// PostUpgradeChecksStatus defines the status of the checks run after the
+// last upgrade.
+type PostUpgradeChecksStatus struct {
+	// The image checked
+	// +optional
+	Image string `json:"image,omitempty"`
+	// The version checked
+	// +optional
+	Version string `json:"version,omitempty"`
+	// The name of the Job running the checks
+	// +optional
+	JobName string `json:"jobName,omitempty"`
+	// Represents the state of the checks
+	// +optional
+	State PostUpgradeChecksState `json:"state,omitempty"`
+	// The error reported by the Job if the checks failed
+	// +optional
+	Message string `json:"message,omitempty"`
+	// The time when the checks passed or failed
+	// +optional
+	CompletionTime *metav1.Time `json:"completionTime,omitempty"`
+}
+
+// VolumeResizeState is the state of the resize of a volume.
+type VolumeResizeState string
+
+const (
+	// VolumeResizing is the state when the volume is being expanded
+	VolumeResizing VolumeResizeState = "resizing"
+	// VolumeResizeUnsupported is the state when the StorageClass of the
+	// volume does not allow volume expansion
+	VolumeResizeUnsupported VolumeResizeState = "unsupported"
 )
 
+// VolumeResizeStatus defines the observed state of a volume resize.
+type VolumeResizeStatus struct {
+	// The name of the PersistentVolumeClaim
+	Name string `json:"name"`
+	// The storage size requested for the volume
+	// +optional
+	RequestedSize string `json:"requestedSize,omitempty"`
+	// The current storage capacity of the volume
+	// +optional
+	Capacity string `json:"capacity,omitempty"`
+	// Represents the state of the resize
+	// +optional
+	State VolumeResizeState `json:"state,omitempty"`
+}
+
+// UpgradeSnapshotsStatus defines the snapshots taken before the last upgrade.
+type UpgradeSnapshotsStatus struct {
+	// The image running before the upgrade
+	// +optional
+	FromImage string `json:"fromImage,omitempty"`
+	// The image Mattermost is upgraded to
+	// +optional
+	ToImage string `json:"toImage,omitempty"`
+	// The names of the VolumeSnapshots taken before the upgrade
+	// +optional
+	Snapshots []string `json:"snapshots,omitempty"`
+	// Indicates whether all snapshots are ready to use
+	// +optional
+	Ready bool `json:"ready,omitempty"`
+}
+
+// The components of the Mattermost reported in its status.
+const (
+	// AppComponent are the Mattermost app deployments.
+	AppComponent = "app"
+	// DatabaseComponent is the operator managed database cluster.
+	DatabaseComponent = "database"
+	// FileStoreComponent is the operator managed MinIO instance.
+	FileStoreComponent = "fileStore"
+	// IngressComponent are the ingresses of the Mattermost.
+	IngressComponent = "ingress"
+)
+
+// ComponentStatus defines the readiness of a resource managed for the
+// Mattermost.
+type ComponentStatus struct {
+	// The component the resource is part of, one of app, database,
+	// fileStore or ingress.
+	Component string `json:"component"`
+	// The kind of the resource
+	Kind string `json:"kind"`
+	// The name of the resource
+	Name string `json:"name"`
+	// Whether the resource is ready
+	Ready bool `json:"ready"`
+	// The number of ready replicas of the resource
+	// +optional
+	ReadyReplicas int32 `json:"readyReplicas,omitempty"`
+	// The number of desired replicas of the resource
+	// +optional
+	DesiredReplicas int32 `json:"desiredReplicas,omitempty"`
+	// Why the resource is not ready
+	// +optional
+	Message string `json:"message,omitempty"`
+}
+
+// ApplicationHealthStatus defines the result of the application health
+// checks of the Mattermost, run through the ping API of its service.
+type ApplicationHealthStatus struct {
+	// The time of the last check
+	// +optional
+	LastCheckTime *metav1.Time `json:"lastCheckTime,omitempty"`
+	// The time Mattermost took to respond to the last check
+	// +optional
+	Latency *metav1.Duration `json:"latency,omitempty"`
+	// The number of checks which failed in a row
+	// +optional
+	ConsecutiveFailures int32 `json:"consecutiveFailures,omitempty"`
+	// The error of the last check, if it failed
+	// +optional
+	Error string `json:"error,omitempty"`
+	// The time of the last restart of the Mattermost pods by self-healing
+	// +optional
+	LastRestartTime *metav1.Time `json:"lastRestartTime,omitempty"`
+}
+
+// LastErrorStatus defines the last error of the Mattermost, reported by the
+// Error or the Degraded condition.
+type LastErrorStatus struct {
+	// The reason of the condition reporting the error
+	// +optional
+	Reason string `json:"reason,omitempty"`
+	// The message of the error
+	// +optional
+	Message string `json:"message,omitempty"`
+	// The time when the error first occurred
+	// +optional
+	Time *metav1.Time `json:"time,omitempty"`
+}
+
 // MattermostStatus defines the observed state of Mattermost
 type MattermostStatus struct {
-	// Represents the running state of the Mattermost instance
+	// Represents the running state of the Mattermost instance, also
+	// reported by the Ready and Progressing conditions.
 	// +optional
 	State RunningState `json:"state,omitempty"`
 	// The version currently running in the Mattermost instance
@@ -316,9 +1866,102 @@ type MattermostStatus struct {
 	// that are running with the desired image.
 	// +optional
 	UpdatedReplicas int32 `json:"updatedReplicas,omitempty"`
+	// The label selector of the Mattermost pods, used by the scale
+	// subresource.
+	// +optional
+	Selector string `json:"selector,omitempty"`
+	// The number of pods the Mattermost deployment should run.
+	// +optional
+	DesiredReplicas int32 `json:"desiredReplicas,omitempty"`
+	// The readiness of the app deployments, the operator managed database
+	// and file store, and the ingresses of the Mattermost.
+	// +optional
+	// +listType=map
+	// +listMapKey=kind
+	// +listMapKey=name
+	Components []ComponentStatus `json:"components,omitempty"`
+	// The last error of the Mattermost, cleared once it is stable again.
+	// +optional
+	LastError *LastErrorStatus `json:"lastError,omitempty"`
 	// The last observed Generation of the Mattermost resource that was acted on.
 	// +optional
 	ObservedGeneration int64 `json:"observedGeneration,omitempty"`
+	// The state of the last file store migration.
+	// +optional
+	FileStoreMigration *FileStoreMigrationStatus `json:"fileStoreMigration,omitempty"`
+	// The volumes of the operator managed file store and database which are
+	// not yet expanded to the requested storage size.
+	// +optional
+	VolumeResizes []VolumeResizeStatus `json:"volumeResizes,omitempty"`
+	// The VolumeSnapshots taken before the last upgrade, they can be used
+	// to restore the volumes if the upgrade has to be rolled back.
+	// +optional
+	UpgradeSnapshots *UpgradeSnapshotsStatus `json:"upgradeSnapshots,omitempty"`
+	// The state of the last workspace export.
+	// +optional
+	Export *ExportStatus `json:"export,omitempty"`
+	// The state of the last workspace import.
+	// +optional
+	Import *ImportStatus `json:"import,omitempty"`
+	// The state of the relocation to another namespace.
+	// +optional
+	Relocation *RelocationStatus `json:"relocation,omitempty"`
+	// The state of the maintenance mode
+	// +optional
+	MaintenanceMode *MaintenanceModeStatus `json:"maintenanceMode,omitempty"`
+	// The name of the blue deployment in BlueGreen
+	// +optional
+	BlueName string `json:"blueName,omitempty"`
+	// The name of the green deployment in BlueGreen
+	// +optional
+	GreenName string `json:"greenName,omitempty"`
+	// The name of the canary deployment
+	// +optional
+	CanaryName string `json:"canaryName,omitempty"`
+	// The last upgrade of the Mattermost image tracked for automatic
+	// rollback, or for the limit of concurrent upgrades.
+	// +optional
+	Upgrade *UpgradeStatus `json:"upgrade,omitempty"`
+	// The change of the Mattermost deployment queued until the next
+	// maintenance window, or until an upgrade slot is free.
+	// +optional
+	PendingUpdate *PendingUpdateStatus `json:"pendingUpdate,omitempty"`
+	// The last upgrade found in the release channel of the update policy.
+	// +optional
+	ChannelUpdate *ChannelUpdateStatus `json:"channelUpdate,omitempty"`
+	// The newer Mattermost releases available in the releases feed
+	// configured for the operator.
+	// +optional
+	AvailableUpdate *AvailableUpdateStatus `json:"availableUpdate,omitempty"`
+	// The size computed from the active users of the Mattermost.
+	// +optional
+	AutoSizing *AutoSizingStatus `json:"autoSizing,omitempty"`
+	// The digest the Mattermost image is pinned to.
+	// +optional
+	ImageVerification *ImageVerificationStatus `json:"imageVerification,omitempty"`
+	// The backup taken before the last upgrade, it can be restored if the
+	// upgrade has to be rolled back.
+	// +optional
+	PreUpgradeBackup *PreUpgradeBackupStatus `json:"preUpgradeBackup,omitempty"`
+	// The result of the checks run after the last upgrade.
+	// +optional
+	PostUpgradeChecks *PostUpgradeChecksStatus `json:"postUpgradeChecks,omitempty"`
+	// The result of the last application health check, which checks that
+	// Mattermost reaches its database and file store.
+	// +optional
+	ApplicationHealth *ApplicationHealthStatus `json:"applicationHealth,omitempty"`
+	// The revision of the revision history the Mattermost was last
+	// successfully reconciled with.
+	// +optional
+	Revision int64 `json:"revision,omitempty"`
+	// Represents the latest available observations of the Mattermost state,
+	// including the Ready, Progressing, Degraded, Error, DatabaseReachable,
+	// FileStoreReachable, HealthDegraded, CrashLoopBackOff, ImagePullBackOff
+	// and Unschedulable conditions.
+	// +optional
+	// +listType=map
+	// +listMapKey=type
+	Conditions []metav1.Condition `json:"conditions,omitempty"`
 }
 
 // +genclient
@@ -328,10 +1971,17 @@ type MattermostStatus struct {
 // +kubebuilder:object:root=true
 // +kubebuilder:resource:shortName="mm"
 // +kubebuilder:subresource:status
+// +kubebuilder:subresource:scale:specpath=.spec.replicas,statuspath=.status.replicas,selectorpath=.status.selector
 // +kubebuilder:printcolumn:priority=0,name="State",type=string,JSONPath=".status.state",description="State of Mattermost"
 // +kubebuilder:printcolumn:priority=0,name="Image",type=string,JSONPath=".status.image",description="Image of Mattermost"
 // +kubebuilder:printcolumn:priority=0,name="Version",type=string,JSONPath=".status.version",description="Version of Mattermost"
 // +kubebuilder:printcolumn:priority=0,name="Endpoint",type=string,JSONPath=".status.endpoint",description="Endpoint"
+// +kubebuilder:printcolumn:priority=0,name="Ready",type=integer,JSONPath=".status.updatedReplicas",description="Ready pods running the desired image"
+// +kubebuilder:printcolumn:priority=0,name="Desired",type=integer,JSONPath=".status.desiredReplicas",description="Desired pods"
+// +kubebuilder:printcolumn:priority=0,name="Last Error",type=string,JSONPath=".status.lastError.reason",description="Reason of the last error"
+// +kubebuilder:printcolumn:priority=0,name="Error Age",type=date,JSONPath=".status.lastError.time",description="Time of the last error"
+// +kubebuilder:printcolumn:priority=1,name="Error Message",type=string,JSONPath=".status.lastError.message",description="Message of the last error"
+// +kubebuilder:printcolumn:priority=0,name="Age",type=date,JSONPath=".metadata.creationTimestamp"
 type Mattermost struct {
 	metav1.TypeMeta   `json:",inline"`
 	metav1.ObjectMeta `json:"metadata,omitempty"`
@@ -6,11 +6,14 @@ package v1beta1
 import (
 	"fmt"
 	"strings"
+	"time"
 
+	"github.com/mattermost/mattermost-operator/pkg/utils"
 	"github.com/pkg/errors"
 
 	appsv1 "k8s.io/api/apps/v1"
 	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
 )
 
 const (
@@ -18,6 +21,12 @@ const (
 	OperatorName = "mattermost-operator"
 	// DefaultMattermostImage is the default Mattermost docker image
 	DefaultMattermostImage = "mattermost/mattermost-enterprise-edition"
+	// DefaultMattermostTeamImage is the default Mattermost docker image of
+	// the team edition
+	DefaultMattermostTeamImage = "mattermost/mattermost-team-edition"
+	// DefaultMattermostFIPSImage is the default Mattermost docker image of
+	// the fips image variant
+	DefaultMattermostFIPSImage = "mattermost/mattermost-enterprise-fips-image"
 	// DefaultMattermostVersion is the default Mattermost docker tag
 	DefaultMattermostVersion = "5.37.1"
 	// DefaultMattermostSize is the default number of users
@@ -30,6 +39,77 @@ const (
 	DefaultStorageSize = "50Gi"
 	// DefaultPullPolicy is the default Pull Policy used by Mattermost app container
 	DefaultPullPolicy = corev1.PullIfNotPresent
+	// DefaultUpgradeProgressDeadline is the default time the pods running a
+	// new Mattermost image have to pass the health checks before the upgrade
+	// is rolled back
+	DefaultUpgradeProgressDeadline = 10 * time.Minute
+	// DefaultMaintenanceWindowTimeZone is the default time zone of the
+	// maintenance window schedule
+	DefaultMaintenanceWindowTimeZone = "UTC"
+	// DefaultPostUpgradeChecksImage is the default image of the Job running
+	// the checks after an upgrade
+	DefaultPostUpgradeChecksImage = "appropriate/curl:latest"
+	// DefaultCurlImage is the default image of the init containers waiting
+	// for MySQL and MinIO
+	DefaultCurlImage = "appropriate/curl:latest"
+	// DefaultPostgresImage is the default image of the init container
+	// waiting for an external PostgreSQL database
+	DefaultPostgresImage = "postgres:13"
+	// DefaultMinioClientImage is the default image of the containers using
+	// the MinIO client
+	DefaultMinioClientImage = "minio/mc:RELEASE.2021-06-13T17-48-22Z"
+	// DefaultUtilityCPURequest is the default CPU request of the init
+	// containers and of the job containers not running Mattermost
+	DefaultUtilityCPURequest = "50m"
+	// DefaultUtilityMemoryRequest is the default memory request of the init
+	// containers and of the job containers not running Mattermost
+	DefaultUtilityMemoryRequest = "64Mi"
+	// DefaultUtilityCPULimit is the default CPU limit of the init containers
+	// and of the job containers not running Mattermost
+	DefaultUtilityCPULimit = "500m"
+	// DefaultUtilityMemoryLimit is the default memory limit of the init
+	// containers and of the job containers not running Mattermost
+	DefaultUtilityMemoryLimit = "512Mi"
+	// DefaultAutoSizingMinSize is the default smallest size applied by
+	// auto-sizing
+	DefaultAutoSizingMinSize = "100users"
+	// DefaultAutoSizingMaxSize is the default largest size applied by
+	// auto-sizing
+	DefaultAutoSizingMaxSize = "25000users"
+	// DefaultAutoSizingScaleUpThreshold is the default percentage of the
+	// users of the size above which auto-sizing applies a larger size
+	DefaultAutoSizingScaleUpThreshold = 80
+	// DefaultAutoSizingScaleDownThreshold is the default percentage of the
+	// users of the size below which auto-sizing applies a smaller size
+	DefaultAutoSizingScaleDownThreshold = 40
+	// DefaultAutoSizingCheckInterval is the default interval the active
+	// users are checked at
+	DefaultAutoSizingCheckInterval = time.Hour
+	// DefaultSelfHealingFailureThreshold is the default number of failed
+	// application health checks in a row restarting the Mattermost pods
+	DefaultSelfHealingFailureThreshold = 3
+	// DefaultSelfHealingCooloff is the default minimum time between two
+	// restarts of the Mattermost pods by self-healing
+	DefaultSelfHealingCooloff = 30 * time.Minute
+	// DefaultAlertsReplicasUnavailableFor is the default time replicas have
+	// to be unavailable before alerting
+	DefaultAlertsReplicasUnavailableFor = 10 * time.Minute
+	// DefaultAlertsHealthCheckFailures is the default number of application
+	// health checks failing within 15 minutes before alerting
+	DefaultAlertsHealthCheckFailures = 3
+	// DefaultAlertsDatabaseConnectionsPercent is the default percentage of
+	// the maximum connections of the database above which its connections
+	// are saturated
+	DefaultAlertsDatabaseConnectionsPercent = 80
+	// DefaultTrustedCABundleKey is the default key of the CA bundle in the
+	// trusted CA bundle ConfigMap
+	DefaultTrustedCABundleKey = "ca-bundle.crt"
+	// DefaultIngressPath is the default path of the Ingress rule routing the
+	// requests to Mattermost
+	DefaultIngressPath = "/"
+	// DefaultMaintenanceMessage is the default text of the announcement
+	// banner shown in maintenance mode
+	DefaultMaintenanceMessage = "Mattermost is under maintenance, some features may be unavailable."
 
 	// ClusterLabel is the label applied across all components
 	ClusterLabel = "installation.mattermost.com/installation"
@@ -38,9 +118,49 @@ const (
 	// as well as all other resources created to support it.
 	ClusterResourceLabel = "installation.mattermost.com/resource"
 
+	// DeletionProtectedAnnotation protects a Mattermost from deletion when
+	// set to "true", ie for production installations.
+	DeletionProtectedAnnotation = "mattermost.com/deletion-protected"
+	// DeletionProtectionFinalizer holds the deletion of the protected
+	// Mattermosts, and of their resources, until their protection is removed.
+	DeletionProtectionFinalizer = "installation.mattermost.com/deletion-protection"
+	// AdoptResourcesAnnotation makes a Mattermost adopt the existing
+	// resources with the names of its resources when set to "true", ie of a
+	// hand-managed installation migrated under the management of the
+	// operator.
+	AdoptResourcesAnnotation = "mattermost.com/adopt-resources"
+	// AdoptedAnnotation marks the resources adopted by a Mattermost.
+	AdoptedAnnotation = "installation.mattermost.com/adopted"
+	// OrphanResourcesFinalizer holds the deletion of the Mattermosts with
+	// the Orphan deletion policy until their data resources are orphaned.
+	OrphanResourcesFinalizer = "installation.mattermost.com/orphan-resources"
+	// OrphanedAnnotation marks the resources of a Mattermost orphaned on
+	// purpose, ie by its deletion policy, which are kept by the sweep of
+	// the orphaned resources.
+	OrphanedAnnotation = "installation.mattermost.com/orphaned"
+	// RelocatedFromAnnotation marks the Mattermost created in another
+	// namespace by the relocation of a Mattermost, and the Secrets and
+	// ConfigMaps copied for it, with the namespace and name of the
+	// relocated Mattermost.
+	RelocatedFromAnnotation = "installation.mattermost.com/relocated-from"
+	// StoreDefaultsAnnotation keeps the defaults of a Mattermost out of its
+	// stored spec when set to "false", ie for Mattermosts synced by GitOps
+	// tools which would report the defaulted spec as out of sync. The
+	// defaults are then only applied when reconciling it.
+	StoreDefaultsAnnotation = "mattermost.com/store-defaults"
+
 	// MattermostAppContainerName is the name of the container which runs the
 	// Mattermost application
 	MattermostAppContainerName = "mattermost"
+
+	// BlueName is the name of the blue Mattermost deployment in a blue/green
+	// configuration.
+	BlueName = "blue"
+	// GreenName is the name of the green Mattermost deployment in a
+	// blue/green configuration.
+	GreenName = "green"
+	// CanaryName is the name of the canary Mattermost deployment.
+	CanaryName = "canary"
 )
 
 // SetDefaults set the missing values in the manifest to the default ones
@@ -48,8 +168,20 @@ func (mm *Mattermost) SetDefaults() error {
 	if mm.IngressEnabled() && mm.GetIngressHost() == "" {
 		return errors.New("ingress.host required, but not set")
 	}
-	if mm.Spec.Image == "" {
-		mm.Spec.Image = DefaultMattermostImage
+	if err := mm.validateIngress(); err != nil {
+		return err
+	}
+	if err := mm.validateEdition(); err != nil {
+		return err
+	}
+	if err := mm.validateClustering(); err != nil {
+		return err
+	}
+	if err := mm.validateStandby(); err != nil {
+		return err
+	}
+	if mm.Spec.Image == "" || isDefaultMattermostImage(mm.Spec.Image) {
+		mm.Spec.Image = mm.GetDefaultImage()
 	}
 	if mm.Spec.Version == "" {
 		mm.Spec.Version = DefaultMattermostVersion
@@ -60,10 +192,432 @@ func (mm *Mattermost) SetDefaults() error {
 
 	mm.Spec.FileStore.SetDefaults()
 	mm.Spec.Database.SetDefaults()
+	if mm.Spec.TrustedCABundle != nil && mm.Spec.TrustedCABundle.Key == "" {
+		mm.Spec.TrustedCABundle.Key = DefaultTrustedCABundleKey
+	}
+
+	if mm.Spec.BlueGreen != nil {
+		err := mm.Spec.BlueGreen.SetDefaults(mm)
+		if err != nil {
+			return err
+		}
+	}
+	if mm.Spec.Canary != nil {
+		err := mm.Spec.Canary.SetDefaults(mm)
+		if err != nil {
+			return err
+		}
+	}
+	if mm.Spec.UpdatePolicy != nil {
+		err := mm.Spec.UpdatePolicy.SetDefaults()
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// SetDefaults sets the missing values in BlueGreen to the default ones.
+func (bg *BlueGreen) SetDefaults(mm *Mattermost) error {
+	if !bg.Enabled {
+		return nil
+	}
+	if bg.ProductionDeployment != BlueName && bg.ProductionDeployment != GreenName {
+		return fmt.Errorf("%s is not a valid blueGreen.productionDeployment value, must be 'blue' or 'green'", bg.ProductionDeployment)
+	}
+	if bg.Blue.Version == "" || bg.Green.Version == "" {
+		return errors.New("blueGreen.blue.version and blueGreen.green.version required, but not set")
+	}
+
+	if bg.Blue.Image == "" || isDefaultMattermostImage(bg.Blue.Image) {
+		bg.Blue.Image = mm.Spec.Image
+	}
+	if bg.Green.Image == "" || isDefaultMattermostImage(bg.Green.Image) {
+		bg.Green.Image = mm.Spec.Image
+	}
+	if bg.Blue.Name == "" {
+		bg.Blue.Name = fmt.Sprintf("%s-%s", mm.Name, BlueName)
+	}
+	if bg.Green.Name == "" {
+		bg.Green.Name = fmt.Sprintf("%s-%s", mm.Name, GreenName)
+	}
+	if bg.Blue.Name == bg.Green.Name || bg.Blue.Name == mm.Name || bg.Green.Name == mm.Name {
+		return errors.New("blueGreen.blue.name and blueGreen.green.name must differ from each other and from the Mattermost name")
+	}
+
+	host := mm.GetIngressHost()
+	if host != "" {
+		if bg.Blue.IngressHost == "" {
+			bg.Blue.IngressHost = fmt.Sprintf("%s.%s", BlueName, host)
+		}
+		if bg.Green.IngressHost == "" {
+			bg.Green.IngressHost = fmt.Sprintf("%s.%s", GreenName, host)
+		}
+	}
 
 	return nil
 }
 
+// SetDefaults sets the missing values in Canary to the default ones.
+func (c *Canary) SetDefaults(mm *Mattermost) error {
+	if !c.Enabled {
+		return nil
+	}
+	if mm.BlueGreenEnabled() {
+		return errors.New("canary and blueGreen cannot be enabled at the same time")
+	}
+	if mm.Spec.UseServiceLoadBalancer || !mm.IngressEnabled() {
+		return errors.New("canary requires the ingress to be enabled")
+	}
+	if c.Deployment.Version == "" {
+		return errors.New("canary.deployment.version required, but not set")
+	}
+
+	if c.Deployment.Image == "" || isDefaultMattermostImage(c.Deployment.Image) {
+		c.Deployment.Image = mm.Spec.Image
+	}
+	if c.Deployment.Name == "" {
+		c.Deployment.Name = fmt.Sprintf("%s-%s", mm.Name, CanaryName)
+	}
+	if c.Deployment.Name == mm.Name {
+		return errors.New("canary.deployment.name must differ from the Mattermost name")
+	}
+
+	return nil
+}
+
+// SetDefaults sets the missing values in UpdatePolicy to the default ones.
+func (p *UpdatePolicy) SetDefaults() error {
+	if p.Channel != "" && p.Approval == "" {
+		p.Approval = UpdateApprovalAutomatic
+	}
+	if p.BackupBeforeUpgrade && p.BackupDestination == nil {
+		return errors.New("updatePolicy.backupDestination required, but not set")
+	}
+	if p.PostUpgradeChecks != nil {
+		err := p.PostUpgradeChecks.SetDefaults()
+		if err != nil {
+			return err
+		}
+	}
+	if p.Window != nil {
+		return p.Window.SetDefaults()
+	}
+	return nil
+}
+
+// SetDefaults sets the missing values in PostUpgradeChecks to the default
+// ones.
+func (c *PostUpgradeChecks) SetDefaults() error {
+	if !c.Enabled {
+		return nil
+	}
+	if c.HealthChannel != "" {
+		if c.ProbeAccountSecret == "" {
+			return errors.New("updatePolicy.postUpgradeChecks.probeAccountSecret required to post to the health channel, but not set")
+		}
+		if len(strings.Split(c.HealthChannel, "/")) != 2 {
+			return fmt.Errorf("%s is not a valid updatePolicy.postUpgradeChecks.healthChannel value, must be 'team-name/channel-name'", c.HealthChannel)
+		}
+	}
+	if c.Image == "" {
+		c.Image = DefaultPostUpgradeChecksImage
+	}
+	return nil
+}
+
+// SetDefaults sets the missing values in MaintenanceWindow to the default
+// ones and validates the schedule.
+func (w *MaintenanceWindow) SetDefaults() error {
+	if w.TimeZone == "" {
+		w.TimeZone = DefaultMaintenanceWindowTimeZone
+	}
+	_, _, err := w.Parse()
+	return err
+}
+
+// Parse returns the cron schedule and the time zone of the maintenance
+// window.
+func (w *MaintenanceWindow) Parse() (*utils.CronSchedule, *time.Location, error) {
+	schedule, err := utils.ParseCronSchedule(w.Schedule)
+	if err != nil {
+		return nil, nil, errors.Wrap(err, "invalid updatePolicy.window.schedule")
+	}
+	location, err := time.LoadLocation(w.TimeZone)
+	if err != nil {
+		return nil, nil, errors.Wrap(err, "invalid updatePolicy.window.timeZone")
+	}
+	return schedule, location, nil
+}
+
+// Next returns whether the time is within the maintenance window and when
+// the next window opens. The next window is the zero time if the schedule
+// never matches.
+func (w *MaintenanceWindow) Next(t time.Time) (bool, time.Time, error) {
+	schedule, location, err := w.Parse()
+	if err != nil {
+		return false, time.Time{}, err
+	}
+	t = t.In(location)
+	return schedule.Matches(t), schedule.Next(t), nil
+}
+
+// DeletionProtected determines whether the Mattermost is protected from
+// deletion by its deletion protected annotation.
+func (mm *Mattermost) DeletionProtected() bool {
+	return mm.Annotations[DeletionProtectedAnnotation] == "true"
+}
+
+// StoreDefaults determines whether the defaults of the Mattermost are stored
+// in its spec, unless disabled by its store defaults annotation.
+func (mm *Mattermost) StoreDefaults() bool {
+	return mm.Annotations[StoreDefaultsAnnotation] != "false"
+}
+
+// StandbyEnabled determines whether the Mattermost is a standby not promoted
+// yet, whose deployment is scaled to zero and ingress is not created.
+func (mm *Mattermost) StandbyEnabled() bool {
+	return mm.Spec.Standby != nil && !mm.Spec.Standby.Promoted
+}
+
+// MaintenanceModeEnabled determines whether the Mattermost is in maintenance
+// mode.
+func (mm *Mattermost) MaintenanceModeEnabled() bool {
+	return mm.Spec.MaintenanceMode != nil && mm.Spec.MaintenanceMode.Enabled
+}
+
+// GetMessage returns the text of the announcement banner of the maintenance
+// mode.
+func (m *MaintenanceMode) GetMessage() string {
+	if m.Message == "" {
+		return DefaultMaintenanceMessage
+	}
+	return m.Message
+}
+
+// AdoptsResources determines whether the Mattermost takes the ownership of
+// the existing resources with the names of its resources.
+func (mm *Mattermost) AdoptsResources() bool {
+	return mm.Annotations[AdoptResourcesAnnotation] == "true"
+}
+
+// IgnoredFields returns the JSONPaths of the fields of the generated
+// resources whose drift is not corrected.
+func (mm *Mattermost) IgnoredFields() []string {
+	if mm.Spec.ReconcilePolicy == nil {
+		return nil
+	}
+	return mm.Spec.ReconcilePolicy.IgnoreFields
+}
+
+// MaintenanceWindowEnabled determines whether changes restarting the
+// Mattermost pods should be queued until the maintenance window. It does
+// not apply to BlueGreen deployments, which are switched explicitly.
+func (mm *Mattermost) MaintenanceWindowEnabled() bool {
+	return mm.Spec.UpdatePolicy != nil && mm.Spec.UpdatePolicy.Window != nil && !mm.BlueGreenEnabled()
+}
+
+// UpdateChannelEnabled determines whether the Mattermost version should be
+// upgraded automatically in a release channel. It does not apply to
+// BlueGreen deployments.
+func (mm *Mattermost) UpdateChannelEnabled() bool {
+	return mm.Spec.UpdatePolicy != nil && mm.Spec.UpdatePolicy.Channel != "" && !mm.BlueGreenEnabled()
+}
+
+// BackupBeforeUpgradeEnabled determines whether the Mattermost installation
+// should be backed up before a new image is rolled out. It does not apply to
+// BlueGreen deployments.
+func (mm *Mattermost) BackupBeforeUpgradeEnabled() bool {
+	return mm.Spec.UpdatePolicy != nil && mm.Spec.UpdatePolicy.BackupBeforeUpgrade && !mm.BlueGreenEnabled()
+}
+
+// PostUpgradeChecksEnabled determines whether the checks should run after a
+// new image is rolled out. It does not apply to BlueGreen deployments.
+func (mm *Mattermost) PostUpgradeChecksEnabled() bool {
+	return mm.Spec.UpdatePolicy != nil &&
+		mm.Spec.UpdatePolicy.PostUpgradeChecks != nil &&
+		mm.Spec.UpdatePolicy.PostUpgradeChecks.Enabled &&
+		!mm.BlueGreenEnabled()
+}
+
+// ImageVerificationEnabled determines whether the Mattermost image should
+// be pinned to its digest. It does not apply to BlueGreen deployments.
+func (mm *Mattermost) ImageVerificationEnabled() bool {
+	return mm.Spec.ImageVerification != nil && mm.Spec.ImageVerification.Enabled && !mm.BlueGreenEnabled()
+}
+
+// ECRCredentialsEnabled determines whether the Operator should refresh the
+// image pull Secret of the ECR registry.
+func (mm *Mattermost) ECRCredentialsEnabled() bool {
+	return mm.Spec.ECRCredentials != nil && mm.Spec.ECRCredentials.Enabled
+}
+
+// ECRPullSecretName returns the name of the image pull Secret of the ECR
+// registry refreshed by the Operator.
+func (mm *Mattermost) ECRPullSecretName() string {
+	return fmt.Sprintf("%s-ecr-credentials", mm.Name)
+}
+
+// GetImagePullSecrets returns the image pull Secrets of the pods created for
+// the Mattermost, including the ECR image pull Secret if enabled.
+func (mm *Mattermost) GetImagePullSecrets() []corev1.LocalObjectReference {
+	if !mm.ECRCredentialsEnabled() {
+		return mm.Spec.ImagePullSecrets
+	}
+	pullSecrets := append([]corev1.LocalObjectReference{}, mm.Spec.ImagePullSecrets...)
+	return append(pullSecrets, corev1.LocalObjectReference{Name: mm.ECRPullSecretName()})
+}
+
+// AutoSizingEnabled determines whether the Mattermost is sized from its
+// active users.
+func (mm *Mattermost) AutoSizingEnabled() bool {
+	return mm.Spec.AutoSizing != nil && mm.Spec.AutoSizing.Enabled
+}
+
+// GetMinSize returns the smallest size applied by auto-sizing.
+func (a *AutoSizing) GetMinSize() string {
+	if a.MinSize == "" {
+		return DefaultAutoSizingMinSize
+	}
+	return a.MinSize
+}
+
+// GetMaxSize returns the largest size applied by auto-sizing.
+func (a *AutoSizing) GetMaxSize() string {
+	if a.MaxSize == "" {
+		return DefaultAutoSizingMaxSize
+	}
+	return a.MaxSize
+}
+
+// GetScaleUpThreshold returns the percentage of the users of the size above
+// which a larger size is applied.
+func (a *AutoSizing) GetScaleUpThreshold() int32 {
+	if a.ScaleUpThreshold == 0 {
+		return DefaultAutoSizingScaleUpThreshold
+	}
+	return a.ScaleUpThreshold
+}
+
+// GetScaleDownThreshold returns the percentage of the users of the size
+// below which a smaller size is applied.
+func (a *AutoSizing) GetScaleDownThreshold() int32 {
+	if a.ScaleDownThreshold == 0 {
+		return DefaultAutoSizingScaleDownThreshold
+	}
+	return a.ScaleDownThreshold
+}
+
+// GetCheckInterval returns how often the active users are checked.
+func (a *AutoSizing) GetCheckInterval() time.Duration {
+	if a.CheckInterval == nil || a.CheckInterval.Duration <= 0 {
+		return DefaultAutoSizingCheckInterval
+	}
+	return a.CheckInterval.Duration
+}
+
+// CanaryEnabled determines whether the canary deployment should be created.
+func (mm *Mattermost) CanaryEnabled() bool {
+	return mm.Spec.Canary != nil && mm.Spec.Canary.Enabled
+}
+
+// UpgradeRollbackEnabled determines whether failed upgrades of the Mattermost
+// deployment should be rolled back.
+func (mm *Mattermost) UpgradeRollbackEnabled() bool {
+	return mm.Spec.UpgradeRollback != nil && mm.Spec.UpgradeRollback.Enabled && !mm.BlueGreenEnabled()
+}
+
+// GetUpgradeProgressDeadline returns the time the pods running a new
+// Mattermost image have to pass the health checks.
+func (mm *Mattermost) GetUpgradeProgressDeadline() time.Duration {
+	if mm.Spec.UpgradeRollback == nil || mm.Spec.UpgradeRollback.ProgressDeadline == nil {
+		return DefaultUpgradeProgressDeadline
+	}
+	return mm.Spec.UpgradeRollback.ProgressDeadline.Duration
+}
+
+// MonitoringSupported returns true if the license can enable performance
+// monitoring, which requires the Enterprise Edition and a license.
+func (mm *Mattermost) MonitoringSupported() bool {
+	return !mm.TeamEdition() && mm.Spec.LicenseSecret != ""
+}
+
+// MonitoringEnabled determines whether the metrics of the Mattermost and
+// of its operator managed database and file store should be scraped.
+func (mm *Mattermost) MonitoringEnabled() bool {
+	return mm.Spec.Monitoring != nil && mm.Spec.Monitoring.Enabled
+}
+
+// AppMonitoringEnabled determines whether a ServiceMonitor should be
+// generated for the metrics endpoint of Mattermost, which requires the
+// license to enable performance monitoring.
+func (mm *Mattermost) AppMonitoringEnabled() bool {
+	return mm.MonitoringEnabled() && mm.MonitoringSupported()
+}
+
+// SelfHealingEnabled determines whether the Mattermost pods should be
+// restarted when the application health checks fail repeatedly.
+func (mm *Mattermost) SelfHealingEnabled() bool {
+	return mm.Spec.SelfHealing != nil && mm.Spec.SelfHealing.Enabled
+}
+
+// GetFailureThreshold returns how many application health checks have to
+// fail in a row before the pods are restarted.
+func (s *SelfHealing) GetFailureThreshold() int32 {
+	if s.FailureThreshold <= 0 {
+		return DefaultSelfHealingFailureThreshold
+	}
+	return s.FailureThreshold
+}
+
+// GetCooloff returns the minimum time between two restarts.
+func (s *SelfHealing) GetCooloff() time.Duration {
+	if s.Cooloff == nil || s.Cooloff.Duration <= 0 {
+		return DefaultSelfHealingCooloff
+	}
+	return s.Cooloff.Duration
+}
+
+// AlertsEnabled determines whether a PrometheusRule should be generated with
+// the alerts of the Mattermost.
+func (mm *Mattermost) AlertsEnabled() bool {
+	return mm.MonitoringEnabled() && mm.Spec.Monitoring.Alerts != nil && mm.Spec.Monitoring.Alerts.Enabled
+}
+
+// GetReplicasUnavailableFor returns how long replicas have to be unavailable
+// before alerting.
+func (a *MonitoringAlerts) GetReplicasUnavailableFor() time.Duration {
+	if a.ReplicasUnavailableFor == nil || a.ReplicasUnavailableFor.Duration <= 0 {
+		return DefaultAlertsReplicasUnavailableFor
+	}
+	return a.ReplicasUnavailableFor.Duration
+}
+
+// GetHealthCheckFailures returns how many application health checks have to
+// fail within 15 minutes before alerting.
+func (a *MonitoringAlerts) GetHealthCheckFailures() int32 {
+	if a.HealthCheckFailures <= 0 {
+		return DefaultAlertsHealthCheckFailures
+	}
+	return a.HealthCheckFailures
+}
+
+// GetDatabaseConnectionsPercent returns the percentage of the maximum
+// connections of the database above which its connections are saturated.
+func (a *MonitoringAlerts) GetDatabaseConnectionsPercent() int32 {
+	if a.DatabaseConnectionsPercent <= 0 {
+		return DefaultAlertsDatabaseConnectionsPercent
+	}
+	return a.DatabaseConnectionsPercent
+}
+
+// BlueGreenEnabled determines whether the blue and green deployments should
+// be created instead of the Mattermost deployment.
+func (mm *Mattermost) BlueGreenEnabled() bool {
+	return mm.Spec.BlueGreen != nil && mm.Spec.BlueGreen.Enabled
+}
+
 // IngressEnabled determines whether Mattermost Ingress should be created.
 func (mm *Mattermost) IngressEnabled() bool {
 	if mm.Spec.Ingress != nil {
@@ -99,6 +653,31 @@ func (mm *Mattermost) GetIngressTLSSecret() string {
 	return ""
 }
 
+// GetIngressPath returns the path of the Mattermost Ingress rule.
+func (mm *Mattermost) GetIngressPath() string {
+	if mm.Spec.Ingress == nil || mm.Spec.Ingress.Path == "" {
+		return DefaultIngressPath
+	}
+	return mm.Spec.Ingress.Path
+}
+
+// GetIngressPathType returns the path type of the Mattermost Ingress rule.
+func (mm *Mattermost) GetIngressPathType() IngressPathType {
+	if mm.Spec.Ingress == nil || mm.Spec.Ingress.PathType == "" {
+		return IngressPathTypeImplementationSpecific
+	}
+	return mm.Spec.Ingress.PathType
+}
+
+// GetIngressExtraPaths returns the additional paths of the Mattermost Ingress
+// rule.
+func (mm *Mattermost) GetIngressExtraPaths() []IngressPath {
+	if mm.Spec.Ingress == nil {
+		return nil
+	}
+	return mm.Spec.Ingress.ExtraPaths
+}
+
 func defaultTLSSecret(mm *Mattermost) string {
 	return strings.ReplaceAll(mm.GetIngressHost(), ".", "-") + "-tls-cert"
 }
@@ -139,17 +718,374 @@ func (mm *Mattermost) GetImageName() string {
 	// sha256:dd15a51ac7dafd213744d1ef23394e7532f71a90f477c969b94600e46da5a0cf
 	// we need to set the @ instead of : to split the image name and "tag"
 	if strings.Contains(mm.Spec.Version, "sha256:") {
-		return fmt.Sprintf("%s@%s", mm.Spec.Image, mm.Spec.Version)
+		return fmt.Sprintf("%s@%s", mm.GetImage(), mm.Spec.Version)
+	}
+	imageName := fmt.Sprintf("%s:%s", mm.GetImage(), mm.GetImageTag(mm.Spec.Version))
+	if digest := mm.PinnedImageDigest(imageName); digest != "" {
+		return fmt.Sprintf("%s@%s", mm.GetImage(), digest)
+	}
+	return imageName
+}
+
+// imageVariant defines the image of a Mattermost image variant.
+type imageVariant struct {
+	// image is the default Mattermost image of the variant, the default
+	// image of the edition if empty.
+	image string
+	// tagSuffix is appended to the Mattermost version in the image tags.
+	tagSuffix string
+	// command is the entrypoint of the Mattermost binary in the image.
+	command []string
+}
+
+var imageVariants = map[ImageVariant]imageVariant{
+	ImageVariantFIPS: {
+		image:   DefaultMattermostFIPSImage,
+		command: []string{"mattermost"},
+	},
+	ImageVariantUBI: {
+		tagSuffix: "-ubi",
+		// The Mattermost binary is not in the PATH of the UBI image.
+		command: []string{"/opt/mattermost/bin/mattermost"},
+	},
+}
+
+// isDefaultMattermostImage returns true if the image is the default image of
+// Mattermost or of one of its variants.
+func isDefaultMattermostImage(image string) bool {
+	if image == DefaultMattermostImage || image == DefaultMattermostTeamImage {
+		return true
+	}
+	for _, variant := range imageVariants {
+		if image == variant.image {
+			return true
+		}
+	}
+	return false
+}
+
+// GetDefaultImage returns the default Mattermost image of the edition and
+// the image variant.
+func (mm *Mattermost) GetDefaultImage() string {
+	if variant := imageVariants[mm.Spec.ImageVariant]; variant.image != "" {
+		return variant.image
+	}
+	if mm.TeamEdition() {
+		return DefaultMattermostTeamImage
+	}
+	return DefaultMattermostImage
+}
+
+// TeamEdition returns true if Mattermost runs the Team Edition.
+func (mm *Mattermost) TeamEdition() bool {
+	return mm.Spec.Edition == EditionTeam
+}
+
+// GetUpdateCheckJob returns the settings of the update check job, nil if not
+// set.
+func (mm *Mattermost) GetUpdateCheckJob() *UpdateCheckJob {
+	if mm.Spec.Jobs == nil {
+		return nil
+	}
+	return mm.Spec.Jobs.UpdateCheck
+}
+
+// UpdateCheckDisabled returns true if the app servers are updated to a new
+// image without running the update check job first.
+func (mm *Mattermost) UpdateCheckDisabled() bool {
+	if mm.InPlaceUpdateEnabled() {
+		return true
+	}
+	updateCheck := mm.GetUpdateCheckJob()
+	return updateCheck != nil && updateCheck.Disabled
+}
+
+// InPlaceUpdateEnabled returns true if new images are rolled out in place,
+// protected by the rolling update of the deployment instead of the update
+// check job.
+func (mm *Mattermost) InPlaceUpdateEnabled() bool {
+	return mm.Spec.UpdatePolicy != nil && mm.Spec.UpdatePolicy.SkipVersionCheckJob
+}
+
+// ClusteringSupported returns true if the Mattermost app servers can run as
+// a cluster, which requires the Enterprise Edition and a license.
+func (mm *Mattermost) ClusteringSupported() bool {
+	return !mm.TeamEdition() && mm.Spec.LicenseSecret != ""
+}
+
+// ClusteringEnabled returns true if the Mattermost app servers run as a
+// cluster, with more than one replica.
+func (mm *Mattermost) ClusteringEnabled() bool {
+	return mm.ClusteringSupported() && mm.Spec.Replicas != nil && *mm.Spec.Replicas > 1
+}
+
+// ClusterReadinessGateEnabled returns true if the pods of the clustered app
+// servers are held unready until they joined the cluster.
+func (mm *Mattermost) ClusterReadinessGateEnabled() bool {
+	return mm.ClusteringEnabled() && mm.Spec.ClusterReadinessGate != nil && mm.Spec.ClusterReadinessGate.Enabled
+}
+
+// ClusterServiceName returns the name of the headless Service the app
+// servers of the cluster discover each other through.
+func (mm *Mattermost) ClusterServiceName() string {
+	return fmt.Sprintf("%s-cluster", mm.Name)
+}
+
+// validateClustering returns an error if more than one replica is requested
+// with clustering disabled in the Mattermost env.
+func (mm *Mattermost) validateClustering() error {
+	if mm.Spec.Replicas == nil || *mm.Spec.Replicas <= 1 {
+		return nil
+	}
+	for _, envVar := range mm.Spec.MattermostEnv {
+		if envVar.Name == "MM_CLUSTERSETTINGS_ENABLE" && strings.EqualFold(envVar.Value, "false") {
+			return errors.New("replicas greater than 1 require clustering, but MM_CLUSTERSETTINGS_ENABLE is false")
+		}
+	}
+	return nil
+}
+
+// validateStandby returns an error if the standby Mattermost has data of its
+// own, which the active installation does not share, or runs other
+// deployments than the scaled down one.
+func (mm *Mattermost) validateStandby() error {
+	if mm.Spec.Standby == nil {
+		return nil
+	}
+	if !mm.Spec.Database.IsExternal() || !mm.Spec.FileStore.IsExternal() {
+		return errors.New("standby requires an external database and file store")
+	}
+	if mm.StandbyEnabled() && (mm.BlueGreenEnabled() || mm.CanaryEnabled()) {
+		return errors.New("standby cannot be enabled with blueGreen or canary until it is promoted")
+	}
+	return nil
+}
+
+// validateIngress returns an error if a path of the Ingress is invalid.
+func (mm *Mattermost) validateIngress() error {
+	if mm.Spec.Ingress == nil {
+		return nil
 	}
-	return fmt.Sprintf("%s:%s", mm.Spec.Image, mm.Spec.Version)
+	if err := validateIngressPath("ingress", mm.Spec.Ingress.Path, mm.Spec.Ingress.PathType); err != nil {
+		return err
+	}
+	for i, path := range mm.Spec.Ingress.ExtraPaths {
+		field := fmt.Sprintf("ingress.extraPaths[%d]", i)
+		if path.Path == "" {
+			return fmt.Errorf("%s.path required, but not set", field)
+		}
+		if err := validateIngressPath(field, path.Path, path.PathType); err != nil {
+			return err
+		}
+		if path.Port < 0 || path.Port > 65535 {
+			return fmt.Errorf("%s.port %d is not a valid port", field, path.Port)
+		}
+	}
+	return nil
+}
+
+func validateIngressPath(field, path string, pathType IngressPathType) error {
+	if path != "" && !strings.HasPrefix(path, "/") {
+		return fmt.Errorf("%s.path %q must start with '/'", field, path)
+	}
+	switch pathType {
+	case "", IngressPathTypePrefix, IngressPathTypeExact, IngressPathTypeImplementationSpecific:
+		return nil
+	}
+	return fmt.Errorf("%s is not a valid %s.pathType value, must be 'Prefix', 'Exact' or 'ImplementationSpecific'", pathType, field)
+}
+
+// validateEdition returns an error if the spec configures features the
+// edition does not support.
+func (mm *Mattermost) validateEdition() error {
+	if !mm.TeamEdition() {
+		return nil
+	}
+	if mm.Spec.ImageVariant == ImageVariantFIPS {
+		return errors.New("imageVariant fips requires the enterprise edition")
+	}
+	if mm.Spec.LicenseSecret != "" {
+		return errors.New("licenseSecret requires the enterprise edition")
+	}
+	if mm.Spec.Replicas != nil && *mm.Spec.Replicas > 1 {
+		return errors.New("replicas greater than 1 require clustering, which requires the enterprise edition")
+	}
+	return nil
+}
+
+// GetImageTag returns the tag of the Mattermost version in the images of the
+// image variant.
+func (mm *Mattermost) GetImageTag(version string) string {
+	variant := imageVariants[mm.Spec.ImageVariant]
+	if variant.tagSuffix == "" || strings.HasSuffix(version, variant.tagSuffix) {
+		return version
+	}
+	return version + variant.tagSuffix
+}
+
+// GetMattermostCommand returns the entrypoint of the Mattermost container.
+func (mm *Mattermost) GetMattermostCommand() []string {
+	if variant, ok := imageVariants[mm.Spec.ImageVariant]; ok {
+		return append([]string{}, variant.command...)
+	}
+	return []string{"mattermost"}
+}
+
+// GetImage returns the Mattermost image in the image registry.
+func (mm *Mattermost) GetImage() string {
+	return mm.ImageWithRegistry(mm.Spec.Image)
+}
+
+// ImageWithRegistry returns the image in the image registry of the
+// Mattermost, the image itself if no image registry is set.
+func (mm *Mattermost) ImageWithRegistry(image string) string {
+	return ImageWithRegistry(image, mm.Spec.ImageRegistry)
+}
+
+// GetCurl returns the curl image of the init containers waiting for MySQL
+// and MinIO.
+func (i *UtilityImages) GetCurl() string {
+	if i == nil || i.Curl == "" {
+		return DefaultCurlImage
+	}
+	return i.Curl
+}
+
+// GetPostgres returns the PostgreSQL image of the init container waiting for
+// an external PostgreSQL database.
+func (i *UtilityImages) GetPostgres() string {
+	if i == nil || i.Postgres == "" {
+		return DefaultPostgresImage
+	}
+	return i.Postgres
+}
+
+// GetMinioClient returns the MinIO client image.
+func (i *UtilityImages) GetMinioClient() string {
+	if i == nil || i.MinioClient == "" {
+		return DefaultMinioClientImage
+	}
+	return i.MinioClient
+}
+
+// GetUtilityResources returns the resources of the init containers and of
+// the job containers not running Mattermost.
+func (mm *Mattermost) GetUtilityResources() corev1.ResourceRequirements {
+	if mm.Spec.UtilityResources != nil {
+		return *mm.Spec.UtilityResources.DeepCopy()
+	}
+	return corev1.ResourceRequirements{
+		Requests: corev1.ResourceList{
+			corev1.ResourceCPU:    resource.MustParse(DefaultUtilityCPURequest),
+			corev1.ResourceMemory: resource.MustParse(DefaultUtilityMemoryRequest),
+		},
+		Limits: corev1.ResourceList{
+			corev1.ResourceCPU:    resource.MustParse(DefaultUtilityCPULimit),
+			corev1.ResourceMemory: resource.MustParse(DefaultUtilityMemoryLimit),
+		},
+	}
+}
+
+// SetUtilityImageDefaults sets the utility images not overridden by the
+// Mattermost to the defaults, ie the utility images configured for the
+// Operator.
+func (mm *Mattermost) SetUtilityImageDefaults(defaults UtilityImages) {
+	if defaults == (UtilityImages{}) {
+		return
+	}
+	if mm.Spec.UtilityImages == nil {
+		mm.Spec.UtilityImages = &UtilityImages{}
+	}
+	images := mm.Spec.UtilityImages
+	if images.Curl == "" {
+		images.Curl = defaults.Curl
+	}
+	if images.Postgres == "" {
+		images.Postgres = defaults.Postgres
+	}
+	if images.MinioClient == "" {
+		images.MinioClient = defaults.MinioClient
+	}
+}
+
+// ImageWithRegistry replaces the registry of the image with the registry,
+// keeping the repository, tag and digest of the image. Images already in the
+// registry are returned as is.
+func ImageWithRegistry(image, registry string) string {
+	registry = strings.TrimSuffix(registry, "/")
+	if registry == "" || image == "" || strings.HasPrefix(image, registry+"/") {
+		return image
+	}
+
+	// The first component of the image is the registry if it is a host.
+	parts := strings.SplitN(image, "/", 2)
+	if len(parts) == 2 && (strings.ContainsAny(parts[0], ".:") || parts[0] == "localhost") {
+		image = parts[1]
+	}
+
+	return fmt.Sprintf("%s/%s", registry, image)
+}
+
+// PinnedImageDigest returns the digest the image name is pinned to, empty if
+// it is not pinned.
+func (mm *Mattermost) PinnedImageDigest(imageName string) string {
+	verification := mm.Status.ImageVerification
+	if !mm.ImageVerificationEnabled() || verification == nil ||
+		verification.Image != imageName || verification.State == ImageVerificationFailed {
+		return ""
+	}
+	return verification.Digest
 }
 
 // GetProductionDeploymentName returns the name of the deployment that is
 // currently designated as production.
 func (mm *Mattermost) GetProductionDeploymentName() string {
+	if deployment := mm.GetProductionDeployment(); deployment != nil {
+		return deployment.Name
+	}
 	return mm.Name
 }
 
+// GetProductionImageName returns the container image name of the deployment
+// that is currently designated as production.
+func (mm *Mattermost) GetProductionImageName() string {
+	if deployment := mm.GetProductionDeployment(); deployment != nil {
+		return mm.GetAppDeploymentImageName(*deployment)
+	}
+	return mm.GetImageName()
+}
+
+// GetProductionDeployment returns the blue or green deployment that is
+// currently designated as production, nil if BlueGreen is not enabled.
+func (mm *Mattermost) GetProductionDeployment() *AppDeployment {
+	if !mm.BlueGreenEnabled() {
+		return nil
+	}
+	if mm.Spec.BlueGreen.ProductionDeployment == GreenName {
+		return &mm.Spec.BlueGreen.Green
+	}
+	return &mm.Spec.BlueGreen.Blue
+}
+
+// GetAppDeploymentImageName returns the container image name of the
+// deployment, in the image registry and tagged for the image variant of the
+// Mattermost.
+func (mm *Mattermost) GetAppDeploymentImageName(deployment AppDeployment) string {
+	if !strings.Contains(deployment.Version, "sha256:") {
+		deployment.Version = mm.GetImageTag(deployment.Version)
+	}
+	return mm.ImageWithRegistry(deployment.GetDeploymentImageName())
+}
+
+// GetDeploymentImageName returns the container image name that matches the spec
+// of the deployment.
+func (d *AppDeployment) GetDeploymentImageName() string {
+	if strings.Contains(d.Version, "sha256:") {
+		return fmt.Sprintf("%s@%s", d.Image, d.Version)
+	}
+	return fmt.Sprintf("%s:%s", d.Image, d.Version)
+}
+
 // MattermostSelectorLabels returns the selector labels for selecting the resources
 // belonging to the given mattermost instance.
 func MattermostSelectorLabels(name string) map[string]string {
@@ -2,6 +2,7 @@ package v1beta1
 
 import (
 	"testing"
+	"time"
 
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
@@ -21,6 +22,239 @@ func TestMattermost_SetDefaults(t *testing.T) {
 		err := mm.SetDefaults()
 		require.NoError(t, err)
 	})
+	t.Run("default trusted CA bundle key", func(t *testing.T) {
+		mm.Spec.TrustedCABundle = &TrustedCABundle{ConfigMap: "internal-ca"}
+		err := mm.SetDefaults()
+		require.NoError(t, err)
+		assert.Equal(t, DefaultTrustedCABundleKey, mm.Spec.TrustedCABundle.Key)
+	})
+}
+
+func TestMattermost_IngressPaths(t *testing.T) {
+	newMattermost := func(ingress *Ingress) *Mattermost {
+		ingress.Enabled = true
+		ingress.Host = "test-mm.com"
+		return &Mattermost{Spec: MattermostSpec{Ingress: ingress}}
+	}
+
+	t.Run("defaults", func(t *testing.T) {
+		mm := newMattermost(&Ingress{})
+		require.NoError(t, mm.SetDefaults())
+		assert.Equal(t, DefaultIngressPath, mm.GetIngressPath())
+		assert.Equal(t, IngressPathTypeImplementationSpecific, mm.GetIngressPathType())
+		assert.Empty(t, mm.Spec.Ingress.Path)
+		assert.Empty(t, mm.Spec.Ingress.PathType)
+	})
+	t.Run("custom path", func(t *testing.T) {
+		mm := newMattermost(&Ingress{
+			Path:       "/chat",
+			PathType:   IngressPathTypePrefix,
+			ExtraPaths: []IngressPath{{Path: "/plugins", PathType: IngressPathTypeExact}},
+		})
+		require.NoError(t, mm.SetDefaults())
+		assert.Equal(t, "/chat", mm.GetIngressPath())
+		assert.Equal(t, IngressPathTypePrefix, mm.GetIngressPathType())
+		assert.Len(t, mm.GetIngressExtraPaths(), 1)
+	})
+
+	for _, testCase := range []struct {
+		name    string
+		ingress *Ingress
+		err     string
+	}{
+		{
+			name:    "relative path",
+			ingress: &Ingress{Path: "chat"},
+			err:     `ingress.path "chat" must start with '/'`,
+		},
+		{
+			name:    "invalid path type",
+			ingress: &Ingress{PathType: "Regex"},
+			err:     "Regex is not a valid ingress.pathType value, must be 'Prefix', 'Exact' or 'ImplementationSpecific'",
+		},
+		{
+			name:    "extra path not set",
+			ingress: &Ingress{ExtraPaths: []IngressPath{{Service: "calls"}}},
+			err:     "ingress.extraPaths[0].path required, but not set",
+		},
+		{
+			name:    "relative extra path",
+			ingress: &Ingress{ExtraPaths: []IngressPath{{Path: "/plugins"}, {Path: "api"}}},
+			err:     `ingress.extraPaths[1].path "api" must start with '/'`,
+		},
+		{
+			name:    "invalid extra port",
+			ingress: &Ingress{ExtraPaths: []IngressPath{{Path: "/plugins", Port: 70000}}},
+			err:     "ingress.extraPaths[0].port 70000 is not a valid port",
+		},
+	} {
+		t.Run(testCase.name, func(t *testing.T) {
+			err := newMattermost(testCase.ingress).SetDefaults()
+			require.Error(t, err)
+			assert.Equal(t, testCase.err, err.Error())
+		})
+	}
+}
+
+func TestMattermost_BlueGreen(t *testing.T) {
+	newMattermost := func() *Mattermost {
+		mm := &Mattermost{Spec: MattermostSpec{
+			IngressName: "test-mm.com",
+			BlueGreen: &BlueGreen{
+				Enabled:              true,
+				ProductionDeployment: BlueName,
+				Blue:                 AppDeployment{Version: "5.36.0"},
+				Green:                AppDeployment{Version: "5.37.1"},
+			},
+		}}
+		mm.Name = "mm"
+		return mm
+	}
+
+	t.Run("set defaults", func(t *testing.T) {
+		mm := newMattermost()
+		err := mm.SetDefaults()
+		require.NoError(t, err)
+
+		assert.Equal(t, AppDeployment{
+			Name:        "mm-blue",
+			IngressHost: "blue.test-mm.com",
+			Image:       DefaultMattermostImage,
+			Version:     "5.36.0",
+		}, mm.Spec.BlueGreen.Blue)
+		assert.Equal(t, "mm-green", mm.Spec.BlueGreen.Green.Name)
+		assert.Equal(t, "green.test-mm.com", mm.Spec.BlueGreen.Green.IngressHost)
+	})
+
+	t.Run("return error when version not set", func(t *testing.T) {
+		mm := newMattermost()
+		mm.Spec.BlueGreen.Green.Version = ""
+		err := mm.SetDefaults()
+		require.Error(t, err)
+	})
+
+	t.Run("return error when names conflict", func(t *testing.T) {
+		mm := newMattermost()
+		mm.Spec.BlueGreen.Green.Name = "mm"
+		err := mm.SetDefaults()
+		require.Error(t, err)
+	})
+
+	t.Run("production deployment", func(t *testing.T) {
+		mm := newMattermost()
+		err := mm.SetDefaults()
+		require.NoError(t, err)
+
+		assert.Equal(t, "mm-blue", mm.GetProductionDeploymentName())
+		assert.Equal(t, DefaultMattermostImage+":5.36.0", mm.GetProductionImageName())
+
+		mm.Spec.BlueGreen.ProductionDeployment = GreenName
+		assert.Equal(t, "mm-green", mm.GetProductionDeploymentName())
+		assert.Equal(t, DefaultMattermostImage+":5.37.1", mm.GetProductionImageName())
+
+		mm.Spec.BlueGreen.Enabled = false
+		assert.Equal(t, "mm", mm.GetProductionDeploymentName())
+		assert.Equal(t, mm.GetImageName(), mm.GetProductionImageName())
+	})
+}
+
+func TestMattermost_Canary(t *testing.T) {
+	newMattermost := func() *Mattermost {
+		mm := &Mattermost{Spec: MattermostSpec{
+			IngressName: "test-mm.com",
+			Canary: &Canary{
+				Enabled:    true,
+				Weight:     10,
+				Deployment: AppDeployment{Version: "5.37.1"},
+			},
+		}}
+		mm.Name = "mm"
+		return mm
+	}
+
+	t.Run("set defaults", func(t *testing.T) {
+		mm := newMattermost()
+		err := mm.SetDefaults()
+		require.NoError(t, err)
+
+		assert.Equal(t, "mm-canary", mm.Spec.Canary.Deployment.Name)
+		assert.Equal(t, DefaultMattermostImage, mm.Spec.Canary.Deployment.Image)
+		assert.Equal(t, "mm", mm.GetProductionDeploymentName())
+	})
+
+	t.Run("return error when ingress disabled", func(t *testing.T) {
+		mm := newMattermost()
+		mm.Spec.UseServiceLoadBalancer = true
+		err := mm.SetDefaults()
+		require.Error(t, err)
+	})
+
+	t.Run("return error when blue green enabled", func(t *testing.T) {
+		mm := newMattermost()
+		mm.Spec.BlueGreen = &BlueGreen{
+			Enabled:              true,
+			ProductionDeployment: BlueName,
+			Blue:                 AppDeployment{Version: "5.36.0"},
+			Green:                AppDeployment{Version: "5.37.1"},
+		}
+		err := mm.SetDefaults()
+		require.Error(t, err)
+	})
+}
+
+func TestMattermost_MaintenanceWindow(t *testing.T) {
+	newMattermost := func(window MaintenanceWindow) *Mattermost {
+		mm := &Mattermost{Spec: MattermostSpec{
+			IngressName:  "test-mm.com",
+			UpdatePolicy: &UpdatePolicy{Window: &window},
+		}}
+		mm.Name = "mm"
+		return mm
+	}
+
+	t.Run("set defaults", func(t *testing.T) {
+		mm := newMattermost(MaintenanceWindow{Schedule: "* 2-4 * * 6,0"})
+		err := mm.SetDefaults()
+		require.NoError(t, err)
+
+		assert.Equal(t, DefaultMaintenanceWindowTimeZone, mm.Spec.UpdatePolicy.Window.TimeZone)
+		assert.True(t, mm.MaintenanceWindowEnabled())
+	})
+
+	t.Run("next window in time zone", func(t *testing.T) {
+		window := MaintenanceWindow{Schedule: "* 2-4 * * 6,0", TimeZone: "Europe/Berlin"}
+
+		// Saturday 1:30 UTC is 3:30 in Berlin.
+		inWindow, next, err := window.Next(time.Date(2021, time.June, 5, 1, 30, 0, 0, time.UTC))
+		require.NoError(t, err)
+		assert.True(t, inWindow)
+		assert.Equal(t, time.Date(2021, time.June, 5, 1, 31, 0, 0, time.UTC), next.UTC())
+
+		// Sunday 3:00 UTC is 5:00 in Berlin, the next window opens on Saturday.
+		inWindow, next, err = window.Next(time.Date(2021, time.June, 6, 3, 0, 0, 0, time.UTC))
+		require.NoError(t, err)
+		assert.False(t, inWindow)
+		assert.Equal(t, time.Date(2021, time.June, 12, 0, 0, 0, 0, time.UTC), next.UTC())
+	})
+
+	t.Run("return error when schedule invalid", func(t *testing.T) {
+		mm := newMattermost(MaintenanceWindow{Schedule: "* 2-4 * *"})
+		err := mm.SetDefaults()
+		require.Error(t, err)
+	})
+
+	t.Run("return error when backup destination missing", func(t *testing.T) {
+		mm := newMattermost(MaintenanceWindow{Schedule: "* 2-4 * * 6,0"})
+		mm.Spec.UpdatePolicy.BackupBeforeUpgrade = true
+		err := mm.SetDefaults()
+		require.Error(t, err)
+	})
+
+	t.Run("return error when time zone invalid", func(t *testing.T) {
+		mm := newMattermost(MaintenanceWindow{Schedule: "* 2-4 * * 6,0", TimeZone: "Mars/Olympus"})
+		err := mm.SetDefaults()
+		require.Error(t, err)
+	})
 }
 
 func TestMattermost_IngressAccessors(t *testing.T) {
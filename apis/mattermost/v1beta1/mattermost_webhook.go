@@ -0,0 +1,110 @@
+// Copyright (c) 2017-present Mattermost, Inc. All Rights Reserved.
+// See License.txt for license information.
+
+package v1beta1
+
+import (
+	"fmt"
+
+	"k8s.io/apimachinery/pkg/runtime"
+	ctrl "sigs.k8s.io/controller-runtime"
+	logf "sigs.k8s.io/controller-runtime/pkg/log"
+	"sigs.k8s.io/controller-runtime/pkg/webhook"
+)
+
+var mattermostlog = logf.Log.WithName("mattermost-resource")
+
+// SetupWebhookWithManager registers the defaulting and validating webhooks of
+// the Mattermost with the manager.
+func (mm *Mattermost) SetupWebhookWithManager(mgr ctrl.Manager) error {
+	return ctrl.NewWebhookManagedBy(mgr).
+		For(mm).
+		Complete()
+}
+
+// +kubebuilder:webhook:path=/mutate-installation-mattermost-com-v1beta1-mattermost,mutating=true,failurePolicy=fail,sideEffects=None,groups=installation.mattermost.com,resources=mattermosts,verbs=create;update,versions=v1beta1,name=mmattermost.kb.io,admissionReviewVersions={v1,v1beta1}
+
+var _ webhook.Defaulter = &Mattermost{}
+
+// Default stores the defaults of the Mattermost, including the replicas and
+// resources derived from its size, so that the stored object reflects what
+// is deployed. Invalid Mattermosts are stored as is, the controller reports
+// their error. The defaults of the Mattermosts referencing a template,
+// merged in memory by the controller, and of the Mattermosts with the store
+// defaults annotation set to "false" are not stored. The default images are left to the controller, which applies the
+// global defaults before them.
+func (mm *Mattermost) Default() {
+	if mm.Spec.TemplateRef != "" || !mm.StoreDefaults() {
+		return
+	}
+	defaulted := mm.DeepCopy()
+	err := defaulted.SetDefaults()
+	if err != nil {
+		mattermostlog.Info("Skipping defaults of invalid Mattermost", "name", mm.Name, "namespace", mm.Namespace, "error", err.Error())
+		return
+	}
+
+	err = defaulted.SetReplicasAndResourcesFromSize()
+	if err != nil {
+		mattermostlog.Info("Using default replicas and resources", "name", mm.Name, "namespace", mm.Namespace, "error", err.Error())
+	}
+
+	defaulted.Spec.Image = mm.Spec.Image
+	if mm.Spec.BlueGreen != nil {
+		defaulted.Spec.BlueGreen.Blue.Image = mm.Spec.BlueGreen.Blue.Image
+		defaulted.Spec.BlueGreen.Green.Image = mm.Spec.BlueGreen.Green.Image
+	}
+	if mm.Spec.Canary != nil {
+		defaulted.Spec.Canary.Deployment.Image = mm.Spec.Canary.Deployment.Image
+	}
+
+	mm.Spec = defaulted.Spec
+}
+
+// +kubebuilder:webhook:path=/validate-installation-mattermost-com-v1beta1-mattermost,mutating=false,failurePolicy=fail,sideEffects=None,groups=installation.mattermost.com,resources=mattermosts,verbs=update;delete,versions=v1beta1,name=vmattermost.kb.io,admissionReviewVersions={v1,v1beta1}
+
+var _ webhook.Validator = &Mattermost{}
+
+// ValidateCreate implements webhook.Validator. New Mattermosts are validated
+// by the controller.
+func (mm *Mattermost) ValidateCreate() error {
+	return nil
+}
+
+// ValidateUpdate rejects changes of the database and file store backends of
+// the Mattermost, which would break the installation.
+func (mm *Mattermost) ValidateUpdate(old runtime.Object) error {
+	oldMattermost, ok := old.(*Mattermost)
+	if !ok {
+		return fmt.Errorf("expected a Mattermost but got a %T", old)
+	}
+	return mm.ValidateBackendChange(oldMattermost)
+}
+
+// ValidateDelete rejects the deletion of the Mattermosts protected from
+// deletion. The deletion protection finalizer holds their deletion if the
+// webhook is not enabled.
+func (mm *Mattermost) ValidateDelete() error {
+	if mm.DeletionProtected() {
+		return fmt.Errorf("the Mattermost is protected from deletion, set the %s annotation to false to delete it", DeletionProtectedAnnotation)
+	}
+	return nil
+}
+
+// ValidateBackendChange returns an error if the database or the file store
+// backend changed from the old Mattermost. The database backend may change
+// once the migration is acknowledged, the file store backend only through a
+// file store migration.
+func (mm *Mattermost) ValidateBackendChange(old *Mattermost) error {
+	oldBackend, newBackend := old.Spec.Database.Backend(), mm.Spec.Database.Backend()
+	if oldBackend != newBackend && !mm.Spec.Database.AllowMigration {
+		return fmt.Errorf("database backend cannot be changed from %s to %s, set database.allowMigration to acknowledge that the data was migrated", oldBackend, newBackend)
+	}
+
+	oldBackend, newBackend = old.Spec.FileStore.Backend(), mm.Spec.FileStore.Backend()
+	if oldBackend != newBackend && old.Spec.FileStore.MigrateTo == nil {
+		return fmt.Errorf("file store backend cannot be changed from %s to %s, set fileStore.migrateTo to migrate the files to an external file store", oldBackend, newBackend)
+	}
+
+	return nil
+}
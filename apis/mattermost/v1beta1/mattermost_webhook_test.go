@@ -0,0 +1,142 @@
+package v1beta1
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func TestMattermost_Default(t *testing.T) {
+	t.Run("stores defaults and size", func(t *testing.T) {
+		mm := &Mattermost{
+			ObjectMeta: metav1.ObjectMeta{Name: "foo"},
+			Spec: MattermostSpec{
+				Size:    "1000users",
+				Ingress: &Ingress{Enabled: false},
+			},
+		}
+
+		mm.Default()
+		assert.Empty(t, mm.Spec.Image)
+		assert.Equal(t, DefaultMattermostVersion, mm.Spec.Version)
+		assert.Equal(t, DefaultPullPolicy, mm.Spec.ImagePullPolicy)
+		assert.Empty(t, mm.Spec.Size)
+		require.NotNil(t, mm.Spec.Replicas)
+		assert.Equal(t, int32(2), *mm.Spec.Replicas)
+		assert.NotEmpty(t, mm.Spec.Scheduling.Resources.Requests)
+
+		defaulted := mm.DeepCopy()
+		mm.Default()
+		assert.Equal(t, defaulted, mm)
+	})
+
+	t.Run("image set by the Mattermost stored as is", func(t *testing.T) {
+		mm := &Mattermost{
+			ObjectMeta: metav1.ObjectMeta{Name: "foo"},
+			Spec: MattermostSpec{
+				Image:   DefaultMattermostImage,
+				Ingress: &Ingress{Enabled: true, Host: "foo.example.com"},
+				Canary:  &Canary{Enabled: true, Deployment: AppDeployment{Version: "5.37.1"}},
+			},
+		}
+
+		mm.Default()
+		assert.Equal(t, DefaultMattermostImage, mm.Spec.Image)
+		assert.Empty(t, mm.Spec.Canary.Deployment.Image)
+		assert.NotEmpty(t, mm.Spec.Canary.Deployment.Name)
+	})
+
+	t.Run("Mattermost referencing a template stored as is", func(t *testing.T) {
+		mm := &Mattermost{
+			ObjectMeta: metav1.ObjectMeta{Name: "foo"},
+			Spec:       MattermostSpec{TemplateRef: "production", Ingress: &Ingress{Enabled: false}},
+		}
+
+		mm.Default()
+		assert.Empty(t, mm.Spec.Image)
+		assert.Empty(t, mm.Spec.Version)
+	})
+
+	t.Run("Mattermost opting out of stored defaults stored as is", func(t *testing.T) {
+		mm := &Mattermost{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:        "foo",
+				Annotations: map[string]string{StoreDefaultsAnnotation: "false"},
+			},
+			Spec: MattermostSpec{Size: "1000users", Ingress: &Ingress{Enabled: false}},
+		}
+
+		mm.Default()
+		assert.Empty(t, mm.Spec.Image)
+		assert.Equal(t, "1000users", mm.Spec.Size)
+		assert.Nil(t, mm.Spec.Replicas)
+	})
+
+	t.Run("invalid Mattermost stored as is", func(t *testing.T) {
+		mm := &Mattermost{
+			ObjectMeta: metav1.ObjectMeta{Name: "foo"},
+			Spec:       MattermostSpec{Size: "1000users"},
+		}
+
+		mm.Default()
+		assert.Equal(t, MattermostSpec{Size: "1000users"}, mm.Spec)
+	})
+}
+
+func TestMattermost_ValidateBackendChange(t *testing.T) {
+	newMattermost := func() *Mattermost {
+		mm := &Mattermost{
+			ObjectMeta: metav1.ObjectMeta{Name: "foo"},
+			Spec:       MattermostSpec{Ingress: &Ingress{Enabled: false}},
+		}
+		require.NoError(t, mm.SetDefaults())
+		return mm
+	}
+
+	t.Run("unchanged backends", func(t *testing.T) {
+		old := newMattermost()
+		mm := old.DeepCopy()
+		mm.Spec.Version = "5.38.0"
+		assert.NoError(t, mm.ValidateUpdate(old))
+	})
+
+	t.Run("database backend", func(t *testing.T) {
+		old := newMattermost()
+		mm := old.DeepCopy()
+		mm.Spec.Database.External = &ExternalDatabase{Secret: "db-secret"}
+		assert.Error(t, mm.ValidateUpdate(old))
+
+		mm.Spec.Database.AllowMigration = true
+		assert.NoError(t, mm.ValidateUpdate(old))
+	})
+
+	t.Run("operator managed database type", func(t *testing.T) {
+		old := newMattermost()
+		mm := old.DeepCopy()
+		mm.Spec.Database.OperatorManaged.Type = "postgres"
+		assert.Error(t, mm.ValidateUpdate(old))
+	})
+
+	t.Run("file store backend", func(t *testing.T) {
+		old := newMattermost()
+		mm := old.DeepCopy()
+		mm.Spec.FileStore.External = &ExternalFileStore{URL: "s3.amazonaws.com", Bucket: "files", Secret: "s3-secret"}
+		assert.Error(t, mm.ValidateUpdate(old))
+
+		old.Spec.FileStore.MigrateTo = mm.Spec.FileStore.External
+		assert.NoError(t, mm.ValidateUpdate(old))
+	})
+}
+
+func TestMattermost_ValidateDelete(t *testing.T) {
+	mm := &Mattermost{ObjectMeta: metav1.ObjectMeta{Name: "foo"}}
+	assert.NoError(t, mm.ValidateDelete())
+
+	mm.Annotations = map[string]string{DeletionProtectedAnnotation: "true"}
+	assert.Error(t, mm.ValidateDelete())
+
+	mm.Annotations[DeletionProtectedAnnotation] = "false"
+	assert.NoError(t, mm.ValidateDelete())
+}
@@ -0,0 +1,139 @@
+// Copyright (c) 2017-present Mattermost, Inc. All Rights Reserved.
+// See License.txt for license information.
+
+package v1beta1
+
+import (
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+////////////////////////////////////////////////////////////////////////////////
+//                                 IMPORTANT!                                 //
+////////////////////////////////////////////////////////////////////////////////
+// Run "make generate manifests" in the root of this repository to regenerate //
+// code after modifying this file.                                            //
+// Add custom validation using kubebuilder tags:                              //
+// https://book.kubebuilder.io/reference/generating-crd.html                  //
+////////////////////////////////////////////////////////////////////////////////
+
+// MattermostBackupSpec defines the desired state of MattermostBackup
+type MattermostBackupSpec struct {
+	// MattermostName defines the name of the Mattermost installation to back
+	// up. It has to be in the same namespace as the backup.
+	MattermostName string `json:"mattermostName"`
+	// Schedule defines when the backup runs, in Cron format. If not set the
+	// backup runs once.
+	// +optional
+	Schedule string `json:"schedule,omitempty"`
+	// Destination defines the S3 bucket the backups are uploaded to.
+	Destination BackupDestination `json:"destination"`
+	// Retention defines how many backups are kept in the destination.
+	// All backups are kept if not set.
+	// +optional
+	Retention *BackupRetention `json:"retention,omitempty"`
+}
+
+// BackupDestination defines the S3 bucket the backups are uploaded to.
+type BackupDestination struct {
+	// Set to the URL of the MinIO or S3 endpoint.
+	URL string `json:"url"`
+	// Set to the name of the bucket.
+	Bucket string `json:"bucket"`
+	// Defines the path in the bucket under which the backups are stored.
+	// +optional
+	Prefix string `json:"prefix,omitempty"`
+	// Set to the name of the secret with credentials to the bucket.
+	// Secret should have two values: "accesskey" and "secretkey".
+	Secret string `json:"secret"`
+}
+
+// BackupRetention defines how many backups are kept in the destination.
+type BackupRetention struct {
+	// Defines the number of most recent backups to keep, older backups are
+	// removed after a successful backup.
+	// +kubebuilder:validation:Minimum=1
+	KeepLast int32 `json:"keepLast"`
+}
+
+// BackupState is the state of a Mattermost backup.
+type BackupState string
+
+const (
+	// BackupRunning is the state when the backup is being taken
+	BackupRunning BackupState = "running"
+	// BackupCompleted is the state when the backup was uploaded to the
+	// destination
+	BackupCompleted BackupState = "completed"
+	// BackupFailed is the state when the backup could not be taken
+	BackupFailed BackupState = "failed"
+)
+
+// BackupResult defines the result of a single backup.
+type BackupResult struct {
+	// The name of the Job taking the backup
+	// +optional
+	JobName string `json:"jobName,omitempty"`
+	// Represents the state of the backup
+	// +optional
+	State BackupState `json:"state,omitempty"`
+	// The location of the backup in the destination bucket
+	// +optional
+	Location string `json:"location,omitempty"`
+	// The error reported by the Job if the backup failed
+	// +optional
+	Message string `json:"message,omitempty"`
+	// The time when the backup started
+	// +optional
+	StartTime *metav1.Time `json:"startTime,omitempty"`
+	// The time when the backup completed or failed
+	// +optional
+	CompletionTime *metav1.Time `json:"completionTime,omitempty"`
+}
+
+// MattermostBackupStatus defines the observed state of MattermostBackup
+type MattermostBackupStatus struct {
+	// Represents the state of the last backup
+	// +optional
+	State BackupState `json:"state,omitempty"`
+	// The reason why the backup could not be started
+	// +optional
+	Message string `json:"message,omitempty"`
+	// The result of the last backup
+	// +optional
+	LastBackup *BackupResult `json:"lastBackup,omitempty"`
+	// The result of the last successful backup
+	// +optional
+	LastSuccessfulBackup *BackupResult `json:"lastSuccessfulBackup,omitempty"`
+	// The last observed Generation of the MattermostBackup resource that was
+	// acted on.
+	// +optional
+	ObservedGeneration int64 `json:"observedGeneration,omitempty"`
+}
+
+// MattermostBackup is the Schema for the mattermostbackups API
+// +kubebuilder:object:root=true
+// +kubebuilder:subresource:status
+// +kubebuilder:printcolumn:priority=0,name="Mattermost",type=string,JSONPath=".spec.mattermostName",description="Name of the Mattermost installation"
+// +kubebuilder:printcolumn:priority=0,name="Schedule",type=string,JSONPath=".spec.schedule",description="Schedule of the backups"
+// +kubebuilder:printcolumn:priority=0,name="State",type=string,JSONPath=".status.state",description="State of the last backup"
+// +kubebuilder:printcolumn:priority=0,name="Last Successful Backup",type=string,JSONPath=".status.lastSuccessfulBackup.location",description="Location of the last successful backup"
+type MattermostBackup struct {
+	metav1.TypeMeta   `json:",inline"`
+	metav1.ObjectMeta `json:"metadata,omitempty"`
+
+	Spec   MattermostBackupSpec   `json:"spec,omitempty"`
+	Status MattermostBackupStatus `json:"status,omitempty"`
+}
+
+// +kubebuilder:object:root=true
+
+// MattermostBackupList contains a list of MattermostBackup
+type MattermostBackupList struct {
+	metav1.TypeMeta `json:",inline"`
+	metav1.ListMeta `json:"metadata,omitempty"`
+	Items           []MattermostBackup `json:"items"`
+}
+
+func init() {
+	SchemeBuilder.Register(&MattermostBackup{}, &MattermostBackupList{})
+}
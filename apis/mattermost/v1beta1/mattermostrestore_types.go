@@ -0,0 +1,109 @@
+// Copyright (c) 2017-present Mattermost, Inc. All Rights Reserved.
+// See License.txt for license information.
+
+package v1beta1
+
+import (
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+////////////////////////////////////////////////////////////////////////////////
+//                                 IMPORTANT!                                 //
+////////////////////////////////////////////////////////////////////////////////
+// Run "make generate manifests" in the root of this repository to regenerate //
+// code after modifying this file.                                            //
+// Add custom validation using kubebuilder tags:                              //
+// https://book.kubebuilder.io/reference/generating-crd.html                  //
+////////////////////////////////////////////////////////////////////////////////
+
+// MattermostRestoreSpec defines the desired state of MattermostRestore
+type MattermostRestoreSpec struct {
+	// MattermostName defines the name of the Mattermost installation to
+	// restore. It has to be in the same namespace as the restore.
+	MattermostName string `json:"mattermostName"`
+	// Source defines the S3 bucket holding the backups.
+	Source BackupDestination `json:"source"`
+	// Backup defines the path of the backup to restore, relative to the
+	// prefix of the source. For backups taken by a MattermostBackup it is
+	// '<MattermostBackup name>/<timestamp>'. The database is restored from
+	// the 'database.sql' dump in this path.
+	Backup string `json:"backup"`
+	// JobPolicy defines the retries and the lifetime of the restore job.
+	// +optional
+	JobPolicy *JobPolicy `json:"jobPolicy,omitempty"`
+}
+
+// MattermostRestoreState is the state of a Mattermost restore.
+type MattermostRestoreState string
+
+const (
+	// RestoreScalingDown is the state when Mattermost is being scaled down
+	// before restoring the database
+	RestoreScalingDown MattermostRestoreState = "scaling-down"
+	// RestoreRestoring is the state when the database is being restored and
+	// validated
+	RestoreRestoring MattermostRestoreState = "restoring"
+	// RestoreScalingUp is the state when Mattermost is being scaled back up
+	// after the database was restored
+	RestoreScalingUp MattermostRestoreState = "scaling-up"
+	// RestoreFinished is the state when the restore is complete
+	RestoreFinished MattermostRestoreState = "finished"
+	// RestoreFailed is the state when the database could not be restored or
+	// validated. Mattermost is left scaled down so that the database can be
+	// inspected.
+	RestoreFailed MattermostRestoreState = "failed"
+)
+
+// MattermostRestoreStatus defines the observed state of MattermostRestore
+type MattermostRestoreStatus struct {
+	// Represents the state of the restore
+	// +optional
+	State MattermostRestoreState `json:"state,omitempty"`
+	// The result of the restore or the reason why it failed
+	// +optional
+	Message string `json:"message,omitempty"`
+	// The name of the Job restoring the database
+	// +optional
+	JobName string `json:"jobName,omitempty"`
+	// The number of Mattermost replicas before the restore, Mattermost is
+	// scaled back to it once the database is restored.
+	// +optional
+	OriginalReplicas int32 `json:"originalReplicas,omitempty"`
+	// The time when the restore started
+	// +optional
+	StartTime *metav1.Time `json:"startTime,omitempty"`
+	// The time when the restore finished or failed
+	// +optional
+	CompletionTime *metav1.Time `json:"completionTime,omitempty"`
+	// The last observed Generation of the MattermostRestore resource that
+	// was acted on.
+	// +optional
+	ObservedGeneration int64 `json:"observedGeneration,omitempty"`
+}
+
+// MattermostRestore is the Schema for the mattermostrestores API
+// +kubebuilder:object:root=true
+// +kubebuilder:subresource:status
+// +kubebuilder:printcolumn:priority=0,name="Mattermost",type=string,JSONPath=".spec.mattermostName",description="Name of the Mattermost installation"
+// +kubebuilder:printcolumn:priority=0,name="Backup",type=string,JSONPath=".spec.backup",description="Path of the restored backup"
+// +kubebuilder:printcolumn:priority=0,name="State",type=string,JSONPath=".status.state",description="State of the restore"
+type MattermostRestore struct {
+	metav1.TypeMeta   `json:",inline"`
+	metav1.ObjectMeta `json:"metadata,omitempty"`
+
+	Spec   MattermostRestoreSpec   `json:"spec,omitempty"`
+	Status MattermostRestoreStatus `json:"status,omitempty"`
+}
+
+// +kubebuilder:object:root=true
+
+// MattermostRestoreList contains a list of MattermostRestore
+type MattermostRestoreList struct {
+	metav1.TypeMeta `json:",inline"`
+	metav1.ListMeta `json:"metadata,omitempty"`
+	Items           []MattermostRestore `json:"items"`
+}
+
+func init() {
+	SchemeBuilder.Register(&MattermostRestore{}, &MattermostRestoreList{})
+}
@@ -0,0 +1,52 @@
+// Copyright (c) 2017-present Mattermost, Inc. All Rights Reserved.
+// See License.txt for license information.
+
+package v1beta1
+
+import (
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+)
+
+////////////////////////////////////////////////////////////////////////////////
+//                                 IMPORTANT!                                 //
+////////////////////////////////////////////////////////////////////////////////
+// Run "make generate manifests" in the root of this repository to regenerate //
+// code after modifying this file.                                            //
+// Add custom validation using kubebuilder tags:                              //
+// https://book.kubebuilder.io/reference/generating-crd.html                  //
+////////////////////////////////////////////////////////////////////////////////
+
+// MattermostTemplateSpec defines the desired state of MattermostTemplate
+type MattermostTemplateSpec struct {
+	// Mattermost defines the values of the Mattermost spec merged under the
+	// spec of the Mattermosts referencing the template, ie the database,
+	// file store, scheduling or ingress settings approved by the platform
+	// team.
+	// +kubebuilder:pruning:PreserveUnknownFields
+	// +kubebuilder:validation:Type=object
+	Mattermost runtime.RawExtension `json:"mattermost"`
+}
+
+// MattermostTemplate is the Schema for the mattermosttemplates API
+// +kubebuilder:object:root=true
+// +kubebuilder:resource:scope=Cluster
+type MattermostTemplate struct {
+	metav1.TypeMeta   `json:",inline"`
+	metav1.ObjectMeta `json:"metadata,omitempty"`
+
+	Spec MattermostTemplateSpec `json:"spec,omitempty"`
+}
+
+// +kubebuilder:object:root=true
+
+// MattermostTemplateList contains a list of MattermostTemplate
+type MattermostTemplateList struct {
+	metav1.TypeMeta `json:",inline"`
+	metav1.ListMeta `json:"metadata,omitempty"`
+	Items           []MattermostTemplate `json:"items"`
+}
+
+func init() {
+	SchemeBuilder.Register(&MattermostTemplate{}, &MattermostTemplateList{})
+}
@@ -0,0 +1,172 @@
+// Copyright (c) 2017-present Mattermost, Inc. All Rights Reserved.
+// See License.txt for license information.
+
+package v1beta1
+
+import (
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+////////////////////////////////////////////////////////////////////////////////
+//                                 IMPORTANT!                                 //
+////////////////////////////////////////////////////////////////////////////////
+// Run "make generate manifests" in the root of this repository to regenerate //
+// code after modifying this file.                                            //
+// Add custom validation using kubebuilder tags:                              //
+// https://book.kubebuilder.io/reference/generating-crd.html                  //
+////////////////////////////////////////////////////////////////////////////////
+
+// MattermostUpgradeSpec defines the desired state of MattermostUpgrade
+type MattermostUpgradeSpec struct {
+	// Selector selects the Mattermost installations to upgrade by their
+	// labels, in all namespaces.
+	Selector metav1.LabelSelector `json:"selector"`
+	// Version defines the Mattermost version the installations are upgraded
+	// to. Changing it starts a new rollout.
+	Version string `json:"version"`
+	// Image defines the Mattermost image the installations are upgraded to.
+	// The image of each installation is kept if not set.
+	// +optional
+	Image string `json:"image,omitempty"`
+	// BatchSize defines how many installations are upgraded at the same
+	// time. Defaults to 1.
+	// +kubebuilder:validation:Minimum=1
+	// +optional
+	BatchSize int32 `json:"batchSize,omitempty"`
+	// MaxFailures defines how many installations can fail to upgrade before
+	// the rollout stops. Defaults to 0, the rollout stops at the first
+	// failure.
+	// +kubebuilder:validation:Minimum=0
+	// +optional
+	MaxFailures int32 `json:"maxFailures,omitempty"`
+	// Timeout defines how long an installation has to run the new version
+	// stably once its upgrade started, ie 1h. Defaults to 30m.
+	// +optional
+	Timeout *metav1.Duration `json:"timeout,omitempty"`
+	// Set to true to stop upgrading new installations, the upgrades in
+	// progress are completed.
+	// +optional
+	Paused bool `json:"paused,omitempty"`
+}
+
+// MattermostUpgradeState is the state of a fleet upgrade.
+type MattermostUpgradeState string
+
+const (
+	// MattermostUpgradeUpgrading is the state when the installations are
+	// being upgraded batch by batch.
+	MattermostUpgradeUpgrading MattermostUpgradeState = "upgrading"
+	// MattermostUpgradePaused is the state when the rollout is paused.
+	MattermostUpgradePaused MattermostUpgradeState = "paused"
+	// MattermostUpgradeCompleted is the state when all the selected
+	// installations were upgraded, or failed within the tolerated failures.
+	MattermostUpgradeCompleted MattermostUpgradeState = "completed"
+	// MattermostUpgradeFailed is the state when more installations failed to
+	// upgrade than tolerated. The remaining installations are not upgraded.
+	MattermostUpgradeFailed MattermostUpgradeState = "failed"
+)
+
+// InstallationUpgradeState is the state of the upgrade of an installation
+// of a fleet upgrade.
+type InstallationUpgradeState string
+
+const (
+	// InstallationUpgradePending is the state of the installations waiting
+	// for their batch.
+	InstallationUpgradePending InstallationUpgradeState = "pending"
+	// InstallationUpgradeUpgrading is the state of the installations being
+	// upgraded.
+	InstallationUpgradeUpgrading InstallationUpgradeState = "upgrading"
+	// InstallationUpgradeUpgraded is the state of the installations running
+	// the new version stably.
+	InstallationUpgradeUpgraded InstallationUpgradeState = "upgraded"
+	// InstallationUpgradeFailed is the state of the installations which did
+	// not run the new version stably within the timeout, or rolled back.
+	InstallationUpgradeFailed InstallationUpgradeState = "failed"
+)
+
+// InstallationUpgradeStatus is the result of the upgrade of an installation.
+type InstallationUpgradeStatus struct {
+	// The namespace of the Mattermost installation
+	Namespace string `json:"namespace"`
+	// The name of the Mattermost installation
+	Name string `json:"name"`
+	// Represents the state of the upgrade of the installation
+	State InstallationUpgradeState `json:"state"`
+	// The version of the installation before the upgrade
+	// +optional
+	FromVersion string `json:"fromVersion,omitempty"`
+	// The reason why the upgrade failed
+	// +optional
+	Message string `json:"message,omitempty"`
+	// The time when the upgrade of the installation started
+	// +optional
+	StartTime *metav1.Time `json:"startTime,omitempty"`
+	// The time when the installation was upgraded or failed
+	// +optional
+	CompletionTime *metav1.Time `json:"completionTime,omitempty"`
+}
+
+// MattermostUpgradeStatus defines the observed state of MattermostUpgrade
+type MattermostUpgradeStatus struct {
+	// Represents the state of the rollout
+	// +optional
+	State MattermostUpgradeState `json:"state,omitempty"`
+	// The version rolled out, the rollout restarts once the version of the
+	// spec changes.
+	// +optional
+	Version string `json:"version,omitempty"`
+	// The number of installations selected
+	// +optional
+	Total int32 `json:"total,omitempty"`
+	// The number of installations upgraded
+	// +optional
+	Upgraded int32 `json:"upgraded,omitempty"`
+	// The number of installations which failed to upgrade
+	// +optional
+	Failed int32 `json:"failed,omitempty"`
+	// The result of the upgrade of each selected installation, in upgrade
+	// order.
+	// +optional
+	Installations []InstallationUpgradeStatus `json:"installations,omitempty"`
+	// The time when the rollout started
+	// +optional
+	StartTime *metav1.Time `json:"startTime,omitempty"`
+	// The time when the rollout completed or failed
+	// +optional
+	CompletionTime *metav1.Time `json:"completionTime,omitempty"`
+	// The last observed Generation of the MattermostUpgrade resource that
+	// was acted on.
+	// +optional
+	ObservedGeneration int64 `json:"observedGeneration,omitempty"`
+}
+
+// MattermostUpgrade is the Schema for the mattermostupgrades API
+// +kubebuilder:object:root=true
+// +kubebuilder:subresource:status
+// +kubebuilder:resource:scope=Cluster
+// +kubebuilder:printcolumn:priority=0,name="Version",type=string,JSONPath=".spec.version",description="Version the installations are upgraded to"
+// +kubebuilder:printcolumn:priority=0,name="State",type=string,JSONPath=".status.state",description="State of the rollout"
+// +kubebuilder:printcolumn:priority=0,name="Upgraded",type=integer,JSONPath=".status.upgraded",description="Number of upgraded installations"
+// +kubebuilder:printcolumn:priority=0,name="Failed",type=integer,JSONPath=".status.failed",description="Number of installations which failed to upgrade"
+// +kubebuilder:printcolumn:priority=0,name="Total",type=integer,JSONPath=".status.total",description="Number of selected installations"
+type MattermostUpgrade struct {
+	metav1.TypeMeta   `json:",inline"`
+	metav1.ObjectMeta `json:"metadata,omitempty"`
+
+	Spec   MattermostUpgradeSpec   `json:"spec,omitempty"`
+	Status MattermostUpgradeStatus `json:"status,omitempty"`
+}
+
+// +kubebuilder:object:root=true
+
+// MattermostUpgradeList contains a list of MattermostUpgrade
+type MattermostUpgradeList struct {
+	metav1.TypeMeta `json:",inline"`
+	metav1.ListMeta `json:"metadata,omitempty"`
+	Items           []MattermostUpgrade `json:"items"`
+}
+
+func init() {
+	SchemeBuilder.Register(&MattermostUpgrade{}, &MattermostUpgradeList{})
+}
@@ -0,0 +1,32 @@
+// Copyright (c) 2017-present Mattermost, Inc. All Rights Reserved.
+// See License.txt for license information.
+
+package v1beta1
+
+import "time"
+
+const (
+	// DefaultUpgradeBatchSize is the default number of installations
+	// upgraded at the same time by a MattermostUpgrade
+	DefaultUpgradeBatchSize = 1
+	// DefaultUpgradeTimeout is the default time an installation has to run
+	// the new version stably once its upgrade started
+	DefaultUpgradeTimeout = 30 * time.Minute
+)
+
+// GetBatchSize returns how many installations are upgraded at the same time.
+func (s *MattermostUpgradeSpec) GetBatchSize() int {
+	if s.BatchSize <= 0 {
+		return DefaultUpgradeBatchSize
+	}
+	return int(s.BatchSize)
+}
+
+// GetTimeout returns how long an installation has to run the new version
+// stably once its upgrade started.
+func (s *MattermostUpgradeSpec) GetTimeout() time.Duration {
+	if s.Timeout == nil || s.Timeout.Duration <= 0 {
+		return DefaultUpgradeTimeout
+	}
+	return s.Timeout.Duration
+}
@@ -9,390 +9,2175 @@ package v1beta1
 
 import (
 	"k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	runtime "k8s.io/apimachinery/pkg/runtime"
 )
 
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
-func (in *Database) DeepCopyInto(out *Database) {
+func (in *AppDeployment) DeepCopyInto(out *AppDeployment) {
 	*out = *in
-	if in.External != nil {
-		in, out := &in.External, &out.External
-		*out = new(ExternalDatabase)
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new AppDeployment.
+func (in *AppDeployment) DeepCopy() *AppDeployment {
+	if in == nil {
+		return nil
+	}
+	out := new(AppDeployment)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ApplicationHealthStatus) DeepCopyInto(out *ApplicationHealthStatus) {
+	*out = *in
+	if in.LastCheckTime != nil {
+		in, out := &in.LastCheckTime, &out.LastCheckTime
+		*out = (*in).DeepCopy()
+	}
+	if in.Latency != nil {
+		in, out := &in.Latency, &out.Latency
+		*out = new(metav1.Duration)
 		**out = **in
 	}
-	if in.OperatorManaged != nil {
-		in, out := &in.OperatorManaged, &out.OperatorManaged
-		*out = new(OperatorManagedDatabase)
-		(*in).DeepCopyInto(*out)
+	if in.LastRestartTime != nil {
+		in, out := &in.LastRestartTime, &out.LastRestartTime
+		*out = (*in).DeepCopy()
 	}
 }
 
-// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new Database.
-func (in *Database) DeepCopy() *Database {
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ApplicationHealthStatus.
+func (in *ApplicationHealthStatus) DeepCopy() *ApplicationHealthStatus {
 	if in == nil {
 		return nil
 	}
-	out := new(Database)
+	out := new(ApplicationHealthStatus)
 	in.DeepCopyInto(out)
 	return out
 }
 
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
-func (in *ElasticSearch) DeepCopyInto(out *ElasticSearch) {
+func (in *AutoSizing) DeepCopyInto(out *AutoSizing) {
 	*out = *in
+	if in.CheckInterval != nil {
+		in, out := &in.CheckInterval, &out.CheckInterval
+		*out = new(metav1.Duration)
+		**out = **in
+	}
 }
 
-// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ElasticSearch.
-func (in *ElasticSearch) DeepCopy() *ElasticSearch {
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new AutoSizing.
+func (in *AutoSizing) DeepCopy() *AutoSizing {
 	if in == nil {
 		return nil
 	}
-	out := new(ElasticSearch)
+	out := new(AutoSizing)
 	in.DeepCopyInto(out)
 	return out
 }
 
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
-func (in *ExternalDatabase) DeepCopyInto(out *ExternalDatabase) {
+func (in *AutoSizingStatus) DeepCopyInto(out *AutoSizingStatus) {
 	*out = *in
+	if in.LastCheckTime != nil {
+		in, out := &in.LastCheckTime, &out.LastCheckTime
+		*out = (*in).DeepCopy()
+	}
 }
 
-// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ExternalDatabase.
-func (in *ExternalDatabase) DeepCopy() *ExternalDatabase {
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new AutoSizingStatus.
+func (in *AutoSizingStatus) DeepCopy() *AutoSizingStatus {
 	if in == nil {
 		return nil
 	}
-	out := new(ExternalDatabase)
+	out := new(AutoSizingStatus)
 	in.DeepCopyInto(out)
 	return out
 }
 
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
-func (in *ExternalFileStore) DeepCopyInto(out *ExternalFileStore) {
+func (in *AvailableUpdateStatus) DeepCopyInto(out *AvailableUpdateStatus) {
 	*out = *in
+	if in.LastCheckTime != nil {
+		in, out := &in.LastCheckTime, &out.LastCheckTime
+		*out = (*in).DeepCopy()
+	}
 }
 
-// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ExternalFileStore.
-func (in *ExternalFileStore) DeepCopy() *ExternalFileStore {
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new AvailableUpdateStatus.
+func (in *AvailableUpdateStatus) DeepCopy() *AvailableUpdateStatus {
 	if in == nil {
 		return nil
 	}
-	out := new(ExternalFileStore)
+	out := new(AvailableUpdateStatus)
 	in.DeepCopyInto(out)
 	return out
 }
 
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
-func (in *FileStore) DeepCopyInto(out *FileStore) {
+func (in *BackupDestination) DeepCopyInto(out *BackupDestination) {
 	*out = *in
-	if in.External != nil {
-		in, out := &in.External, &out.External
-		*out = new(ExternalFileStore)
-		**out = **in
-	}
-	if in.OperatorManaged != nil {
-		in, out := &in.OperatorManaged, &out.OperatorManaged
-		*out = new(OperatorManagedMinio)
-		(*in).DeepCopyInto(*out)
-	}
 }
 
-// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new FileStore.
-func (in *FileStore) DeepCopy() *FileStore {
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new BackupDestination.
+func (in *BackupDestination) DeepCopy() *BackupDestination {
 	if in == nil {
 		return nil
 	}
-	out := new(FileStore)
+	out := new(BackupDestination)
 	in.DeepCopyInto(out)
 	return out
 }
 
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
-func (in *Ingress) DeepCopyInto(out *Ingress) {
+func (in *BackupResult) DeepCopyInto(out *BackupResult) {
 	*out = *in
-	if in.Annotations != nil {
-		in, out := &in.Annotations, &out.Annotations
-		*out = make(map[string]string, len(*in))
-		for key, val := range *in {
-			(*out)[key] = val
-		}
+	if in.StartTime != nil {
+		in, out := &in.StartTime, &out.StartTime
+		*out = (*in).DeepCopy()
+	}
+	if in.CompletionTime != nil {
+		in, out := &in.CompletionTime, &out.CompletionTime
+		*out = (*in).DeepCopy()
 	}
 }
 
-// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new Ingress.
-func (in *Ingress) DeepCopy() *Ingress {
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new BackupResult.
+func (in *BackupResult) DeepCopy() *BackupResult {
 	if in == nil {
 		return nil
 	}
-	out := new(Ingress)
+	out := new(BackupResult)
 	in.DeepCopyInto(out)
 	return out
 }
 
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
-func (in *Mattermost) DeepCopyInto(out *Mattermost) {
+func (in *BackupRetention) DeepCopyInto(out *BackupRetention) {
 	*out = *in
-	out.TypeMeta = in.TypeMeta
-	in.ObjectMeta.DeepCopyInto(&out.ObjectMeta)
-	in.Spec.DeepCopyInto(&out.Spec)
-	out.Status = in.Status
 }
 
-// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new Mattermost.
-func (in *Mattermost) DeepCopy() *Mattermost {
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new BackupRetention.
+func (in *BackupRetention) DeepCopy() *BackupRetention {
 	if in == nil {
 		return nil
 	}
-	out := new(Mattermost)
+	out := new(BackupRetention)
 	in.DeepCopyInto(out)
 	return out
 }
 
-// DeepCopyObject is an autogenerated deepcopy function, copying the receiver, creating a new runtime.Object.
-func (in *Mattermost) DeepCopyObject() runtime.Object {
-	if c := in.DeepCopy(); c != nil {
-		return c
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *BlueGreen) DeepCopyInto(out *BlueGreen) {
+	*out = *in
+	out.Blue = in.Blue
+	out.Green = in.Green
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new BlueGreen.
+func (in *BlueGreen) DeepCopy() *BlueGreen {
+	if in == nil {
+		return nil
 	}
-	return nil
+	out := new(BlueGreen)
+	in.DeepCopyInto(out)
+	return out
 }
 
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
-func (in *MattermostList) DeepCopyInto(out *MattermostList) {
+func (in *Canary) DeepCopyInto(out *Canary) {
 	*out = *in
-	out.TypeMeta = in.TypeMeta
-	in.ListMeta.DeepCopyInto(&out.ListMeta)
-	if in.Items != nil {
-		in, out := &in.Items, &out.Items
-		*out = make([]Mattermost, len(*in))
-		for i := range *in {
-			(*in)[i].DeepCopyInto(&(*out)[i])
-		}
-	}
+	out.Deployment = in.Deployment
 }
 
-// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new MattermostList.
-func (in *MattermostList) DeepCopy() *MattermostList {
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new Canary.
+func (in *Canary) DeepCopy() *Canary {
 	if in == nil {
 		return nil
 	}
-	out := new(MattermostList)
+	out := new(Canary)
 	in.DeepCopyInto(out)
 	return out
 }
 
-// DeepCopyObject is an autogenerated deepcopy function, copying the receiver, creating a new runtime.Object.
-func (in *MattermostList) DeepCopyObject() runtime.Object {
-	if c := in.DeepCopy(); c != nil {
-		return c
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ChannelUpdateStatus) DeepCopyInto(out *ChannelUpdateStatus) {
+	*out = *in
+	if in.LastTransitionTime != nil {
+		in, out := &in.LastTransitionTime, &out.LastTransitionTime
+		*out = (*in).DeepCopy()
 	}
-	return nil
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ChannelUpdateStatus.
+func (in *ChannelUpdateStatus) DeepCopy() *ChannelUpdateStatus {
+	if in == nil {
+		return nil
+	}
+	out := new(ChannelUpdateStatus)
+	in.DeepCopyInto(out)
+	return out
 }
 
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
-func (in *MattermostSpec) DeepCopyInto(out *MattermostSpec) {
+func (in *ClusterReadinessGate) DeepCopyInto(out *ClusterReadinessGate) {
 	*out = *in
-	if in.Replicas != nil {
-		in, out := &in.Replicas, &out.Replicas
-		*out = new(int32)
-		**out = **in
-	}
-	if in.MattermostEnv != nil {
-		in, out := &in.MattermostEnv, &out.MattermostEnv
-		*out = make([]v1.EnvVar, len(*in))
-		for i := range *in {
-			(*in)[i].DeepCopyInto(&(*out)[i])
-		}
-	}
-	if in.IngressAnnotations != nil {
-		in, out := &in.IngressAnnotations, &out.IngressAnnotations
-		*out = make(map[string]string, len(*in))
-		for key, val := range *in {
-			(*out)[key] = val
-		}
-	}
-	if in.ServiceAnnotations != nil {
-		in, out := &in.ServiceAnnotations, &out.ServiceAnnotations
-		*out = make(map[string]string, len(*in))
-		for key, val := range *in {
-			(*out)[key] = val
-		}
-	}
-	if in.ResourceLabels != nil {
-		in, out := &in.ResourceLabels, &out.ResourceLabels
-		*out = make(map[string]string, len(*in))
-		for key, val := range *in {
-			(*out)[key] = val
-		}
-	}
-	if in.Ingress != nil {
-		in, out := &in.Ingress, &out.Ingress
-		*out = new(Ingress)
-		(*in).DeepCopyInto(*out)
-	}
-	if in.Volumes != nil {
-		in, out := &in.Volumes, &out.Volumes
-		*out = make([]v1.Volume, len(*in))
-		for i := range *in {
-			(*in)[i].DeepCopyInto(&(*out)[i])
-		}
-	}
-	if in.VolumeMounts != nil {
-		in, out := &in.VolumeMounts, &out.VolumeMounts
-		*out = make([]v1.VolumeMount, len(*in))
-		for i := range *in {
-			(*in)[i].DeepCopyInto(&(*out)[i])
-		}
-	}
-	if in.ImagePullSecrets != nil {
-		in, out := &in.ImagePullSecrets, &out.ImagePullSecrets
-		*out = make([]v1.LocalObjectReference, len(*in))
-		copy(*out, *in)
-	}
-	in.Database.DeepCopyInto(&out.Database)
-	in.FileStore.DeepCopyInto(&out.FileStore)
-	out.ElasticSearch = in.ElasticSearch
-	in.Scheduling.DeepCopyInto(&out.Scheduling)
-	in.Probes.DeepCopyInto(&out.Probes)
-	in.PodExtensions.DeepCopyInto(&out.PodExtensions)
 }
 
-// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new MattermostSpec.
-func (in *MattermostSpec) DeepCopy() *MattermostSpec {
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ClusterReadinessGate.
+func (in *ClusterReadinessGate) DeepCopy() *ClusterReadinessGate {
 	if in == nil {
 		return nil
 	}
-	out := new(MattermostSpec)
+	out := new(ClusterReadinessGate)
 	in.DeepCopyInto(out)
 	return out
 }
 
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
-func (in *MattermostStatus) DeepCopyInto(out *MattermostStatus) {
+func (in *ComponentStatus) DeepCopyInto(out *ComponentStatus) {
 	*out = *in
 }
 
-// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new MattermostStatus.
-func (in *MattermostStatus) DeepCopy() *MattermostStatus {
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ComponentStatus.
+func (in *ComponentStatus) DeepCopy() *ComponentStatus {
 	if in == nil {
 		return nil
 	}
-	out := new(MattermostStatus)
+	out := new(ComponentStatus)
 	in.DeepCopyInto(out)
 	return out
 }
 
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
-func (in *OperatorManagedDatabase) DeepCopyInto(out *OperatorManagedDatabase) {
+func (in *Database) DeepCopyInto(out *Database) {
 	*out = *in
-	if in.Replicas != nil {
-		in, out := &in.Replicas, &out.Replicas
-		*out = new(int32)
+	if in.External != nil {
+		in, out := &in.External, &out.External
+		*out = new(ExternalDatabase)
 		**out = **in
 	}
-	in.Resources.DeepCopyInto(&out.Resources)
+	if in.OperatorManaged != nil {
+		in, out := &in.OperatorManaged, &out.OperatorManaged
+		*out = new(OperatorManagedDatabase)
+		(*in).DeepCopyInto(*out)
+	}
 }
 
-// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new OperatorManagedDatabase.
-func (in *OperatorManagedDatabase) DeepCopy() *OperatorManagedDatabase {
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new Database.
+func (in *Database) DeepCopy() *Database {
 	if in == nil {
 		return nil
 	}
-	out := new(OperatorManagedDatabase)
+	out := new(Database)
 	in.DeepCopyInto(out)
 	return out
 }
 
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
-func (in *OperatorManagedMinio) DeepCopyInto(out *OperatorManagedMinio) {
+func (in *ECRCredentials) DeepCopyInto(out *ECRCredentials) {
 	*out = *in
-	if in.Replicas != nil {
-		in, out := &in.Replicas, &out.Replicas
-		*out = new(int32)
-		**out = **in
-	}
-	in.Resources.DeepCopyInto(&out.Resources)
 }
 
-// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new OperatorManagedMinio.
-func (in *OperatorManagedMinio) DeepCopy() *OperatorManagedMinio {
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ECRCredentials.
+func (in *ECRCredentials) DeepCopy() *ECRCredentials {
 	if in == nil {
 		return nil
 	}
-	out := new(OperatorManagedMinio)
+	out := new(ECRCredentials)
 	in.DeepCopyInto(out)
 	return out
 }
 
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
-func (in *PodExtensions) DeepCopyInto(out *PodExtensions) {
+func (in *ElasticSearch) DeepCopyInto(out *ElasticSearch) {
 	*out = *in
-	if in.InitContainers != nil {
-		in, out := &in.InitContainers, &out.InitContainers
-		*out = make([]v1.Container, len(*in))
-		for i := range *in {
-			(*in)[i].DeepCopyInto(&(*out)[i])
-		}
-	}
 }
 
-// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new PodExtensions.
-func (in *PodExtensions) DeepCopy() *PodExtensions {
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ElasticSearch.
+func (in *ElasticSearch) DeepCopy() *ElasticSearch {
 	if in == nil {
 		return nil
 	}
-	out := new(PodExtensions)
+	out := new(ElasticSearch)
 	in.DeepCopyInto(out)
 	return out
 }
 
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
-func (in *Probes) DeepCopyInto(out *Probes) {
+func (in *ExportJob) DeepCopyInto(out *ExportJob) {
 	*out = *in
-	in.LivenessProbe.DeepCopyInto(&out.LivenessProbe)
-	in.ReadinessProbe.DeepCopyInto(&out.ReadinessProbe)
+	out.Destination = in.Destination
 }
 
-// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new Probes.
-func (in *Probes) DeepCopy() *Probes {
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ExportJob.
+func (in *ExportJob) DeepCopy() *ExportJob {
 	if in == nil {
 		return nil
 	}
-	out := new(Probes)
+	out := new(ExportJob)
 	in.DeepCopyInto(out)
 	return out
 }
 
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
-func (in *Scheduling) DeepCopyInto(out *Scheduling) {
+func (in *ExportStatus) DeepCopyInto(out *ExportStatus) {
 	*out = *in
-	in.Resources.DeepCopyInto(&out.Resources)
-	if in.NodeSelector != nil {
-		in, out := &in.NodeSelector, &out.NodeSelector
-		*out = make(map[string]string, len(*in))
-		for key, val := range *in {
-			(*out)[key] = val
-		}
-	}
-	if in.Affinity != nil {
-		in, out := &in.Affinity, &out.Affinity
-		*out = new(v1.Affinity)
-		(*in).DeepCopyInto(*out)
+	if in.StartTime != nil {
+		in, out := &in.StartTime, &out.StartTime
+		*out = (*in).DeepCopy()
 	}
-	if in.Tolerations != nil {
-		in, out := &in.Tolerations, &out.Tolerations
-		*out = make([]v1.Toleration, len(*in))
-		for i := range *in {
-			(*in)[i].DeepCopyInto(&(*out)[i])
-		}
+	if in.CompletionTime != nil {
+		in, out := &in.CompletionTime, &out.CompletionTime
+		*out = (*in).DeepCopy()
 	}
 }
 
-// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new Scheduling.
-func (in *Scheduling) DeepCopy() *Scheduling {
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ExportStatus.
+func (in *ExportStatus) DeepCopy() *ExportStatus {
 	if in == nil {
 		return nil
 	}
-	out := new(Scheduling)
+	out := new(ExportStatus)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ExternalDatabase) DeepCopyInto(out *ExternalDatabase) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ExternalDatabase.
+func (in *ExternalDatabase) DeepCopy() *ExternalDatabase {
+	if in == nil {
+		return nil
+	}
+	out := new(ExternalDatabase)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ExternalFileStore) DeepCopyInto(out *ExternalFileStore) {
+	*out = *in
+	if in.ServerSideEncryption != nil {
+		in, out := &in.ServerSideEncryption, &out.ServerSideEncryption
+		*out = new(FileStoreEncryption)
+		**out = **in
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ExternalFileStore.
+func (in *ExternalFileStore) DeepCopy() *ExternalFileStore {
+	if in == nil {
+		return nil
+	}
+	out := new(ExternalFileStore)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *FileStore) DeepCopyInto(out *FileStore) {
+	*out = *in
+	if in.External != nil {
+		in, out := &in.External, &out.External
+		*out = new(ExternalFileStore)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.OperatorManaged != nil {
+		in, out := &in.OperatorManaged, &out.OperatorManaged
+		*out = new(OperatorManagedMinio)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.Lifecycle != nil {
+		in, out := &in.Lifecycle, &out.Lifecycle
+		*out = new(FileStoreLifecycle)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.MigrateTo != nil {
+		in, out := &in.MigrateTo, &out.MigrateTo
+		*out = new(ExternalFileStore)
+		(*in).DeepCopyInto(*out)
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new FileStore.
+func (in *FileStore) DeepCopy() *FileStore {
+	if in == nil {
+		return nil
+	}
+	out := new(FileStore)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *FileStoreEncryption) DeepCopyInto(out *FileStoreEncryption) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new FileStoreEncryption.
+func (in *FileStoreEncryption) DeepCopy() *FileStoreEncryption {
+	if in == nil {
+		return nil
+	}
+	out := new(FileStoreEncryption)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *FileStoreLifecycle) DeepCopyInto(out *FileStoreLifecycle) {
+	*out = *in
+	if in.Rules != nil {
+		in, out := &in.Rules, &out.Rules
+		*out = make([]FileStoreLifecycleRule, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new FileStoreLifecycle.
+func (in *FileStoreLifecycle) DeepCopy() *FileStoreLifecycle {
+	if in == nil {
+		return nil
+	}
+	out := new(FileStoreLifecycle)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *FileStoreLifecycleRule) DeepCopyInto(out *FileStoreLifecycleRule) {
+	*out = *in
+	if in.ExpirationDays != nil {
+		in, out := &in.ExpirationDays, &out.ExpirationDays
+		*out = new(int32)
+		**out = **in
+	}
+	if in.TransitionDays != nil {
+		in, out := &in.TransitionDays, &out.TransitionDays
+		*out = new(int32)
+		**out = **in
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new FileStoreLifecycleRule.
+func (in *FileStoreLifecycleRule) DeepCopy() *FileStoreLifecycleRule {
+	if in == nil {
+		return nil
+	}
+	out := new(FileStoreLifecycleRule)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *FileStoreMigrationStatus) DeepCopyInto(out *FileStoreMigrationStatus) {
+	*out = *in
+	if in.StartTime != nil {
+		in, out := &in.StartTime, &out.StartTime
+		*out = (*in).DeepCopy()
+	}
+	if in.CompletionTime != nil {
+		in, out := &in.CompletionTime, &out.CompletionTime
+		*out = (*in).DeepCopy()
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new FileStoreMigrationStatus.
+func (in *FileStoreMigrationStatus) DeepCopy() *FileStoreMigrationStatus {
+	if in == nil {
+		return nil
+	}
+	out := new(FileStoreMigrationStatus)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ImageVerification) DeepCopyInto(out *ImageVerification) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ImageVerification.
+func (in *ImageVerification) DeepCopy() *ImageVerification {
+	if in == nil {
+		return nil
+	}
+	out := new(ImageVerification)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ImageVerificationStatus) DeepCopyInto(out *ImageVerificationStatus) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ImageVerificationStatus.
+func (in *ImageVerificationStatus) DeepCopy() *ImageVerificationStatus {
+	if in == nil {
+		return nil
+	}
+	out := new(ImageVerificationStatus)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ImportJob) DeepCopyInto(out *ImportJob) {
+	*out = *in
+	out.Source = in.Source
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ImportJob.
+func (in *ImportJob) DeepCopy() *ImportJob {
+	if in == nil {
+		return nil
+	}
+	out := new(ImportJob)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ImportStatus) DeepCopyInto(out *ImportStatus) {
+	*out = *in
+	if in.StartTime != nil {
+		in, out := &in.StartTime, &out.StartTime
+		*out = (*in).DeepCopy()
+	}
+	if in.CompletionTime != nil {
+		in, out := &in.CompletionTime, &out.CompletionTime
+		*out = (*in).DeepCopy()
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ImportStatus.
+func (in *ImportStatus) DeepCopy() *ImportStatus {
+	if in == nil {
+		return nil
+	}
+	out := new(ImportStatus)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *Ingress) DeepCopyInto(out *Ingress) {
+	*out = *in
+	if in.Annotations != nil {
+		in, out := &in.Annotations, &out.Annotations
+		*out = make(map[string]string, len(*in))
+		for key, val := range *in {
+			(*out)[key] = val
+		}
+	}
+	if in.ExtraPaths != nil {
+		in, out := &in.ExtraPaths, &out.ExtraPaths
+		*out = make([]IngressPath, len(*in))
+		copy(*out, *in)
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new Ingress.
+func (in *Ingress) DeepCopy() *Ingress {
+	if in == nil {
+		return nil
+	}
+	out := new(Ingress)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *IngressPath) DeepCopyInto(out *IngressPath) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new IngressPath.
+func (in *IngressPath) DeepCopy() *IngressPath {
+	if in == nil {
+		return nil
+	}
+	out := new(IngressPath)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *InstallationUpgradeStatus) DeepCopyInto(out *InstallationUpgradeStatus) {
+	*out = *in
+	if in.StartTime != nil {
+		in, out := &in.StartTime, &out.StartTime
+		*out = (*in).DeepCopy()
+	}
+	if in.CompletionTime != nil {
+		in, out := &in.CompletionTime, &out.CompletionTime
+		*out = (*in).DeepCopy()
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new InstallationUpgradeStatus.
+func (in *InstallationUpgradeStatus) DeepCopy() *InstallationUpgradeStatus {
+	if in == nil {
+		return nil
+	}
+	out := new(InstallationUpgradeStatus)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *JobPolicy) DeepCopyInto(out *JobPolicy) {
+	*out = *in
+	if in.BackoffLimit != nil {
+		in, out := &in.BackoffLimit, &out.BackoffLimit
+		*out = new(int32)
+		**out = **in
+	}
+	if in.ActiveDeadlineSeconds != nil {
+		in, out := &in.ActiveDeadlineSeconds, &out.ActiveDeadlineSeconds
+		*out = new(int64)
+		**out = **in
+	}
+	if in.TTLSecondsAfterFinished != nil {
+		in, out := &in.TTLSecondsAfterFinished, &out.TTLSecondsAfterFinished
+		*out = new(int32)
+		**out = **in
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new JobPolicy.
+func (in *JobPolicy) DeepCopy() *JobPolicy {
+	if in == nil {
+		return nil
+	}
+	out := new(JobPolicy)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *Jobs) DeepCopyInto(out *Jobs) {
+	*out = *in
+	if in.Export != nil {
+		in, out := &in.Export, &out.Export
+		*out = new(ExportJob)
+		**out = **in
+	}
+	if in.Import != nil {
+		in, out := &in.Import, &out.Import
+		*out = new(ImportJob)
+		**out = **in
+	}
+	if in.UpdateCheck != nil {
+		in, out := &in.UpdateCheck, &out.UpdateCheck
+		*out = new(UpdateCheckJob)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.DatabaseSetup != nil {
+		in, out := &in.DatabaseSetup, &out.DatabaseSetup
+		*out = new(JobPolicy)
+		(*in).DeepCopyInto(*out)
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new Jobs.
+func (in *Jobs) DeepCopy() *Jobs {
+	if in == nil {
+		return nil
+	}
+	out := new(Jobs)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *LastErrorStatus) DeepCopyInto(out *LastErrorStatus) {
+	*out = *in
+	if in.Time != nil {
+		in, out := &in.Time, &out.Time
+		*out = (*in).DeepCopy()
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new LastErrorStatus.
+func (in *LastErrorStatus) DeepCopy() *LastErrorStatus {
+	if in == nil {
+		return nil
+	}
+	out := new(LastErrorStatus)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *MaintenanceMode) DeepCopyInto(out *MaintenanceMode) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new MaintenanceMode.
+func (in *MaintenanceMode) DeepCopy() *MaintenanceMode {
+	if in == nil {
+		return nil
+	}
+	out := new(MaintenanceMode)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *MaintenanceModeStatus) DeepCopyInto(out *MaintenanceModeStatus) {
+	*out = *in
+	if in.StartTime != nil {
+		in, out := &in.StartTime, &out.StartTime
+		*out = (*in).DeepCopy()
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new MaintenanceModeStatus.
+func (in *MaintenanceModeStatus) DeepCopy() *MaintenanceModeStatus {
+	if in == nil {
+		return nil
+	}
+	out := new(MaintenanceModeStatus)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *MaintenanceWindow) DeepCopyInto(out *MaintenanceWindow) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new MaintenanceWindow.
+func (in *MaintenanceWindow) DeepCopy() *MaintenanceWindow {
+	if in == nil {
+		return nil
+	}
+	out := new(MaintenanceWindow)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *Mattermost) DeepCopyInto(out *Mattermost) {
+	*out = *in
+	out.TypeMeta = in.TypeMeta
+	in.ObjectMeta.DeepCopyInto(&out.ObjectMeta)
+	in.Spec.DeepCopyInto(&out.Spec)
+	in.Status.DeepCopyInto(&out.Status)
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new Mattermost.
+func (in *Mattermost) DeepCopy() *Mattermost {
+	if in == nil {
+		return nil
+	}
+	out := new(Mattermost)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyObject is an autogenerated deepcopy function, copying the receiver, creating a new runtime.Object.
+func (in *Mattermost) DeepCopyObject() runtime.Object {
+	if c := in.DeepCopy(); c != nil {
+		return c
+	}
+	return nil
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *MattermostBackup) DeepCopyInto(out *MattermostBackup) {
+	*out = *in
+	out.TypeMeta = in.TypeMeta
+	in.ObjectMeta.DeepCopyInto(&out.ObjectMeta)
+	in.Spec.DeepCopyInto(&out.Spec)
+	in.Status.DeepCopyInto(&out.Status)
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new MattermostBackup.
+func (in *MattermostBackup) DeepCopy() *MattermostBackup {
+	if in == nil {
+		return nil
+	}
+	out := new(MattermostBackup)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyObject is an autogenerated deepcopy function, copying the receiver, creating a new runtime.Object.
+func (in *MattermostBackup) DeepCopyObject() runtime.Object {
+	if c := in.DeepCopy(); c != nil {
+		return c
+	}
+	return nil
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *MattermostBackupList) DeepCopyInto(out *MattermostBackupList) {
+	*out = *in
+	out.TypeMeta = in.TypeMeta
+	in.ListMeta.DeepCopyInto(&out.ListMeta)
+	if in.Items != nil {
+		in, out := &in.Items, &out.Items
+		*out = make([]MattermostBackup, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new MattermostBackupList.
+func (in *MattermostBackupList) DeepCopy() *MattermostBackupList {
+	if in == nil {
+		return nil
+	}
+	out := new(MattermostBackupList)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyObject is an autogenerated deepcopy function, copying the receiver, creating a new runtime.Object.
+func (in *MattermostBackupList) DeepCopyObject() runtime.Object {
+	if c := in.DeepCopy(); c != nil {
+		return c
+	}
+	return nil
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *MattermostBackupSpec) DeepCopyInto(out *MattermostBackupSpec) {
+	*out = *in
+	out.Destination = in.Destination
+	if in.Retention != nil {
+		in, out := &in.Retention, &out.Retention
+		*out = new(BackupRetention)
+		**out = **in
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new MattermostBackupSpec.
+func (in *MattermostBackupSpec) DeepCopy() *MattermostBackupSpec {
+	if in == nil {
+		return nil
+	}
+	out := new(MattermostBackupSpec)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *MattermostBackupStatus) DeepCopyInto(out *MattermostBackupStatus) {
+	*out = *in
+	if in.LastBackup != nil {
+		in, out := &in.LastBackup, &out.LastBackup
+		*out = new(BackupResult)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.LastSuccessfulBackup != nil {
+		in, out := &in.LastSuccessfulBackup, &out.LastSuccessfulBackup
+		*out = new(BackupResult)
+		(*in).DeepCopyInto(*out)
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new MattermostBackupStatus.
+func (in *MattermostBackupStatus) DeepCopy() *MattermostBackupStatus {
+	if in == nil {
+		return nil
+	}
+	out := new(MattermostBackupStatus)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *MattermostList) DeepCopyInto(out *MattermostList) {
+	*out = *in
+	out.TypeMeta = in.TypeMeta
+	in.ListMeta.DeepCopyInto(&out.ListMeta)
+	if in.Items != nil {
+		in, out := &in.Items, &out.Items
+		*out = make([]Mattermost, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new MattermostList.
+func (in *MattermostList) DeepCopy() *MattermostList {
+	if in == nil {
+		return nil
+	}
+	out := new(MattermostList)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyObject is an autogenerated deepcopy function, copying the receiver, creating a new runtime.Object.
+func (in *MattermostList) DeepCopyObject() runtime.Object {
+	if c := in.DeepCopy(); c != nil {
+		return c
+	}
+	return nil
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *MattermostRestore) DeepCopyInto(out *MattermostRestore) {
+	*out = *in
+	out.TypeMeta = in.TypeMeta
+	in.ObjectMeta.DeepCopyInto(&out.ObjectMeta)
+	in.Spec.DeepCopyInto(&out.Spec)
+	in.Status.DeepCopyInto(&out.Status)
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new MattermostRestore.
+func (in *MattermostRestore) DeepCopy() *MattermostRestore {
+	if in == nil {
+		return nil
+	}
+	out := new(MattermostRestore)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyObject is an autogenerated deepcopy function, copying the receiver, creating a new runtime.Object.
+func (in *MattermostRestore) DeepCopyObject() runtime.Object {
+	if c := in.DeepCopy(); c != nil {
+		return c
+	}
+	return nil
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *MattermostRestoreList) DeepCopyInto(out *MattermostRestoreList) {
+	*out = *in
+	out.TypeMeta = in.TypeMeta
+	in.ListMeta.DeepCopyInto(&out.ListMeta)
+	if in.Items != nil {
+		in, out := &in.Items, &out.Items
+		*out = make([]MattermostRestore, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new MattermostRestoreList.
+func (in *MattermostRestoreList) DeepCopy() *MattermostRestoreList {
+	if in == nil {
+		return nil
+	}
+	out := new(MattermostRestoreList)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyObject is an autogenerated deepcopy function, copying the receiver, creating a new runtime.Object.
+func (in *MattermostRestoreList) DeepCopyObject() runtime.Object {
+	if c := in.DeepCopy(); c != nil {
+		return c
+	}
+	return nil
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *MattermostRestoreSpec) DeepCopyInto(out *MattermostRestoreSpec) {
+	*out = *in
+	out.Source = in.Source
+	if in.JobPolicy != nil {
+		in, out := &in.JobPolicy, &out.JobPolicy
+		*out = new(JobPolicy)
+		(*in).DeepCopyInto(*out)
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new MattermostRestoreSpec.
+func (in *MattermostRestoreSpec) DeepCopy() *MattermostRestoreSpec {
+	if in == nil {
+		return nil
+	}
+	out := new(MattermostRestoreSpec)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *MattermostRestoreStatus) DeepCopyInto(out *MattermostRestoreStatus) {
+	*out = *in
+	if in.StartTime != nil {
+		in, out := &in.StartTime, &out.StartTime
+		*out = (*in).DeepCopy()
+	}
+	if in.CompletionTime != nil {
+		in, out := &in.CompletionTime, &out.CompletionTime
+		*out = (*in).DeepCopy()
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new MattermostRestoreStatus.
+func (in *MattermostRestoreStatus) DeepCopy() *MattermostRestoreStatus {
+	if in == nil {
+		return nil
+	}
+	out := new(MattermostRestoreStatus)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *MattermostSpec) DeepCopyInto(out *MattermostSpec) {
+	*out = *in
+	if in.AutoSizing != nil {
+		in, out := &in.AutoSizing, &out.AutoSizing
+		*out = new(AutoSizing)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.UtilityImages != nil {
+		in, out := &in.UtilityImages, &out.UtilityImages
+		*out = new(UtilityImages)
+		**out = **in
+	}
+	if in.UtilityResources != nil {
+		in, out := &in.UtilityResources, &out.UtilityResources
+		*out = new(v1.ResourceRequirements)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.Replicas != nil {
+		in, out := &in.Replicas, &out.Replicas
+		*out = new(int32)
+		**out = **in
+	}
+	if in.MattermostEnv != nil {
+		in, out := &in.MattermostEnv, &out.MattermostEnv
+		*out = make([]v1.EnvVar, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+	if in.ClusterReadinessGate != nil {
+		in, out := &in.ClusterReadinessGate, &out.ClusterReadinessGate
+		*out = new(ClusterReadinessGate)
+		**out = **in
+	}
+	if in.IngressAnnotations != nil {
+		in, out := &in.IngressAnnotations, &out.IngressAnnotations
+		*out = make(map[string]string, len(*in))
+		for key, val := range *in {
+			(*out)[key] = val
+		}
+	}
+	if in.ServiceAnnotations != nil {
+		in, out := &in.ServiceAnnotations, &out.ServiceAnnotations
+		*out = make(map[string]string, len(*in))
+		for key, val := range *in {
+			(*out)[key] = val
+		}
+	}
+	if in.ResourceLabels != nil {
+		in, out := &in.ResourceLabels, &out.ResourceLabels
+		*out = make(map[string]string, len(*in))
+		for key, val := range *in {
+			(*out)[key] = val
+		}
+	}
+	if in.Ingress != nil {
+		in, out := &in.Ingress, &out.Ingress
+		*out = new(Ingress)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.Volumes != nil {
+		in, out := &in.Volumes, &out.Volumes
+		*out = make([]v1.Volume, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+	if in.VolumeMounts != nil {
+		in, out := &in.VolumeMounts, &out.VolumeMounts
+		*out = make([]v1.VolumeMount, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+	if in.ImagePullSecrets != nil {
+		in, out := &in.ImagePullSecrets, &out.ImagePullSecrets
+		*out = make([]v1.LocalObjectReference, len(*in))
+		copy(*out, *in)
+	}
+	in.Database.DeepCopyInto(&out.Database)
+	in.FileStore.DeepCopyInto(&out.FileStore)
+	out.ElasticSearch = in.ElasticSearch
+	if in.Proxy != nil {
+		in, out := &in.Proxy, &out.Proxy
+		*out = new(Proxy)
+		**out = **in
+	}
+	if in.TrustedCABundle != nil {
+		in, out := &in.TrustedCABundle, &out.TrustedCABundle
+		*out = new(TrustedCABundle)
+		**out = **in
+	}
+	if in.UpgradeSnapshots != nil {
+		in, out := &in.UpgradeSnapshots, &out.UpgradeSnapshots
+		*out = new(UpgradeSnapshots)
+		**out = **in
+	}
+	if in.UpgradeRollback != nil {
+		in, out := &in.UpgradeRollback, &out.UpgradeRollback
+		*out = new(UpgradeRollback)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.SelfHealing != nil {
+		in, out := &in.SelfHealing, &out.SelfHealing
+		*out = new(SelfHealing)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.Notifications != nil {
+		in, out := &in.Notifications, &out.Notifications
+		*out = new(Notifications)
+		**out = **in
+	}
+	if in.Monitoring != nil {
+		in, out := &in.Monitoring, &out.Monitoring
+		*out = new(Monitoring)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.RollbackTo != nil {
+		in, out := &in.RollbackTo, &out.RollbackTo
+		*out = new(int64)
+		**out = **in
+	}
+	if in.VeleroBackups != nil {
+		in, out := &in.VeleroBackups, &out.VeleroBackups
+		*out = new(VeleroBackups)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.Jobs != nil {
+		in, out := &in.Jobs, &out.Jobs
+		*out = new(Jobs)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.BlueGreen != nil {
+		in, out := &in.BlueGreen, &out.BlueGreen
+		*out = new(BlueGreen)
+		**out = **in
+	}
+	if in.Canary != nil {
+		in, out := &in.Canary, &out.Canary
+		*out = new(Canary)
+		**out = **in
+	}
+	if in.UpdatePolicy != nil {
+		in, out := &in.UpdatePolicy, &out.UpdatePolicy
+		*out = new(UpdatePolicy)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.ReconcilePolicy != nil {
+		in, out := &in.ReconcilePolicy, &out.ReconcilePolicy
+		*out = new(ReconcilePolicy)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.Relocation != nil {
+		in, out := &in.Relocation, &out.Relocation
+		*out = new(Relocation)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.Standby != nil {
+		in, out := &in.Standby, &out.Standby
+		*out = new(Standby)
+		**out = **in
+	}
+	if in.MaintenanceMode != nil {
+		in, out := &in.MaintenanceMode, &out.MaintenanceMode
+		*out = new(MaintenanceMode)
+		**out = **in
+	}
+	if in.ImageVerification != nil {
+		in, out := &in.ImageVerification, &out.ImageVerification
+		*out = new(ImageVerification)
+		**out = **in
+	}
+	if in.ECRCredentials != nil {
+		in, out := &in.ECRCredentials, &out.ECRCredentials
+		*out = new(ECRCredentials)
+		**out = **in
+	}
+	in.Scheduling.DeepCopyInto(&out.Scheduling)
+	in.Probes.DeepCopyInto(&out.Probes)
+	in.PodExtensions.DeepCopyInto(&out.PodExtensions)
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new MattermostSpec.
+func (in *MattermostSpec) DeepCopy() *MattermostSpec {
+	if in == nil {
+		return nil
+	}
+	out := new(MattermostSpec)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *MattermostStatus) DeepCopyInto(out *MattermostStatus) {
+	*out = *in
+	if in.Components != nil {
+		in, out := &in.Components, &out.Components
+		*out = make([]ComponentStatus, len(*in))
+		copy(*out, *in)
+	}
+	if in.LastError != nil {
+		in, out := &in.LastError, &out.LastError
+		*out = new(LastErrorStatus)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.FileStoreMigration != nil {
+		in, out := &in.FileStoreMigration, &out.FileStoreMigration
+		*out = new(FileStoreMigrationStatus)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.VolumeResizes != nil {
+		in, out := &in.VolumeResizes, &out.VolumeResizes
+		*out = make([]VolumeResizeStatus, len(*in))
+		copy(*out, *in)
+	}
+	if in.UpgradeSnapshots != nil {
+		in, out := &in.UpgradeSnapshots, &out.UpgradeSnapshots
+		*out = new(UpgradeSnapshotsStatus)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.Export != nil {
+		in, out := &in.Export, &out.Export
+		*out = new(ExportStatus)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.Import != nil {
+		in, out := &in.Import, &out.Import
+		*out = new(ImportStatus)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.Relocation != nil {
+		in, out := &in.Relocation, &out.Relocation
+		*out = new(RelocationStatus)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.MaintenanceMode != nil {
+		in, out := &in.MaintenanceMode, &out.MaintenanceMode
+		*out = new(MaintenanceModeStatus)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.Upgrade != nil {
+		in, out := &in.Upgrade, &out.Upgrade
+		*out = new(UpgradeStatus)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.PendingUpdate != nil {
+		in, out := &in.PendingUpdate, &out.PendingUpdate
+		*out = new(PendingUpdateStatus)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.ChannelUpdate != nil {
+		in, out := &in.ChannelUpdate, &out.ChannelUpdate
+		*out = new(ChannelUpdateStatus)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.AvailableUpdate != nil {
+		in, out := &in.AvailableUpdate, &out.AvailableUpdate
+		*out = new(AvailableUpdateStatus)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.AutoSizing != nil {
+		in, out := &in.AutoSizing, &out.AutoSizing
+		*out = new(AutoSizingStatus)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.ImageVerification != nil {
+		in, out := &in.ImageVerification, &out.ImageVerification
+		*out = new(ImageVerificationStatus)
+		**out = **in
+	}
+	if in.PreUpgradeBackup != nil {
+		in, out := &in.PreUpgradeBackup, &out.PreUpgradeBackup
+		*out = new(PreUpgradeBackupStatus)
+		**out = **in
+	}
+	if in.PostUpgradeChecks != nil {
+		in, out := &in.PostUpgradeChecks, &out.PostUpgradeChecks
+		*out = new(PostUpgradeChecksStatus)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.ApplicationHealth != nil {
+		in, out := &in.ApplicationHealth, &out.ApplicationHealth
+		*out = new(ApplicationHealthStatus)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.Conditions != nil {
+		in, out := &in.Conditions, &out.Conditions
+		*out = make([]metav1.Condition, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new MattermostStatus.
+func (in *MattermostStatus) DeepCopy() *MattermostStatus {
+	if in == nil {
+		return nil
+	}
+	out := new(MattermostStatus)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *MattermostTemplate) DeepCopyInto(out *MattermostTemplate) {
+	*out = *in
+	out.TypeMeta = in.TypeMeta
+	in.ObjectMeta.DeepCopyInto(&out.ObjectMeta)
+	in.Spec.DeepCopyInto(&out.Spec)
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new MattermostTemplate.
+func (in *MattermostTemplate) DeepCopy() *MattermostTemplate {
+	if in == nil {
+		return nil
+	}
+	out := new(MattermostTemplate)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyObject is an autogenerated deepcopy function, copying the receiver, creating a new runtime.Object.
+func (in *MattermostTemplate) DeepCopyObject() runtime.Object {
+	if c := in.DeepCopy(); c != nil {
+		return c
+	}
+	return nil
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *MattermostTemplateList) DeepCopyInto(out *MattermostTemplateList) {
+	*out = *in
+	out.TypeMeta = in.TypeMeta
+	in.ListMeta.DeepCopyInto(&out.ListMeta)
+	if in.Items != nil {
+		in, out := &in.Items, &out.Items
+		*out = make([]MattermostTemplate, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new MattermostTemplateList.
+func (in *MattermostTemplateList) DeepCopy() *MattermostTemplateList {
+	if in == nil {
+		return nil
+	}
+	out := new(MattermostTemplateList)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyObject is an autogenerated deepcopy function, copying the receiver, creating a new runtime.Object.
+func (in *MattermostTemplateList) DeepCopyObject() runtime.Object {
+	if c := in.DeepCopy(); c != nil {
+		return c
+	}
+	return nil
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *MattermostTemplateSpec) DeepCopyInto(out *MattermostTemplateSpec) {
+	*out = *in
+	in.Mattermost.DeepCopyInto(&out.Mattermost)
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new MattermostTemplateSpec.
+func (in *MattermostTemplateSpec) DeepCopy() *MattermostTemplateSpec {
+	if in == nil {
+		return nil
+	}
+	out := new(MattermostTemplateSpec)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *MattermostUpgrade) DeepCopyInto(out *MattermostUpgrade) {
+	*out = *in
+	out.TypeMeta = in.TypeMeta
+	in.ObjectMeta.DeepCopyInto(&out.ObjectMeta)
+	in.Spec.DeepCopyInto(&out.Spec)
+	in.Status.DeepCopyInto(&out.Status)
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new MattermostUpgrade.
+func (in *MattermostUpgrade) DeepCopy() *MattermostUpgrade {
+	if in == nil {
+		return nil
+	}
+	out := new(MattermostUpgrade)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyObject is an autogenerated deepcopy function, copying the receiver, creating a new runtime.Object.
+func (in *MattermostUpgrade) DeepCopyObject() runtime.Object {
+	if c := in.DeepCopy(); c != nil {
+		return c
+	}
+	return nil
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *MattermostUpgradeList) DeepCopyInto(out *MattermostUpgradeList) {
+	*out = *in
+	out.TypeMeta = in.TypeMeta
+	in.ListMeta.DeepCopyInto(&out.ListMeta)
+	if in.Items != nil {
+		in, out := &in.Items, &out.Items
+		*out = make([]MattermostUpgrade, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new MattermostUpgradeList.
+func (in *MattermostUpgradeList) DeepCopy() *MattermostUpgradeList {
+	if in == nil {
+		return nil
+	}
+	out := new(MattermostUpgradeList)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyObject is an autogenerated deepcopy function, copying the receiver, creating a new runtime.Object.
+func (in *MattermostUpgradeList) DeepCopyObject() runtime.Object {
+	if c := in.DeepCopy(); c != nil {
+		return c
+	}
+	return nil
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *MattermostUpgradeSpec) DeepCopyInto(out *MattermostUpgradeSpec) {
+	*out = *in
+	in.Selector.DeepCopyInto(&out.Selector)
+	if in.Timeout != nil {
+		in, out := &in.Timeout, &out.Timeout
+		*out = new(metav1.Duration)
+		**out = **in
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new MattermostUpgradeSpec.
+func (in *MattermostUpgradeSpec) DeepCopy() *MattermostUpgradeSpec {
+	if in == nil {
+		return nil
+	}
+	out := new(MattermostUpgradeSpec)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *MattermostUpgradeStatus) DeepCopyInto(out *MattermostUpgradeStatus) {
+	*out = *in
+	if in.Installations != nil {
+		in, out := &in.Installations, &out.Installations
+		*out = make([]InstallationUpgradeStatus, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+	if in.StartTime != nil {
+		in, out := &in.StartTime, &out.StartTime
+		*out = (*in).DeepCopy()
+	}
+	if in.CompletionTime != nil {
+		in, out := &in.CompletionTime, &out.CompletionTime
+		*out = (*in).DeepCopy()
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new MattermostUpgradeStatus.
+func (in *MattermostUpgradeStatus) DeepCopy() *MattermostUpgradeStatus {
+	if in == nil {
+		return nil
+	}
+	out := new(MattermostUpgradeStatus)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *Monitoring) DeepCopyInto(out *Monitoring) {
+	*out = *in
+	if in.Relabelings != nil {
+		in, out := &in.Relabelings, &out.Relabelings
+		*out = make([]RelabelConfig, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+	if in.Labels != nil {
+		in, out := &in.Labels, &out.Labels
+		*out = make(map[string]string, len(*in))
+		for key, val := range *in {
+			(*out)[key] = val
+		}
+	}
+	if in.Alerts != nil {
+		in, out := &in.Alerts, &out.Alerts
+		*out = new(MonitoringAlerts)
+		(*in).DeepCopyInto(*out)
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new Monitoring.
+func (in *Monitoring) DeepCopy() *Monitoring {
+	if in == nil {
+		return nil
+	}
+	out := new(Monitoring)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *MonitoringAlerts) DeepCopyInto(out *MonitoringAlerts) {
+	*out = *in
+	if in.ReplicasUnavailableFor != nil {
+		in, out := &in.ReplicasUnavailableFor, &out.ReplicasUnavailableFor
+		*out = new(metav1.Duration)
+		**out = **in
+	}
+	if in.Labels != nil {
+		in, out := &in.Labels, &out.Labels
+		*out = make(map[string]string, len(*in))
+		for key, val := range *in {
+			(*out)[key] = val
+		}
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new MonitoringAlerts.
+func (in *MonitoringAlerts) DeepCopy() *MonitoringAlerts {
+	if in == nil {
+		return nil
+	}
+	out := new(MonitoringAlerts)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *Notifications) DeepCopyInto(out *Notifications) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new Notifications.
+func (in *Notifications) DeepCopy() *Notifications {
+	if in == nil {
+		return nil
+	}
+	out := new(Notifications)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *OperatorManagedDatabase) DeepCopyInto(out *OperatorManagedDatabase) {
+	*out = *in
+	if in.Replicas != nil {
+		in, out := &in.Replicas, &out.Replicas
+		*out = new(int32)
+		**out = **in
+	}
+	in.Resources.DeepCopyInto(&out.Resources)
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new OperatorManagedDatabase.
+func (in *OperatorManagedDatabase) DeepCopy() *OperatorManagedDatabase {
+	if in == nil {
+		return nil
+	}
+	out := new(OperatorManagedDatabase)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *OperatorManagedMinio) DeepCopyInto(out *OperatorManagedMinio) {
+	*out = *in
+	if in.Replicas != nil {
+		in, out := &in.Replicas, &out.Replicas
+		*out = new(int32)
+		**out = **in
+	}
+	in.Resources.DeepCopyInto(&out.Resources)
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new OperatorManagedMinio.
+func (in *OperatorManagedMinio) DeepCopy() *OperatorManagedMinio {
+	if in == nil {
+		return nil
+	}
+	out := new(OperatorManagedMinio)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *PendingUpdateStatus) DeepCopyInto(out *PendingUpdateStatus) {
+	*out = *in
+	if in.Since != nil {
+		in, out := &in.Since, &out.Since
+		*out = (*in).DeepCopy()
+	}
+	if in.NextWindow != nil {
+		in, out := &in.NextWindow, &out.NextWindow
+		*out = (*in).DeepCopy()
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new PendingUpdateStatus.
+func (in *PendingUpdateStatus) DeepCopy() *PendingUpdateStatus {
+	if in == nil {
+		return nil
+	}
+	out := new(PendingUpdateStatus)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *PodExtensions) DeepCopyInto(out *PodExtensions) {
+	*out = *in
+	if in.InitContainers != nil {
+		in, out := &in.InitContainers, &out.InitContainers
+		*out = make([]v1.Container, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new PodExtensions.
+func (in *PodExtensions) DeepCopy() *PodExtensions {
+	if in == nil {
+		return nil
+	}
+	out := new(PodExtensions)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *PostUpgradeChecks) DeepCopyInto(out *PostUpgradeChecks) {
+	*out = *in
+	if in.Command != nil {
+		in, out := &in.Command, &out.Command
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new PostUpgradeChecks.
+func (in *PostUpgradeChecks) DeepCopy() *PostUpgradeChecks {
+	if in == nil {
+		return nil
+	}
+	out := new(PostUpgradeChecks)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *PostUpgradeChecksStatus) DeepCopyInto(out *PostUpgradeChecksStatus) {
+	*out = *in
+	if in.CompletionTime != nil {
+		in, out := &in.CompletionTime, &out.CompletionTime
+		*out = (*in).DeepCopy()
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new PostUpgradeChecksStatus.
+func (in *PostUpgradeChecksStatus) DeepCopy() *PostUpgradeChecksStatus {
+	if in == nil {
+		return nil
+	}
+	out := new(PostUpgradeChecksStatus)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *PreUpgradeBackupStatus) DeepCopyInto(out *PreUpgradeBackupStatus) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new PreUpgradeBackupStatus.
+func (in *PreUpgradeBackupStatus) DeepCopy() *PreUpgradeBackupStatus {
+	if in == nil {
+		return nil
+	}
+	out := new(PreUpgradeBackupStatus)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *Probes) DeepCopyInto(out *Probes) {
+	*out = *in
+	in.LivenessProbe.DeepCopyInto(&out.LivenessProbe)
+	in.ReadinessProbe.DeepCopyInto(&out.ReadinessProbe)
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new Probes.
+func (in *Probes) DeepCopy() *Probes {
+	if in == nil {
+		return nil
+	}
+	out := new(Probes)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *Proxy) DeepCopyInto(out *Proxy) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new Proxy.
+func (in *Proxy) DeepCopy() *Proxy {
+	if in == nil {
+		return nil
+	}
+	out := new(Proxy)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ReconcilePolicy) DeepCopyInto(out *ReconcilePolicy) {
+	*out = *in
+	if in.IgnoreFields != nil {
+		in, out := &in.IgnoreFields, &out.IgnoreFields
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ReconcilePolicy.
+func (in *ReconcilePolicy) DeepCopy() *ReconcilePolicy {
+	if in == nil {
+		return nil
+	}
+	out := new(ReconcilePolicy)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *RelabelConfig) DeepCopyInto(out *RelabelConfig) {
+	*out = *in
+	if in.SourceLabels != nil {
+		in, out := &in.SourceLabels, &out.SourceLabels
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new RelabelConfig.
+func (in *RelabelConfig) DeepCopy() *RelabelConfig {
+	if in == nil {
+		return nil
+	}
+	out := new(RelabelConfig)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *Relocation) DeepCopyInto(out *Relocation) {
+	*out = *in
+	if in.FileStore != nil {
+		in, out := &in.FileStore, &out.FileStore
+		*out = new(ExternalFileStore)
+		**out = **in
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new Relocation.
+func (in *Relocation) DeepCopy() *Relocation {
+	if in == nil {
+		return nil
+	}
+	out := new(Relocation)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *RelocationStatus) DeepCopyInto(out *RelocationStatus) {
+	*out = *in
+	if in.StartTime != nil {
+		in, out := &in.StartTime, &out.StartTime
+		*out = (*in).DeepCopy()
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new RelocationStatus.
+func (in *RelocationStatus) DeepCopy() *RelocationStatus {
+	if in == nil {
+		return nil
+	}
+	out := new(RelocationStatus)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *Scheduling) DeepCopyInto(out *Scheduling) {
+	*out = *in
+	in.Resources.DeepCopyInto(&out.Resources)
+	if in.NodeSelector != nil {
+		in, out := &in.NodeSelector, &out.NodeSelector
+		*out = make(map[string]string, len(*in))
+		for key, val := range *in {
+			(*out)[key] = val
+		}
+	}
+	if in.Affinity != nil {
+		in, out := &in.Affinity, &out.Affinity
+		*out = new(v1.Affinity)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.Tolerations != nil {
+		in, out := &in.Tolerations, &out.Tolerations
+		*out = make([]v1.Toleration, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new Scheduling.
+func (in *Scheduling) DeepCopy() *Scheduling {
+	if in == nil {
+		return nil
+	}
+	out := new(Scheduling)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *SelfHealing) DeepCopyInto(out *SelfHealing) {
+	*out = *in
+	if in.Cooloff != nil {
+		in, out := &in.Cooloff, &out.Cooloff
+		*out = new(metav1.Duration)
+		**out = **in
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new SelfHealing.
+func (in *SelfHealing) DeepCopy() *SelfHealing {
+	if in == nil {
+		return nil
+	}
+	out := new(SelfHealing)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *Standby) DeepCopyInto(out *Standby) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new Standby.
+func (in *Standby) DeepCopy() *Standby {
+	if in == nil {
+		return nil
+	}
+	out := new(Standby)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *TrustedCABundle) DeepCopyInto(out *TrustedCABundle) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new TrustedCABundle.
+func (in *TrustedCABundle) DeepCopy() *TrustedCABundle {
+	if in == nil {
+		return nil
+	}
+	out := new(TrustedCABundle)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *UpdateCheckJob) DeepCopyInto(out *UpdateCheckJob) {
+	*out = *in
+	if in.Resources != nil {
+		in, out := &in.Resources, &out.Resources
+		*out = new(v1.ResourceRequirements)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.NodeSelector != nil {
+		in, out := &in.NodeSelector, &out.NodeSelector
+		*out = make(map[string]string, len(*in))
+		for key, val := range *in {
+			(*out)[key] = val
+		}
+	}
+	if in.Tolerations != nil {
+		in, out := &in.Tolerations, &out.Tolerations
+		*out = make([]v1.Toleration, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+	if in.ImagePullSecrets != nil {
+		in, out := &in.ImagePullSecrets, &out.ImagePullSecrets
+		*out = make([]v1.LocalObjectReference, len(*in))
+		copy(*out, *in)
+	}
+	in.JobPolicy.DeepCopyInto(&out.JobPolicy)
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new UpdateCheckJob.
+func (in *UpdateCheckJob) DeepCopy() *UpdateCheckJob {
+	if in == nil {
+		return nil
+	}
+	out := new(UpdateCheckJob)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *UpdatePolicy) DeepCopyInto(out *UpdatePolicy) {
+	*out = *in
+	if in.Window != nil {
+		in, out := &in.Window, &out.Window
+		*out = new(MaintenanceWindow)
+		**out = **in
+	}
+	if in.BackupDestination != nil {
+		in, out := &in.BackupDestination, &out.BackupDestination
+		*out = new(BackupDestination)
+		**out = **in
+	}
+	if in.PostUpgradeChecks != nil {
+		in, out := &in.PostUpgradeChecks, &out.PostUpgradeChecks
+		*out = new(PostUpgradeChecks)
+		(*in).DeepCopyInto(*out)
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new UpdatePolicy.
+func (in *UpdatePolicy) DeepCopy() *UpdatePolicy {
+	if in == nil {
+		return nil
+	}
+	out := new(UpdatePolicy)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *UpgradeRollback) DeepCopyInto(out *UpgradeRollback) {
+	*out = *in
+	if in.ProgressDeadline != nil {
+		in, out := &in.ProgressDeadline, &out.ProgressDeadline
+		*out = new(metav1.Duration)
+		**out = **in
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new UpgradeRollback.
+func (in *UpgradeRollback) DeepCopy() *UpgradeRollback {
+	if in == nil {
+		return nil
+	}
+	out := new(UpgradeRollback)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *UpgradeSnapshots) DeepCopyInto(out *UpgradeSnapshots) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new UpgradeSnapshots.
+func (in *UpgradeSnapshots) DeepCopy() *UpgradeSnapshots {
+	if in == nil {
+		return nil
+	}
+	out := new(UpgradeSnapshots)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *UpgradeSnapshotsStatus) DeepCopyInto(out *UpgradeSnapshotsStatus) {
+	*out = *in
+	if in.Snapshots != nil {
+		in, out := &in.Snapshots, &out.Snapshots
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new UpgradeSnapshotsStatus.
+func (in *UpgradeSnapshotsStatus) DeepCopy() *UpgradeSnapshotsStatus {
+	if in == nil {
+		return nil
+	}
+	out := new(UpgradeSnapshotsStatus)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *UpgradeStatus) DeepCopyInto(out *UpgradeStatus) {
+	*out = *in
+	if in.StartTime != nil {
+		in, out := &in.StartTime, &out.StartTime
+		*out = (*in).DeepCopy()
+	}
+	if in.CompletionTime != nil {
+		in, out := &in.CompletionTime, &out.CompletionTime
+		*out = (*in).DeepCopy()
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new UpgradeStatus.
+func (in *UpgradeStatus) DeepCopy() *UpgradeStatus {
+	if in == nil {
+		return nil
+	}
+	out := new(UpgradeStatus)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *UtilityImages) DeepCopyInto(out *UtilityImages) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new UtilityImages.
+func (in *UtilityImages) DeepCopy() *UtilityImages {
+	if in == nil {
+		return nil
+	}
+	out := new(UtilityImages)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *VeleroBackups) DeepCopyInto(out *VeleroBackups) {
+	*out = *in
+	if in.Database != nil {
+		in, out := &in.Database, &out.Database
+		*out = new(VeleroHooks)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.FileStore != nil {
+		in, out := &in.FileStore, &out.FileStore
+		*out = new(VeleroHooks)
+		(*in).DeepCopyInto(*out)
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new VeleroBackups.
+func (in *VeleroBackups) DeepCopy() *VeleroBackups {
+	if in == nil {
+		return nil
+	}
+	out := new(VeleroBackups)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *VeleroHook) DeepCopyInto(out *VeleroHook) {
+	*out = *in
+	if in.Command != nil {
+		in, out := &in.Command, &out.Command
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new VeleroHook.
+func (in *VeleroHook) DeepCopy() *VeleroHook {
+	if in == nil {
+		return nil
+	}
+	out := new(VeleroHook)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *VeleroHooks) DeepCopyInto(out *VeleroHooks) {
+	*out = *in
+	if in.PreHook != nil {
+		in, out := &in.PreHook, &out.PreHook
+		*out = new(VeleroHook)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.PostHook != nil {
+		in, out := &in.PostHook, &out.PostHook
+		*out = new(VeleroHook)
+		(*in).DeepCopyInto(*out)
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new VeleroHooks.
+func (in *VeleroHooks) DeepCopy() *VeleroHooks {
+	if in == nil {
+		return nil
+	}
+	out := new(VeleroHooks)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *VolumeResizeStatus) DeepCopyInto(out *VolumeResizeStatus) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new VolumeResizeStatus.
+func (in *VolumeResizeStatus) DeepCopy() *VolumeResizeStatus {
+	if in == nil {
+		return nil
+	}
+	out := new(VolumeResizeStatus)
 	in.DeepCopyInto(out)
 	return out
 }
@@ -79,6 +79,19 @@ func schema_mattermost_operator_apis_mattermost_v1beta1_MattermostSpec(ref commo
 							Format:      "",
 						},
 					},
+					"autoSizing": {
+						SchemaProps: spec.SchemaProps{
+							Description: "AutoSizing defines the automatic sizing of the Mattermost from its active users. The computed size is applied as Size, overriding the replicas and resources, and reported in the status.",
+							Ref:         ref("github.com/mattermost/mattermost-operator/apis/mattermost/v1beta1.AutoSizing"),
+						},
+					},
+					"templateRef": {
+						SchemaProps: spec.SchemaProps{
+							Description: "TemplateRef defines the name of the MattermostTemplate whose values are merged under the ones of the Mattermost. The values set by the Mattermost take precedence.",
+							Type:        []string{"string"},
+							Format:      "",
+						},
+					},
 					"image": {
 						SchemaProps: spec.SchemaProps{
 							Description: "Image defines the Mattermost Docker image.",
@@ -93,9 +106,42 @@ func schema_mattermost_operator_apis_mattermost_v1beta1_MattermostSpec(ref commo
 							Format:      "",
 						},
 					},
+					"edition": {
+						SchemaProps: spec.SchemaProps{
+							Description: "Edition defines the Mattermost edition selecting the default image: 'team' for Mattermost Team Edition, 'enterprise' for Mattermost Enterprise Edition. The Team Edition does not support licenses and clustering, it runs a single replica. Defaults to enterprise.",
+							Type:        []string{"string"},
+							Format:      "",
+						},
+					},
+					"imageVariant": {
+						SchemaProps: spec.SchemaProps{
+							Description: "ImageVariant defines the variant of the Mattermost image: 'fips' for the FIPS 140-2 compliant image, 'ubi' for the image based on the Red Hat Universal Base Image. The variant selects the default image, the tags matching Version and the entrypoint, so that the default version and automatic upgrades keep working.",
+							Type:        []string{"string"},
+							Format:      "",
+						},
+					},
+					"imageRegistry": {
+						SchemaProps: spec.SchemaProps{
+							Description: "ImageRegistry defines the registry, optionally followed by a path, replacing the registry of all images created by the Operator, ie registry.example.com/mirror pulls postgres:13 from registry.example.com/mirror/postgres:13. Defaults to the image registry configured for the Operator.",
+							Type:        []string{"string"},
+							Format:      "",
+						},
+					},
+					"utilityImages": {
+						SchemaProps: spec.SchemaProps{
+							Description: "UtilityImages overrides the images of the utility containers created by the Operator, such as the init containers waiting for the database and MinIO. Defaults to the utility images configured for the Operator.",
+							Ref:         ref("github.com/mattermost/mattermost-operator/apis/mattermost/v1beta1.UtilityImages"),
+						},
+					},
+					"utilityResources": {
+						SchemaProps: spec.SchemaProps{
+							Description: "UtilityResources defines the resources of the init containers and of the job containers created by the Operator not running Mattermost. Defaults to requests of 50m CPU and 64Mi memory, and limits of 500m CPU and 512Mi memory.",
+							Ref:         ref("k8s.io/api/core/v1.ResourceRequirements"),
+						},
+					},
 					"replicas": {
 						SchemaProps: spec.SchemaProps{
-							Description: "Replicas defines the number of replicas to use for the Mattermost app servers.",
+							Description: "Replicas defines the number of replicas to use for the Mattermost app servers. It is the target of the scale subresource, therefore can be set with kubectl scale or a HorizontalPodAutoscaler.",
 							Type:        []string{"integer"},
 							Format:      "int32",
 						},
@@ -120,6 +166,12 @@ func schema_mattermost_operator_apis_mattermost_v1beta1_MattermostSpec(ref commo
 							Format:      "",
 						},
 					},
+					"clusterReadinessGate": {
+						SchemaProps: spec.SchemaProps{
+							Description: "ClusterReadinessGate holds the pods of clustered Mattermost app servers unready until they joined the cluster, so that rollouts do not shift traffic to app servers outside of it. Applies only with clustering enabled, with a license and more than one replica.",
+							Ref:         ref("github.com/mattermost/mattermost-operator/apis/mattermost/v1beta1.ClusterReadinessGate"),
+						},
+					},
 					"ingressName": {
 						SchemaProps: spec.SchemaProps{
 							Description: "IngressName defines the host to be used when creating the ingress rules. Deprecated: Use Spec.Ingress.Host instead.",
@@ -251,6 +303,128 @@ func schema_mattermost_operator_apis_mattermost_v1beta1_MattermostSpec(ref commo
 							Ref: ref("github.com/mattermost/mattermost-operator/apis/mattermost/v1beta1.ElasticSearch"),
 						},
 					},
+					"proxy": {
+						SchemaProps: spec.SchemaProps{
+							Description: "Proxy defines the outbound HTTP proxy of Mattermost and of the jobs created by the Operator.",
+							Ref:         ref("github.com/mattermost/mattermost-operator/apis/mattermost/v1beta1.Proxy"),
+						},
+					},
+					"trustedCABundle": {
+						SchemaProps: spec.SchemaProps{
+							Description: "TrustedCABundle defines the ConfigMap with the CA certificates trusted by Mattermost and the jobs created by the Operator, ie for identity providers, S3 endpoints or SMTP servers with certificates issued by internal CAs.",
+							Ref:         ref("github.com/mattermost/mattermost-operator/apis/mattermost/v1beta1.TrustedCABundle"),
+						},
+					},
+					"deletionPolicy": {
+						SchemaProps: spec.SchemaProps{
+							Description: "DeletionPolicy defines whether the data of the Mattermost is deleted with it: 'Delete', the default, deletes the operator managed database and file store along with their Secrets and volumes, 'Orphan' keeps them once the Mattermost is deleted. The other resources of the Mattermost are deleted with it.",
+							Type:        []string{"string"},
+							Format:      "",
+						},
+					},
+					"upgradeSnapshots": {
+						SchemaProps: spec.SchemaProps{
+							Description: "UpgradeSnapshots defines the snapshots of the operator managed database and file store volumes taken before upgrading Mattermost to a new version.",
+							Ref:         ref("github.com/mattermost/mattermost-operator/apis/mattermost/v1beta1.UpgradeSnapshots"),
+						},
+					},
+					"upgradeRollback": {
+						SchemaProps: spec.SchemaProps{
+							Description: "UpgradeRollback defines the automatic rollback of Mattermost upgrades whose pods do not pass the health checks.",
+							Ref:         ref("github.com/mattermost/mattermost-operator/apis/mattermost/v1beta1.UpgradeRollback"),
+						},
+					},
+					"selfHealing": {
+						SchemaProps: spec.SchemaProps{
+							Description: "SelfHealing defines the automatic rolling restart of Mattermost when its application health checks fail repeatedly.",
+							Ref:         ref("github.com/mattermost/mattermost-operator/apis/mattermost/v1beta1.SelfHealing"),
+						},
+					},
+					"notifications": {
+						SchemaProps: spec.SchemaProps{
+							Description: "Notifications defines the webhook notified of the upgrades, failures and health degradation of the Mattermost, overriding the webhook of the operator.",
+							Ref:         ref("github.com/mattermost/mattermost-operator/apis/mattermost/v1beta1.Notifications"),
+						},
+					},
+					"monitoring": {
+						SchemaProps: spec.SchemaProps{
+							Description: "Monitoring defines the ServiceMonitor and the PodMonitors generated for the metrics endpoints of Mattermost and of its operator managed database and file store, so that the Prometheus Operator scrapes them.",
+							Ref:         ref("github.com/mattermost/mattermost-operator/apis/mattermost/v1beta1.Monitoring"),
+						},
+					},
+					"rollbackTo": {
+						SchemaProps: spec.SchemaProps{
+							Description: "RollbackTo restores the image, version, environment and ingress settings of a revision of the revision history, listed in the '<name>-revisions' Secret, and is cleared once restored.",
+							Type:        []string{"integer"},
+							Format:      "int64",
+						},
+					},
+					"veleroBackups": {
+						SchemaProps: spec.SchemaProps{
+							Description: "VeleroBackups defines the Velero backup hooks and annotations added to the operator managed database and file store, so that cluster-level Velero backups of the installation are consistent.",
+							Ref:         ref("github.com/mattermost/mattermost-operator/apis/mattermost/v1beta1.VeleroBackups"),
+						},
+					},
+					"jobs": {
+						SchemaProps: spec.SchemaProps{
+							Description: "Jobs defines the one-off jobs run for the Mattermost installation.",
+							Ref:         ref("github.com/mattermost/mattermost-operator/apis/mattermost/v1beta1.Jobs"),
+						},
+					},
+					"blueGreen": {
+						SchemaProps: spec.SchemaProps{
+							Description: "BlueGreen defines the blue and green deployments of Mattermost, which allow a new version to be staged behind a test hostname before the production traffic is switched to it.",
+							Ref:         ref("github.com/mattermost/mattermost-operator/apis/mattermost/v1beta1.BlueGreen"),
+						},
+					},
+					"canary": {
+						SchemaProps: spec.SchemaProps{
+							Description: "Canary defines a canary deployment of Mattermost receiving a share of the traffic of the Mattermost ingress, so that a new version can be gradually exposed to a fraction of the users.",
+							Ref:         ref("github.com/mattermost/mattermost-operator/apis/mattermost/v1beta1.Canary"),
+						},
+					},
+					"updatePolicy": {
+						SchemaProps: spec.SchemaProps{
+							Description: "UpdatePolicy defines how changes to the Mattermost deployment are rolled out.",
+							Ref:         ref("github.com/mattermost/mattermost-operator/apis/mattermost/v1beta1.UpdatePolicy"),
+						},
+					},
+					"reconcilePolicy": {
+						SchemaProps: spec.SchemaProps{
+							Description: "ReconcilePolicy defines how the Operator corrects the drift of the resources it generates for the Mattermost.",
+							Ref:         ref("github.com/mattermost/mattermost-operator/apis/mattermost/v1beta1.ReconcilePolicy"),
+						},
+					},
+					"relocation": {
+						SchemaProps: spec.SchemaProps{
+							Description: "Relocation moves the Mattermost to another namespace: the Secrets and ConfigMaps it references are copied there, a Mattermost with the same spec is created there, and once it is stable the ingress is switched to it and this Mattermost is deleted. Only the Mattermosts with an external database and file store can be relocated.",
+							Ref:         ref("github.com/mattermost/mattermost-operator/apis/mattermost/v1beta1.Relocation"),
+						},
+					},
+					"standby": {
+						SchemaProps: spec.SchemaProps{
+							Description: "Standby keeps the Mattermost as the idle copy of an installation of another cluster, for disaster recovery: its deployment is scaled to zero and its ingress is not created until it is promoted. It requires an external database and file store, shared with the active installation or replicated from its ones.",
+							Ref:         ref("github.com/mattermost/mattermost-operator/apis/mattermost/v1beta1.Standby"),
+						},
+					},
+					"maintenanceMode": {
+						SchemaProps: spec.SchemaProps{
+							Description: "MaintenanceMode flips the Mattermost into a safe state, ie during the maintenance or the restore of its database: an announcement banner is shown to the users, the database sessions can be made read-only and the ingress can redirect the requests to a maintenance page.",
+							Ref:         ref("github.com/mattermost/mattermost-operator/apis/mattermost/v1beta1.MaintenanceMode"),
+						},
+					},
+					"imageVerification": {
+						SchemaProps: spec.SchemaProps{
+							Description: "ImageVerification defines the resolution of the Mattermost image tag to the digest the deployment is pinned to, and the verification of the image signature before it is rolled out.",
+							Ref:         ref("github.com/mattermost/mattermost-operator/apis/mattermost/v1beta1.ImageVerification"),
+						},
+					},
+					"ecrCredentials": {
+						SchemaProps: spec.SchemaProps{
+							Description: "ECRCredentials defines the image pull Secret of an Amazon ECR registry refreshed by the Operator, for clusters where the kubelets cannot authenticate to ECR.",
+							Ref:         ref("github.com/mattermost/mattermost-operator/apis/mattermost/v1beta1.ECRCredentials"),
+						},
+					},
 					"scheduling": {
 						SchemaProps: spec.SchemaProps{
 							Description: "Scheduling defines the configuration related to scheduling of the Mattermost pods as well as resource constraints. These settings generally don't need to be changed.",
@@ -273,6 +447,6 @@ func schema_mattermost_operator_apis_mattermost_v1beta1_MattermostSpec(ref commo
 			},
 		},
 		Dependencies: []string{
-			"github.com/mattermost/mattermost-operator/apis/mattermost/v1beta1.Database", "github.com/mattermost/mattermost-operator/apis/mattermost/v1beta1.ElasticSearch", "github.com/mattermost/mattermost-operator/apis/mattermost/v1beta1.FileStore", "github.com/mattermost/mattermost-operator/apis/mattermost/v1beta1.Ingress", "github.com/mattermost/mattermost-operator/apis/mattermost/v1beta1.PodExtensions", "github.com/mattermost/mattermost-operator/apis/mattermost/v1beta1.Probes", "github.com/mattermost/mattermost-operator/apis/mattermost/v1beta1.Scheduling", "k8s.io/api/core/v1.EnvVar", "k8s.io/api/core/v1.LocalObjectReference", "k8s.io/api/core/v1.Volume", "k8s.io/api/core/v1.VolumeMount"},
+			"github.com/mattermost/mattermost-operator/apis/mattermost/v1beta1.AutoSizing", "github.com/mattermost/mattermost-operator/apis/mattermost/v1beta1.BlueGreen", "github.com/mattermost/mattermost-operator/apis/mattermost/v1beta1.Canary", "github.com/mattermost/mattermost-operator/apis/mattermost/v1beta1.ClusterReadinessGate", "github.com/mattermost/mattermost-operator/apis/mattermost/v1beta1.Database", "github.com/mattermost/mattermost-operator/apis/mattermost/v1beta1.ECRCredentials", "github.com/mattermost/mattermost-operator/apis/mattermost/v1beta1.ElasticSearch", "github.com/mattermost/mattermost-operator/apis/mattermost/v1beta1.FileStore", "github.com/mattermost/mattermost-operator/apis/mattermost/v1beta1.ImageVerification", "github.com/mattermost/mattermost-operator/apis/mattermost/v1beta1.Ingress", "github.com/mattermost/mattermost-operator/apis/mattermost/v1beta1.Jobs", "github.com/mattermost/mattermost-operator/apis/mattermost/v1beta1.MaintenanceMode", "github.com/mattermost/mattermost-operator/apis/mattermost/v1beta1.Monitoring", "github.com/mattermost/mattermost-operator/apis/mattermost/v1beta1.Notifications", "github.com/mattermost/mattermost-operator/apis/mattermost/v1beta1.PodExtensions", "github.com/mattermost/mattermost-operator/apis/mattermost/v1beta1.Probes", "github.com/mattermost/mattermost-operator/apis/mattermost/v1beta1.Proxy", "github.com/mattermost/mattermost-operator/apis/mattermost/v1beta1.ReconcilePolicy", "github.com/mattermost/mattermost-operator/apis/mattermost/v1beta1.Relocation", "github.com/mattermost/mattermost-operator/apis/mattermost/v1beta1.Scheduling", "github.com/mattermost/mattermost-operator/apis/mattermost/v1beta1.SelfHealing", "github.com/mattermost/mattermost-operator/apis/mattermost/v1beta1.Standby", "github.com/mattermost/mattermost-operator/apis/mattermost/v1beta1.TrustedCABundle", "github.com/mattermost/mattermost-operator/apis/mattermost/v1beta1.UpdatePolicy", "github.com/mattermost/mattermost-operator/apis/mattermost/v1beta1.UpgradeRollback", "github.com/mattermost/mattermost-operator/apis/mattermost/v1beta1.UpgradeSnapshots", "github.com/mattermost/mattermost-operator/apis/mattermost/v1beta1.UtilityImages", "github.com/mattermost/mattermost-operator/apis/mattermost/v1beta1.VeleroBackups", "k8s.io/api/core/v1.EnvVar", "k8s.io/api/core/v1.LocalObjectReference", "k8s.io/api/core/v1.ResourceRequirements", "k8s.io/api/core/v1.Volume", "k8s.io/api/core/v1.VolumeMount"},
 	}
 }
@@ -0,0 +1,142 @@
+// Command kubectl-mattermost is a kubectl plugin operating the Mattermosts
+// by their name: kubectl mattermost status|health|restart|logs|version NAME.
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"os"
+	"time"
+
+	blubr "github.com/mattermost/blubr"
+	mmv1beta "github.com/mattermost/mattermost-operator/apis/mattermost/v1beta1"
+	"github.com/mattermost/mattermost-operator/pkg/kubectlplugin"
+	k8sruntime "k8s.io/apimachinery/pkg/runtime"
+	utilruntime "k8s.io/apimachinery/pkg/util/runtime"
+	"k8s.io/client-go/kubernetes"
+	clientgoscheme "k8s.io/client-go/kubernetes/scheme"
+	"k8s.io/client-go/tools/clientcmd"
+	clientcmdapi "k8s.io/client-go/tools/clientcmd/api"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+const usage = `Operate the Mattermosts managed by the Mattermost Operator.
+
+Usage:
+  kubectl mattermost COMMAND NAME [flags]
+
+Commands:
+  status   Print the status of the Mattermost reported by the operator
+  health   Check the health of the Mattermost pods, exits with 1 if unhealthy
+  restart  Request a rolling restart of the Mattermost pods
+  logs     Print the logs of the Mattermost pods
+  version  Print the version of the Mattermost and the updates available
+
+Flags:
+`
+
+var scheme = k8sruntime.NewScheme()
+
+func init() {
+	utilruntime.Must(clientgoscheme.AddToScheme(scheme))
+	utilruntime.Must(mmv1beta.AddToScheme(scheme))
+}
+
+func main() {
+	os.Exit(run(os.Args[1:]))
+}
+
+func run(args []string) int {
+	flags := flag.NewFlagSet("kubectl-mattermost", flag.ExitOnError)
+	flags.Usage = func() {
+		fmt.Fprint(os.Stderr, usage)
+		flags.PrintDefaults()
+	}
+	var namespace string
+	flags.StringVar(&namespace, "namespace", "", "The namespace of the Mattermost, the namespace of the current context by default.")
+	flags.StringVar(&namespace, "n", "", "Shorthand of --namespace.")
+	follow := flags.Bool("follow", false, "logs: Stream the logs.")
+	previous := flags.Bool("previous", false, "logs: Print the logs of the previous instances of the containers.")
+	tail := flags.Int64("tail", -1, "logs: The number of recent lines printed for each pod, all lines by default.")
+
+	// The flags are allowed before and after the command and its name.
+	var positional []string
+	for {
+		_ = flags.Parse(args)
+		args = flags.Args()
+		if len(args) == 0 {
+			break
+		}
+		positional = append(positional, args[0])
+		args = args[1:]
+	}
+	if len(positional) != 2 {
+		flags.Usage()
+		return 2
+	}
+	command, name := positional[0], positional[1]
+
+	clientConfig := clientcmd.NewNonInteractiveDeferredLoadingClientConfig(
+		clientcmd.NewDefaultClientConfigLoadingRules(),
+		&clientcmd.ConfigOverrides{Context: clientcmdapi.Context{Namespace: namespace}},
+	)
+	namespace, _, err := clientConfig.Namespace()
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Unable to get the namespace of the current context: %s\n", err)
+		return 1
+	}
+	config, err := clientConfig.ClientConfig()
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Unable to get the Kubernetes client configuration: %s\n", err)
+		return 1
+	}
+	c, err := client.New(config, client.Options{Scheme: scheme})
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Unable to create the Kubernetes client: %s\n", err)
+		return 1
+	}
+	clientset, err := kubernetes.NewForConfig(config)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Unable to create the Kubernetes client: %s\n", err)
+		return 1
+	}
+
+	plugin := &kubectlplugin.Plugin{
+		Client:    c,
+		Clientset: clientset,
+		Namespace: namespace,
+		Out:       os.Stdout,
+		Logger:    blubr.InitLogger(),
+	}
+	ctx := context.Background()
+	switch command {
+	case "status":
+		err = plugin.Status(ctx, name)
+	case "health":
+		var healthy bool
+		healthy, err = plugin.Health(ctx, name)
+		if err == nil && !healthy {
+			return 1
+		}
+	case "restart":
+		err = plugin.Restart(ctx, name, time.Now())
+	case "logs":
+		options := kubectlplugin.LogOptions{Follow: *follow, Previous: *previous}
+		if *tail >= 0 {
+			options.TailLines = tail
+		}
+		err = plugin.Logs(ctx, name, options)
+	case "version":
+		err = plugin.Version(ctx, name)
+	default:
+		fmt.Fprintf(os.Stderr, "Unknown command %q\n", command)
+		flags.Usage()
+		return 2
+	}
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %s\n", err)
+		return 1
+	}
+	return 0
+}
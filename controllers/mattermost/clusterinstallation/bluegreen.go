@@ -8,7 +8,7 @@ import (
 
 func (r *ClusterInstallationReconciler) checkBlueGreen(mattermost *mattermostv1alpha1.ClusterInstallation, reqLogger logr.Logger) error {
 	if mattermost.Spec.BlueGreen.Enable {
-		reqLogger = reqLogger.WithValues("Reconcile", "mattermost")
+		reqLogger = reqLogger.WithValues("phase", "mattermost")
 
 		blueGreen := []mattermostv1alpha1.AppDeployment{mattermost.Spec.BlueGreen.Blue, mattermost.Spec.BlueGreen.Green}
 		for _, deployment := range blueGreen {
@@ -7,7 +7,7 @@ import (
 )
 
 func (r *ClusterInstallationReconciler) checkCanary(mattermost *mattermostv1alpha1.ClusterInstallation, reqLogger logr.Logger) error {
-	reqLogger = reqLogger.WithValues("Reconcile", "mattermost")
+	reqLogger = reqLogger.WithValues("phase", "mattermost")
 	if mattermost.Spec.Canary.Enable {
 		ingressAnnotations := map[string]string{
 			"kubernetes.io/ingress.class":                  "nginx",
@@ -0,0 +1,109 @@
+package clusterinstallation
+
+import (
+	mattermostv1alpha1 "github.com/mattermost/mattermost-operator/apis/mattermost/v1alpha1"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// setStateConditions sets the Ready, Progressing and Error conditions, and
+// the last error, from the state of the ClusterInstallation, the error of its
+// health check and the error of the reconciliation, like the conditions of
+// the Mattermosts.
+func setStateConditions(status *mattermostv1alpha1.ClusterInstallationStatus, generation int64, healthErr, reconcileErr error) {
+	switch status.State {
+	case mattermostv1alpha1.Stable:
+		setStatusCondition(status, metav1.Condition{
+			Type:               mattermostv1alpha1.ReadyCondition,
+			Status:             metav1.ConditionTrue,
+			ObservedGeneration: generation,
+			Reason:             "Stable",
+			Message:            "All Mattermost pods run the requested image and are ready",
+		})
+		setStatusCondition(status, metav1.Condition{
+			Type:               mattermostv1alpha1.ProgressingCondition,
+			Status:             metav1.ConditionFalse,
+			ObservedGeneration: generation,
+			Reason:             "Stable",
+			Message:            "All changes are rolled out",
+		})
+	default:
+		message := "The ClusterInstallation is being reconciled"
+		if healthErr != nil {
+			message = healthErr.Error()
+		}
+		setStatusCondition(status, metav1.Condition{
+			Type:               mattermostv1alpha1.ReadyCondition,
+			Status:             metav1.ConditionFalse,
+			ObservedGeneration: generation,
+			Reason:             "Reconciling",
+			Message:            message,
+		})
+		if reconcileErr != nil {
+			setStatusCondition(status, metav1.Condition{
+				Type:               mattermostv1alpha1.ProgressingCondition,
+				Status:             metav1.ConditionFalse,
+				ObservedGeneration: generation,
+				Reason:             "ReconcileFailed",
+				Message:            "The changes cannot be rolled out until the error is resolved",
+			})
+		} else {
+			setStatusCondition(status, metav1.Condition{
+				Type:               mattermostv1alpha1.ProgressingCondition,
+				Status:             metav1.ConditionTrue,
+				ObservedGeneration: generation,
+				Reason:             "Reconciling",
+				Message:            message,
+			})
+		}
+	}
+
+	if reconcileErr != nil {
+		setStatusCondition(status, metav1.Condition{
+			Type:               mattermostv1alpha1.ErrorCondition,
+			Status:             metav1.ConditionTrue,
+			ObservedGeneration: generation,
+			Reason:             "ReconcileFailed",
+			Message:            reconcileErr.Error(),
+		})
+	} else {
+		setStatusCondition(status, metav1.Condition{
+			Type:               mattermostv1alpha1.ErrorCondition,
+			Status:             metav1.ConditionFalse,
+			ObservedGeneration: generation,
+			Reason:             "ReconcileSucceeded",
+			Message:            "The last reconciliation succeeded",
+		})
+	}
+
+	setLastError(status)
+}
+
+// setLastError reports the error of the Error condition as the last error,
+// which is kept until the ClusterInstallation is stable again.
+func setLastError(status *mattermostv1alpha1.ClusterInstallationStatus) {
+	condition := meta.FindStatusCondition(status.Conditions, mattermostv1alpha1.ErrorCondition)
+	if condition == nil || condition.Status != metav1.ConditionTrue {
+		if status.State == mattermostv1alpha1.Stable {
+			status.LastError = nil
+		}
+		return
+	}
+
+	lastTransitionTime := condition.LastTransitionTime
+	status.LastError = &mattermostv1alpha1.LastErrorStatus{
+		Reason:  condition.Reason,
+		Message: condition.Message,
+		Time:    &lastTransitionTime,
+	}
+}
+
+// setStatusCondition sets the condition on a copy of the conditions of the
+// status, leaving the conditions of the ClusterInstallation it was copied
+// from untouched.
+func setStatusCondition(status *mattermostv1alpha1.ClusterInstallationStatus, condition metav1.Condition) {
+	conditions := make([]metav1.Condition, len(status.Conditions))
+	copy(conditions, status.Conditions)
+	meta.SetStatusCondition(&conditions, condition)
+	status.Conditions = conditions
+}
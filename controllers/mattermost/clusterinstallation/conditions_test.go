@@ -0,0 +1,60 @@
+package clusterinstallation
+
+import (
+	"errors"
+	"testing"
+
+	mattermostv1alpha1 "github.com/mattermost/mattermost-operator/apis/mattermost/v1alpha1"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func TestSetStateConditions(t *testing.T) {
+	assertCondition := func(t *testing.T, status mattermostv1alpha1.ClusterInstallationStatus, conditionType string, conditionStatus metav1.ConditionStatus, reason string) {
+		condition := meta.FindStatusCondition(status.Conditions, conditionType)
+		require.NotNil(t, condition, conditionType)
+		assert.Equal(t, conditionStatus, condition.Status, conditionType)
+		assert.Equal(t, reason, condition.Reason, conditionType)
+		assert.Equal(t, int64(3), condition.ObservedGeneration, conditionType)
+	}
+
+	t.Run("stable", func(t *testing.T) {
+		status := mattermostv1alpha1.ClusterInstallationStatus{State: mattermostv1alpha1.Stable}
+		setStateConditions(&status, 3, nil, nil)
+
+		assertCondition(t, status, mattermostv1alpha1.ReadyCondition, metav1.ConditionTrue, "Stable")
+		assertCondition(t, status, mattermostv1alpha1.ProgressingCondition, metav1.ConditionFalse, "Stable")
+		assertCondition(t, status, mattermostv1alpha1.ErrorCondition, metav1.ConditionFalse, "ReconcileSucceeded")
+		assert.Nil(t, status.LastError)
+	})
+
+	t.Run("rolling out", func(t *testing.T) {
+		status := mattermostv1alpha1.ClusterInstallationStatus{State: mattermostv1alpha1.Reconciling}
+		setStateConditions(&status, 3, errors.New("found 1 updated replicas, but wanted 2"), nil)
+
+		assertCondition(t, status, mattermostv1alpha1.ReadyCondition, metav1.ConditionFalse, "Reconciling")
+		assertCondition(t, status, mattermostv1alpha1.ProgressingCondition, metav1.ConditionTrue, "Reconciling")
+		assertCondition(t, status, mattermostv1alpha1.ErrorCondition, metav1.ConditionFalse, "ReconcileSucceeded")
+		assert.Equal(t, "found 1 updated replicas, but wanted 2", meta.FindStatusCondition(status.Conditions, mattermostv1alpha1.ReadyCondition).Message)
+	})
+
+	t.Run("reconcile error kept until stable", func(t *testing.T) {
+		status := mattermostv1alpha1.ClusterInstallationStatus{State: mattermostv1alpha1.Reconciling}
+		setStateConditions(&status, 3, nil, errors.New("failed to get database secret"))
+
+		assertCondition(t, status, mattermostv1alpha1.ProgressingCondition, metav1.ConditionFalse, "ReconcileFailed")
+		assertCondition(t, status, mattermostv1alpha1.ErrorCondition, metav1.ConditionTrue, "ReconcileFailed")
+		require.NotNil(t, status.LastError)
+		assert.Equal(t, "ReconcileFailed", status.LastError.Reason)
+		assert.Equal(t, "failed to get database secret", status.LastError.Message)
+
+		setStateConditions(&status, 3, nil, nil)
+		require.NotNil(t, status.LastError)
+
+		status.State = mattermostv1alpha1.Stable
+		setStateConditions(&status, 3, nil, nil)
+		assert.Nil(t, status.LastError)
+	})
+}
@@ -6,7 +6,10 @@ import (
 	"reflect"
 	"time"
 
+	"github.com/mattermost/mattermost-operator/pkg/logging"
+	"github.com/mattermost/mattermost-operator/pkg/namespaceselector"
 	"github.com/mattermost/mattermost-operator/pkg/resources"
+	"github.com/mattermost/mattermost-operator/pkg/sharding"
 
 	batchv1 "k8s.io/api/batch/v1"
 
@@ -19,7 +22,10 @@ import (
 	"k8s.io/apimachinery/pkg/runtime"
 	"k8s.io/apimachinery/pkg/types"
 	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/builder"
 	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/controller"
+	"sigs.k8s.io/controller-runtime/pkg/ratelimiter"
 	"sigs.k8s.io/controller-runtime/pkg/reconcile"
 
 	mattermostv1alpha1 "github.com/mattermost/mattermost-operator/apis/mattermost/v1alpha1"
@@ -37,20 +43,38 @@ type ClusterInstallationReconciler struct {
 	MaxReconciling      int
 	RequeueOnLimitDelay time.Duration
 	Resources           *resources.ResourceHelper
+	// MaxConcurrentReconciles is the number of ClusterInstallations
+	// reconciled in parallel, 1 if 0.
+	MaxConcurrentReconciles int
+	// RateLimiter delays the requeues of the failed reconciliations, the
+	// default rate limiter is used if nil.
+	RateLimiter ratelimiter.RateLimiter
+	// Shard is the shard of the ClusterInstallations reconciled by this
+	// replica of the operator, all of them by default.
+	Shard sharding.Shard
+	// Namespaces selects the namespaces of the ClusterInstallations
+	// reconciled by the operator, all of them if nil.
+	Namespaces *namespaceselector.Selector
+	// MigrationVerificationTimeout is how long the migration to Mattermost
+	// waits for the migrated Mattermost to report that it reaches its
+	// database and file store before rolling back, the application health
+	// is not verified if 0.
+	MigrationVerificationTimeout time.Duration
 }
 
 // +kubebuilder:rbac:groups=mattermost.com,resources=clusterinstallations,verbs=get;list;watch;create;update;patch;delete
 // +kubebuilder:rbac:groups=mattermost.com,resources=clusterinstallations/status,verbs=get;update;patch
 
 func (r *ClusterInstallationReconciler) SetupWithManager(mgr ctrl.Manager) error {
-	return ctrl.NewControllerManagedBy(mgr).
-		For(&mattermostv1alpha1.ClusterInstallation{}).
+	blder := ctrl.NewControllerManagedBy(mgr).
+		For(&mattermostv1alpha1.ClusterInstallation{}, builder.WithPredicates(r.Shard.Predicate(), r.Namespaces.Predicate())).
 		Owns(&corev1.Service{}).
 		Owns(&corev1.Secret{}).
 		Owns(&networkingv1.Ingress{}).
 		Owns(&appsv1.Deployment{}).
 		Owns(&batchv1.Job{}).
-		Complete(r)
+		WithOptions(controller.Options{MaxConcurrentReconciles: r.MaxConcurrentReconciles, RateLimiter: r.RateLimiter})
+	return r.Namespaces.Watch(blder, &mattermostv1alpha1.ClusterInstallationList{}).Complete(r)
 }
 
 // Reconcile reads the state of the cluster for a ClusterInstallation object and
@@ -61,7 +85,7 @@ func (r *ClusterInstallationReconciler) SetupWithManager(mgr ctrl.Manager) error
 // error is non-nil or Result.Requeue is true, otherwise upon completion it will
 // remove the work from the queue.
 func (r *ClusterInstallationReconciler) Reconcile(ctx context.Context, request ctrl.Request) (ctrl.Result, error) {
-	reqLogger := r.Log.WithValues("Request.Namespace", request.Namespace, "Request.Name", request.Name)
+	reqLogger := r.Log.WithValues("installation", request.Name, "namespace", request.Namespace)
 	reqLogger.Info("Reconciling ClusterInstallation")
 
 	// Fetch the ClusterInstallation.
@@ -76,6 +100,19 @@ func (r *ClusterInstallationReconciler) Reconcile(ctx context.Context, request c
 		// Error reading the object - requeue the request.
 		return reconcile.Result{}, err
 	}
+	// The events of the owned resources are not filtered by shard.
+	if !r.Shard.Owns(mattermost) {
+		reqLogger.V(1).Info("Skipping ClusterInstallation of another shard", "shard", r.Shard.Of(mattermost))
+		return reconcile.Result{}, nil
+	}
+	selected, err := r.Namespaces.Selects(ctx, mattermost.Namespace)
+	if err != nil {
+		return reconcile.Result{}, err
+	} else if !selected {
+		reqLogger.V(1).Info("Skipping ClusterInstallation of a namespace not selected")
+		return reconcile.Result{}, nil
+	}
+	reqLogger = logging.ForObject(reqLogger, mattermost)
 
 	if mattermost.Status.State != mattermostv1alpha1.Reconciling {
 		var clusterInstallations mattermostv1alpha1.ClusterInstallationList
@@ -85,7 +122,7 @@ func (r *ClusterInstallationReconciler) Reconcile(ctx context.Context, request c
 		}
 
 		// Check if limit of Cluster Installations reconciling at the same time is reached.
-		if countReconciling(clusterInstallations.Items) >= r.MaxReconciling {
+		if countReconciling(clusterInstallations.Items, r.Shard) >= r.MaxReconciling {
 			reqLogger.Info(fmt.Sprintf("Reached limit of reconciling installations, requeuing in %s", r.RequeueOnLimitDelay.String()))
 			return ctrl.Result{RequeueAfter: r.RequeueOnLimitDelay}, nil
 		}
@@ -114,7 +151,7 @@ func (r *ClusterInstallationReconciler) Reconcile(ctx context.Context, request c
 	originalMattermost := mattermost.DeepCopy()
 	err = mattermost.SetDefaults()
 	if err != nil {
-		r.setStateReconcilingAndLogError(mattermost, reqLogger)
+		r.setStateReconcilingAndLogError(mattermost, err, reqLogger)
 		return reconcile.Result{}, err
 	}
 
@@ -131,42 +168,49 @@ func (r *ClusterInstallationReconciler) Reconcile(ctx context.Context, request c
 		err = r.Client.Update(ctx, mattermost)
 		if err != nil {
 			reqLogger.Error(err, "failed to update the clusterinstallation spec")
-			r.setStateReconcilingAndLogError(mattermost, reqLogger)
+			r.setStateReconcilingAndLogError(mattermost, err, reqLogger)
 			return reconcile.Result{}, err
 		}
 	}
 
 	err = r.checkDatabase(ctx, mattermost, reqLogger)
 	if err != nil {
-		r.setStateReconcilingAndLogError(mattermost, reqLogger)
+		r.setStateReconcilingAndLogError(mattermost, err, reqLogger)
 		return reconcile.Result{}, err
 	}
 
 	err = r.checkMinio(mattermost, reqLogger)
 	if err != nil {
-		r.setStateReconcilingAndLogError(mattermost, reqLogger)
+		r.setStateReconcilingAndLogError(mattermost, err, reqLogger)
 		return reconcile.Result{}, err
 	}
 
 	err = r.checkMattermost(mattermost, reqLogger)
 	if err != nil {
-		r.setStateReconcilingAndLogError(mattermost, reqLogger)
+		r.setStateReconcilingAndLogError(mattermost, err, reqLogger)
 		return reconcile.Result{}, err
 	}
 
 	err = r.checkBlueGreen(mattermost, reqLogger)
 	if err != nil {
-		r.setStateReconcilingAndLogError(mattermost, reqLogger)
+		r.setStateReconcilingAndLogError(mattermost, err, reqLogger)
 		return reconcile.Result{}, err
 	}
 
 	err = r.checkCanary(mattermost, reqLogger)
 	if err != nil {
-		r.setStateReconcilingAndLogError(mattermost, reqLogger)
+		r.setStateReconcilingAndLogError(mattermost, err, reqLogger)
 		return reconcile.Result{}, err
 	}
 
 	status, err := r.handleCheckClusterInstallation(mattermost, reqLogger)
+	if migration := mattermost.Status.Migration; migration != nil && migration.RolledBack {
+		// Keep reporting why the migration was rolled back.
+		status.Migration = migration
+	}
+	status.Conditions = mattermost.Status.Conditions
+	status.LastError = mattermost.Status.LastError
+	setStateConditions(&status, mattermost.Generation, err, nil)
 	if err != nil {
 		statusErr := r.updateStatus(mattermost, status, reqLogger)
 		if statusErr != nil {
@@ -178,7 +222,7 @@ func (r *ClusterInstallationReconciler) Reconcile(ctx context.Context, request c
 
 	err = r.updateStatus(mattermost, status, reqLogger)
 	if err != nil {
-		r.setStateReconcilingAndLogError(mattermost, reqLogger)
+		r.setStateReconcilingAndLogError(mattermost, err, reqLogger)
 		return reconcile.Result{}, err
 	}
 
@@ -236,7 +280,9 @@ func (r *ClusterInstallationReconciler) tryToMigrate(mattermost *mattermostv1alp
 
 	status := mattermost.Status
 	status.Migration = &mattermostv1alpha1.MigrationStatus{
-		Status: res.Status,
+		Status:     res.Status,
+		Error:      res.Error,
+		RolledBack: res.RolledBack,
 	}
 	err = r.updateStatus(mattermost, status, reqLogger)
 	if err != nil {
@@ -246,10 +292,10 @@ func (r *ClusterInstallationReconciler) tryToMigrate(mattermost *mattermostv1alp
 	return ctrl.Result{RequeueAfter: res.RequeueIn}, nil
 }
 
-func countReconciling(clusterInstallations []mattermostv1alpha1.ClusterInstallation) int {
+func countReconciling(clusterInstallations []mattermostv1alpha1.ClusterInstallation, shard sharding.Shard) int {
 	sum := 0
 	for _, ci := range clusterInstallations {
-		if ci.Status.State == mattermostv1alpha1.Reconciling {
+		if ci.Status.State == mattermostv1alpha1.Reconciling && shard.Owns(&ci) {
 			sum++
 		}
 	}
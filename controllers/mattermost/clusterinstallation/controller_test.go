@@ -8,6 +8,8 @@ import (
 	mmv1beta "github.com/mattermost/mattermost-operator/apis/mattermost/v1beta1"
 
 	"github.com/mattermost/mattermost-operator/pkg/resources"
+	resourcesfake "github.com/mattermost/mattermost-operator/pkg/resources/fake"
+	"github.com/mattermost/mattermost-operator/pkg/sharding"
 
 	blubr "github.com/mattermost/blubr"
 	"github.com/mattermost/mattermost-operator/pkg/components/utils"
@@ -17,6 +19,7 @@ import (
 	"golang.org/x/net/context"
 	networkingv1 "k8s.io/api/networking/v1"
 	k8sErrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/meta"
 	"k8s.io/apimachinery/pkg/runtime"
 	"k8s.io/apimachinery/pkg/types"
 	"k8s.io/client-go/kubernetes/scheme"
@@ -71,7 +74,7 @@ func TestReconcile(t *testing.T) {
 		Scheme:             s,
 		Log:                logger,
 		MaxReconciling:     5,
-		Resources:          resources.NewResourceHelper(c, s),
+		Resources:          resources.NewResourceHelper(resourcesfake.NewApplyClient(c), s),
 	}
 
 	err := c.Create(context.TODO(), ci)
@@ -271,6 +274,11 @@ func TestReconcile(t *testing.T) {
 			assert.Equal(t, ci.Status.Version, ci.Spec.Version)
 			assert.Equal(t, ci.Status.Image, ci.Spec.Image)
 			assert.Equal(t, ci.Status.Endpoint, ci.Spec.IngressName)
+			assert.Equal(t, ci.Status.DesiredReplicas, ci.Spec.Replicas)
+			assert.Equal(t, ci.Status.ObservedGeneration, ci.Generation)
+			assert.True(t, meta.IsStatusConditionTrue(ci.Status.Conditions, mattermostv1alpha1.ReadyCondition))
+			assert.True(t, meta.IsStatusConditionFalse(ci.Status.Conditions, mattermostv1alpha1.ErrorCondition))
+			assert.Nil(t, ci.Status.LastError)
 		})
 	})
 
@@ -512,7 +520,7 @@ func TestReconcilingLimit(t *testing.T) {
 		Log:                 logger,
 		MaxReconciling:      2,
 		RequeueOnLimitDelay: requeueOnLimitDelay,
-		Resources:           resources.NewResourceHelper(c, s),
+		Resources:           resources.NewResourceHelper(resourcesfake.NewApplyClient(c), s),
 	}
 
 	assertInstallationsCount := func(t *testing.T, expectedCIs, expectedReconciling int) {
@@ -521,7 +529,7 @@ func TestReconcilingLimit(t *testing.T) {
 		require.NoError(t, err)
 
 		assert.Equal(t, expectedCIs, len(ciList.Items))
-		assert.Equal(t, expectedReconciling, countReconciling(ciList.Items))
+		assert.Equal(t, expectedReconciling, countReconciling(ciList.Items, sharding.Shard{}))
 	}
 
 	err := c.Create(context.TODO(), ci1)
@@ -680,7 +688,7 @@ func TestMigration(t *testing.T) {
 		Log:                 logger,
 		MaxReconciling:      2,
 		RequeueOnLimitDelay: requeueOnLimitDelay,
-		Resources:           resources.NewResourceHelper(c, s),
+		Resources:           resources.NewResourceHelper(resourcesfake.NewApplyClient(c), s),
 	}
 
 	assertMigrationStatus := func(ciName, status string) {
@@ -692,10 +700,11 @@ func TestMigration(t *testing.T) {
 		assert.Equal(t, mattermostv1alpha1.Reconciling, ci.Status.State)
 	}
 
-	t.Run("cannot perform migration with blue-green", func(t *testing.T) {
+	t.Run("cannot perform migration with blue-green and canary", func(t *testing.T) {
 		ci := ci1.DeepCopy()
-		ci.Name = "blue-green-test"
+		ci.Name = "blue-green-canary-test"
 		ci.Spec.BlueGreen = mattermostv1alpha1.BlueGreen{Enable: true}
+		ci.Spec.Canary = mattermostv1alpha1.Canary{Enable: true}
 
 		err := c.Create(context.Background(), ci)
 		require.NoError(t, err)
@@ -781,6 +790,152 @@ func TestMigration(t *testing.T) {
 		err = r.Get(context.Background(), namespacedNameForCI(ci1), ci1)
 		assert.True(t, k8sErrors.IsNotFound(err))
 	})
+
+	t.Run("roll back migration failing verification", func(t *testing.T) {
+		ci := ci1.DeepCopy()
+		ci.ResourceVersion = ""
+		ci.Name = "rollback-test"
+		err = c.Create(context.Background(), ci)
+		require.NoError(t, err)
+
+		siteURL := func(url string) corev1.PodTemplateSpec {
+			return corev1.PodTemplateSpec{Spec: corev1.PodSpec{Containers: []corev1.Container{{
+				Name: mattermostv1alpha1.MattermostAppContainerName,
+				Env:  []corev1.EnvVar{{Name: "MM_SERVICESETTINGS_SITEURL", Value: url}},
+			}}}}
+		}
+		err = c.Create(context.Background(), &appsv1.Deployment{
+			ObjectMeta: metav1.ObjectMeta{Name: ci.Name, Namespace: ciNamespace},
+			Spec: appsv1.DeploymentSpec{
+				Selector: &metav1.LabelSelector{MatchLabels: mattermostv1alpha1.ClusterInstallationSelectorLabels(ci.Name)},
+			},
+		})
+		require.NoError(t, err)
+		err = c.Create(context.Background(), &appsv1.ReplicaSet{
+			ObjectMeta: metav1.ObjectMeta{Name: ci.Name + "-1", Namespace: ciNamespace, Labels: ci.ClusterInstallationLabels(ci.Name)},
+			Spec:       appsv1.ReplicaSetSpec{Template: siteURL("https://rollback-test.mattermost.dev")},
+		})
+		require.NoError(t, err)
+
+		res, err = r.Reconcile(context.Background(), requestForCI(ci))
+		require.NoError(t, err)
+		assertMigrationStatus(ci.Name, "Migration to Mattermost is in progress - recreating deployment")
+
+		readyPod := pod.DeepCopy()
+		readyPod.ResourceVersion = ""
+		readyPod.Name = ci.Name
+		readyPod.Labels = (&mmv1beta.Mattermost{}).MattermostLabels(ci.Name)
+		err = c.Create(context.Background(), readyPod)
+		require.NoError(t, err)
+
+		res, err = r.Reconcile(context.Background(), requestForCI(ci))
+		require.NoError(t, err)
+		assertMigrationStatus(ci.Name, "Migration to Mattermost is in progress - waiting for Mattermost to be ready")
+
+		var mm mmv1beta.Mattermost
+		err = c.Get(context.Background(), namespacedNameForCI(ci), &mm)
+		require.NoError(t, err)
+		mm.Status.State = mmv1beta.Stable
+		err = c.Update(context.Background(), &mm)
+		require.NoError(t, err)
+
+		var deployment appsv1.Deployment
+		err = c.Get(context.Background(), namespacedNameForCI(ci), &deployment)
+		require.NoError(t, err)
+		deployment.Spec.Template = siteURL("https://other.mattermost.dev")
+		err = c.Update(context.Background(), &deployment)
+		require.NoError(t, err)
+
+		res, err = r.Reconcile(context.Background(), requestForCI(ci))
+		require.NoError(t, err)
+		assert.Equal(t, time.Duration(0), res.RequeueAfter)
+
+		err = c.Get(context.Background(), namespacedNameForCI(ci), &mm)
+		assert.True(t, k8sErrors.IsNotFound(err))
+		err = c.Get(context.Background(), namespacedNameForCI(ci), &deployment)
+		assert.True(t, k8sErrors.IsNotFound(err))
+		var rolledBack mattermostv1alpha1.ClusterInstallation
+		err = c.Get(context.Background(), namespacedNameForCI(ci), &rolledBack)
+		require.NoError(t, err)
+		assert.False(t, rolledBack.Spec.Migrate)
+		require.NotNil(t, rolledBack.Status.Migration)
+		assert.True(t, rolledBack.Status.Migration.RolledBack)
+		assert.Equal(t, "Migration to Mattermost rolled back - verification failed", rolledBack.Status.Migration.Status)
+		assert.Equal(t, "the migrated deployment rollback-test is configured differently: MM_SERVICESETTINGS_SITEURL", rolledBack.Status.Migration.Error)
+
+		// The rolled back ClusterInstallation no longer counts against the
+		// limit of installations reconciling.
+		rolledBack.Status.State = mattermostv1alpha1.Stable
+		err = c.Status().Update(context.Background(), &rolledBack)
+		require.NoError(t, err)
+	})
+
+	t.Run("migrate blue-green deployments", func(t *testing.T) {
+		ci := ci1.DeepCopy()
+		ci.ResourceVersion = ""
+		ci.Name = "blue-green-test"
+		ci.Spec.BlueGreen = mattermostv1alpha1.BlueGreen{
+			Enable:               true,
+			ProductionDeployment: mattermostv1alpha1.BlueName,
+			Blue: mattermostv1alpha1.AppDeployment{
+				Name:    "blue-green-test-blue",
+				Image:   "mattermost/mattermost-enterprise-edition",
+				Version: operatortest.PreviousStableMattermostVersion,
+			},
+			Green: mattermostv1alpha1.AppDeployment{
+				Name:    "blue-green-test-green",
+				Image:   "mattermost/mattermost-enterprise-edition",
+				Version: operatortest.LatestStableMattermostVersion,
+			},
+		}
+
+		err = c.Create(context.Background(), ci)
+		require.NoError(t, err)
+
+		blueGreen := []mattermostv1alpha1.AppDeployment{ci.Spec.BlueGreen.Blue, ci.Spec.BlueGreen.Green}
+		for _, appDeployment := range blueGreen {
+			err = c.Create(context.Background(), &appsv1.Deployment{
+				ObjectMeta: metav1.ObjectMeta{Name: appDeployment.Name, Namespace: ciNamespace},
+				Spec: appsv1.DeploymentSpec{
+					Selector: &metav1.LabelSelector{MatchLabels: mattermostv1alpha1.ClusterInstallationSelectorLabels(appDeployment.Name)},
+				},
+			})
+			require.NoError(t, err)
+		}
+
+		res, err = r.Reconcile(context.Background(), requestForCI(ci))
+		require.NoError(t, err)
+		assert.Equal(t, 10*time.Second, res.RequeueAfter)
+		assertMigrationStatus(ci.Name, "Migration to Mattermost is in progress - recreating deployment")
+
+		for _, appDeployment := range blueGreen {
+			var deployment appsv1.Deployment
+			err = c.Get(context.Background(), types.NamespacedName{Name: appDeployment.Name, Namespace: ciNamespace}, &deployment)
+			require.NoError(t, err)
+			assert.Equal(t, mmv1beta.MattermostSelectorLabels(appDeployment.Name), deployment.Spec.Selector.MatchLabels)
+
+			readyPod := pod.DeepCopy()
+			readyPod.ResourceVersion = ""
+			readyPod.Name = appDeployment.Name
+			readyPod.Labels = (&mmv1beta.Mattermost{}).MattermostLabels(appDeployment.Name)
+			readyPod.Spec.Containers[0].Image = appDeployment.GetDeploymentImageName()
+			err = c.Create(context.Background(), readyPod)
+			require.NoError(t, err)
+		}
+
+		res, err = r.Reconcile(context.Background(), requestForCI(ci))
+		require.NoError(t, err)
+		assertMigrationStatus(ci.Name, "Migration to Mattermost is in progress - waiting for Mattermost to be ready")
+
+		var mm mmv1beta.Mattermost
+		err = c.Get(context.Background(), namespacedNameForCI(ci), &mm)
+		require.NoError(t, err)
+		require.NotNil(t, mm.Spec.BlueGreen)
+		assert.True(t, mm.Spec.BlueGreen.Enabled)
+		assert.Equal(t, mmv1beta.BlueName, mm.Spec.BlueGreen.ProductionDeployment)
+		assert.Equal(t, "blue-green-test-blue", mm.Spec.BlueGreen.Blue.Name)
+		assert.Equal(t, "blue-green-test-green", mm.Spec.BlueGreen.Green.Name)
+	})
 }
 
 func namespacedNameForCI(ci *mattermostv1alpha1.ClusterInstallation) types.NamespacedName {
@@ -2,6 +2,8 @@ package clusterinstallation
 
 import (
 	"context"
+	"fmt"
+	"strings"
 
 	mattermostv1alpha1 "github.com/mattermost/mattermost-operator/apis/mattermost/v1alpha1"
 	mattermostv1beta1 "github.com/mattermost/mattermost-operator/apis/mattermost/v1beta1"
@@ -14,12 +16,12 @@ import (
 )
 
 func (r *ClusterInstallationReconciler) IsConvertible(ci *mattermostv1alpha1.ClusterInstallation) error {
-	if ci.Spec.BlueGreen.Enable {
-		return errors.New("ClusterInstallation resource with BlueGreen enabled cannot be converted to Mattermost resource. Disable BlueGreen to enable migration.")
+	if ci.Spec.BlueGreen.Enable && ci.Spec.Canary.Enable {
+		return errors.New("ClusterInstallation resource with both BlueGreen and Canary enabled cannot be converted to Mattermost resource. Disable one of them to enable migration.")
 	}
 
-	if ci.Spec.Canary.Enable {
-		return errors.New("ClusterInstallation resource with Canary enabled cannot be converted to Mattermost resource. Disable Canary to enable migration.")
+	if ci.Spec.Canary.Enable && (ci.Spec.UseServiceLoadBalancer || ci.Spec.IngressName == "") {
+		return errors.New("ClusterInstallation resource with Canary enabled cannot be converted to Mattermost resource without an ingress. Set IngressName or disable Canary to enable migration.")
 	}
 
 	return nil
@@ -51,6 +53,8 @@ func (r *ClusterInstallationReconciler) ConvertToMM(ci *mattermostv1alpha1.Clust
 			ElasticSearch:          convertElasticSearch(ci.Spec.ElasticSearch),
 			Scheduling:             convertScheduling(ci.Spec),
 			Probes:                 convertProbes(ci.Spec),
+			BlueGreen:              convertBlueGreen(ci),
+			Canary:                 convertCanary(ci),
 		},
 	}
 
@@ -157,6 +161,51 @@ func convertProbes(spec mattermostv1alpha1.ClusterInstallationSpec) mattermostv1
 	}
 }
 
+func convertBlueGreen(ci *mattermostv1alpha1.ClusterInstallation) *mattermostv1beta1.BlueGreen {
+	if !ci.Spec.BlueGreen.Enable {
+		return nil
+	}
+
+	return &mattermostv1beta1.BlueGreen{
+		Enabled:              true,
+		ProductionDeployment: strings.ToLower(ci.Spec.BlueGreen.ProductionDeployment),
+		Blue:                 convertAppDeployment(ci.Spec.BlueGreen.Blue, fmt.Sprintf("%s-%s", ci.Name, mattermostv1beta1.BlueName)),
+		Green:                convertAppDeployment(ci.Spec.BlueGreen.Green, fmt.Sprintf("%s-%s", ci.Name, mattermostv1beta1.GreenName)),
+	}
+}
+
+// convertCanary converts the canary deployment. ClusterInstallation routes
+// the requests with the canary cookie to the canary deployment, which the
+// Mattermost does not support, therefore the canary starts with no share of
+// the requests.
+func convertCanary(ci *mattermostv1alpha1.ClusterInstallation) *mattermostv1beta1.Canary {
+	if !ci.Spec.Canary.Enable {
+		return nil
+	}
+
+	return &mattermostv1beta1.Canary{
+		Enabled:    true,
+		Weight:     0,
+		Deployment: convertAppDeployment(ci.Spec.Canary.Deployment, fmt.Sprintf("%s-%s", ci.Name, mattermostv1beta1.CanaryName)),
+	}
+}
+
+// convertAppDeployment converts the deployment, setting its name so that the
+// existing deployment is handed over even if the name was not stored.
+func convertAppDeployment(deployment mattermostv1alpha1.AppDeployment, defaultName string) mattermostv1beta1.AppDeployment {
+	name := deployment.Name
+	if name == "" {
+		name = defaultName
+	}
+
+	return mattermostv1beta1.AppDeployment{
+		Name:        name,
+		IngressHost: deployment.IngressName,
+		Image:       deployment.Image,
+		Version:     deployment.Version,
+	}
+}
+
 func convertReplicas(old int32) *int32 {
 	if old < 0 {
 		return utils.NewInt32(0)
@@ -307,6 +307,118 @@ func TestConvertToMM(t *testing.T) {
 				},
 			},
 		},
+		{
+			description: "should convert BlueGreen",
+			clusterInstallation: mattermostv1alpha1.ClusterInstallation{
+				ObjectMeta: fixObjectMeta(),
+				Spec: mattermostv1alpha1.ClusterInstallationSpec{
+					IngressName: "ingress",
+					BlueGreen: mattermostv1alpha1.BlueGreen{
+						Enable:               true,
+						ProductionDeployment: "Green",
+						Blue: mattermostv1alpha1.AppDeployment{
+							Name:        "my-blue",
+							IngressName: "blue.ingress",
+							Image:       "blue-image",
+							Version:     "blue-ver",
+						},
+						Green: mattermostv1alpha1.AppDeployment{
+							Image:   "green-image",
+							Version: "green-ver",
+						},
+					},
+				},
+			},
+			mattermost: mattermostv1beta1.Mattermost{
+				ObjectMeta: fixObjectMeta(),
+				Spec: mattermostv1beta1.MattermostSpec{
+					IngressName: "ingress",
+					Database: mattermostv1beta1.Database{
+						OperatorManaged: &mattermostv1beta1.OperatorManagedDatabase{},
+					},
+					FileStore: mattermostv1beta1.FileStore{
+						OperatorManaged: &mattermostv1beta1.OperatorManagedMinio{},
+					},
+					BlueGreen: &mattermostv1beta1.BlueGreen{
+						Enabled:              true,
+						ProductionDeployment: mattermostv1beta1.GreenName,
+						Blue: mattermostv1beta1.AppDeployment{
+							Name:        "my-blue",
+							IngressHost: "blue.ingress",
+							Image:       "blue-image",
+							Version:     "blue-ver",
+						},
+						Green: mattermostv1beta1.AppDeployment{
+							Name:    "test-name-green",
+							Image:   "green-image",
+							Version: "green-ver",
+						},
+					},
+				},
+			},
+		},
+		{
+			description: "should convert Canary",
+			clusterInstallation: mattermostv1alpha1.ClusterInstallation{
+				ObjectMeta: fixObjectMeta(),
+				Spec: mattermostv1alpha1.ClusterInstallationSpec{
+					IngressName: "ingress",
+					Canary: mattermostv1alpha1.Canary{
+						Enable: true,
+						Deployment: mattermostv1alpha1.AppDeployment{
+							Image:   "canary-image",
+							Version: "canary-ver",
+						},
+					},
+				},
+			},
+			mattermost: mattermostv1beta1.Mattermost{
+				ObjectMeta: fixObjectMeta(),
+				Spec: mattermostv1beta1.MattermostSpec{
+					IngressName: "ingress",
+					Database: mattermostv1beta1.Database{
+						OperatorManaged: &mattermostv1beta1.OperatorManagedDatabase{},
+					},
+					FileStore: mattermostv1beta1.FileStore{
+						OperatorManaged: &mattermostv1beta1.OperatorManagedMinio{},
+					},
+					Canary: &mattermostv1beta1.Canary{
+						Enabled: true,
+						Weight:  0,
+						Deployment: mattermostv1beta1.AppDeployment{
+							Name:    "test-name-canary",
+							Image:   "canary-image",
+							Version: "canary-ver",
+						},
+					},
+				},
+			},
+		},
+		{
+			description: "should skip disabled BlueGreen and Canary",
+			clusterInstallation: mattermostv1alpha1.ClusterInstallation{
+				ObjectMeta: fixObjectMeta(),
+				Spec: mattermostv1alpha1.ClusterInstallationSpec{
+					BlueGreen: mattermostv1alpha1.BlueGreen{
+						Blue: mattermostv1alpha1.AppDeployment{Version: "blue-ver"},
+					},
+					Canary: mattermostv1alpha1.Canary{
+						Deployment: mattermostv1alpha1.AppDeployment{Version: "canary-ver"},
+					},
+				},
+			},
+			mattermost: mattermostv1beta1.Mattermost{
+				ObjectMeta: fixObjectMeta(),
+				Spec: mattermostv1beta1.MattermostSpec{
+					Database: mattermostv1beta1.Database{
+						OperatorManaged: &mattermostv1beta1.OperatorManagedDatabase{},
+					},
+					FileStore: mattermostv1beta1.FileStore{
+						OperatorManaged: &mattermostv1beta1.OperatorManagedMinio{},
+					},
+				},
+			},
+		},
 	} {
 		t.Run(testCase.description, func(t *testing.T) {
 			mm, err := reconciler.ConvertToMM(&testCase.clusterInstallation)
@@ -351,6 +463,73 @@ func TestConvertToMM(t *testing.T) {
 	})
 }
 
+func TestIsConvertible(t *testing.T) {
+	reconciler := &ClusterInstallationReconciler{}
+
+	for _, testCase := range []struct {
+		description string
+		spec        mattermostv1alpha1.ClusterInstallationSpec
+		convertible bool
+	}{
+		{
+			description: "default",
+			spec:        mattermostv1alpha1.ClusterInstallationSpec{},
+			convertible: true,
+		},
+		{
+			description: "BlueGreen",
+			spec: mattermostv1alpha1.ClusterInstallationSpec{
+				BlueGreen: mattermostv1alpha1.BlueGreen{Enable: true},
+			},
+			convertible: true,
+		},
+		{
+			description: "Canary with ingress",
+			spec: mattermostv1alpha1.ClusterInstallationSpec{
+				IngressName: "ingress",
+				Canary:      mattermostv1alpha1.Canary{Enable: true},
+			},
+			convertible: true,
+		},
+		{
+			description: "Canary without ingress",
+			spec: mattermostv1alpha1.ClusterInstallationSpec{
+				Canary: mattermostv1alpha1.Canary{Enable: true},
+			},
+			convertible: false,
+		},
+		{
+			description: "Canary with service load balancer",
+			spec: mattermostv1alpha1.ClusterInstallationSpec{
+				IngressName:            "ingress",
+				UseServiceLoadBalancer: true,
+				Canary:                 mattermostv1alpha1.Canary{Enable: true},
+			},
+			convertible: false,
+		},
+		{
+			description: "BlueGreen and Canary",
+			spec: mattermostv1alpha1.ClusterInstallationSpec{
+				IngressName: "ingress",
+				BlueGreen:   mattermostv1alpha1.BlueGreen{Enable: true},
+				Canary:      mattermostv1alpha1.Canary{Enable: true},
+			},
+			convertible: false,
+		},
+	} {
+		t.Run(testCase.description, func(t *testing.T) {
+			ci := &mattermostv1alpha1.ClusterInstallation{ObjectMeta: fixObjectMeta(), Spec: testCase.spec}
+
+			err := reconciler.IsConvertible(ci)
+			if testCase.convertible {
+				assert.NoError(t, err)
+			} else {
+				assert.Error(t, err)
+			}
+		})
+	}
+}
+
 func fixObjectMeta() metav1.ObjectMeta {
 	return metav1.ObjectMeta{
 		Name:      "test-name",
@@ -5,6 +5,7 @@ import (
 	"testing"
 
 	"github.com/mattermost/mattermost-operator/pkg/resources"
+	resourcesfake "github.com/mattermost/mattermost-operator/pkg/resources/fake"
 
 	rbacv1 "k8s.io/api/rbac/v1"
 
@@ -58,7 +59,7 @@ func TestCheckMattermost(t *testing.T) {
 		Scheme:         s,
 		Log:            logger,
 		MaxReconciling: 5,
-		Resources:      resources.NewResourceHelper(c, s),
+		Resources:      resources.NewResourceHelper(resourcesfake.NewApplyClient(c), s),
 	}
 
 	err := prepAllDependencyTestResources(r.Client, ci)
@@ -384,7 +385,7 @@ func TestCheckMattermostExternalDB(t *testing.T) {
 		Scheme:              s,
 		MaxReconciling:      5,
 		RequeueOnLimitDelay: 0,
-		Resources:           resources.NewResourceHelper(c, s),
+		Resources:           resources.NewResourceHelper(resourcesfake.NewApplyClient(c), s),
 	}
 
 	err := prepAllDependencyTestResources(r.Client, ci)
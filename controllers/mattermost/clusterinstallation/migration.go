@@ -21,6 +21,10 @@ type MigrationResult struct {
 	Status    string
 	RequeueIn time.Duration
 	Finished  bool
+	// RolledBack is set when the verification of the migrated Mattermost
+	// failed and the migration was rolled back, Error holding the reason.
+	RolledBack bool
+	Error      string
 }
 
 // HandleMigration performs necessary steps to migrate ClusterInstallation to Mattermost.
@@ -65,8 +69,31 @@ func (r *ClusterInstallationReconciler) HandleMigration(ci *mattermostv1alpha1.C
 		}, nil
 	}
 
+	verified, failure, err := r.verifyMigration(ci, &existingMM, logger)
+	if err != nil {
+		return MigrationResult{}, errors.Wrap(err, "failed to verify migration")
+	}
+	if failure != "" {
+		logger.Info("Migration verification failed. Rolling back", "reason", failure)
+		err = r.rollbackMigration(ci, &existingMM, logger)
+		if err != nil {
+			return MigrationResult{}, errors.Wrap(err, "failed to roll back migration")
+		}
+		return MigrationResult{
+			Status:     "Migration to Mattermost rolled back - verification failed",
+			RolledBack: true,
+			Error:      failure,
+		}, nil
+	}
+	if !verified {
+		return MigrationResult{
+			RequeueIn: 10 * time.Second,
+			Status:    "Migration to Mattermost is in progress - verifying Mattermost",
+		}, nil
+	}
+
 	logger.Info("Migration finished. Removing old Replica Sets and ClusterInstallation")
-	err = r.cleanupReplicaSets(ci)
+	err = r.cleanupReplicaSets(ci, &existingMM)
 	if err != nil {
 		return MigrationResult{}, errors.Wrap(err, "failed to cleanup old Replica Sets")
 	}
@@ -78,6 +105,33 @@ func (r *ClusterInstallationReconciler) HandleMigration(ci *mattermostv1alpha1.C
 	return MigrationResult{Finished: true}, nil
 }
 
+// migratedDeployment is a Mattermost deployment of the ClusterInstallation
+// handed over to the Mattermost.
+type migratedDeployment struct {
+	name  string
+	image string
+}
+
+// migratedDeployments returns the deployments handed over to the Mattermost,
+// the blue and green deployments replacing the Mattermost deployment if
+// BlueGreen is enabled and the canary deployment if Canary is enabled.
+func migratedDeployments(mm *mmv1beta.Mattermost) []migratedDeployment {
+	deployments := []migratedDeployment{{name: mm.Name, image: mm.GetImageName()}}
+	if mm.BlueGreenEnabled() {
+		blue, green := mm.Spec.BlueGreen.Blue, mm.Spec.BlueGreen.Green
+		deployments = []migratedDeployment{
+			{name: blue.Name, image: mm.GetAppDeploymentImageName(blue)},
+			{name: green.Name, image: mm.GetAppDeploymentImageName(green)},
+		}
+	}
+	if mm.CanaryEnabled() {
+		canary := mm.Spec.Canary.Deployment
+		deployments = append(deployments, migratedDeployment{name: canary.Name, image: mm.GetAppDeploymentImageName(canary)})
+	}
+
+	return deployments
+}
+
 // initializeMigration initializes migration of ClusterInstallation.
 // Returns indication if the initialization if finished or an error.
 func (r *ClusterInstallationReconciler) initializeMigration(ci *mattermostv1alpha1.ClusterInstallation, logger logr.Logger) (bool, error) {
@@ -86,27 +140,32 @@ func (r *ClusterInstallationReconciler) initializeMigration(ci *mattermostv1alph
 		return false, errors.Wrap(err, "failed to convert ClusterInstallation to Mattermost")
 	}
 
-	isMigrated, err := r.isDeploymentMigrated(mm, ci)
-	if err != nil {
-		return false, errors.Wrap(err, "failed to check if Deployment is migrated")
-	}
-
-	if !isMigrated {
-		logger.Info("Deployment is not migrated. Starting recreation")
-		err = r.recreateDeployment(mm, ci, logger)
+	deployments := migratedDeployments(mm)
+	for _, deployment := range deployments {
+		isMigrated, err := r.isDeploymentMigrated(mm, ci, deployment.name)
 		if err != nil {
-			return false, errors.Wrap(err, "failed to migrate Deployment")
+			return false, errors.Wrapf(err, "failed to check if Deployment %s is migrated", deployment.name)
 		}
-	}
 
-	isReady, err := r.isDeploymentReady(mm, ci, logger)
-	if err != nil {
-		return false, errors.Wrap(err, "failed to check if deployment is ready")
+		if !isMigrated {
+			logger.Info("Deployment is not migrated. Starting recreation", "deployment", deployment.name)
+			err = r.recreateDeployment(mm, ci, deployment.name, logger)
+			if err != nil {
+				return false, errors.Wrapf(err, "failed to migrate Deployment %s", deployment.name)
+			}
+		}
 	}
 
-	if !isReady {
-		logger.Info("Deployment is not ready after recreation")
-		return false, nil
+	for _, deployment := range deployments {
+		isReady, err := r.isDeploymentReady(mm, ci, deployment, logger)
+		if err != nil {
+			return false, errors.Wrapf(err, "failed to check if deployment %s is ready", deployment.name)
+		}
+
+		if !isReady {
+			logger.Info("Deployment is not ready after recreation", "deployment", deployment.name)
+			return false, nil
+		}
 	}
 
 	logger.Info("Deployment migration finished. Creating Mattermost CR")
@@ -118,8 +177,8 @@ func (r *ClusterInstallationReconciler) initializeMigration(ci *mattermostv1alph
 	return true, nil
 }
 
-func (r *ClusterInstallationReconciler) recreateDeployment(mm *mmv1beta.Mattermost, ci *mattermostv1alpha1.ClusterInstallation, logger logr.Logger) error {
-	oldDeploymentName := types.NamespacedName{Name: ci.Name, Namespace: ci.Namespace}
+func (r *ClusterInstallationReconciler) recreateDeployment(mm *mmv1beta.Mattermost, ci *mattermostv1alpha1.ClusterInstallation, name string, logger logr.Logger) error {
+	oldDeploymentName := types.NamespacedName{Name: name, Namespace: ci.Namespace}
 	var oldDeployment appsv1.Deployment
 	err := r.Client.Get(context.TODO(), oldDeploymentName, &oldDeployment)
 	if err != nil {
@@ -137,11 +196,11 @@ func (r *ClusterInstallationReconciler) recreateDeployment(mm *mmv1beta.Mattermo
 		return errors.Wrap(err, "error while waiting for deployment deletion")
 	}
 
-	newLabels := mm.MattermostLabels(mm.Name)
-	selectorLabels := mmv1beta.MattermostSelectorLabels(mm.Name)
+	newLabels := mm.MattermostLabels(name)
+	selectorLabels := mmv1beta.MattermostSelectorLabels(name)
 	newDeployment := &appsv1.Deployment{
 		ObjectMeta: v1.ObjectMeta{
-			Name:      ci.Name,
+			Name:      name,
 			Namespace: ci.Namespace,
 			Labels:    newLabels,
 		},
@@ -182,8 +241,8 @@ func (r *ClusterInstallationReconciler) waitForDeploymentDeletion(name types.Nam
 	}
 }
 
-func (r *ClusterInstallationReconciler) isDeploymentMigrated(mm *mmv1beta.Mattermost, ci *mattermostv1alpha1.ClusterInstallation) (bool, error) {
-	labels := mm.MattermostLabels(mm.Name)
+func (r *ClusterInstallationReconciler) isDeploymentMigrated(mm *mmv1beta.Mattermost, ci *mattermostv1alpha1.ClusterInstallation, name string) (bool, error) {
+	labels := mm.MattermostLabels(name)
 	listOptions := []client.ListOption{
 		client.InNamespace(ci.Namespace),
 		client.MatchingLabels(labels),
@@ -195,11 +254,11 @@ func (r *ClusterInstallationReconciler) isDeploymentMigrated(mm *mmv1beta.Matter
 		return false, errors.Wrap(err, "failed to list deployments")
 	}
 
-	return len(deployments.Items) == 1, nil
+	return getDeployment(deployments, name) != nil, nil
 }
 
-func (r *ClusterInstallationReconciler) isDeploymentReady(mm *mmv1beta.Mattermost, ci *mattermostv1alpha1.ClusterInstallation, logger logr.Logger) (bool, error) {
-	labels := mm.MattermostLabels(mm.Name)
+func (r *ClusterInstallationReconciler) isDeploymentReady(mm *mmv1beta.Mattermost, ci *mattermostv1alpha1.ClusterInstallation, deployment migratedDeployment, logger logr.Logger) (bool, error) {
+	labels := mm.MattermostLabels(deployment.name)
 	listOptions := []client.ListOption{
 		client.InNamespace(ci.Namespace),
 		client.MatchingLabels(labels),
@@ -211,14 +270,14 @@ func (r *ClusterInstallationReconciler) isDeploymentReady(mm *mmv1beta.Mattermos
 		return false, errors.Wrap(err, "failed to list deployments")
 	}
 
-	mmDeployment := getDeployment(deployments, mm.Name)
+	mmDeployment := getDeployment(deployments, deployment.name)
 	if mmDeployment == nil {
 		return false, fmt.Errorf("failed to found migrated deployment")
 	}
 
 	healthChecker := healthcheck.NewHealthChecker(r.NonCachedAPIReader, listOptions, logger)
 
-	status, err := healthChecker.CheckPodsRollOut(mm.GetImageName())
+	status, err := healthChecker.CheckPodsRollOut(deployment.image)
 	if err != nil {
 		return false, errors.Wrap(err, "failed to check if pods are ready")
 	}
@@ -231,22 +290,24 @@ func (r *ClusterInstallationReconciler) isDeploymentReady(mm *mmv1beta.Mattermos
 	return replicas == status.UpdatedReplicas, nil
 }
 
-func (r *ClusterInstallationReconciler) cleanupReplicaSets(ci *mattermostv1alpha1.ClusterInstallation) error {
-	listOptions := []client.ListOption{
-		client.InNamespace(ci.Namespace),
-		client.MatchingLabels(ci.ClusterInstallationLabels(ci.Name)),
-	}
-
-	replicaSets := appsv1.ReplicaSetList{}
-	err := r.List(context.TODO(), &replicaSets, listOptions...)
-	if err != nil {
-		return errors.Wrap(err, "failed to list Replica Sets")
-	}
+func (r *ClusterInstallationReconciler) cleanupReplicaSets(ci *mattermostv1alpha1.ClusterInstallation, mm *mmv1beta.Mattermost) error {
+	for _, deployment := range migratedDeployments(mm) {
+		listOptions := []client.ListOption{
+			client.InNamespace(ci.Namespace),
+			client.MatchingLabels(ci.ClusterInstallationLabels(deployment.name)),
+		}
 
-	for _, res := range replicaSets.Items {
-		err = r.Delete(context.TODO(), &res)
+		replicaSets := appsv1.ReplicaSetList{}
+		err := r.List(context.TODO(), &replicaSets, listOptions...)
 		if err != nil {
-			return errors.Wrap(err, "failed to remove old Replica Sets")
+			return errors.Wrap(err, "failed to list Replica Sets")
+		}
+
+		for _, res := range replicaSets.Items {
+			err = r.Delete(context.TODO(), &res)
+			if err != nil {
+				return errors.Wrap(err, "failed to remove old Replica Sets")
+			}
 		}
 	}
 
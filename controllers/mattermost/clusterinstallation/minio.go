@@ -15,7 +15,7 @@ import (
 )
 
 func (r *ClusterInstallationReconciler) checkMinio(mattermost *mattermostv1alpha1.ClusterInstallation, reqLogger logr.Logger) error {
-	reqLogger = reqLogger.WithValues("Reconcile", "minio")
+	reqLogger = reqLogger.WithValues("phase", "minio")
 
 	err := r.checkMinioSecret(mattermost, reqLogger)
 	if err != nil {
@@ -16,7 +16,7 @@ import (
 )
 
 func (r *ClusterInstallationReconciler) checkMySQLCluster(mattermost *mattermostv1alpha1.ClusterInstallation, reqLogger logr.Logger) error {
-	reqLogger = reqLogger.WithValues("Reconcile", "mysql")
+	reqLogger = reqLogger.WithValues("phase", "mysql")
 	desired := mattermostmysql.Cluster(mattermost)
 
 	err := r.Resources.CreateMySQLClusterIfNotExists(mattermost, desired, reqLogger)
@@ -10,7 +10,7 @@ import (
 
 // TODO: implement postgres
 func (r *ClusterInstallationReconciler) checkPostgres(mattermost *mattermostv1alpha1.ClusterInstallation, reqLogger logr.Logger) error {
-	// reqLogger := reqLogger.WithValues("Reconcile", "postgres")
+	// reqLogger := reqLogger.WithValues("phase", "postgres")
 
 	return errors.New("database type 'postgres' not yet implemented")
 }
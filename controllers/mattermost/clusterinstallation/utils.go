@@ -9,6 +9,7 @@ import (
 
 	"github.com/go-logr/logr"
 	mattermostv1alpha1 "github.com/mattermost/mattermost-operator/apis/mattermost/v1alpha1"
+	"github.com/mattermost/mattermost-operator/pkg/utils"
 	"github.com/pkg/errors"
 	corev1 "k8s.io/api/core/v1"
 	"k8s.io/apimachinery/pkg/types"
@@ -82,10 +83,14 @@ func (r *ClusterInstallationReconciler) handleCheckClusterInstallation(mattermos
 // check at the very end to ensure that everything in the installation is as it
 // should be. Over time, more types of checks should be added here as needed.
 func (r *ClusterInstallationReconciler) checkClusterInstallation(namespace, name, imageName, image, version string, replicas int32, useServiceLoadBalancer bool, labels map[string]string, logger logr.Logger) (mattermostv1alpha1.ClusterInstallationStatus, error) {
+	if replicas < 0 {
+		replicas = 0
+	}
 	status := mattermostv1alpha1.ClusterInstallationStatus{
 		State:           mattermostv1alpha1.Reconciling,
 		Replicas:        0,
 		UpdatedReplicas: 0,
+		DesiredReplicas: replicas,
 	}
 
 	listOptions := []client.ListOption{
@@ -108,10 +113,6 @@ func (r *ClusterInstallationReconciler) checkClusterInstallation(namespace, name
 	status.UpdatedReplicas = podsStatus.UpdatedReplicas
 	status.Replicas = podsStatus.Replicas
 
-	if replicas < 0 {
-		replicas = 0
-	}
-
 	if podsStatus.UpdatedReplicas != replicas {
 		return status, fmt.Errorf("found %d updated replicas, but wanted %d", podsStatus.UpdatedReplicas, replicas)
 	}
@@ -164,13 +165,15 @@ func (r *ClusterInstallationReconciler) checkSecret(secretName, keyName, namespa
 }
 
 func (r *ClusterInstallationReconciler) updateStatus(mattermost *mattermostv1alpha1.ClusterInstallation, status mattermostv1alpha1.ClusterInstallationStatus, reqLogger logr.Logger) error {
+	status.ObservedGeneration = mattermost.Generation
 	if !reflect.DeepEqual(mattermost.Status, status) {
 		if mattermost.Status.State != status.State {
 			reqLogger.Info(fmt.Sprintf("Updating ClusterInstallation state from '%s' to '%s'", mattermost.Status.State, status.State))
 		}
 
-		mattermost.Status = status
-		err := r.Client.Status().Update(context.TODO(), mattermost)
+		err := utils.PatchStatus(context.TODO(), r.Client, mattermost, func() {
+			mattermost.Status = status
+		})
 		if err != nil {
 			return errors.Wrap(err, "failed to update the clusterinstallation status")
 		}
@@ -185,10 +188,14 @@ func (r *ClusterInstallationReconciler) setStateReconciling(mattermost *mattermo
 }
 
 // setStateReconcilingAndLogError attempts to set the ClusterInstallation state
-// to reconciling. Any errors attempting this are logged, but not returned. This
-// should only be used when the outcome of setting the state can be ignored.
-func (r *ClusterInstallationReconciler) setStateReconcilingAndLogError(mattermost *mattermostv1alpha1.ClusterInstallation, reqLogger logr.Logger) {
-	err := r.setState(mattermost, mattermostv1alpha1.Reconciling, reqLogger)
+// to reconciling and reports the reconciliation error in the status. Any errors
+// attempting this are logged, but not returned. This should only be used when
+// the outcome of setting the state can be ignored.
+func (r *ClusterInstallationReconciler) setStateReconcilingAndLogError(mattermost *mattermostv1alpha1.ClusterInstallation, reconcileErr error, reqLogger logr.Logger) {
+	status := mattermost.Status
+	status.State = mattermostv1alpha1.Reconciling
+	setStateConditions(&status, mattermost.Generation, nil, reconcileErr)
+	err := r.updateStatus(mattermost, status, reqLogger)
 	if err != nil {
 		reqLogger.Error(err, "Failed to set state to reconciling")
 	}
@@ -200,6 +207,7 @@ func (r *ClusterInstallationReconciler) setState(mattermost *mattermostv1alpha1.
 	if mattermost.Status.State != desired {
 		status := mattermost.Status
 		status.State = desired
+		setStateConditions(&status, mattermost.Generation, nil, nil)
 		err := r.updateStatus(mattermost, status, reqLogger)
 		if err != nil {
 			return errors.Wrapf(err, "failed to set state to %s", desired)
@@ -0,0 +1,258 @@
+package clusterinstallation
+
+import (
+	"context"
+	"fmt"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/go-logr/logr"
+	mattermostv1alpha1 "github.com/mattermost/mattermost-operator/apis/mattermost/v1alpha1"
+	mmv1beta "github.com/mattermost/mattermost-operator/apis/mattermost/v1beta1"
+	"github.com/pkg/errors"
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	k8sErrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/meta"
+	v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+// verifiedEnvVars are the environment variables of the Mattermost container
+// configuring the database, the file store and the site URL, which must
+// resolve to the same values on the migrated deployments as on the
+// deployments of the ClusterInstallation.
+var verifiedEnvVars = []string{
+	"MM_CONFIG",
+	"MM_SQLSETTINGS_DRIVERNAME",
+	"MM_SQLSETTINGS_DATASOURCE",
+	"MM_FILESETTINGS_DRIVERNAME",
+	"MM_FILESETTINGS_DIRECTORY",
+	"MM_FILESETTINGS_AMAZONS3BUCKET",
+	"MM_FILESETTINGS_AMAZONS3ENDPOINT",
+	"MM_FILESETTINGS_AMAZONS3ACCESSKEYID",
+	"MM_FILESETTINGS_AMAZONS3SECRETACCESSKEY",
+	"MM_SERVICESETTINGS_SITEURL",
+}
+
+// envVarReference matches the references to other environment variables
+// expanded by Kubernetes.
+var envVarReference = regexp.MustCompile(`\$\(([A-Za-z_][A-Za-z0-9_]*)\)`)
+
+// verifyMigration verifies that the migrated Mattermost is configured like
+// the ClusterInstallation and, unless MigrationVerificationTimeout is 0, that
+// it reaches its database and file store. It returns whether the migration
+// is verified, or the reason the verification failed and the migration must
+// be rolled back.
+func (r *ClusterInstallationReconciler) verifyMigration(ci *mattermostv1alpha1.ClusterInstallation, mm *mmv1beta.Mattermost, logger logr.Logger) (bool, string, error) {
+	for _, deployment := range migratedDeployments(mm) {
+		failure, err := r.verifyMigratedConfig(ci, deployment.name)
+		if err != nil {
+			return false, "", errors.Wrapf(err, "failed to verify the configuration of Deployment %s", deployment.name)
+		}
+		if failure != "" {
+			return false, failure, nil
+		}
+	}
+
+	if r.MigrationVerificationTimeout <= 0 {
+		return true, "", nil
+	}
+
+	pending := []string{}
+	for _, conditionType := range []string{mmv1beta.DatabaseReachableCondition, mmv1beta.FileStoreReachableCondition} {
+		condition := meta.FindStatusCondition(mm.Status.Conditions, conditionType)
+		switch {
+		case condition == nil || condition.Status == v1.ConditionUnknown:
+			pending = append(pending, conditionType)
+		case condition.Status == v1.ConditionFalse:
+			return false, fmt.Sprintf("the migrated Mattermost reports %s False: %s", conditionType, condition.Message), nil
+		}
+	}
+	if len(pending) == 0 {
+		return true, "", nil
+	}
+
+	since := mm.CreationTimestamp.Time
+	if ready := meta.FindStatusCondition(mm.Status.Conditions, mmv1beta.ReadyCondition); ready != nil && ready.Status == v1.ConditionTrue {
+		since = ready.LastTransitionTime.Time
+	}
+	if time.Since(since) > r.MigrationVerificationTimeout {
+		return false, fmt.Sprintf("the migrated Mattermost did not report %s within %s", strings.Join(pending, " and "), r.MigrationVerificationTimeout), nil
+	}
+	logger.Info("Waiting for the application health checks of the migrated Mattermost", "conditions", pending)
+
+	return false, "", nil
+}
+
+// verifyMigratedConfig compares the verified environment variables of the
+// migrated deployment with the ones of the last Replica Set of the
+// ClusterInstallation deployment. It returns the differences, empty if the
+// Replica Sets were already cleaned up.
+func (r *ClusterInstallationReconciler) verifyMigratedConfig(ci *mattermostv1alpha1.ClusterInstallation, name string) (string, error) {
+	replicaSets := appsv1.ReplicaSetList{}
+	err := r.List(context.TODO(), &replicaSets,
+		client.InNamespace(ci.Namespace),
+		client.MatchingLabels(ci.ClusterInstallationLabels(name)),
+	)
+	if err != nil {
+		return "", errors.Wrap(err, "failed to list Replica Sets")
+	}
+	replicaSet := latestReplicaSet(replicaSets.Items)
+	if replicaSet == nil {
+		return "", nil
+	}
+
+	var deployment appsv1.Deployment
+	err = r.Get(context.TODO(), types.NamespacedName{Name: name, Namespace: ci.Namespace}, &deployment)
+	if err != nil {
+		return "", errors.Wrap(err, "failed to get migrated deployment")
+	}
+
+	previous, err := r.resolveEnv(ci.Namespace, mattermostContainerEnv(replicaSet.Spec.Template.Spec))
+	if err != nil {
+		return "", err
+	}
+	migrated, err := r.resolveEnv(ci.Namespace, mattermostContainerEnv(deployment.Spec.Template.Spec))
+	if err != nil {
+		return "", err
+	}
+
+	differences := []string{}
+	for _, envVar := range verifiedEnvVars {
+		value, ok := previous[envVar]
+		if !ok {
+			continue
+		}
+		if migratedValue, ok := migrated[envVar]; !ok || migratedValue != value {
+			differences = append(differences, envVar)
+		}
+	}
+	if len(differences) > 0 {
+		return fmt.Sprintf("the migrated deployment %s is configured differently: %s", name, strings.Join(differences, ", ")), nil
+	}
+
+	return "", nil
+}
+
+// resolveEnv returns the values of the environment variables, reading the
+// referenced Secrets and ConfigMaps and expanding the references to the
+// previous variables like Kubernetes. The variables set from the pod fields
+// or resources are left out.
+func (r *ClusterInstallationReconciler) resolveEnv(namespace string, env []corev1.EnvVar) (map[string]string, error) {
+	values := map[string]string{}
+	for _, envVar := range env {
+		if envVar.ValueFrom == nil {
+			values[envVar.Name] = envVarReference.ReplaceAllStringFunc(envVar.Value, func(reference string) string {
+				if value, ok := values[reference[2:len(reference)-1]]; ok {
+					return value
+				}
+				return reference
+			})
+			continue
+		}
+
+		switch {
+		case envVar.ValueFrom.SecretKeyRef != nil:
+			ref := envVar.ValueFrom.SecretKeyRef
+			var secret corev1.Secret
+			err := r.Get(context.TODO(), types.NamespacedName{Name: ref.Name, Namespace: namespace}, &secret)
+			if k8sErrors.IsNotFound(err) && ref.Optional != nil && *ref.Optional {
+				continue
+			}
+			if err != nil {
+				return nil, errors.Wrapf(err, "failed to get Secret %s of %s", ref.Name, envVar.Name)
+			}
+			if value, ok := secret.Data[ref.Key]; ok {
+				values[envVar.Name] = string(value)
+			}
+		case envVar.ValueFrom.ConfigMapKeyRef != nil:
+			ref := envVar.ValueFrom.ConfigMapKeyRef
+			var configMap corev1.ConfigMap
+			err := r.Get(context.TODO(), types.NamespacedName{Name: ref.Name, Namespace: namespace}, &configMap)
+			if k8sErrors.IsNotFound(err) && ref.Optional != nil && *ref.Optional {
+				continue
+			}
+			if err != nil {
+				return nil, errors.Wrapf(err, "failed to get ConfigMap %s of %s", ref.Name, envVar.Name)
+			}
+			if value, ok := configMap.Data[ref.Key]; ok {
+				values[envVar.Name] = value
+			}
+		}
+	}
+
+	return values, nil
+}
+
+// rollbackMigration reverts the migration of the ClusterInstallation: the
+// Mattermost and the migrated deployments are deleted and the migration is
+// disabled, so that the ClusterInstallation recreates its deployments which
+// adopt their Replica Sets left from before the migration.
+func (r *ClusterInstallationReconciler) rollbackMigration(ci *mattermostv1alpha1.ClusterInstallation, mm *mmv1beta.Mattermost, logger logr.Logger) error {
+	backgroundPropagation := v1.DeletePropagationBackground
+	err := r.Delete(context.TODO(), mm, client.PropagationPolicy(backgroundPropagation))
+	if err != nil && !k8sErrors.IsNotFound(err) {
+		return errors.Wrap(err, "failed to delete Mattermost")
+	}
+
+	for _, deployment := range migratedDeployments(mm) {
+		logger.Info("Deleting migrated deployment", "deployment", deployment.name)
+		migrated := &appsv1.Deployment{
+			ObjectMeta: v1.ObjectMeta{Name: deployment.name, Namespace: ci.Namespace},
+		}
+		err = r.Delete(context.TODO(), migrated, client.PropagationPolicy(backgroundPropagation))
+		if err != nil && !k8sErrors.IsNotFound(err) {
+			return errors.Wrapf(err, "failed to delete migrated deployment %s", deployment.name)
+		}
+	}
+
+	ci.Spec.Migrate = false
+	err = r.Update(context.TODO(), ci)
+	if err != nil {
+		return errors.Wrap(err, "failed to disable the migration of ClusterInstallation")
+	}
+
+	return nil
+}
+
+// mattermostContainerEnv returns the environment of the Mattermost container
+// of the pod.
+func mattermostContainerEnv(pod corev1.PodSpec) []corev1.EnvVar {
+	for _, container := range pod.Containers {
+		if container.Name == mattermostv1alpha1.MattermostAppContainerName {
+			return container.Env
+		}
+	}
+	if len(pod.Containers) > 0 {
+		return pod.Containers[0].Env
+	}
+
+	return nil
+}
+
+// latestReplicaSet returns the Replica Set of the last revision of the
+// deployment, nil if there are none.
+func latestReplicaSet(replicaSets []appsv1.ReplicaSet) *appsv1.ReplicaSet {
+	if len(replicaSets) == 0 {
+		return nil
+	}
+	sort.SliceStable(replicaSets, func(i, j int) bool {
+		return replicaSetRevision(replicaSets[i]) > replicaSetRevision(replicaSets[j])
+	})
+
+	return &replicaSets[0]
+}
+
+func replicaSetRevision(replicaSet appsv1.ReplicaSet) int64 {
+	revision, err := strconv.ParseInt(replicaSet.Annotations["deployment.kubernetes.io/revision"], 10, 64)
+	if err != nil {
+		return 0
+	}
+
+	return revision
+}
@@ -0,0 +1,219 @@
+package ecrcredentials
+
+import (
+	"context"
+	"os"
+	"time"
+
+	"github.com/go-logr/logr"
+	mmv1beta "github.com/mattermost/mattermost-operator/apis/mattermost/v1beta1"
+	"github.com/mattermost/mattermost-operator/pkg/logging"
+	mattermostApp "github.com/mattermost/mattermost-operator/pkg/mattermost"
+	"github.com/mattermost/mattermost-operator/pkg/namespaceselector"
+	"github.com/mattermost/mattermost-operator/pkg/registry"
+	"github.com/mattermost/mattermost-operator/pkg/sharding"
+	"github.com/mattermost/mattermost-operator/pkg/utils"
+	"github.com/pkg/errors"
+	corev1 "k8s.io/api/core/v1"
+	k8sErrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/builder"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+)
+
+// expiryMargin is how long before the authorization token expires the image
+// pull Secret is refreshed at the latest.
+const expiryMargin = 30 * time.Minute
+
+// ECRAuthorizer requests ECR authorization tokens.
+type ECRAuthorizer interface {
+	ECRAuthorization(ctx context.Context, region string, credentials registry.AWSCredentials) (*registry.ECRAuthorization, error)
+}
+
+// ECRCredentialsReconciler refreshes the ECR image pull Secrets of the
+// Mattermosts with ECR credentials enabled.
+type ECRCredentialsReconciler struct {
+	client.Client
+	Log    logr.Logger
+	Scheme *runtime.Scheme
+	ECR    ECRAuthorizer
+	// RefreshInterval is the interval the authorization tokens are refreshed
+	// at. ECR authorization tokens are valid for 12 hours.
+	RefreshInterval time.Duration
+	// DefaultCredentials are the AWS credentials of the Mattermosts not
+	// setting their own.
+	DefaultCredentials registry.AWSCredentials
+	// Shard is the shard of the Mattermosts refreshed by this replica of the
+	// operator, all of them by default.
+	Shard sharding.Shard
+	// Namespaces selects the namespaces of the Mattermosts refreshed by the
+	// operator, all of them if nil.
+	Namespaces *namespaceselector.Selector
+}
+
+func NewECRCredentialsReconciler(mgr ctrl.Manager, refreshInterval time.Duration) *ECRCredentialsReconciler {
+	return &ECRCredentialsReconciler{
+		Client:          mgr.GetClient(),
+		Log:             ctrl.Log.WithName("controllers").WithName("ECRCredentials"),
+		Scheme:          mgr.GetScheme(),
+		ECR:             registry.NewClient(),
+		RefreshInterval: refreshInterval,
+		DefaultCredentials: registry.AWSCredentials{
+			AccessKeyID:     os.Getenv("AWS_ACCESS_KEY_ID"),
+			SecretAccessKey: os.Getenv("AWS_SECRET_ACCESS_KEY"),
+			SessionToken:    os.Getenv("AWS_SESSION_TOKEN"),
+		},
+	}
+}
+
+func (r *ECRCredentialsReconciler) SetupWithManager(mgr ctrl.Manager) error {
+	blder := ctrl.NewControllerManagedBy(mgr).
+		Named("ecrcredentials").
+		For(&mmv1beta.Mattermost{}, builder.WithPredicates(utils.SpecChangedPredicate(), r.Shard.Predicate(), r.Namespaces.Predicate())).
+		Owns(&corev1.Secret{}, builder.WithPredicates(utils.OwnedResourcePredicate(mmv1beta.ClusterResourceLabel)))
+	return r.Namespaces.Watch(blder, &mmv1beta.MattermostList{}).Complete(r)
+}
+
+// Reconcile refreshes the ECR image pull Secret of the Mattermost once the
+// refresh interval elapsed, and deletes it if ECR credentials are disabled.
+func (r *ECRCredentialsReconciler) Reconcile(ctx context.Context, request ctrl.Request) (ctrl.Result, error) {
+	reqLogger := r.Log.WithValues("installation", request.Name, "namespace", request.Namespace)
+
+	mattermost := &mmv1beta.Mattermost{}
+	err := r.Client.Get(ctx, request.NamespacedName, mattermost)
+	if err != nil && k8sErrors.IsNotFound(err) {
+		// Request object not found, could have been deleted after reconcile
+		// request. Owned objects are automatically garbage collected.
+		return reconcile.Result{}, nil
+	} else if err != nil {
+		return reconcile.Result{}, err
+	}
+	// The events of the image pull Secrets are not filtered by shard.
+	if !r.Shard.Owns(mattermost) {
+		return reconcile.Result{}, nil
+	}
+	if selected, err := r.Namespaces.Selects(ctx, mattermost.Namespace); err != nil || !selected {
+		return reconcile.Result{}, err
+	}
+	reqLogger = logging.ForObject(reqLogger, mattermost)
+
+	current := &corev1.Secret{}
+	err = r.Client.Get(ctx, types.NamespacedName{Name: mattermost.ECRPullSecretName(), Namespace: mattermost.Namespace}, current)
+	if err != nil && !k8sErrors.IsNotFound(err) {
+		return reconcile.Result{}, errors.Wrap(err, "failed to get ECR image pull secret")
+	}
+	exists := err == nil
+
+	if !mattermost.ECRCredentialsEnabled() {
+		if !exists || !metav1.IsControlledBy(current, mattermost) {
+			return reconcile.Result{}, nil
+		}
+		reqLogger.Info("Deleting ECR image pull secret", "name", current.Name)
+		err = r.Client.Delete(ctx, current)
+		if err != nil && !k8sErrors.IsNotFound(err) {
+			return reconcile.Result{}, errors.Wrap(err, "failed to delete ECR image pull secret")
+		}
+		return reconcile.Result{}, nil
+	}
+
+	now := time.Now()
+	if exists {
+		if next := r.nextRefresh(current); next.After(now) {
+			return reconcile.Result{RequeueAfter: next.Sub(now)}, nil
+		}
+	}
+
+	region, err := ecrRegion(mattermost)
+	if err != nil {
+		return reconcile.Result{}, err
+	}
+	credentials, err := r.awsCredentials(ctx, mattermost)
+	if err != nil {
+		return reconcile.Result{}, err
+	}
+
+	authorization, err := r.ECR.ECRAuthorization(ctx, region, credentials)
+	if err != nil {
+		return reconcile.Result{}, errors.Wrap(err, "failed to get ECR authorization token")
+	}
+	dockerConfig, err := authorization.DockerConfigJSON()
+	if err != nil {
+		return reconcile.Result{}, errors.Wrap(err, "failed to generate ECR Docker config")
+	}
+
+	desired := mattermostApp.GenerateECRPullSecretV1Beta(mattermost, dockerConfig, now, authorization.ExpiresAt)
+	if exists {
+		desired.ResourceVersion = current.ResourceVersion
+		err = r.Client.Update(ctx, desired)
+	} else {
+		err = r.Client.Create(ctx, desired)
+	}
+	if err != nil {
+		return reconcile.Result{}, errors.Wrap(err, "failed to save ECR image pull secret")
+	}
+	reqLogger.Info("Refreshed ECR image pull secret", "name", desired.Name, "registry", authorization.Registry, "expiresAt", authorization.ExpiresAt)
+
+	return reconcile.Result{RequeueAfter: r.nextRefresh(desired).Sub(now)}, nil
+}
+
+// nextRefresh returns when the image pull Secret should be refreshed, once
+// the refresh interval elapsed or shortly before the token expires.
+func (r *ECRCredentialsReconciler) nextRefresh(secret *corev1.Secret) time.Time {
+	refreshedAt, err := time.Parse(time.RFC3339, secret.Annotations[mattermostApp.ECRCredentialsRefreshedAtAnnotation])
+	if err != nil {
+		return time.Time{}
+	}
+	expiresAt, err := time.Parse(time.RFC3339, secret.Annotations[mattermostApp.ECRCredentialsExpiresAtAnnotation])
+	if err != nil {
+		return time.Time{}
+	}
+
+	next := refreshedAt.Add(r.RefreshInterval)
+	if latest := expiresAt.Add(-expiryMargin); latest.Before(next) {
+		return latest
+	}
+	return next
+}
+
+// ecrRegion returns the region of the ECR registry, defaulting to the region
+// of the registry of the Mattermost image.
+func ecrRegion(mattermost *mmv1beta.Mattermost) (string, error) {
+	if mattermost.Spec.ECRCredentials.Region != "" {
+		return mattermost.Spec.ECRCredentials.Region, nil
+	}
+
+	ref, err := registry.ParseReference(mattermost.GetImage())
+	if err != nil {
+		return "", errors.Wrap(err, "failed to parse Mattermost image")
+	}
+	region, ok := registry.ECRRegion(ref.Registry)
+	if !ok {
+		return "", errors.Errorf("ecrCredentials.region required, Mattermost image registry %s is not an ECR registry", ref.Registry)
+	}
+	return region, nil
+}
+
+// awsCredentials returns the AWS credentials of the credentials Secret of the
+// Mattermost, or the default credentials.
+func (r *ECRCredentialsReconciler) awsCredentials(ctx context.Context, mattermost *mmv1beta.Mattermost) (registry.AWSCredentials, error) {
+	secretName := mattermost.Spec.ECRCredentials.CredentialsSecret
+	if secretName == "" {
+		return r.DefaultCredentials, nil
+	}
+
+	secret := &corev1.Secret{}
+	err := r.Client.Get(ctx, types.NamespacedName{Name: secretName, Namespace: mattermost.Namespace}, secret)
+	if err != nil {
+		return registry.AWSCredentials{}, errors.Wrap(err, "failed to get ECR credentials secret")
+	}
+
+	return registry.AWSCredentials{
+		AccessKeyID:     string(secret.Data["AWS_ACCESS_KEY_ID"]),
+		SecretAccessKey: string(secret.Data["AWS_SECRET_ACCESS_KEY"]),
+		SessionToken:    string(secret.Data["AWS_SESSION_TOKEN"]),
+	}, nil
+}
@@ -0,0 +1,121 @@
+package ecrcredentials
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	blubr "github.com/mattermost/blubr"
+	mmv1beta "github.com/mattermost/mattermost-operator/apis/mattermost/v1beta1"
+	mattermostApp "github.com/mattermost/mattermost-operator/pkg/mattermost"
+	"github.com/mattermost/mattermost-operator/pkg/registry"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	corev1 "k8s.io/api/core/v1"
+	k8sErrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/kubernetes/scheme"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+)
+
+type fakeECR struct {
+	regions     []string
+	credentials []registry.AWSCredentials
+}
+
+func (f *fakeECR) ECRAuthorization(_ context.Context, region string, credentials registry.AWSCredentials) (*registry.ECRAuthorization, error) {
+	f.regions = append(f.regions, region)
+	f.credentials = append(f.credentials, credentials)
+	return &registry.ECRAuthorization{
+		Registry:  "123456789012.dkr.ecr." + region + ".amazonaws.com",
+		Username:  "AWS",
+		Password:  "token",
+		ExpiresAt: time.Now().Add(12 * time.Hour),
+	}, nil
+}
+
+func TestReconcile(t *testing.T) {
+	s := scheme.Scheme
+	s.AddKnownTypes(mmv1beta.GroupVersion, &mmv1beta.Mattermost{})
+
+	mattermost := &mmv1beta.Mattermost{
+		ObjectMeta: metav1.ObjectMeta{Name: "mm", Namespace: "mm-namespace", UID: "mm-uid"},
+		Spec: mmv1beta.MattermostSpec{
+			Image:          "123456789012.dkr.ecr.eu-west-1.amazonaws.com/mattermost",
+			Version:        "5.37.1",
+			ECRCredentials: &mmv1beta.ECRCredentials{Enabled: true},
+		},
+	}
+	c := fake.NewFakeClientWithScheme(s, mattermost)
+	ecr := &fakeECR{}
+	r := &ECRCredentialsReconciler{
+		Client:             c,
+		Log:                blubr.InitLogger(),
+		Scheme:             s,
+		ECR:                ecr,
+		RefreshInterval:    6 * time.Hour,
+		DefaultCredentials: registry.AWSCredentials{AccessKeyID: "AKID", SecretAccessKey: "secret"},
+	}
+	request := ctrl.Request{NamespacedName: types.NamespacedName{Name: "mm", Namespace: "mm-namespace"}}
+	secretKey := types.NamespacedName{Name: "mm-ecr-credentials", Namespace: "mm-namespace"}
+
+	t.Run("creates the image pull secret", func(t *testing.T) {
+		result, err := r.Reconcile(context.TODO(), request)
+		require.NoError(t, err)
+		assert.InDelta(t, float64(6*time.Hour), float64(result.RequeueAfter), float64(time.Minute))
+		assert.Equal(t, []string{"eu-west-1"}, ecr.regions)
+		assert.Equal(t, r.DefaultCredentials, ecr.credentials[0])
+
+		secret := &corev1.Secret{}
+		require.NoError(t, c.Get(context.TODO(), secretKey, secret))
+		assert.Equal(t, corev1.SecretTypeDockerConfigJson, secret.Type)
+		assert.True(t, metav1.IsControlledBy(secret, mattermost))
+		assert.NotEmpty(t, secret.Annotations[mattermostApp.ECRCredentialsExpiresAtAnnotation])
+
+		keychain, err := registry.NewDockerConfigKeychain(secret.Data[corev1.DockerConfigJsonKey])
+		require.NoError(t, err)
+		assert.Equal(t, &registry.Credentials{Username: "AWS", Password: "token"}, keychain.Resolve("123456789012.dkr.ecr.eu-west-1.amazonaws.com"))
+	})
+
+	t.Run("waits for the refresh interval", func(t *testing.T) {
+		result, err := r.Reconcile(context.TODO(), request)
+		require.NoError(t, err)
+		assert.True(t, result.RequeueAfter > 5*time.Hour)
+		assert.Len(t, ecr.regions, 1)
+	})
+
+	t.Run("refreshes with the credentials secret", func(t *testing.T) {
+		require.NoError(t, c.Create(context.TODO(), &corev1.Secret{
+			ObjectMeta: metav1.ObjectMeta{Name: "aws", Namespace: "mm-namespace"},
+			Data: map[string][]byte{
+				"AWS_ACCESS_KEY_ID":     []byte("other"),
+				"AWS_SECRET_ACCESS_KEY": []byte("other-secret"),
+			},
+		}))
+		stored := &mmv1beta.Mattermost{}
+		require.NoError(t, c.Get(context.TODO(), request.NamespacedName, stored))
+		stored.Spec.ECRCredentials.CredentialsSecret = "aws"
+		stored.Spec.ECRCredentials.Region = "us-east-1"
+		require.NoError(t, c.Update(context.TODO(), stored))
+
+		r.RefreshInterval = 0
+		_, err := r.Reconcile(context.TODO(), request)
+		require.NoError(t, err)
+		assert.Equal(t, []string{"eu-west-1", "us-east-1"}, ecr.regions)
+		assert.Equal(t, registry.AWSCredentials{AccessKeyID: "other", SecretAccessKey: "other-secret"}, ecr.credentials[1])
+	})
+
+	t.Run("deletes the image pull secret when disabled", func(t *testing.T) {
+		stored := &mmv1beta.Mattermost{}
+		require.NoError(t, c.Get(context.TODO(), request.NamespacedName, stored))
+		stored.Spec.ECRCredentials.Enabled = false
+		require.NoError(t, c.Update(context.TODO(), stored))
+
+		_, err := r.Reconcile(context.TODO(), request)
+		require.NoError(t, err)
+		err = c.Get(context.TODO(), secretKey, &corev1.Secret{})
+		assert.True(t, k8sErrors.IsNotFound(err))
+	})
+}
@@ -0,0 +1,106 @@
+package mattermost
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/go-logr/logr"
+	mmv1beta "github.com/mattermost/mattermost-operator/apis/mattermost/v1beta1"
+	"github.com/pkg/errors"
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	k8sErrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/controller/controllerutil"
+)
+
+// adoptResource takes the ownership of an existing resource of the
+// Mattermost it does not control, if the Mattermost adopts resources. The
+// adopted resource is then updated to its desired state like the resources
+// created by the operator. Resources controlled by another object are not
+// adopted.
+func (r *MattermostReconciler) adoptResource(mattermost *mmv1beta.Mattermost, kind string, current client.Object, reqLogger logr.Logger) error {
+	if !mattermost.AdoptsResources() || metav1.IsControlledBy(current, mattermost) {
+		return nil
+	}
+	if owner := metav1.GetControllerOf(current); owner != nil {
+		message := fmt.Sprintf("%s %s is controlled by %s %s and cannot be adopted", kind, current.GetName(), owner.Kind, owner.Name)
+		r.recordEvent(mattermost, corev1.EventTypeWarning, "AdoptionFailed", message)
+		return errors.New(message)
+	}
+
+	reqLogger.Info("Adopting resource", "kind", kind, "name", current.GetName())
+	patch := client.MergeFrom(current.DeepCopyObject().(client.Object))
+	err := controllerutil.SetControllerReference(mattermost, current, r.Scheme)
+	if err != nil {
+		return errors.Wrapf(err, "failed to adopt %s %s", kind, current.GetName())
+	}
+	annotations := current.GetAnnotations()
+	if annotations == nil {
+		annotations = map[string]string{}
+	}
+	annotations[mmv1beta.AdoptedAnnotation] = "true"
+	current.SetAnnotations(annotations)
+	err = r.Client.Patch(context.TODO(), current, patch)
+	if err != nil {
+		return errors.Wrapf(err, "failed to adopt %s %s", kind, current.GetName())
+	}
+
+	r.recordEvent(mattermost, corev1.EventTypeNormal, "Adopted", fmt.Sprintf("Adopted %s %s", kind, current.GetName()))
+	return nil
+}
+
+// keepAdoptedSelector keeps the selector of an adopted deployment, which
+// cannot be changed, and sets its labels on the pods of the desired
+// deployment, over the conflicting labels of the operator. The adopted pods
+// are then replaced by a rolling update.
+func keepAdoptedSelector(current, desired *appsv1.Deployment) {
+	if current.Annotations[mmv1beta.AdoptedAnnotation] != "true" || current.Spec.Selector == nil {
+		return
+	}
+	desired.Spec.Selector = current.Spec.Selector.DeepCopy()
+	if desired.Spec.Template.Labels == nil {
+		desired.Spec.Template.Labels = map[string]string{}
+	}
+	for key, value := range current.Spec.Selector.MatchLabels {
+		desired.Spec.Template.Labels[key] = value
+	}
+}
+
+// keepAdoptedServiceSelector keeps the selector of an adopted Service until
+// the pods of the deployment are all replaced by pods carrying the labels
+// the Service selects, so that the adopted pods keep serving meanwhile.
+func (r *MattermostReconciler) keepAdoptedServiceSelector(current, desired *corev1.Service, deploymentName string) error {
+	if current.Annotations[mmv1beta.AdoptedAnnotation] != "true" || labels.Equals(current.Spec.Selector, desired.Spec.Selector) {
+		return nil
+	}
+
+	deployment := &appsv1.Deployment{}
+	err := r.Client.Get(context.TODO(), types.NamespacedName{Namespace: desired.Namespace, Name: deploymentName}, deployment)
+	if err != nil && !k8sErrors.IsNotFound(err) {
+		return errors.Wrap(err, "failed to get mattermost deployment")
+	}
+	if err == nil && deploymentRolledOut(deployment) &&
+		labels.SelectorFromSet(desired.Spec.Selector).Matches(labels.Set(deployment.Spec.Template.Labels)) {
+		return nil
+	}
+
+	desired.Spec.Selector = current.Spec.Selector
+	return nil
+}
+
+// deploymentRolledOut returns true if all the pods of the deployment run
+// its current template.
+func deploymentRolledOut(deployment *appsv1.Deployment) bool {
+	replicas := int32(1)
+	if deployment.Spec.Replicas != nil {
+		replicas = *deployment.Spec.Replicas
+	}
+	return deployment.Status.ObservedGeneration >= deployment.Generation &&
+		deployment.Status.UpdatedReplicas == replicas &&
+		deployment.Status.Replicas == replicas &&
+		deployment.Status.AvailableReplicas == replicas
+}
@@ -0,0 +1,135 @@
+package mattermost
+
+import (
+	"context"
+	"testing"
+
+	blubr "github.com/mattermost/blubr"
+	mmv1beta "github.com/mattermost/mattermost-operator/apis/mattermost/v1beta1"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/kubernetes/scheme"
+	"k8s.io/client-go/tools/record"
+	"k8s.io/utils/pointer"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+)
+
+func TestAdoptResource(t *testing.T) {
+	s := prepareSchema(t, scheme.Scheme)
+	s.AddKnownTypes(mmv1beta.GroupVersion, &mmv1beta.Mattermost{})
+	mattermost := &mmv1beta.Mattermost{ObjectMeta: metav1.ObjectMeta{
+		Name:        "mm",
+		Namespace:   "mm-namespace",
+		UID:         "mm-uid",
+		Annotations: map[string]string{mmv1beta.AdoptResourcesAnnotation: "true"},
+	}}
+	key := types.NamespacedName{Namespace: "mm-namespace", Name: "mm"}
+
+	newReconciler := func(service *corev1.Service) (*MattermostReconciler, *record.FakeRecorder) {
+		recorder := record.NewFakeRecorder(10)
+		c := fake.NewFakeClientWithScheme(s, service)
+		return &MattermostReconciler{Client: c, Scheme: s, Recorder: recorder}, recorder
+	}
+
+	t.Run("adopted", func(t *testing.T) {
+		service := &corev1.Service{ObjectMeta: metav1.ObjectMeta{Name: key.Name, Namespace: key.Namespace}}
+		r, recorder := newReconciler(service)
+
+		require.NoError(t, r.adoptResource(mattermost, "Service", service, blubr.InitLogger()))
+		current := &corev1.Service{}
+		require.NoError(t, r.Client.Get(context.TODO(), key, current))
+		assert.True(t, metav1.IsControlledBy(current, mattermost))
+		assert.Equal(t, "true", current.Annotations[mmv1beta.AdoptedAnnotation])
+		assert.Len(t, recorder.Events, 1)
+	})
+
+	t.Run("not adopting", func(t *testing.T) {
+		service := &corev1.Service{ObjectMeta: metav1.ObjectMeta{Name: key.Name, Namespace: key.Namespace}}
+		r, _ := newReconciler(service)
+		notAdopting := mattermost.DeepCopy()
+		notAdopting.Annotations = nil
+
+		require.NoError(t, r.adoptResource(notAdopting, "Service", service, blubr.InitLogger()))
+		current := &corev1.Service{}
+		require.NoError(t, r.Client.Get(context.TODO(), key, current))
+		assert.Empty(t, current.OwnerReferences)
+	})
+
+	t.Run("controlled by another object", func(t *testing.T) {
+		service := &corev1.Service{ObjectMeta: metav1.ObjectMeta{
+			Name:            key.Name,
+			Namespace:       key.Namespace,
+			OwnerReferences: []metav1.OwnerReference{{Kind: "ClusterInstallation", Name: "ci", UID: "ci-uid", Controller: pointer.BoolPtr(true)}},
+		}}
+		r, recorder := newReconciler(service)
+
+		err := r.adoptResource(mattermost, "Service", service, blubr.InitLogger())
+		assert.EqualError(t, err, "Service mm is controlled by ClusterInstallation ci and cannot be adopted")
+		assert.Len(t, recorder.Events, 1)
+	})
+}
+
+func TestKeepAdoptedSelector(t *testing.T) {
+	adopted := map[string]string{mmv1beta.AdoptedAnnotation: "true"}
+	current := &appsv1.Deployment{
+		ObjectMeta: metav1.ObjectMeta{Annotations: adopted},
+		Spec: appsv1.DeploymentSpec{
+			Selector: &metav1.LabelSelector{MatchLabels: map[string]string{"app": "chat"}},
+		},
+	}
+	desired := &appsv1.Deployment{
+		Spec: appsv1.DeploymentSpec{
+			Selector: &metav1.LabelSelector{MatchLabels: mmv1beta.MattermostSelectorLabels("mm")},
+		},
+	}
+	desired.Spec.Template.Labels = mmv1beta.MattermostSelectorLabels("mm")
+
+	keepAdoptedSelector(current, desired)
+	assert.Equal(t, map[string]string{"app": "chat"}, desired.Spec.Selector.MatchLabels)
+	// The pods match the adopted selector, the conflicting labels of the
+	// operator are not set.
+	assert.Equal(t, "chat", desired.Spec.Template.Labels["app"])
+	assert.Equal(t, "mm", desired.Spec.Template.Labels[mmv1beta.ClusterLabel])
+}
+
+func TestKeepAdoptedServiceSelector(t *testing.T) {
+	s := prepareSchema(t, scheme.Scheme)
+	adopted := map[string]string{mmv1beta.AdoptedAnnotation: "true"}
+	current := &corev1.Service{
+		ObjectMeta: metav1.ObjectMeta{Namespace: "mm-namespace", Name: "mm", Annotations: adopted},
+		Spec:       corev1.ServiceSpec{Selector: map[string]string{"app": "chat"}},
+	}
+	newDesired := func() *corev1.Service {
+		return &corev1.Service{
+			ObjectMeta: metav1.ObjectMeta{Namespace: "mm-namespace", Name: "mm"},
+			Spec:       corev1.ServiceSpec{Selector: mmv1beta.MattermostSelectorLabels("mm")},
+		}
+	}
+	newDeployment := func(updatedReplicas int32) *appsv1.Deployment {
+		deployment := &appsv1.Deployment{
+			ObjectMeta: metav1.ObjectMeta{Namespace: "mm-namespace", Name: "mm"},
+			Spec:       appsv1.DeploymentSpec{Replicas: pointer.Int32Ptr(2)},
+			Status:     appsv1.DeploymentStatus{Replicas: 2, UpdatedReplicas: updatedReplicas, AvailableReplicas: 2},
+		}
+		deployment.Spec.Template.Labels = mmv1beta.MattermostSelectorLabels("mm")
+		return deployment
+	}
+
+	t.Run("rolling out", func(t *testing.T) {
+		r := &MattermostReconciler{Client: fake.NewFakeClientWithScheme(s, newDeployment(1))}
+		desired := newDesired()
+		require.NoError(t, r.keepAdoptedServiceSelector(current, desired, "mm"))
+		assert.Equal(t, current.Spec.Selector, desired.Spec.Selector)
+	})
+
+	t.Run("rolled out", func(t *testing.T) {
+		r := &MattermostReconciler{Client: fake.NewFakeClientWithScheme(s, newDeployment(2))}
+		desired := newDesired()
+		require.NoError(t, r.keepAdoptedServiceSelector(current, desired, "mm"))
+		assert.Equal(t, mmv1beta.MattermostSelectorLabels("mm"), desired.Spec.Selector)
+	})
+}
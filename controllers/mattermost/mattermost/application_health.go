@@ -0,0 +1,150 @@
+package mattermost
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/go-logr/logr"
+	mmv1beta "github.com/mattermost/mattermost-operator/apis/mattermost/v1beta1"
+	mattermostApp "github.com/mattermost/mattermost-operator/pkg/mattermost"
+	"github.com/mattermost/mattermost-operator/pkg/mattermost/healthcheck"
+	"github.com/prometheus/client_golang/prometheus"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"sigs.k8s.io/controller-runtime/pkg/metrics"
+)
+
+var (
+	healthCheckDurationHistogram = prometheus.NewHistogramVec(
+		prometheus.HistogramOpts{
+			Name: "mattermost_operator_health_check_duration_seconds",
+			Help: "Time Mattermost took to respond to the application health checks of the installation.",
+		},
+		[]string{"namespace", "name"},
+	)
+	healthCheckFailuresCounter = prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Name: "mattermost_operator_health_check_failures_total",
+			Help: "Number of failed application health checks of the installation.",
+		},
+		[]string{"namespace", "name"},
+	)
+	healthDegradedGauge = prometheus.NewGaugeVec(
+		prometheus.GaugeOpts{
+			Name: "mattermost_operator_health_degraded",
+			Help: "Whether the application health checks of the installation failed repeatedly.",
+		},
+		[]string{"namespace", "name"},
+	)
+)
+
+func init() {
+	metrics.Registry.MustRegister(healthCheckDurationHistogram, healthCheckFailuresCounter, healthDegradedGauge)
+}
+
+// checkApplicationHealth checks that Mattermost reaches its database and
+// file store through the ping API of its service once the health check
+// interval elapsed. The results are reported as the DatabaseReachable and
+// FileStoreReachable conditions, failed pings with the Unknown status, and
+// the HealthDegraded condition once the failure threshold is reached.
+func (r *MattermostReconciler) checkApplicationHealth(ctx context.Context, mattermost *mmv1beta.Mattermost, status *mmv1beta.MattermostStatus, reqLogger logr.Logger) {
+	if r.ApplicationHealth == nil || r.HealthCheckInterval <= 0 {
+		return
+	}
+	previous := status.ApplicationHealth
+	if previous != nil && previous.LastCheckTime != nil && time.Since(previous.LastCheckTime.Time) < r.HealthCheckInterval {
+		return
+	}
+
+	checkTime := metav1.Now()
+	health := &mmv1beta.ApplicationHealthStatus{LastCheckTime: &checkTime}
+	if previous != nil {
+		health.ConsecutiveFailures = previous.ConsecutiveFailures
+		health.LastRestartTime = previous.LastRestartTime
+	}
+
+	start := time.Now()
+	serverStatus, err := r.ApplicationHealth.ServerStatus(ctx, mattermostApp.ServiceURL(mattermost))
+	if err != nil {
+		reqLogger.Error(err, "Unable to check the application health of Mattermost")
+		for _, conditionType := range []string{mmv1beta.DatabaseReachableCondition, mmv1beta.FileStoreReachableCondition} {
+			setStatusCondition(status, metav1.Condition{
+				Type:               conditionType,
+				Status:             metav1.ConditionUnknown,
+				ObservedGeneration: mattermost.Generation,
+				Reason:             "PingFailed",
+				Message:            err.Error(),
+			})
+		}
+	} else {
+		latency := time.Since(start)
+		health.Latency = &metav1.Duration{Duration: latency.Round(time.Millisecond)}
+		healthCheckDurationHistogram.WithLabelValues(mattermost.Namespace, mattermost.Name).Observe(latency.Seconds())
+
+		setStatusCondition(status, reachableCondition(mmv1beta.DatabaseReachableCondition, "database", serverStatus.DatabaseStatus, mattermost.Generation))
+		setStatusCondition(status, reachableCondition(mmv1beta.FileStoreReachableCondition, "file store", serverStatus.FileStoreStatus, mattermost.Generation))
+		if serverStatus.DatabaseStatus != healthcheck.StatusOK || serverStatus.FileStoreStatus != healthcheck.StatusOK {
+			err = fmt.Errorf("Mattermost reported the database status %s and the file store status %s", serverStatus.DatabaseStatus, serverStatus.FileStoreStatus)
+		}
+	}
+
+	if err != nil {
+		health.ConsecutiveFailures++
+		health.Error = err.Error()
+		healthCheckFailuresCounter.WithLabelValues(mattermost.Namespace, mattermost.Name).Inc()
+	} else {
+		health.ConsecutiveFailures = 0
+	}
+	status.ApplicationHealth = health
+
+	degraded := r.HealthFailureThreshold > 0 && health.ConsecutiveFailures >= r.HealthFailureThreshold
+	if degraded {
+		healthDegradedGauge.WithLabelValues(mattermost.Namespace, mattermost.Name).Set(1)
+		setStatusCondition(status, metav1.Condition{
+			Type:               mmv1beta.HealthDegradedCondition,
+			Status:             metav1.ConditionTrue,
+			ObservedGeneration: mattermost.Generation,
+			Reason:             "HealthChecksFailing",
+			Message:            fmt.Sprintf("%d application health checks failed in a row: %s", health.ConsecutiveFailures, health.Error),
+		})
+		return
+	}
+	healthDegradedGauge.WithLabelValues(mattermost.Namespace, mattermost.Name).Set(0)
+	setStatusCondition(status, metav1.Condition{
+		Type:               mmv1beta.HealthDegradedCondition,
+		Status:             metav1.ConditionFalse,
+		ObservedGeneration: mattermost.Generation,
+		Reason:             "HealthChecksPassing",
+		Message:            "The application health checks pass",
+	})
+}
+
+// reachableCondition returns the condition reporting the status of a
+// dependency of Mattermost.
+func reachableCondition(conditionType, dependency, dependencyStatus string, generation int64) metav1.Condition {
+	if dependencyStatus == healthcheck.StatusOK {
+		return metav1.Condition{
+			Type:               conditionType,
+			Status:             metav1.ConditionTrue,
+			ObservedGeneration: generation,
+			Reason:             "Reachable",
+			Message:            fmt.Sprintf("Mattermost reached the %s", dependency),
+		}
+	}
+
+	return metav1.Condition{
+		Type:               conditionType,
+		Status:             metav1.ConditionFalse,
+		ObservedGeneration: generation,
+		Reason:             "Unreachable",
+		Message:            fmt.Sprintf("Mattermost reported the %s status %s", dependency, dependencyStatus),
+	}
+}
+
+// deleteApplicationHealthMetrics removes the metrics of a deleted
+// Mattermost.
+func deleteApplicationHealthMetrics(namespace, name string) {
+	healthCheckDurationHistogram.DeleteLabelValues(namespace, name)
+	healthCheckFailuresCounter.DeleteLabelValues(namespace, name)
+	healthDegradedGauge.DeleteLabelValues(namespace, name)
+}
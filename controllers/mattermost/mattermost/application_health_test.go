@@ -0,0 +1,102 @@
+package mattermost
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	blubr "github.com/mattermost/blubr"
+	mmv1beta "github.com/mattermost/mattermost-operator/apis/mattermost/v1beta1"
+	"github.com/mattermost/mattermost-operator/pkg/mattermost/healthcheck"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+type fakeApplicationHealth struct {
+	status healthcheck.ServerStatus
+	err    error
+	checks int
+}
+
+func (f *fakeApplicationHealth) ServerStatus(_ context.Context, _ string) (healthcheck.ServerStatus, error) {
+	f.checks++
+	return f.status, f.err
+}
+
+func TestCheckApplicationHealth(t *testing.T) {
+	logger := blubr.InitLogger()
+	mattermost := &mmv1beta.Mattermost{
+		ObjectMeta: metav1.ObjectMeta{Name: "mm", Namespace: "mm-namespace", Generation: 2},
+	}
+	healthy := healthcheck.ServerStatus{DatabaseStatus: "OK", FileStoreStatus: "OK"}
+
+	assertCondition := func(t *testing.T, status mmv1beta.MattermostStatus, conditionType string, conditionStatus metav1.ConditionStatus, reason string) {
+		condition := meta.FindStatusCondition(status.Conditions, conditionType)
+		require.NotNil(t, condition, conditionType)
+		assert.Equal(t, conditionStatus, condition.Status, conditionType)
+		assert.Equal(t, reason, condition.Reason, conditionType)
+	}
+
+	t.Run("healthy", func(t *testing.T) {
+		client := &fakeApplicationHealth{status: healthy}
+		r := &MattermostReconciler{ApplicationHealth: client, HealthCheckInterval: time.Minute, HealthFailureThreshold: 3}
+
+		status := mmv1beta.MattermostStatus{}
+		r.checkApplicationHealth(context.TODO(), mattermost, &status, logger)
+
+		require.NotNil(t, status.ApplicationHealth)
+		require.NotNil(t, status.ApplicationHealth.LastCheckTime)
+		require.NotNil(t, status.ApplicationHealth.Latency)
+		assert.Equal(t, int32(0), status.ApplicationHealth.ConsecutiveFailures)
+		assertCondition(t, status, mmv1beta.DatabaseReachableCondition, metav1.ConditionTrue, "Reachable")
+		assertCondition(t, status, mmv1beta.FileStoreReachableCondition, metav1.ConditionTrue, "Reachable")
+		assertCondition(t, status, mmv1beta.HealthDegradedCondition, metav1.ConditionFalse, "HealthChecksPassing")
+
+		r.checkApplicationHealth(context.TODO(), mattermost, &status, logger)
+		assert.Equal(t, 1, client.checks, "checked again before the interval elapsed")
+	})
+
+	t.Run("degraded once the threshold is reached", func(t *testing.T) {
+		client := &fakeApplicationHealth{status: healthcheck.ServerStatus{DatabaseStatus: "OK", FileStoreStatus: "UNHEALTHY"}}
+		r := &MattermostReconciler{ApplicationHealth: client, HealthCheckInterval: time.Minute, HealthFailureThreshold: 2}
+
+		status := mmv1beta.MattermostStatus{}
+		r.checkApplicationHealth(context.TODO(), mattermost, &status, logger)
+		assertCondition(t, status, mmv1beta.DatabaseReachableCondition, metav1.ConditionTrue, "Reachable")
+		assertCondition(t, status, mmv1beta.FileStoreReachableCondition, metav1.ConditionFalse, "Unreachable")
+		assertCondition(t, status, mmv1beta.HealthDegradedCondition, metav1.ConditionFalse, "HealthChecksPassing")
+		assert.Equal(t, int32(1), status.ApplicationHealth.ConsecutiveFailures)
+
+		status.ApplicationHealth.LastCheckTime = &metav1.Time{Time: time.Now().Add(-time.Hour)}
+		client.status, client.err = healthcheck.ServerStatus{}, errors.New("connection refused")
+		r.checkApplicationHealth(context.TODO(), mattermost, &status, logger)
+		assertCondition(t, status, mmv1beta.DatabaseReachableCondition, metav1.ConditionUnknown, "PingFailed")
+		assertCondition(t, status, mmv1beta.FileStoreReachableCondition, metav1.ConditionUnknown, "PingFailed")
+		assertCondition(t, status, mmv1beta.HealthDegradedCondition, metav1.ConditionTrue, "HealthChecksFailing")
+		assert.Equal(t, int32(2), status.ApplicationHealth.ConsecutiveFailures)
+		assert.Equal(t, "connection refused", status.ApplicationHealth.Error)
+		assert.Nil(t, status.ApplicationHealth.Latency)
+
+		status.ApplicationHealth.LastCheckTime = &metav1.Time{Time: time.Now().Add(-time.Hour)}
+		client.status, client.err = healthy, nil
+		r.checkApplicationHealth(context.TODO(), mattermost, &status, logger)
+		assertCondition(t, status, mmv1beta.HealthDegradedCondition, metav1.ConditionFalse, "HealthChecksPassing")
+		assert.Equal(t, int32(0), status.ApplicationHealth.ConsecutiveFailures)
+		assert.Empty(t, status.ApplicationHealth.Error)
+	})
+
+	t.Run("disabled", func(t *testing.T) {
+		client := &fakeApplicationHealth{}
+		r := &MattermostReconciler{ApplicationHealth: client}
+
+		status := mmv1beta.MattermostStatus{}
+		r.checkApplicationHealth(context.TODO(), mattermost, &status, logger)
+
+		assert.Equal(t, 0, client.checks)
+		assert.Nil(t, status.ApplicationHealth)
+		assert.Empty(t, status.Conditions)
+	})
+}
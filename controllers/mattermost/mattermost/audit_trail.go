@@ -0,0 +1,115 @@
+package mattermost
+
+import (
+	"context"
+	"encoding/json"
+
+	"github.com/go-logr/logr"
+	mmv1beta "github.com/mattermost/mattermost-operator/apis/mattermost/v1beta1"
+	mattermostApp "github.com/mattermost/mattermost-operator/pkg/mattermost"
+	"github.com/mattermost/mattermost-operator/pkg/mattermost/audit"
+	"github.com/pkg/errors"
+	corev1 "k8s.io/api/core/v1"
+	k8sErrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+)
+
+// auditConfigMapSuffix is the suffix of the name of the ConfigMap holding
+// the audit trail of a Mattermost.
+const auditConfigMapSuffix = "-audit"
+
+// firstRevisionChange is the change of the first audited revision, whose
+// previous spec is not known.
+const firstRevisionChange = "first audited revision"
+
+// checkAuditTrail records the revision of the spec in the audit ConfigMap of
+// the Mattermost once its generation changed, keeping the last
+// AuditHistoryLimit revisions, with the changed fields and the field manager
+// which changed them.
+func (r *MattermostReconciler) checkAuditTrail(ctx context.Context, mattermost *mmv1beta.Mattermost, reqLogger logr.Logger) error {
+	if r.AuditHistoryLimit <= 0 {
+		return nil
+	}
+
+	configMap := &corev1.ConfigMap{}
+	err := r.NonCachedAPIReader.Get(ctx, types.NamespacedName{Name: mattermost.Name + auditConfigMapSuffix, Namespace: mattermost.Namespace}, configMap)
+	exists := err == nil
+	if err != nil && !k8sErrors.IsNotFound(err) {
+		return errors.Wrap(err, "failed to get audit ConfigMap")
+	}
+
+	var revisions []audit.Revision
+	var previousFields map[string]string
+	if exists {
+		if data, ok := configMap.Data[audit.RevisionsKey]; ok {
+			err = json.Unmarshal([]byte(data), &revisions)
+			if err != nil {
+				return errors.Wrap(err, "failed to unmarshal audited revisions")
+			}
+		}
+		if data, ok := configMap.Data[audit.FieldsKey]; ok {
+			err = json.Unmarshal([]byte(data), &previousFields)
+			if err != nil {
+				return errors.Wrap(err, "failed to unmarshal audited fields")
+			}
+		}
+	}
+	if len(revisions) > 0 && revisions[0].Generation == mattermost.Generation {
+		return nil
+	}
+
+	fields, err := audit.Fields(mattermost.Spec)
+	if err != nil {
+		return err
+	}
+
+	revision := audit.Revision{Generation: mattermost.Generation, Time: metav1.Now(), Changes: []string{firstRevisionChange}}
+	if previousFields != nil {
+		revision.Changes = audit.Changes(previousFields, fields)
+	}
+	author, changeTime := audit.SpecAuthor(mattermost.ManagedFields)
+	revision.Author = author
+	if changeTime != nil {
+		revision.Time = *changeTime
+	}
+
+	revisions = append([]audit.Revision{revision}, revisions...)
+	if len(revisions) > r.AuditHistoryLimit {
+		revisions = revisions[:r.AuditHistoryLimit]
+	}
+
+	revisionsData, err := json.MarshalIndent(revisions, "", "  ")
+	if err != nil {
+		return errors.Wrap(err, "failed to marshal audited revisions")
+	}
+	fieldsData, err := json.Marshal(fields)
+	if err != nil {
+		return errors.Wrap(err, "failed to marshal audited fields")
+	}
+	configMap.Data = map[string]string{
+		audit.RevisionsKey: string(revisionsData),
+		audit.FieldsKey:    string(fieldsData),
+	}
+
+	reqLogger.Info("Recording spec revision in the audit trail", "generation", mattermost.Generation, "author", author)
+	if exists {
+		err = r.Client.Update(ctx, configMap)
+		if err != nil {
+			return errors.Wrap(err, "failed to update audit ConfigMap")
+		}
+		return nil
+	}
+
+	configMap.ObjectMeta = metav1.ObjectMeta{
+		Name:            mattermost.Name + auditConfigMapSuffix,
+		Namespace:       mattermost.Namespace,
+		Labels:          mmv1beta.MattermostResourceLabels(mattermost.Name),
+		OwnerReferences: mattermostApp.MattermostOwnerReference(mattermost),
+	}
+	err = r.Client.Create(ctx, configMap)
+	if err != nil {
+		return errors.Wrap(err, "failed to create audit ConfigMap")
+	}
+	return nil
+}
@@ -0,0 +1,94 @@
+package mattermost
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+
+	blubr "github.com/mattermost/blubr"
+	mmv1beta "github.com/mattermost/mattermost-operator/apis/mattermost/v1beta1"
+	"github.com/mattermost/mattermost-operator/pkg/mattermost/audit"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/kubernetes/scheme"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+)
+
+func TestCheckAuditTrail(t *testing.T) {
+	logger := blubr.InitLogger()
+	s := prepareSchema(t, scheme.Scheme)
+	s.AddKnownTypes(mmv1beta.GroupVersion, &mmv1beta.Mattermost{})
+
+	client := fake.NewFakeClientWithScheme(s)
+	r := &MattermostReconciler{Client: client, NonCachedAPIReader: client, Scheme: s, AuditHistoryLimit: 2}
+
+	revisions := func(t *testing.T) []audit.Revision {
+		configMap := &corev1.ConfigMap{}
+		err := client.Get(context.TODO(), types.NamespacedName{Name: "mm-audit", Namespace: "mm-namespace"}, configMap)
+		require.NoError(t, err)
+		var revisions []audit.Revision
+		require.NoError(t, json.Unmarshal([]byte(configMap.Data[audit.RevisionsKey]), &revisions))
+		return revisions
+	}
+
+	mattermost := &mmv1beta.Mattermost{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:       "mm",
+			Namespace:  "mm-namespace",
+			UID:        "mm-uid",
+			Generation: 1,
+			ManagedFields: []metav1.ManagedFieldsEntry{
+				{Manager: "kubectl", FieldsV1: &metav1.FieldsV1{Raw: []byte(`{"f:spec":{"f:image":{}}}`)}},
+			},
+		},
+		Spec: mmv1beta.MattermostSpec{Image: "mattermost/mattermost-enterprise-edition", Version: "5.35.0"},
+	}
+
+	t.Run("first revision", func(t *testing.T) {
+		require.NoError(t, r.checkAuditTrail(context.TODO(), mattermost, logger))
+
+		recorded := revisions(t)
+		require.Len(t, recorded, 1)
+		assert.Equal(t, int64(1), recorded[0].Generation)
+		assert.Equal(t, "kubectl", recorded[0].Author)
+		assert.Equal(t, []string{firstRevisionChange}, recorded[0].Changes)
+	})
+
+	t.Run("same generation", func(t *testing.T) {
+		require.NoError(t, r.checkAuditTrail(context.TODO(), mattermost, logger))
+		assert.Len(t, revisions(t), 1)
+	})
+
+	t.Run("changed spec", func(t *testing.T) {
+		mattermost.Generation = 2
+		mattermost.Spec.Version = "5.36.0"
+		require.NoError(t, r.checkAuditTrail(context.TODO(), mattermost, logger))
+
+		recorded := revisions(t)
+		require.Len(t, recorded, 2)
+		assert.Equal(t, int64(2), recorded[0].Generation)
+		assert.Equal(t, []string{`spec.version: "5.35.0" -> "5.36.0"`}, recorded[0].Changes)
+	})
+
+	t.Run("history limit", func(t *testing.T) {
+		mattermost.Generation = 3
+		mattermost.Spec.Replicas = nil
+		mattermost.Spec.Image = "mattermost/mattermost-team-edition"
+		require.NoError(t, r.checkAuditTrail(context.TODO(), mattermost, logger))
+
+		recorded := revisions(t)
+		require.Len(t, recorded, 2)
+		assert.Equal(t, int64(3), recorded[0].Generation)
+		assert.Equal(t, int64(2), recorded[1].Generation)
+	})
+
+	t.Run("disabled", func(t *testing.T) {
+		disabled := &MattermostReconciler{Client: client, NonCachedAPIReader: client, Scheme: s}
+		mattermost.Generation = 4
+		require.NoError(t, disabled.checkAuditTrail(context.TODO(), mattermost, logger))
+		assert.Equal(t, int64(3), revisions(t)[0].Generation)
+	})
+}
@@ -0,0 +1,95 @@
+package mattermost
+
+import (
+	"context"
+	"time"
+
+	"github.com/go-logr/logr"
+	mmv1beta "github.com/mattermost/mattermost-operator/apis/mattermost/v1beta1"
+	mattermostApp "github.com/mattermost/mattermost-operator/pkg/mattermost"
+	"github.com/mattermost/mattermost-operator/pkg/mattermost/autosizing"
+	"github.com/pkg/errors"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+)
+
+// accessTokenKey is the key of the access token in the access token
+// Secrets of the Mattermost admin API.
+const accessTokenKey = "token"
+
+// checkAutoSizing checks the active users of the Mattermost once the check
+// interval elapsed, and returns the size computed from them in the status.
+// The size is not stored in the spec, its replicas and resources are applied
+// in memory on every reconciliation with SetReplicasAndResourcesFromAutoSize.
+// Failed checks are reported in the status and keep the current size.
+func (r *MattermostReconciler) checkAutoSizing(ctx context.Context, mattermost *mmv1beta.Mattermost, reqLogger logr.Logger) *mmv1beta.AutoSizingStatus {
+	if !mattermost.AutoSizingEnabled() {
+		return nil
+	}
+
+	autoSizing := mattermost.Spec.AutoSizing
+	previous := mattermost.Status.AutoSizing
+	if previous != nil && previous.LastCheckTime != nil && time.Since(previous.LastCheckTime.Time) < autoSizing.GetCheckInterval() {
+		return previous
+	}
+
+	checkTime := metav1.Now()
+	status := &mmv1beta.AutoSizingStatus{LastCheckTime: &checkTime}
+	if previous != nil {
+		status.Size = previous.Size
+		status.ActiveUsers = previous.ActiveUsers
+	}
+
+	activeUsers, err := r.activeUsers(ctx, mattermost)
+	if err != nil {
+		reqLogger.Error(err, "Unable to check the active users of Mattermost")
+		status.Error = err.Error()
+		return status
+	}
+
+	size, err := autosizing.DesiredSize(activeUsers, status.Size, autosizing.Policy{
+		MinSize:            autoSizing.GetMinSize(),
+		MaxSize:            autoSizing.GetMaxSize(),
+		ScaleUpThreshold:   autoSizing.GetScaleUpThreshold(),
+		ScaleDownThreshold: autoSizing.GetScaleDownThreshold(),
+	})
+	if err != nil {
+		status.Error = err.Error()
+		return status
+	}
+
+	if size != status.Size {
+		reqLogger.Info("Applying size from active users", "size", size, "activeUsers", activeUsers)
+		r.Recorder.Eventf(mattermost, corev1.EventTypeNormal, "AutoSized", "Applied size %s for %d monthly active users", size, activeUsers)
+	}
+	status.Size = size
+	status.ActiveUsers = activeUsers
+
+	return status
+}
+
+// activeUsers queries the monthly active users of the Mattermost with the
+// access token of the auto-sizing Secret.
+func (r *MattermostReconciler) activeUsers(ctx context.Context, mattermost *mmv1beta.Mattermost) (int64, error) {
+	token, err := r.accessToken(ctx, mattermost, mattermost.Spec.AutoSizing.AccessTokenSecret)
+	if err != nil {
+		return 0, errors.Wrap(err, "failed to get auto-sizing access token")
+	}
+
+	return r.AutoSizing.ActiveUsers(ctx, mattermostApp.ServiceURL(mattermost), token)
+}
+
+// accessToken returns the access token of the Secret of the Mattermost.
+func (r *MattermostReconciler) accessToken(ctx context.Context, mattermost *mmv1beta.Mattermost, secretName string) (string, error) {
+	secret := &corev1.Secret{}
+	err := r.Client.Get(ctx, types.NamespacedName{Name: secretName, Namespace: mattermost.Namespace}, secret)
+	if err != nil {
+		return "", errors.Wrap(err, "failed to get access token secret")
+	}
+	token, ok := secret.Data[accessTokenKey]
+	if !ok || len(token) == 0 {
+		return "", errors.Errorf("access token secret %s does not have a '%s' value", secret.Name, accessTokenKey)
+	}
+	return string(token), nil
+}
@@ -0,0 +1,207 @@
+package mattermost
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	blubr "github.com/mattermost/blubr"
+	mmv1alpha1 "github.com/mattermost/mattermost-operator/apis/mattermost/v1alpha1"
+	mmv1beta "github.com/mattermost/mattermost-operator/apis/mattermost/v1beta1"
+	"github.com/mattermost/mattermost-operator/pkg/resources"
+	resourcesfake "github.com/mattermost/mattermost-operator/pkg/resources/fake"
+	operatortest "github.com/mattermost/mattermost-operator/test"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/kubernetes/scheme"
+	"k8s.io/client-go/tools/record"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+)
+
+type fakeActiveUsers struct {
+	activeUsers int64
+	url         string
+	token       string
+}
+
+func (f *fakeActiveUsers) ActiveUsers(_ context.Context, url, token string) (int64, error) {
+	f.url = url
+	f.token = token
+	return f.activeUsers, nil
+}
+
+func TestCheckAutoSizing(t *testing.T) {
+	logger := blubr.InitLogger()
+
+	s := prepareSchema(t, scheme.Scheme)
+	s.AddKnownTypes(mmv1beta.GroupVersion, &mmv1beta.Mattermost{})
+
+	secret := &corev1.Secret{
+		ObjectMeta: metav1.ObjectMeta{Name: "admin-token", Namespace: "mm-namespace"},
+		Data:       map[string][]byte{"token": []byte("secret-token")},
+	}
+
+	newReconciler := func(activeUsers *fakeActiveUsers) *MattermostReconciler {
+		c := fake.NewFakeClientWithScheme(s, secret)
+		return &MattermostReconciler{
+			Client:     c,
+			Scheme:     s,
+			Recorder:   record.NewFakeRecorder(10),
+			AutoSizing: activeUsers,
+		}
+	}
+
+	newMattermost := func() *mmv1beta.Mattermost {
+		return &mmv1beta.Mattermost{
+			ObjectMeta: metav1.ObjectMeta{Name: "mm", Namespace: "mm-namespace"},
+			Spec: mmv1beta.MattermostSpec{
+				AutoSizing: &mmv1beta.AutoSizing{
+					Enabled:           true,
+					AccessTokenSecret: "admin-token",
+				},
+			},
+		}
+	}
+
+	t.Run("disabled", func(t *testing.T) {
+		mattermost := newMattermost()
+		mattermost.Spec.AutoSizing.Enabled = false
+		assert.Nil(t, newReconciler(&fakeActiveUsers{}).checkAutoSizing(context.TODO(), mattermost, logger))
+	})
+
+	t.Run("apply size", func(t *testing.T) {
+		activeUsers := &fakeActiveUsers{activeUsers: 900}
+		mattermost := newMattermost()
+
+		status := newReconciler(activeUsers).checkAutoSizing(context.TODO(), mattermost, logger)
+		require.NotNil(t, status)
+		assert.Equal(t, "5000users", status.Size)
+		assert.Equal(t, int64(900), status.ActiveUsers)
+		assert.Empty(t, status.Error)
+		assert.Empty(t, mattermost.Spec.Size)
+		assert.Equal(t, "http://mm.mm-namespace.svc.cluster.local:8065", activeUsers.url)
+		assert.Equal(t, "secret-token", activeUsers.token)
+	})
+
+	t.Run("keep size", func(t *testing.T) {
+		mattermost := newMattermost()
+		mattermost.Status.AutoSizing = &mmv1beta.AutoSizingStatus{Size: "5000users"}
+
+		status := newReconciler(&fakeActiveUsers{activeUsers: 2500}).checkAutoSizing(context.TODO(), mattermost, logger)
+		assert.Equal(t, "5000users", status.Size)
+		assert.Empty(t, mattermost.Spec.Size)
+	})
+
+	t.Run("check interval not elapsed", func(t *testing.T) {
+		lastCheck := metav1.NewTime(time.Now().Add(-time.Minute))
+		mattermost := newMattermost()
+		mattermost.Status.AutoSizing = &mmv1beta.AutoSizingStatus{Size: "1000users", LastCheckTime: &lastCheck}
+
+		status := newReconciler(&fakeActiveUsers{activeUsers: 20000}).checkAutoSizing(context.TODO(), mattermost, logger)
+		assert.Equal(t, mattermost.Status.AutoSizing, status)
+		assert.Empty(t, mattermost.Spec.Size)
+	})
+
+	t.Run("missing access token", func(t *testing.T) {
+		mattermost := newMattermost()
+		mattermost.Spec.AutoSizing.AccessTokenSecret = "missing"
+		mattermost.Status.AutoSizing = &mmv1beta.AutoSizingStatus{Size: "1000users"}
+
+		status := newReconciler(&fakeActiveUsers{activeUsers: 20000}).checkAutoSizing(context.TODO(), mattermost, logger)
+		assert.Equal(t, "1000users", status.Size)
+		assert.NotEmpty(t, status.Error)
+		assert.Empty(t, mattermost.Spec.Size)
+	})
+}
+
+func TestReconcileAutoSizing(t *testing.T) {
+	logger := blubr.InitLogger()
+	s := prepareSchema(t, scheme.Scheme)
+	size, err := mmv1alpha1.GetClusterSize("5000users")
+	require.NoError(t, err)
+
+	for _, storeDefaults := range []string{"true", "false"} {
+		t.Run("store defaults "+storeDefaults, func(t *testing.T) {
+			key := types.NamespacedName{Namespace: "auto-sizing", Name: "chat"}
+			replicas := int32(1)
+			mattermost := &mmv1beta.Mattermost{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:        key.Name,
+					Namespace:   key.Namespace,
+					UID:         "mm-uid",
+					Generation:  1,
+					Annotations: map[string]string{mmv1beta.StoreDefaultsAnnotation: storeDefaults},
+				},
+				Spec: mmv1beta.MattermostSpec{
+					Replicas: &replicas,
+					Image:    "mattermost/mattermost-enterprise-edition",
+					Version:  operatortest.LatestStableMattermostVersion,
+					Ingress:  &mmv1beta.Ingress{Enabled: true, Host: "chat.example.com"},
+					Database: mmv1beta.Database{External: &mmv1beta.ExternalDatabase{Secret: "db"}},
+					FileStore: mmv1beta.FileStore{
+						External: &mmv1beta.ExternalFileStore{URL: "s3.example.com", Bucket: "chat", Secret: "s3"},
+					},
+					AutoSizing: &mmv1beta.AutoSizing{Enabled: true, AccessTokenSecret: "admin-token"},
+				},
+			}
+			secrets := []client.Object{
+				&corev1.Secret{
+					ObjectMeta: metav1.ObjectMeta{Name: "db", Namespace: key.Namespace},
+					Data:       map[string][]byte{"DB_CONNECTION_STRING": []byte("postgres://mmuser:secret@db:5432/mattermost")},
+				},
+				&corev1.Secret{
+					ObjectMeta: metav1.ObjectMeta{Name: "s3", Namespace: key.Namespace},
+					Data:       map[string][]byte{"accesskey": []byte("key"), "secretkey": []byte("secret")},
+				},
+				&corev1.Secret{
+					ObjectMeta: metav1.ObjectMeta{Name: "admin-token", Namespace: key.Namespace},
+					Data:       map[string][]byte{"token": []byte("secret-token")},
+				},
+			}
+
+			c := fake.NewClientBuilder().WithScheme(s).WithObjects(append(secrets, mattermost)...).Build()
+			activeUsers := &fakeActiveUsers{activeUsers: 900}
+			r := &MattermostReconciler{
+				Client:             c,
+				NonCachedAPIReader: c,
+				Scheme:             s,
+				Log:                logger,
+				MaxReconciling:     5,
+				Resources:          resources.NewResourceHelper(resourcesfake.NewApplyClient(c), s),
+				Recorder:           record.NewFakeRecorder(100),
+				AutoSizing:         activeUsers,
+			}
+
+			deploymentReplicas := func(t *testing.T) int32 {
+				_, err := r.Reconcile(context.TODO(), reconcile.Request{NamespacedName: key})
+				require.NoError(t, err)
+				deployment := &appsv1.Deployment{}
+				require.NoError(t, c.Get(context.TODO(), key, deployment))
+				return *deployment.Spec.Replicas
+			}
+
+			// The auto-sized replicas are applied to the deployment but not
+			// stored in the spec, which keeps the replicas set by the user.
+			assert.Equal(t, size.App.Replicas, deploymentReplicas(t))
+			assert.NotEmpty(t, activeUsers.url)
+
+			stored := &mmv1beta.Mattermost{}
+			require.NoError(t, c.Get(context.TODO(), key, stored))
+			assert.Equal(t, replicas, *stored.Spec.Replicas)
+			assert.Empty(t, stored.Spec.Size)
+			require.NotNil(t, stored.Status.AutoSizing)
+			assert.Equal(t, "5000users", stored.Status.AutoSizing.Size)
+
+			// Until the next check, the size of the status is applied.
+			activeUsers.url = ""
+			assert.Equal(t, size.App.Replicas, deploymentReplicas(t))
+			assert.Empty(t, activeUsers.url)
+		})
+	}
+}
@@ -0,0 +1,81 @@
+package mattermost
+
+import (
+	"context"
+
+	"github.com/go-logr/logr"
+	mmv1beta "github.com/mattermost/mattermost-operator/apis/mattermost/v1beta1"
+	"github.com/mattermost/mattermost-operator/pkg/mattermost/releases"
+	"github.com/prometheus/client_golang/prometheus"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"sigs.k8s.io/controller-runtime/pkg/metrics"
+)
+
+const (
+	availableUpdatePatch = "patch"
+	availableUpdateESR   = "esr"
+)
+
+var updateAvailableGauge = prometheus.NewGaugeVec(
+	prometheus.GaugeOpts{
+		Name: "mattermost_operator_update_available",
+		Help: "Whether a newer Mattermost release of the given type, patch or esr, is available for the installation.",
+	},
+	[]string{"namespace", "name", "type"},
+)
+
+func init() {
+	metrics.Registry.MustRegister(updateAvailableGauge)
+}
+
+// checkAvailableUpdate reports the latest patch release and the latest
+// Extended Support Release newer than the running version in the status and
+// in the metrics. Failing to fetch the releases feed keeps the previous
+// status.
+func (r *MattermostReconciler) checkAvailableUpdate(ctx context.Context, mattermost *mmv1beta.Mattermost, reqLogger logr.Logger) *mmv1beta.AvailableUpdateStatus {
+	previous := mattermost.Status.AvailableUpdate
+	if r.ReleasesFeed == nil {
+		return previous
+	}
+
+	available, err := r.ReleasesFeed.Releases(ctx)
+	if err != nil {
+		reqLogger.Error(err, "Unable to get Mattermost releases")
+		return previous
+	}
+
+	version := mattermost.Status.Version
+	if version == "" {
+		version = mattermost.Spec.Version
+	}
+	latestPatch, latestESR := releases.AvailableUpdates(available, version)
+
+	setUpdateAvailable(mattermost, availableUpdatePatch, latestPatch != "")
+	setUpdateAvailable(mattermost, availableUpdateESR, latestESR != "")
+
+	checkTime := metav1.NewTime(r.ReleasesFeed.FetchTime())
+	if previous != nil && previous.LatestPatch == latestPatch && previous.LatestESR == latestESR &&
+		previous.LastCheckTime != nil && previous.LastCheckTime.Equal(&checkTime) {
+		return previous
+	}
+
+	return &mmv1beta.AvailableUpdateStatus{
+		LatestPatch:   latestPatch,
+		LatestESR:     latestESR,
+		LastCheckTime: &checkTime,
+	}
+}
+
+func setUpdateAvailable(mattermost *mmv1beta.Mattermost, updateType string, available bool) {
+	value := 0.0
+	if available {
+		value = 1
+	}
+	updateAvailableGauge.WithLabelValues(mattermost.Namespace, mattermost.Name, updateType).Set(value)
+}
+
+// deleteUpdateAvailableMetrics removes the metrics of a deleted Mattermost.
+func deleteUpdateAvailableMetrics(namespace, name string) {
+	updateAvailableGauge.DeleteLabelValues(namespace, name, availableUpdatePatch)
+	updateAvailableGauge.DeleteLabelValues(namespace, name, availableUpdateESR)
+}
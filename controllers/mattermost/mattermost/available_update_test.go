@@ -0,0 +1,41 @@
+package mattermost
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	blubr "github.com/mattermost/blubr"
+	mmv1beta "github.com/mattermost/mattermost-operator/apis/mattermost/v1beta1"
+	"github.com/mattermost/mattermost-operator/pkg/mattermost/releases"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func TestCheckAvailableUpdate(t *testing.T) {
+	logger := blubr.InitLogger()
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write([]byte(`{"releases": [{"version": "5.36.1"}, {"version": "5.37.1", "esr": true}, {"version": "5.38.0"}]}`))
+	}))
+	defer server.Close()
+
+	r := &MattermostReconciler{ReleasesFeed: releases.NewFeed(server.URL, time.Hour)}
+	mattermost := &mmv1beta.Mattermost{
+		ObjectMeta: metav1.ObjectMeta{Name: "mm", Namespace: "mm-namespace"},
+		Spec:       mmv1beta.MattermostSpec{Version: "5.36.1"},
+		Status:     mmv1beta.MattermostStatus{Version: "5.36.0"},
+	}
+
+	update := r.checkAvailableUpdate(context.TODO(), mattermost, logger)
+	require.NotNil(t, update)
+	assert.Equal(t, "5.36.1", update.LatestPatch)
+	assert.Equal(t, "5.37.1", update.LatestESR)
+	require.NotNil(t, update.LastCheckTime)
+
+	mattermost.Status.AvailableUpdate = update
+	assert.Same(t, update, r.checkAvailableUpdate(context.TODO(), mattermost, logger))
+}
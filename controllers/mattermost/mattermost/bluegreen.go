@@ -0,0 +1,96 @@
+package mattermost
+
+import (
+	"github.com/go-logr/logr"
+	mmv1beta "github.com/mattermost/mattermost-operator/apis/mattermost/v1beta1"
+	mattermostApp "github.com/mattermost/mattermost-operator/pkg/mattermost"
+	"github.com/pkg/errors"
+	appsv1 "k8s.io/api/apps/v1"
+	"k8s.io/apimachinery/pkg/types"
+)
+
+// checkBlueGreen checks the service, ingress and deployment of the blue and
+// green deployments and removes the Mattermost deployment they replace. Once
+// BlueGreen is disabled, the components of the blue and green deployments
+// are removed.
+func (r *MattermostReconciler) checkBlueGreen(
+	mattermost *mmv1beta.Mattermost,
+	dbConfig mattermostApp.DatabaseConfig,
+	fileStoreInfo *mattermostApp.FileStoreInfo,
+	reqLogger logr.Logger) error {
+	reqLogger = reqLogger.WithValues("phase", "blueGreen")
+
+	if !mattermost.BlueGreenEnabled() {
+		for _, name := range []string{mattermost.Status.BlueName, mattermost.Status.GreenName} {
+			if name == "" {
+				continue
+			}
+			err := r.deleteAppDeploymentComponents(mattermost, name, reqLogger)
+			if err != nil {
+				return errors.Wrapf(err, "failed to delete BlueGreen deployment %s", name)
+			}
+		}
+		return nil
+	}
+
+	blueGreen := []mmv1beta.AppDeployment{mattermost.Spec.BlueGreen.Blue, mattermost.Spec.BlueGreen.Green}
+	for _, deployment := range blueGreen {
+		err := r.checkMattermostService(mattermost, deployment.Name, deployment.Name, reqLogger)
+		if err != nil {
+			return err
+		}
+
+		if !mattermost.Spec.UseServiceLoadBalancer {
+			err = r.checkMattermostIngress(mattermost, deployment.Name, deployment.IngressHost, reqLogger)
+			if err != nil {
+				return err
+			}
+		}
+
+		err = r.checkDeployment(mattermost, generateBlueGreenDeployment(mattermost, dbConfig, fileStoreInfo, deployment), reqLogger)
+		if err != nil {
+			return err
+		}
+	}
+
+	err := r.Resources.DeleteDeployment(types.NamespacedName{Name: mattermost.Name, Namespace: mattermost.Namespace}, reqLogger)
+	if err != nil {
+		return errors.Wrap(err, "failed to delete Mattermost deployment replaced by BlueGreen")
+	}
+
+	return nil
+}
+
+// generateBlueGreenDeployment returns the desired blue or green deployment.
+// The production deployment uses the main ingress host as its site URL, the
+// other one its own test host.
+func generateBlueGreenDeployment(mattermost *mmv1beta.Mattermost, dbConfig mattermostApp.DatabaseConfig, fileStoreInfo *mattermostApp.FileStoreInfo, deployment mmv1beta.AppDeployment) *appsv1.Deployment {
+	host := deployment.IngressHost
+	if deployment.Name == mattermost.GetProductionDeploymentName() {
+		host = mattermost.GetIngressHost()
+	}
+
+	return mattermostApp.GenerateDeploymentV1Beta(
+		mattermost,
+		dbConfig,
+		fileStoreInfo,
+		deployment.Name,
+		host,
+		mattermost.Name,
+		mattermost.GetAppDeploymentImageName(deployment),
+	)
+}
+
+func (r *MattermostReconciler) deleteAppDeploymentComponents(mattermost *mmv1beta.Mattermost, name string, reqLogger logr.Logger) error {
+	key := types.NamespacedName{Name: name, Namespace: mattermost.Namespace}
+
+	err := r.Resources.DeleteDeployment(key, reqLogger)
+	if err != nil {
+		return err
+	}
+	err = r.Resources.DeleteService(key, reqLogger)
+	if err != nil {
+		return err
+	}
+	return r.Resources.DeleteIngress(key, reqLogger)
+}
@@ -0,0 +1,65 @@
+package mattermost
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/go-logr/logr"
+	mmv1beta "github.com/mattermost/mattermost-operator/apis/mattermost/v1beta1"
+	mattermostApp "github.com/mattermost/mattermost-operator/pkg/mattermost"
+	"github.com/mattermost/mattermost-operator/pkg/resources"
+	"github.com/pkg/errors"
+	batchv1 "k8s.io/api/batch/v1"
+	k8sErrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	k8sClient "sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+// checkBucketConfiguration runs the Job configuring the encryption and
+// lifecycle of the external file store bucket. The Job runs once for each
+// configuration: it is only replaced when the configuration changes.
+func (r *MattermostReconciler) checkBucketConfiguration(mattermost *mmv1beta.Mattermost, fileStore *mattermostApp.FileStoreInfo, reqLogger logr.Logger) error {
+	name := mattermostApp.BucketConfigurationJobName(mattermost)
+	current := &batchv1.Job{}
+	err := r.Client.Get(context.TODO(), types.NamespacedName{Name: name, Namespace: mattermost.Namespace}, current)
+	if err != nil && !k8sErrors.IsNotFound(err) {
+		return errors.Wrap(err, "failed to get bucket configuration job")
+	}
+	found := err == nil
+
+	desired := mattermostApp.GenerateBucketConfigurationJobV1Beta(mattermost, fileStore)
+	if desired == nil {
+		if found {
+			r.deleteBucketConfigurationJob(current, reqLogger)
+		}
+		return nil
+	}
+
+	if !found {
+		reqLogger.Info("Launching bucket configuration job")
+		return r.Resources.Create(mattermost, desired, reqLogger)
+	}
+
+	if current.Annotations[mattermostApp.BucketConfigurationHashAnnotation] != desired.Annotations[mattermostApp.BucketConfigurationHashAnnotation] {
+		reqLogger.Info("Bucket configuration changed, restarting bucket configuration job")
+		r.deleteBucketConfigurationJob(current, reqLogger)
+		return nil
+	}
+
+	if resources.JobConditionTrue(current, batchv1.JobFailed) {
+		return errors.Errorf("bucket configuration job %s failed, delete it to retry: %s", current.Name, r.Resources.JobTerminationMessage(current, reqLogger))
+	}
+
+	return nil
+}
+
+func (r *MattermostReconciler) deleteBucketConfigurationJob(job *batchv1.Job, reqLogger logr.Logger) {
+	reqLogger.Info(fmt.Sprintf("Deleting bucket configuration job %s/%s", job.GetNamespace(), job.GetName()))
+
+	err := r.Client.Delete(context.TODO(), job, k8sClient.PropagationPolicy(metav1.DeletePropagationBackground))
+	if err != nil && !k8sErrors.IsNotFound(err) {
+		// Do not return error on fail as it is not critical
+		reqLogger.Error(err, "Unable to delete bucket configuration job")
+	}
+}
@@ -0,0 +1,61 @@
+package mattermost
+
+import (
+	"github.com/go-logr/logr"
+	mmv1beta "github.com/mattermost/mattermost-operator/apis/mattermost/v1beta1"
+	mattermostApp "github.com/mattermost/mattermost-operator/pkg/mattermost"
+	"github.com/pkg/errors"
+	appsv1 "k8s.io/api/apps/v1"
+)
+
+// checkCanary checks the service, ingress and deployment of the canary
+// deployment. The canary ingress shares the host of the Mattermost ingress
+// and receives the configured share of its requests. Once Canary is
+// disabled, the components of the canary deployment are removed.
+func (r *MattermostReconciler) checkCanary(
+	mattermost *mmv1beta.Mattermost,
+	dbConfig mattermostApp.DatabaseConfig,
+	fileStoreInfo *mattermostApp.FileStoreInfo,
+	reqLogger logr.Logger) error {
+	reqLogger = reqLogger.WithValues("phase", "canary")
+
+	if !mattermost.CanaryEnabled() {
+		if mattermost.Status.CanaryName == "" {
+			return nil
+		}
+		err := r.deleteAppDeploymentComponents(mattermost, mattermost.Status.CanaryName, reqLogger)
+		if err != nil {
+			return errors.Wrapf(err, "failed to delete canary deployment %s", mattermost.Status.CanaryName)
+		}
+		return nil
+	}
+
+	canary := mattermost.Spec.Canary.Deployment
+	err := r.checkMattermostService(mattermost, canary.Name, canary.Name, reqLogger)
+	if err != nil {
+		return err
+	}
+
+	err = r.checkIngress(mattermost, mattermostApp.GenerateCanaryIngressV1Beta(mattermost), reqLogger)
+	if err != nil {
+		return errors.Wrap(err, "failed to check canary ingress")
+	}
+
+	return r.checkDeployment(mattermost, generateCanaryDeployment(mattermost, dbConfig, fileStoreInfo), reqLogger)
+}
+
+// generateCanaryDeployment returns the desired canary deployment. It serves
+// the Mattermost ingress host, which it uses as its site URL.
+func generateCanaryDeployment(mattermost *mmv1beta.Mattermost, dbConfig mattermostApp.DatabaseConfig, fileStoreInfo *mattermostApp.FileStoreInfo) *appsv1.Deployment {
+	canary := mattermost.Spec.Canary.Deployment
+
+	return mattermostApp.GenerateDeploymentV1Beta(
+		mattermost,
+		dbConfig,
+		fileStoreInfo,
+		canary.Name,
+		mattermost.GetIngressHost(),
+		mattermost.Name,
+		mattermost.GetAppDeploymentImageName(canary),
+	)
+}
@@ -0,0 +1,121 @@
+package mattermost
+
+import (
+	"context"
+
+	"github.com/go-logr/logr"
+	mmv1beta "github.com/mattermost/mattermost-operator/apis/mattermost/v1beta1"
+	mattermostApp "github.com/mattermost/mattermost-operator/pkg/mattermost"
+	"github.com/mattermost/mattermost-operator/pkg/mattermost/clusterstatus"
+	"github.com/mattermost/mattermost-operator/pkg/utils"
+	"github.com/pkg/errors"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+// checkClusterReadiness sets the cluster joined readiness gate of the pods
+// of the production deployment once their app server joined the cluster,
+// that is reports another app server in its cluster status. Pods are only
+// checked once their containers are ready, and stay joined once they joined.
+func (r *MattermostReconciler) checkClusterReadiness(ctx context.Context, mattermost *mmv1beta.Mattermost, reqLogger logr.Logger) error {
+	if !mattermost.ClusterReadinessGateEnabled() {
+		return nil
+	}
+
+	pods := &corev1.PodList{}
+	err := r.NonCachedAPIReader.List(ctx, pods,
+		client.InNamespace(mattermost.Namespace),
+		client.MatchingLabels(mmv1beta.MattermostSelectorLabels(mattermost.GetProductionDeploymentName())),
+	)
+	if err != nil {
+		return errors.Wrap(err, "failed to list Mattermost pods")
+	}
+
+	var token string
+	for i := range pods.Items {
+		pod := &pods.Items[i]
+		if !hasReadinessGate(pod, mattermostApp.ClusterJoinedCondition) ||
+			podConditionTrue(pod, mattermostApp.ClusterJoinedCondition) ||
+			!podConditionTrue(pod, corev1.ContainersReady) ||
+			pod.Status.PodIP == "" {
+			continue
+		}
+
+		if token == "" {
+			token, err = r.accessToken(ctx, mattermost, mattermost.Spec.ClusterReadinessGate.AccessTokenSecret)
+			if err != nil {
+				return errors.Wrap(err, "failed to get cluster readiness gate access token")
+			}
+		}
+
+		condition := corev1.PodCondition{
+			Type:   mattermostApp.ClusterJoinedCondition,
+			Status: corev1.ConditionFalse,
+			Reason: "NotJoined",
+		}
+		nodes, err := r.ClusterStatus.Nodes(ctx, mattermostApp.PodURL(pod), token)
+		if err != nil {
+			reqLogger.Error(err, "Unable to check the cluster status of Mattermost pod", "pod", pod.Name)
+			condition.Reason = "ClusterStatusUnavailable"
+			condition.Message = err.Error()
+		} else if clusterstatus.HasPeers(nodes, pod.Name, pod.Status.PodIP) {
+			condition.Status = corev1.ConditionTrue
+			condition.Reason = "Joined"
+			reqLogger.Info("Mattermost pod joined the cluster", "pod", pod.Name)
+		}
+
+		if !setPodCondition(pod.DeepCopy(), condition) {
+			continue
+		}
+		// The condition is set again on the pod fetched again if the kubelet
+		// changed its conditions meanwhile.
+		err = utils.PatchStatus(ctx, r.Client, pod, func() {
+			setPodCondition(pod, condition)
+		})
+		if err != nil {
+			return errors.Wrapf(err, "failed to update cluster readiness gate of pod %s", pod.Name)
+		}
+	}
+
+	return nil
+}
+
+func hasReadinessGate(pod *corev1.Pod, conditionType corev1.PodConditionType) bool {
+	for _, gate := range pod.Spec.ReadinessGates {
+		if gate.ConditionType == conditionType {
+			return true
+		}
+	}
+	return false
+}
+
+func podConditionTrue(pod *corev1.Pod, conditionType corev1.PodConditionType) bool {
+	for _, condition := range pod.Status.Conditions {
+		if condition.Type == conditionType {
+			return condition.Status == corev1.ConditionTrue
+		}
+	}
+	return false
+}
+
+// setPodCondition sets the condition on the pod, returning false if it is
+// already set.
+func setPodCondition(pod *corev1.Pod, condition corev1.PodCondition) bool {
+	condition.LastTransitionTime = metav1.Now()
+	for i, existing := range pod.Status.Conditions {
+		if existing.Type != condition.Type {
+			continue
+		}
+		if existing.Status == condition.Status && existing.Reason == condition.Reason && existing.Message == condition.Message {
+			return false
+		}
+		if existing.Status == condition.Status {
+			condition.LastTransitionTime = existing.LastTransitionTime
+		}
+		pod.Status.Conditions[i] = condition
+		return true
+	}
+	pod.Status.Conditions = append(pod.Status.Conditions, condition)
+	return true
+}
@@ -0,0 +1,105 @@
+package mattermost
+
+import (
+	"context"
+	"testing"
+
+	blubr "github.com/mattermost/blubr"
+	mmv1beta "github.com/mattermost/mattermost-operator/apis/mattermost/v1beta1"
+	mattermostApp "github.com/mattermost/mattermost-operator/pkg/mattermost"
+	"github.com/mattermost/mattermost-operator/pkg/mattermost/clusterstatus"
+	"github.com/mattermost/mattermost-operator/pkg/utils"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/kubernetes/scheme"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+)
+
+type fakeClusterStatus struct {
+	nodes map[string][]clusterstatus.Node
+}
+
+func (f *fakeClusterStatus) Nodes(_ context.Context, url, _ string) ([]clusterstatus.Node, error) {
+	return f.nodes[url], nil
+}
+
+func TestCheckClusterReadiness(t *testing.T) {
+	logger := blubr.InitLogger()
+
+	s := prepareSchema(t, scheme.Scheme)
+	s.AddKnownTypes(mmv1beta.GroupVersion, &mmv1beta.Mattermost{})
+
+	mattermost := &mmv1beta.Mattermost{
+		ObjectMeta: metav1.ObjectMeta{Name: "mm", Namespace: "mm-namespace"},
+		Spec: mmv1beta.MattermostSpec{
+			Replicas:      utils.NewInt32(2),
+			LicenseSecret: "license",
+			ClusterReadinessGate: &mmv1beta.ClusterReadinessGate{
+				Enabled:           true,
+				AccessTokenSecret: "admin-token",
+			},
+		},
+	}
+	secret := &corev1.Secret{
+		ObjectMeta: metav1.ObjectMeta{Name: "admin-token", Namespace: "mm-namespace"},
+		Data:       map[string][]byte{"token": []byte("secret-token")},
+	}
+	newPod := func(name, ip string) *corev1.Pod {
+		return &corev1.Pod{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      name,
+				Namespace: "mm-namespace",
+				Labels:    mmv1beta.MattermostSelectorLabels("mm"),
+			},
+			Spec: corev1.PodSpec{
+				ReadinessGates: []corev1.PodReadinessGate{{ConditionType: mattermostApp.ClusterJoinedCondition}},
+			},
+			Status: corev1.PodStatus{
+				PodIP:      ip,
+				Conditions: []corev1.PodCondition{{Type: corev1.ContainersReady, Status: corev1.ConditionTrue}},
+			},
+		}
+	}
+
+	c := fake.NewFakeClientWithScheme(s, secret, newPod("mm-a", "10.0.0.1"), newPod("mm-b", "10.0.0.2"))
+	r := &MattermostReconciler{
+		Client:             c,
+		NonCachedAPIReader: c,
+		Scheme:             s,
+		ClusterStatus: &fakeClusterStatus{nodes: map[string][]clusterstatus.Node{
+			"http://10.0.0.1:8065": {{Hostname: "mm-a", IPAddress: "10.0.0.1"}, {Hostname: "mm-b", IPAddress: "10.0.0.2"}},
+			"http://10.0.0.2:8065": {{Hostname: "mm-b", IPAddress: "10.0.0.2"}},
+		}},
+	}
+
+	err := r.checkClusterReadiness(context.TODO(), mattermost, logger)
+	require.NoError(t, err)
+
+	joinedCondition := func(name string) corev1.PodCondition {
+		pod := &corev1.Pod{}
+		err := c.Get(context.TODO(), types.NamespacedName{Name: name, Namespace: "mm-namespace"}, pod)
+		require.NoError(t, err)
+		for _, condition := range pod.Status.Conditions {
+			if condition.Type == mattermostApp.ClusterJoinedCondition {
+				return condition
+			}
+		}
+		return corev1.PodCondition{}
+	}
+
+	assert.Equal(t, corev1.ConditionTrue, joinedCondition("mm-a").Status)
+	assert.Equal(t, corev1.ConditionFalse, joinedCondition("mm-b").Status)
+	assert.Equal(t, "NotJoined", joinedCondition("mm-b").Reason)
+
+	t.Run("disabled", func(t *testing.T) {
+		disabled := mattermost.DeepCopy()
+		disabled.Spec.Replicas = utils.NewInt32(1)
+		r := &MattermostReconciler{}
+
+		err := r.checkClusterReadiness(context.TODO(), disabled, logger)
+		require.NoError(t, err)
+	})
+}
@@ -0,0 +1,211 @@
+package mattermost
+
+import (
+	"context"
+	"fmt"
+
+	mmv1beta "github.com/mattermost/mattermost-operator/apis/mattermost/v1beta1"
+	mattermostMinio "github.com/mattermost/mattermost-operator/pkg/components/minio"
+	mattermostmysql "github.com/mattermost/mattermost-operator/pkg/components/mysql"
+	minioOperator "github.com/minio/minio-operator/pkg/apis/miniocontroller/v1beta1"
+	"github.com/pkg/errors"
+	mysqlOperator "github.com/presslabs/mysql-operator/pkg/apis/mysql/v1alpha1"
+	appsv1 "k8s.io/api/apps/v1"
+	networkingv1 "k8s.io/api/networking/v1"
+	k8sErrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/types"
+	k8sClient "sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+const componentNotFoundMessage = "not found"
+
+// checkComponents returns the readiness of the app deployments, the operator
+// managed database and file store, and the ingresses of the Mattermost.
+func (r *MattermostReconciler) checkComponents(mattermost *mmv1beta.Mattermost) ([]mmv1beta.ComponentStatus, error) {
+	var components []mmv1beta.ComponentStatus
+
+	for _, name := range appDeploymentNames(mattermost) {
+		component, err := r.deploymentComponent(mattermost, name)
+		if err != nil {
+			return nil, err
+		}
+		components = append(components, component)
+	}
+
+	if !mattermost.Spec.Database.IsExternal() {
+		component, err := r.databaseComponent(mattermost)
+		if err != nil {
+			return nil, err
+		}
+		components = append(components, component)
+	}
+
+	if !mattermost.Spec.FileStore.IsExternal() {
+		component, err := r.fileStoreComponent(mattermost)
+		if err != nil {
+			return nil, err
+		}
+		components = append(components, component)
+	}
+
+	for _, name := range ingressNames(mattermost) {
+		component, err := r.ingressComponent(mattermost, name)
+		if err != nil {
+			return nil, err
+		}
+		components = append(components, component)
+	}
+
+	return components, nil
+}
+
+// appDeploymentNames returns the names of the app deployments of the
+// Mattermost.
+func appDeploymentNames(mattermost *mmv1beta.Mattermost) []string {
+	names := []string{mattermost.Name}
+	if mattermost.BlueGreenEnabled() {
+		names = []string{mattermost.Spec.BlueGreen.Blue.Name, mattermost.Spec.BlueGreen.Green.Name}
+	}
+	if mattermost.CanaryEnabled() {
+		names = append(names, mattermost.Spec.Canary.Deployment.Name)
+	}
+	return names
+}
+
+// ingressNames returns the names of the ingresses of the Mattermost.
+func ingressNames(mattermost *mmv1beta.Mattermost) []string {
+	var names []string
+	if !mattermost.Spec.UseServiceLoadBalancer && mattermost.IngressEnabled() {
+		names = append(names, mattermost.Name)
+		if mattermost.BlueGreenEnabled() {
+			names = append(names, mattermost.Spec.BlueGreen.Blue.Name, mattermost.Spec.BlueGreen.Green.Name)
+		}
+	}
+	if mattermost.CanaryEnabled() {
+		names = append(names, mattermost.Spec.Canary.Deployment.Name)
+	}
+	return names
+}
+
+func (r *MattermostReconciler) deploymentComponent(mattermost *mmv1beta.Mattermost, name string) (mmv1beta.ComponentStatus, error) {
+	component := mmv1beta.ComponentStatus{Component: mmv1beta.AppComponent, Kind: "Deployment", Name: name}
+
+	deployment := &appsv1.Deployment{}
+	found, err := r.getComponent(mattermost, name, deployment)
+	if err != nil || !found {
+		component.Message = componentNotFoundMessage
+		return component, err
+	}
+
+	component.DesiredReplicas = 1
+	if deployment.Spec.Replicas != nil {
+		component.DesiredReplicas = *deployment.Spec.Replicas
+	}
+	component.ReadyReplicas = deployment.Status.ReadyReplicas
+
+	switch {
+	case rolloutInProgress(deployment):
+		component.Message = "rollout in progress"
+	case component.ReadyReplicas < component.DesiredReplicas:
+		component.Message = replicasMessage(component)
+	default:
+		component.Ready = true
+	}
+
+	return component, nil
+}
+
+// rolloutInProgress returns true if the pods of the deployment do not all
+// run its current pod template yet.
+func rolloutInProgress(deployment *appsv1.Deployment) bool {
+	replicas := int32(1)
+	if deployment.Spec.Replicas != nil {
+		replicas = *deployment.Spec.Replicas
+	}
+	return deployment.Status.ObservedGeneration < deployment.Generation || deployment.Status.UpdatedReplicas < replicas
+}
+
+func (r *MattermostReconciler) databaseComponent(mattermost *mmv1beta.Mattermost) (mmv1beta.ComponentStatus, error) {
+	name := mattermostmysql.ClusterV1Beta(mattermost).Name
+	component := mmv1beta.ComponentStatus{Component: mmv1beta.DatabaseComponent, Kind: "MysqlCluster", Name: name}
+
+	cluster := &mysqlOperator.MysqlCluster{}
+	found, err := r.getComponent(mattermost, name, cluster)
+	if err != nil || !found {
+		component.Message = componentNotFoundMessage
+		return component, err
+	}
+
+	component.DesiredReplicas = 1
+	if cluster.Spec.Replicas != nil {
+		component.DesiredReplicas = *cluster.Spec.Replicas
+	}
+	component.ReadyReplicas = int32(cluster.Status.ReadyNodes)
+
+	if component.ReadyReplicas < component.DesiredReplicas {
+		component.Message = replicasMessage(component)
+	} else {
+		component.Ready = true
+	}
+
+	return component, nil
+}
+
+func (r *MattermostReconciler) fileStoreComponent(mattermost *mmv1beta.Mattermost) (mmv1beta.ComponentStatus, error) {
+	name := mattermostMinio.InstanceV1Beta(mattermost).Name
+	component := mmv1beta.ComponentStatus{Component: mmv1beta.FileStoreComponent, Kind: "MinIOInstance", Name: name}
+
+	instance := &minioOperator.MinIOInstance{}
+	found, err := r.getComponent(mattermost, name, instance)
+	if err != nil || !found {
+		component.Message = componentNotFoundMessage
+		return component, err
+	}
+
+	component.DesiredReplicas = instance.Spec.Replicas
+	component.ReadyReplicas = instance.Status.AvailableReplicas
+
+	if component.ReadyReplicas < component.DesiredReplicas {
+		component.Message = replicasMessage(component)
+	} else {
+		component.Ready = true
+	}
+
+	return component, nil
+}
+
+func (r *MattermostReconciler) ingressComponent(mattermost *mmv1beta.Mattermost, name string) (mmv1beta.ComponentStatus, error) {
+	component := mmv1beta.ComponentStatus{Component: mmv1beta.IngressComponent, Kind: "Ingress", Name: name}
+
+	ingress := &networkingv1.Ingress{}
+	found, err := r.getComponent(mattermost, name, ingress)
+	if err != nil || !found {
+		component.Message = componentNotFoundMessage
+		return component, err
+	}
+
+	if len(ingress.Status.LoadBalancer.Ingress) == 0 {
+		component.Message = "waiting for the load balancer address"
+	} else {
+		component.Ready = true
+	}
+
+	return component, nil
+}
+
+// getComponent gets the resource of a component, returning false if it does
+// not exist.
+func (r *MattermostReconciler) getComponent(mattermost *mmv1beta.Mattermost, name string, obj k8sClient.Object) (bool, error) {
+	err := r.Client.Get(context.TODO(), types.NamespacedName{Name: name, Namespace: mattermost.Namespace}, obj)
+	if k8sErrors.IsNotFound(err) {
+		return false, nil
+	}
+	if err != nil {
+		return false, errors.Wrapf(err, "failed to get %s", name)
+	}
+	return true, nil
+}
+
+func replicasMessage(component mmv1beta.ComponentStatus) string {
+	return fmt.Sprintf("%d of %d replicas ready", component.ReadyReplicas, component.DesiredReplicas)
+}
@@ -0,0 +1,70 @@
+package mattermost
+
+import (
+	"testing"
+
+	mmv1beta "github.com/mattermost/mattermost-operator/apis/mattermost/v1beta1"
+	mattermostmysql "github.com/mattermost/mattermost-operator/pkg/components/mysql"
+	"github.com/mattermost/mattermost-operator/pkg/utils"
+	mysqlOperator "github.com/presslabs/mysql-operator/pkg/apis/mysql/v1alpha1"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	networkingv1 "k8s.io/api/networking/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes/scheme"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+)
+
+func TestCheckComponents(t *testing.T) {
+	s := prepareSchema(t, scheme.Scheme)
+	s.AddKnownTypes(mmv1beta.GroupVersion, &mmv1beta.Mattermost{})
+
+	mattermost := &mmv1beta.Mattermost{
+		ObjectMeta: metav1.ObjectMeta{Name: "mm", Namespace: "mm-namespace"},
+		Spec: mmv1beta.MattermostSpec{
+			IngressName: "mm.example.com",
+			Replicas:    utils.NewInt32(2),
+		},
+	}
+	require.NoError(t, mattermost.SetDefaults())
+	require.NoError(t, mattermost.SetReplicasAndResourcesFromSize())
+
+	deployment := &appsv1.Deployment{
+		ObjectMeta: metav1.ObjectMeta{Name: "mm", Namespace: "mm-namespace"},
+		Spec:       appsv1.DeploymentSpec{Replicas: utils.NewInt32(2)},
+		Status:     appsv1.DeploymentStatus{UpdatedReplicas: 2, ReadyReplicas: 1},
+	}
+	cluster := mattermostmysql.ClusterV1Beta(mattermost)
+	cluster.Status = mysqlOperator.MysqlClusterStatus{ReadyNodes: int(*cluster.Spec.Replicas)}
+	ingress := &networkingv1.Ingress{
+		ObjectMeta: metav1.ObjectMeta{Name: "mm", Namespace: "mm-namespace"},
+		Status: networkingv1.IngressStatus{
+			LoadBalancer: corev1.LoadBalancerStatus{Ingress: []corev1.LoadBalancerIngress{{Hostname: "lb.example.com"}}},
+		},
+	}
+
+	r := &MattermostReconciler{Client: fake.NewFakeClientWithScheme(s, deployment, cluster, ingress), Scheme: s}
+
+	components, err := r.checkComponents(mattermost)
+	require.NoError(t, err)
+	assert.Equal(t, []mmv1beta.ComponentStatus{
+		{Component: mmv1beta.AppComponent, Kind: "Deployment", Name: "mm", ReadyReplicas: 1, DesiredReplicas: 2, Message: "1 of 2 replicas ready"},
+		{Component: mmv1beta.DatabaseComponent, Kind: "MysqlCluster", Name: cluster.Name, Ready: true, ReadyReplicas: *cluster.Spec.Replicas, DesiredReplicas: *cluster.Spec.Replicas},
+		{Component: mmv1beta.FileStoreComponent, Kind: "MinIOInstance", Name: "mm-minio", Message: "not found"},
+		{Component: mmv1beta.IngressComponent, Kind: "Ingress", Name: "mm", Ready: true},
+	}, components)
+
+	t.Run("external dependencies and no ingress", func(t *testing.T) {
+		external := mattermost.DeepCopy()
+		external.Spec.Database = mmv1beta.Database{External: &mmv1beta.ExternalDatabase{Secret: "db"}}
+		external.Spec.FileStore = mmv1beta.FileStore{External: &mmv1beta.ExternalFileStore{URL: "s3.amazonaws.com", Bucket: "bucket", Secret: "fs"}}
+		external.Spec.UseServiceLoadBalancer = true
+
+		components, err := r.checkComponents(external)
+		require.NoError(t, err)
+		require.Len(t, components, 1)
+		assert.Equal(t, mmv1beta.AppComponent, components[0].Component)
+	})
+}
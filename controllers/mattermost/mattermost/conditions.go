@@ -0,0 +1,211 @@
+package mattermost
+
+import (
+	"fmt"
+
+	mmv1beta "github.com/mattermost/mattermost-operator/apis/mattermost/v1beta1"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// setStateConditions sets the Ready, Progressing, Degraded and Error
+// conditions, and the Reconciling and Stalled conditions of kstatus, from the
+// state of the Mattermost, the error of its health check and the error of the
+// reconciliation.
+func setStateConditions(status *mmv1beta.MattermostStatus, generation int64, healthErr, reconcileErr error) {
+	// The Mattermost is degraded if it was healthy with the current spec
+	// and is no longer, not while the changes of its spec are rolled out.
+	degraded := healthErr != nil &&
+		(conditionTrueAtGeneration(status.Conditions, mmv1beta.ReadyCondition, generation) ||
+			conditionTrueAtGeneration(status.Conditions, mmv1beta.DegradedCondition, generation))
+
+	switch status.State {
+	case mmv1beta.Stable:
+		setStatusCondition(status, metav1.Condition{
+			Type:               mmv1beta.ReadyCondition,
+			Status:             metav1.ConditionTrue,
+			ObservedGeneration: generation,
+			Reason:             "Stable",
+			Message:            "All Mattermost pods run the requested image and are ready",
+		})
+		if pending := status.PendingUpdate; pending != nil && pending.Reason != "" {
+			setStatusCondition(status, metav1.Condition{
+				Type:               mmv1beta.ProgressingCondition,
+				Status:             metav1.ConditionFalse,
+				ObservedGeneration: generation,
+				Reason:             string(pending.Reason),
+				Message:            fmt.Sprintf("The update to %s is queued", pending.Image),
+			})
+		} else {
+			setStatusCondition(status, metav1.Condition{
+				Type:               mmv1beta.ProgressingCondition,
+				Status:             metav1.ConditionFalse,
+				ObservedGeneration: generation,
+				Reason:             "Stable",
+				Message:            "All changes are rolled out",
+			})
+		}
+	case mmv1beta.StandingBy:
+		setStatusCondition(status, metav1.Condition{
+			Type:               mmv1beta.ReadyCondition,
+			Status:             metav1.ConditionFalse,
+			ObservedGeneration: generation,
+			Reason:             "Standby",
+			Message:            "The standby Mattermost runs no pods until it is promoted",
+		})
+		setStatusCondition(status, metav1.Condition{
+			Type:               mmv1beta.ProgressingCondition,
+			Status:             metav1.ConditionFalse,
+			ObservedGeneration: generation,
+			Reason:             "Standby",
+			Message:            "All changes are rolled out to the idle deployment",
+		})
+	default:
+		message := "The Mattermost is being reconciled"
+		if healthErr != nil {
+			message = healthErr.Error()
+		}
+		setStatusCondition(status, metav1.Condition{
+			Type:               mmv1beta.ReadyCondition,
+			Status:             metav1.ConditionFalse,
+			ObservedGeneration: generation,
+			Reason:             "Reconciling",
+			Message:            message,
+		})
+		if reconcileErr != nil {
+			setStatusCondition(status, metav1.Condition{
+				Type:               mmv1beta.ProgressingCondition,
+				Status:             metav1.ConditionFalse,
+				ObservedGeneration: generation,
+				Reason:             "ReconcileFailed",
+				Message:            "The changes cannot be rolled out until the error is resolved",
+			})
+		} else {
+			setStatusCondition(status, metav1.Condition{
+				Type:               mmv1beta.ProgressingCondition,
+				Status:             metav1.ConditionTrue,
+				ObservedGeneration: generation,
+				Reason:             "Reconciling",
+				Message:            message,
+			})
+		}
+	}
+
+	switch {
+	case meta.IsStatusConditionTrue(status.Conditions, mmv1beta.UpgradeFailedCondition):
+		setStatusCondition(status, metav1.Condition{
+			Type:               mmv1beta.DegradedCondition,
+			Status:             metav1.ConditionTrue,
+			ObservedGeneration: generation,
+			Reason:             "UpgradeRolledBack",
+			Message:            meta.FindStatusCondition(status.Conditions, mmv1beta.UpgradeFailedCondition).Message,
+		})
+	case degraded:
+		setStatusCondition(status, metav1.Condition{
+			Type:               mmv1beta.DegradedCondition,
+			Status:             metav1.ConditionTrue,
+			ObservedGeneration: generation,
+			Reason:             "HealthCheckFailed",
+			Message:            healthErr.Error(),
+		})
+	default:
+		setStatusCondition(status, metav1.Condition{
+			Type:               mmv1beta.DegradedCondition,
+			Status:             metav1.ConditionFalse,
+			ObservedGeneration: generation,
+			Reason:             "Healthy",
+			Message:            "The Mattermost is not degraded",
+		})
+	}
+
+	if reconcileErr != nil {
+		setStatusCondition(status, metav1.Condition{
+			Type:               mmv1beta.ErrorCondition,
+			Status:             metav1.ConditionTrue,
+			ObservedGeneration: generation,
+			Reason:             "ReconcileFailed",
+			Message:            reconcileErr.Error(),
+		})
+	} else {
+		setStatusCondition(status, metav1.Condition{
+			Type:               mmv1beta.ErrorCondition,
+			Status:             metav1.ConditionFalse,
+			ObservedGeneration: generation,
+			Reason:             "ReconcileSucceeded",
+			Message:            "The last reconciliation succeeded",
+		})
+	}
+
+	setKStatusConditions(status, generation, reconcileErr)
+	setLastError(status)
+}
+
+// setKStatusConditions sets the abnormal-true conditions read by kstatus: the
+// Reconciling condition mirrors the Progressing condition, and the Stalled
+// condition reports the reconciliation errors and the rolled back upgrades.
+func setKStatusConditions(status *mmv1beta.MattermostStatus, generation int64, reconcileErr error) {
+	progressing := meta.FindStatusCondition(status.Conditions, mmv1beta.ProgressingCondition)
+	setStatusCondition(status, metav1.Condition{
+		Type:               mmv1beta.ReconcilingCondition,
+		Status:             progressing.Status,
+		ObservedGeneration: generation,
+		Reason:             progressing.Reason,
+		Message:            progressing.Message,
+	})
+
+	switch {
+	case reconcileErr != nil:
+		setStatusCondition(status, metav1.Condition{
+			Type:               mmv1beta.StalledCondition,
+			Status:             metav1.ConditionTrue,
+			ObservedGeneration: generation,
+			Reason:             "ReconcileFailed",
+			Message:            reconcileErr.Error(),
+		})
+	case meta.IsStatusConditionTrue(status.Conditions, mmv1beta.UpgradeFailedCondition):
+		setStatusCondition(status, metav1.Condition{
+			Type:               mmv1beta.StalledCondition,
+			Status:             metav1.ConditionTrue,
+			ObservedGeneration: generation,
+			Reason:             "UpgradeRolledBack",
+			Message:            meta.FindStatusCondition(status.Conditions, mmv1beta.UpgradeFailedCondition).Message,
+		})
+	default:
+		setStatusCondition(status, metav1.Condition{
+			Type:               mmv1beta.StalledCondition,
+			Status:             metav1.ConditionFalse,
+			ObservedGeneration: generation,
+			Reason:             "NotStalled",
+			Message:            "The changes of the Mattermost are rolled out",
+		})
+	}
+}
+
+// setLastError reports the error of the Error or the Degraded condition as
+// the last error, which is kept until the Mattermost is stable again.
+func setLastError(status *mmv1beta.MattermostStatus) {
+	condition := meta.FindStatusCondition(status.Conditions, mmv1beta.ErrorCondition)
+	if condition == nil || condition.Status != metav1.ConditionTrue {
+		condition = meta.FindStatusCondition(status.Conditions, mmv1beta.DegradedCondition)
+	}
+	if condition == nil || condition.Status != metav1.ConditionTrue {
+		if status.State == mmv1beta.Stable || status.State == mmv1beta.StandingBy {
+			status.LastError = nil
+		}
+		return
+	}
+
+	lastTransitionTime := condition.LastTransitionTime
+	status.LastError = &mmv1beta.LastErrorStatus{
+		Reason:  condition.Reason,
+		Message: condition.Message,
+		Time:    &lastTransitionTime,
+	}
+}
+
+// conditionTrueAtGeneration returns true if the condition is true and was
+// observed at the generation.
+func conditionTrueAtGeneration(conditions []metav1.Condition, conditionType string, generation int64) bool {
+	condition := meta.FindStatusCondition(conditions, conditionType)
+	return condition != nil && condition.Status == metav1.ConditionTrue && condition.ObservedGeneration == generation
+}
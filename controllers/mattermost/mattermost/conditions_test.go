@@ -0,0 +1,145 @@
+package mattermost
+
+import (
+	"errors"
+	"testing"
+
+	mmv1beta "github.com/mattermost/mattermost-operator/apis/mattermost/v1beta1"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func TestSetStateConditions(t *testing.T) {
+	assertCondition := func(t *testing.T, status mmv1beta.MattermostStatus, conditionType string, conditionStatus metav1.ConditionStatus, reason string) {
+		condition := meta.FindStatusCondition(status.Conditions, conditionType)
+		require.NotNil(t, condition, conditionType)
+		assert.Equal(t, conditionStatus, condition.Status, conditionType)
+		assert.Equal(t, reason, condition.Reason, conditionType)
+		assert.Equal(t, int64(2), condition.ObservedGeneration, conditionType)
+	}
+
+	t.Run("stable", func(t *testing.T) {
+		status := mmv1beta.MattermostStatus{State: mmv1beta.Stable}
+		setStateConditions(&status, 2, nil, nil)
+
+		assertCondition(t, status, mmv1beta.ReadyCondition, metav1.ConditionTrue, "Stable")
+		assertCondition(t, status, mmv1beta.ProgressingCondition, metav1.ConditionFalse, "Stable")
+		assertCondition(t, status, mmv1beta.DegradedCondition, metav1.ConditionFalse, "Healthy")
+		assertCondition(t, status, mmv1beta.ErrorCondition, metav1.ConditionFalse, "ReconcileSucceeded")
+		assertCondition(t, status, mmv1beta.ReconcilingCondition, metav1.ConditionFalse, "Stable")
+		assertCondition(t, status, mmv1beta.StalledCondition, metav1.ConditionFalse, "NotStalled")
+	})
+
+	t.Run("rolling out", func(t *testing.T) {
+		status := mmv1beta.MattermostStatus{State: mmv1beta.Reconciling}
+		setStateConditions(&status, 2, errors.New("found 1 updated replicas, but wanted 2"), nil)
+
+		assertCondition(t, status, mmv1beta.ReadyCondition, metav1.ConditionFalse, "Reconciling")
+		assertCondition(t, status, mmv1beta.ProgressingCondition, metav1.ConditionTrue, "Reconciling")
+		assertCondition(t, status, mmv1beta.DegradedCondition, metav1.ConditionFalse, "Healthy")
+		assertCondition(t, status, mmv1beta.ErrorCondition, metav1.ConditionFalse, "ReconcileSucceeded")
+		assertCondition(t, status, mmv1beta.ReconcilingCondition, metav1.ConditionTrue, "Reconciling")
+		assertCondition(t, status, mmv1beta.StalledCondition, metav1.ConditionFalse, "NotStalled")
+		assert.Equal(t, "found 1 updated replicas, but wanted 2", meta.FindStatusCondition(status.Conditions, mmv1beta.ReadyCondition).Message)
+	})
+
+	t.Run("reconcile error", func(t *testing.T) {
+		status := mmv1beta.MattermostStatus{State: mmv1beta.Reconciling}
+		setStateConditions(&status, 2, nil, errors.New("secret license is missing"))
+
+		assertCondition(t, status, mmv1beta.ReadyCondition, metav1.ConditionFalse, "Reconciling")
+		assertCondition(t, status, mmv1beta.ProgressingCondition, metav1.ConditionFalse, "ReconcileFailed")
+		assertCondition(t, status, mmv1beta.ErrorCondition, metav1.ConditionTrue, "ReconcileFailed")
+		assertCondition(t, status, mmv1beta.ReconcilingCondition, metav1.ConditionFalse, "ReconcileFailed")
+		assertCondition(t, status, mmv1beta.StalledCondition, metav1.ConditionTrue, "ReconcileFailed")
+		assert.Equal(t, "secret license is missing", meta.FindStatusCondition(status.Conditions, mmv1beta.ErrorCondition).Message)
+	})
+
+	t.Run("degraded once healthy with the same generation", func(t *testing.T) {
+		status := mmv1beta.MattermostStatus{State: mmv1beta.Stable}
+		setStateConditions(&status, 2, nil, nil)
+		readySince := meta.FindStatusCondition(status.Conditions, mmv1beta.ReadyCondition).LastTransitionTime
+
+		status.State = mmv1beta.Reconciling
+		setStateConditions(&status, 2, errors.New("found 1 pods, but wanted 2"), nil)
+		assertCondition(t, status, mmv1beta.ReadyCondition, metav1.ConditionFalse, "Reconciling")
+		assertCondition(t, status, mmv1beta.DegradedCondition, metav1.ConditionTrue, "HealthCheckFailed")
+
+		setStateConditions(&status, 2, errors.New("found 1 pods, but wanted 2"), nil)
+		assertCondition(t, status, mmv1beta.DegradedCondition, metav1.ConditionTrue, "HealthCheckFailed")
+
+		status.State = mmv1beta.Stable
+		setStateConditions(&status, 2, nil, nil)
+		assertCondition(t, status, mmv1beta.ReadyCondition, metav1.ConditionTrue, "Stable")
+		assertCondition(t, status, mmv1beta.DegradedCondition, metav1.ConditionFalse, "Healthy")
+		assert.False(t, meta.FindStatusCondition(status.Conditions, mmv1beta.ReadyCondition).LastTransitionTime.Before(&readySince))
+	})
+
+	t.Run("not degraded while rolling out a new generation", func(t *testing.T) {
+		status := mmv1beta.MattermostStatus{State: mmv1beta.Stable}
+		setStateConditions(&status, 1, nil, nil)
+
+		status.State = mmv1beta.Reconciling
+		setStateConditions(&status, 2, errors.New("found 0 updated replicas, but wanted 2"), nil)
+		assertCondition(t, status, mmv1beta.DegradedCondition, metav1.ConditionFalse, "Healthy")
+		assertCondition(t, status, mmv1beta.ProgressingCondition, metav1.ConditionTrue, "Reconciling")
+	})
+
+	t.Run("degraded after upgrade rollback", func(t *testing.T) {
+		status := mmv1beta.MattermostStatus{
+			State: mmv1beta.Stable,
+			Conditions: []metav1.Condition{{
+				Type:    mmv1beta.UpgradeFailedCondition,
+				Status:  metav1.ConditionTrue,
+				Reason:  "HealthCheckTimeout",
+				Message: "upgrade rolled back",
+			}},
+		}
+		setStateConditions(&status, 2, nil, nil)
+
+		assertCondition(t, status, mmv1beta.ReadyCondition, metav1.ConditionTrue, "Stable")
+		assertCondition(t, status, mmv1beta.DegradedCondition, metav1.ConditionTrue, "UpgradeRolledBack")
+		assertCondition(t, status, mmv1beta.StalledCondition, metav1.ConditionTrue, "UpgradeRolledBack")
+		assert.Equal(t, "upgrade rolled back", meta.FindStatusCondition(status.Conditions, mmv1beta.DegradedCondition).Message)
+	})
+}
+
+func TestSetLastError(t *testing.T) {
+	status := mmv1beta.MattermostStatus{State: mmv1beta.Stable}
+	setStateConditions(&status, 2, nil, nil)
+	assert.Nil(t, status.LastError)
+
+	status.State = mmv1beta.Reconciling
+	setStateConditions(&status, 2, nil, errors.New("secret license is missing"))
+	require.NotNil(t, status.LastError)
+	assert.Equal(t, "ReconcileFailed", status.LastError.Reason)
+	assert.Equal(t, "secret license is missing", status.LastError.Message)
+	errorSince := meta.FindStatusCondition(status.Conditions, mmv1beta.ErrorCondition).LastTransitionTime
+	assert.Equal(t, errorSince, *status.LastError.Time)
+
+	t.Run("kept while reconciling", func(t *testing.T) {
+		status := *status.DeepCopy()
+		setStateConditions(&status, 2, errors.New("found 1 pods, but wanted 2"), nil)
+		require.NotNil(t, status.LastError)
+		assert.Equal(t, "ReconcileFailed", status.LastError.Reason)
+	})
+
+	t.Run("reports degraded", func(t *testing.T) {
+		status := mmv1beta.MattermostStatus{State: mmv1beta.Stable}
+		setStateConditions(&status, 2, nil, nil)
+		status.State = mmv1beta.Reconciling
+		setStateConditions(&status, 2, errors.New("found 1 pods, but wanted 2"), nil)
+		require.NotNil(t, status.LastError)
+		assert.Equal(t, "HealthCheckFailed", status.LastError.Reason)
+		assert.Equal(t, "found 1 pods, but wanted 2", status.LastError.Message)
+	})
+
+	t.Run("cleared once stable", func(t *testing.T) {
+		status := *status.DeepCopy()
+		status.State = mmv1beta.Stable
+		setStateConditions(&status, 2, nil, nil)
+		assert.Nil(t, status.LastError)
+	})
+}
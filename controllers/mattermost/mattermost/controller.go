@@ -6,7 +6,20 @@ import (
 	"reflect"
 	"time"
 
+	"github.com/mattermost/mattermost-operator/pkg/logging"
+	mattermostApp "github.com/mattermost/mattermost-operator/pkg/mattermost"
+	"github.com/mattermost/mattermost-operator/pkg/mattermost/announcement"
+	"github.com/mattermost/mattermost-operator/pkg/mattermost/autosizing"
+	"github.com/mattermost/mattermost-operator/pkg/mattermost/clusterstatus"
+	"github.com/mattermost/mattermost-operator/pkg/mattermost/healthcheck"
+	"github.com/mattermost/mattermost-operator/pkg/mattermost/notifications"
+	"github.com/mattermost/mattermost-operator/pkg/mattermost/releases"
+	"github.com/mattermost/mattermost-operator/pkg/namespaceselector"
+	"github.com/mattermost/mattermost-operator/pkg/registry"
 	"github.com/mattermost/mattermost-operator/pkg/resources"
+	"github.com/mattermost/mattermost-operator/pkg/sharding"
+	"github.com/mattermost/mattermost-operator/pkg/tracing"
+	"github.com/mattermost/mattermost-operator/pkg/utils"
 
 	mmv1beta "github.com/mattermost/mattermost-operator/apis/mattermost/v1beta1"
 
@@ -19,12 +32,23 @@ import (
 	networkingv1 "k8s.io/api/networking/v1"
 	k8sErrors "k8s.io/apimachinery/pkg/api/errors"
 	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/client-go/kubernetes"
+	"k8s.io/client-go/tools/record"
 	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/builder"
 	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/controller"
+	"sigs.k8s.io/controller-runtime/pkg/handler"
+	"sigs.k8s.io/controller-runtime/pkg/predicate"
+	"sigs.k8s.io/controller-runtime/pkg/ratelimiter"
 	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+	"sigs.k8s.io/controller-runtime/pkg/source"
 )
 
-const healthCheckRequeueDelay = 6 * time.Second
+const (
+	healthCheckRequeueDelay    = 6 * time.Second
+	importProgressRequeueDelay = 15 * time.Second
+)
 
 // MattermostReconciler reconciles a Mattermost object
 type MattermostReconciler struct {
@@ -35,29 +59,185 @@ type MattermostReconciler struct {
 	MaxReconciling      int
 	RequeueOnLimitDelay time.Duration
 	Resources           *resources.ResourceHelper
+	Recorder            record.EventRecorder
+	// SupportMatrixConfigMap is the 'namespace/name' of the ConfigMap with
+	// the support matrix of Mattermost versions. The default support matrix
+	// is used if empty.
+	SupportMatrixConfigMap string
+	// GlobalDefaultsConfigMap is the 'namespace/name' of the ConfigMap with
+	// the platform-wide defaults of the Mattermosts, applied before the
+	// defaults of the operator. Not applied if empty.
+	GlobalDefaultsConfigMap string
+	// ReleasesFeed provides the Mattermost releases for the available updates
+	// and the automatic upgrades in release channels. Both are disabled if
+	// nil.
+	ReleasesFeed *releases.Feed
+	// Registry resolves the Mattermost image tags to digests and verifies
+	// the image signatures.
+	Registry *registry.Client
+	// ImageRegistry is the default image registry of the Mattermosts,
+	// replacing the registry of the images created by the operator.
+	ImageRegistry string
+	// UtilityImages are the default utility images of the Mattermosts,
+	// replacing the images of the init containers and MinIO clients.
+	UtilityImages mmv1beta.UtilityImages
+	// AutoSizing queries the active users of the Mattermosts sized from
+	// them.
+	AutoSizing ActiveUsersClient
+	// ClusterStatus queries the cluster status of the app servers held
+	// unready until they joined the cluster.
+	ClusterStatus ClusterStatusClient
+	// ApplicationHealth checks that Mattermost reaches its database and
+	// file store every HealthCheckInterval. The checks are disabled if the
+	// interval is 0. The Mattermost is reported HealthDegraded once
+	// HealthFailureThreshold checks failed in a row.
+	ApplicationHealth      ApplicationHealthClient
+	HealthCheckInterval    time.Duration
+	HealthFailureThreshold int32
+	// Announcement sets the announcement banner of the Mattermosts in
+	// maintenance mode.
+	Announcement AnnouncementClient
+	// PodLogs reads the progress of the file store migrations from the
+	// logs of their Job. The progress is not reported if nil.
+	PodLogs PodLogsClient
+	// Notifier sends the upgrades, failures and health degradation of the
+	// Mattermosts to their notification webhook, or to
+	// NotificationWebhookURL if they do not set one.
+	Notifier               Notifier
+	NotificationWebhookURL string
+	// AuditHistoryLimit is the number of spec revisions kept in the audit
+	// ConfigMaps of the Mattermosts. The audit trail is disabled if 0.
+	AuditHistoryLimit int
+	// RevisionHistoryLimit is the number of successfully reconciled
+	// revisions kept to be rolled back to with spec.rollbackTo. The revision
+	// history is disabled if 0.
+	RevisionHistoryLimit int
+	// MaxConcurrentReconciles is the number of Mattermosts reconciled, and
+	// health checked, in parallel, 1 if 0.
+	MaxConcurrentReconciles int
+	// RateLimiter delays the requeues of the failed reconciliations, the
+	// default rate limiter is used if nil.
+	RateLimiter ratelimiter.RateLimiter
+	// Shard is the shard of the Mattermosts reconciled by this replica of
+	// the operator, all of them by default.
+	Shard sharding.Shard
+	// Namespaces selects the namespaces of the Mattermosts reconciled by the
+	// operator, all of them if nil.
+	Namespaces *namespaceselector.Selector
+	// MaxConcurrentUpgrades is the number of Mattermosts whose image is
+	// upgraded at the same time, the other upgrades are queued. Unlimited if
+	// 0.
+	MaxConcurrentUpgrades int
+	// PriorityTierDelay delays the first reconciliation of the Mattermosts
+	// by their priority tier, so that the production tier is reconciled
+	// first once the operator starts. Not delayed if 0.
+	PriorityTierDelay time.Duration
+}
+
+// ActiveUsersClient queries the active users of Mattermost.
+type ActiveUsersClient interface {
+	ActiveUsers(ctx context.Context, url, token string) (int64, error)
+}
+
+// ClusterStatusClient queries the cluster status of Mattermost.
+type ClusterStatusClient interface {
+	Nodes(ctx context.Context, url, token string) ([]clusterstatus.Node, error)
+}
+
+// ApplicationHealthClient checks the connectivity of Mattermost to its
+// database and file store.
+type ApplicationHealthClient interface {
+	ServerStatus(ctx context.Context, url string) (healthcheck.ServerStatus, error)
+}
+
+// AnnouncementClient sets the announcement banner of Mattermost.
+type AnnouncementClient interface {
+	SetBanner(ctx context.Context, url, token string, banner announcement.Banner) error
+}
+
+// PodLogsClient reads the logs of pods.
+type PodLogsClient interface {
+	TailLogs(ctx context.Context, namespace, pod, container string, lines int64) (string, error)
+}
+
+// Notifier sends notifications to incoming webhooks.
+type Notifier interface {
+	Notify(ctx context.Context, url, text string) error
+}
+
+// MattermostReconcilerOptions are the optional settings of the
+// MattermostReconciler, documented on its fields of the same name.
+type MattermostReconcilerOptions struct {
+	SupportMatrixConfigMap  string
+	GlobalDefaultsConfigMap string
+	ReleasesFeed            *releases.Feed
+	ImageRegistry           string
+	UtilityImages           mmv1beta.UtilityImages
+	HealthCheckInterval     time.Duration
+	HealthFailureThreshold  int32
+	NotificationWebhookURL  string
+	AuditHistoryLimit       int
+	RevisionHistoryLimit    int
+	MaxConcurrentReconciles int
+	RateLimiter             ratelimiter.RateLimiter
+	Shard                   sharding.Shard
+	Namespaces              *namespaceselector.Selector
+	MaxConcurrentUpgrades   int
+	PriorityTierDelay       time.Duration
 }
 
-func NewMattermostReconciler(mgr ctrl.Manager, maxReconciling int, requeueOnLimitDelay time.Duration) *MattermostReconciler {
+func NewMattermostReconciler(mgr ctrl.Manager, maxReconciling int, requeueOnLimitDelay time.Duration, options MattermostReconcilerOptions) *MattermostReconciler {
 	return &MattermostReconciler{
-		Client:              mgr.GetClient(),
-		NonCachedAPIReader:  mgr.GetAPIReader(),
-		Log:                 ctrl.Log.WithName("controllers").WithName("Mattermost"),
-		Scheme:              mgr.GetScheme(),
-		MaxReconciling:      maxReconciling,
-		RequeueOnLimitDelay: requeueOnLimitDelay,
-		Resources:           resources.NewResourceHelper(mgr.GetClient(), mgr.GetScheme()),
+		Client:                  mgr.GetClient(),
+		NonCachedAPIReader:      mgr.GetAPIReader(),
+		Log:                     ctrl.Log.WithName("controllers").WithName("Mattermost"),
+		Scheme:                  mgr.GetScheme(),
+		MaxReconciling:          maxReconciling,
+		RequeueOnLimitDelay:     requeueOnLimitDelay,
+		Resources:               resources.NewResourceHelper(mgr.GetClient(), mgr.GetScheme()),
+		Recorder:                mgr.GetEventRecorderFor("mattermost-operator"),
+		SupportMatrixConfigMap:  options.SupportMatrixConfigMap,
+		GlobalDefaultsConfigMap: options.GlobalDefaultsConfigMap,
+		ReleasesFeed:            options.ReleasesFeed,
+		Registry:                registry.NewClient(),
+		ImageRegistry:           options.ImageRegistry,
+		UtilityImages:           options.UtilityImages,
+		AutoSizing:              autosizing.NewClient(),
+		ClusterStatus:           clusterstatus.NewClient(),
+		ApplicationHealth:       healthcheck.NewConnectivityClient(),
+		HealthCheckInterval:     options.HealthCheckInterval,
+		HealthFailureThreshold:  options.HealthFailureThreshold,
+		Announcement:            announcement.NewClient(),
+		PodLogs:                 resources.NewPodLogs(kubernetes.NewForConfigOrDie(mgr.GetConfig())),
+		Notifier:                notifications.NewClient(),
+		NotificationWebhookURL:  options.NotificationWebhookURL,
+		AuditHistoryLimit:       options.AuditHistoryLimit,
+		RevisionHistoryLimit:    options.RevisionHistoryLimit,
+		MaxConcurrentReconciles: options.MaxConcurrentReconciles,
+		RateLimiter:             options.RateLimiter,
+		Shard:                   options.Shard,
+		Namespaces:              options.Namespaces,
+		MaxConcurrentUpgrades:   options.MaxConcurrentUpgrades,
+		PriorityTierDelay:       options.PriorityTierDelay,
 	}
 }
 
 func (r *MattermostReconciler) SetupWithManager(mgr ctrl.Manager) error {
-	return ctrl.NewControllerManagedBy(mgr).
-		For(&mmv1beta.Mattermost{}).
-		Owns(&corev1.Service{}).
-		Owns(&corev1.Secret{}).
-		Owns(&networkingv1.Ingress{}).
-		Owns(&appsv1.Deployment{}).
-		Owns(&batchv1.Job{}).
-		Complete(r)
+	// The updates of the Mattermost status and the resyncs do not trigger
+	// reconciliations, the periodic checks are requeued instead.
+	owned := builder.WithPredicates(utils.OwnedResourcePredicate(mmv1beta.ClusterResourceLabel))
+	blder := ctrl.NewControllerManagedBy(mgr).
+		For(&mmv1beta.Mattermost{}, builder.WithPredicates(utils.SpecChangedPredicate(), r.Shard.Predicate(), r.Namespaces.Predicate(), r.priorityCreatePredicate())).
+		Watches(&source.Kind{Type: &mmv1beta.Mattermost{}}, r.delayedCreateHandler(), builder.WithPredicates(r.Shard.Predicate(), r.Namespaces.Predicate())).
+		Watches(&source.Kind{Type: &mmv1beta.MattermostTemplate{}}, handler.EnqueueRequestsFromMapFunc(r.mattermostsOfTemplate), builder.WithPredicates(predicate.GenerationChangedPredicate{})).
+		Owns(&corev1.Service{}, owned).
+		Owns(&corev1.Secret{}, owned).
+		Owns(&networkingv1.Ingress{}, owned).
+		Owns(&appsv1.Deployment{}, owned).
+		Owns(&batchv1.Job{}, owned).
+		Owns(&mmv1beta.MattermostBackup{}, owned).
+		WithOptions(controller.Options{MaxConcurrentReconciles: r.MaxConcurrentReconciles, RateLimiter: r.RateLimiter})
+	return r.Namespaces.Watch(blder, &mmv1beta.MattermostList{}).Complete(r)
 }
 
 // Reconcile reads the state of the cluster for a Mattermost object and
@@ -67,20 +247,82 @@ func (r *MattermostReconciler) SetupWithManager(mgr ctrl.Manager) error {
 // The Controller will requeue the Request to be processed again if the returned
 // error is non-nil or Result. Requeue is true, otherwise upon completion it will
 // remove the work from the queue.
-func (r *MattermostReconciler) Reconcile(ctx context.Context, request ctrl.Request) (ctrl.Result, error) {
-	reqLogger := r.Log.WithValues("Request.Namespace", request.Namespace, "Request.Name", request.Name)
+func (r *MattermostReconciler) Reconcile(ctx context.Context, request ctrl.Request) (result ctrl.Result, err error) {
+	reqLogger := r.Log.WithValues("installation", request.Name, "namespace", request.Namespace)
 	reqLogger.Info("Reconciling Mattermost")
+	start := time.Now()
+
+	ctx, span := tracing.Start(ctx, "Reconcile",
+		tracing.String("mattermost.namespace", request.Namespace),
+		tracing.String("mattermost.name", request.Name),
+	)
+	defer func() {
+		span.SetAttributes(tracing.Bool("requeue", result.Requeue || result.RequeueAfter > 0))
+		span.Finish(err)
+	}()
 
 	// Fetch the Mattermost.
 	mattermost := &mmv1beta.Mattermost{}
-	err := r.Client.Get(ctx, request.NamespacedName, mattermost)
+	err = r.Client.Get(ctx, request.NamespacedName, mattermost)
 	if err != nil && k8sErrors.IsNotFound(err) {
 		// Request object not found, could have been deleted after reconcile
 		// request. Owned objects are automatically garbage collected.
+		deleteUpdateAvailableMetrics(request.Namespace, request.Name)
+		deleteApplicationHealthMetrics(request.Namespace, request.Name)
+		deleteInstallationMetrics(request.Namespace, request.Name)
 		return reconcile.Result{}, nil
 	} else if err != nil {
 		return reconcile.Result{}, err
 	}
+	// The events of the owned resources are not filtered by shard.
+	if !r.Shard.Owns(mattermost) {
+		reqLogger.V(1).Info("Skipping Mattermost of another shard", "shard", r.Shard.Of(mattermost))
+		return reconcile.Result{}, nil
+	}
+	selected, err := r.Namespaces.Selects(ctx, mattermost.Namespace)
+	if err != nil {
+		return reconcile.Result{}, err
+	} else if !selected {
+		reqLogger.V(1).Info("Skipping Mattermost of a namespace not selected")
+		return reconcile.Result{}, nil
+	}
+	reqLogger = logging.ForObject(reqLogger, mattermost)
+	defer func() {
+		observeReconcile(mattermost, time.Since(start), err)
+		reqLogger.V(1).Info("Reconciled Mattermost", "duration", time.Since(start).String(), "requeueAfter", result.RequeueAfter.String())
+	}()
+
+	// The Mattermosts being deleted are not reconciled, their resources are
+	// kept while their deletion is held by the deletion protection, and
+	// their data is orphaned with the Orphan deletion policy.
+	deleting, err := r.checkDeletionProtection(ctx, mattermost, reqLogger)
+	if err != nil {
+		return reconcile.Result{}, err
+	}
+	err = r.checkDeletionPolicy(ctx, mattermost, deleting, reqLogger)
+	if err != nil || deleting {
+		return reconcile.Result{}, err
+	}
+
+	// Spec changes are audited even while the reconciliation is delayed.
+	err = r.checkAuditTrail(ctx, mattermost, reqLogger)
+	if err != nil {
+		reqLogger.Error(err, "Failed to record the spec revision in the audit trail")
+	}
+
+	// The resources of a paused Mattermost are neither reconciled nor
+	// health checked, it is reconciled again once the annotation is removed.
+	if mattermostApp.IsPaused(mattermost) {
+		err = r.updateStatusPaused(ctx, mattermost, reqLogger)
+		return reconcile.Result{}, err
+	}
+
+	// The resources of a Mattermost being relocated to another namespace
+	// are left as they are until it is deleted.
+	relocating, relocationResult, err := r.checkRelocation(ctx, mattermost, reqLogger)
+	if err != nil || relocating {
+		return relocationResult, err
+	}
 
 	if mattermost.Status.State != mmv1beta.Reconciling {
 		var mmListInstallations mmv1beta.MattermostList
@@ -90,16 +332,17 @@ func (r *MattermostReconciler) Reconcile(ctx context.Context, request ctrl.Reque
 		}
 
 		// Check if limit of Mattermosts reconciling at the same time is reached.
-		if countReconciling(mmListInstallations.Items) >= r.MaxReconciling {
-			reqLogger.Info(fmt.Sprintf("Reached limit of reconciling installations, requeuing in %s", r.RequeueOnLimitDelay.String()))
-			return ctrl.Result{RequeueAfter: r.RequeueOnLimitDelay}, nil
+		if countReconciling(mmListInstallations.Items, r.Shard) >= r.MaxReconciling {
+			requeueDelay := r.limitRequeueDelay(mattermost, reqLogger)
+			reqLogger.Info(fmt.Sprintf("Reached limit of reconciling installations, requeuing in %s", requeueDelay.String()))
+			return ctrl.Result{RequeueAfter: requeueDelay}, nil
 		}
 	}
 
 	// We copy status to not to refetch the resource
 	status := mattermost.Status
-	// Indicate that the newest generation of the resource has been observed.
-	status.ObservedGeneration = mattermost.Generation
+	setPausedCondition(&status, mattermost.Generation, false)
+	r.checkPromotion(mattermost, &status, reqLogger)
 
 	// Set a new Mattermost's state to reconciling.
 	if len(mattermost.Status.State) == 0 {
@@ -111,46 +354,277 @@ func (r *MattermostReconciler) Reconcile(ctx context.Context, request ctrl.Reque
 
 	// Set defaults and update the resource with said defaults if anything is
 	// different.
+	step := startPhase(ctx, reqLogger, "ApplyDefaults")
 	originalMattermost := mattermost.DeepCopy()
+	rolledBackTo, err := r.checkRollbackTo(ctx, mattermost, reqLogger)
+	if err != nil {
+		step.Finish(err)
+		r.updateStatusReconcilingAndLogError(mattermost, status, err, reqLogger)
+		return reconcile.Result{}, err
+	}
+	// The template is merged in memory on every reconciliation and never
+	// stored, so that its changes reach the installations referencing it.
+	var unmerged *mmv1beta.Mattermost
+	if mattermost.Spec.TemplateRef != "" {
+		unmerged = mattermost.DeepCopy()
+	}
+	err = r.applyTemplate(ctx, mattermost)
+	if err != nil {
+		step.Finish(err)
+		r.updateStatusReconcilingAndLogError(mattermost, status, err, reqLogger)
+		return reconcile.Result{}, err
+	}
+	defaults, err := r.globalDefaults(ctx)
+	if err != nil {
+		step.Finish(err)
+		r.updateStatusReconcilingAndLogError(mattermost, status, err, reqLogger)
+		return reconcile.Result{}, err
+	}
+	if defaults != nil {
+		defaults.Apply(mattermost)
+	}
+	if mattermost.Spec.ImageRegistry == "" {
+		mattermost.Spec.ImageRegistry = r.ImageRegistry
+	}
+	mattermost.SetUtilityImageDefaults(r.UtilityImages)
 	err = mattermost.SetDefaults()
 	if err != nil {
-		r.updateStatusReconcilingAndLogError(mattermost, status, reqLogger)
+		step.Finish(err)
+		r.updateStatusReconcilingAndLogError(mattermost, status, err, reqLogger)
 		return reconcile.Result{}, err
 	}
 
+	status.AutoSizing = r.checkAutoSizing(ctx, mattermost, reqLogger)
+
 	softError := mattermost.SetReplicasAndResourcesFromSize()
 	if softError != nil {
 		reqLogger.Error(softError, "Error setting replicas and resources from size. Using default values")
 	}
 
-	if !reflect.DeepEqual(originalMattermost.Spec, mattermost.Spec) {
-		mattermost.Status = status
-		err = r.updateSpec(ctx, reqLogger, originalMattermost, mattermost)
+	// The defaults are only applied in memory for the Mattermosts with the
+	// store defaults annotation set to "false" or referencing a template, the
+	// restored revisions are still stored, without the template.
+	stored := mattermost
+	if unmerged != nil {
+		stored = unmerged
+	}
+	storeSpec := (mattermost.StoreDefaults() && unmerged == nil) || rolledBackTo != nil
+	if storeSpec && !reflect.DeepEqual(originalMattermost.Spec, stored.Spec) {
+		stored.Status = status
+		err = r.updateSpec(ctx, reqLogger, originalMattermost, stored)
+		if err != nil {
+			step.Finish(err)
+			r.updateStatusReconcilingAndLogError(originalMattermost, status, err, reqLogger)
+			return reconcile.Result{}, err
+		}
+		mattermost.ResourceVersion = stored.ResourceVersion
+		if rolledBackTo != nil {
+			r.recordEvent(mattermost, corev1.EventTypeNormal, "RolledBack", fmt.Sprintf("Restored the settings of revision %d", rolledBackTo.Number))
+		} else {
+			r.Recorder.Event(mattermost, corev1.EventTypeNormal, "DefaultsApplied", "Stored the defaults and the size in the spec")
+		}
+	}
+	step.Finish(nil)
+
+	// The size chosen by auto-sizing is applied after the spec is stored,
+	// the stored spec keeps the replicas and resources set by the user.
+	softError = mattermost.SetReplicasAndResourcesFromAutoSize(status.AutoSizing)
+	if softError != nil {
+		reqLogger.Error(softError, "Error setting replicas and resources from auto-sizing. Using spec values")
+	}
+
+	status.AvailableUpdate = r.checkAvailableUpdate(ctx, mattermost, reqLogger)
+
+	status.ChannelUpdate, err = r.checkUpdateChannel(ctx, mattermost, reqLogger)
+	if err != nil {
+		r.updateStatusReconcilingAndLogError(mattermost, status, err, reqLogger)
+		return reconcile.Result{}, err
+	}
+
+	err = r.checkVersionSupport(mattermost, &status, reqLogger)
+	if err != nil {
+		r.updateStatusReconcilingAndLogError(mattermost, status, err, reqLogger)
+		return reconcile.Result{}, err
+	}
+
+	status.ImageVerification, err = r.checkImageVerification(ctx, mattermost, reqLogger)
+	if err != nil {
+		r.updateStatusReconcilingAndLogError(mattermost, status, err, reqLogger)
+		return reconcile.Result{}, err
+	}
+
+	// The Mattermost image is pinned to the resolved digest once the digest
+	// is stored in the status.
+	if !reflect.DeepEqual(mattermost.Status.ImageVerification, status.ImageVerification) {
+		err = r.updateStatusReconciling(mattermost, status, reqLogger)
 		if err != nil {
-			r.updateStatusReconcilingAndLogError(originalMattermost, status, reqLogger)
 			return reconcile.Result{}, err
 		}
+		return reconcile.Result{Requeue: true}, nil
 	}
 
+	step = startPhase(ctx, reqLogger, "CheckDatabase", tracing.Bool("external", mattermost.Spec.Database.IsExternal()))
 	dbConfig, err := r.checkDatabase(mattermost, reqLogger)
+	step.Finish(err)
 	if err != nil {
-		r.updateStatusReconcilingAndLogError(mattermost, status, reqLogger)
+		r.updateStatusReconcilingAndLogError(mattermost, status, err, reqLogger)
 		return reconcile.Result{}, err
 	}
 
+	step = startPhase(ctx, reqLogger, "CheckFileStore", tracing.Bool("external", mattermost.Spec.FileStore.IsExternal()))
 	fileStoreConfig, err := r.checkFileStore(mattermost, reqLogger)
+	step.Finish(err)
+	if err != nil {
+		r.updateStatusReconcilingAndLogError(mattermost, status, err, reqLogger)
+		return reconcile.Result{}, err
+	}
+
+	// A standby Mattermost only keeps its idle resources up to date, the
+	// database and file store it shares with the active installation are
+	// left to it.
+	if mattermost.StandbyEnabled() {
+		return r.checkStandby(ctx, mattermost, status, dbConfig, fileStoreConfig, reqLogger)
+	}
+
+	migrating := mattermost.Spec.FileStore.MigrateTo != nil
+	status.FileStoreMigration, err = r.checkFileStoreMigration(mattermost, fileStoreConfig, reqLogger)
+	if err != nil {
+		r.updateStatusReconcilingAndLogError(mattermost, status, err, reqLogger)
+		return reconcile.Result{}, err
+	}
+
+	// The resources are reconciled against the new file store once
+	// Mattermost is switched to it, not the file store checked above.
+	if migrating && mattermost.Spec.FileStore.MigrateTo == nil {
+		err = r.updateStatusReconciling(mattermost, status, reqLogger)
+		if err != nil {
+			return reconcile.Result{}, err
+		}
+		return reconcile.Result{Requeue: true}, nil
+	}
+
+	status.VolumeResizes, err = r.checkVolumeExpansion(mattermost, reqLogger)
+	if err != nil {
+		r.updateStatusReconcilingAndLogError(mattermost, status, err, reqLogger)
+		return reconcile.Result{}, err
+	}
+
+	err = r.checkVeleroVolumes(mattermost, reqLogger)
 	if err != nil {
-		r.updateStatusReconcilingAndLogError(mattermost, status, reqLogger)
+		r.updateStatusReconcilingAndLogError(mattermost, status, err, reqLogger)
 		return reconcile.Result{}, err
 	}
 
+	status.PendingUpdate, err = r.checkUpdateWindow(mattermost, dbConfig, fileStoreConfig, reqLogger)
+	if err != nil {
+		r.updateStatusReconcilingAndLogError(mattermost, status, err, reqLogger)
+		return reconcile.Result{}, err
+	}
+
+	// Upgrades queued until the maintenance window are prepared and tracked
+	// once the window opens.
+	if status.PendingUpdate == nil {
+		status.UpgradeSnapshots, err = r.checkUpgradeSnapshots(mattermost, reqLogger)
+		if err != nil {
+			r.updateStatusReconcilingAndLogError(mattermost, status, err, reqLogger)
+			return reconcile.Result{}, err
+		}
+
+		status.PreUpgradeBackup, err = r.checkPreUpgradeBackup(mattermost, reqLogger)
+		if err != nil {
+			r.updateStatusReconcilingAndLogError(mattermost, status, err, reqLogger)
+			return reconcile.Result{}, err
+		}
+
+		status.Upgrade, err = r.checkUpgrade(mattermost, reqLogger)
+		if err != nil {
+			r.updateStatusReconcilingAndLogError(mattermost, status, err, reqLogger)
+			return reconcile.Result{}, err
+		}
+	}
+
+	// The announcement banner is set before the pods are restarted with a
+	// read-only database.
+	status.MaintenanceMode = r.checkMaintenanceMode(ctx, mattermost, reqLogger)
+
+	step = startPhase(ctx, reqLogger, "ApplyResources")
 	err = r.checkMattermost(mattermost, dbConfig, fileStoreConfig, reqLogger)
+	if err == nil {
+		err = r.checkBlueGreen(mattermost, dbConfig, fileStoreConfig, reqLogger)
+	}
+	if err == nil {
+		err = r.checkCanary(mattermost, dbConfig, fileStoreConfig, reqLogger)
+	}
+	step.Finish(err)
 	if err != nil {
-		r.updateStatusReconcilingAndLogError(mattermost, status, reqLogger)
+		r.updateStatusReconcilingAndLogError(mattermost, status, err, reqLogger)
 		return reconcile.Result{}, err
 	}
 
-	status, err = r.checkMattermostHealth(mattermost, reqLogger)
+	step = startPhase(ctx, reqLogger, "CheckClusterReadiness")
+	err = r.checkClusterReadiness(ctx, mattermost, reqLogger)
+	step.Finish(err)
+	if err != nil {
+		r.updateStatusReconcilingAndLogError(mattermost, status, err, reqLogger)
+		return reconcile.Result{}, err
+	}
+
+	status.Export, err = r.checkExport(mattermost, dbConfig, fileStoreConfig, reqLogger)
+	if err != nil {
+		r.updateStatusReconcilingAndLogError(mattermost, status, err, reqLogger)
+		return reconcile.Result{}, err
+	}
+
+	status.Import, err = r.checkImport(mattermost, dbConfig, fileStoreConfig, reqLogger)
+	if err != nil {
+		r.updateStatusReconcilingAndLogError(mattermost, status, err, reqLogger)
+		return reconcile.Result{}, err
+	}
+
+	status.Components, err = r.checkComponents(mattermost)
+	if err != nil {
+		r.updateStatusReconcilingAndLogError(mattermost, status, err, reqLogger)
+		return reconcile.Result{}, err
+	}
+
+	// The health check builds a new status, the results of the previous
+	// checks are carried over.
+	checksStatus := status
+	step = startPhase(ctx, reqLogger, "CheckHealth")
+	status, err = r.checkMattermostHealth(mattermost, checksStatus.PendingUpdate, reqLogger)
+	status.FileStoreMigration = checksStatus.FileStoreMigration
+	status.VolumeResizes = checksStatus.VolumeResizes
+	status.UpgradeSnapshots = checksStatus.UpgradeSnapshots
+	status.Export = checksStatus.Export
+	status.Import = checksStatus.Import
+	status.Relocation = checksStatus.Relocation
+	status.MaintenanceMode = checksStatus.MaintenanceMode
+	status.Upgrade = checksStatus.Upgrade
+	status.PendingUpdate = checksStatus.PendingUpdate
+	status.ChannelUpdate = checksStatus.ChannelUpdate
+	status.AvailableUpdate = checksStatus.AvailableUpdate
+	status.AutoSizing = checksStatus.AutoSizing
+	status.ImageVerification = checksStatus.ImageVerification
+	status.PreUpgradeBackup = checksStatus.PreUpgradeBackup
+	status.PostUpgradeChecks = checksStatus.PostUpgradeChecks
+	status.Conditions = checksStatus.Conditions
+	status.LastError = checksStatus.LastError
+	status.Components = checksStatus.Components
+	status.ApplicationHealth = checksStatus.ApplicationHealth
+	status.Revision = checksStatus.Revision
+	r.checkApplicationHealth(ctx, mattermost, &status, reqLogger)
+	r.checkSelfHealing(ctx, mattermost, &status, reqLogger)
+	r.checkPodProblems(mattermost, &status, reqLogger)
+	if err == nil {
+		status.PostUpgradeChecks, err = r.checkPostUpgradeChecks(mattermost, status, reqLogger)
+		if err != nil {
+			status.State = mmv1beta.Reconciling
+		}
+	}
+	checkUpgradeHealth(mattermost, &status, err, reqLogger)
+	setStateConditions(&status, mattermost.Generation, err, nil)
+	step.SetAttributes(tracing.String("state", string(status.State)))
+	step.Finish(err)
 	if err != nil {
 		statusErr := r.updateStatus(mattermost, status, reqLogger)
 		if statusErr != nil {
@@ -160,13 +634,69 @@ func (r *MattermostReconciler) Reconcile(ctx context.Context, request ctrl.Reque
 		return reconcile.Result{RequeueAfter: healthCheckRequeueDelay}, nil
 	}
 
+	if status.State == mmv1beta.Stable {
+		status.Revision, err = r.checkRevisionHistory(ctx, mattermost, status.Revision, reqLogger)
+		if err != nil {
+			reqLogger.Error(err, "Failed to record the revision in the revision history")
+		}
+	}
+
+	step = startPhase(ctx, reqLogger, "UpdateStatus")
 	err = r.updateStatus(mattermost, status, reqLogger)
+	step.Finish(err)
 	if err != nil {
-		r.updateStatusReconcilingAndLogError(mattermost, status, reqLogger)
+		r.updateStatusReconcilingAndLogError(mattermost, status, err, reqLogger)
 		return reconcile.Result{}, err
 	}
 
-	return reconcile.Result{}, nil
+	// Progress of the import is not reflected in the Job status.
+	if status.Import != nil && status.Import.State != mmv1beta.ImportCompleted && status.Import.State != mmv1beta.ImportFailed {
+		return reconcile.Result{RequeueAfter: importProgressRequeueDelay}, nil
+	}
+
+	// The failed updates of the announcement banner are retried, ie once the
+	// pods no longer run with a read-only database.
+	if status.MaintenanceMode != nil && status.MaintenanceMode.Error != "" {
+		return reconcile.Result{RequeueAfter: maintenanceBannerRequeueDelay}, nil
+	}
+
+	// Queued changes are applied once the maintenance window opens.
+	if status.PendingUpdate != nil && status.PendingUpdate.NextWindow != nil {
+		return reconcile.Result{RequeueAfter: time.Until(status.PendingUpdate.NextWindow.Time)}, nil
+	}
+
+	// Queued upgrades are retried until an upgrade slot is free.
+	if status.PendingUpdate != nil && status.PendingUpdate.Reason == mmv1beta.PendingUpdateWaitingForUpgradeSlot {
+		return reconcile.Result{RequeueAfter: r.RequeueOnLimitDelay}, nil
+	}
+
+	// Available updates, and new versions in the release channel, are
+	// checked once the releases feed is refreshed, the active users once the
+	// auto-sizing check interval elapsed, the application health once the
+	// health check interval elapsed.
+	var requeueAfter time.Duration
+	if r.ReleasesFeed != nil {
+		requeueAfter = r.ReleasesFeed.RefreshInterval()
+	}
+	if mattermost.AutoSizingEnabled() {
+		if interval := mattermost.Spec.AutoSizing.GetCheckInterval(); requeueAfter == 0 || interval < requeueAfter {
+			requeueAfter = interval
+		}
+	}
+	if interval := r.HealthCheckInterval; interval > 0 && (requeueAfter == 0 || interval < requeueAfter) {
+		requeueAfter = interval
+	}
+
+	// The reconcile interval annotation overrides the periodic
+	// reconciliations, slowing down the ones of stable installations.
+	interval, intervalErr := mattermostApp.ReconcileInterval(mattermost)
+	if intervalErr != nil {
+		reqLogger.Error(intervalErr, "Ignoring the reconcile interval annotation", "annotation", mattermostApp.ReconcileIntervalAnnotation)
+	} else if interval > 0 {
+		requeueAfter = interval
+	}
+
+	return reconcile.Result{RequeueAfter: requeueAfter}, nil
 }
 
 func (r *MattermostReconciler) updateSpec(ctx context.Context, reqLogger logr.Logger, originalMattermost *mmv1beta.Mattermost, updated *mmv1beta.Mattermost) error {
@@ -177,10 +707,18 @@ func (r *MattermostReconciler) updateSpec(ctx context.Context, reqLogger logr.Lo
 	return r.Client.Update(ctx, updated)
 }
 
-func countReconciling(mattermosts []mmv1beta.Mattermost) int {
+// startPhase starts the span of a phase of the reconciliation and logs it at
+// the debug level.
+func startPhase(ctx context.Context, reqLogger logr.Logger, phase string, attributes ...tracing.Attribute) *tracing.Span {
+	reqLogger.V(1).Info("Starting reconciliation phase", "phase", phase)
+	_, span := tracing.Start(ctx, phase, attributes...)
+	return span
+}
+
+func countReconciling(mattermosts []mmv1beta.Mattermost, shard sharding.Shard) int {
 	sum := 0
 	for _, ci := range mattermosts {
-		if ci.Status.State == mmv1beta.Reconciling {
+		if ci.Status.State == mmv1beta.Reconciling && shard.Owns(&ci) {
 			sum++
 		}
 	}
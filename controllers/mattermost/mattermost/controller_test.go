@@ -6,6 +6,8 @@ import (
 	"time"
 
 	"github.com/mattermost/mattermost-operator/pkg/resources"
+	resourcesfake "github.com/mattermost/mattermost-operator/pkg/resources/fake"
+	"github.com/mattermost/mattermost-operator/pkg/sharding"
 
 	mmv1beta "github.com/mattermost/mattermost-operator/apis/mattermost/v1beta1"
 
@@ -19,6 +21,7 @@ import (
 	"k8s.io/apimachinery/pkg/runtime"
 	"k8s.io/apimachinery/pkg/types"
 	"k8s.io/client-go/kubernetes/scheme"
+	"k8s.io/client-go/tools/record"
 	"sigs.k8s.io/controller-runtime/pkg/client"
 	"sigs.k8s.io/controller-runtime/pkg/client/fake"
 	logf "sigs.k8s.io/controller-runtime/pkg/log"
@@ -70,7 +73,8 @@ func TestReconcile(t *testing.T) {
 		Scheme:             s,
 		Log:                logger,
 		MaxReconciling:     5,
-		Resources:          resources.NewResourceHelper(c, s),
+		Resources:          resources.NewResourceHelper(resourcesfake.NewApplyClient(c), s),
+		Recorder:           record.NewFakeRecorder(100),
 	}
 
 	err := c.Create(context.TODO(), mm)
@@ -322,7 +326,8 @@ func TestReconcilingLimit(t *testing.T) {
 		Log:                 logger,
 		MaxReconciling:      2,
 		RequeueOnLimitDelay: requeueOnLimitDelay,
-		Resources:           resources.NewResourceHelper(c, s),
+		Resources:           resources.NewResourceHelper(resourcesfake.NewApplyClient(c), s),
+		Recorder:            record.NewFakeRecorder(100),
 	}
 
 	assertInstallationsCount := func(t *testing.T, expectedCIs, expectedReconciling int) {
@@ -331,7 +336,7 @@ func TestReconcilingLimit(t *testing.T) {
 		require.NoError(t, err)
 
 		assert.Equal(t, expectedCIs, len(mmList.Items))
-		assert.Equal(t, expectedReconciling, countReconciling(mmList.Items))
+		assert.Equal(t, expectedReconciling, countReconciling(mmList.Items, sharding.Shard{}))
 	}
 
 	err := c.Create(context.TODO(), mm1)
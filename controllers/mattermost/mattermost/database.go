@@ -16,7 +16,7 @@ import (
 )
 
 func (r *MattermostReconciler) checkDatabase(mattermost *mmv1beta.Mattermost, reqLogger logr.Logger) (mattermostApp.DatabaseConfig, error) {
-	reqLogger = reqLogger.WithValues("Reconcile", "database")
+	reqLogger = reqLogger.WithValues("phase", "database")
 
 	if mattermost.Spec.Database.IsExternal() {
 		return r.readExternalDBSecret(mattermost)
@@ -53,7 +53,7 @@ func (r *MattermostReconciler) checkOperatorManagedDB(mattermost *mmv1beta.Matte
 }
 
 func (r *MattermostReconciler) checkOperatorManagedMySQL(mattermost *mmv1beta.Mattermost, reqLogger logr.Logger) (mattermostApp.DatabaseConfig, error) {
-	reqLogger = reqLogger.WithValues("Reconcile", "mysql")
+	reqLogger = reqLogger.WithValues("phase", "mysql")
 
 	err := r.checkMySQLCluster(mattermost, reqLogger)
 	if err != nil {
@@ -83,5 +83,5 @@ func (r *MattermostReconciler) checkMySQLCluster(mattermost *mmv1beta.Mattermost
 		return err
 	}
 
-	return r.Resources.Update(current, desired, reqLogger)
+	return r.updateResource(mattermost, current, desired, reqLogger)
 }
@@ -0,0 +1,124 @@
+package mattermost
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/go-logr/logr"
+	mmv1beta "github.com/mattermost/mattermost-operator/apis/mattermost/v1beta1"
+	mattermostMinio "github.com/mattermost/mattermost-operator/pkg/components/minio"
+	mattermostmysql "github.com/mattermost/mattermost-operator/pkg/components/mysql"
+	minioOperator "github.com/minio/minio-operator/pkg/apis/miniocontroller/v1beta1"
+	"github.com/pkg/errors"
+	mysqlOperator "github.com/presslabs/mysql-operator/pkg/apis/mysql/v1alpha1"
+	corev1 "k8s.io/api/core/v1"
+	k8sErrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/controller/controllerutil"
+)
+
+// checkDeletionPolicy adds the orphan resources finalizer to the Mattermost
+// whose data is orphaned once it is deleted, and removes it once its
+// deletion policy changes. Once the Mattermost is deleted, and its deletion
+// is no longer held by the deletion protection, the owner references to it
+// are removed from its data resources before the finalizer is removed, so
+// that they are not garbage collected.
+func (r *MattermostReconciler) checkDeletionPolicy(ctx context.Context, mattermost *mmv1beta.Mattermost, deleting bool, reqLogger logr.Logger) error {
+	orphan := mattermost.Spec.DeletionPolicy == mmv1beta.DeletionPolicyOrphan
+	finalized := controllerutil.ContainsFinalizer(mattermost, mmv1beta.OrphanResourcesFinalizer)
+
+	switch {
+	case !deleting && orphan && !finalized:
+		reqLogger.Info("Adding the orphan resources finalizer")
+		patch := client.MergeFrom(mattermost.DeepCopy())
+		controllerutil.AddFinalizer(mattermost, mmv1beta.OrphanResourcesFinalizer)
+		err := r.Client.Patch(ctx, mattermost, patch)
+		if err != nil {
+			return errors.Wrap(err, "failed to add the orphan resources finalizer")
+		}
+		return nil
+	case !finalized:
+		return nil
+	case deleting && controllerutil.ContainsFinalizer(mattermost, mmv1beta.DeletionProtectionFinalizer):
+		return nil
+	case deleting && orphan:
+		err := r.orphanResources(ctx, mattermost, reqLogger)
+		if err != nil {
+			return err
+		}
+	case orphan:
+		return nil
+	}
+
+	reqLogger.Info("Removing the orphan resources finalizer")
+	patch := client.MergeFrom(mattermost.DeepCopy())
+	controllerutil.RemoveFinalizer(mattermost, mmv1beta.OrphanResourcesFinalizer)
+	err := r.Client.Patch(ctx, mattermost, patch)
+	if err != nil {
+		return errors.Wrap(err, "failed to remove the orphan resources finalizer")
+	}
+	return nil
+}
+
+// orphanResources removes the owner references to the Mattermost from its
+// data resources, and annotates them as orphaned so that they are not swept.
+func (r *MattermostReconciler) orphanResources(ctx context.Context, mattermost *mmv1beta.Mattermost, reqLogger logr.Logger) error {
+	for _, obj := range dataResources(mattermost) {
+		err := r.Client.Get(ctx, types.NamespacedName{Namespace: obj.GetNamespace(), Name: obj.GetName()}, obj)
+		if err != nil && k8sErrors.IsNotFound(err) {
+			continue
+		} else if err != nil {
+			return errors.Wrapf(err, "failed to get %T %s", obj, obj.GetName())
+		}
+
+		var owners []metav1.OwnerReference
+		for _, owner := range obj.GetOwnerReferences() {
+			if owner.UID != mattermost.UID {
+				owners = append(owners, owner)
+			}
+		}
+		if len(owners) == len(obj.GetOwnerReferences()) {
+			continue
+		}
+
+		reqLogger.Info(fmt.Sprintf("Orphaning %T %s", obj, obj.GetName()))
+		patch := client.MergeFrom(obj.DeepCopyObject().(client.Object))
+		obj.SetOwnerReferences(owners)
+		annotations := obj.GetAnnotations()
+		if annotations == nil {
+			annotations = map[string]string{}
+		}
+		annotations[mmv1beta.OrphanedAnnotation] = "true"
+		obj.SetAnnotations(annotations)
+		err = r.Client.Patch(ctx, obj, patch)
+		if err != nil {
+			return errors.Wrapf(err, "failed to orphan %T %s", obj, obj.GetName())
+		}
+	}
+	return nil
+}
+
+// dataResources returns the resources holding the data of the Mattermost:
+// the operator managed database and file store, and their Secrets. Their
+// volumes are kept as long as they are.
+func dataResources(mattermost *mmv1beta.Mattermost) []client.Object {
+	var objects []client.Object
+
+	if !mattermost.Spec.Database.IsExternal() && mattermost.Spec.Database.OperatorManaged.Type == "mysql" {
+		objects = append(objects,
+			&mysqlOperator.MysqlCluster{ObjectMeta: metav1.ObjectMeta{Namespace: mattermost.Namespace, Name: mattermostmysql.ClusterName(mattermost.Name)}},
+			&corev1.Secret{ObjectMeta: metav1.ObjectMeta{Namespace: mattermost.Namespace, Name: mattermostmysql.DefaultDatabaseSecretName(mattermost.Name)}},
+		)
+	}
+
+	if !mattermost.Spec.FileStore.IsExternal() {
+		objects = append(objects,
+			&minioOperator.MinIOInstance{ObjectMeta: metav1.ObjectMeta{Namespace: mattermost.Namespace, Name: mattermostMinio.InstanceName(mattermost.Name)}},
+			&corev1.Secret{ObjectMeta: metav1.ObjectMeta{Namespace: mattermost.Namespace, Name: mattermostMinio.DefaultMinioSecretName(mattermost.Name)}},
+		)
+	}
+
+	return objects
+}
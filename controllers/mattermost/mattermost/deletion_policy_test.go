@@ -0,0 +1,111 @@
+package mattermost
+
+import (
+	"context"
+	"testing"
+
+	blubr "github.com/mattermost/blubr"
+	mmv1beta "github.com/mattermost/mattermost-operator/apis/mattermost/v1beta1"
+	mattermostMinio "github.com/mattermost/mattermost-operator/pkg/components/minio"
+	mattermostmysql "github.com/mattermost/mattermost-operator/pkg/components/mysql"
+	mattermostApp "github.com/mattermost/mattermost-operator/pkg/mattermost"
+	minioOperator "github.com/minio/minio-operator/pkg/apis/miniocontroller/v1beta1"
+	mysqlOperator "github.com/presslabs/mysql-operator/pkg/apis/mysql/v1alpha1"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/kubernetes/scheme"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+)
+
+func TestCheckDeletionPolicy(t *testing.T) {
+	s := prepareSchema(t, scheme.Scheme)
+	key := types.NamespacedName{Namespace: "mm-namespace", Name: "mm"}
+
+	newMattermost := func(policy mmv1beta.DeletionPolicy, finalizers ...string) *mmv1beta.Mattermost {
+		mattermost := &mmv1beta.Mattermost{
+			ObjectMeta: metav1.ObjectMeta{Name: key.Name, Namespace: key.Namespace, UID: "mm-uid", Finalizers: finalizers},
+			Spec: mmv1beta.MattermostSpec{
+				DeletionPolicy: policy,
+				Database:       mmv1beta.Database{OperatorManaged: &mmv1beta.OperatorManagedDatabase{Type: "mysql"}},
+			},
+		}
+		return mattermost
+	}
+	getMattermost := func(t *testing.T, c client.Client) *mmv1beta.Mattermost {
+		mattermost := &mmv1beta.Mattermost{}
+		require.NoError(t, c.Get(context.TODO(), key, mattermost))
+		return mattermost
+	}
+
+	t.Run("finalizer added", func(t *testing.T) {
+		mattermost := newMattermost(mmv1beta.DeletionPolicyOrphan)
+		c := fake.NewFakeClientWithScheme(s, mattermost)
+		r := &MattermostReconciler{Client: c, Scheme: s}
+
+		require.NoError(t, r.checkDeletionPolicy(context.TODO(), mattermost, false, blubr.InitLogger()))
+		assert.Equal(t, []string{mmv1beta.OrphanResourcesFinalizer}, getMattermost(t, c).Finalizers)
+	})
+
+	t.Run("policy changed", func(t *testing.T) {
+		mattermost := newMattermost(mmv1beta.DeletionPolicyDelete, mmv1beta.OrphanResourcesFinalizer)
+		c := fake.NewFakeClientWithScheme(s, mattermost)
+		r := &MattermostReconciler{Client: c, Scheme: s}
+
+		require.NoError(t, r.checkDeletionPolicy(context.TODO(), mattermost, false, blubr.InitLogger()))
+		assert.Empty(t, getMattermost(t, c).Finalizers)
+	})
+
+	t.Run("data orphaned", func(t *testing.T) {
+		mattermost := newMattermost(mmv1beta.DeletionPolicyOrphan, mmv1beta.OrphanResourcesFinalizer)
+		now := metav1.Now()
+		mattermost.DeletionTimestamp = &now
+
+		cluster := &mysqlOperator.MysqlCluster{ObjectMeta: metav1.ObjectMeta{
+			Name:            mattermostmysql.ClusterName(mattermost.Name),
+			Namespace:       mattermost.Namespace,
+			OwnerReferences: mattermostApp.MattermostOwnerReference(mattermost),
+		}}
+		minio := &minioOperator.MinIOInstance{ObjectMeta: metav1.ObjectMeta{
+			Name:            mattermostMinio.InstanceName(mattermost.Name),
+			Namespace:       mattermost.Namespace,
+			OwnerReferences: mattermostApp.MattermostOwnerReference(mattermost),
+		}}
+		other := metav1.OwnerReference{APIVersion: "v1", Kind: "ConfigMap", Name: "other", UID: "other-uid"}
+		minioSecret := &corev1.Secret{ObjectMeta: metav1.ObjectMeta{
+			Name:            mattermostMinio.DefaultMinioSecretName(mattermost.Name),
+			Namespace:       mattermost.Namespace,
+			OwnerReferences: append(mattermostApp.MattermostOwnerReference(mattermost), other),
+		}}
+		c := fake.NewFakeClientWithScheme(s, mattermost, cluster, minio, minioSecret)
+		r := &MattermostReconciler{Client: c, Scheme: s}
+
+		require.NoError(t, r.checkDeletionPolicy(context.TODO(), mattermost, true, blubr.InitLogger()))
+		assert.Empty(t, getMattermost(t, c).Finalizers)
+
+		currentCluster := &mysqlOperator.MysqlCluster{}
+		require.NoError(t, c.Get(context.TODO(), types.NamespacedName{Namespace: key.Namespace, Name: cluster.Name}, currentCluster))
+		assert.Empty(t, currentCluster.OwnerReferences)
+		currentMinio := &minioOperator.MinIOInstance{}
+		require.NoError(t, c.Get(context.TODO(), types.NamespacedName{Namespace: key.Namespace, Name: minio.Name}, currentMinio))
+		assert.Empty(t, currentMinio.OwnerReferences)
+		secret := &corev1.Secret{}
+		require.NoError(t, c.Get(context.TODO(), types.NamespacedName{Namespace: minioSecret.Namespace, Name: minioSecret.Name}, secret))
+		assert.Equal(t, []metav1.OwnerReference{other}, secret.OwnerReferences)
+		assert.Equal(t, "true", secret.Annotations[mmv1beta.OrphanedAnnotation])
+	})
+
+	t.Run("deletion held by the deletion protection", func(t *testing.T) {
+		mattermost := newMattermost(mmv1beta.DeletionPolicyOrphan, mmv1beta.DeletionProtectionFinalizer, mmv1beta.OrphanResourcesFinalizer)
+		now := metav1.Now()
+		mattermost.DeletionTimestamp = &now
+		c := fake.NewFakeClientWithScheme(s, mattermost)
+		r := &MattermostReconciler{Client: c, Scheme: s}
+
+		require.NoError(t, r.checkDeletionPolicy(context.TODO(), mattermost, true, blubr.InitLogger()))
+		assert.Contains(t, getMattermost(t, c).Finalizers, mmv1beta.OrphanResourcesFinalizer)
+	})
+}
@@ -0,0 +1,50 @@
+package mattermost
+
+import (
+	"context"
+
+	"github.com/go-logr/logr"
+	mmv1beta "github.com/mattermost/mattermost-operator/apis/mattermost/v1beta1"
+	"github.com/pkg/errors"
+	corev1 "k8s.io/api/core/v1"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/controller/controllerutil"
+)
+
+// checkDeletionProtection adds the deletion protection finalizer to the
+// Mattermost protected from deletion, and removes it once the Mattermost is
+// no longer protected. It returns true if the Mattermost is being deleted,
+// its resources are then left as is.
+//
+// Setting the deletion timestamp of a Mattermost with finalizers changes its
+// generation, which triggers its reconciliation.
+func (r *MattermostReconciler) checkDeletionProtection(ctx context.Context, mattermost *mmv1beta.Mattermost, reqLogger logr.Logger) (bool, error) {
+	deleting := !mattermost.DeletionTimestamp.IsZero()
+	protected := mattermost.DeletionProtected()
+	finalized := controllerutil.ContainsFinalizer(mattermost, mmv1beta.DeletionProtectionFinalizer)
+
+	// Finalizers cannot be added to an object being deleted.
+	if protected && !finalized && !deleting {
+		reqLogger.Info("Adding the deletion protection finalizer")
+		patch := client.MergeFrom(mattermost.DeepCopy())
+		controllerutil.AddFinalizer(mattermost, mmv1beta.DeletionProtectionFinalizer)
+		err := r.Client.Patch(ctx, mattermost, patch)
+		if err != nil {
+			return false, errors.Wrap(err, "failed to add the deletion protection finalizer")
+		}
+	} else if !protected && finalized {
+		reqLogger.Info("Removing the deletion protection finalizer")
+		patch := client.MergeFrom(mattermost.DeepCopy())
+		controllerutil.RemoveFinalizer(mattermost, mmv1beta.DeletionProtectionFinalizer)
+		err := r.Client.Patch(ctx, mattermost, patch)
+		if err != nil {
+			return deleting, errors.Wrap(err, "failed to remove the deletion protection finalizer")
+		}
+	}
+
+	if deleting && protected && finalized {
+		reqLogger.Info("Deletion of the Mattermost held by its deletion protection")
+		r.recordEvent(mattermost, corev1.EventTypeWarning, "DeletionBlocked", "The Mattermost is protected from deletion, set the "+mmv1beta.DeletionProtectedAnnotation+" annotation to false to delete it")
+	}
+	return deleting, nil
+}
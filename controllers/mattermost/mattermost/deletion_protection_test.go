@@ -0,0 +1,77 @@
+package mattermost
+
+import (
+	"context"
+	"testing"
+
+	blubr "github.com/mattermost/blubr"
+	mmv1beta "github.com/mattermost/mattermost-operator/apis/mattermost/v1beta1"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/kubernetes/scheme"
+	"k8s.io/client-go/tools/record"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+)
+
+func TestCheckDeletionProtection(t *testing.T) {
+	s := prepareSchema(t, scheme.Scheme)
+	s.AddKnownTypes(mmv1beta.GroupVersion, &mmv1beta.Mattermost{})
+	key := types.NamespacedName{Namespace: "mm-namespace", Name: "mm"}
+	protected := map[string]string{mmv1beta.DeletionProtectedAnnotation: "true"}
+
+	newReconciler := func(mattermost *mmv1beta.Mattermost) (*MattermostReconciler, *record.FakeRecorder) {
+		recorder := record.NewFakeRecorder(10)
+		c := fake.NewFakeClientWithScheme(s, mattermost)
+		return &MattermostReconciler{Client: c, Scheme: s, Recorder: recorder}, recorder
+	}
+	getMattermost := func(t *testing.T, r *MattermostReconciler) *mmv1beta.Mattermost {
+		mattermost := &mmv1beta.Mattermost{}
+		require.NoError(t, r.Client.Get(context.TODO(), key, mattermost))
+		return mattermost
+	}
+
+	t.Run("finalizer added", func(t *testing.T) {
+		mattermost := &mmv1beta.Mattermost{ObjectMeta: metav1.ObjectMeta{Name: key.Name, Namespace: key.Namespace, Annotations: protected}}
+		r, _ := newReconciler(mattermost)
+
+		deleting, err := r.checkDeletionProtection(context.TODO(), mattermost, blubr.InitLogger())
+		require.NoError(t, err)
+		assert.False(t, deleting)
+		assert.Equal(t, []string{mmv1beta.DeletionProtectionFinalizer}, getMattermost(t, r).Finalizers)
+	})
+
+	t.Run("deletion held", func(t *testing.T) {
+		now := metav1.Now()
+		mattermost := &mmv1beta.Mattermost{ObjectMeta: metav1.ObjectMeta{
+			Name:              key.Name,
+			Namespace:         key.Namespace,
+			Annotations:       protected,
+			Finalizers:        []string{mmv1beta.DeletionProtectionFinalizer},
+			DeletionTimestamp: &now,
+		}}
+		r, recorder := newReconciler(mattermost)
+
+		deleting, err := r.checkDeletionProtection(context.TODO(), mattermost, blubr.InitLogger())
+		require.NoError(t, err)
+		assert.True(t, deleting)
+		assert.Equal(t, []string{mmv1beta.DeletionProtectionFinalizer}, getMattermost(t, r).Finalizers)
+		assert.Len(t, recorder.Events, 1)
+	})
+
+	t.Run("protection removed", func(t *testing.T) {
+		mattermost := &mmv1beta.Mattermost{ObjectMeta: metav1.ObjectMeta{
+			Name:        key.Name,
+			Namespace:   key.Namespace,
+			Annotations: map[string]string{mmv1beta.DeletionProtectedAnnotation: "false"},
+			Finalizers:  []string{mmv1beta.DeletionProtectionFinalizer},
+		}}
+		r, _ := newReconciler(mattermost)
+
+		deleting, err := r.checkDeletionProtection(context.TODO(), mattermost, blubr.InitLogger())
+		require.NoError(t, err)
+		assert.False(t, deleting)
+		assert.Empty(t, getMattermost(t, r).Finalizers)
+	})
+}
@@ -0,0 +1,81 @@
+package mattermost
+
+import (
+	"fmt"
+
+	mmv1beta "github.com/mattermost/mattermost-operator/apis/mattermost/v1beta1"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// recordStatusEvents records Events on the Mattermost for the milestones
+// reached between the previous and the current status: a rollout started or
+// finished, the database became ready, the health check failed, the
+// application health degraded or an upgrade was rolled back.
+func (r *MattermostReconciler) recordStatusEvents(mattermost *mmv1beta.Mattermost, previous, current mmv1beta.MattermostStatus) {
+	// A rollout starts when the Mattermost is reconciled again after it was
+	// stable or standing by, or for the first time.
+	progressing := meta.FindStatusCondition(current.Conditions, mmv1beta.ProgressingCondition)
+	previousProgressing := meta.FindStatusCondition(previous.Conditions, mmv1beta.ProgressingCondition)
+	if progressing != nil && progressing.Status == metav1.ConditionTrue &&
+		(previousProgressing == nil || previousProgressing.Reason == "Stable" || previousProgressing.Reason == "Standby") {
+		r.recordEvent(mattermost, corev1.EventTypeNormal, "RolloutStarted", progressing.Message)
+	}
+
+	if previous.State != mmv1beta.Stable && current.State == mmv1beta.Stable {
+		r.recordEvent(mattermost, corev1.EventTypeNormal, "RolloutFinished", fmt.Sprintf("All Mattermost pods run the image %s and are ready", current.Image))
+	}
+
+	if componentBecameReady(previous.Components, current.Components, mmv1beta.DatabaseComponent) ||
+		(mattermost.Spec.Database.IsExternal() && conditionBecameTrue(previous.Conditions, current.Conditions, mmv1beta.DatabaseReachableCondition)) {
+		r.recordEvent(mattermost, corev1.EventTypeNormal, "DatabaseReady", "The database of the Mattermost is ready")
+	}
+
+	if conditionBecameTrue(previous.Conditions, current.Conditions, mmv1beta.DegradedCondition) {
+		degraded := meta.FindStatusCondition(current.Conditions, mmv1beta.DegradedCondition)
+		if degraded.Reason == "HealthCheckFailed" {
+			r.recordEvent(mattermost, corev1.EventTypeWarning, "HealthCheckFailed", degraded.Message)
+		}
+	}
+
+	if conditionBecameTrue(previous.Conditions, current.Conditions, mmv1beta.HealthDegradedCondition) {
+		healthDegraded := meta.FindStatusCondition(current.Conditions, mmv1beta.HealthDegradedCondition)
+		r.recordEvent(mattermost, corev1.EventTypeWarning, "HealthDegraded", healthDegraded.Message)
+	}
+
+	if conditionBecameTrue(previous.Conditions, current.Conditions, mmv1beta.UpgradeFailedCondition) {
+		upgradeFailed := meta.FindStatusCondition(current.Conditions, mmv1beta.UpgradeFailedCondition)
+		r.recordEvent(mattermost, corev1.EventTypeWarning, "UpgradeRolledBack", upgradeFailed.Message)
+	}
+
+	// The reconciliation errors are recorded as Events as they occur, the
+	// notification webhook is only notified of the first one.
+	if conditionBecameTrue(previous.Conditions, current.Conditions, mmv1beta.ErrorCondition) {
+		reconcileFailed := meta.FindStatusCondition(current.Conditions, mmv1beta.ErrorCondition)
+		r.notify(mattermost, corev1.EventTypeWarning, "ReconcileFailed", reconcileFailed.Message)
+	}
+}
+
+// conditionBecameTrue returns true if the condition is true in the current
+// conditions but not in the previous ones.
+func conditionBecameTrue(previous, current []metav1.Condition, conditionType string) bool {
+	return meta.IsStatusConditionTrue(current, conditionType) && !meta.IsStatusConditionTrue(previous, conditionType)
+}
+
+// componentBecameReady returns true if a component of the kind is ready in
+// the current components but was not in the previous ones.
+func componentBecameReady(previous, current []mmv1beta.ComponentStatus, component string) bool {
+	wasReady := map[string]bool{}
+	for _, status := range previous {
+		if status.Component == component && status.Ready {
+			wasReady[status.Name] = true
+		}
+	}
+	for _, status := range current {
+		if status.Component == component && status.Ready && !wasReady[status.Name] {
+			return true
+		}
+	}
+	return false
+}
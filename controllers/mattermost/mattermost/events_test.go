@@ -0,0 +1,70 @@
+package mattermost
+
+import (
+	"testing"
+
+	mmv1beta "github.com/mattermost/mattermost-operator/apis/mattermost/v1beta1"
+	"github.com/stretchr/testify/assert"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/tools/record"
+)
+
+func TestRecordStatusEvents(t *testing.T) {
+	mattermost := &mmv1beta.Mattermost{
+		ObjectMeta: metav1.ObjectMeta{Name: "mm", Namespace: "mm-namespace"},
+	}
+
+	recordEvents := func(previous, current mmv1beta.MattermostStatus) []string {
+		recorder := record.NewFakeRecorder(10)
+		r := &MattermostReconciler{Recorder: recorder}
+		r.recordStatusEvents(mattermost, previous, current)
+		close(recorder.Events)
+
+		var events []string
+		for event := range recorder.Events {
+			events = append(events, event)
+		}
+		return events
+	}
+
+	reconciling := mmv1beta.MattermostStatus{State: mmv1beta.Reconciling}
+	setStateConditions(&reconciling, 1, nil, nil)
+	stable := mmv1beta.MattermostStatus{State: mmv1beta.Stable, Image: "mattermost/mattermost-enterprise-edition:6.0.0"}
+	setStateConditions(&stable, 1, nil, nil)
+
+	t.Run("rollout started", func(t *testing.T) {
+		assert.Equal(t, []string{"Normal RolloutStarted The Mattermost is being reconciled"}, recordEvents(mmv1beta.MattermostStatus{}, reconciling))
+		assert.Equal(t, []string{"Normal RolloutStarted The Mattermost is being reconciled"}, recordEvents(stable, reconciling))
+		assert.Empty(t, recordEvents(reconciling, reconciling))
+	})
+
+	t.Run("rollout finished", func(t *testing.T) {
+		assert.Equal(t, []string{"Normal RolloutFinished All Mattermost pods run the image mattermost/mattermost-enterprise-edition:6.0.0 and are ready"}, recordEvents(reconciling, stable))
+		assert.Empty(t, recordEvents(stable, stable))
+	})
+
+	t.Run("database ready", func(t *testing.T) {
+		current := stable
+		current.Components = []mmv1beta.ComponentStatus{{Component: mmv1beta.DatabaseComponent, Kind: "MysqlCluster", Name: "db", Ready: true}}
+		assert.Equal(t, []string{"Normal DatabaseReady The database of the Mattermost is ready"}, recordEvents(stable, current))
+		assert.Empty(t, recordEvents(current, current))
+	})
+
+	t.Run("health check failed", func(t *testing.T) {
+		previous := stable
+		previous.Conditions = append([]metav1.Condition{}, stable.Conditions...)
+		setStatusCondition(&previous, metav1.Condition{Type: mmv1beta.ReadyCondition, Status: metav1.ConditionTrue, ObservedGeneration: 1, Reason: "Stable"})
+		current := mmv1beta.MattermostStatus{State: mmv1beta.Reconciling, Conditions: previous.Conditions}
+		setStateConditions(&current, 1, assert.AnError, nil)
+
+		assert.Contains(t, recordEvents(previous, current), "Warning HealthCheckFailed "+assert.AnError.Error())
+	})
+
+	t.Run("upgrade rolled back", func(t *testing.T) {
+		current := reconciling
+		current.Conditions = append([]metav1.Condition{}, reconciling.Conditions...)
+		setStatusCondition(&current, metav1.Condition{Type: mmv1beta.UpgradeFailedCondition, Status: metav1.ConditionTrue, Reason: "ProgressDeadlineExceeded", Message: "pods not ready"})
+
+		assert.Equal(t, []string{"Warning UpgradeRolledBack pods not ready"}, recordEvents(reconciling, current))
+	})
+}
@@ -0,0 +1,90 @@
+package mattermost
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/go-logr/logr"
+	mmv1beta "github.com/mattermost/mattermost-operator/apis/mattermost/v1beta1"
+	mattermostApp "github.com/mattermost/mattermost-operator/pkg/mattermost"
+	"github.com/mattermost/mattermost-operator/pkg/resources"
+	"github.com/pkg/errors"
+	batchv1 "k8s.io/api/batch/v1"
+	k8sErrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	k8sClient "sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+// checkExport runs the workspace export requested in 'Jobs.Export'. A new
+// export is run whenever the export ID changes. It returns the status of the
+// export to report.
+func (r *MattermostReconciler) checkExport(mattermost *mmv1beta.Mattermost, dbConfig mattermostApp.DatabaseConfig, fileStoreInfo *mattermostApp.FileStoreInfo, reqLogger logr.Logger) (*mmv1beta.ExportStatus, error) {
+	if mattermost.Spec.Jobs == nil || mattermost.Spec.Jobs.Export == nil {
+		return mattermost.Status.Export, nil
+	}
+	reqLogger = reqLogger.WithValues("phase", "export")
+
+	destination, err := r.Resources.ReadBackupBucket(mattermost.Spec.Jobs.Export.Destination, mattermost.Namespace)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to check export destination")
+	}
+
+	deployment := generateMattermostDeployment(mattermost, dbConfig, fileStoreInfo)
+	desired, err := mattermostApp.GenerateExportJobV1Beta(mattermost, deployment, destination)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to generate export job")
+	}
+
+	status := &mmv1beta.ExportStatus{
+		ID:      mattermost.Spec.Jobs.Export.ID,
+		State:   mmv1beta.ExportRunning,
+		JobName: desired.Name,
+	}
+
+	current := &batchv1.Job{}
+	err = r.Client.Get(context.TODO(), types.NamespacedName{Name: desired.Name, Namespace: desired.Namespace}, current)
+	if err != nil {
+		if k8sErrors.IsNotFound(err) {
+			reqLogger.Info("Launching export job", "id", status.ID)
+			startTime := metav1.Now()
+			status.StartTime = &startTime
+			return status, r.Resources.Create(mattermost, desired, reqLogger)
+		}
+		return nil, errors.Wrap(err, "failed to get export job")
+	}
+	status.StartTime = current.Status.StartTime
+
+	if current.Annotations[mattermostApp.ExportIDAnnotation] != status.ID {
+		reqLogger.Info("Export ID changed, restarting export job", "id", status.ID)
+		r.deleteExportJob(current, reqLogger)
+		return status, nil
+	}
+
+	if resources.JobConditionTrue(current, batchv1.JobFailed) {
+		status.State = mmv1beta.ExportFailed
+		status.CompletionTime = resources.JobConditionTime(current, batchv1.JobFailed)
+		status.Message = r.Resources.JobTerminationMessage(current, reqLogger)
+		return status, nil
+	}
+
+	if !resources.JobConditionTrue(current, batchv1.JobComplete) {
+		return status, nil
+	}
+
+	status.State = mmv1beta.ExportCompleted
+	status.CompletionTime = current.Status.CompletionTime
+	status.URL = mattermostApp.ExportURL(mattermost, destination)
+
+	return status, nil
+}
+
+func (r *MattermostReconciler) deleteExportJob(job *batchv1.Job, reqLogger logr.Logger) {
+	reqLogger.Info(fmt.Sprintf("Deleting export job %s/%s", job.GetNamespace(), job.GetName()))
+
+	err := r.Client.Delete(context.TODO(), job, k8sClient.PropagationPolicy(metav1.DeletePropagationBackground))
+	if err != nil && !k8sErrors.IsNotFound(err) {
+		// Do not return error on fail as it is not critical
+		reqLogger.Error(err, "Unable to delete export job")
+	}
+}
@@ -15,7 +15,11 @@ import (
 )
 
 func (r *MattermostReconciler) checkFileStore(mattermost *mmv1beta.Mattermost, reqLogger logr.Logger) (*mattermostApp.FileStoreInfo, error) {
-	reqLogger = reqLogger.WithValues("Reconcile", "fileStore")
+	reqLogger = reqLogger.WithValues("phase", "fileStore")
+
+	if err := mattermostApp.ValidateFileStore(mattermost.Spec.FileStore); err != nil {
+		return nil, err
+	}
 
 	if mattermost.Spec.FileStore.IsExternal() {
 		return r.checkExternalFileStore(mattermost, reqLogger)
@@ -32,7 +36,20 @@ func (r *MattermostReconciler) checkExternalFileStore(mattermost *mmv1beta.Matte
 		return nil, err
 	}
 
-	return mattermostApp.NewExternalFileStoreInfo(mattermost, *secret)
+	fileStore, err := mattermostApp.NewExternalFileStoreInfo(mattermost, *secret)
+	if err != nil {
+		return nil, err
+	}
+
+	// The bucket is configured by the active installation.
+	if !mattermost.StandbyEnabled() {
+		err = r.checkBucketConfiguration(mattermost, fileStore, reqLogger)
+		if err != nil {
+			return nil, errors.Wrap(err, "failed to check bucket configuration")
+		}
+	}
+
+	return fileStore, nil
 }
 
 func (r *MattermostReconciler) checkOperatorManagedMinio(mattermost *mmv1beta.Mattermost, reqLogger logr.Logger) (*mattermostApp.FileStoreInfo, error) {
@@ -81,5 +98,5 @@ func (r *MattermostReconciler) checkMinioInstance(mattermost *mmv1beta.Mattermos
 	// For some reason, our current minio operator seems to remove labels on
 	// the instance resource when we add them. For that reason, trying to
 	// ensure the labels are correct doesn't work.
-	return r.Resources.Update(current, desired, reqLogger)
+	return r.updateResource(mattermost, current, desired, reqLogger)
 }
@@ -0,0 +1,176 @@
+package mattermost
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/go-logr/logr"
+	mmv1beta "github.com/mattermost/mattermost-operator/apis/mattermost/v1beta1"
+	mattermostApp "github.com/mattermost/mattermost-operator/pkg/mattermost"
+	"github.com/mattermost/mattermost-operator/pkg/resources"
+	"github.com/pkg/errors"
+	batchv1 "k8s.io/api/batch/v1"
+	corev1 "k8s.io/api/core/v1"
+	k8sErrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	k8sClient "sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+// checkFileStoreMigration runs the migration of the files to the file store
+// set in 'MigrateTo' and switches Mattermost to it once the files are copied.
+// It returns the status of the migration to report.
+func (r *MattermostReconciler) checkFileStoreMigration(mattermost *mmv1beta.Mattermost, source *mattermostApp.FileStoreInfo, reqLogger logr.Logger) (*mmv1beta.FileStoreMigrationStatus, error) {
+	if mattermost.Spec.FileStore.MigrateTo == nil {
+		return mattermost.Status.FileStoreMigration, nil
+	}
+	reqLogger = reqLogger.WithValues("phase", "fileStoreMigration")
+
+	target, err := r.checkFileStoreMigrationTarget(mattermost)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to check file store migration target")
+	}
+	if target.Location() == source.Location() {
+		return nil, errors.New("file store migration target is the current file store")
+	}
+
+	desired := mattermostApp.GenerateFileStoreMigrationJobV1Beta(mattermost, source, target)
+	status := &mmv1beta.FileStoreMigrationStatus{
+		State:   mmv1beta.FileStoreMigrationSyncing,
+		Target:  target.Location(),
+		JobName: desired.Name,
+	}
+
+	current := &batchv1.Job{}
+	err = r.Client.Get(context.TODO(), types.NamespacedName{Name: desired.Name, Namespace: desired.Namespace}, current)
+	if err != nil {
+		if k8sErrors.IsNotFound(err) {
+			reqLogger.Info("Launching file store migration job", "target", status.Target)
+			startTime := metav1.Now()
+			status.StartTime = &startTime
+			return status, r.Resources.Create(mattermost, desired, reqLogger)
+		}
+		return nil, errors.Wrap(err, "failed to get file store migration job")
+	}
+	status.StartTime = current.Status.StartTime
+
+	if current.Annotations[mattermostApp.FileStoreMigrationTargetAnnotation] != status.Target {
+		reqLogger.Info("File store migration target changed, restarting migration job")
+		r.deleteFileStoreMigrationJob(current, reqLogger)
+		return status, nil
+	}
+
+	if resources.JobConditionTrue(current, batchv1.JobFailed) {
+		status.State = mmv1beta.FileStoreMigrationFailed
+		status.CompletionTime = resources.JobConditionTime(current, batchv1.JobFailed)
+		status.Message = r.Resources.JobTerminationMessage(current, reqLogger)
+		return status, nil
+	}
+
+	if !resources.JobConditionTrue(current, batchv1.JobComplete) {
+		r.checkFileStoreMigrationProgress(current, status, reqLogger)
+		return status, nil
+	}
+
+	status.State = mmv1beta.FileStoreMigrationCompleted
+	status.CompletionTime = current.Status.CompletionTime
+	status.Message = r.Resources.JobTerminationMessage(current, reqLogger)
+
+	reqLogger.Info("File store migration job completed, switching Mattermost to the new file store", "target", status.Target)
+	err = r.switchToMigratedFileStore(mattermost)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to switch to the migrated file store")
+	}
+
+	// The Job is removed so that a later migration does not reuse its result.
+	r.deleteFileStoreMigrationJob(current, reqLogger)
+
+	return status, nil
+}
+
+// checkFileStoreMigrationProgress reports the number of objects copied by the
+// running migration Job, read from the logs of its pod.
+func (r *MattermostReconciler) checkFileStoreMigrationProgress(job *batchv1.Job, status *mmv1beta.FileStoreMigrationStatus, reqLogger logr.Logger) {
+	if r.PodLogs == nil {
+		return
+	}
+
+	pods := &corev1.PodList{}
+	err := r.Client.List(context.TODO(), pods, k8sClient.InNamespace(job.Namespace), k8sClient.MatchingLabels{"job-name": job.Name})
+	if err != nil {
+		reqLogger.Error(err, "Unable to list file store migration job pods")
+		return
+	}
+	var running *corev1.Pod
+	for i, pod := range pods.Items {
+		if pod.Status.Phase != corev1.PodRunning {
+			continue
+		}
+		if running == nil || running.CreationTimestamp.Before(&pod.CreationTimestamp) {
+			running = &pods.Items[i]
+		}
+	}
+	if running == nil {
+		return
+	}
+
+	logs, err := r.PodLogs.TailLogs(context.TODO(), running.Namespace, running.Name, mattermostApp.FileStoreMigrationContainerName, 10)
+	if err != nil {
+		reqLogger.Error(err, "Unable to read file store migration job logs")
+		return
+	}
+	copied, total, found := mattermostApp.ParseFileStoreMigrationProgress(logs)
+	if !found {
+		return
+	}
+	status.CopiedObjects = copied
+	status.TotalObjects = total
+	status.Message = fmt.Sprintf("copied %d of %d objects", copied, total)
+}
+
+// switchToMigratedFileStore sets the migration target as the external file
+// store of the Mattermost. Only the file store of the stored Mattermost is
+// patched, as the in-memory Mattermost holds defaults and template settings
+// which are not stored.
+func (r *MattermostReconciler) switchToMigratedFileStore(mattermost *mmv1beta.Mattermost) error {
+	stored := &mmv1beta.Mattermost{}
+	err := r.Client.Get(context.TODO(), k8sClient.ObjectKeyFromObject(mattermost), stored)
+	if err != nil {
+		return err
+	}
+	patch := k8sClient.MergeFrom(stored.DeepCopy())
+	stored.Spec.FileStore.External = mattermost.Spec.FileStore.MigrateTo
+	stored.Spec.FileStore.MigrateTo = nil
+	err = r.Client.Patch(context.TODO(), stored, patch)
+	if err != nil {
+		return err
+	}
+
+	mattermost.Spec.FileStore.External = mattermost.Spec.FileStore.MigrateTo
+	mattermost.Spec.FileStore.MigrateTo = nil
+	mattermost.ResourceVersion = stored.ResourceVersion
+	return nil
+}
+
+func (r *MattermostReconciler) checkFileStoreMigrationTarget(mattermost *mmv1beta.Mattermost) (*mattermostApp.FileStoreInfo, error) {
+	secret := &corev1.Secret{}
+	err := r.Client.Get(context.TODO(), types.NamespacedName{Name: mattermost.Spec.FileStore.MigrateTo.Secret, Namespace: mattermost.Namespace}, secret)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to get migration target file store secret")
+	}
+
+	target := mattermost.DeepCopy()
+	target.Spec.FileStore.External = target.Spec.FileStore.MigrateTo
+
+	return mattermostApp.NewExternalFileStoreInfo(target, *secret)
+}
+
+func (r *MattermostReconciler) deleteFileStoreMigrationJob(job *batchv1.Job, reqLogger logr.Logger) {
+	reqLogger.Info(fmt.Sprintf("Deleting file store migration job %s/%s", job.GetNamespace(), job.GetName()))
+
+	err := r.Client.Delete(context.TODO(), job, k8sClient.PropagationPolicy(metav1.DeletePropagationBackground))
+	if err != nil && !k8sErrors.IsNotFound(err) {
+		// Do not return error on fail as it is not critical
+		reqLogger.Error(err, "Unable to delete file store migration job")
+	}
+}
@@ -0,0 +1,148 @@
+package mattermost
+
+import (
+	"context"
+	"testing"
+
+	blubr "github.com/mattermost/blubr"
+	mmv1beta "github.com/mattermost/mattermost-operator/apis/mattermost/v1beta1"
+	mattermostApp "github.com/mattermost/mattermost-operator/pkg/mattermost"
+	"github.com/mattermost/mattermost-operator/pkg/resources"
+	resourcesfake "github.com/mattermost/mattermost-operator/pkg/resources/fake"
+	operatortest "github.com/mattermost/mattermost-operator/test"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	appsv1 "k8s.io/api/apps/v1"
+	batchv1 "k8s.io/api/batch/v1"
+	corev1 "k8s.io/api/core/v1"
+	k8sErrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/kubernetes/scheme"
+	"k8s.io/client-go/tools/record"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+)
+
+func TestReconcileFileStoreMigrationSwitch(t *testing.T) {
+	logger := blubr.InitLogger()
+	s := prepareSchema(t, scheme.Scheme)
+	key := types.NamespacedName{Namespace: "migration", Name: "chat"}
+
+	replicas := int32(1)
+	mattermost := &mmv1beta.Mattermost{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:        key.Name,
+			Namespace:   key.Namespace,
+			UID:         "mm-uid",
+			Generation:  1,
+			Annotations: map[string]string{mmv1beta.StoreDefaultsAnnotation: "false"},
+		},
+		Spec: mmv1beta.MattermostSpec{
+			Replicas: &replicas,
+			Image:    "mattermost/mattermost-enterprise-edition",
+			Version:  operatortest.LatestStableMattermostVersion,
+			Ingress:  &mmv1beta.Ingress{Enabled: true, Host: "chat.example.com"},
+			Database: mmv1beta.Database{External: &mmv1beta.ExternalDatabase{Secret: "db"}},
+			FileStore: mmv1beta.FileStore{
+				External:  &mmv1beta.ExternalFileStore{URL: "old.example.com", Bucket: "chat", Secret: "s3"},
+				MigrateTo: &mmv1beta.ExternalFileStore{URL: "new.example.com", Bucket: "chat", Secret: "s3"},
+			},
+		},
+	}
+	dbSecret := &corev1.Secret{
+		ObjectMeta: metav1.ObjectMeta{Name: "db", Namespace: key.Namespace},
+		Data:       map[string][]byte{"DB_CONNECTION_STRING": []byte("postgres://mmuser:secret@db:5432/mattermost")},
+	}
+	fileStoreSecret := &corev1.Secret{
+		ObjectMeta: metav1.ObjectMeta{Name: "s3", Namespace: key.Namespace},
+		Data:       map[string][]byte{"accesskey": []byte("key"), "secretkey": []byte("secret")},
+	}
+	job := &batchv1.Job{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:        mattermostApp.FileStoreMigrationJobName(mattermost),
+			Namespace:   key.Namespace,
+			Annotations: map[string]string{mattermostApp.FileStoreMigrationTargetAnnotation: "https://new.example.com/chat"},
+		},
+		Status: batchv1.JobStatus{
+			Conditions: []batchv1.JobCondition{{Type: batchv1.JobComplete, Status: corev1.ConditionTrue}},
+		},
+	}
+
+	c := fake.NewClientBuilder().WithScheme(s).WithObjects(mattermost, dbSecret, fileStoreSecret, job).Build()
+	r := &MattermostReconciler{
+		Client:             c,
+		NonCachedAPIReader: c,
+		Scheme:             s,
+		Log:                logger,
+		MaxReconciling:     5,
+		Resources:          resources.NewResourceHelper(resourcesfake.NewApplyClient(c), s),
+		Recorder:           record.NewFakeRecorder(100),
+	}
+
+	result, err := r.Reconcile(context.TODO(), reconcile.Request{NamespacedName: key})
+	require.NoError(t, err)
+	assert.Equal(t, reconcile.Result{Requeue: true}, result)
+
+	switched := &mmv1beta.Mattermost{}
+	require.NoError(t, c.Get(context.TODO(), key, switched))
+	assert.Nil(t, switched.Spec.FileStore.MigrateTo)
+	assert.Equal(t, "new.example.com", switched.Spec.FileStore.External.URL)
+	// Only the file store is patched, the defaults are not stored.
+	assert.Empty(t, switched.Spec.ImagePullPolicy)
+	require.NotNil(t, switched.Status.FileStoreMigration)
+	assert.Equal(t, mmv1beta.FileStoreMigrationCompleted, switched.Status.FileStoreMigration.State)
+
+	// The deployment is not rendered against the previous file store.
+	err = c.Get(context.TODO(), key, &appsv1.Deployment{})
+	assert.True(t, k8sErrors.IsNotFound(err))
+}
+
+type fakePodLogs struct {
+	logs string
+}
+
+func (f *fakePodLogs) TailLogs(_ context.Context, namespace, pod, container string, lines int64) (string, error) {
+	return f.logs, nil
+}
+
+func TestFileStoreMigrationProgress(t *testing.T) {
+	logger := blubr.InitLogger()
+	s := prepareSchema(t, scheme.Scheme)
+
+	job := &batchv1.Job{
+		ObjectMeta: metav1.ObjectMeta{Name: "chat-file-store-migration", Namespace: "migration"},
+	}
+	pod := &corev1.Pod{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "chat-file-store-migration-abcde",
+			Namespace: "migration",
+			Labels:    map[string]string{"job-name": job.Name},
+		},
+		Status: corev1.PodStatus{Phase: corev1.PodRunning},
+	}
+	c := fake.NewClientBuilder().WithScheme(s).WithObjects(job, pod).Build()
+	r := &MattermostReconciler{
+		Client:  c,
+		Scheme:  s,
+		Log:     logger,
+		PodLogs: &fakePodLogs{logs: "copied 0 of 40 objects\ncopied 1 of 40 objects\ncopied 2 of 40 objects\n"},
+	}
+
+	status := &mmv1beta.FileStoreMigrationStatus{State: mmv1beta.FileStoreMigrationSyncing}
+	r.checkFileStoreMigrationProgress(job, status, logger)
+	assert.Equal(t, int64(2), status.CopiedObjects)
+	assert.Equal(t, int64(40), status.TotalObjects)
+	assert.Equal(t, "copied 2 of 40 objects", status.Message)
+
+	t.Run("no running pod", func(t *testing.T) {
+		pod := pod.DeepCopy()
+		pod.Status.Phase = corev1.PodFailed
+		require.NoError(t, c.Update(context.TODO(), pod))
+
+		status := &mmv1beta.FileStoreMigrationStatus{State: mmv1beta.FileStoreMigrationSyncing}
+		r.checkFileStoreMigrationProgress(job, status, logger)
+		assert.Zero(t, status.CopiedObjects)
+		assert.Empty(t, status.Message)
+	})
+}
@@ -0,0 +1,37 @@
+package mattermost
+
+import (
+	"context"
+	"strings"
+
+	"github.com/mattermost/mattermost-operator/pkg/mattermost/globaldefaults"
+	"github.com/pkg/errors"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/types"
+)
+
+// globalDefaults returns the global defaults from the ConfigMap configured
+// for the operator, nil if there is none.
+func (r *MattermostReconciler) globalDefaults(ctx context.Context) (*globaldefaults.Defaults, error) {
+	if r.GlobalDefaultsConfigMap == "" {
+		return nil, nil
+	}
+
+	key := strings.SplitN(r.GlobalDefaultsConfigMap, "/", 2)
+	if len(key) != 2 {
+		return nil, errors.Errorf("global defaults ConfigMap %s must be set as 'namespace/name'", r.GlobalDefaultsConfigMap)
+	}
+
+	configMap := &corev1.ConfigMap{}
+	err := r.NonCachedAPIReader.Get(ctx, types.NamespacedName{Namespace: key[0], Name: key[1]}, configMap)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to get global defaults ConfigMap")
+	}
+
+	data, ok := configMap.Data[globaldefaults.ConfigMapKey]
+	if !ok {
+		return nil, errors.Errorf("global defaults ConfigMap %s does not have a '%s' value", r.GlobalDefaultsConfigMap, globaldefaults.ConfigMapKey)
+	}
+
+	return globaldefaults.Parse([]byte(data))
+}
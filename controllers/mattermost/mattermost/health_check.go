@@ -7,6 +7,7 @@ import (
 	mmv1beta "github.com/mattermost/mattermost-operator/apis/mattermost/v1beta1"
 	"github.com/mattermost/mattermost-operator/pkg/mattermost/healthcheck"
 	"github.com/pkg/errors"
+	k8sLabels "k8s.io/apimachinery/pkg/labels"
 	"sigs.k8s.io/controller-runtime/pkg/client"
 )
 
@@ -16,15 +17,78 @@ import (
 // NOTE: this is a vital health check. Every reconciliation loop should run this
 // check at the very end to ensure that everything in the installation is as it
 // should be. Over time, more types of checks should be added here as needed.
-func (r *MattermostReconciler) checkMattermostHealth(mattermost *mmv1beta.Mattermost, logger logr.Logger) (mmv1beta.MattermostStatus, error) {
+//
+// The pods are expected to run the current image while an update is queued
+// until the maintenance window.
+func (r *MattermostReconciler) checkMattermostHealth(mattermost *mmv1beta.Mattermost, pendingUpdate *mmv1beta.PendingUpdateStatus, logger logr.Logger) (mmv1beta.MattermostStatus, error) {
+	status, err := r.checkProductionHealth(mattermost, pendingUpdate, logger)
+	if !mattermost.CanaryEnabled() {
+		return status, err
+	}
+
+	canary := mattermost.Spec.Canary.Deployment
+	status.CanaryName = canary.Name
+	if err != nil {
+		return status, err
+	}
+
+	_, err = r.checkDeploymentHealth(mattermost, canary.Name, canary.Image, canary.Version, mattermost.GetAppDeploymentImageName(canary), logger)
+	if err != nil {
+		status.State = mmv1beta.Reconciling
+		return status, errors.Wrap(err, "canary deployment health check failed")
+	}
+
+	return status, nil
+}
+
+// checkProductionHealth checks the health of the Mattermost deployment, or of
+// the blue and green deployments if BlueGreen is enabled.
+func (r *MattermostReconciler) checkProductionHealth(mattermost *mmv1beta.Mattermost, pendingUpdate *mmv1beta.PendingUpdateStatus, logger logr.Logger) (mmv1beta.MattermostStatus, error) {
+	if !mattermost.BlueGreenEnabled() {
+		imageName, image, version := mattermostImage(mattermost)
+		if pendingUpdate != nil {
+			imageName, image, version = splitImageName(pendingUpdate.RunningImage)
+		}
+		return r.checkDeploymentHealth(mattermost, mattermost.Name, image, version, imageName, logger)
+	}
+
+	// Both deployments are checked, the status of the production deployment
+	// is reported.
+	blue := mattermost.Spec.BlueGreen.Blue
+	blueStatus, blueErr := r.checkDeploymentHealth(mattermost, blue.Name, blue.Image, blue.Version, mattermost.GetAppDeploymentImageName(blue), logger)
+	green := mattermost.Spec.BlueGreen.Green
+	greenStatus, greenErr := r.checkDeploymentHealth(mattermost, green.Name, green.Image, green.Version, mattermost.GetAppDeploymentImageName(green), logger)
+
+	status := blueStatus
+	if mattermost.Spec.BlueGreen.ProductionDeployment == mmv1beta.GreenName {
+		status = greenStatus
+	}
+	status.BlueName = blue.Name
+	status.GreenName = green.Name
+
+	if blueErr != nil {
+		status.State = mmv1beta.Reconciling
+		return status, errors.Wrap(blueErr, "blue deployment health check failed")
+	}
+	if greenErr != nil {
+		status.State = mmv1beta.Reconciling
+		return status, errors.Wrap(greenErr, "green deployment health check failed")
+	}
+
+	return status, nil
+}
+
+// checkDeploymentHealth checks the health of a single Mattermost deployment.
+func (r *MattermostReconciler) checkDeploymentHealth(mattermost *mmv1beta.Mattermost, deploymentName, image, version, imageName string, logger logr.Logger) (mmv1beta.MattermostStatus, error) {
 	status := mmv1beta.MattermostStatus{
 		State:              mmv1beta.Reconciling,
 		ObservedGeneration: mattermost.Generation,
 		Replicas:           0,
 		UpdatedReplicas:    0,
+		Selector:           k8sLabels.SelectorFromSet(mmv1beta.MattermostSelectorLabels(deploymentName)).String(),
 	}
 
-	labels := mattermost.MattermostLabels(mattermost.Name)
+	labels := mattermost.MattermostLabels(deploymentName)
 	listOptions := []client.ListOption{
 		client.InNamespace(mattermost.Namespace),
 		client.MatchingLabels(labels),
@@ -32,24 +96,25 @@ func (r *MattermostReconciler) checkMattermostHealth(mattermost *mmv1beta.Matter
 
 	healthChecker := healthcheck.NewHealthChecker(r.NonCachedAPIReader, listOptions, logger)
 
-	err := healthChecker.AssertDeploymentRolloutStarted(mattermost.Name, mattermost.Namespace)
+	err := healthChecker.AssertDeploymentRolloutStarted(deploymentName, mattermost.Namespace)
 	if err != nil {
 		return status, errors.Wrap(err, "rollout not yet started")
 	}
 
-	podsStatus, err := healthChecker.CheckPodsRollOut(mattermost.GetImageName())
+	podsStatus, err := healthChecker.CheckPodsRollOut(imageName)
 	if err != nil {
 		return status, errors.Wrap(err, "failed to check pods status")
 	}
 
-	status.UpdatedReplicas = podsStatus.UpdatedReplicas
-	status.Replicas = podsStatus.Replicas
-
 	var replicas int32 = 1
 	if mattermost.Spec.Replicas != nil {
 		replicas = *mattermost.Spec.Replicas
 	}
 
+	status.UpdatedReplicas = podsStatus.UpdatedReplicas
+	status.Replicas = podsStatus.Replicas
+	status.DesiredReplicas = replicas
+
 	if podsStatus.UpdatedReplicas != replicas {
 		return status, fmt.Errorf("found %d updated replicas, but wanted %d", podsStatus.UpdatedReplicas, replicas)
 	}
@@ -57,8 +122,8 @@ func (r *MattermostReconciler) checkMattermostHealth(mattermost *mmv1beta.Matter
 		return status, fmt.Errorf("found %d pods, but wanted %d", podsStatus.Replicas, replicas)
 	}
 
-	status.Image = mattermost.Spec.Image
-	status.Version = mattermost.Spec.Version
+	status.Image = image
+	status.Version = version
 
 	status.Endpoint = "not available"
 	var endpoint string
@@ -0,0 +1,135 @@
+package mattermost
+
+import (
+	"context"
+	"crypto"
+	"crypto/sha256"
+	"fmt"
+	"sort"
+
+	"github.com/go-logr/logr"
+	mmv1beta "github.com/mattermost/mattermost-operator/apis/mattermost/v1beta1"
+	"github.com/mattermost/mattermost-operator/pkg/registry"
+	"github.com/pkg/errors"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/types"
+)
+
+// checkImageVerification resolves the tag of the Mattermost image to the
+// digest the deployment is pinned to, verifying the cosign signature of the
+// image if keys are configured. Every image name is resolved once for the
+// same cosign keys, the image is verified again if the key Secret is set,
+// changed or its keys rotated. Failures are retried.
+func (r *MattermostReconciler) checkImageVerification(ctx context.Context, mattermost *mmv1beta.Mattermost, reqLogger logr.Logger) (*mmv1beta.ImageVerificationStatus, error) {
+	if !mattermost.ImageVerificationEnabled() {
+		return nil, nil
+	}
+	reqLogger = reqLogger.WithValues("phase", "imageVerification")
+
+	imageName := joinImageName(mattermost.GetImage(), mattermost.Spec.Version)
+	var keys []crypto.PublicKey
+	var keysRef string
+	var keysErr error
+	if secret := mattermost.Spec.ImageVerification.CosignKeySecret; secret != "" {
+		keys, keysRef, keysErr = r.cosignKeys(mattermost.Namespace, secret)
+	}
+
+	previous := mattermost.Status.ImageVerification
+	if previous != nil && previous.Image == imageName && previous.CosignKeys == keysRef && keysErr == nil && previous.State != mmv1beta.ImageVerificationFailed {
+		return previous, nil
+	}
+
+	status := &mmv1beta.ImageVerificationStatus{
+		Image: imageName,
+		State: mmv1beta.ImageVerificationFailed,
+	}
+	failed := func(err error) (*mmv1beta.ImageVerificationStatus, error) {
+		status.Message = err.Error()
+		return status, errors.Wrapf(err, "failed to verify Mattermost image %s", imageName)
+	}
+	if keysErr != nil {
+		return failed(keysErr)
+	}
+
+	ref, err := registry.ParseReference(imageName)
+	if err != nil {
+		return failed(err)
+	}
+
+	keychain, err := r.imagePullKeychain(mattermost)
+	if err != nil {
+		return failed(err)
+	}
+
+	status.Digest, err = r.Registry.Digest(ctx, ref, keychain)
+	if err != nil {
+		return failed(err)
+	}
+
+	state := mmv1beta.ImageVerificationPinned
+	if len(keys) > 0 {
+		err = r.Registry.VerifyCosignSignature(ctx, ref, status.Digest, keys, keychain)
+		if err != nil {
+			return failed(err)
+		}
+		state = mmv1beta.ImageVerificationVerified
+	}
+	status.State = state
+	status.CosignKeys = keysRef
+
+	reqLogger.Info("Pinned Mattermost image to digest", "image", imageName, "digest", status.Digest, "state", state)
+
+	return status, nil
+}
+
+// imagePullKeychain returns the registry credentials of the image pull
+// Secrets of the Mattermost.
+func (r *MattermostReconciler) imagePullKeychain(mattermost *mmv1beta.Mattermost) (*registry.DockerConfigKeychain, error) {
+	var configs [][]byte
+	for _, pullSecret := range mattermost.GetImagePullSecrets() {
+		secret := &corev1.Secret{}
+		err := r.Client.Get(context.TODO(), types.NamespacedName{Name: pullSecret.Name, Namespace: mattermost.Namespace}, secret)
+		if err != nil {
+			return nil, errors.Wrapf(err, "failed to get image pull secret %s", pullSecret.Name)
+		}
+		if config, ok := secret.Data[corev1.DockerConfigJsonKey]; ok {
+			configs = append(configs, config)
+		} else if config, ok := secret.Data[corev1.DockerConfigKey]; ok {
+			configs = append(configs, config)
+		}
+	}
+
+	return registry.NewDockerConfigKeychain(configs...)
+}
+
+// cosignKeys returns the public keys stored in the values of the Secret, and
+// the name of the Secret with the hash of its keys.
+func (r *MattermostReconciler) cosignKeys(namespace, secretName string) ([]crypto.PublicKey, string, error) {
+	secret := &corev1.Secret{}
+	err := r.Client.Get(context.TODO(), types.NamespacedName{Name: secretName, Namespace: namespace}, secret)
+	if err != nil {
+		return nil, "", errors.Wrap(err, "failed to get cosign key secret")
+	}
+
+	names := make([]string, 0, len(secret.Data))
+	for name := range secret.Data {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	var keys []crypto.PublicKey
+	hash := sha256.New()
+	for _, name := range names {
+		parsed, err := registry.ParsePublicKeys(secret.Data[name])
+		if err != nil {
+			return nil, "", errors.Wrapf(err, "invalid key %s in cosign key secret", name)
+		}
+		keys = append(keys, parsed...)
+		fmt.Fprintf(hash, "%s=%x\n", name, secret.Data[name])
+	}
+	if len(keys) == 0 {
+		return nil, "", errors.Errorf("cosign key secret %s has no keys", secretName)
+	}
+
+	return keys, fmt.Sprintf("%s/%x", secretName, hash.Sum(nil)), nil
+}
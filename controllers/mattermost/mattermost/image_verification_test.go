@@ -0,0 +1,158 @@
+package mattermost
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/x509"
+	"encoding/pem"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	blubr "github.com/mattermost/blubr"
+	mmv1beta "github.com/mattermost/mattermost-operator/apis/mattermost/v1beta1"
+	"github.com/mattermost/mattermost-operator/pkg/registry"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes/scheme"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+)
+
+func TestCheckImageVerification(t *testing.T) {
+	logger := blubr.InitLogger()
+	digest := "sha256:dd15a51ac7dafd213744d1ef23394e7532f71a90f477c969b94600e46da5a0cf"
+
+	server := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/v2/mattermost/mattermost-enterprise-edition/manifests/5.37.1" {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		w.Header().Set("Docker-Content-Digest", digest)
+	}))
+	defer server.Close()
+
+	s := prepareSchema(t, scheme.Scheme)
+	c := fake.NewFakeClientWithScheme(s)
+	r := &MattermostReconciler{
+		Client:   c,
+		Scheme:   s,
+		Registry: &registry.Client{HTTPClient: server.Client()},
+	}
+
+	newMattermost := func(version string) *mmv1beta.Mattermost {
+		return &mmv1beta.Mattermost{
+			ObjectMeta: metav1.ObjectMeta{Name: "mm", Namespace: "mm-namespace"},
+			Spec: mmv1beta.MattermostSpec{
+				Image:             strings.TrimPrefix(server.URL, "https://") + "/mattermost/mattermost-enterprise-edition",
+				Version:           version,
+				ImageVerification: &mmv1beta.ImageVerification{Enabled: true},
+			},
+		}
+	}
+
+	t.Run("tag resolved and pinned", func(t *testing.T) {
+		mattermost := newMattermost("5.37.1")
+
+		status, err := r.checkImageVerification(context.TODO(), mattermost, logger)
+		require.NoError(t, err)
+		assert.Equal(t, mmv1beta.ImageVerificationPinned, status.State)
+		assert.Equal(t, digest, status.Digest)
+
+		mattermost.Status.ImageVerification = status
+		assert.Equal(t, mattermost.Spec.Image+"@"+digest, mattermost.GetImageName())
+
+		pinned, err := r.checkImageVerification(context.TODO(), mattermost, logger)
+		require.NoError(t, err)
+		assert.Same(t, status, pinned)
+	})
+
+	t.Run("unknown tag", func(t *testing.T) {
+		mattermost := newMattermost("5.99.0")
+
+		status, err := r.checkImageVerification(context.TODO(), mattermost, logger)
+		require.Error(t, err)
+		assert.Equal(t, mmv1beta.ImageVerificationFailed, status.State)
+		assert.NotEmpty(t, status.Message)
+	})
+
+	t.Run("missing cosign keys", func(t *testing.T) {
+		mattermost := newMattermost("5.37.1")
+		mattermost.Spec.ImageVerification.CosignKeySecret = "cosign-keys"
+
+		status, err := r.checkImageVerification(context.TODO(), mattermost, logger)
+		require.Error(t, err)
+		assert.Equal(t, mmv1beta.ImageVerificationFailed, status.State)
+	})
+
+	t.Run("cosign key added after the image is pinned", func(t *testing.T) {
+		mattermost := newMattermost("5.37.1")
+		status, err := r.checkImageVerification(context.TODO(), mattermost, logger)
+		require.NoError(t, err)
+		require.Equal(t, mmv1beta.ImageVerificationPinned, status.State)
+		mattermost.Status.ImageVerification = status
+
+		secret := &corev1.Secret{
+			ObjectMeta: metav1.ObjectMeta{Name: "added-keys", Namespace: "mm-namespace"},
+			Data:       map[string][]byte{"key.pub": publicKeyPEM(t)},
+		}
+		require.NoError(t, c.Create(context.TODO(), secret))
+		mattermost.Spec.ImageVerification.CosignKeySecret = secret.Name
+
+		// The image is not signed, the verification fails.
+		verified, err := r.checkImageVerification(context.TODO(), mattermost, logger)
+		require.Error(t, err)
+		assert.Equal(t, mmv1beta.ImageVerificationFailed, verified.State)
+	})
+
+	t.Run("cosign keys rotated", func(t *testing.T) {
+		secret := &corev1.Secret{
+			ObjectMeta: metav1.ObjectMeta{Name: "rotated-keys", Namespace: "mm-namespace"},
+			Data:       map[string][]byte{"key.pub": publicKeyPEM(t)},
+		}
+		require.NoError(t, c.Create(context.TODO(), secret))
+		_, keysRef, err := r.cosignKeys(secret.Namespace, secret.Name)
+		require.NoError(t, err)
+
+		mattermost := newMattermost("5.37.1")
+		mattermost.Spec.ImageVerification.CosignKeySecret = secret.Name
+		mattermost.Status.ImageVerification = &mmv1beta.ImageVerificationStatus{
+			Image:      mattermost.Spec.Image + ":5.37.1",
+			Digest:     digest,
+			State:      mmv1beta.ImageVerificationVerified,
+			CosignKeys: keysRef,
+		}
+
+		cached, err := r.checkImageVerification(context.TODO(), mattermost, logger)
+		require.NoError(t, err)
+		assert.Same(t, mattermost.Status.ImageVerification, cached)
+
+		secret.Data["key.pub"] = publicKeyPEM(t)
+		require.NoError(t, c.Update(context.TODO(), secret))
+
+		verified, err := r.checkImageVerification(context.TODO(), mattermost, logger)
+		require.Error(t, err)
+		assert.Equal(t, mmv1beta.ImageVerificationFailed, verified.State)
+	})
+
+	t.Run("disabled", func(t *testing.T) {
+		mattermost := newMattermost("5.37.1")
+		mattermost.Spec.ImageVerification.Enabled = false
+
+		status, err := r.checkImageVerification(context.TODO(), mattermost, logger)
+		require.NoError(t, err)
+		assert.Nil(t, status)
+	})
+}
+
+func publicKeyPEM(t *testing.T) []byte {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+	der, err := x509.MarshalPKIXPublicKey(&key.PublicKey)
+	require.NoError(t, err)
+	return pem.EncodeToMemory(&pem.Block{Type: "PUBLIC KEY", Bytes: der})
+}
@@ -0,0 +1,98 @@
+package mattermost
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/go-logr/logr"
+	mmv1beta "github.com/mattermost/mattermost-operator/apis/mattermost/v1beta1"
+	mattermostApp "github.com/mattermost/mattermost-operator/pkg/mattermost"
+	"github.com/mattermost/mattermost-operator/pkg/resources"
+	"github.com/pkg/errors"
+	batchv1 "k8s.io/api/batch/v1"
+	k8sErrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	k8sClient "sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+// checkImport runs the workspace import requested in 'Jobs.Import'. A new
+// import is run whenever the import ID changes. It returns the status of the
+// import to report.
+func (r *MattermostReconciler) checkImport(mattermost *mmv1beta.Mattermost, dbConfig mattermostApp.DatabaseConfig, fileStoreInfo *mattermostApp.FileStoreInfo, reqLogger logr.Logger) (*mmv1beta.ImportStatus, error) {
+	if mattermost.Spec.Jobs == nil || mattermost.Spec.Jobs.Import == nil {
+		return mattermost.Status.Import, nil
+	}
+	reqLogger = reqLogger.WithValues("phase", "import")
+
+	source, err := r.Resources.ReadBackupBucket(mattermost.Spec.Jobs.Import.Source, mattermost.Namespace)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to check import source")
+	}
+
+	deployment := generateMattermostDeployment(mattermost, dbConfig, fileStoreInfo)
+	desired, err := mattermostApp.GenerateImportJobV1Beta(mattermost, deployment, source)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to generate import job")
+	}
+
+	status := &mmv1beta.ImportStatus{
+		ID:      mattermost.Spec.Jobs.Import.ID,
+		State:   mmv1beta.ImportDownloading,
+		JobName: desired.Name,
+		Archive: mattermostApp.ImportArchiveURL(mattermost, source),
+	}
+
+	current := &batchv1.Job{}
+	err = r.Client.Get(context.TODO(), types.NamespacedName{Name: desired.Name, Namespace: desired.Namespace}, current)
+	if err != nil {
+		if k8sErrors.IsNotFound(err) {
+			reqLogger.Info("Launching import job", "id", status.ID, "archive", status.Archive)
+			startTime := metav1.Now()
+			status.StartTime = &startTime
+			return status, r.Resources.Create(mattermost, desired, reqLogger)
+		}
+		return nil, errors.Wrap(err, "failed to get import job")
+	}
+	status.StartTime = current.Status.StartTime
+
+	if current.Annotations[mattermostApp.ImportIDAnnotation] != status.ID {
+		reqLogger.Info("Import ID changed, restarting import job", "id", status.ID)
+		r.deleteImportJob(current, reqLogger)
+		return status, nil
+	}
+
+	if resources.JobConditionTrue(current, batchv1.JobFailed) {
+		status.State = mmv1beta.ImportFailed
+		status.CompletionTime = resources.JobConditionTime(current, batchv1.JobFailed)
+		status.Message = r.Resources.JobTerminationMessage(current, reqLogger)
+		return status, nil
+	}
+
+	if resources.JobConditionTrue(current, batchv1.JobComplete) {
+		status.State = mmv1beta.ImportCompleted
+		status.CompletionTime = current.Status.CompletionTime
+		status.Message = r.Resources.JobTerminationMessage(current, reqLogger)
+		return status, nil
+	}
+
+	// The progress of the import is reported by the step the Job is running.
+	switch r.Resources.JobActiveContainer(current, reqLogger) {
+	case mattermostApp.ValidateImportContainerName:
+		status.State = mmv1beta.ImportValidating
+	case mattermostApp.ImportContainerName:
+		status.State = mmv1beta.ImportImporting
+	}
+
+	return status, nil
+}
+
+func (r *MattermostReconciler) deleteImportJob(job *batchv1.Job, reqLogger logr.Logger) {
+	reqLogger.Info(fmt.Sprintf("Deleting import job %s/%s", job.GetNamespace(), job.GetName()))
+
+	err := r.Client.Delete(context.TODO(), job, k8sClient.PropagationPolicy(metav1.DeletePropagationBackground))
+	if err != nil && !k8sErrors.IsNotFound(err) {
+		// Do not return error on fail as it is not critical
+		reqLogger.Error(err, "Unable to delete import job")
+	}
+}
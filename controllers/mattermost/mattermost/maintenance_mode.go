@@ -0,0 +1,81 @@
+package mattermost
+
+import (
+	"context"
+	"time"
+
+	"github.com/go-logr/logr"
+	mmv1beta "github.com/mattermost/mattermost-operator/apis/mattermost/v1beta1"
+	mattermostApp "github.com/mattermost/mattermost-operator/pkg/mattermost"
+	"github.com/mattermost/mattermost-operator/pkg/mattermost/announcement"
+	"github.com/pkg/errors"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// maintenanceBannerRequeueDelay is the delay before the failed updates of the
+// announcement banner of the maintenance mode are retried.
+const maintenanceBannerRequeueDelay = 30 * time.Second
+
+// checkMaintenanceMode sets the announcement banner of the Mattermost in
+// maintenance mode, before its pods are restarted with a read-only database,
+// and removes it once the maintenance mode is disabled. The banner is set
+// once, failures to update it are reported in the status and retried.
+func (r *MattermostReconciler) checkMaintenanceMode(ctx context.Context, mattermost *mmv1beta.Mattermost, reqLogger logr.Logger) *mmv1beta.MaintenanceModeStatus {
+	previous := mattermost.Status.MaintenanceMode
+
+	if !mattermost.MaintenanceModeEnabled() {
+		if previous == nil {
+			return nil
+		}
+		if previous.BannerAccessTokenSecret != "" {
+			err := r.setMaintenanceBanner(ctx, mattermost, previous.BannerAccessTokenSecret, announcement.Banner{})
+			if err != nil {
+				reqLogger.Error(err, "Failed to remove the announcement banner of the maintenance mode")
+				status := previous.DeepCopy()
+				status.Error = err.Error()
+				return status
+			}
+		}
+		reqLogger.Info("Maintenance mode disabled")
+		r.recordEvent(mattermost, corev1.EventTypeNormal, "MaintenanceModeDisabled", "The Mattermost is no longer in maintenance mode")
+		return nil
+	}
+
+	status := &mmv1beta.MaintenanceModeStatus{}
+	if previous != nil {
+		status = previous.DeepCopy()
+	} else {
+		startTime := metav1.Now()
+		status.StartTime = &startTime
+		reqLogger.Info("Maintenance mode enabled")
+		r.recordEvent(mattermost, corev1.EventTypeNormal, "MaintenanceModeEnabled", "The Mattermost is in maintenance mode")
+	}
+
+	maintenanceMode := mattermost.Spec.MaintenanceMode
+	if maintenanceMode.AccessTokenSecret == "" || status.BannerAccessTokenSecret != "" {
+		return status
+	}
+	err := r.setMaintenanceBanner(ctx, mattermost, maintenanceMode.AccessTokenSecret, announcement.Banner{Enabled: true, Text: maintenanceMode.GetMessage()})
+	if err != nil {
+		reqLogger.Error(err, "Failed to set the announcement banner of the maintenance mode")
+		status.Error = err.Error()
+		return status
+	}
+	status.BannerAccessTokenSecret = maintenanceMode.AccessTokenSecret
+	status.Error = ""
+	return status
+}
+
+// setMaintenanceBanner updates the announcement banner of the Mattermost with
+// the access token of the Secret.
+func (r *MattermostReconciler) setMaintenanceBanner(ctx context.Context, mattermost *mmv1beta.Mattermost, tokenSecret string, banner announcement.Banner) error {
+	if r.Announcement == nil {
+		return errors.New("announcement client not configured")
+	}
+	token, err := r.accessToken(ctx, mattermost, tokenSecret)
+	if err != nil {
+		return errors.Wrap(err, "failed to get maintenance mode access token")
+	}
+	return r.Announcement.SetBanner(ctx, mattermostApp.ServiceURL(mattermost), token, banner)
+}
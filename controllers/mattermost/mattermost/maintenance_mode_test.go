@@ -0,0 +1,113 @@
+package mattermost
+
+import (
+	"context"
+	"testing"
+
+	blubr "github.com/mattermost/blubr"
+	mmv1beta "github.com/mattermost/mattermost-operator/apis/mattermost/v1beta1"
+	"github.com/mattermost/mattermost-operator/pkg/mattermost/announcement"
+	"github.com/pkg/errors"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes/scheme"
+	"k8s.io/client-go/tools/record"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+)
+
+type fakeAnnouncementClient struct {
+	banners []announcement.Banner
+	err     error
+}
+
+func (f *fakeAnnouncementClient) SetBanner(_ context.Context, _, token string, banner announcement.Banner) error {
+	if token != "admin-token" {
+		return errors.New("unexpected token")
+	}
+	if f.err != nil {
+		return f.err
+	}
+	f.banners = append(f.banners, banner)
+	return nil
+}
+
+func TestCheckMaintenanceMode(t *testing.T) {
+	logger := blubr.InitLogger()
+	s := prepareSchema(t, scheme.Scheme)
+
+	mattermost := &mmv1beta.Mattermost{
+		ObjectMeta: metav1.ObjectMeta{Name: "chat", Namespace: "team"},
+		Spec: mmv1beta.MattermostSpec{
+			MaintenanceMode: &mmv1beta.MaintenanceMode{Enabled: true, AccessTokenSecret: "admin"},
+		},
+	}
+	secret := &corev1.Secret{
+		ObjectMeta: metav1.ObjectMeta{Name: "admin", Namespace: "team"},
+		Data:       map[string][]byte{accessTokenKey: []byte("admin-token")},
+	}
+	c := fake.NewClientBuilder().WithScheme(s).WithObjects(mattermost, secret).Build()
+	announcements := &fakeAnnouncementClient{}
+	recorder := record.NewFakeRecorder(10)
+	r := &MattermostReconciler{
+		Client:       c,
+		Scheme:       s,
+		Log:          logger,
+		Recorder:     recorder,
+		Announcement: announcements,
+	}
+
+	status := r.checkMaintenanceMode(context.TODO(), mattermost, logger)
+	require.NotNil(t, status)
+	assert.NotNil(t, status.StartTime)
+	assert.Equal(t, "admin", status.BannerAccessTokenSecret)
+	assert.Empty(t, status.Error)
+	assert.Equal(t, []announcement.Banner{{Enabled: true, Text: mmv1beta.DefaultMaintenanceMessage}}, announcements.banners)
+	require.Len(t, recorder.Events, 1)
+	assert.Contains(t, <-recorder.Events, "MaintenanceModeEnabled")
+
+	// The banner is only set once.
+	mattermost.Status.MaintenanceMode = status
+	status = r.checkMaintenanceMode(context.TODO(), mattermost, logger)
+	assert.Equal(t, mattermost.Status.MaintenanceMode, status)
+	assert.Len(t, announcements.banners, 1)
+	assert.Len(t, recorder.Events, 0)
+
+	t.Run("failed removal", func(t *testing.T) {
+		disabled := mattermost.DeepCopy()
+		disabled.Spec.MaintenanceMode = nil
+		announcements.err = errors.New("read-only database")
+
+		status := r.checkMaintenanceMode(context.TODO(), disabled, logger)
+		require.NotNil(t, status)
+		assert.Equal(t, "admin", status.BannerAccessTokenSecret)
+		assert.Equal(t, "read-only database", status.Error)
+		assert.Len(t, recorder.Events, 0)
+	})
+
+	t.Run("disabled", func(t *testing.T) {
+		disabled := mattermost.DeepCopy()
+		disabled.Spec.MaintenanceMode.Enabled = false
+		announcements.err = nil
+
+		status := r.checkMaintenanceMode(context.TODO(), disabled, logger)
+		assert.Nil(t, status)
+		assert.Equal(t, announcement.Banner{}, announcements.banners[len(announcements.banners)-1])
+		require.Len(t, recorder.Events, 1)
+		assert.Contains(t, <-recorder.Events, "MaintenanceModeDisabled")
+	})
+
+	t.Run("missing token", func(t *testing.T) {
+		missing := mattermost.DeepCopy()
+		missing.Status.MaintenanceMode = nil
+		missing.Spec.MaintenanceMode.AccessTokenSecret = "missing"
+		recorder := record.NewFakeRecorder(10)
+		r.Recorder = recorder
+
+		status := r.checkMaintenanceMode(context.TODO(), missing, logger)
+		require.NotNil(t, status)
+		assert.Empty(t, status.BannerAccessTokenSecret)
+		assert.Contains(t, status.Error, "failed to get maintenance mode access token")
+	})
+}
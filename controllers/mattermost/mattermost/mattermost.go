@@ -3,6 +3,7 @@ package mattermost
 import (
 	"context"
 	"fmt"
+	"time"
 
 	"github.com/mattermost/mattermost-operator/pkg/resources"
 
@@ -26,14 +27,24 @@ func (r *MattermostReconciler) checkMattermost(
 	dbInfo mattermostApp.DatabaseConfig,
 	fileStoreInfo *mattermostApp.FileStoreInfo,
 	reqLogger logr.Logger) error {
-	reqLogger = reqLogger.WithValues("Reconcile", "mattermost")
+	reqLogger = reqLogger.WithValues("phase", "mattermost")
 
 	err := r.checkLicence(mattermost)
 	if err != nil {
 		return errors.Wrap(err, "failed to check mattermost license secret.")
 	}
 
-	err = r.checkMattermostService(mattermost, reqLogger)
+	err = r.checkMattermostService(mattermost, mattermost.Name, mattermost.GetProductionDeploymentName(), reqLogger)
+	if err != nil {
+		return err
+	}
+
+	err = r.checkMattermostClusterService(mattermost, reqLogger)
+	if err != nil {
+		return err
+	}
+
+	err = r.checkMonitoring(mattermost, reqLogger)
 	if err != nil {
 		return err
 	}
@@ -44,15 +55,17 @@ func (r *MattermostReconciler) checkMattermost(
 	}
 
 	if !mattermost.Spec.UseServiceLoadBalancer {
-		err = r.checkMattermostIngress(mattermost, reqLogger)
+		err = r.checkMattermostIngress(mattermost, mattermost.Name, mattermost.GetIngressHost(), reqLogger)
 		if err != nil {
 			return err
 		}
 	}
 
-	err = r.checkMattermostDeployment(mattermost, dbInfo, fileStoreInfo, reqLogger)
-	if err != nil {
-		return err
+	if !mattermost.BlueGreenEnabled() {
+		err = r.checkMattermostDeployment(mattermost, dbInfo, fileStoreInfo, reqLogger)
+		if err != nil {
+			return err
+		}
 	}
 
 	return nil
@@ -65,8 +78,48 @@ func (r *MattermostReconciler) checkLicence(mattermost *mmv1beta.Mattermost) err
 	return r.assertSecretContains(mattermost.Spec.LicenseSecret, "license", mattermost.Namespace)
 }
 
-func (r *MattermostReconciler) checkMattermostService(mattermost *mmv1beta.Mattermost, reqLogger logr.Logger) error {
-	desired := mattermostApp.GenerateServiceV1Beta(mattermost)
+func (r *MattermostReconciler) checkMattermostService(mattermost *mmv1beta.Mattermost, resourceName, selectorName string, reqLogger logr.Logger) error {
+	desired := mattermostApp.GenerateServiceV1Beta(mattermost, resourceName, selectorName)
+
+	err := r.Resources.CreateServiceIfNotExists(mattermost, desired, reqLogger)
+	if err != nil {
+		return err
+	}
+
+	current := &corev1.Service{}
+	err = r.Client.Get(context.TODO(), types.NamespacedName{Name: desired.Name, Namespace: desired.Namespace}, current)
+	if err != nil {
+		return err
+	}
+
+	err = r.adoptResource(mattermost, "Service", current, reqLogger)
+	if err != nil {
+		return err
+	}
+	err = r.keepAdoptedServiceSelector(current, desired, selectorName)
+	if err != nil {
+		return err
+	}
+
+	resources.CopyServiceEmptyAutoAssignedFields(desired, current)
+
+	return r.updateResource(mattermost, current, desired, reqLogger)
+}
+
+// checkMattermostClusterService ensures the headless Service the app servers
+// gossip through exists while clustering is enabled, and warns about
+// multi-replica installs that cannot run as a cluster.
+func (r *MattermostReconciler) checkMattermostClusterService(mattermost *mmv1beta.Mattermost, reqLogger logr.Logger) error {
+	if !mattermost.ClusteringEnabled() {
+		if mattermost.Spec.Replicas != nil && *mattermost.Spec.Replicas > 1 {
+			reqLogger.Info("Running multiple replicas without clustering", "replicas", *mattermost.Spec.Replicas)
+			r.Recorder.Event(mattermost, corev1.EventTypeWarning, "ClusteringUnavailable",
+				"Clustering requires the Enterprise Edition and a license, replicas will not share cluster state")
+		}
+		return r.Resources.DeleteService(types.NamespacedName{Name: mattermost.ClusterServiceName(), Namespace: mattermost.Namespace}, reqLogger)
+	}
+
+	desired := mattermostApp.GenerateClusterServiceV1Beta(mattermost, mattermost.GetProductionDeploymentName())
 
 	err := r.Resources.CreateServiceIfNotExists(mattermost, desired, reqLogger)
 	if err != nil {
@@ -81,7 +134,7 @@ func (r *MattermostReconciler) checkMattermostService(mattermost *mmv1beta.Matte
 
 	resources.CopyServiceEmptyAutoAssignedFields(desired, current)
 
-	return r.Resources.Update(current, desired, reqLogger)
+	return r.updateResource(mattermost, current, desired, reqLogger)
 }
 
 func (r *MattermostReconciler) checkMattermostRBAC(mattermost *mmv1beta.Mattermost, reqLogger logr.Logger) error {
@@ -114,7 +167,7 @@ func (r *MattermostReconciler) checkMattermostSA(mattermost *mmv1beta.Mattermost
 		return err
 	}
 
-	return r.Resources.Update(current, desired, reqLogger)
+	return r.updateResource(mattermost, current, desired, reqLogger)
 }
 
 func (r *MattermostReconciler) checkMattermostRole(mattermost *mmv1beta.Mattermost, reqLogger logr.Logger) error {
@@ -130,7 +183,7 @@ func (r *MattermostReconciler) checkMattermostRole(mattermost *mmv1beta.Mattermo
 		return err
 	}
 
-	return r.Resources.Update(current, desired, reqLogger)
+	return r.updateResource(mattermost, current, desired, reqLogger)
 }
 
 func (r *MattermostReconciler) checkMattermostRoleBinding(mattermost *mmv1beta.Mattermost, reqLogger logr.Logger) error {
@@ -146,13 +199,14 @@ func (r *MattermostReconciler) checkMattermostRoleBinding(mattermost *mmv1beta.M
 		return err
 	}
 
-	return r.Resources.Update(current, desired, reqLogger)
+	return r.updateResource(mattermost, current, desired, reqLogger)
 }
 
-func (r *MattermostReconciler) checkMattermostIngress(mattermost *mmv1beta.Mattermost, reqLogger logr.Logger) error {
-	desired := mattermostApp.GenerateIngressV1Beta(mattermost)
+func (r *MattermostReconciler) checkMattermostIngress(mattermost *mmv1beta.Mattermost, resourceName, host string, reqLogger logr.Logger) error {
+	desired := mattermostApp.GenerateIngressV1Beta(mattermost, resourceName, host)
 
-	if !mattermost.IngressEnabled() {
+	// The ingress of a standby Mattermost is created once it is promoted.
+	if !mattermost.IngressEnabled() || mattermost.StandbyEnabled() {
 		err := r.Resources.DeleteIngress(types.NamespacedName{Namespace: desired.Namespace, Name: desired.Name}, reqLogger)
 		if err != nil {
 			return errors.Wrap(err, "failed to delete disabled ingress")
@@ -160,6 +214,11 @@ func (r *MattermostReconciler) checkMattermostIngress(mattermost *mmv1beta.Matte
 		return nil
 	}
 
+	return r.checkIngress(mattermost, desired, reqLogger)
+}
+
+// checkIngress creates or updates the desired Mattermost ingress.
+func (r *MattermostReconciler) checkIngress(mattermost *mmv1beta.Mattermost, desired *networkingv1.Ingress, reqLogger logr.Logger) error {
 	err := r.Resources.CreateIngressIfNotExists(mattermost, desired, reqLogger)
 	if err != nil {
 		return err
@@ -171,25 +230,52 @@ func (r *MattermostReconciler) checkMattermostIngress(mattermost *mmv1beta.Matte
 		return err
 	}
 
-	return r.Resources.Update(current, desired, reqLogger)
+	err = r.adoptResource(mattermost, "Ingress", current, reqLogger)
+	if err != nil {
+		return err
+	}
+
+	return r.updateResource(mattermost, current, desired, reqLogger)
 }
 
-func (r *MattermostReconciler) checkMattermostDeployment(
-	mattermost *mmv1beta.Mattermost,
-	dbConfig mattermostApp.DatabaseConfig,
-	fileStoreInfo *mattermostApp.FileStoreInfo,
-	reqLogger logr.Logger) error {
+// generateMattermostDeployment returns the desired Mattermost deployment, the
+// production deployment if BlueGreen is enabled. It runs the previous image
+// if the upgrade to the desired image was rolled back, and no pods while the
+// Mattermost is a standby.
+func generateMattermostDeployment(mattermost *mmv1beta.Mattermost, dbConfig mattermostApp.DatabaseConfig, fileStoreInfo *mattermostApp.FileStoreInfo) *appsv1.Deployment {
+	imageName := mattermost.GetProductionImageName()
+	if upgradeRolledBack(mattermost) {
+		imageName, _, _ = mattermostImage(mattermost)
+	}
 
-	desired := mattermostApp.GenerateDeploymentV1Beta(
+	deployment := mattermostApp.GenerateDeploymentV1Beta(
 		mattermost,
 		dbConfig,
 		fileStoreInfo,
-		mattermost.Name,
+		mattermost.GetProductionDeploymentName(),
 		mattermost.GetIngressHost(),
 		mattermost.Name,
-		mattermost.GetImageName(),
+		imageName,
 	)
+	if mattermost.StandbyEnabled() {
+		var replicas int32
+		deployment.Spec.Replicas = &replicas
+	}
+
+	return deployment
+}
+
+func (r *MattermostReconciler) checkMattermostDeployment(
+	mattermost *mmv1beta.Mattermost,
+	dbConfig mattermostApp.DatabaseConfig,
+	fileStoreInfo *mattermostApp.FileStoreInfo,
+	reqLogger logr.Logger) error {
+
+	return r.checkDeployment(mattermost, generateMattermostDeployment(mattermost, dbConfig, fileStoreInfo), reqLogger)
+}
 
+// checkDeployment creates or updates the desired Mattermost deployment.
+func (r *MattermostReconciler) checkDeployment(mattermost *mmv1beta.Mattermost, desired *appsv1.Deployment, reqLogger logr.Logger) error {
 	// TODO: DB setup job is temporarily disabled as `mattermost version` command
 	// does not account for the custom configuration
 	//err = r.checkMattermostDBSetupJob(mattermost, desired, reqLogger)
@@ -197,6 +283,13 @@ func (r *MattermostReconciler) checkMattermostDeployment(
 	//	return errors.Wrap(err, "failed to check mattermost DB setup job")
 	//}
 
+	if mattermost.MaintenanceWindowEnabled() && desired.Name == mattermost.Name {
+		err := mattermostApp.SetPodTemplateHash(desired)
+		if err != nil {
+			return errors.Wrap(err, "failed to set pod template hash")
+		}
+	}
+
 	err := r.Resources.CreateDeploymentIfNotExists(mattermost, desired, reqLogger)
 	if err != nil {
 		return errors.Wrap(err, "failed to create mattermost deployment")
@@ -208,6 +301,12 @@ func (r *MattermostReconciler) checkMattermostDeployment(
 		return errors.Wrap(err, "failed to get mattermost deployment")
 	}
 
+	err = r.adoptResource(mattermost, "Deployment", current, reqLogger)
+	if err != nil {
+		return err
+	}
+	keepAdoptedSelector(current, desired)
+
 	err = r.updateMattermostDeployment(mattermost, current, desired, reqLogger)
 	if err != nil {
 		return errors.Wrap(err, "failed to update mattermost deployment")
@@ -219,6 +318,10 @@ func (r *MattermostReconciler) checkMattermostDeployment(
 func (r *MattermostReconciler) checkMattermostDBSetupJob(mattermost *mmv1beta.Mattermost, deployment *appsv1.Deployment, reqLogger logr.Logger) error {
 	desiredJob := resources.PrepareMattermostJobTemplate(mattermostApp.SetupJobName, mattermost.Namespace, deployment)
 	desiredJob.OwnerReferences = mattermostApp.MattermostOwnerReference(mattermost)
+	mattermostApp.SetJobPodSettings(mattermost, &desiredJob.Spec.Template.Spec)
+	if mattermost.Spec.Jobs != nil {
+		mattermostApp.SetJobPolicy(mattermost.Spec.Jobs.DatabaseSetup, &desiredJob.Spec)
+	}
 
 	currentJob := &batchv1.Job{}
 	err := r.Client.Get(context.TODO(), types.NamespacedName{Name: desiredJob.Name, Namespace: desiredJob.Namespace}, currentJob)
@@ -281,6 +384,38 @@ func (r *MattermostReconciler) updateMattermostDeployment(
 	desired *appsv1.Deployment,
 	reqLogger logr.Logger,
 ) error {
+	queued, nextWindow, err := updateQueued(mattermost, current, desired, time.Now())
+	if err != nil {
+		return err
+	}
+	if queued {
+		reqLogger.Info("Changes restarting the Mattermost pods are queued until the maintenance window", "nextWindow", nextWindow)
+		holdPodTemplate(current, desired)
+	} else {
+		waiting, err := r.waitingForUpgradeSlot(mattermost, current, desired)
+		if err != nil {
+			return err
+		}
+		if waiting {
+			reqLogger.Info("Upgrade queued until fewer installations are upgraded", "maxConcurrentUpgrades", r.MaxConcurrentUpgrades)
+			holdPodTemplate(current, desired)
+		}
+	}
+
+	// The restarts by self-healing are kept, once applied to the current
+	// deployment, and the restarts requested by the restart annotation are
+	// applied. The latest restart wins.
+	var restartTime time.Time
+	if health := mattermost.Status.ApplicationHealth; health != nil && health.LastRestartTime != nil {
+		restartTime = health.LastRestartTime.Time
+	}
+	if requested, ok := mattermostApp.RestartRequestedAt(mattermost); ok && requested.After(restartTime) {
+		restartTime = requested
+	}
+	if !restartTime.IsZero() {
+		mattermostApp.SetRestartedAt(desired, restartTime)
+	}
+
 	sameImage, err := r.isMainDeploymentContainerImageSame(current, desired)
 	if err != nil {
 		return err
@@ -288,7 +423,14 @@ func (r *MattermostReconciler) updateMattermostDeployment(
 
 	if sameImage {
 		// Need to update other fields only, update job is not required
-		return r.Resources.Update(current, desired, reqLogger)
+		return r.updateResource(mattermost, current, desired, reqLogger)
+	}
+
+	if upgradeRolledBack(mattermost) && desired.Name == mattermost.Name {
+		// The previous image already ran with the database, update job is
+		// not required
+		reqLogger.Info("Rolling back the Mattermost upgrade", "image", mattermost.Status.Upgrade.FromImage)
+		return r.updateResource(mattermost, current, desired, reqLogger)
 	}
 
 	// Image is not the same
@@ -299,7 +441,26 @@ func (r *MattermostReconciler) updateMattermostDeployment(
 
 	reqLogger.Info("Current image is not the same as the requested, will upgrade the Mattermost installation")
 
-	job, err := r.checkUpdateJob(mattermost.Namespace, desired, reqLogger)
+	// The update job of a standby Mattermost would migrate the database of
+	// the active installation, the image of its idle deployment is replaced
+	// without it.
+	if mattermost.StandbyEnabled() {
+		reqLogger.Info("Update check job skipped for the standby Mattermost")
+		return r.updateResource(mattermost, current, desired, reqLogger)
+	}
+
+	if mattermost.UpdateCheckDisabled() {
+		reqLogger.Info("Update check job skipped, rolling out the new image in place")
+		err = r.updateResource(mattermost, current, desired, reqLogger)
+		if err != nil {
+			return err
+		}
+		r.recordEvent(mattermost, corev1.EventTypeNormal, "UpgradeStarted",
+			fmt.Sprintf("Upgrading Mattermost from %s to %s", mattermostContainerImage(current), mattermostContainerImage(desired)))
+		return nil
+	}
+
+	job, err := r.checkUpdateJob(mattermost, desired, reqLogger)
 	if job != nil {
 		// Job is done, need to cleanup
 		defer r.cleanupUpdateJob(job, reqLogger)
@@ -310,23 +471,25 @@ func (r *MattermostReconciler) updateMattermostDeployment(
 
 	// Job completed successfully
 
-	return r.Resources.Update(current, desired, reqLogger)
+	return r.updateResource(mattermost, current, desired, reqLogger)
 }
 
 // checkUpdateJob checks whether update job status. In case job is not running it is launched
 func (r *MattermostReconciler) checkUpdateJob(
-	jobNamespace string,
+	mattermost *mmv1beta.Mattermost,
 	baseDeployment *appsv1.Deployment,
 	reqLogger logr.Logger,
 ) (*batchv1.Job, error) {
 	reqLogger.Info(fmt.Sprintf("Running Mattermost update image job check for image %s", mmv1beta.GetMattermostAppContainerFromDeployment(baseDeployment).Image))
-	job, err := r.Resources.FetchMattermostUpdateJob(jobNamespace)
+	job, err := r.Resources.FetchMattermostUpdateJob(mattermost.Namespace)
 	if err != nil {
 		if k8sErrors.IsNotFound(err) {
 			reqLogger.Info("Launching update image job")
-			if err = r.Resources.LaunchMattermostUpdateJob(jobNamespace, baseDeployment); err != nil {
+			if err = r.Resources.LaunchMattermostUpdateJob(mattermost.Namespace, baseDeployment, mattermost); err != nil {
 				return nil, errors.Wrap(err, "Launching update image job failed")
 			}
+			r.recordEvent(mattermost, corev1.EventTypeNormal, "UpgradeStarted",
+				fmt.Sprintf("Checking %s with the update image job before upgrading Mattermost", mattermostContainerImage(baseDeployment)))
 			return nil, errors.New("Began update image job")
 		}
 
@@ -345,10 +508,12 @@ func (r *MattermostReconciler) checkUpdateJob(
 	}
 	if !isSameImage {
 		reqLogger.Info("Mattermost image changed, restarting update job")
-		err := r.Resources.RestartMattermostUpdateJob(job, baseDeployment)
+		err := r.Resources.RestartMattermostUpdateJob(job, baseDeployment, mattermost)
 		if err != nil {
 			return nil, errors.Wrap(err, "failed to restart update job")
 		}
+		r.recordEvent(mattermost, corev1.EventTypeNormal, "UpgradeStarted",
+			fmt.Sprintf("Checking %s with the update image job before upgrading Mattermost", mattermostContainerImage(baseDeployment)))
 
 		return nil, errors.New("Restarted update image job")
 	}
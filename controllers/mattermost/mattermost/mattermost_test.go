@@ -7,6 +7,7 @@ import (
 	"testing"
 
 	"github.com/mattermost/mattermost-operator/pkg/resources"
+	resourcesfake "github.com/mattermost/mattermost-operator/pkg/resources/fake"
 
 	mmv1beta "github.com/mattermost/mattermost-operator/apis/mattermost/v1beta1"
 	mattermostApp "github.com/mattermost/mattermost-operator/pkg/mattermost"
@@ -26,6 +27,7 @@ import (
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/types"
 	"k8s.io/client-go/kubernetes/scheme"
+	"k8s.io/client-go/tools/record"
 	"sigs.k8s.io/controller-runtime/pkg/client/fake"
 	logf "sigs.k8s.io/controller-runtime/pkg/log"
 )
@@ -75,11 +77,12 @@ func TestCheckMattermost(t *testing.T) {
 		Scheme:         s,
 		Log:            logger,
 		MaxReconciling: 5,
-		Resources:      resources.NewResourceHelper(c, s),
+		Resources:      resources.NewResourceHelper(resourcesfake.NewApplyClient(c), s),
+		Recorder:       record.NewFakeRecorder(100),
 	}
 
 	t.Run("service", func(t *testing.T) {
-		err = r.checkMattermostService(mm, logger)
+		err = r.checkMattermostService(mm, mm.Name, mm.Name, logger)
 		assert.NoError(t, err)
 
 		found := &corev1.Service{}
@@ -95,7 +98,7 @@ func TestCheckMattermost(t *testing.T) {
 
 		err = r.Client.Update(context.TODO(), modified)
 		require.NoError(t, err)
-		err = r.checkMattermostService(mm, logger)
+		err = r.checkMattermostService(mm, mm.Name, mm.Name, logger)
 		require.NoError(t, err)
 		err = r.Client.Get(context.TODO(), types.NamespacedName{Name: mmName, Namespace: mmNamespace}, found)
 		require.NoError(t, err)
@@ -172,7 +175,7 @@ func TestCheckMattermost(t *testing.T) {
 
 	t.Run("ingress no tls", func(t *testing.T) {
 		mm.Spec.UseIngressTLS = false
-		err = r.checkMattermostIngress(mm, logger)
+		err = r.checkMattermostIngress(mm, mm.Name, mm.GetIngressHost(), logger)
 		assert.NoError(t, err)
 
 		found := &v1beta1.Ingress{}
@@ -189,7 +192,7 @@ func TestCheckMattermost(t *testing.T) {
 
 		err = r.Client.Update(context.TODO(), modified)
 		require.NoError(t, err)
-		err = r.checkMattermostIngress(mm, logger)
+		err = r.checkMattermostIngress(mm, mm.Name, mm.GetIngressHost(), logger)
 		require.NoError(t, err)
 		err = r.Client.Get(context.TODO(), types.NamespacedName{Name: mmName, Namespace: mmNamespace}, found)
 		require.NoError(t, err)
@@ -206,7 +209,7 @@ func TestCheckMattermost(t *testing.T) {
 			"test-ingress":                "blabla",
 		}
 
-		err = r.checkMattermostIngress(mm, logger)
+		err = r.checkMattermostIngress(mm, mm.Name, mm.GetIngressHost(), logger)
 		assert.NoError(t, err)
 
 		found := &v1beta1.Ingress{}
@@ -225,7 +228,7 @@ func TestCheckMattermost(t *testing.T) {
 
 		err = r.Client.Update(context.TODO(), modified)
 		require.NoError(t, err)
-		err = r.checkMattermostIngress(mm, logger)
+		err = r.checkMattermostIngress(mm, mm.Name, mm.GetIngressHost(), logger)
 		require.NoError(t, err)
 		err = r.Client.Get(context.TODO(), types.NamespacedName{Name: mmName, Namespace: mmNamespace}, found)
 		require.NoError(t, err)
@@ -237,7 +240,7 @@ func TestCheckMattermost(t *testing.T) {
 	})
 
 	t.Run("ingress disabled", func(t *testing.T) {
-		err = r.checkMattermostIngress(mm, logger)
+		err = r.checkMattermostIngress(mm, mm.Name, mm.GetIngressHost(), logger)
 		assert.NoError(t, err)
 
 		found := &v1beta1.Ingress{}
@@ -247,7 +250,7 @@ func TestCheckMattermost(t *testing.T) {
 
 		mm.Spec.Ingress = &mmv1beta.Ingress{Enabled: false}
 
-		err = r.checkMattermostIngress(mm, logger)
+		err = r.checkMattermostIngress(mm, mm.Name, mm.GetIngressHost(), logger)
 		require.NoError(t, err)
 
 		err = r.Client.Get(context.TODO(), types.NamespacedName{Name: mmName, Namespace: mmNamespace}, found)
@@ -432,7 +435,8 @@ func TestCheckMattermostExternalDBAndFileStore(t *testing.T) {
 		Scheme:         s,
 		Log:            logger,
 		MaxReconciling: 5,
-		Resources:      resources.NewResourceHelper(c, s),
+		Resources:      resources.NewResourceHelper(resourcesfake.NewApplyClient(c), s),
+		Recorder:       record.NewFakeRecorder(100),
 	}
 
 	externalDBSecret := &corev1.Secret{
@@ -448,7 +452,7 @@ func TestCheckMattermostExternalDBAndFileStore(t *testing.T) {
 	require.NoError(t, err)
 
 	t.Run("service", func(t *testing.T) {
-		err = r.checkMattermostService(mm, logger)
+		err = r.checkMattermostService(mm, mm.Name, mm.Name, logger)
 		assert.NoError(t, err)
 
 		found := &corev1.Service{}
@@ -464,7 +468,7 @@ func TestCheckMattermostExternalDBAndFileStore(t *testing.T) {
 
 		err = r.Client.Update(context.TODO(), modified)
 		require.NoError(t, err)
-		err = r.checkMattermostService(mm, logger)
+		err = r.checkMattermostService(mm, mm.Name, mm.Name, logger)
 		require.NoError(t, err)
 		err = r.Client.Get(context.TODO(), types.NamespacedName{Name: mmName, Namespace: mmNamespace}, found)
 		require.NoError(t, err)
@@ -475,7 +479,7 @@ func TestCheckMattermostExternalDBAndFileStore(t *testing.T) {
 	})
 
 	t.Run("ingress", func(t *testing.T) {
-		err = r.checkMattermostIngress(mm, logger)
+		err = r.checkMattermostIngress(mm, mm.Name, mm.GetIngressHost(), logger)
 		assert.NoError(t, err)
 
 		found := &v1beta1.Ingress{}
@@ -491,7 +495,7 @@ func TestCheckMattermostExternalDBAndFileStore(t *testing.T) {
 
 		err = r.Client.Update(context.TODO(), modified)
 		require.NoError(t, err)
-		err = r.checkMattermostIngress(mm, logger)
+		err = r.checkMattermostIngress(mm, mm.Name, mm.GetIngressHost(), logger)
 		require.NoError(t, err)
 		err = r.Client.Get(context.TODO(), types.NamespacedName{Name: mmName, Namespace: mmNamespace}, found)
 		require.NoError(t, err)
@@ -595,12 +599,13 @@ func TestSpecialCases(t *testing.T) {
 		Scheme:         s,
 		Log:            logger,
 		MaxReconciling: 5,
-		Resources:      resources.NewResourceHelper(c, s),
+		Resources:      resources.NewResourceHelper(resourcesfake.NewApplyClient(c), s),
+		Recorder:       record.NewFakeRecorder(100),
 	}
 
 	t.Run("service - copy ClusterIP for LoadBalancer service", func(t *testing.T) {
 		// Create the service
-		err := r.checkMattermostService(mm, logger)
+		err := r.checkMattermostService(mm, mm.Name, mm.Name, logger)
 		require.NoError(t, err)
 
 		service := &corev1.Service{}
@@ -613,7 +618,7 @@ func TestSpecialCases(t *testing.T) {
 		require.NoError(t, err)
 
 		mm.Spec.ResourceLabels = map[string]string{"myLabel": "test"}
-		err = r.checkMattermostService(mm, logger)
+		err = r.checkMattermostService(mm, mm.Name, mm.Name, logger)
 		require.NoError(t, err)
 
 		modified := &corev1.Service{}
@@ -0,0 +1,151 @@
+package mattermost
+
+import (
+	"sync"
+	"time"
+
+	mmv1beta "github.com/mattermost/mattermost-operator/apis/mattermost/v1beta1"
+	"github.com/prometheus/client_golang/prometheus"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/metrics"
+)
+
+const (
+	reconcileResultSuccess = "success"
+	reconcileResultError   = "error"
+)
+
+var (
+	reconcileDurationHistogram = prometheus.NewHistogramVec(
+		prometheus.HistogramOpts{
+			Name: "mattermost_operator_reconcile_duration_seconds",
+			Help: "Time the reconciliations of the installation took, by result.",
+		},
+		[]string{"namespace", "name", "result"},
+	)
+	reconcilesCounter = prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Name: "mattermost_operator_reconciles_total",
+			Help: "Number of reconciliations of the installation, by result.",
+		},
+		[]string{"namespace", "name", "result"},
+	)
+	lastSuccessfulReconcileGauge = prometheus.NewGaugeVec(
+		prometheus.GaugeOpts{
+			Name: "mattermost_operator_last_successful_reconcile_timestamp_seconds",
+			Help: "Unix time of the last successful reconciliation of the installation.",
+		},
+		[]string{"namespace", "name"},
+	)
+	installationStateGauge = prometheus.NewGaugeVec(
+		prometheus.GaugeOpts{
+			Name: "mattermost_operator_installation_state",
+			Help: "Whether the installation is in the state.",
+		},
+		[]string{"namespace", "name", "state"},
+	)
+	installationInfoGauge = prometheus.NewGaugeVec(
+		prometheus.GaugeOpts{
+			Name: "mattermost_operator_installation_info",
+			Help: "The version and the image the installation runs.",
+		},
+		[]string{"namespace", "name", "version", "image"},
+	)
+	readyReplicasGauge = prometheus.NewGaugeVec(
+		prometheus.GaugeOpts{
+			Name: "mattermost_operator_ready_replicas",
+			Help: "Number of ready Mattermost app server replicas of the installation.",
+		},
+		[]string{"namespace", "name"},
+	)
+	desiredReplicasGauge = prometheus.NewGaugeVec(
+		prometheus.GaugeOpts{
+			Name: "mattermost_operator_desired_replicas",
+			Help: "Number of desired Mattermost app server replicas of the installation.",
+		},
+		[]string{"namespace", "name"},
+	)
+
+	// installationInfoLabels are the labels of the info series of the
+	// installations, deleted once the version or the image changes, or the
+	// installation is deleted.
+	installationInfoLabels   = map[types.NamespacedName]prometheus.Labels{}
+	installationInfoLabelsMu sync.Mutex
+)
+
+func init() {
+	metrics.Registry.MustRegister(
+		reconcileDurationHistogram,
+		reconcilesCounter,
+		lastSuccessfulReconcileGauge,
+		installationStateGauge,
+		installationInfoGauge,
+		readyReplicasGauge,
+		desiredReplicasGauge,
+	)
+}
+
+// observeReconcile reports the duration and the result of the
+// reconciliation, and the state, the version and the replicas of the
+// installation from its status.
+func observeReconcile(mattermost *mmv1beta.Mattermost, duration time.Duration, err error) {
+	result := reconcileResultSuccess
+	if err != nil {
+		result = reconcileResultError
+	}
+	reconcileDurationHistogram.WithLabelValues(mattermost.Namespace, mattermost.Name, result).Observe(duration.Seconds())
+	reconcilesCounter.WithLabelValues(mattermost.Namespace, mattermost.Name, result).Inc()
+	if err == nil {
+		lastSuccessfulReconcileGauge.WithLabelValues(mattermost.Namespace, mattermost.Name).SetToCurrentTime()
+	}
+
+	status := mattermost.Status
+	for _, state := range []mmv1beta.RunningState{mmv1beta.Reconciling, mmv1beta.Stable} {
+		value := 0.0
+		if status.State == state {
+			value = 1
+		}
+		installationStateGauge.WithLabelValues(mattermost.Namespace, mattermost.Name, string(state)).Set(value)
+	}
+
+	var ready, desired int32
+	for _, component := range status.Components {
+		if component.Component == mmv1beta.AppComponent {
+			ready += component.ReadyReplicas
+			desired += component.DesiredReplicas
+		}
+	}
+	readyReplicasGauge.WithLabelValues(mattermost.Namespace, mattermost.Name).Set(float64(ready))
+	desiredReplicasGauge.WithLabelValues(mattermost.Namespace, mattermost.Name).Set(float64(desired))
+
+	labels := prometheus.Labels{"namespace": mattermost.Namespace, "name": mattermost.Name, "version": status.Version, "image": status.Image}
+	key := types.NamespacedName{Namespace: mattermost.Namespace, Name: mattermost.Name}
+	installationInfoLabelsMu.Lock()
+	defer installationInfoLabelsMu.Unlock()
+	if previous, ok := installationInfoLabels[key]; ok {
+		installationInfoGauge.Delete(previous)
+	}
+	installationInfoGauge.With(labels).Set(1)
+	installationInfoLabels[key] = labels
+}
+
+func deleteInstallationMetrics(namespace, name string) {
+	for _, result := range []string{reconcileResultSuccess, reconcileResultError} {
+		reconcileDurationHistogram.DeleteLabelValues(namespace, name, result)
+		reconcilesCounter.DeleteLabelValues(namespace, name, result)
+	}
+	lastSuccessfulReconcileGauge.DeleteLabelValues(namespace, name)
+	for _, state := range []mmv1beta.RunningState{mmv1beta.Reconciling, mmv1beta.Stable} {
+		installationStateGauge.DeleteLabelValues(namespace, name, string(state))
+	}
+	readyReplicasGauge.DeleteLabelValues(namespace, name)
+	desiredReplicasGauge.DeleteLabelValues(namespace, name)
+
+	key := types.NamespacedName{Namespace: namespace, Name: name}
+	installationInfoLabelsMu.Lock()
+	defer installationInfoLabelsMu.Unlock()
+	if labels, ok := installationInfoLabels[key]; ok {
+		installationInfoGauge.Delete(labels)
+		delete(installationInfoLabels, key)
+	}
+}
@@ -0,0 +1,71 @@
+package mattermost
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	mmv1beta "github.com/mattermost/mattermost-operator/apis/mattermost/v1beta1"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"sigs.k8s.io/controller-runtime/pkg/metrics"
+)
+
+func TestObserveReconcile(t *testing.T) {
+	// gauges returns the values of the series of the metric of the
+	// installation, keyed by the value of the label.
+	gauges := func(t *testing.T, name, label string) map[string]float64 {
+		families, err := metrics.Registry.Gather()
+		require.NoError(t, err)
+		values := map[string]float64{}
+		for _, family := range families {
+			if family.GetName() != name {
+				continue
+			}
+			for _, metric := range family.GetMetric() {
+				labels := map[string]string{}
+				for _, pair := range metric.GetLabel() {
+					labels[pair.GetName()] = pair.GetValue()
+				}
+				if labels["name"] == "mm-metrics" {
+					values[labels[label]] = metric.GetGauge().GetValue()
+				}
+			}
+		}
+		return values
+	}
+
+	mattermost := &mmv1beta.Mattermost{
+		ObjectMeta: metav1.ObjectMeta{Name: "mm-metrics", Namespace: "mm-namespace"},
+		Status: mmv1beta.MattermostStatus{
+			State:   mmv1beta.Stable,
+			Version: "6.0.0",
+			Image:   "mattermost/mattermost-enterprise-edition",
+			Components: []mmv1beta.ComponentStatus{
+				{Component: mmv1beta.AppComponent, DesiredReplicas: 2, ReadyReplicas: 1},
+				{Component: mmv1beta.DatabaseComponent, DesiredReplicas: 3, ReadyReplicas: 3},
+			},
+		},
+	}
+	defer deleteInstallationMetrics("mm-namespace", "mm-metrics")
+
+	observeReconcile(mattermost, time.Second, nil)
+	assert.Equal(t, map[string]float64{"stable": 1, "reconciling": 0}, gauges(t, "mattermost_operator_installation_state", "state"))
+	assert.Equal(t, map[string]float64{"mm-namespace": 1}, gauges(t, "mattermost_operator_ready_replicas", "namespace"))
+	assert.Equal(t, map[string]float64{"mm-namespace": 2}, gauges(t, "mattermost_operator_desired_replicas", "namespace"))
+	assert.NotZero(t, gauges(t, "mattermost_operator_last_successful_reconcile_timestamp_seconds", "namespace")["mm-namespace"])
+	assert.Equal(t, map[string]float64{"6.0.0": 1}, gauges(t, "mattermost_operator_installation_info", "version"))
+
+	t.Run("version changed", func(t *testing.T) {
+		mattermost.Status.Version = "6.1.0"
+		observeReconcile(mattermost, time.Second, errors.New("failed"))
+		assert.Equal(t, map[string]float64{"6.1.0": 1}, gauges(t, "mattermost_operator_installation_info", "version"))
+	})
+
+	t.Run("deleted", func(t *testing.T) {
+		deleteInstallationMetrics("mm-namespace", "mm-metrics")
+		assert.Empty(t, gauges(t, "mattermost_operator_installation_info", "version"))
+		assert.Empty(t, gauges(t, "mattermost_operator_installation_state", "state"))
+	})
+}
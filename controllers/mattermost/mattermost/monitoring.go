@@ -0,0 +1,117 @@
+package mattermost
+
+import (
+	"context"
+
+	"github.com/go-logr/logr"
+	mmv1beta "github.com/mattermost/mattermost-operator/apis/mattermost/v1beta1"
+	mattermostMinio "github.com/mattermost/mattermost-operator/pkg/components/minio"
+	mattermostmysql "github.com/mattermost/mattermost-operator/pkg/components/mysql"
+	mattermostApp "github.com/mattermost/mattermost-operator/pkg/mattermost"
+	"github.com/pkg/errors"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/equality"
+	k8sErrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/meta"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/types"
+)
+
+// checkMonitoring ensures the ServiceMonitor of the Mattermost, the
+// PodMonitors of its operator managed database and file store and the
+// PrometheusRule with its alerts exist while monitoring is enabled, and
+// deletes them otherwise. The monitors are skipped with a warning if the
+// Prometheus Operator is not installed.
+func (r *MattermostReconciler) checkMonitoring(mattermost *mmv1beta.Mattermost, reqLogger logr.Logger) error {
+	if mattermost.MonitoringEnabled() && !mattermost.MonitoringSupported() {
+		r.Recorder.Event(mattermost, corev1.EventTypeWarning, "MonitoringUnavailable",
+			"Performance monitoring requires the Enterprise Edition and a license, the Mattermost metrics are not scraped")
+	}
+
+	monitors := []struct {
+		desired *unstructured.Unstructured
+		enabled bool
+	}{
+		{
+			desired: mattermostApp.GenerateServiceMonitorV1Beta(mattermost),
+			enabled: mattermost.AppMonitoringEnabled(),
+		},
+		{
+			desired: mattermostmysql.PodMonitorV1Beta(mattermost),
+			enabled: mattermost.MonitoringEnabled() && !mattermost.Spec.Database.IsExternal(),
+		},
+		{
+			desired: mattermostMinio.PodMonitorV1Beta(mattermost),
+			enabled: mattermost.MonitoringEnabled() && !mattermost.Spec.FileStore.IsExternal(),
+		},
+		{
+			desired: mattermostApp.GeneratePrometheusRuleV1Beta(mattermost),
+			enabled: mattermost.AlertsEnabled(),
+		},
+	}
+
+	for _, monitor := range monitors {
+		err := r.checkMonitor(mattermost, monitor.desired, monitor.enabled, reqLogger)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// checkMonitor creates or updates the ServiceMonitor, PodMonitor or
+// PrometheusRule if it is enabled, and deletes it otherwise.
+func (r *MattermostReconciler) checkMonitor(mattermost *mmv1beta.Mattermost, desired *unstructured.Unstructured, enabled bool, reqLogger logr.Logger) error {
+	kind := desired.GetKind()
+
+	current := &unstructured.Unstructured{}
+	current.SetGroupVersionKind(desired.GroupVersionKind())
+	err := r.Client.Get(context.TODO(), types.NamespacedName{Name: desired.GetName(), Namespace: desired.GetNamespace()}, current)
+	if meta.IsNoMatchError(err) {
+		if enabled {
+			r.Recorder.Event(mattermost, corev1.EventTypeWarning, "MonitoringUnavailable",
+				"The "+kind+" cannot be created, the Prometheus Operator is not installed")
+		}
+		return nil
+	}
+	exists := err == nil
+	if err != nil && !k8sErrors.IsNotFound(err) {
+		return errors.Wrapf(err, "failed to check if %s exists", kind)
+	}
+
+	if !enabled {
+		if !exists {
+			return nil
+		}
+		reqLogger.Info("Deleting monitor", "kind", kind, "name", current.GetName())
+		err = r.Client.Delete(context.TODO(), current)
+		if err != nil && !k8sErrors.IsNotFound(err) {
+			return errors.Wrapf(err, "failed to delete %s", kind)
+		}
+		return nil
+	}
+
+	if !exists {
+		reqLogger.Info("Creating monitor", "kind", kind, "name", desired.GetName())
+		err = r.Client.Create(context.TODO(), desired)
+		if err != nil {
+			return errors.Wrapf(err, "failed to create %s", kind)
+		}
+		return nil
+	}
+
+	// Monitors are unstructured, so the spec and the labels are compared
+	// directly instead of with the last applied configuration.
+	if equality.Semantic.DeepEqual(current.Object["spec"], desired.Object["spec"]) &&
+		equality.Semantic.DeepEqual(current.GetLabels(), desired.GetLabels()) {
+		return nil
+	}
+	reqLogger.Info("Updating monitor", "kind", kind, "name", desired.GetName())
+	current.Object["spec"] = desired.Object["spec"]
+	current.SetLabels(desired.GetLabels())
+	err = r.Client.Update(context.TODO(), current)
+	if err != nil {
+		return errors.Wrapf(err, "failed to update %s", kind)
+	}
+	return nil
+}
@@ -0,0 +1,102 @@
+package mattermost
+
+import (
+	"context"
+	"testing"
+
+	blubr "github.com/mattermost/blubr"
+	mmv1beta "github.com/mattermost/mattermost-operator/apis/mattermost/v1beta1"
+	mattermostMinio "github.com/mattermost/mattermost-operator/pkg/components/minio"
+	mattermostmysql "github.com/mattermost/mattermost-operator/pkg/components/mysql"
+	mattermostApp "github.com/mattermost/mattermost-operator/pkg/mattermost"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	k8sErrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/kubernetes/scheme"
+	"k8s.io/client-go/tools/record"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+)
+
+func TestCheckMonitoring(t *testing.T) {
+	logger := blubr.InitLogger()
+	s := prepareSchema(t, scheme.Scheme)
+	s.AddKnownTypes(mmv1beta.GroupVersion, &mmv1beta.Mattermost{})
+
+	client := fake.NewFakeClientWithScheme(s)
+	recorder := record.NewFakeRecorder(10)
+	r := &MattermostReconciler{Client: client, Scheme: s, Recorder: recorder}
+
+	mattermost := &mmv1beta.Mattermost{
+		ObjectMeta: metav1.ObjectMeta{Name: "mm", Namespace: "mm-namespace", UID: "mm-uid"},
+		Spec: mmv1beta.MattermostSpec{
+			Monitoring: &mmv1beta.Monitoring{Enabled: true, Interval: "30s", Alerts: &mmv1beta.MonitoringAlerts{Enabled: true}},
+		},
+	}
+	getMonitor := func(gvk schema.GroupVersionKind, name string) (*unstructured.Unstructured, error) {
+		monitor := &unstructured.Unstructured{}
+		monitor.SetGroupVersionKind(gvk)
+		err := client.Get(context.TODO(), types.NamespacedName{Name: name, Namespace: "mm-namespace"}, monitor)
+		return monitor, err
+	}
+	getServiceMonitor := func() (*unstructured.Unstructured, error) {
+		return getMonitor(mattermostApp.ServiceMonitorGVK, "mm")
+	}
+	minioPodMonitorName := mattermostMinio.PodMonitorV1Beta(mattermost).GetName()
+	mysqlPodMonitorName := mattermostmysql.PodMonitorV1Beta(mattermost).GetName()
+
+	t.Run("without license", func(t *testing.T) {
+		require.NoError(t, r.checkMonitoring(mattermost, logger))
+		_, err := getServiceMonitor()
+		assert.True(t, k8sErrors.IsNotFound(err))
+		_, err = getMonitor(mattermostApp.PodMonitorGVK, mysqlPodMonitorName)
+		assert.NoError(t, err, "the database is scraped without license")
+		_, err = getMonitor(mattermostApp.PodMonitorGVK, minioPodMonitorName)
+		assert.NoError(t, err, "the file store is scraped without license")
+		_, err = getMonitor(mattermostApp.PrometheusRuleGVK, "mm")
+		assert.NoError(t, err)
+		require.Len(t, recorder.Events, 1)
+		assert.Contains(t, <-recorder.Events, "MonitoringUnavailable")
+	})
+
+	t.Run("create", func(t *testing.T) {
+		mattermost.Spec.LicenseSecret = "license"
+		require.NoError(t, r.checkMonitoring(mattermost, logger))
+		serviceMonitor, err := getServiceMonitor()
+		require.NoError(t, err)
+		endpoints, _, _ := unstructured.NestedSlice(serviceMonitor.Object, "spec", "endpoints")
+		require.Len(t, endpoints, 1)
+		assert.Equal(t, "30s", endpoints[0].(map[string]interface{})["interval"])
+	})
+
+	t.Run("update", func(t *testing.T) {
+		mattermost.Spec.Monitoring.Interval = "1m"
+		require.NoError(t, r.checkMonitoring(mattermost, logger))
+		serviceMonitor, err := getServiceMonitor()
+		require.NoError(t, err)
+		endpoints, _, _ := unstructured.NestedSlice(serviceMonitor.Object, "spec", "endpoints")
+		require.Len(t, endpoints, 1)
+		assert.Equal(t, "1m", endpoints[0].(map[string]interface{})["interval"])
+	})
+
+	t.Run("external database", func(t *testing.T) {
+		mattermost.Spec.Database = mmv1beta.Database{External: &mmv1beta.ExternalDatabase{Secret: "database"}}
+		require.NoError(t, r.checkMonitoring(mattermost, logger))
+		_, err := getMonitor(mattermostApp.PodMonitorGVK, mysqlPodMonitorName)
+		assert.True(t, k8sErrors.IsNotFound(err))
+	})
+
+	t.Run("disable", func(t *testing.T) {
+		mattermost.Spec.Monitoring.Enabled = false
+		require.NoError(t, r.checkMonitoring(mattermost, logger))
+		_, err := getServiceMonitor()
+		assert.True(t, k8sErrors.IsNotFound(err))
+		_, err = getMonitor(mattermostApp.PodMonitorGVK, minioPodMonitorName)
+		assert.True(t, k8sErrors.IsNotFound(err))
+		_, err = getMonitor(mattermostApp.PrometheusRuleGVK, "mm")
+		assert.True(t, k8sErrors.IsNotFound(err))
+	})
+}
@@ -0,0 +1,89 @@
+package mattermost
+
+import (
+	"context"
+	"fmt"
+
+	mmv1beta "github.com/mattermost/mattermost-operator/apis/mattermost/v1beta1"
+	"github.com/mattermost/mattermost-operator/pkg/logging"
+	"github.com/pkg/errors"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/types"
+)
+
+// webhookURLKey is the key of the webhook URL in the notifications Secret.
+const webhookURLKey = "url"
+
+// notifiedReasons are the reasons of the Events also sent to the
+// notification webhook, besides the first reconciliation error.
+var notifiedReasons = map[string]bool{
+	"UpgradeStarted":          true,
+	"RolloutFinished":         true,
+	"UpgradeRolledBack":       true,
+	"RolledBack":              true,
+	"HealthCheckFailed":       true,
+	"HealthDegraded":          true,
+	"Promoted":                true,
+	"MaintenanceModeEnabled":  true,
+	"MaintenanceModeDisabled": true,
+}
+
+// recordEvent records the Event on the Mattermost, and sends it to the
+// notification webhook if its reason is notified.
+func (r *MattermostReconciler) recordEvent(mattermost *mmv1beta.Mattermost, eventType, reason, message string) {
+	r.Recorder.Event(mattermost, eventType, reason, message)
+	if notifiedReasons[reason] {
+		r.notify(mattermost, eventType, reason, message)
+	}
+}
+
+// notify sends the notification to the webhook of the Mattermost, or to the
+// webhook of the operator if the Mattermost does not set one. Failures to
+// notify are logged only.
+func (r *MattermostReconciler) notify(mattermost *mmv1beta.Mattermost, eventType, reason, message string) {
+	if r.Notifier == nil {
+		return
+	}
+	logger := logging.ForObject(r.Log.WithValues("installation", mattermost.Name, "namespace", mattermost.Namespace), mattermost)
+
+	url, err := r.notificationWebhookURL(context.TODO(), mattermost)
+	if err != nil {
+		logger.Error(err, "Failed to get the notification webhook")
+		return
+	}
+	if url == "" {
+		return
+	}
+
+	text := fmt.Sprintf("[%s/%s] %s: %s", mattermost.Namespace, mattermost.Name, reason, message)
+	if eventType == corev1.EventTypeWarning {
+		text = ":warning: " + text
+	}
+	err = r.Notifier.Notify(context.TODO(), url, text)
+	if err != nil {
+		logger.Error(err, "Failed to send notification", "reason", reason)
+	}
+}
+
+// notificationWebhookURL returns the URL of the webhook notified of the
+// Mattermost, empty if none is.
+func (r *MattermostReconciler) notificationWebhookURL(ctx context.Context, mattermost *mmv1beta.Mattermost) (string, error) {
+	notifications := mattermost.Spec.Notifications
+	if notifications != nil && notifications.Disabled {
+		return "", nil
+	}
+	if notifications == nil || notifications.WebhookSecret == "" {
+		return r.NotificationWebhookURL, nil
+	}
+
+	secret := &corev1.Secret{}
+	err := r.Client.Get(ctx, types.NamespacedName{Name: notifications.WebhookSecret, Namespace: mattermost.Namespace}, secret)
+	if err != nil {
+		return "", errors.Wrap(err, "failed to get notifications secret")
+	}
+	url, ok := secret.Data[webhookURLKey]
+	if !ok || len(url) == 0 {
+		return "", errors.Errorf("notifications secret %s does not have a '%s' value", secret.Name, webhookURLKey)
+	}
+	return string(url), nil
+}
@@ -0,0 +1,90 @@
+package mattermost
+
+import (
+	"context"
+	"testing"
+
+	blubr "github.com/mattermost/blubr"
+	mmv1beta "github.com/mattermost/mattermost-operator/apis/mattermost/v1beta1"
+	"github.com/stretchr/testify/assert"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes/scheme"
+	"k8s.io/client-go/tools/record"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+)
+
+type notification struct {
+	url  string
+	text string
+}
+
+type fakeNotifier struct {
+	notifications []notification
+}
+
+func (f *fakeNotifier) Notify(_ context.Context, url, text string) error {
+	f.notifications = append(f.notifications, notification{url: url, text: text})
+	return nil
+}
+
+func TestRecordEventNotifications(t *testing.T) {
+	secret := &corev1.Secret{
+		ObjectMeta: metav1.ObjectMeta{Name: "webhook", Namespace: "mm-namespace"},
+		Data:       map[string][]byte{"url": []byte("https://chat.example.com/hooks/team")},
+	}
+	newReconciler := func() (*MattermostReconciler, *fakeNotifier) {
+		notifier := &fakeNotifier{}
+		return &MattermostReconciler{
+			Client:                 fake.NewFakeClientWithScheme(scheme.Scheme, secret),
+			Log:                    blubr.InitLogger(),
+			Recorder:               record.NewFakeRecorder(10),
+			Notifier:               notifier,
+			NotificationWebhookURL: "https://chat.example.com/hooks/operator",
+		}, notifier
+	}
+	newMattermost := func(notifications *mmv1beta.Notifications) *mmv1beta.Mattermost {
+		return &mmv1beta.Mattermost{
+			ObjectMeta: metav1.ObjectMeta{Name: "mm", Namespace: "mm-namespace"},
+			Spec:       mmv1beta.MattermostSpec{Notifications: notifications},
+		}
+	}
+
+	t.Run("operator webhook", func(t *testing.T) {
+		r, notifier := newReconciler()
+
+		r.recordEvent(newMattermost(nil), corev1.EventTypeWarning, "UpgradeRolledBack", "pods not ready")
+		r.recordEvent(newMattermost(nil), corev1.EventTypeNormal, "DatabaseReady", "The database of the Mattermost is ready")
+
+		assert.Equal(t, []notification{
+			{url: "https://chat.example.com/hooks/operator", text: ":warning: [mm-namespace/mm] UpgradeRolledBack: pods not ready"},
+		}, notifier.notifications)
+		assert.Len(t, r.Recorder.(*record.FakeRecorder).Events, 2)
+	})
+
+	t.Run("webhook of the Mattermost", func(t *testing.T) {
+		r, notifier := newReconciler()
+
+		r.recordEvent(newMattermost(&mmv1beta.Notifications{WebhookSecret: "webhook"}), corev1.EventTypeNormal, "RolloutFinished", "ready")
+
+		assert.Equal(t, []notification{
+			{url: "https://chat.example.com/hooks/team", text: "[mm-namespace/mm] RolloutFinished: ready"},
+		}, notifier.notifications)
+	})
+
+	t.Run("missing secret", func(t *testing.T) {
+		r, notifier := newReconciler()
+
+		r.recordEvent(newMattermost(&mmv1beta.Notifications{WebhookSecret: "missing"}), corev1.EventTypeNormal, "RolloutFinished", "ready")
+
+		assert.Empty(t, notifier.notifications)
+	})
+
+	t.Run("disabled", func(t *testing.T) {
+		r, notifier := newReconciler()
+
+		r.recordEvent(newMattermost(&mmv1beta.Notifications{Disabled: true}), corev1.EventTypeWarning, "HealthDegraded", "ping failed")
+
+		assert.Empty(t, notifier.notifications)
+	})
+}
@@ -0,0 +1,62 @@
+package mattermost
+
+import (
+	"context"
+	"fmt"
+	"reflect"
+
+	"github.com/go-logr/logr"
+	mmv1beta "github.com/mattermost/mattermost-operator/apis/mattermost/v1beta1"
+	mattermostApp "github.com/mattermost/mattermost-operator/pkg/mattermost"
+	"github.com/mattermost/mattermost-operator/pkg/utils"
+	"github.com/pkg/errors"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// setPausedCondition sets the Paused condition of the Mattermost. The
+// condition is only reported as false once the Mattermost was paused, it is
+// not added to the Mattermosts never paused.
+func setPausedCondition(status *mmv1beta.MattermostStatus, generation int64, paused bool) {
+	if paused {
+		setStatusCondition(status, metav1.Condition{
+			Type:               mmv1beta.PausedCondition,
+			Status:             metav1.ConditionTrue,
+			ObservedGeneration: generation,
+			Reason:             "Paused",
+			Message:            fmt.Sprintf("The reconciliation is paused by the %s annotation", mattermostApp.PausedAnnotation),
+		})
+		return
+	}
+	if meta.IsStatusConditionTrue(status.Conditions, mmv1beta.PausedCondition) {
+		setStatusCondition(status, metav1.Condition{
+			Type:               mmv1beta.PausedCondition,
+			Status:             metav1.ConditionFalse,
+			ObservedGeneration: generation,
+			Reason:             "Resumed",
+			Message:            "The Mattermost is reconciled",
+		})
+	}
+}
+
+// updateStatusPaused reports that the reconciliation of the Mattermost is
+// paused. The observed generation is kept, the spec of a paused Mattermost
+// is not rolled out.
+func (r *MattermostReconciler) updateStatusPaused(ctx context.Context, mattermost *mmv1beta.Mattermost, reqLogger logr.Logger) error {
+	status := *mattermost.Status.DeepCopy()
+	setPausedCondition(&status, mattermost.Generation, true)
+	if reflect.DeepEqual(mattermost.Status, status) {
+		return nil
+	}
+
+	reqLogger.Info("Reconciliation paused", "annotation", mattermostApp.PausedAnnotation)
+	err := utils.PatchStatus(ctx, r.Client, mattermost, func() {
+		mattermost.Status = status
+	})
+	if err != nil {
+		return errors.Wrap(err, "failed to update the Mattermost status")
+	}
+	r.recordEvent(mattermost, corev1.EventTypeNormal, "Paused", "The reconciliation is paused, manual changes are kept until it is resumed")
+	return nil
+}
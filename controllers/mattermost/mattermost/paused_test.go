@@ -0,0 +1,65 @@
+package mattermost
+
+import (
+	"context"
+	"testing"
+
+	blubr "github.com/mattermost/blubr"
+	mmv1beta "github.com/mattermost/mattermost-operator/apis/mattermost/v1beta1"
+	mattermostApp "github.com/mattermost/mattermost-operator/pkg/mattermost"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/kubernetes/scheme"
+	"k8s.io/client-go/tools/record"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+)
+
+func TestUpdateStatusPaused(t *testing.T) {
+	s := prepareSchema(t, scheme.Scheme)
+	s.AddKnownTypes(mmv1beta.GroupVersion, &mmv1beta.Mattermost{})
+
+	mattermost := &mmv1beta.Mattermost{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:        "mm",
+			Namespace:   "mm-namespace",
+			Generation:  3,
+			Annotations: map[string]string{mattermostApp.PausedAnnotation: "true"},
+		},
+		Status: mmv1beta.MattermostStatus{State: mmv1beta.Stable, ObservedGeneration: 2},
+	}
+	c := fake.NewFakeClientWithScheme(s, mattermost)
+	recorder := record.NewFakeRecorder(10)
+	r := &MattermostReconciler{Client: c, Scheme: s, Recorder: recorder}
+
+	require.True(t, mattermostApp.IsPaused(mattermost))
+	require.NoError(t, r.updateStatusPaused(context.TODO(), mattermost, blubr.InitLogger()))
+
+	current := &mmv1beta.Mattermost{}
+	require.NoError(t, c.Get(context.TODO(), types.NamespacedName{Namespace: "mm-namespace", Name: "mm"}, current))
+	assert.True(t, meta.IsStatusConditionTrue(current.Status.Conditions, mmv1beta.PausedCondition))
+	// The spec of a paused Mattermost is not observed.
+	assert.Equal(t, int64(2), current.Status.ObservedGeneration)
+	assert.Len(t, recorder.Events, 1)
+
+	// The event is only recorded once.
+	require.NoError(t, r.updateStatusPaused(context.TODO(), current, blubr.InitLogger()))
+	assert.Len(t, recorder.Events, 1)
+
+	t.Run("resumed", func(t *testing.T) {
+		status := current.Status
+		setPausedCondition(&status, 3, false)
+		condition := meta.FindStatusCondition(status.Conditions, mmv1beta.PausedCondition)
+		require.NotNil(t, condition)
+		assert.Equal(t, metav1.ConditionFalse, condition.Status)
+		assert.Equal(t, "Resumed", condition.Reason)
+	})
+
+	t.Run("never paused", func(t *testing.T) {
+		status := mmv1beta.MattermostStatus{}
+		setPausedCondition(&status, 1, false)
+		assert.Empty(t, status.Conditions)
+	})
+}
@@ -0,0 +1,63 @@
+package mattermost
+
+import (
+	"github.com/go-logr/logr"
+	mmv1beta "github.com/mattermost/mattermost-operator/apis/mattermost/v1beta1"
+	"github.com/mattermost/mattermost-operator/pkg/mattermost/healthcheck"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+// checkPodProblems sets the CrashLoopBackOff, ImagePullBackOff and
+// Unschedulable conditions from the pods of the app deployments, naming the
+// offending pod and container. The conditions are kept if the pods cannot be
+// listed.
+func (r *MattermostReconciler) checkPodProblems(mattermost *mmv1beta.Mattermost, status *mmv1beta.MattermostStatus, logger logr.Logger) {
+	problems := healthcheck.PodProblems{}
+	for _, name := range appDeploymentNames(mattermost) {
+		listOptions := []client.ListOption{
+			client.InNamespace(mattermost.Namespace),
+			client.MatchingLabels(mattermost.MattermostLabels(name)),
+		}
+		deploymentProblems, err := healthcheck.NewHealthChecker(r.NonCachedAPIReader, listOptions, logger).CheckPodProblems()
+		if err != nil {
+			logger.Error(err, "Unable to check the Mattermost pods for problems")
+			return
+		}
+		if problems.CrashLoop == nil {
+			problems.CrashLoop = deploymentProblems.CrashLoop
+		}
+		if problems.ImagePull == nil {
+			problems.ImagePull = deploymentProblems.ImagePull
+		}
+		if problems.Unschedulable == nil {
+			problems.Unschedulable = deploymentProblems.Unschedulable
+		}
+	}
+
+	setStatusCondition(status, podProblemCondition(mmv1beta.CrashLoopBackOffCondition, problems.CrashLoop, "NoContainerCrashing", "No container of the Mattermost pods is crashing", mattermost.Generation))
+	setStatusCondition(status, podProblemCondition(mmv1beta.ImagePullBackOffCondition, problems.ImagePull, "ImagesPulled", "The images of the Mattermost pods are pulled", mattermost.Generation))
+	setStatusCondition(status, podProblemCondition(mmv1beta.UnschedulableCondition, problems.Unschedulable, "PodsScheduled", "The Mattermost pods are scheduled", mattermost.Generation))
+}
+
+// podProblemCondition returns the condition reporting a kind of pod problem,
+// true with the reason reported by Kubernetes if the problem was found.
+func podProblemCondition(conditionType string, problem *healthcheck.PodProblem, okReason, okMessage string, generation int64) metav1.Condition {
+	if problem == nil {
+		return metav1.Condition{
+			Type:               conditionType,
+			Status:             metav1.ConditionFalse,
+			ObservedGeneration: generation,
+			Reason:             okReason,
+			Message:            okMessage,
+		}
+	}
+
+	return metav1.Condition{
+		Type:               conditionType,
+		Status:             metav1.ConditionTrue,
+		ObservedGeneration: generation,
+		Reason:             problem.Reason,
+		Message:            problem.String(),
+	}
+}
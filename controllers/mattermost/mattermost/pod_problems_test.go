@@ -0,0 +1,52 @@
+package mattermost
+
+import (
+	"testing"
+
+	blubr "github.com/mattermost/blubr"
+	mmv1beta "github.com/mattermost/mattermost-operator/apis/mattermost/v1beta1"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes/scheme"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+)
+
+func TestCheckPodProblems(t *testing.T) {
+	logger := blubr.InitLogger()
+	mattermost := &mmv1beta.Mattermost{
+		ObjectMeta: metav1.ObjectMeta{Name: "mm", Namespace: "mm-namespace", Generation: 3},
+	}
+	pod := &corev1.Pod{
+		ObjectMeta: metav1.ObjectMeta{Name: "mm-pod", Namespace: "mm-namespace", Labels: mattermost.MattermostLabels("mm")},
+		Status: corev1.PodStatus{
+			ContainerStatuses: []corev1.ContainerStatus{{
+				Name:  mmv1beta.MattermostAppContainerName,
+				State: corev1.ContainerState{Waiting: &corev1.ContainerStateWaiting{Reason: "ImagePullBackOff", Message: "Back-off pulling image"}},
+			}},
+		},
+	}
+
+	r := &MattermostReconciler{NonCachedAPIReader: fake.NewFakeClientWithScheme(scheme.Scheme, pod)}
+
+	status := mmv1beta.MattermostStatus{}
+	r.checkPodProblems(mattermost, &status, logger)
+
+	imagePull := meta.FindStatusCondition(status.Conditions, mmv1beta.ImagePullBackOffCondition)
+	require.NotNil(t, imagePull)
+	assert.Equal(t, metav1.ConditionTrue, imagePull.Status)
+	assert.Equal(t, "ImagePullBackOff", imagePull.Reason)
+	assert.Equal(t, "pod mm-pod container mattermost: ImagePullBackOff: Back-off pulling image", imagePull.Message)
+	assert.Equal(t, int64(3), imagePull.ObservedGeneration)
+	assert.True(t, meta.IsStatusConditionFalse(status.Conditions, mmv1beta.CrashLoopBackOffCondition))
+	assert.True(t, meta.IsStatusConditionFalse(status.Conditions, mmv1beta.UnschedulableCondition))
+
+	t.Run("resolved", func(t *testing.T) {
+		r := &MattermostReconciler{NonCachedAPIReader: fake.NewFakeClientWithScheme(scheme.Scheme)}
+
+		r.checkPodProblems(mattermost, &status, logger)
+		assert.True(t, meta.IsStatusConditionFalse(status.Conditions, mmv1beta.ImagePullBackOffCondition))
+	})
+}
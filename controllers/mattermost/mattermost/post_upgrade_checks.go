@@ -0,0 +1,93 @@
+package mattermost
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/go-logr/logr"
+	mmv1beta "github.com/mattermost/mattermost-operator/apis/mattermost/v1beta1"
+	mattermostApp "github.com/mattermost/mattermost-operator/pkg/mattermost"
+	"github.com/mattermost/mattermost-operator/pkg/resources"
+	"github.com/pkg/errors"
+	batchv1 "k8s.io/api/batch/v1"
+	k8sErrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	k8sClient "sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+// checkPostUpgradeChecks runs the post-upgrade checks once the pods running
+// the image reported by the health check are healthy. Every image is checked
+// once. It returns an error until the checks passed, so that the Mattermost
+// is not reported as stable before.
+func (r *MattermostReconciler) checkPostUpgradeChecks(mattermost *mmv1beta.Mattermost, health mmv1beta.MattermostStatus, reqLogger logr.Logger) (*mmv1beta.PostUpgradeChecksStatus, error) {
+	previous := mattermost.Status.PostUpgradeChecks
+	if !mattermost.PostUpgradeChecksEnabled() {
+		return previous, nil
+	}
+	reqLogger = reqLogger.WithValues("phase", "postUpgradeChecks")
+
+	if previous != nil && previous.Image == health.Image && previous.Version == health.Version {
+		switch previous.State {
+		case mmv1beta.PostUpgradeChecksPassed:
+			return previous, nil
+		case mmv1beta.PostUpgradeChecksFailed:
+			return previous, errors.Errorf("post-upgrade checks failed: %s", previous.Message)
+		}
+	}
+
+	image := joinImageName(health.Image, health.Version)
+	desired := mattermostApp.GeneratePostUpgradeChecksJobV1Beta(mattermost, image)
+	status := &mmv1beta.PostUpgradeChecksStatus{
+		Image:   health.Image,
+		Version: health.Version,
+		JobName: desired.Name,
+		State:   mmv1beta.PostUpgradeChecksRunning,
+	}
+
+	current := &batchv1.Job{}
+	err := r.Client.Get(context.TODO(), types.NamespacedName{Name: desired.Name, Namespace: desired.Namespace}, current)
+	if err != nil {
+		if k8sErrors.IsNotFound(err) {
+			reqLogger.Info("Launching post-upgrade checks job", "image", image)
+			err = r.Resources.Create(mattermost, desired, reqLogger)
+			if err != nil {
+				return previous, errors.Wrap(err, "failed to create post-upgrade checks job")
+			}
+			return status, errors.New("waiting for post-upgrade checks to complete")
+		}
+		return previous, errors.Wrap(err, "failed to get post-upgrade checks job")
+	}
+
+	if current.Annotations[mattermostApp.PostUpgradeChecksImageAnnotation] != image {
+		reqLogger.Info("Image changed, restarting post-upgrade checks job", "image", image)
+		r.deletePostUpgradeChecksJob(current, reqLogger)
+		return status, errors.New("waiting for post-upgrade checks to complete")
+	}
+
+	if resources.JobConditionTrue(current, batchv1.JobFailed) {
+		status.State = mmv1beta.PostUpgradeChecksFailed
+		status.CompletionTime = resources.JobConditionTime(current, batchv1.JobFailed)
+		status.Message = r.Resources.JobTerminationMessage(current, reqLogger)
+		return status, errors.Errorf("post-upgrade checks failed: %s", status.Message)
+	}
+
+	if !resources.JobConditionTrue(current, batchv1.JobComplete) {
+		return status, errors.New("waiting for post-upgrade checks to complete")
+	}
+
+	status.State = mmv1beta.PostUpgradeChecksPassed
+	status.CompletionTime = current.Status.CompletionTime
+
+	return status, nil
+}
+
+func (r *MattermostReconciler) deletePostUpgradeChecksJob(job *batchv1.Job, reqLogger logr.Logger) {
+	reqLogger.Info(fmt.Sprintf("Deleting post-upgrade checks job %s/%s", job.GetNamespace(), job.GetName()))
+
+	err := r.Client.Delete(context.TODO(), job, k8sClient.PropagationPolicy(metav1.DeletePropagationBackground))
+	if err != nil && !k8sErrors.IsNotFound(err) {
+		// Do not return error on fail as it is not critical
+		reqLogger.Error(err, "Unable to delete post-upgrade checks job")
+	}
+}
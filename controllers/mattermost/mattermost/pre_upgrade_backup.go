@@ -0,0 +1,81 @@
+package mattermost
+
+import (
+	"context"
+
+	"github.com/go-logr/logr"
+	mmv1beta "github.com/mattermost/mattermost-operator/apis/mattermost/v1beta1"
+	mattermostApp "github.com/mattermost/mattermost-operator/pkg/mattermost"
+	"github.com/pkg/errors"
+	appsv1 "k8s.io/api/apps/v1"
+	k8sErrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/types"
+)
+
+// checkPreUpgradeBackup backs up the Mattermost installation with a
+// MattermostBackup before Mattermost is upgraded to a new image. It returns
+// an error until the backup completes, so that the upgrade waits for it, and
+// keeps returning one if the backup failed.
+func (r *MattermostReconciler) checkPreUpgradeBackup(mattermost *mmv1beta.Mattermost, reqLogger logr.Logger) (*mmv1beta.PreUpgradeBackupStatus, error) {
+	if !mattermost.BackupBeforeUpgradeEnabled() || upgradeRolledBack(mattermost) {
+		return mattermost.Status.PreUpgradeBackup, nil
+	}
+	reqLogger = reqLogger.WithValues("phase", "preUpgradeBackup")
+
+	current := &appsv1.Deployment{}
+	err := r.Client.Get(context.TODO(), types.NamespacedName{Name: mattermost.Name, Namespace: mattermost.Namespace}, current)
+	if err != nil {
+		if k8sErrors.IsNotFound(err) {
+			// Nothing to back up on a new installation.
+			return mattermost.Status.PreUpgradeBackup, nil
+		}
+		return nil, errors.Wrap(err, "failed to get Mattermost deployment")
+	}
+
+	container := mmv1beta.GetMattermostAppContainerFromDeployment(current)
+	if container == nil || container.Image == mattermost.GetImageName() {
+		return mattermost.Status.PreUpgradeBackup, nil
+	}
+
+	desired := mattermostApp.GeneratePreUpgradeBackupV1Beta(mattermost, mattermost.GetImageName())
+	status := &mmv1beta.PreUpgradeBackupStatus{
+		Name:      desired.Name,
+		FromImage: container.Image,
+		ToImage:   mattermost.GetImageName(),
+		State:     mmv1beta.BackupRunning,
+	}
+
+	backup := &mmv1beta.MattermostBackup{}
+	err = r.Client.Get(context.TODO(), types.NamespacedName{Name: desired.Name, Namespace: desired.Namespace}, backup)
+	if err != nil {
+		if k8sErrors.IsNotFound(err) {
+			reqLogger.Info("Backing up Mattermost before the upgrade", "backup", desired.Name, "to", status.ToImage)
+			err = r.Resources.Create(mattermost, desired, reqLogger)
+			if err != nil {
+				return nil, errors.Wrap(err, "failed to create pre-upgrade backup")
+			}
+			return status, errors.New("waiting for the pre-upgrade backup to complete before upgrading Mattermost")
+		}
+		return nil, errors.Wrap(err, "failed to get pre-upgrade backup")
+	}
+
+	switch backup.Status.State {
+	case mmv1beta.BackupCompleted:
+		status.State = mmv1beta.BackupCompleted
+		if backup.Status.LastSuccessfulBackup != nil {
+			status.Location = backup.Status.LastSuccessfulBackup.Location
+		}
+		return status, nil
+	case mmv1beta.BackupFailed:
+		status.State = mmv1beta.BackupFailed
+		if backup.Status.LastBackup != nil {
+			status.Message = backup.Status.LastBackup.Message
+		}
+		return status, errors.Errorf("pre-upgrade backup %s failed, delete it to retry the upgrade", backup.Name)
+	}
+
+	if backup.Status.Message != "" {
+		status.Message = backup.Status.Message
+	}
+	return status, errors.New("waiting for the pre-upgrade backup to complete before upgrading Mattermost")
+}
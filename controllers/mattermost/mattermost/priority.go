@@ -0,0 +1,74 @@
+package mattermost
+
+import (
+	"time"
+
+	"github.com/go-logr/logr"
+	mmv1beta "github.com/mattermost/mattermost-operator/apis/mattermost/v1beta1"
+	mattermostApp "github.com/mattermost/mattermost-operator/pkg/mattermost"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/util/workqueue"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/event"
+	"sigs.k8s.io/controller-runtime/pkg/handler"
+	"sigs.k8s.io/controller-runtime/pkg/predicate"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+)
+
+// installationPriority returns the priority tier of the Mattermost, the
+// production tier if its priority annotation is invalid.
+func installationPriority(mattermost *mmv1beta.Mattermost, logger logr.Logger) mattermostApp.Priority {
+	priority, err := mattermostApp.GetPriority(mattermost)
+	if err != nil {
+		logger.Error(err, "Ignoring the priority annotation", "annotation", mattermostApp.PriorityAnnotation)
+	}
+	return priority
+}
+
+// createDelay returns how long the first reconciliation of the Mattermost is
+// delayed, PriorityTierDelay for each tier above its own. All the
+// Mattermosts are created in the cache once the operator starts, so that
+// the production tier is reconciled first.
+func (r *MattermostReconciler) createDelay(obj client.Object) time.Duration {
+	mattermost, ok := obj.(*mmv1beta.Mattermost)
+	if !ok {
+		return 0
+	}
+	rank := installationPriority(mattermost, r.Log.WithValues("installation", mattermost.Name, "namespace", mattermost.Namespace)).Rank()
+	return time.Duration(rank) * r.PriorityTierDelay
+}
+
+// priorityCreatePredicate filters out the create events of the Mattermosts
+// whose first reconciliation is delayed, they are enqueued by the
+// delayedCreateHandler instead.
+func (r *MattermostReconciler) priorityCreatePredicate() predicate.Predicate {
+	return predicate.Funcs{
+		CreateFunc: func(e event.CreateEvent) bool {
+			return r.createDelay(e.Object) == 0
+		},
+	}
+}
+
+// delayedCreateHandler enqueues the Mattermosts whose first reconciliation
+// is delayed once their delay elapsed.
+func (r *MattermostReconciler) delayedCreateHandler() handler.EventHandler {
+	return handler.Funcs{
+		CreateFunc: func(e event.CreateEvent, q workqueue.RateLimitingInterface) {
+			if delay := r.createDelay(e.Object); delay > 0 {
+				q.AddAfter(reconcile.Request{NamespacedName: types.NamespacedName{
+					Namespace: e.Object.GetNamespace(),
+					Name:      e.Object.GetName(),
+				}}, delay)
+			}
+		},
+	}
+}
+
+// limitRequeueDelay returns the delay after which the Mattermost is
+// reconciled again once the limit of reconciling installations is reached,
+// longer for the lower tiers so that the production tier takes the free
+// slots first.
+func (r *MattermostReconciler) limitRequeueDelay(mattermost *mmv1beta.Mattermost, logger logr.Logger) time.Duration {
+	rank := installationPriority(mattermost, logger).Rank()
+	return time.Duration(rank+1) * r.RequeueOnLimitDelay
+}
@@ -0,0 +1,58 @@
+package mattermost
+
+import (
+	"testing"
+	"time"
+
+	blubr "github.com/mattermost/blubr"
+	mmv1beta "github.com/mattermost/mattermost-operator/apis/mattermost/v1beta1"
+	mattermostApp "github.com/mattermost/mattermost-operator/pkg/mattermost"
+	"github.com/stretchr/testify/assert"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/util/workqueue"
+	"sigs.k8s.io/controller-runtime/pkg/event"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+)
+
+func TestPriority(t *testing.T) {
+	logger := blubr.InitLogger()
+	r := &MattermostReconciler{Log: logger, RequeueOnLimitDelay: 20 * time.Second, PriorityTierDelay: time.Millisecond}
+
+	newMattermost := func(priority string) *mmv1beta.Mattermost {
+		mattermost := &mmv1beta.Mattermost{ObjectMeta: metav1.ObjectMeta{Name: "mm", Namespace: "mm-namespace"}}
+		if priority != "" {
+			mattermost.Annotations = map[string]string{mattermostApp.PriorityAnnotation: priority}
+		}
+		return mattermost
+	}
+	production := newMattermost("")
+	dev := newMattermost("dev")
+
+	t.Run("create delay", func(t *testing.T) {
+		assert.Zero(t, r.createDelay(production))
+		assert.Zero(t, r.createDelay(newMattermost("critical")))
+		assert.Equal(t, time.Millisecond, r.createDelay(newMattermost("staging")))
+		assert.Equal(t, 2*time.Millisecond, r.createDelay(dev))
+	})
+
+	t.Run("delayed create", func(t *testing.T) {
+		assert.True(t, r.priorityCreatePredicate().Create(event.CreateEvent{Object: production}))
+		assert.False(t, r.priorityCreatePredicate().Create(event.CreateEvent{Object: dev}))
+		assert.True(t, r.priorityCreatePredicate().Update(event.UpdateEvent{ObjectOld: dev, ObjectNew: dev}))
+
+		q := workqueue.NewRateLimitingQueue(workqueue.DefaultControllerRateLimiter())
+		defer q.ShutDown()
+		r.delayedCreateHandler().Create(event.CreateEvent{Object: production}, q)
+		assert.Zero(t, q.Len())
+
+		r.delayedCreateHandler().Create(event.CreateEvent{Object: dev}, q)
+		item, _ := q.Get()
+		assert.Equal(t, reconcile.Request{NamespacedName: types.NamespacedName{Name: "mm", Namespace: "mm-namespace"}}, item)
+	})
+
+	t.Run("limit requeue delay", func(t *testing.T) {
+		assert.Equal(t, 20*time.Second, r.limitRequeueDelay(production, logger))
+		assert.Equal(t, 60*time.Second, r.limitRequeueDelay(dev, logger))
+	})
+}
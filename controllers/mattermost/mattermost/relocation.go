@@ -0,0 +1,462 @@
+package mattermost
+
+import (
+	"context"
+	"fmt"
+	"reflect"
+	"sort"
+	"time"
+
+	"github.com/go-logr/logr"
+	mmv1beta "github.com/mattermost/mattermost-operator/apis/mattermost/v1beta1"
+	"github.com/mattermost/mattermost-operator/pkg/utils"
+	"github.com/pkg/errors"
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	networkingv1 "k8s.io/api/networking/v1"
+	k8sErrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+// relocationRequeueDelay is the delay between the checks of the relocated
+// Mattermost.
+const relocationRequeueDelay = 10 * time.Second
+
+// checkRelocation moves the Mattermost to the namespace of its relocation:
+// the Secrets and ConfigMaps it references are copied there and a
+// Mattermost with the same spec, its ingress disabled, is created there.
+// Once the relocated Mattermost is stable, the ingress of the Mattermost is
+// deleted and the one of the relocated Mattermost enabled, then the
+// Mattermost is deleted. Its resources are no longer reconciled meanwhile,
+// it returns whether the reconciliation has to stop with the result to
+// return. Removing the relocation deletes the relocated Mattermost.
+func (r *MattermostReconciler) checkRelocation(ctx context.Context, mattermost *mmv1beta.Mattermost, reqLogger logr.Logger) (bool, ctrl.Result, error) {
+	relocation := mattermost.Spec.Relocation
+	if relocation == nil {
+		if mattermost.Status.Relocation == nil {
+			return false, ctrl.Result{}, nil
+		}
+		return false, ctrl.Result{}, r.abortRelocation(ctx, mattermost, reqLogger)
+	}
+	reqLogger = reqLogger.WithValues("phase", "relocation", "targetNamespace", relocation.Namespace)
+
+	status := &mmv1beta.RelocationStatus{Phase: mmv1beta.RelocationReplicating, Namespace: relocation.Namespace}
+	if current := mattermost.Status.Relocation; current != nil && current.Phase != mmv1beta.RelocationFailed {
+		status = current.DeepCopy()
+	}
+	if status.StartTime == nil {
+		startTime := metav1.Now()
+		status.StartTime = &startTime
+	}
+
+	failure, err := r.validateRelocation(ctx, mattermost, status)
+	if err != nil {
+		return false, ctrl.Result{}, err
+	}
+	if failure != "" {
+		return false, ctrl.Result{}, r.failRelocation(ctx, mattermost, status, failure, reqLogger)
+	}
+
+	target := &mmv1beta.Mattermost{}
+	err = r.Client.Get(ctx, types.NamespacedName{Name: mattermost.Name, Namespace: relocation.Namespace}, target)
+	if err != nil && !k8sErrors.IsNotFound(err) {
+		return false, ctrl.Result{}, errors.Wrap(err, "failed to get relocated Mattermost")
+	}
+	targetFound := err == nil
+	if !targetFound {
+		status.Phase = mmv1beta.RelocationReplicating
+	} else if target.Annotations[mmv1beta.RelocatedFromAnnotation] != relocatedFrom(mattermost) {
+		return false, ctrl.Result{}, r.failRelocation(ctx, mattermost, status, fmt.Sprintf("Mattermost %s already exists in namespace %s", mattermost.Name, relocation.Namespace), reqLogger)
+	}
+
+	switch status.Phase {
+	case mmv1beta.RelocationReplicating:
+		failure, err = r.replicateRelocatedObjects(ctx, mattermost, reqLogger)
+		if err != nil {
+			return false, ctrl.Result{}, err
+		}
+		if failure != "" {
+			return false, ctrl.Result{}, r.failRelocation(ctx, mattermost, status, failure, reqLogger)
+		}
+		if !targetFound {
+			reqLogger.Info("Creating relocated Mattermost")
+			err = r.Client.Create(ctx, relocatedMattermost(mattermost))
+			if err != nil && !k8sErrors.IsAlreadyExists(err) {
+				return false, ctrl.Result{}, errors.Wrap(err, "failed to create relocated Mattermost")
+			}
+			r.recordEvent(mattermost, corev1.EventTypeNormal, "Relocating", fmt.Sprintf("Created the relocated Mattermost in namespace %s", relocation.Namespace))
+		}
+		status.Phase = mmv1beta.RelocationWaitingForTarget
+	case mmv1beta.RelocationWaitingForTarget:
+		if !relocatedMattermostStable(target) {
+			break
+		}
+		err = r.deleteRelocatedIngresses(ctx, mattermost, reqLogger)
+		if err != nil {
+			return false, ctrl.Result{}, err
+		}
+		if mattermost.Spec.Ingress != nil && mattermost.Spec.Ingress.Enabled {
+			reqLogger.Info("Enabling the ingress of the relocated Mattermost")
+			patch := client.MergeFrom(target.DeepCopy())
+			target.Spec.Ingress = mattermost.Spec.Ingress.DeepCopy()
+			err = r.Client.Patch(ctx, target, patch)
+			if err != nil {
+				return false, ctrl.Result{}, errors.Wrap(err, "failed to enable the ingress of the relocated Mattermost")
+			}
+		}
+		r.recordEvent(mattermost, corev1.EventTypeNormal, "Relocating", fmt.Sprintf("Switched the ingress to the relocated Mattermost in namespace %s", relocation.Namespace))
+		status.Phase = mmv1beta.RelocationCuttingOver
+	case mmv1beta.RelocationCuttingOver:
+		if !relocatedMattermostStable(target) {
+			break
+		}
+		reqLogger.Info("Mattermost relocated, deleting it")
+		r.recordEvent(target, corev1.EventTypeNormal, "Relocated", fmt.Sprintf("Relocated from namespace %s", mattermost.Namespace))
+		err = r.Client.Delete(ctx, mattermost, client.PropagationPolicy(metav1.DeletePropagationBackground))
+		if err != nil && !k8sErrors.IsNotFound(err) {
+			return false, ctrl.Result{}, errors.Wrap(err, "failed to delete relocated Mattermost")
+		}
+		return true, ctrl.Result{}, nil
+	}
+
+	err = r.updateRelocationStatus(ctx, mattermost, status)
+	if err != nil {
+		return false, ctrl.Result{}, err
+	}
+	return true, ctrl.Result{RequeueAfter: relocationRequeueDelay}, nil
+}
+
+// validateRelocation returns why the Mattermost cannot be relocated, empty
+// if it can. The data of the operator managed database and file store, and
+// of the volumes, stays in the namespace of the Mattermost.
+func (r *MattermostReconciler) validateRelocation(ctx context.Context, mattermost *mmv1beta.Mattermost, status *mmv1beta.RelocationStatus) (string, error) {
+	relocation := mattermost.Spec.Relocation
+	switch {
+	case relocation.Namespace == "" || relocation.Namespace == mattermost.Namespace:
+		return "the relocation namespace must be another namespace", nil
+	case status.Namespace != relocation.Namespace:
+		return fmt.Sprintf("the relocation to namespace %s is in progress, remove the relocation to abort it before relocating to another namespace", status.Namespace), nil
+	case !mattermost.Spec.Database.IsExternal() && relocation.DatabaseSecret == "":
+		return "the operator managed database cannot be relocated, migrate the data to an external database and set its Secret in the relocation", nil
+	case !mattermost.Spec.FileStore.IsExternal() && relocation.FileStore == nil:
+		return "the operator managed file store cannot be relocated, migrate the files to an external file store and set it in the relocation", nil
+	case mattermost.BlueGreenEnabled() || mattermost.CanaryEnabled():
+		return "BlueGreen and Canary must be disabled to relocate the Mattermost", nil
+	case mattermost.DeletionProtected():
+		return fmt.Sprintf("the Mattermost is protected from deletion, set the %s annotation to false to relocate it", mmv1beta.DeletionProtectedAnnotation), nil
+	}
+	for _, volume := range mattermost.Spec.Volumes {
+		if volume.PersistentVolumeClaim != nil {
+			return fmt.Sprintf("the PersistentVolumeClaim %s of volume %s cannot be relocated", volume.PersistentVolumeClaim.ClaimName, volume.Name), nil
+		}
+	}
+
+	namespace := &corev1.Namespace{}
+	err := r.Client.Get(ctx, types.NamespacedName{Name: relocation.Namespace}, namespace)
+	if err != nil && k8sErrors.IsNotFound(err) {
+		return fmt.Sprintf("namespace %s not found", relocation.Namespace), nil
+	} else if err != nil {
+		return "", errors.Wrap(err, "failed to get relocation namespace")
+	}
+	selected, err := r.Namespaces.Selects(ctx, relocation.Namespace)
+	if err != nil {
+		return "", err
+	} else if !selected {
+		return fmt.Sprintf("namespace %s is not selected by the operator", relocation.Namespace), nil
+	}
+	return "", nil
+}
+
+// replicateRelocatedObjects copies the Secrets and ConfigMaps referenced by
+// the Mattermost and by the pods of its deployments to the namespace of its
+// relocation, except the ones it generates. It returns why they cannot be
+// copied, ie the Secrets of the same name already in the namespace.
+func (r *MattermostReconciler) replicateRelocatedObjects(ctx context.Context, mattermost *mmv1beta.Mattermost, reqLogger logr.Logger) (string, error) {
+	secrets, configMaps, err := r.relocatedObjectNames(ctx, mattermost)
+	if err != nil {
+		return "", err
+	}
+
+	var objects []client.Object
+	for _, name := range secrets {
+		objects = append(objects, &corev1.Secret{ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: mattermost.Namespace}})
+	}
+	for _, name := range configMaps {
+		objects = append(objects, &corev1.ConfigMap{ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: mattermost.Namespace}})
+	}
+
+	for _, obj := range objects {
+		kind := reflect.TypeOf(obj).Elem().Name()
+		err = r.Client.Get(ctx, client.ObjectKeyFromObject(obj), obj)
+		if err != nil && k8sErrors.IsNotFound(err) {
+			reqLogger.Info(fmt.Sprintf("Skipping missing %s %s", kind, obj.GetName()))
+			continue
+		} else if err != nil {
+			return "", errors.Wrapf(err, "failed to get %s %s", kind, obj.GetName())
+		}
+		if metav1.IsControlledBy(obj, mattermost) {
+			continue
+		}
+
+		desired := relocatedObject(mattermost, obj)
+		current := reflect.New(reflect.TypeOf(obj).Elem()).Interface().(client.Object)
+		err = r.Client.Get(ctx, client.ObjectKeyFromObject(desired), current)
+		if err != nil && k8sErrors.IsNotFound(err) {
+			reqLogger.Info(fmt.Sprintf("Copying %s %s", kind, obj.GetName()))
+			err = r.Client.Create(ctx, desired)
+			if err != nil {
+				return "", errors.Wrapf(err, "failed to copy %s %s", kind, obj.GetName())
+			}
+			continue
+		} else if err != nil {
+			return "", errors.Wrapf(err, "failed to get relocated %s %s", kind, obj.GetName())
+		}
+
+		if current.GetAnnotations()[mmv1beta.RelocatedFromAnnotation] != relocatedFrom(mattermost) {
+			if relocatedData(current, desired) {
+				continue
+			}
+			return fmt.Sprintf("%s %s already exists in namespace %s", kind, obj.GetName(), desired.GetNamespace()), nil
+		}
+		desired.SetResourceVersion(current.GetResourceVersion())
+		err = r.Client.Update(ctx, desired)
+		if err != nil {
+			return "", errors.Wrapf(err, "failed to update relocated %s %s", kind, obj.GetName())
+		}
+	}
+	return "", nil
+}
+
+// relocatedObjectNames returns the names of the Secrets and ConfigMaps
+// referenced by the spec of the Mattermost and by the pod templates of its
+// deployments.
+func (r *MattermostReconciler) relocatedObjectNames(ctx context.Context, mattermost *mmv1beta.Mattermost) ([]string, []string, error) {
+	secrets := map[string]bool{}
+	configMaps := map[string]bool{}
+	relocation := mattermost.Spec.Relocation
+
+	secrets[mattermost.Spec.LicenseSecret] = true
+	if mattermost.Spec.Ingress != nil {
+		secrets[mattermost.Spec.Ingress.TLSSecret] = true
+	}
+	if mattermost.Spec.Database.IsExternal() && relocation.DatabaseSecret == "" {
+		secrets[mattermost.Spec.Database.External.Secret] = true
+	}
+	if mattermost.Spec.FileStore.IsExternal() && relocation.FileStore == nil {
+		secrets[mattermost.Spec.FileStore.External.Secret] = true
+	}
+	for _, pullSecret := range mattermost.Spec.ImagePullSecrets {
+		secrets[pullSecret.Name] = true
+	}
+	if mattermost.Spec.TrustedCABundle != nil {
+		configMaps[mattermost.Spec.TrustedCABundle.ConfigMap] = true
+	}
+
+	var deployments appsv1.DeploymentList
+	err := r.Client.List(ctx, &deployments, client.InNamespace(mattermost.Namespace), client.HasLabels{mmv1beta.ClusterResourceLabel})
+	if err != nil {
+		return nil, nil, errors.Wrap(err, "failed to list deployments")
+	}
+	for _, deployment := range deployments.Items {
+		if !metav1.IsControlledBy(&deployment, mattermost) {
+			continue
+		}
+		pod := deployment.Spec.Template.Spec
+		for _, pullSecret := range pod.ImagePullSecrets {
+			secrets[pullSecret.Name] = true
+		}
+		for _, volume := range pod.Volumes {
+			if volume.Secret != nil {
+				secrets[volume.Secret.SecretName] = true
+			}
+			if volume.ConfigMap != nil {
+				configMaps[volume.ConfigMap.Name] = true
+			}
+		}
+		for _, container := range append(pod.InitContainers, pod.Containers...) {
+			for _, envFrom := range container.EnvFrom {
+				if envFrom.SecretRef != nil {
+					secrets[envFrom.SecretRef.Name] = true
+				}
+				if envFrom.ConfigMapRef != nil {
+					configMaps[envFrom.ConfigMapRef.Name] = true
+				}
+			}
+			for _, env := range container.Env {
+				if env.ValueFrom != nil && env.ValueFrom.SecretKeyRef != nil {
+					secrets[env.ValueFrom.SecretKeyRef.Name] = true
+				}
+				if env.ValueFrom != nil && env.ValueFrom.ConfigMapKeyRef != nil {
+					configMaps[env.ValueFrom.ConfigMapKeyRef.Name] = true
+				}
+			}
+		}
+	}
+
+	return sortedNames(secrets), sortedNames(configMaps), nil
+}
+
+// deleteRelocatedIngresses deletes the ingresses of the Mattermost, so that
+// the ingress of the relocated Mattermost takes over their hosts.
+func (r *MattermostReconciler) deleteRelocatedIngresses(ctx context.Context, mattermost *mmv1beta.Mattermost, reqLogger logr.Logger) error {
+	var ingresses networkingv1.IngressList
+	err := r.Client.List(ctx, &ingresses, client.InNamespace(mattermost.Namespace), client.HasLabels{mmv1beta.ClusterResourceLabel})
+	if err != nil {
+		return errors.Wrap(err, "failed to list ingresses")
+	}
+	for i := range ingresses.Items {
+		ingress := &ingresses.Items[i]
+		if !metav1.IsControlledBy(ingress, mattermost) {
+			continue
+		}
+		reqLogger.Info("Deleting ingress", "ingress", ingress.Name)
+		err = r.Client.Delete(ctx, ingress)
+		if err != nil && !k8sErrors.IsNotFound(err) {
+			return errors.Wrapf(err, "failed to delete ingress %s", ingress.Name)
+		}
+	}
+	return nil
+}
+
+// abortRelocation deletes the relocated Mattermost once the relocation is
+// removed. The copied Secrets and ConfigMaps are kept.
+func (r *MattermostReconciler) abortRelocation(ctx context.Context, mattermost *mmv1beta.Mattermost, reqLogger logr.Logger) error {
+	target := &mmv1beta.Mattermost{}
+	err := r.Client.Get(ctx, types.NamespacedName{Name: mattermost.Name, Namespace: mattermost.Status.Relocation.Namespace}, target)
+	if err != nil && !k8sErrors.IsNotFound(err) {
+		return errors.Wrap(err, "failed to get relocated Mattermost")
+	}
+	if err == nil && target.Annotations[mmv1beta.RelocatedFromAnnotation] == relocatedFrom(mattermost) {
+		reqLogger.Info("Relocation removed, deleting the relocated Mattermost", "targetNamespace", target.Namespace)
+		err = r.Client.Delete(ctx, target, client.PropagationPolicy(metav1.DeletePropagationBackground))
+		if err != nil && !k8sErrors.IsNotFound(err) {
+			return errors.Wrap(err, "failed to delete relocated Mattermost")
+		}
+		r.recordEvent(mattermost, corev1.EventTypeNormal, "RelocationAborted", fmt.Sprintf("Deleted the relocated Mattermost in namespace %s", target.Namespace))
+	}
+	return r.updateRelocationStatus(ctx, mattermost, nil)
+}
+
+// failRelocation reports why the Mattermost cannot be relocated, it keeps
+// being reconciled in its namespace meanwhile.
+func (r *MattermostReconciler) failRelocation(ctx context.Context, mattermost *mmv1beta.Mattermost, status *mmv1beta.RelocationStatus, message string, reqLogger logr.Logger) error {
+	previous := mattermost.Status.Relocation
+	if previous == nil || previous.Phase != mmv1beta.RelocationFailed || previous.Message != message {
+		reqLogger.Info("Mattermost cannot be relocated", "reason", message)
+		r.recordEvent(mattermost, corev1.EventTypeWarning, "RelocationFailed", message)
+	}
+	failed := status.DeepCopy()
+	failed.Phase = mmv1beta.RelocationFailed
+	failed.Message = message
+	return r.updateRelocationStatus(ctx, mattermost, failed)
+}
+
+func (r *MattermostReconciler) updateRelocationStatus(ctx context.Context, mattermost *mmv1beta.Mattermost, status *mmv1beta.RelocationStatus) error {
+	if reflect.DeepEqual(mattermost.Status.Relocation, status) {
+		return nil
+	}
+	err := utils.PatchStatus(ctx, r.Client, mattermost, func() {
+		mattermost.Status.Relocation = status
+	})
+	if err != nil {
+		return errors.Wrap(err, "failed to update the relocation status")
+	}
+	return nil
+}
+
+// relocatedMattermost returns the Mattermost created in the namespace of the
+// relocation, its ingress disabled until the cut over.
+func relocatedMattermost(mattermost *mmv1beta.Mattermost) *mmv1beta.Mattermost {
+	relocation := mattermost.Spec.Relocation
+	target := &mmv1beta.Mattermost{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:        mattermost.Name,
+			Namespace:   relocation.Namespace,
+			Labels:      mattermost.Labels,
+			Annotations: relocatedAnnotations(mattermost, mattermost.Annotations),
+		},
+		Spec: *mattermost.Spec.DeepCopy(),
+	}
+	target.Spec.Relocation = nil
+	if relocation.DatabaseSecret != "" {
+		target.Spec.Database.External = &mmv1beta.ExternalDatabase{Secret: relocation.DatabaseSecret}
+		target.Spec.Database.OperatorManaged = nil
+	}
+	if relocation.FileStore != nil {
+		target.Spec.FileStore.External = relocation.FileStore.DeepCopy()
+		target.Spec.FileStore.OperatorManaged = nil
+	}
+	if target.Spec.Ingress != nil {
+		target.Spec.Ingress.Enabled = false
+	}
+	return target
+}
+
+// relocatedObject returns the copy of the Secret or ConfigMap in the
+// namespace of the relocation.
+func relocatedObject(mattermost *mmv1beta.Mattermost, obj client.Object) client.Object {
+	meta := metav1.ObjectMeta{
+		Name:        obj.GetName(),
+		Namespace:   mattermost.Spec.Relocation.Namespace,
+		Labels:      obj.GetLabels(),
+		Annotations: relocatedAnnotations(mattermost, obj.GetAnnotations()),
+	}
+	switch obj := obj.(type) {
+	case *corev1.Secret:
+		return &corev1.Secret{ObjectMeta: meta, Type: obj.Type, Data: obj.Data}
+	case *corev1.ConfigMap:
+		return &corev1.ConfigMap{ObjectMeta: meta, Data: obj.Data, BinaryData: obj.BinaryData}
+	}
+	return nil
+}
+
+// relocatedData returns whether the Secret or ConfigMap of the relocation
+// namespace already holds the data of the copy.
+func relocatedData(current, desired client.Object) bool {
+	switch current := current.(type) {
+	case *corev1.Secret:
+		return reflect.DeepEqual(current.Data, desired.(*corev1.Secret).Data)
+	case *corev1.ConfigMap:
+		desired := desired.(*corev1.ConfigMap)
+		return reflect.DeepEqual(current.Data, desired.Data) && reflect.DeepEqual(current.BinaryData, desired.BinaryData)
+	}
+	return false
+}
+
+func relocatedAnnotations(mattermost *mmv1beta.Mattermost, annotations map[string]string) map[string]string {
+	relocated := map[string]string{}
+	for key, value := range annotations {
+		if key == corev1.LastAppliedConfigAnnotation {
+			continue
+		}
+		relocated[key] = value
+	}
+	relocated[mmv1beta.RelocatedFromAnnotation] = relocatedFrom(mattermost)
+	return relocated
+}
+
+// relocatedFrom returns the value of the relocated from annotation of the
+// copies of the Mattermost and of its Secrets and ConfigMaps.
+func relocatedFrom(mattermost *mmv1beta.Mattermost) string {
+	return mattermost.Namespace + "/" + mattermost.Name
+}
+
+// relocatedMattermostStable returns whether the relocated Mattermost rolled
+// out its last spec.
+func relocatedMattermostStable(target *mmv1beta.Mattermost) bool {
+	return target.Status.State == mmv1beta.Stable && target.Status.ObservedGeneration >= target.Generation
+}
+
+func sortedNames(names map[string]bool) []string {
+	var sorted []string
+	for name := range names {
+		if name != "" {
+			sorted = append(sorted, name)
+		}
+	}
+	sort.Strings(sorted)
+	return sorted
+}
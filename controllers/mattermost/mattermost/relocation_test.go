@@ -0,0 +1,215 @@
+package mattermost
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	blubr "github.com/mattermost/blubr"
+	mmv1beta "github.com/mattermost/mattermost-operator/apis/mattermost/v1beta1"
+	mattermostApp "github.com/mattermost/mattermost-operator/pkg/mattermost"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	networkingv1 "k8s.io/api/networking/v1"
+	k8sErrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/kubernetes/scheme"
+	"k8s.io/client-go/tools/record"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+)
+
+func TestCheckRelocation(t *testing.T) {
+	s := prepareSchema(t, scheme.Scheme)
+	logger := blubr.InitLogger()
+	sourceKey := types.NamespacedName{Namespace: "team-a", Name: "chat"}
+	targetKey := types.NamespacedName{Namespace: "team-b", Name: "chat"}
+
+	newMattermost := func() *mmv1beta.Mattermost {
+		return &mmv1beta.Mattermost{
+			ObjectMeta: metav1.ObjectMeta{Name: sourceKey.Name, Namespace: sourceKey.Namespace, UID: "mm-uid"},
+			Spec: mmv1beta.MattermostSpec{
+				LicenseSecret: "license",
+				Ingress:       &mmv1beta.Ingress{Enabled: true, Host: "chat.example.com", TLSSecret: "tls"},
+				Database:      mmv1beta.Database{External: &mmv1beta.ExternalDatabase{Secret: "db"}},
+				FileStore:     mmv1beta.FileStore{External: &mmv1beta.ExternalFileStore{URL: "s3.amazonaws.com", Bucket: "chat", Secret: "s3"}},
+				Relocation:    &mmv1beta.Relocation{Namespace: targetKey.Namespace},
+			},
+		}
+	}
+	newObjects := func(mattermost *mmv1beta.Mattermost) []client.Object {
+		secret := func(name string) *corev1.Secret {
+			return &corev1.Secret{
+				ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: sourceKey.Namespace},
+				Data:       map[string][]byte{"key": []byte(name)},
+			}
+		}
+		generated := secret("chat-generated")
+		generated.OwnerReferences = mattermostApp.MattermostOwnerReference(mattermost)
+		return []client.Object{
+			&corev1.Namespace{ObjectMeta: metav1.ObjectMeta{Name: sourceKey.Namespace}},
+			&corev1.Namespace{ObjectMeta: metav1.ObjectMeta{Name: targetKey.Namespace}},
+			secret("license"), secret("tls"), secret("db"), secret("s3"), secret("smtp"), generated,
+			&corev1.ConfigMap{
+				ObjectMeta: metav1.ObjectMeta{Name: "config", Namespace: sourceKey.Namespace},
+				Data:       map[string]string{"key": "config"},
+			},
+			&appsv1.Deployment{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:            mattermost.Name,
+					Namespace:       mattermost.Namespace,
+					Labels:          mattermost.MattermostLabels(mattermost.Name),
+					OwnerReferences: mattermostApp.MattermostOwnerReference(mattermost),
+				},
+				Spec: appsv1.DeploymentSpec{Template: corev1.PodTemplateSpec{Spec: corev1.PodSpec{
+					Volumes: []corev1.Volume{{Name: "config", VolumeSource: corev1.VolumeSource{
+						ConfigMap: &corev1.ConfigMapVolumeSource{LocalObjectReference: corev1.LocalObjectReference{Name: "config"}},
+					}}},
+					Containers: []corev1.Container{{
+						Name: mmv1beta.MattermostAppContainerName,
+						Env: []corev1.EnvVar{
+							{Name: "MM_EMAILSETTINGS_SMTPPASSWORD", ValueFrom: mattermostApp.EnvSourceFromSecret("smtp", "key")},
+							{Name: "MM_GENERATED", ValueFrom: mattermostApp.EnvSourceFromSecret("chat-generated", "key")},
+						},
+					}},
+				}}},
+			},
+			&networkingv1.Ingress{ObjectMeta: metav1.ObjectMeta{
+				Name:            mattermost.Name,
+				Namespace:       mattermost.Namespace,
+				Labels:          mattermost.MattermostLabels(mattermost.Name),
+				OwnerReferences: mattermostApp.MattermostOwnerReference(mattermost),
+			}},
+		}
+	}
+	newReconciler := func(mattermost *mmv1beta.Mattermost, objects ...client.Object) (*MattermostReconciler, client.Client) {
+		c := fake.NewClientBuilder().WithScheme(s).WithObjects(append(objects, mattermost)...).Build()
+		return &MattermostReconciler{Client: c, Scheme: s, Log: logger, Recorder: record.NewFakeRecorder(10)}, c
+	}
+	getMattermost := func(t *testing.T, c client.Client, key types.NamespacedName) *mmv1beta.Mattermost {
+		mattermost := &mmv1beta.Mattermost{}
+		require.NoError(t, c.Get(context.TODO(), key, mattermost))
+		return mattermost
+	}
+	setStable := func(t *testing.T, c client.Client) {
+		target := getMattermost(t, c, targetKey)
+		target.Status.State = mmv1beta.Stable
+		require.NoError(t, c.Status().Update(context.TODO(), target))
+	}
+
+	t.Run("relocated", func(t *testing.T) {
+		mattermost := newMattermost()
+		r, c := newReconciler(mattermost, newObjects(mattermost)...)
+
+		relocating, result, err := r.checkRelocation(context.TODO(), mattermost, logger)
+		require.NoError(t, err)
+		assert.True(t, relocating)
+		assert.Equal(t, relocationRequeueDelay, result.RequeueAfter)
+		require.NotNil(t, mattermost.Status.Relocation)
+		assert.Equal(t, mmv1beta.RelocationWaitingForTarget, mattermost.Status.Relocation.Phase)
+
+		target := getMattermost(t, c, targetKey)
+		assert.Equal(t, "team-a/chat", target.Annotations[mmv1beta.RelocatedFromAnnotation])
+		assert.Nil(t, target.Spec.Relocation)
+		assert.False(t, target.Spec.Ingress.Enabled)
+		assert.Equal(t, "db", target.Spec.Database.External.Secret)
+
+		for _, name := range []string{"license", "tls", "db", "s3", "smtp"} {
+			secret := &corev1.Secret{}
+			require.NoError(t, c.Get(context.TODO(), types.NamespacedName{Namespace: targetKey.Namespace, Name: name}, secret), name)
+			assert.Equal(t, []byte(name), secret.Data["key"])
+			assert.Equal(t, "team-a/chat", secret.Annotations[mmv1beta.RelocatedFromAnnotation])
+		}
+		err = c.Get(context.TODO(), types.NamespacedName{Namespace: targetKey.Namespace, Name: "chat-generated"}, &corev1.Secret{})
+		assert.True(t, k8sErrors.IsNotFound(err))
+		err = c.Get(context.TODO(), types.NamespacedName{Namespace: targetKey.Namespace, Name: "config"}, &corev1.ConfigMap{})
+		assert.NoError(t, err)
+
+		// The ingress is kept until the relocated Mattermost is stable.
+		_, _, err = r.checkRelocation(context.TODO(), mattermost, logger)
+		require.NoError(t, err)
+		assert.Equal(t, mmv1beta.RelocationWaitingForTarget, mattermost.Status.Relocation.Phase)
+		err = c.Get(context.TODO(), sourceKey, &networkingv1.Ingress{})
+		assert.NoError(t, err)
+
+		setStable(t, c)
+		_, _, err = r.checkRelocation(context.TODO(), mattermost, logger)
+		require.NoError(t, err)
+		assert.Equal(t, mmv1beta.RelocationCuttingOver, mattermost.Status.Relocation.Phase)
+		err = c.Get(context.TODO(), sourceKey, &networkingv1.Ingress{})
+		assert.True(t, k8sErrors.IsNotFound(err))
+		assert.True(t, getMattermost(t, c, targetKey).Spec.Ingress.Enabled)
+
+		relocating, result, err = r.checkRelocation(context.TODO(), mattermost, logger)
+		require.NoError(t, err)
+		assert.True(t, relocating)
+		assert.Equal(t, time.Duration(0), result.RequeueAfter)
+		err = c.Get(context.TODO(), sourceKey, &mmv1beta.Mattermost{})
+		assert.True(t, k8sErrors.IsNotFound(err))
+	})
+
+	t.Run("database re-pointed", func(t *testing.T) {
+		mattermost := newMattermost()
+		mattermost.Spec.Relocation.DatabaseSecret = "team-b-db"
+		mattermost.Spec.Relocation.FileStore = &mmv1beta.ExternalFileStore{URL: "minio.team-b:9000", Bucket: "chat", Secret: "team-b-minio"}
+		r, c := newReconciler(mattermost, newObjects(mattermost)...)
+
+		_, _, err := r.checkRelocation(context.TODO(), mattermost, logger)
+		require.NoError(t, err)
+		target := getMattermost(t, c, targetKey)
+		assert.Equal(t, "team-b-db", target.Spec.Database.External.Secret)
+		assert.Equal(t, mattermost.Spec.Relocation.FileStore, target.Spec.FileStore.External)
+		err = c.Get(context.TODO(), types.NamespacedName{Namespace: targetKey.Namespace, Name: "db"}, &corev1.Secret{})
+		assert.True(t, k8sErrors.IsNotFound(err))
+	})
+
+	t.Run("operator managed database", func(t *testing.T) {
+		mattermost := newMattermost()
+		mattermost.Spec.Database = mmv1beta.Database{OperatorManaged: &mmv1beta.OperatorManagedDatabase{Type: "mysql"}}
+		r, c := newReconciler(mattermost, newObjects(mattermost)...)
+
+		relocating, _, err := r.checkRelocation(context.TODO(), mattermost, logger)
+		require.NoError(t, err)
+		assert.False(t, relocating)
+		assert.Equal(t, mmv1beta.RelocationFailed, mattermost.Status.Relocation.Phase)
+		assert.Contains(t, mattermost.Status.Relocation.Message, "the operator managed database cannot be relocated")
+		err = c.Get(context.TODO(), targetKey, &mmv1beta.Mattermost{})
+		assert.True(t, k8sErrors.IsNotFound(err))
+	})
+
+	t.Run("conflicting Secret", func(t *testing.T) {
+		mattermost := newMattermost()
+		conflicting := &corev1.Secret{
+			ObjectMeta: metav1.ObjectMeta{Name: "db", Namespace: targetKey.Namespace},
+			Data:       map[string][]byte{"key": []byte("other")},
+		}
+		r, c := newReconciler(mattermost, append(newObjects(mattermost), conflicting)...)
+
+		relocating, _, err := r.checkRelocation(context.TODO(), mattermost, logger)
+		require.NoError(t, err)
+		assert.False(t, relocating)
+		assert.Equal(t, "Secret db already exists in namespace team-b", mattermost.Status.Relocation.Message)
+		err = c.Get(context.TODO(), targetKey, &mmv1beta.Mattermost{})
+		assert.True(t, k8sErrors.IsNotFound(err))
+	})
+
+	t.Run("relocation aborted", func(t *testing.T) {
+		mattermost := newMattermost()
+		r, c := newReconciler(mattermost, newObjects(mattermost)...)
+
+		_, _, err := r.checkRelocation(context.TODO(), mattermost, logger)
+		require.NoError(t, err)
+		getMattermost(t, c, targetKey)
+
+		mattermost.Spec.Relocation = nil
+		relocating, _, err := r.checkRelocation(context.TODO(), mattermost, logger)
+		require.NoError(t, err)
+		assert.False(t, relocating)
+		assert.Nil(t, mattermost.Status.Relocation)
+		err = c.Get(context.TODO(), targetKey, &mmv1beta.Mattermost{})
+		assert.True(t, k8sErrors.IsNotFound(err))
+	})
+}
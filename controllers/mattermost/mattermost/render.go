@@ -0,0 +1,205 @@
+package mattermost
+
+import (
+	"fmt"
+
+	mmv1beta "github.com/mattermost/mattermost-operator/apis/mattermost/v1beta1"
+	mattermostMinio "github.com/mattermost/mattermost-operator/pkg/components/minio"
+	mattermostmysql "github.com/mattermost/mattermost-operator/pkg/components/mysql"
+	mattermostApp "github.com/mattermost/mattermost-operator/pkg/mattermost"
+	"github.com/mattermost/mattermost-operator/pkg/mattermost/supportmatrix"
+	minioConstants "github.com/minio/minio-operator/pkg/constants"
+	"github.com/pkg/errors"
+	corev1 "k8s.io/api/core/v1"
+	utilerrors "k8s.io/apimachinery/pkg/util/errors"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+// RenderOptions are the inputs of the rendering of a Mattermost which are
+// read from the cluster or the flags of the operator when reconciling it.
+type RenderOptions struct {
+	// Secrets are the Secrets referenced by the Mattermost, ie the Secrets
+	// of its external database and file store. Stand-ins with the required
+	// keys are used for the missing Secrets.
+	Secrets []corev1.Secret
+	// ImageRegistry is the image registry of the operator.
+	ImageRegistry string
+}
+
+// RenderResult holds the resources generated for a Mattermost.
+type RenderResult struct {
+	// Mattermost is the Mattermost with its defaults.
+	Mattermost *mmv1beta.Mattermost
+	// Objects are the resources the operator creates for the Mattermost.
+	Objects []client.Object
+	// Warnings report the settings which are not rendered or are ignored.
+	Warnings []string
+}
+
+// Render defaults and validates the Mattermost the way it is reconciled, and
+// returns the resources the operator creates for it, without a cluster. The
+// validation errors are aggregated. The jobs, ie the update job, and the
+// resources of the blue/green and canary deployments are not rendered.
+func Render(mattermost *mmv1beta.Mattermost, options RenderOptions) (*RenderResult, error) {
+	mattermost = mattermost.DeepCopy()
+	result := &RenderResult{Mattermost: mattermost}
+
+	if mattermost.Spec.TemplateRef != "" {
+		result.Warnings = append(result.Warnings, fmt.Sprintf("the MattermostTemplate %s is not merged", mattermost.Spec.TemplateRef))
+	}
+	if mattermost.Spec.ImageRegistry == "" {
+		mattermost.Spec.ImageRegistry = options.ImageRegistry
+	}
+	err := mattermost.SetDefaults()
+	if err != nil {
+		return nil, err
+	}
+	softError := mattermost.SetReplicasAndResourcesFromSize()
+	if softError != nil {
+		result.Warnings = append(result.Warnings, fmt.Sprintf("using the default replicas and resources: %s", softError))
+	}
+
+	var errs []error
+	if !mattermost.BlueGreenEnabled() {
+		validationErr := supportmatrix.Default.ValidateUpgrade(mattermost.Status.Version, mattermost.Spec.Version)
+		if validationErr != nil && mattermost.Spec.UpdatePolicy != nil && mattermost.Spec.UpdatePolicy.AllowUnsupportedUpgrades {
+			result.Warnings = append(result.Warnings, fmt.Sprintf("unsupported Mattermost upgrade allowed: %s", validationErr))
+		} else if validationErr != nil {
+			errs = append(errs, errors.Wrap(validationErr, "unsupported Mattermost upgrade rejected"))
+		}
+	}
+
+	dbConfig, dbObjects, err := renderDatabase(mattermost, options.Secrets, result)
+	if err != nil {
+		errs = append(errs, err)
+	}
+	fileStoreInfo, fileStoreObjects, err := renderFileStore(mattermost, options.Secrets, result)
+	if err != nil {
+		errs = append(errs, err)
+	}
+	if len(errs) > 0 {
+		return nil, utilerrors.NewAggregate(errs)
+	}
+	result.Objects = append(result.Objects, dbObjects...)
+	result.Objects = append(result.Objects, fileStoreObjects...)
+
+	result.Objects = append(result.Objects, mattermostApp.GenerateServiceV1Beta(mattermost, mattermost.Name, mattermost.GetProductionDeploymentName()))
+	if mattermost.ClusteringEnabled() {
+		result.Objects = append(result.Objects, mattermostApp.GenerateClusterServiceV1Beta(mattermost, mattermost.GetProductionDeploymentName()))
+	} else if mattermost.Spec.Replicas != nil && *mattermost.Spec.Replicas > 1 {
+		result.Warnings = append(result.Warnings, "clustering requires the Enterprise Edition and a license, replicas will not share cluster state")
+	}
+
+	if mattermost.AppMonitoringEnabled() {
+		result.Objects = append(result.Objects, mattermostApp.GenerateServiceMonitorV1Beta(mattermost))
+	}
+	if mattermost.MonitoringEnabled() && !mattermost.Spec.Database.IsExternal() {
+		result.Objects = append(result.Objects, mattermostmysql.PodMonitorV1Beta(mattermost))
+	}
+	if mattermost.MonitoringEnabled() && !mattermost.Spec.FileStore.IsExternal() {
+		result.Objects = append(result.Objects, mattermostMinio.PodMonitorV1Beta(mattermost))
+	}
+	if mattermost.AlertsEnabled() {
+		result.Objects = append(result.Objects, mattermostApp.GeneratePrometheusRuleV1Beta(mattermost))
+	}
+
+	result.Objects = append(result.Objects,
+		mattermostApp.GenerateServiceAccountV1Beta(mattermost, mattermost.Name),
+		mattermostApp.GenerateRoleV1Beta(mattermost, mattermost.Name),
+		mattermostApp.GenerateRoleBindingV1Beta(mattermost, mattermost.Name, mattermost.Name),
+	)
+
+	// The ingress of a standby Mattermost is created once it is promoted.
+	if !mattermost.Spec.UseServiceLoadBalancer && mattermost.IngressEnabled() && !mattermost.StandbyEnabled() {
+		result.Objects = append(result.Objects, mattermostApp.GenerateIngressV1Beta(mattermost, mattermost.Name, mattermost.GetIngressHost()))
+	}
+
+	if mattermost.BlueGreenEnabled() {
+		result.Warnings = append(result.Warnings, "the resources of the blue/green deployments are not rendered")
+	} else {
+		deployment := generateMattermostDeployment(mattermost, dbConfig, fileStoreInfo)
+		if mattermost.MaintenanceWindowEnabled() {
+			err = mattermostApp.SetPodTemplateHash(deployment)
+			if err != nil {
+				return nil, errors.Wrap(err, "failed to set pod template hash")
+			}
+		}
+		result.Objects = append(result.Objects, deployment)
+	}
+	if mattermost.CanaryEnabled() {
+		result.Warnings = append(result.Warnings, "the resources of the canary deployment are not rendered")
+	}
+
+	return result, nil
+}
+
+// renderDatabase returns the database configuration of the Mattermost and
+// the resources of its operator managed database.
+func renderDatabase(mattermost *mmv1beta.Mattermost, secrets []corev1.Secret, result *RenderResult) (mattermostApp.DatabaseConfig, []client.Object, error) {
+	if mattermost.Spec.Database.IsExternal() {
+		secret, found := findSecret(secrets, mattermost.Spec.Database.External.Secret)
+		if !found {
+			result.Warnings = append(result.Warnings, fmt.Sprintf("the external database Secret %s is not provided, a PostgreSQL database is assumed", secret.Name))
+			secret.Data = map[string][]byte{"DB_CONNECTION_STRING": []byte("postgres://")}
+		}
+		dbConfig, err := mattermostApp.NewExternalDBConfig(mattermost, secret)
+		return dbConfig, nil, err
+	}
+
+	if mattermost.Spec.Database.OperatorManaged == nil {
+		return nil, nil, errors.New("configuration for Operator managed database not provided")
+	}
+	if mattermost.Spec.Database.OperatorManaged.Type != "mysql" {
+		return nil, nil, errors.Errorf("database of type '%s' is not supported", mattermost.Spec.Database.OperatorManaged.Type)
+	}
+	// The credentials of the MySQL database are generated by the operator
+	// unless the Secret exists.
+	secret, found := findSecret(secrets, mattermostmysql.DefaultDatabaseSecretName(mattermost.Name))
+	if !found {
+		secret.Data = map[string][]byte{
+			"ROOT_PASSWORD": []byte("generated"),
+			"USER":          []byte("generated"),
+			"PASSWORD":      []byte("generated"),
+			"DATABASE":      []byte("mattermost"),
+		}
+	}
+	dbConfig, err := mattermostApp.NewMySQLDBConfig(secret)
+	return dbConfig, []client.Object{mattermostmysql.ClusterV1Beta(mattermost)}, err
+}
+
+// renderFileStore returns the file store configuration of the Mattermost and
+// the resources of its operator managed file store.
+func renderFileStore(mattermost *mmv1beta.Mattermost, secrets []corev1.Secret, result *RenderResult) (*mattermostApp.FileStoreInfo, []client.Object, error) {
+	if err := mattermostApp.ValidateFileStore(mattermost.Spec.FileStore); err != nil {
+		return nil, nil, err
+	}
+
+	if mattermost.Spec.FileStore.IsExternal() {
+		secret, found := findSecret(secrets, mattermost.Spec.FileStore.External.Secret)
+		if !found {
+			result.Warnings = append(result.Warnings, fmt.Sprintf("the external file store Secret %s is not provided", secret.Name))
+			secret.Data = map[string][]byte{"accesskey": nil, "secretkey": nil}
+		}
+		fileStoreInfo, err := mattermostApp.NewExternalFileStoreInfo(mattermost, secret)
+		return fileStoreInfo, nil, err
+	}
+
+	// The credentials of the Minio instance are generated by the operator,
+	// and its URL is the one of the service created by the Minio operator.
+	minioURL := fmt.Sprintf("%s-minio-hl-svc.%s:%d", mattermost.Name, mattermost.Namespace, minioConstants.MinIOPort)
+	fileStoreInfo := mattermostApp.NewOperatorManagedFileStoreInfo(mattermost, mattermostMinio.DefaultMinioSecretName(mattermost.Name), minioURL)
+	return fileStoreInfo, []client.Object{mattermostMinio.InstanceV1Beta(mattermost)}, nil
+}
+
+// findSecret returns the Secret with the name, or an empty Secret with the
+// name if it is not found.
+func findSecret(secrets []corev1.Secret, name string) (corev1.Secret, bool) {
+	for _, secret := range secrets {
+		if secret.Name == name {
+			return secret, true
+		}
+	}
+	secret := corev1.Secret{}
+	secret.Name = name
+	return secret, false
+}
@@ -0,0 +1,108 @@
+package mattermost
+
+import (
+	"testing"
+
+	mmv1beta "github.com/mattermost/mattermost-operator/apis/mattermost/v1beta1"
+	"github.com/mattermost/mattermost-operator/pkg/utils"
+	mysqlOperator "github.com/presslabs/mysql-operator/pkg/apis/mysql/v1alpha1"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	networkingv1 "k8s.io/api/networking/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+func TestRender(t *testing.T) {
+	mattermost := &mmv1beta.Mattermost{
+		ObjectMeta: metav1.ObjectMeta{Name: "chat", Namespace: "team"},
+		Spec: mmv1beta.MattermostSpec{
+			Replicas:  utils.NewInt32(1),
+			Ingress:   &mmv1beta.Ingress{Enabled: true, Host: "chat.example.com"},
+			Database:  mmv1beta.Database{External: &mmv1beta.ExternalDatabase{Secret: "db"}},
+			FileStore: mmv1beta.FileStore{External: &mmv1beta.ExternalFileStore{URL: "s3.amazonaws.com", Bucket: "chat", Secret: "s3"}},
+		},
+	}
+	secrets := []corev1.Secret{
+		{
+			ObjectMeta: metav1.ObjectMeta{Name: "db"},
+			Data:       map[string][]byte{"DB_CONNECTION_STRING": []byte("postgres://mmuser:secret@db:5432/mattermost")},
+		},
+		{
+			ObjectMeta: metav1.ObjectMeta{Name: "s3"},
+			Data:       map[string][]byte{"accesskey": []byte("key"), "secretkey": []byte("secret")},
+		},
+	}
+	kinds := func(objects []client.Object) []string {
+		var kinds []string
+		for _, obj := range objects {
+			switch obj.(type) {
+			case *corev1.Service:
+				kinds = append(kinds, "Service")
+			case *corev1.ServiceAccount:
+				kinds = append(kinds, "ServiceAccount")
+			case *appsv1.Deployment:
+				kinds = append(kinds, "Deployment")
+			case *networkingv1.Ingress:
+				kinds = append(kinds, "Ingress")
+			case *mysqlOperator.MysqlCluster:
+				kinds = append(kinds, "MysqlCluster")
+			default:
+				kinds = append(kinds, obj.GetObjectKind().GroupVersionKind().Kind)
+			}
+		}
+		return kinds
+	}
+
+	result, err := Render(mattermost, RenderOptions{Secrets: secrets})
+	require.NoError(t, err)
+	assert.Empty(t, result.Warnings)
+	assert.Equal(t, mmv1beta.DefaultMattermostVersion, result.Mattermost.Spec.Version)
+	assert.Empty(t, mattermost.Spec.Version)
+	require.Len(t, result.Objects, 6)
+	assert.Equal(t, []string{"Service", "ServiceAccount", "", "", "Ingress", "Deployment"}, kinds(result.Objects))
+	deployment := result.Objects[5].(*appsv1.Deployment)
+	assert.Equal(t, result.Mattermost.GetImageName(), mmv1beta.GetMattermostAppContainerFromDeployment(deployment).Image)
+
+	t.Run("missing secrets", func(t *testing.T) {
+		result, err := Render(mattermost, RenderOptions{})
+		require.NoError(t, err)
+		assert.Len(t, result.Warnings, 2)
+		assert.Len(t, result.Objects, 6)
+	})
+
+	t.Run("operator managed database", func(t *testing.T) {
+		managed := mattermost.DeepCopy()
+		managed.Spec.Database = mmv1beta.Database{OperatorManaged: &mmv1beta.OperatorManagedDatabase{Type: "mysql"}}
+		result, err := Render(managed, RenderOptions{Secrets: secrets})
+		require.NoError(t, err)
+		assert.Equal(t, "MysqlCluster", kinds(result.Objects)[0])
+	})
+
+	t.Run("standby", func(t *testing.T) {
+		standby := mattermost.DeepCopy()
+		standby.Spec.Standby = &mmv1beta.Standby{}
+		result, err := Render(standby, RenderOptions{Secrets: secrets})
+		require.NoError(t, err)
+		assert.NotContains(t, kinds(result.Objects), "Ingress")
+		deployment := result.Objects[len(result.Objects)-1].(*appsv1.Deployment)
+		assert.Equal(t, int32(0), *deployment.Spec.Replicas)
+	})
+
+	t.Run("validation errors", func(t *testing.T) {
+		invalid := mattermost.DeepCopy()
+		invalid.Spec.FileStore.External.Bucket = ""
+		invalid.Status.Version = "5.31.3"
+		invalid.Spec.Version = "5.39.0"
+		_, err := Render(invalid, RenderOptions{Secrets: secrets})
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "unsupported Mattermost upgrade rejected")
+		assert.Contains(t, err.Error(), "external file store bucket is empty")
+
+		invalid.Spec.Ingress.Host = ""
+		_, err = Render(invalid, RenderOptions{Secrets: secrets})
+		assert.EqualError(t, err, "ingress.host required, but not set")
+	})
+}
@@ -0,0 +1,114 @@
+package mattermost
+
+import (
+	"context"
+	"encoding/json"
+
+	"github.com/go-logr/logr"
+	mmv1beta "github.com/mattermost/mattermost-operator/apis/mattermost/v1beta1"
+	mattermostApp "github.com/mattermost/mattermost-operator/pkg/mattermost"
+	"github.com/mattermost/mattermost-operator/pkg/mattermost/revisions"
+	"github.com/pkg/errors"
+	corev1 "k8s.io/api/core/v1"
+	k8sErrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+)
+
+// revisionsSecretSuffix is the suffix of the name of the Secret holding the
+// revision history of a Mattermost. A Secret is used as the environment of
+// Mattermost may hold credentials.
+const revisionsSecretSuffix = "-revisions"
+
+// checkRollbackTo restores the settings of the revision requested with
+// spec.rollbackTo in the spec and clears it, returning the restored revision.
+// The spec is saved with the defaults.
+func (r *MattermostReconciler) checkRollbackTo(ctx context.Context, mattermost *mmv1beta.Mattermost, reqLogger logr.Logger) (*revisions.Revision, error) {
+	if mattermost.Spec.RollbackTo == nil {
+		return nil, nil
+	}
+
+	history, _, err := r.getRevisionHistory(ctx, mattermost)
+	if err != nil {
+		return nil, err
+	}
+	revision, found := revisions.Find(history, *mattermost.Spec.RollbackTo)
+	if !found {
+		return nil, errors.Errorf("revision %d to roll back to is not in the revision history", *mattermost.Spec.RollbackTo)
+	}
+
+	reqLogger.Info("Rolling back to revision", "revision", revision.Number)
+	revision.Settings.Apply(&mattermost.Spec)
+	mattermost.Spec.RollbackTo = nil
+	return &revision, nil
+}
+
+// checkRevisionHistory records the settings of the successfully reconciled
+// Mattermost in its revision history if they changed, keeping the last
+// RevisionHistoryLimit revisions, and returns the current revision.
+func (r *MattermostReconciler) checkRevisionHistory(ctx context.Context, mattermost *mmv1beta.Mattermost, current int64, reqLogger logr.Logger) (int64, error) {
+	if r.RevisionHistoryLimit <= 0 {
+		return current, nil
+	}
+
+	history, secret, err := r.getRevisionHistory(ctx, mattermost)
+	if err != nil {
+		return current, err
+	}
+	history, recorded := revisions.Record(history, revisions.FromSpec(mattermost.Spec), mattermost.Generation, metav1.Now(), r.RevisionHistoryLimit)
+	if !recorded {
+		return history[0].Number, nil
+	}
+
+	data, err := json.MarshalIndent(history, "", "  ")
+	if err != nil {
+		return current, errors.Wrap(err, "failed to marshal revision history")
+	}
+
+	reqLogger.Info("Recording revision in the revision history", "revision", history[0].Number)
+	if secret != nil {
+		secret.Data = map[string][]byte{revisions.RevisionsKey: data}
+		err = r.Client.Update(ctx, secret)
+		if err != nil {
+			return current, errors.Wrap(err, "failed to update revision history Secret")
+		}
+		return history[0].Number, nil
+	}
+
+	secret = &corev1.Secret{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:            mattermost.Name + revisionsSecretSuffix,
+			Namespace:       mattermost.Namespace,
+			Labels:          mmv1beta.MattermostResourceLabels(mattermost.Name),
+			OwnerReferences: mattermostApp.MattermostOwnerReference(mattermost),
+		},
+		Data: map[string][]byte{revisions.RevisionsKey: data},
+	}
+	err = r.Client.Create(ctx, secret)
+	if err != nil {
+		return current, errors.Wrap(err, "failed to create revision history Secret")
+	}
+	return history[0].Number, nil
+}
+
+// getRevisionHistory returns the revisions of the Mattermost, newest first,
+// and the Secret holding them, nil if it does not exist.
+func (r *MattermostReconciler) getRevisionHistory(ctx context.Context, mattermost *mmv1beta.Mattermost) ([]revisions.Revision, *corev1.Secret, error) {
+	secret := &corev1.Secret{}
+	err := r.NonCachedAPIReader.Get(ctx, types.NamespacedName{Name: mattermost.Name + revisionsSecretSuffix, Namespace: mattermost.Namespace}, secret)
+	if k8sErrors.IsNotFound(err) {
+		return nil, nil, nil
+	}
+	if err != nil {
+		return nil, nil, errors.Wrap(err, "failed to get revision history Secret")
+	}
+
+	var history []revisions.Revision
+	if data, ok := secret.Data[revisions.RevisionsKey]; ok {
+		err = json.Unmarshal(data, &history)
+		if err != nil {
+			return nil, nil, errors.Wrap(err, "failed to unmarshal revision history")
+		}
+	}
+	return history, secret, nil
+}
@@ -0,0 +1,74 @@
+package mattermost
+
+import (
+	"context"
+	"testing"
+
+	blubr "github.com/mattermost/blubr"
+	mmv1beta "github.com/mattermost/mattermost-operator/apis/mattermost/v1beta1"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes/scheme"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+)
+
+func TestRevisionHistory(t *testing.T) {
+	logger := blubr.InitLogger()
+	s := prepareSchema(t, scheme.Scheme)
+	s.AddKnownTypes(mmv1beta.GroupVersion, &mmv1beta.Mattermost{})
+
+	client := fake.NewFakeClientWithScheme(s)
+	r := &MattermostReconciler{Client: client, NonCachedAPIReader: client, Scheme: s, RevisionHistoryLimit: 3}
+
+	mattermost := &mmv1beta.Mattermost{
+		ObjectMeta: metav1.ObjectMeta{Name: "mm", Namespace: "mm-namespace", UID: "mm-uid", Generation: 1},
+		Spec: mmv1beta.MattermostSpec{
+			Image:   "mattermost/mattermost-enterprise-edition",
+			Version: "6.0.0",
+			Ingress: &mmv1beta.Ingress{Enabled: true, Host: "chat.example.com"},
+		},
+	}
+
+	revision, err := r.checkRevisionHistory(context.TODO(), mattermost, 0, logger)
+	require.NoError(t, err)
+	assert.Equal(t, int64(1), revision)
+
+	t.Run("unchanged settings", func(t *testing.T) {
+		mattermost.Generation = 2
+		mattermost.Spec.Replicas = new(int32)
+		revision, err := r.checkRevisionHistory(context.TODO(), mattermost, 1, logger)
+		require.NoError(t, err)
+		assert.Equal(t, int64(1), revision)
+	})
+
+	t.Run("changed settings", func(t *testing.T) {
+		mattermost.Generation = 3
+		mattermost.Spec.Version = "6.1.0"
+		mattermost.Spec.Ingress.Host = "mattermost.example.com"
+		revision, err := r.checkRevisionHistory(context.TODO(), mattermost, 1, logger)
+		require.NoError(t, err)
+		assert.Equal(t, int64(2), revision)
+	})
+
+	t.Run("roll back", func(t *testing.T) {
+		mattermost.Spec.RollbackTo = new(int64)
+		*mattermost.Spec.RollbackTo = 1
+		restored, err := r.checkRollbackTo(context.TODO(), mattermost, logger)
+		require.NoError(t, err)
+		require.NotNil(t, restored)
+		assert.Equal(t, int64(1), restored.Number)
+		assert.Nil(t, mattermost.Spec.RollbackTo)
+		assert.Equal(t, "6.0.0", mattermost.Spec.Version)
+		assert.Equal(t, "chat.example.com", mattermost.Spec.Ingress.Host)
+		assert.NotNil(t, mattermost.Spec.Replicas)
+	})
+
+	t.Run("unknown revision", func(t *testing.T) {
+		mattermost.Spec.RollbackTo = new(int64)
+		*mattermost.Spec.RollbackTo = 5
+		_, err := r.checkRollbackTo(context.TODO(), mattermost, logger)
+		require.Error(t, err)
+		assert.Equal(t, "6.0.0", mattermost.Spec.Version)
+	})
+}
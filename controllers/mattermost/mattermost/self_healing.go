@@ -0,0 +1,77 @@
+package mattermost
+
+import (
+	"context"
+	"time"
+
+	"github.com/go-logr/logr"
+	mmv1beta "github.com/mattermost/mattermost-operator/apis/mattermost/v1beta1"
+	mattermostApp "github.com/mattermost/mattermost-operator/pkg/mattermost"
+	"github.com/pkg/errors"
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// checkSelfHealing restarts the Mattermost pods with a rolling restart once
+// the application health checks failed the failure threshold times in a row,
+// at most once per cooloff and only if a check failed since the previous
+// restart. Deployments being rolled out are not restarted.
+func (r *MattermostReconciler) checkSelfHealing(ctx context.Context, mattermost *mmv1beta.Mattermost, status *mmv1beta.MattermostStatus, reqLogger logr.Logger) {
+	health := status.ApplicationHealth
+	if !mattermost.SelfHealingEnabled() || health == nil || health.LastCheckTime == nil {
+		return
+	}
+	selfHealing := mattermost.Spec.SelfHealing
+	if health.ConsecutiveFailures < selfHealing.GetFailureThreshold() {
+		return
+	}
+	if health.LastRestartTime != nil &&
+		(!health.LastCheckTime.After(health.LastRestartTime.Time) || time.Since(health.LastRestartTime.Time) < selfHealing.GetCooloff()) {
+		return
+	}
+
+	restartTime := metav1.Now()
+	restarted, err := r.restartDeployments(ctx, mattermost, restartTime.Time, reqLogger)
+	if restarted > 0 {
+		health.LastRestartTime = &restartTime
+		r.Recorder.Eventf(mattermost, corev1.EventTypeWarning, "SelfHealingRestart",
+			"Restarted Mattermost after %d application health checks failed in a row: %s", health.ConsecutiveFailures, health.Error)
+	}
+	if err != nil {
+		reqLogger.Error(err, "Failed to restart Mattermost")
+	}
+}
+
+// restartDeployments annotates the pod templates of the app deployments with
+// the restart time, returning how many deployments were restarted. None is
+// restarted while one of them is rolled out.
+func (r *MattermostReconciler) restartDeployments(ctx context.Context, mattermost *mmv1beta.Mattermost, restartTime time.Time, reqLogger logr.Logger) (int, error) {
+	var deployments []*appsv1.Deployment
+	for _, name := range appDeploymentNames(mattermost) {
+		deployment := &appsv1.Deployment{}
+		found, err := r.getComponent(mattermost, name, deployment)
+		if err != nil {
+			return 0, err
+		}
+		if !found {
+			continue
+		}
+		if rolloutInProgress(deployment) {
+			reqLogger.Info("Skipping self-healing restart while the deployment is rolled out", "deployment", name)
+			return 0, nil
+		}
+		deployments = append(deployments, deployment)
+	}
+
+	for i, deployment := range deployments {
+		reqLogger.Info("Restarting Mattermost deployment after repeated application health check failures", "deployment", deployment.Name)
+		mattermostApp.SetRestartedAt(deployment, restartTime)
+		err := r.Client.Update(ctx, deployment)
+		if err != nil {
+			return i, errors.Wrapf(err, "failed to restart deployment %s", deployment.Name)
+		}
+	}
+
+	return len(deployments), nil
+}
@@ -0,0 +1,109 @@
+package mattermost
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	blubr "github.com/mattermost/blubr"
+	mmv1beta "github.com/mattermost/mattermost-operator/apis/mattermost/v1beta1"
+	mattermostApp "github.com/mattermost/mattermost-operator/pkg/mattermost"
+	"github.com/mattermost/mattermost-operator/pkg/utils"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	appsv1 "k8s.io/api/apps/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/kubernetes/scheme"
+	"k8s.io/client-go/tools/record"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+)
+
+func TestCheckSelfHealing(t *testing.T) {
+	logger := blubr.InitLogger()
+	s := prepareSchema(t, scheme.Scheme)
+	s.AddKnownTypes(mmv1beta.GroupVersion, &mmv1beta.Mattermost{})
+
+	mattermost := &mmv1beta.Mattermost{
+		ObjectMeta: metav1.ObjectMeta{Name: "mm", Namespace: "mm-namespace"},
+		Spec: mmv1beta.MattermostSpec{
+			SelfHealing: &mmv1beta.SelfHealing{Enabled: true, FailureThreshold: 2},
+		},
+	}
+	newDeployment := func() *appsv1.Deployment {
+		return &appsv1.Deployment{
+			ObjectMeta: metav1.ObjectMeta{Name: "mm", Namespace: "mm-namespace"},
+			Spec:       appsv1.DeploymentSpec{Replicas: utils.NewInt32(2)},
+			Status:     appsv1.DeploymentStatus{UpdatedReplicas: 2, ReadyReplicas: 2},
+		}
+	}
+	failingHealth := func(failures int32) *mmv1beta.ApplicationHealthStatus {
+		checkTime := metav1.Now()
+		return &mmv1beta.ApplicationHealthStatus{LastCheckTime: &checkTime, ConsecutiveFailures: failures, Error: "connection refused"}
+	}
+	restartedAt := func(t *testing.T, r *MattermostReconciler) string {
+		deployment := &appsv1.Deployment{}
+		err := r.Client.Get(context.TODO(), types.NamespacedName{Name: "mm", Namespace: "mm-namespace"}, deployment)
+		require.NoError(t, err)
+		return deployment.Spec.Template.Annotations[mattermostApp.RestartedAtAnnotation]
+	}
+	newReconciler := func(deployment *appsv1.Deployment) (*MattermostReconciler, *record.FakeRecorder) {
+		recorder := record.NewFakeRecorder(10)
+		return &MattermostReconciler{Client: fake.NewFakeClientWithScheme(s, deployment), Scheme: s, Recorder: recorder}, recorder
+	}
+
+	t.Run("restart once the threshold is reached", func(t *testing.T) {
+		r, recorder := newReconciler(newDeployment())
+
+		status := mmv1beta.MattermostStatus{ApplicationHealth: failingHealth(1)}
+		r.checkSelfHealing(context.TODO(), mattermost, &status, logger)
+		assert.Nil(t, status.ApplicationHealth.LastRestartTime)
+		assert.Empty(t, restartedAt(t, r))
+
+		status.ApplicationHealth = failingHealth(2)
+		r.checkSelfHealing(context.TODO(), mattermost, &status, logger)
+		require.NotNil(t, status.ApplicationHealth.LastRestartTime)
+		assert.Equal(t, status.ApplicationHealth.LastRestartTime.UTC().Format(time.RFC3339), restartedAt(t, r))
+		require.Len(t, recorder.Events, 1)
+		assert.Contains(t, <-recorder.Events, "SelfHealingRestart")
+	})
+
+	t.Run("no restart within the cooloff", func(t *testing.T) {
+		r, recorder := newReconciler(newDeployment())
+
+		lastRestart := metav1.NewTime(time.Now().Add(-10 * time.Minute))
+		status := mmv1beta.MattermostStatus{ApplicationHealth: failingHealth(5)}
+		status.ApplicationHealth.LastRestartTime = &lastRestart
+		r.checkSelfHealing(context.TODO(), mattermost, &status, logger)
+		assert.Equal(t, &lastRestart, status.ApplicationHealth.LastRestartTime)
+		assert.Empty(t, restartedAt(t, r))
+		assert.Len(t, recorder.Events, 0)
+
+		lastRestart = metav1.NewTime(time.Now().Add(-time.Hour))
+		r.checkSelfHealing(context.TODO(), mattermost, &status, logger)
+		assert.NotEqual(t, &lastRestart, status.ApplicationHealth.LastRestartTime)
+		assert.NotEmpty(t, restartedAt(t, r))
+	})
+
+	t.Run("no restart while rolled out", func(t *testing.T) {
+		deployment := newDeployment()
+		deployment.Status.UpdatedReplicas = 1
+		r, _ := newReconciler(deployment)
+
+		status := mmv1beta.MattermostStatus{ApplicationHealth: failingHealth(2)}
+		r.checkSelfHealing(context.TODO(), mattermost, &status, logger)
+		assert.Nil(t, status.ApplicationHealth.LastRestartTime)
+		assert.Empty(t, restartedAt(t, r))
+	})
+
+	t.Run("disabled", func(t *testing.T) {
+		r, _ := newReconciler(newDeployment())
+		disabled := mattermost.DeepCopy()
+		disabled.Spec.SelfHealing.Enabled = false
+
+		status := mmv1beta.MattermostStatus{ApplicationHealth: failingHealth(5)}
+		r.checkSelfHealing(context.TODO(), disabled, &status, logger)
+		assert.Nil(t, status.ApplicationHealth.LastRestartTime)
+		assert.Empty(t, restartedAt(t, r))
+	})
+}
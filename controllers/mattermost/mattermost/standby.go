@@ -0,0 +1,110 @@
+package mattermost
+
+import (
+	"context"
+
+	"github.com/go-logr/logr"
+	mmv1beta "github.com/mattermost/mattermost-operator/apis/mattermost/v1beta1"
+	mattermostApp "github.com/mattermost/mattermost-operator/pkg/mattermost"
+	"github.com/pkg/errors"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	k8sLabels "k8s.io/apimachinery/pkg/labels"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+// setStandbyCondition sets the Standby condition of the Mattermost. The
+// condition is only reported as false once the Mattermost was a standby, it
+// is not added to the other Mattermosts.
+func setStandbyCondition(status *mmv1beta.MattermostStatus, generation int64, standby bool) {
+	if standby {
+		setStatusCondition(status, metav1.Condition{
+			Type:               mmv1beta.StandbyCondition,
+			Status:             metav1.ConditionTrue,
+			ObservedGeneration: generation,
+			Reason:             "Standby",
+			Message:            "The Mattermost is a standby, its deployment is scaled to zero and its ingress withheld until it is promoted",
+		})
+		return
+	}
+	if meta.IsStatusConditionTrue(status.Conditions, mmv1beta.StandbyCondition) {
+		setStatusCondition(status, metav1.Condition{
+			Type:               mmv1beta.StandbyCondition,
+			Status:             metav1.ConditionFalse,
+			ObservedGeneration: generation,
+			Reason:             "Promoted",
+			Message:            "The standby Mattermost is promoted",
+		})
+	}
+}
+
+// checkPromotion reports the promotion of the standby Mattermost, whose
+// deployment is then scaled to its replicas and ingress created.
+func (r *MattermostReconciler) checkPromotion(mattermost *mmv1beta.Mattermost, status *mmv1beta.MattermostStatus, reqLogger logr.Logger) {
+	if mattermost.StandbyEnabled() || !meta.IsStatusConditionTrue(status.Conditions, mmv1beta.StandbyCondition) {
+		return
+	}
+	reqLogger.Info("Standby Mattermost promoted")
+	r.recordEvent(mattermost, corev1.EventTypeNormal, "Promoted", "The standby Mattermost is promoted, its deployment is scaled up and its ingress created")
+	setStandbyCondition(status, mattermost.Generation, false)
+}
+
+// checkStandby reconciles the standby Mattermost: its resources are kept up
+// to date, its deployment scaled to zero and without ingress, and it is
+// reported standing by once its pods are terminated. The jobs and checks
+// using the database and file store are left to the active installation.
+func (r *MattermostReconciler) checkStandby(
+	ctx context.Context,
+	mattermost *mmv1beta.Mattermost,
+	status mmv1beta.MattermostStatus,
+	dbConfig mattermostApp.DatabaseConfig,
+	fileStoreConfig *mattermostApp.FileStoreInfo,
+	reqLogger logr.Logger) (ctrl.Result, error) {
+	reqLogger = reqLogger.WithValues("phase", "standby")
+
+	err := r.checkMattermost(mattermost, dbConfig, fileStoreConfig, reqLogger)
+	if err != nil {
+		r.updateStatusReconcilingAndLogError(mattermost, status, err, reqLogger)
+		return ctrl.Result{}, err
+	}
+
+	var pods corev1.PodList
+	err = r.NonCachedAPIReader.List(ctx, &pods,
+		client.InNamespace(mattermost.Namespace),
+		client.MatchingLabels(mattermost.MattermostLabels(mattermost.Name)),
+	)
+	if err != nil {
+		err = errors.Wrap(err, "failed to list the pods of the standby Mattermost")
+		r.updateStatusReconcilingAndLogError(mattermost, status, err, reqLogger)
+		return ctrl.Result{}, err
+	}
+
+	_, image, version := mattermostImage(mattermost)
+	status.Image = image
+	status.Version = version
+	status.Endpoint = "not available"
+	status.Replicas = int32(len(pods.Items))
+	status.UpdatedReplicas = 0
+	status.DesiredReplicas = 0
+	status.Selector = k8sLabels.SelectorFromSet(mmv1beta.MattermostSelectorLabels(mattermost.Name)).String()
+	status.State = mmv1beta.StandingBy
+	if len(pods.Items) > 0 {
+		reqLogger.Info("Waiting for the pods of the standby Mattermost to terminate", "pods", len(pods.Items))
+		status.State = mmv1beta.Reconciling
+	}
+	setStandbyCondition(&status, mattermost.Generation, true)
+	setStateConditions(&status, mattermost.Generation, nil, nil)
+
+	err = r.updateStatus(mattermost, status, reqLogger)
+	if err != nil {
+		r.updateStatusReconcilingAndLogError(mattermost, status, err, reqLogger)
+		return ctrl.Result{}, err
+	}
+
+	if status.State != mmv1beta.StandingBy {
+		return ctrl.Result{RequeueAfter: healthCheckRequeueDelay}, nil
+	}
+	return ctrl.Result{}, nil
+}
@@ -0,0 +1,128 @@
+package mattermost
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	blubr "github.com/mattermost/blubr"
+	mmv1beta "github.com/mattermost/mattermost-operator/apis/mattermost/v1beta1"
+	mattermostApp "github.com/mattermost/mattermost-operator/pkg/mattermost"
+	"github.com/mattermost/mattermost-operator/pkg/resources"
+	resourcesfake "github.com/mattermost/mattermost-operator/pkg/resources/fake"
+	operatortest "github.com/mattermost/mattermost-operator/test"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	networkingv1 "k8s.io/api/networking/v1"
+	k8sErrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/kubernetes/scheme"
+	"k8s.io/client-go/tools/record"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+)
+
+func TestCheckStandby(t *testing.T) {
+	logger := blubr.InitLogger()
+	s := prepareSchema(t, scheme.Scheme)
+	key := types.NamespacedName{Namespace: "dr", Name: "chat"}
+
+	replicas := int32(2)
+	mattermost := &mmv1beta.Mattermost{
+		ObjectMeta: metav1.ObjectMeta{Name: key.Name, Namespace: key.Namespace, UID: "mm-uid", Generation: 1},
+		Spec: mmv1beta.MattermostSpec{
+			Replicas:  &replicas,
+			Image:     "mattermost/mattermost-enterprise-edition",
+			Version:   operatortest.LatestStableMattermostVersion,
+			Ingress:   &mmv1beta.Ingress{Enabled: true, Host: "chat.example.com"},
+			Database:  mmv1beta.Database{External: &mmv1beta.ExternalDatabase{Secret: "db"}},
+			FileStore: mmv1beta.FileStore{External: &mmv1beta.ExternalFileStore{URL: "s3.amazonaws.com", Bucket: "chat", Secret: "s3"}},
+			Standby:   &mmv1beta.Standby{},
+		},
+	}
+	dbConfig, err := mattermostApp.NewExternalDBConfig(mattermost, corev1.Secret{
+		Data: map[string][]byte{"DB_CONNECTION_STRING": []byte("postgres://mmuser:secret@db:5432/mattermost")},
+	})
+	require.NoError(t, err)
+	fileStoreConfig, err := mattermostApp.NewExternalFileStoreInfo(mattermost, corev1.Secret{
+		Data: map[string][]byte{"accesskey": []byte("key"), "secretkey": []byte("secret")},
+	})
+	require.NoError(t, err)
+
+	// The standby Mattermost replaces an installation whose pods and ingress
+	// still exist.
+	ingress := &networkingv1.Ingress{ObjectMeta: metav1.ObjectMeta{Name: key.Name, Namespace: key.Namespace}}
+	pod := &corev1.Pod{ObjectMeta: metav1.ObjectMeta{
+		Name:      "chat-1",
+		Namespace: key.Namespace,
+		Labels:    mattermost.MattermostLabels(mattermost.Name),
+	}}
+	c := fake.NewClientBuilder().WithScheme(s).WithObjects(mattermost, ingress, pod).Build()
+	recorder := record.NewFakeRecorder(10)
+	r := &MattermostReconciler{
+		Client:             c,
+		NonCachedAPIReader: c,
+		Scheme:             s,
+		Log:                logger,
+		Resources:          resources.NewResourceHelper(resourcesfake.NewApplyClient(c), s),
+		Recorder:           recorder,
+	}
+
+	result, err := r.checkStandby(context.TODO(), mattermost, mattermost.Status, dbConfig, fileStoreConfig, logger)
+	require.NoError(t, err)
+	assert.Equal(t, healthCheckRequeueDelay, result.RequeueAfter)
+	assert.Equal(t, mmv1beta.Reconciling, mattermost.Status.State)
+
+	deployment := &appsv1.Deployment{}
+	require.NoError(t, c.Get(context.TODO(), key, deployment))
+	require.NotNil(t, deployment.Spec.Replicas)
+	assert.Equal(t, int32(0), *deployment.Spec.Replicas)
+	err = c.Get(context.TODO(), key, &networkingv1.Ingress{})
+	assert.True(t, k8sErrors.IsNotFound(err))
+
+	require.NoError(t, c.Delete(context.TODO(), pod))
+	result, err = r.checkStandby(context.TODO(), mattermost, mattermost.Status, dbConfig, fileStoreConfig, logger)
+	require.NoError(t, err)
+	assert.Equal(t, time.Duration(0), result.RequeueAfter)
+	assert.Equal(t, mmv1beta.StandingBy, mattermost.Status.State)
+	assert.Equal(t, operatortest.LatestStableMattermostVersion, mattermost.Status.Version)
+	assert.True(t, meta.IsStatusConditionTrue(mattermost.Status.Conditions, mmv1beta.StandbyCondition))
+	ready := meta.FindStatusCondition(mattermost.Status.Conditions, mmv1beta.ReadyCondition)
+	require.NotNil(t, ready)
+	assert.Equal(t, "Standby", ready.Reason)
+
+	t.Run("upgraded without update job", func(t *testing.T) {
+		upgraded := mattermost.DeepCopy()
+		upgraded.Spec.Version = "9.0.0"
+		_, err := r.checkStandby(context.TODO(), upgraded, upgraded.Status, dbConfig, fileStoreConfig, logger)
+		require.NoError(t, err)
+
+		deployment := &appsv1.Deployment{}
+		require.NoError(t, c.Get(context.TODO(), key, deployment))
+		assert.Equal(t, "mattermost/mattermost-enterprise-edition:9.0.0", mmv1beta.GetMattermostAppContainerFromDeployment(deployment).Image)
+		_, err = r.Resources.FetchMattermostUpdateJob(key.Namespace)
+		assert.True(t, k8sErrors.IsNotFound(err))
+	})
+
+	t.Run("promoted", func(t *testing.T) {
+		promoted := mattermost.DeepCopy()
+		promoted.Spec.Standby.Promoted = true
+		status := promoted.Status
+		recorder := record.NewFakeRecorder(10)
+		r.Recorder = recorder
+		r.checkPromotion(promoted, &status, logger)
+
+		condition := meta.FindStatusCondition(status.Conditions, mmv1beta.StandbyCondition)
+		require.NotNil(t, condition)
+		assert.Equal(t, metav1.ConditionFalse, condition.Status)
+		assert.Equal(t, "Promoted", condition.Reason)
+		require.Len(t, recorder.Events, 1)
+		assert.Contains(t, <-recorder.Events, "Promoted")
+
+		deployment := generateMattermostDeployment(promoted, dbConfig, fileStoreConfig)
+		assert.Equal(t, replicas, *deployment.Spec.Replicas)
+	})
+}
@@ -0,0 +1,146 @@
+package mattermost
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+
+	mmv1beta "github.com/mattermost/mattermost-operator/apis/mattermost/v1beta1"
+	"github.com/pkg/errors"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+)
+
+// applyTemplate merges the values of the MattermostTemplate referenced by
+// the Mattermost under its spec, in memory only.
+func (r *MattermostReconciler) applyTemplate(ctx context.Context, mattermost *mmv1beta.Mattermost) error {
+	if mattermost.Spec.TemplateRef == "" {
+		return nil
+	}
+
+	template := &mmv1beta.MattermostTemplate{}
+	err := r.Client.Get(ctx, types.NamespacedName{Name: mattermost.Spec.TemplateRef}, template)
+	if err != nil {
+		return errors.Wrapf(err, "failed to get MattermostTemplate %s", mattermost.Spec.TemplateRef)
+	}
+
+	// The values set by the installation are read from the stored object, the
+	// empty values being omitted from the spec once decoded.
+	stored := &unstructured.Unstructured{}
+	stored.SetGroupVersionKind(mmv1beta.GroupVersion.WithKind("Mattermost"))
+	err = r.NonCachedAPIReader.Get(ctx, types.NamespacedName{Namespace: mattermost.Namespace, Name: mattermost.Name}, stored)
+	if err != nil {
+		return errors.Wrap(err, "failed to get the stored Mattermost")
+	}
+	storedSpec, _, err := unstructured.NestedMap(stored.Object, "spec")
+	if err != nil {
+		return errors.Wrap(err, "failed to read the stored Mattermost spec")
+	}
+
+	spec, err := mergeTemplate(mattermost.Spec, storedSpec, template.Spec.Mattermost.Raw)
+	if err != nil {
+		return errors.Wrapf(err, "failed to apply MattermostTemplate %s", template.Name)
+	}
+	mattermost.Spec = spec
+	return nil
+}
+
+// mergeTemplate deep-merges the values of the template under the spec. The
+// objects are merged key by key, the other values of the spec take
+// precedence unless they are empty and not set in the stored spec, so that
+// the installation overrides the template with false or 0.
+func mergeTemplate(spec mmv1beta.MattermostSpec, stored map[string]interface{}, template []byte) (mmv1beta.MattermostSpec, error) {
+	if len(template) == 0 {
+		return spec, nil
+	}
+
+	var templateValues map[string]interface{}
+	err := json.Unmarshal(template, &templateValues)
+	if err != nil {
+		return spec, errors.Wrap(err, "failed to parse template")
+	}
+	// Templates do not reference other templates.
+	delete(templateValues, "templateRef")
+
+	data, err := json.Marshal(spec)
+	if err != nil {
+		return spec, err
+	}
+	var specValues map[string]interface{}
+	err = json.Unmarshal(data, &specValues)
+	if err != nil {
+		return spec, err
+	}
+
+	data, err = json.Marshal(mergeValues(specValues, templateValues, stored))
+	if err != nil {
+		return spec, err
+	}
+	merged := mmv1beta.MattermostSpec{}
+	decoder := json.NewDecoder(bytes.NewReader(data))
+	decoder.DisallowUnknownFields()
+	err = decoder.Decode(&merged)
+	if err != nil {
+		return spec, errors.Wrap(err, "invalid template")
+	}
+	return merged, nil
+}
+
+func mergeValues(value, template, stored interface{}) interface{} {
+	templateObject, templateIsObject := template.(map[string]interface{})
+	storedObject, storedIsObject := stored.(map[string]interface{})
+	if value == nil && templateIsObject && storedIsObject {
+		value = map[string]interface{}{}
+	}
+	valueObject, valueIsObject := value.(map[string]interface{})
+	if valueIsObject && templateIsObject {
+		for key, templateValue := range templateObject {
+			valueObject[key] = mergeValues(valueObject[key], templateValue, storedObject[key])
+		}
+		return valueObject
+	}
+	if stored != nil || !isEmptyValue(value) {
+		return value
+	}
+	return template
+}
+
+func isEmptyValue(value interface{}) bool {
+	switch v := value.(type) {
+	case nil:
+		return true
+	case string:
+		return v == ""
+	case bool:
+		return !v
+	case float64:
+		return v == 0
+	case map[string]interface{}:
+		return len(v) == 0
+	case []interface{}:
+		return len(v) == 0
+	}
+	return false
+}
+
+// mattermostsOfTemplate returns the requests of the Mattermosts referencing
+// the MattermostTemplate.
+func (r *MattermostReconciler) mattermostsOfTemplate(template client.Object) []reconcile.Request {
+	var mattermosts mmv1beta.MattermostList
+	err := r.Client.List(context.TODO(), &mattermosts)
+	if err != nil {
+		r.Log.Error(err, "Failed to list the Mattermosts of the MattermostTemplate", "template", template.GetName())
+		return nil
+	}
+
+	var requests []reconcile.Request
+	for i := range mattermosts.Items {
+		mattermost := &mattermosts.Items[i]
+		if mattermost.Spec.TemplateRef == template.GetName() && r.Shard.Owns(mattermost) {
+			requests = append(requests, reconcile.Request{NamespacedName: types.NamespacedName{Namespace: mattermost.Namespace, Name: mattermost.Name}})
+		}
+	}
+	return requests
+}
@@ -0,0 +1,180 @@
+package mattermost
+
+import (
+	"context"
+	"testing"
+
+	blubr "github.com/mattermost/blubr"
+	mmv1beta "github.com/mattermost/mattermost-operator/apis/mattermost/v1beta1"
+	"github.com/mattermost/mattermost-operator/pkg/resources"
+	resourcesfake "github.com/mattermost/mattermost-operator/pkg/resources/fake"
+	operatortest "github.com/mattermost/mattermost-operator/test"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/kubernetes/scheme"
+	"k8s.io/client-go/tools/record"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+)
+
+func TestApplyTemplate(t *testing.T) {
+	s := prepareSchema(t, scheme.Scheme)
+	s.AddKnownTypes(mmv1beta.GroupVersion, &mmv1beta.Mattermost{}, &mmv1beta.MattermostList{}, &mmv1beta.MattermostTemplate{}, &mmv1beta.MattermostTemplateList{})
+
+	template := &mmv1beta.MattermostTemplate{
+		ObjectMeta: metav1.ObjectMeta{Name: "production"},
+		Spec: mmv1beta.MattermostTemplateSpec{
+			Mattermost: runtime.RawExtension{Raw: []byte(`{
+				"version": "7.8.0",
+				"imagePullPolicy": "IfNotPresent",
+				"ingress": {"enabled": true, "annotations": {"cert-manager.io/cluster-issuer": "letsencrypt", "owner": "platform"}},
+				"resourceLabels": {"team": "platform"},
+				"scheduling": {"nodeSelector": {"pool": "mattermost"}},
+				"templateRef": "other"
+			}`)},
+		},
+	}
+	mattermost := &mmv1beta.Mattermost{
+		ObjectMeta: metav1.ObjectMeta{Name: "mm", Namespace: "mm-namespace"},
+		Spec: mmv1beta.MattermostSpec{
+			TemplateRef: "production",
+			Version:     "7.9.0",
+			Ingress:     &mmv1beta.Ingress{Host: "chat.example.com", Annotations: map[string]string{"owner": "chat"}},
+		},
+	}
+	client := fake.NewFakeClientWithScheme(s, template, mattermost)
+	// The stored Mattermost, as written by the user, is read without the
+	// Mattermost types to keep the values it omits omitted.
+	stored := &unstructured.Unstructured{Object: map[string]interface{}{
+		"apiVersion": mmv1beta.GroupVersion.String(),
+		"kind":       "Mattermost",
+		"metadata":   map[string]interface{}{"name": "mm", "namespace": "mm-namespace"},
+		"spec": map[string]interface{}{
+			"templateRef": "production",
+			"version":     "7.9.0",
+			"ingress":     map[string]interface{}{"host": "chat.example.com", "annotations": map[string]interface{}{"owner": "chat"}},
+		},
+	}}
+	storedClient := fake.NewClientBuilder().WithScheme(runtime.NewScheme()).WithRuntimeObjects(stored).Build()
+	r := &MattermostReconciler{Client: client, NonCachedAPIReader: storedClient, Scheme: s}
+
+	t.Run("merged under the spec", func(t *testing.T) {
+		merged := mattermost.DeepCopy()
+		require.NoError(t, r.applyTemplate(context.TODO(), merged))
+		assert.Equal(t, "production", merged.Spec.TemplateRef)
+		assert.Equal(t, "7.9.0", merged.Spec.Version)
+		assert.Equal(t, "IfNotPresent", string(merged.Spec.ImagePullPolicy))
+		assert.True(t, merged.Spec.Ingress.Enabled)
+		assert.Equal(t, "chat.example.com", merged.Spec.Ingress.Host)
+		assert.Equal(t, map[string]string{"cert-manager.io/cluster-issuer": "letsencrypt", "owner": "chat"}, merged.Spec.Ingress.Annotations)
+		assert.Equal(t, map[string]string{"team": "platform"}, merged.Spec.ResourceLabels)
+		assert.Equal(t, map[string]string{"pool": "mattermost"}, merged.Spec.Scheduling.NodeSelector)
+	})
+
+	t.Run("empty values set by the installation", func(t *testing.T) {
+		require.NoError(t, unstructured.SetNestedField(stored.Object, false, "spec", "ingress", "enabled"))
+		require.NoError(t, storedClient.Update(context.TODO(), stored))
+		defer func() {
+			unstructured.RemoveNestedField(stored.Object, "spec", "ingress", "enabled")
+			require.NoError(t, storedClient.Update(context.TODO(), stored))
+		}()
+
+		merged := mattermost.DeepCopy()
+		require.NoError(t, r.applyTemplate(context.TODO(), merged))
+		assert.False(t, merged.Spec.Ingress.Enabled)
+		assert.Equal(t, map[string]string{"cert-manager.io/cluster-issuer": "letsencrypt", "owner": "chat"}, merged.Spec.Ingress.Annotations)
+	})
+
+	t.Run("missing template", func(t *testing.T) {
+		missing := mattermost.DeepCopy()
+		missing.Spec.TemplateRef = "staging"
+		assert.Error(t, r.applyTemplate(context.TODO(), missing))
+	})
+
+	t.Run("invalid template", func(t *testing.T) {
+		_, err := mergeTemplate(mattermost.Spec, nil, []byte(`{"replica": 3}`))
+		assert.Error(t, err)
+	})
+
+	t.Run("Mattermosts of the template", func(t *testing.T) {
+		assert.Equal(t, []reconcile.Request{{NamespacedName: types.NamespacedName{Name: "mm", Namespace: "mm-namespace"}}}, r.mattermostsOfTemplate(template))
+		assert.Empty(t, r.mattermostsOfTemplate(&mmv1beta.MattermostTemplate{ObjectMeta: metav1.ObjectMeta{Name: "staging"}}))
+	})
+}
+
+func TestReconcileTemplate(t *testing.T) {
+	logger := blubr.InitLogger()
+	s := prepareSchema(t, scheme.Scheme)
+	s.AddKnownTypes(mmv1beta.GroupVersion, &mmv1beta.MattermostTemplate{}, &mmv1beta.MattermostTemplateList{})
+	key := types.NamespacedName{Namespace: "templates", Name: "chat"}
+
+	template := &mmv1beta.MattermostTemplate{
+		ObjectMeta: metav1.ObjectMeta{Name: "production"},
+		Spec: mmv1beta.MattermostTemplateSpec{
+			Mattermost: runtime.RawExtension{Raw: []byte(`{"replicas": 3}`)},
+		},
+	}
+	mattermost := &mmv1beta.Mattermost{
+		ObjectMeta: metav1.ObjectMeta{Name: key.Name, Namespace: key.Namespace, UID: "mm-uid", Generation: 1},
+		Spec: mmv1beta.MattermostSpec{
+			TemplateRef: "production",
+			Image:       "mattermost/mattermost-enterprise-edition",
+			Version:     operatortest.LatestStableMattermostVersion,
+			Ingress:     &mmv1beta.Ingress{Enabled: true, Host: "chat.example.com"},
+			Database:    mmv1beta.Database{External: &mmv1beta.ExternalDatabase{Secret: "db"}},
+			FileStore: mmv1beta.FileStore{
+				External: &mmv1beta.ExternalFileStore{URL: "s3.example.com", Bucket: "chat", Secret: "s3"},
+			},
+		},
+	}
+	secrets := []client.Object{
+		&corev1.Secret{
+			ObjectMeta: metav1.ObjectMeta{Name: "db", Namespace: key.Namespace},
+			Data:       map[string][]byte{"DB_CONNECTION_STRING": []byte("postgres://mmuser:secret@db:5432/mattermost")},
+		},
+		&corev1.Secret{
+			ObjectMeta: metav1.ObjectMeta{Name: "s3", Namespace: key.Namespace},
+			Data:       map[string][]byte{"accesskey": []byte("key"), "secretkey": []byte("secret")},
+		},
+	}
+
+	c := fake.NewClientBuilder().WithScheme(s).WithObjects(append(secrets, template, mattermost)...).Build()
+	r := &MattermostReconciler{
+		Client:             c,
+		NonCachedAPIReader: c,
+		Scheme:             s,
+		Log:                logger,
+		MaxReconciling:     5,
+		Resources:          resources.NewResourceHelper(resourcesfake.NewApplyClient(c), s),
+		Recorder:           record.NewFakeRecorder(100),
+	}
+
+	deploymentReplicas := func(t *testing.T) int32 {
+		_, err := r.Reconcile(context.TODO(), reconcile.Request{NamespacedName: key})
+		require.NoError(t, err)
+		deployment := &appsv1.Deployment{}
+		require.NoError(t, c.Get(context.TODO(), key, deployment))
+		return *deployment.Spec.Replicas
+	}
+
+	assert.Equal(t, int32(3), deploymentReplicas(t))
+
+	// The template is merged in memory, neither it nor the defaults are
+	// stored in the spec.
+	stored := &mmv1beta.Mattermost{}
+	require.NoError(t, c.Get(context.TODO(), key, stored))
+	assert.Equal(t, mattermost.Spec, stored.Spec)
+
+	// The changes of the template reach the installation.
+	require.NoError(t, c.Get(context.TODO(), types.NamespacedName{Name: template.Name}, template))
+	template.Spec.Mattermost.Raw = []byte(`{"replicas": 4}`)
+	require.NoError(t, c.Update(context.TODO(), template))
+	assert.Equal(t, int32(4), deploymentReplicas(t))
+}
@@ -0,0 +1,112 @@
+package mattermost
+
+import (
+	"context"
+
+	"github.com/go-logr/logr"
+	mmv1beta "github.com/mattermost/mattermost-operator/apis/mattermost/v1beta1"
+	"github.com/mattermost/mattermost-operator/pkg/mattermost/releases"
+	"github.com/pkg/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+// checkUpdateChannel upgrades the Mattermost version to the newest version
+// of the release channel supported by the support matrix. With manual
+// approval the version is only upgraded once approved. The upgrade is then
+// rolled out as if the version was changed by the user.
+func (r *MattermostReconciler) checkUpdateChannel(ctx context.Context, mattermost *mmv1beta.Mattermost, reqLogger logr.Logger) (*mmv1beta.ChannelUpdateStatus, error) {
+	previous := mattermost.Status.ChannelUpdate
+	if !mattermost.UpdateChannelEnabled() {
+		return previous, nil
+	}
+	reqLogger = reqLogger.WithValues("phase", "updateChannel")
+
+	if r.ReleasesFeed == nil {
+		reqLogger.Info("Releases feed not configured for the operator, skipping automatic upgrades")
+		return previous, nil
+	}
+
+	available, err := r.ReleasesFeed.Releases(ctx)
+	if err != nil {
+		// The releases feed is external, failing to fetch it should not
+		// block the reconciliation.
+		reqLogger.Error(err, "Unable to get Mattermost releases")
+		return previous, nil
+	}
+
+	matrix, err := r.supportMatrix()
+	if err != nil {
+		return previous, err
+	}
+
+	policy := mattermost.Spec.UpdatePolicy
+	versions := releases.ChannelVersions(available, policy.Channel, mattermost.Spec.Version, matrix)
+	if len(versions) == 0 {
+		return previous, nil
+	}
+
+	version := versions[0]
+	if policy.Approval == mmv1beta.UpdateApprovalManual {
+		if !containsString(versions, policy.ApprovedVersion) {
+			if previous == nil || previous.Version != version || previous.State != mmv1beta.ChannelUpdateAwaitingApproval {
+				reqLogger.Info("Mattermost upgrade awaiting approval", "channel", policy.Channel, "version", version)
+			}
+			return newChannelUpdateStatus(previous, policy.Channel, version, mmv1beta.ChannelUpdateAwaitingApproval), nil
+		}
+		version = policy.ApprovedVersion
+	}
+
+	reqLogger.Info("Upgrading Mattermost in the release channel", "channel", policy.Channel, "from", mattermost.Spec.Version, "to", version)
+	err = r.patchVersion(ctx, mattermost, version)
+	if err != nil {
+		return previous, errors.Wrap(err, "failed to upgrade Mattermost version")
+	}
+
+	return newChannelUpdateStatus(previous, policy.Channel, version, mmv1beta.ChannelUpdateApplied), nil
+}
+
+// patchVersion sets the version of the Mattermost. Only the version of the
+// stored Mattermost is patched, as the in-memory Mattermost holds defaults
+// and template settings which are not stored.
+func (r *MattermostReconciler) patchVersion(ctx context.Context, mattermost *mmv1beta.Mattermost, version string) error {
+	stored := &mmv1beta.Mattermost{}
+	err := r.Client.Get(ctx, client.ObjectKeyFromObject(mattermost), stored)
+	if err != nil {
+		return err
+	}
+	patch := client.MergeFrom(stored.DeepCopy())
+	stored.Spec.Version = version
+	err = r.Client.Patch(ctx, stored, patch)
+	if err != nil {
+		return err
+	}
+
+	mattermost.Spec.Version = version
+	mattermost.ResourceVersion = stored.ResourceVersion
+	return nil
+}
+
+// newChannelUpdateStatus returns the status of the upgrade, keeping the
+// transition time if the upgrade did not change.
+func newChannelUpdateStatus(previous *mmv1beta.ChannelUpdateStatus, channel mmv1beta.UpdateChannel, version string, state mmv1beta.ChannelUpdateState) *mmv1beta.ChannelUpdateStatus {
+	if previous != nil && previous.Channel == channel && previous.Version == version && previous.State == state {
+		return previous
+	}
+	now := metav1.Now()
+	return &mmv1beta.ChannelUpdateStatus{
+		Channel:            channel,
+		Version:            version,
+		State:              state,
+		LastTransitionTime: &now,
+	}
+}
+
+func containsString(values []string, value string) bool {
+	for _, v := range values {
+		if v == value {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,107 @@
+package mattermost
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	blubr "github.com/mattermost/blubr"
+	mmv1beta "github.com/mattermost/mattermost-operator/apis/mattermost/v1beta1"
+	"github.com/mattermost/mattermost-operator/pkg/mattermost/releases"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/kubernetes/scheme"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+)
+
+func TestCheckUpdateChannel(t *testing.T) {
+	logger := blubr.InitLogger()
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write([]byte(`{"releases": [{"version": "5.37.0"}, {"version": "5.37.1"}, {"version": "5.38.0"}]}`))
+	}))
+	defer server.Close()
+
+	s := prepareSchema(t, scheme.Scheme)
+	s.AddKnownTypes(mmv1beta.GroupVersion, &mmv1beta.Mattermost{})
+
+	newReconciler := func(mattermost *mmv1beta.Mattermost) *MattermostReconciler {
+		c := fake.NewFakeClientWithScheme(s, mattermost)
+		return &MattermostReconciler{
+			Client:             c,
+			NonCachedAPIReader: c,
+			Scheme:             s,
+			ReleasesFeed:       releases.NewFeed(server.URL, time.Hour),
+		}
+	}
+
+	newMattermost := func(approval mmv1beta.UpdateApproval) *mmv1beta.Mattermost {
+		return &mmv1beta.Mattermost{
+			ObjectMeta: metav1.ObjectMeta{Name: "mm", Namespace: "mm-namespace"},
+			Spec: mmv1beta.MattermostSpec{
+				Image:   "mattermost/mattermost-enterprise-edition",
+				Version: "5.37.0",
+				UpdatePolicy: &mmv1beta.UpdatePolicy{
+					Channel:  mmv1beta.UpdateChannelPatchOnly,
+					Approval: approval,
+				},
+			},
+		}
+	}
+
+	t.Run("automatic upgrade", func(t *testing.T) {
+		mattermost := newMattermost(mmv1beta.UpdateApprovalAutomatic)
+		r := newReconciler(mattermost)
+
+		update, err := r.checkUpdateChannel(context.TODO(), mattermost, logger)
+		require.NoError(t, err)
+		assert.Equal(t, mmv1beta.ChannelUpdateApplied, update.State)
+		assert.Equal(t, "5.37.1", update.Version)
+
+		stored := &mmv1beta.Mattermost{}
+		require.NoError(t, r.Client.Get(context.TODO(), types.NamespacedName{Name: "mm", Namespace: "mm-namespace"}, stored))
+		assert.Equal(t, "5.37.1", stored.Spec.Version)
+	})
+
+	t.Run("only the version is stored", func(t *testing.T) {
+		mattermost := newMattermost(mmv1beta.UpdateApprovalAutomatic)
+		r := newReconciler(mattermost.DeepCopy())
+		// The defaults applied in memory are not stored with the version.
+		mattermost.Spec.ImagePullPolicy = mmv1beta.DefaultPullPolicy
+
+		_, err := r.checkUpdateChannel(context.TODO(), mattermost, logger)
+		require.NoError(t, err)
+		assert.Equal(t, "5.37.1", mattermost.Spec.Version)
+
+		stored := &mmv1beta.Mattermost{}
+		require.NoError(t, r.Client.Get(context.TODO(), types.NamespacedName{Name: "mm", Namespace: "mm-namespace"}, stored))
+		assert.Equal(t, "5.37.1", stored.Spec.Version)
+		assert.Empty(t, stored.Spec.ImagePullPolicy)
+	})
+
+	t.Run("manual upgrade awaiting approval", func(t *testing.T) {
+		mattermost := newMattermost(mmv1beta.UpdateApprovalManual)
+		r := newReconciler(mattermost)
+
+		update, err := r.checkUpdateChannel(context.TODO(), mattermost, logger)
+		require.NoError(t, err)
+		assert.Equal(t, mmv1beta.ChannelUpdateAwaitingApproval, update.State)
+		assert.Equal(t, "5.37.1", update.Version)
+		assert.Equal(t, "5.37.0", mattermost.Spec.Version)
+	})
+
+	t.Run("manual upgrade approved", func(t *testing.T) {
+		mattermost := newMattermost(mmv1beta.UpdateApprovalManual)
+		mattermost.Spec.UpdatePolicy.ApprovedVersion = "5.37.1"
+		r := newReconciler(mattermost)
+
+		update, err := r.checkUpdateChannel(context.TODO(), mattermost, logger)
+		require.NoError(t, err)
+		assert.Equal(t, mmv1beta.ChannelUpdateApplied, update.State)
+		assert.Equal(t, "5.37.1", mattermost.Spec.Version)
+	})
+}
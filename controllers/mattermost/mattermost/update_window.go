@@ -0,0 +1,127 @@
+package mattermost
+
+import (
+	"context"
+	"time"
+
+	"github.com/go-logr/logr"
+	mmv1beta "github.com/mattermost/mattermost-operator/apis/mattermost/v1beta1"
+	mattermostApp "github.com/mattermost/mattermost-operator/pkg/mattermost"
+	"github.com/pkg/errors"
+	appsv1 "k8s.io/api/apps/v1"
+	k8sErrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+)
+
+// checkUpdateWindow returns the change of the Mattermost deployment queued
+// until the next maintenance window, or until an upgrade slot is free, or
+// nil if there is none.
+func (r *MattermostReconciler) checkUpdateWindow(
+	mattermost *mmv1beta.Mattermost,
+	dbConfig mattermostApp.DatabaseConfig,
+	fileStoreInfo *mattermostApp.FileStoreInfo,
+	reqLogger logr.Logger) (*mmv1beta.PendingUpdateStatus, error) {
+	if !mattermost.MaintenanceWindowEnabled() && r.MaxConcurrentUpgrades <= 0 {
+		return nil, nil
+	}
+
+	desired := generateMattermostDeployment(mattermost, dbConfig, fileStoreInfo)
+	err := mattermostApp.SetPodTemplateHash(desired)
+	if err != nil {
+		return nil, err
+	}
+
+	current := &appsv1.Deployment{}
+	err = r.Client.Get(context.TODO(), types.NamespacedName{Name: desired.Name, Namespace: desired.Namespace}, current)
+	if err != nil {
+		if k8sErrors.IsNotFound(err) {
+			// A new installation is created right away.
+			return nil, nil
+		}
+		return nil, errors.Wrap(err, "failed to get Mattermost deployment")
+	}
+
+	reason := mmv1beta.PendingUpdateMaintenanceWindow
+	queued, nextWindow, err := updateQueued(mattermost, current, desired, time.Now())
+	if err != nil {
+		return nil, err
+	}
+	if !queued {
+		queued, err = r.waitingForUpgradeSlot(mattermost, current, desired)
+		if err != nil || !queued {
+			return nil, err
+		}
+		reason = mmv1beta.PendingUpdateWaitingForUpgradeSlot
+	}
+
+	pending := &mmv1beta.PendingUpdateStatus{
+		Reason:       reason,
+		Image:        mattermostContainerImage(desired),
+		RunningImage: mattermostContainerImage(current),
+	}
+	if previous := mattermost.Status.PendingUpdate; previous != nil && previous.Since != nil {
+		pending.Since = previous.Since
+	} else {
+		since := metav1.Now()
+		pending.Since = &since
+	}
+	if !nextWindow.IsZero() {
+		next := metav1.NewTime(nextWindow)
+		pending.NextWindow = &next
+	}
+
+	return pending, nil
+}
+
+// updateQueued returns true if the desired deployment changes the pod
+// template of the current one outside of the maintenance window, together
+// with the time when the next window opens. Rollbacks of failed upgrades
+// are never queued.
+func updateQueued(mattermost *mmv1beta.Mattermost, current, desired *appsv1.Deployment, now time.Time) (bool, time.Time, error) {
+	if !mattermost.MaintenanceWindowEnabled() || desired.Name != mattermost.Name || upgradeRolledBack(mattermost) {
+		return false, time.Time{}, nil
+	}
+	if !podTemplateChanged(current, desired) {
+		return false, time.Time{}, nil
+	}
+
+	inWindow, nextWindow, err := mattermost.Spec.UpdatePolicy.Window.Next(now)
+	if err != nil {
+		return false, time.Time{}, err
+	}
+
+	return !inWindow, nextWindow, nil
+}
+
+// podTemplateChanged compares the pod template hashes of the deployments.
+// Deployments created before the maintenance window was set are not
+// annotated, only a change of their image is detected.
+func podTemplateChanged(current, desired *appsv1.Deployment) bool {
+	hash := current.Annotations[mattermostApp.PodTemplateHashAnnotation]
+	if hash == "" {
+		return mattermostContainerImage(current) != mattermostContainerImage(desired)
+	}
+	return hash != desired.Annotations[mattermostApp.PodTemplateHashAnnotation]
+}
+
+// holdPodTemplate keeps the current pod template in the desired deployment,
+// so that only the changes which do not restart the pods are applied.
+func holdPodTemplate(current, desired *appsv1.Deployment) {
+	desired.Spec.Template = *current.Spec.Template.DeepCopy()
+
+	hash := current.Annotations[mattermostApp.PodTemplateHashAnnotation]
+	if hash == "" {
+		delete(desired.Annotations, mattermostApp.PodTemplateHashAnnotation)
+		return
+	}
+	desired.Annotations[mattermostApp.PodTemplateHashAnnotation] = hash
+}
+
+func mattermostContainerImage(deployment *appsv1.Deployment) string {
+	container := mmv1beta.GetMattermostAppContainerFromDeployment(deployment)
+	if container == nil {
+		return ""
+	}
+	return container.Image
+}
@@ -0,0 +1,85 @@
+package mattermost
+
+import (
+	"testing"
+	"time"
+
+	mmv1beta "github.com/mattermost/mattermost-operator/apis/mattermost/v1beta1"
+	mattermostApp "github.com/mattermost/mattermost-operator/pkg/mattermost"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func TestUpdateQueued(t *testing.T) {
+	mattermost := &mmv1beta.Mattermost{
+		ObjectMeta: metav1.ObjectMeta{Name: "mm", Namespace: "mm-namespace"},
+		Spec: mmv1beta.MattermostSpec{
+			UpdatePolicy: &mmv1beta.UpdatePolicy{
+				// Saturdays and Sundays from 2:00 to 4:59.
+				Window: &mmv1beta.MaintenanceWindow{Schedule: "* 2-4 * * 6,0", TimeZone: "UTC"},
+			},
+		},
+	}
+
+	newDeployment := func(image string, replicas int32) *appsv1.Deployment {
+		deployment := &appsv1.Deployment{
+			ObjectMeta: metav1.ObjectMeta{Name: "mm", Namespace: "mm-namespace"},
+			Spec: appsv1.DeploymentSpec{
+				Replicas: &replicas,
+				Template: corev1.PodTemplateSpec{
+					Spec: corev1.PodSpec{
+						Containers: []corev1.Container{{Name: mmv1beta.MattermostAppContainerName, Image: image}},
+					},
+				},
+			},
+		}
+		require.NoError(t, mattermostApp.SetPodTemplateHash(deployment))
+		return deployment
+	}
+
+	friday := time.Date(2021, time.June, 4, 12, 0, 0, 0, time.UTC)
+	saturday := time.Date(2021, time.June, 5, 3, 0, 0, 0, time.UTC)
+
+	t.Run("pod template change queued outside window", func(t *testing.T) {
+		current := newDeployment("mattermost:5.36.0", 1)
+		desired := newDeployment("mattermost:5.37.1", 2)
+
+		queued, next, err := updateQueued(mattermost, current, desired, friday)
+		require.NoError(t, err)
+		assert.True(t, queued)
+		assert.Equal(t, time.Date(2021, time.June, 5, 2, 0, 0, 0, time.UTC), next)
+
+		holdPodTemplate(current, desired)
+		assert.Equal(t, current.Spec.Template, desired.Spec.Template)
+		assert.Equal(t, current.Annotations, desired.Annotations)
+		assert.Equal(t, int32(2), *desired.Spec.Replicas)
+	})
+
+	t.Run("pod template change applied within window", func(t *testing.T) {
+		queued, _, err := updateQueued(mattermost, newDeployment("mattermost:5.36.0", 1), newDeployment("mattermost:5.37.1", 1), saturday)
+		require.NoError(t, err)
+		assert.False(t, queued)
+	})
+
+	t.Run("deployment change applied outside window", func(t *testing.T) {
+		queued, _, err := updateQueued(mattermost, newDeployment("mattermost:5.36.0", 1), newDeployment("mattermost:5.36.0", 3), friday)
+		require.NoError(t, err)
+		assert.False(t, queued)
+	})
+
+	t.Run("image change of deployment without hash queued", func(t *testing.T) {
+		current := newDeployment("mattermost:5.36.0", 1)
+		current.Annotations = nil
+
+		queued, _, err := updateQueued(mattermost, current, newDeployment("mattermost:5.37.1", 1), friday)
+		require.NoError(t, err)
+		assert.True(t, queued)
+
+		queued, _, err = updateQueued(mattermost, current, newDeployment("mattermost:5.36.0", 1), friday)
+		require.NoError(t, err)
+		assert.False(t, queued)
+	})
+}
@@ -0,0 +1,203 @@
+package mattermost
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/go-logr/logr"
+	mmv1beta "github.com/mattermost/mattermost-operator/apis/mattermost/v1beta1"
+	"github.com/pkg/errors"
+	appsv1 "k8s.io/api/apps/v1"
+	k8sErrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+)
+
+// checkUpgrade tracks the upgrade of the Mattermost deployment to a new image
+// when automatic upgrade rollback is enabled, or when the concurrent upgrades
+// are limited. It returns the status of the upgrade to report.
+func (r *MattermostReconciler) checkUpgrade(mattermost *mmv1beta.Mattermost, reqLogger logr.Logger) (*mmv1beta.UpgradeStatus, error) {
+	if !mattermost.UpgradeRollbackEnabled() && r.MaxConcurrentUpgrades <= 0 {
+		return mattermost.Status.Upgrade, nil
+	}
+	reqLogger = reqLogger.WithValues("phase", "upgradeRollback")
+
+	desiredImage := mattermost.GetImageName()
+	if mattermost.Status.Upgrade != nil && mattermost.Status.Upgrade.ToImage == desiredImage {
+		// The upgrade to the desired image is already tracked.
+		return mattermost.Status.Upgrade, nil
+	}
+
+	current := &appsv1.Deployment{}
+	err := r.Client.Get(context.TODO(), types.NamespacedName{Name: mattermost.Name, Namespace: mattermost.Namespace}, current)
+	if err != nil {
+		if k8sErrors.IsNotFound(err) {
+			// Nothing to upgrade on a new installation.
+			return mattermost.Status.Upgrade, nil
+		}
+		return nil, errors.Wrap(err, "failed to get Mattermost deployment")
+	}
+
+	container := mmv1beta.GetMattermostAppContainerFromDeployment(current)
+	if container == nil || container.Image == desiredImage {
+		return mattermost.Status.Upgrade, nil
+	}
+
+	reqLogger.Info("Tracking Mattermost upgrade", "from", container.Image, "to", desiredImage)
+	startTime := metav1.Now()
+
+	return &mmv1beta.UpgradeStatus{
+		State:     mmv1beta.UpgradeInProgress,
+		FromImage: container.Image,
+		ToImage:   desiredImage,
+		StartTime: &startTime,
+	}, nil
+}
+
+// checkUpgradeHealth updates the status of the upgrade with the result of the
+// health check. The upgrade is completed once the pods running the new image
+// pass the health check. With automatic upgrade rollback enabled, it is
+// rolled back if they did not pass it within the progress deadline, or right
+// away if they failed the post-upgrade checks.
+func checkUpgradeHealth(mattermost *mmv1beta.Mattermost, status *mmv1beta.MattermostStatus, healthErr error, reqLogger logr.Logger) {
+	if status.Upgrade == nil {
+		return
+	}
+	rollbackEnabled := mattermost.UpgradeRollbackEnabled()
+	now := metav1.Now()
+
+	if healthErr == nil {
+		if status.Upgrade.State == mmv1beta.UpgradeInProgress {
+			status.Upgrade = status.Upgrade.DeepCopy()
+			status.Upgrade.State = mmv1beta.UpgradeCompleted
+			status.Upgrade.CompletionTime = &now
+			if !rollbackEnabled {
+				return
+			}
+			setStatusCondition(status, metav1.Condition{
+				Type:               mmv1beta.UpgradeFailedCondition,
+				Status:             metav1.ConditionFalse,
+				Reason:             "UpgradeCompleted",
+				Message:            fmt.Sprintf("Upgrade to %s completed", status.Upgrade.ToImage),
+				ObservedGeneration: mattermost.Generation,
+			})
+		} else if rollbackEnabled && !upgradeRolledBack(mattermost) && meta.IsStatusConditionTrue(status.Conditions, mmv1beta.UpgradeFailedCondition) {
+			setStatusCondition(status, metav1.Condition{
+				Type:               mmv1beta.UpgradeFailedCondition,
+				Status:             metav1.ConditionFalse,
+				Reason:             "DesiredImageRunning",
+				Message:            fmt.Sprintf("Mattermost runs %s", mattermost.GetImageName()),
+				ObservedGeneration: mattermost.Generation,
+			})
+		}
+		return
+	}
+
+	if !rollbackEnabled || status.Upgrade.State != mmv1beta.UpgradeInProgress || status.Upgrade.StartTime == nil {
+		return
+	}
+
+	if postUpgradeChecksFailed(status) {
+		reqLogger.Info("Mattermost upgrade did not pass the post-upgrade checks, rolling back",
+			"from", status.Upgrade.FromImage,
+			"to", status.Upgrade.ToImage,
+		)
+		rollBackUpgrade(mattermost, status, "PostUpgradeChecksFailed", fmt.Sprintf("Post-upgrade checks of %s failed, rolled back to %s: %s",
+			status.Upgrade.ToImage, status.Upgrade.FromImage, healthErr.Error()))
+		return
+	}
+
+	deadline := mattermost.GetUpgradeProgressDeadline()
+	if now.Sub(status.Upgrade.StartTime.Time) < deadline {
+		return
+	}
+
+	reqLogger.Info("Mattermost upgrade did not pass the health checks within the progress deadline, rolling back",
+		"from", status.Upgrade.FromImage,
+		"to", status.Upgrade.ToImage,
+	)
+	rollBackUpgrade(mattermost, status, "ProgressDeadlineExceeded", fmt.Sprintf("Pods running %s did not pass the health checks within %s, rolled back to %s: %s",
+		status.Upgrade.ToImage, deadline, status.Upgrade.FromImage, healthErr.Error()))
+}
+
+// rollBackUpgrade marks the upgrade as rolled back, so that the previous
+// image is deployed again, and reports the reason in the UpgradeFailed
+// condition.
+func rollBackUpgrade(mattermost *mmv1beta.Mattermost, status *mmv1beta.MattermostStatus, reason, message string) {
+	now := metav1.Now()
+	status.Upgrade = status.Upgrade.DeepCopy()
+	status.Upgrade.State = mmv1beta.UpgradeRolledBack
+	status.Upgrade.CompletionTime = &now
+	setStatusCondition(status, metav1.Condition{
+		Type:               mmv1beta.UpgradeFailedCondition,
+		Status:             metav1.ConditionTrue,
+		Reason:             reason,
+		Message:            message,
+		ObservedGeneration: mattermost.Generation,
+	})
+}
+
+// postUpgradeChecksFailed returns true if the post-upgrade checks of the
+// image Mattermost is upgraded to failed.
+func postUpgradeChecksFailed(status *mmv1beta.MattermostStatus) bool {
+	checks := status.PostUpgradeChecks
+	return checks != nil &&
+		checks.State == mmv1beta.PostUpgradeChecksFailed &&
+		joinImageName(checks.Image, checks.Version) == status.Upgrade.ToImage
+}
+
+// setStatusCondition sets the condition on a copy of the conditions, so that
+// the status of the Mattermost is not modified before it is compared with
+// the new one.
+func setStatusCondition(status *mmv1beta.MattermostStatus, condition metav1.Condition) {
+	conditions := make([]metav1.Condition, len(status.Conditions))
+	copy(conditions, status.Conditions)
+	meta.SetStatusCondition(&conditions, condition)
+	status.Conditions = conditions
+}
+
+// upgradeRolledBack returns true if the upgrade of the Mattermost deployment
+// to the desired image was rolled back.
+func upgradeRolledBack(mattermost *mmv1beta.Mattermost) bool {
+	upgrade := mattermost.Status.Upgrade
+	return mattermost.UpgradeRollbackEnabled() &&
+		upgrade != nil &&
+		upgrade.State == mmv1beta.UpgradeRolledBack &&
+		upgrade.ToImage == mattermost.GetImageName()
+}
+
+// mattermostImage returns the image name, image and version run by the
+// Mattermost deployment. These are the ones of the previous image if the
+// upgrade to the desired image was rolled back.
+func mattermostImage(mattermost *mmv1beta.Mattermost) (string, string, string) {
+	if !upgradeRolledBack(mattermost) {
+		return mattermost.GetImageName(), mattermost.Spec.Image, mattermost.Spec.Version
+	}
+
+	return splitImageName(mattermost.Status.Upgrade.FromImage)
+}
+
+// splitImageName returns the image name together with its image and its tag
+// or digest.
+func splitImageName(imageName string) (string, string, string) {
+	if i := strings.LastIndex(imageName, "@"); i >= 0 {
+		return imageName, imageName[:i], imageName[i+1:]
+	}
+	if i := strings.LastIndex(imageName, ":"); i > strings.LastIndex(imageName, "/") {
+		return imageName, imageName[:i], imageName[i+1:]
+	}
+	return imageName, imageName, ""
+}
+
+// joinImageName returns the image name of the image and its tag or digest.
+func joinImageName(image, version string) string {
+	if version == "" {
+		return image
+	}
+	if strings.Contains(version, "sha256:") {
+		return fmt.Sprintf("%s@%s", image, version)
+	}
+	return fmt.Sprintf("%s:%s", image, version)
+}
@@ -0,0 +1,127 @@
+package mattermost
+
+import (
+	"testing"
+	"time"
+
+	blubr "github.com/mattermost/blubr"
+	mmv1beta "github.com/mattermost/mattermost-operator/apis/mattermost/v1beta1"
+	"github.com/pkg/errors"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func TestCheckUpgradeHealth(t *testing.T) {
+	logger := blubr.InitLogger()
+
+	newMattermost := func(startedAgo time.Duration) *mmv1beta.Mattermost {
+		startTime := metav1.NewTime(time.Now().Add(-startedAgo))
+		return &mmv1beta.Mattermost{
+			ObjectMeta: metav1.ObjectMeta{Name: "mm", Namespace: "mm-namespace"},
+			Spec: mmv1beta.MattermostSpec{
+				Image:   "mattermost/mattermost-enterprise-edition",
+				Version: "5.37.1",
+				UpgradeRollback: &mmv1beta.UpgradeRollback{
+					Enabled:          true,
+					ProgressDeadline: &metav1.Duration{Duration: 5 * time.Minute},
+				},
+			},
+			Status: mmv1beta.MattermostStatus{
+				Upgrade: &mmv1beta.UpgradeStatus{
+					State:     mmv1beta.UpgradeInProgress,
+					FromImage: "mattermost/mattermost-enterprise-edition:5.36.0",
+					ToImage:   "mattermost/mattermost-enterprise-edition:5.37.1",
+					StartTime: &startTime,
+				},
+			},
+		}
+	}
+
+	t.Run("healthy upgrade completes", func(t *testing.T) {
+		mattermost := newMattermost(time.Minute)
+		status := mattermost.Status
+
+		checkUpgradeHealth(mattermost, &status, nil, logger)
+		assert.Equal(t, mmv1beta.UpgradeCompleted, status.Upgrade.State)
+		assert.True(t, meta.IsStatusConditionFalse(status.Conditions, mmv1beta.UpgradeFailedCondition))
+		assert.Equal(t, mmv1beta.UpgradeInProgress, mattermost.Status.Upgrade.State)
+	})
+
+	t.Run("unhealthy upgrade within deadline", func(t *testing.T) {
+		mattermost := newMattermost(time.Minute)
+		status := mattermost.Status
+
+		checkUpgradeHealth(mattermost, &status, errors.New("pods not ready"), logger)
+		assert.Equal(t, mmv1beta.UpgradeInProgress, status.Upgrade.State)
+		assert.Empty(t, status.Conditions)
+	})
+
+	t.Run("unhealthy upgrade past deadline is rolled back", func(t *testing.T) {
+		mattermost := newMattermost(10 * time.Minute)
+		status := mattermost.Status
+
+		checkUpgradeHealth(mattermost, &status, errors.New("pods not ready"), logger)
+		assert.Equal(t, mmv1beta.UpgradeRolledBack, status.Upgrade.State)
+		condition := meta.FindStatusCondition(status.Conditions, mmv1beta.UpgradeFailedCondition)
+		require.NotNil(t, condition)
+		assert.Equal(t, metav1.ConditionTrue, condition.Status)
+		assert.Contains(t, condition.Message, "pods not ready")
+
+		mattermost.Status = status
+		assert.True(t, upgradeRolledBack(mattermost))
+		imageName, image, version := mattermostImage(mattermost)
+		assert.Equal(t, "mattermost/mattermost-enterprise-edition:5.36.0", imageName)
+		assert.Equal(t, "mattermost/mattermost-enterprise-edition", image)
+		assert.Equal(t, "5.36.0", version)
+
+		// A new version ends the rollback.
+		mattermost.Spec.Version = "5.38.0"
+		assert.False(t, upgradeRolledBack(mattermost))
+	})
+
+	t.Run("failed post-upgrade checks are rolled back within deadline", func(t *testing.T) {
+		mattermost := newMattermost(time.Minute)
+		status := mattermost.Status
+		status.PostUpgradeChecks = &mmv1beta.PostUpgradeChecksStatus{
+			Image:   "mattermost/mattermost-enterprise-edition",
+			Version: "5.37.1",
+			State:   mmv1beta.PostUpgradeChecksFailed,
+			Message: "login of probe account failed",
+		}
+
+		checkUpgradeHealth(mattermost, &status, errors.New("post-upgrade checks failed"), logger)
+		assert.Equal(t, mmv1beta.UpgradeRolledBack, status.Upgrade.State)
+		condition := meta.FindStatusCondition(status.Conditions, mmv1beta.UpgradeFailedCondition)
+		require.NotNil(t, condition)
+		assert.Equal(t, "PostUpgradeChecksFailed", condition.Reason)
+	})
+
+	t.Run("running post-upgrade checks within deadline", func(t *testing.T) {
+		mattermost := newMattermost(time.Minute)
+		status := mattermost.Status
+		status.PostUpgradeChecks = &mmv1beta.PostUpgradeChecksStatus{
+			Image:   "mattermost/mattermost-enterprise-edition",
+			Version: "5.37.1",
+			State:   mmv1beta.PostUpgradeChecksRunning,
+		}
+
+		checkUpgradeHealth(mattermost, &status, errors.New("waiting for post-upgrade checks"), logger)
+		assert.Equal(t, mmv1beta.UpgradeInProgress, status.Upgrade.State)
+	})
+
+	t.Run("upgrade tracked without rollback", func(t *testing.T) {
+		mattermost := newMattermost(time.Hour)
+		mattermost.Spec.UpgradeRollback = nil
+		status := mattermost.Status
+
+		checkUpgradeHealth(mattermost, &status, errors.New("pods not ready"), logger)
+		assert.Equal(t, mmv1beta.UpgradeInProgress, status.Upgrade.State)
+		assert.Empty(t, status.Conditions)
+
+		checkUpgradeHealth(mattermost, &status, nil, logger)
+		assert.Equal(t, mmv1beta.UpgradeCompleted, status.Upgrade.State)
+		assert.Empty(t, status.Conditions)
+	})
+}
@@ -0,0 +1,56 @@
+package mattermost
+
+import (
+	"context"
+
+	mmv1beta "github.com/mattermost/mattermost-operator/apis/mattermost/v1beta1"
+	"github.com/pkg/errors"
+	appsv1 "k8s.io/api/apps/v1"
+)
+
+// waitingForUpgradeSlot returns true if the desired deployment upgrades the
+// image of the Mattermost while MaxConcurrentUpgrades other installations
+// are being upgraded. Rollbacks of failed upgrades never wait.
+func (r *MattermostReconciler) waitingForUpgradeSlot(mattermost *mmv1beta.Mattermost, current, desired *appsv1.Deployment) (bool, error) {
+	if r.MaxConcurrentUpgrades <= 0 || desired.Name != mattermost.Name || upgradeRolledBack(mattermost) {
+		return false, nil
+	}
+	if mattermostContainerImage(current) == mattermostContainerImage(desired) {
+		return false, nil
+	}
+	if holdsUpgradeSlot(mattermost) {
+		return false, nil
+	}
+
+	var mattermosts mmv1beta.MattermostList
+	err := r.Client.List(context.TODO(), &mattermosts)
+	if err != nil {
+		return false, errors.Wrap(err, "failed to list Mattermosts")
+	}
+
+	return countUpgrading(mattermosts.Items, mattermost) >= r.MaxConcurrentUpgrades, nil
+}
+
+// holdsUpgradeSlot returns true if the upgrade of the Mattermost to its
+// desired image is in progress.
+func holdsUpgradeSlot(mattermost *mmv1beta.Mattermost) bool {
+	upgrade := mattermost.Status.Upgrade
+	return upgrade != nil &&
+		upgrade.State == mmv1beta.UpgradeInProgress &&
+		upgrade.ToImage == mattermost.GetImageName()
+}
+
+// countUpgrading returns the number of installations, other than the
+// Mattermost, whose upgrade is in progress.
+func countUpgrading(mattermosts []mmv1beta.Mattermost, mattermost *mmv1beta.Mattermost) int {
+	upgrading := 0
+	for _, other := range mattermosts {
+		if other.Namespace == mattermost.Namespace && other.Name == mattermost.Name {
+			continue
+		}
+		if other.Status.Upgrade != nil && other.Status.Upgrade.State == mmv1beta.UpgradeInProgress {
+			upgrading++
+		}
+	}
+	return upgrading
+}
@@ -0,0 +1,101 @@
+package mattermost
+
+import (
+	"testing"
+
+	mmv1beta "github.com/mattermost/mattermost-operator/apis/mattermost/v1beta1"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes/scheme"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+)
+
+func TestWaitingForUpgradeSlot(t *testing.T) {
+	s := prepareSchema(t, scheme.Scheme)
+	s.AddKnownTypes(mmv1beta.GroupVersion, &mmv1beta.Mattermost{}, &mmv1beta.MattermostList{})
+
+	newMattermost := func(namespace string, upgrade *mmv1beta.UpgradeStatus) *mmv1beta.Mattermost {
+		return &mmv1beta.Mattermost{
+			ObjectMeta: metav1.ObjectMeta{Name: "mm", Namespace: namespace},
+			Spec:       mmv1beta.MattermostSpec{Image: "mattermost/mattermost-enterprise-edition", Version: "5.37.1"},
+			Status:     mmv1beta.MattermostStatus{Upgrade: upgrade},
+		}
+	}
+	inProgress := &mmv1beta.UpgradeStatus{
+		State:     mmv1beta.UpgradeInProgress,
+		FromImage: "mattermost/mattermost-enterprise-edition:5.36.0",
+		ToImage:   "mattermost/mattermost-enterprise-edition:5.37.1",
+	}
+	completed := inProgress.DeepCopy()
+	completed.State = mmv1beta.UpgradeCompleted
+
+	newDeployment := func(image string) *appsv1.Deployment {
+		return &appsv1.Deployment{
+			ObjectMeta: metav1.ObjectMeta{Name: "mm", Namespace: "mm-namespace"},
+			Spec: appsv1.DeploymentSpec{
+				Template: corev1.PodTemplateSpec{
+					Spec: corev1.PodSpec{
+						Containers: []corev1.Container{{Name: mmv1beta.MattermostAppContainerName, Image: image}},
+					},
+				},
+			},
+		}
+	}
+	current := newDeployment("mattermost/mattermost-enterprise-edition:5.36.0")
+	desired := newDeployment("mattermost/mattermost-enterprise-edition:5.37.1")
+
+	mattermost := newMattermost("mm-namespace", nil)
+	client := fake.NewFakeClientWithScheme(s,
+		mattermost,
+		newMattermost("team-a", inProgress),
+		newMattermost("team-b", inProgress),
+		newMattermost("team-c", completed),
+	)
+	r := &MattermostReconciler{Client: client, Scheme: s, MaxConcurrentUpgrades: 2}
+
+	t.Run("waiting while the slots are taken", func(t *testing.T) {
+		waiting, err := r.waitingForUpgradeSlot(mattermost, current, desired)
+		require.NoError(t, err)
+		assert.True(t, waiting)
+	})
+
+	t.Run("slot free", func(t *testing.T) {
+		r := &MattermostReconciler{Client: client, Scheme: s, MaxConcurrentUpgrades: 3}
+		waiting, err := r.waitingForUpgradeSlot(mattermost, current, desired)
+		require.NoError(t, err)
+		assert.False(t, waiting)
+	})
+
+	t.Run("unlimited", func(t *testing.T) {
+		r := &MattermostReconciler{Client: client, Scheme: s}
+		waiting, err := r.waitingForUpgradeSlot(mattermost, current, desired)
+		require.NoError(t, err)
+		assert.False(t, waiting)
+	})
+
+	t.Run("same image", func(t *testing.T) {
+		waiting, err := r.waitingForUpgradeSlot(mattermost, current, current)
+		require.NoError(t, err)
+		assert.False(t, waiting)
+	})
+
+	t.Run("upgrade holding a slot", func(t *testing.T) {
+		upgrading := newMattermost("mm-namespace", inProgress)
+		waiting, err := r.waitingForUpgradeSlot(upgrading, current, desired)
+		require.NoError(t, err)
+		assert.False(t, waiting)
+	})
+
+	t.Run("rollback", func(t *testing.T) {
+		rolledBack := inProgress.DeepCopy()
+		rolledBack.State = mmv1beta.UpgradeRolledBack
+		rollingBack := newMattermost("mm-namespace", rolledBack)
+		rollingBack.Spec.UpgradeRollback = &mmv1beta.UpgradeRollback{Enabled: true}
+		waiting, err := r.waitingForUpgradeSlot(rollingBack, desired, current)
+		require.NoError(t, err)
+		assert.False(t, waiting)
+	})
+}
@@ -0,0 +1,75 @@
+package mattermost
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/go-logr/logr"
+	mmv1beta "github.com/mattermost/mattermost-operator/apis/mattermost/v1beta1"
+	"github.com/mattermost/mattermost-operator/pkg/components/utils"
+	"github.com/pkg/errors"
+	appsv1 "k8s.io/api/apps/v1"
+	k8sErrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/types"
+)
+
+// checkUpgradeSnapshots takes snapshots of the volumes of the operator managed
+// file store and database before Mattermost is upgraded to a new image. It
+// returns an error until all snapshots are ready, so that the upgrade waits
+// for them.
+func (r *MattermostReconciler) checkUpgradeSnapshots(mattermost *mmv1beta.Mattermost, reqLogger logr.Logger) (*mmv1beta.UpgradeSnapshotsStatus, error) {
+	if mattermost.Spec.UpgradeSnapshots == nil || !mattermost.Spec.UpgradeSnapshots.Enabled {
+		return mattermost.Status.UpgradeSnapshots, nil
+	}
+	reqLogger = reqLogger.WithValues("phase", "upgradeSnapshots")
+
+	current := &appsv1.Deployment{}
+	err := r.Client.Get(context.TODO(), types.NamespacedName{Name: mattermost.Name, Namespace: mattermost.Namespace}, current)
+	if err != nil {
+		if k8sErrors.IsNotFound(err) {
+			// Nothing to upgrade on a new installation.
+			return mattermost.Status.UpgradeSnapshots, nil
+		}
+		return nil, errors.Wrap(err, "failed to get Mattermost deployment")
+	}
+
+	container := mmv1beta.GetMattermostAppContainerFromDeployment(current)
+	if container == nil || container.Image == mattermost.GetImageName() {
+		return mattermost.Status.UpgradeSnapshots, nil
+	}
+
+	status := &mmv1beta.UpgradeSnapshotsStatus{
+		FromImage: container.Image,
+		ToImage:   mattermost.GetImageName(),
+		Ready:     true,
+	}
+
+	for _, volumes := range operatorManagedVolumes(mattermost) {
+		claims, err := r.Resources.ListStatefulSetVolumes(volumes.namespace, volumes.statefulSetName, volumes.claimTemplateName)
+		if err != nil {
+			return nil, errors.Wrapf(err, "failed to list %s volumes", volumes.component)
+		}
+
+		for i := range claims {
+			name := fmt.Sprintf("%s-upgrade-%s", claims[i].Name, utils.HashedName(status.ToImage))
+			ready, err := r.Resources.CreateVolumeSnapshotIfNotExists(
+				name,
+				&claims[i],
+				mattermost.Spec.UpgradeSnapshots.VolumeSnapshotClassName,
+				mmv1beta.MattermostResourceLabels(mattermost.Name),
+				reqLogger,
+			)
+			if err != nil {
+				return nil, errors.Wrapf(err, "failed to take snapshot of %s volume %s", volumes.component, claims[i].Name)
+			}
+			status.Snapshots = append(status.Snapshots, name)
+			status.Ready = status.Ready && ready
+		}
+	}
+
+	if !status.Ready {
+		return status, errors.New("waiting for volume snapshots to be ready before upgrading Mattermost")
+	}
+
+	return status, nil
+}
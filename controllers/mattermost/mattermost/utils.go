@@ -7,6 +7,8 @@ import (
 
 	"github.com/go-logr/logr"
 	mmv1beta "github.com/mattermost/mattermost-operator/apis/mattermost/v1beta1"
+	"github.com/mattermost/mattermost-operator/pkg/resources"
+	"github.com/mattermost/mattermost-operator/pkg/utils"
 	"github.com/pkg/errors"
 	corev1 "k8s.io/api/core/v1"
 	"k8s.io/apimachinery/pkg/types"
@@ -28,23 +30,38 @@ func (r *MattermostReconciler) assertSecretContains(secretName, keyName, namespa
 	return fmt.Errorf("secret %s is missing data key: %s", secretName, keyName)
 }
 
+// updateResource updates the resource of the Mattermost to its desired state,
+// keeping the fields ignored by its reconcile policy.
+func (r *MattermostReconciler) updateResource(mattermost *mmv1beta.Mattermost, current, desired resources.Object, reqLogger logr.Logger) error {
+	return r.Resources.UpdateIgnoringFields(current, desired, mattermost.IgnoredFields(), reqLogger)
+}
+
 // updateStatusReconciling sets the Mattermost state to reconciling.
 func (r *MattermostReconciler) updateStatusReconciling(mattermost *mmv1beta.Mattermost, status mmv1beta.MattermostStatus, reqLogger logr.Logger) error {
 	status.State = mmv1beta.Reconciling
+	setStateConditions(&status, mattermost.Generation, nil, nil)
 	return r.updateStatus(mattermost, status, reqLogger)
 }
 
 // updateStatusReconcilingAndLogError attempts to set the Mattermost state to reconciling
-// and updates the status. Any errors attempting this are logged, but not returned.
-// This should only be used when the outcome of setting the state can be ignored.
-func (r *MattermostReconciler) updateStatusReconcilingAndLogError(mattermost *mmv1beta.Mattermost, status mmv1beta.MattermostStatus, reqLogger logr.Logger) {
-	err := r.updateStatusReconciling(mattermost, status, reqLogger)
+// and reports the reconciliation error in the status. Any errors attempting this are
+// logged, but not returned. This should only be used when the outcome of setting the
+// state can be ignored.
+func (r *MattermostReconciler) updateStatusReconcilingAndLogError(mattermost *mmv1beta.Mattermost, status mmv1beta.MattermostStatus, reconcileErr error, reqLogger logr.Logger) {
+	r.Recorder.Event(mattermost, corev1.EventTypeWarning, "ReconcileFailed", reconcileErr.Error())
+
+	status.State = mmv1beta.Reconciling
+	setStateConditions(&status, mattermost.Generation, nil, reconcileErr)
+	err := r.updateStatus(mattermost, status, reqLogger)
 	if err != nil {
 		reqLogger.Error(err, "Failed to set state to reconciling")
 	}
 }
 
 func (r *MattermostReconciler) updateStatus(mattermost *mmv1beta.Mattermost, status mmv1beta.MattermostStatus, reqLogger logr.Logger) error {
+	// The status reflects the latest spec once it is updated, including the
+	// defaults stored by the operator.
+	status.ObservedGeneration = mattermost.Generation
 	if reflect.DeepEqual(mattermost.Status, status) {
 		return nil
 	}
@@ -53,11 +70,14 @@ func (r *MattermostReconciler) updateStatus(mattermost *mmv1beta.Mattermost, sta
 		reqLogger.Info(fmt.Sprintf("Updating Mattermost state from '%s' to '%s'", mattermost.Status.State, status.State))
 	}
 
-	mattermost.Status = status
-	err := r.Client.Status().Update(context.TODO(), mattermost)
+	previous := mattermost.Status
+	err := utils.PatchStatus(context.TODO(), r.Client, mattermost, func() {
+		mattermost.Status = status
+	})
 	if err != nil {
 		return errors.Wrap(err, "failed to update the Mattermost status")
 	}
+	r.recordStatusEvents(mattermost, previous, status)
 
 	return nil
 }
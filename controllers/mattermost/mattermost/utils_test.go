@@ -0,0 +1,49 @@
+package mattermost
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	blubr "github.com/mattermost/blubr"
+	mmv1beta "github.com/mattermost/mattermost-operator/apis/mattermost/v1beta1"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/kubernetes/scheme"
+	"k8s.io/client-go/tools/record"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+)
+
+func TestUpdateStatusObservedGeneration(t *testing.T) {
+	logger := blubr.InitLogger()
+
+	s := prepareSchema(t, scheme.Scheme)
+	s.AddKnownTypes(mmv1beta.GroupVersion, &mmv1beta.Mattermost{})
+
+	mattermost := &mmv1beta.Mattermost{
+		ObjectMeta: metav1.ObjectMeta{Name: "mm", Namespace: "mm-namespace", Generation: 3},
+		Status:     mmv1beta.MattermostStatus{State: mmv1beta.Stable, ObservedGeneration: 2},
+	}
+	c := fake.NewFakeClientWithScheme(s, mattermost)
+	r := &MattermostReconciler{Client: c, Scheme: s, Recorder: record.NewFakeRecorder(10)}
+
+	current := &mmv1beta.Mattermost{}
+	err := c.Get(context.Background(), types.NamespacedName{Name: "mm", Namespace: "mm-namespace"}, current)
+	require.NoError(t, err)
+
+	r.updateStatusReconcilingAndLogError(current, current.Status, errors.New("failed to check database"), logger)
+
+	updated := &mmv1beta.Mattermost{}
+	err = c.Get(context.Background(), types.NamespacedName{Name: "mm", Namespace: "mm-namespace"}, updated)
+	require.NoError(t, err)
+	assert.Equal(t, current.Generation, updated.Status.ObservedGeneration)
+	assert.Equal(t, mmv1beta.Reconciling, updated.Status.State)
+
+	condition := meta.FindStatusCondition(updated.Status.Conditions, mmv1beta.ErrorCondition)
+	require.NotNil(t, condition)
+	assert.Equal(t, metav1.ConditionTrue, condition.Status)
+	assert.Equal(t, current.Generation, condition.ObservedGeneration)
+}
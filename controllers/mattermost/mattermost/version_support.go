@@ -0,0 +1,123 @@
+package mattermost
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/go-logr/logr"
+	mmv1beta "github.com/mattermost/mattermost-operator/apis/mattermost/v1beta1"
+	"github.com/mattermost/mattermost-operator/pkg/mattermost/supportmatrix"
+	"github.com/pkg/errors"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+)
+
+// checkVersionSupport validates the upgrade from the version Mattermost runs
+// to the requested one against the support matrix, and reports unsupported
+// upgrades in the UnsupportedUpgrade condition. Unsupported upgrades are
+// rejected unless the update policy allows them.
+func (r *MattermostReconciler) checkVersionSupport(mattermost *mmv1beta.Mattermost, status *mmv1beta.MattermostStatus, reqLogger logr.Logger) error {
+	matrix, err := r.supportMatrix()
+	if err != nil {
+		return err
+	}
+
+	r.checkVersionEndOfLife(mattermost, matrix, status, reqLogger)
+
+	if mattermost.BlueGreenEnabled() {
+		return nil
+	}
+
+	validationErr := matrix.ValidateUpgrade(mattermost.Status.Version, mattermost.Spec.Version)
+	if validationErr == nil {
+		if meta.IsStatusConditionTrue(status.Conditions, mmv1beta.UnsupportedUpgradeCondition) {
+			setStatusCondition(status, metav1.Condition{
+				Type:               mmv1beta.UnsupportedUpgradeCondition,
+				Status:             metav1.ConditionFalse,
+				Reason:             "SupportedVersion",
+				Message:            fmt.Sprintf("Mattermost %s is supported", mattermost.Spec.Version),
+				ObservedGeneration: mattermost.Generation,
+			})
+		}
+		return nil
+	}
+
+	setStatusCondition(status, metav1.Condition{
+		Type:               mmv1beta.UnsupportedUpgradeCondition,
+		Status:             metav1.ConditionTrue,
+		Reason:             "UnsupportedVersion",
+		Message:            validationErr.Error(),
+		ObservedGeneration: mattermost.Generation,
+	})
+
+	if mattermost.Spec.UpdatePolicy != nil && mattermost.Spec.UpdatePolicy.AllowUnsupportedUpgrades {
+		reqLogger.Info("Proceeding with unsupported Mattermost upgrade", "reason", validationErr.Error())
+		return nil
+	}
+
+	return errors.Wrap(validationErr, "unsupported Mattermost upgrade rejected")
+}
+
+// checkVersionEndOfLife reports a running version older than the minimum
+// supported version in the VersionUnsupported condition, and with a warning
+// Event once it falls out of support.
+func (r *MattermostReconciler) checkVersionEndOfLife(mattermost *mmv1beta.Mattermost, matrix *supportmatrix.SupportMatrix, status *mmv1beta.MattermostStatus, reqLogger logr.Logger) {
+	if mattermost.Status.Version == "" {
+		return
+	}
+
+	validationErr := matrix.ValidateVersion(mattermost.Status.Version)
+	if validationErr == nil {
+		if meta.IsStatusConditionTrue(status.Conditions, mmv1beta.VersionUnsupportedCondition) {
+			setStatusCondition(status, metav1.Condition{
+				Type:               mmv1beta.VersionUnsupportedCondition,
+				Status:             metav1.ConditionFalse,
+				Reason:             "SupportedVersion",
+				Message:            fmt.Sprintf("Mattermost %s is supported", mattermost.Status.Version),
+				ObservedGeneration: mattermost.Generation,
+			})
+		}
+		return
+	}
+
+	if !meta.IsStatusConditionTrue(status.Conditions, mmv1beta.VersionUnsupportedCondition) {
+		reqLogger.Info("Running Mattermost version is no longer supported", "version", mattermost.Status.Version)
+		r.Recorder.Event(mattermost, corev1.EventTypeWarning, "VersionUnsupported", validationErr.Error())
+	}
+	setStatusCondition(status, metav1.Condition{
+		Type:               mmv1beta.VersionUnsupportedCondition,
+		Status:             metav1.ConditionTrue,
+		Reason:             "EndOfLife",
+		Message:            validationErr.Error(),
+		ObservedGeneration: mattermost.Generation,
+	})
+}
+
+// supportMatrix returns the support matrix from the ConfigMap configured for
+// the operator, or the default one.
+func (r *MattermostReconciler) supportMatrix() (*supportmatrix.SupportMatrix, error) {
+	if r.SupportMatrixConfigMap == "" {
+		return &supportmatrix.Default, nil
+	}
+
+	key := strings.SplitN(r.SupportMatrixConfigMap, "/", 2)
+	if len(key) != 2 {
+		return nil, errors.Errorf("support matrix ConfigMap %s must be set as 'namespace/name'", r.SupportMatrixConfigMap)
+	}
+
+	configMap := &corev1.ConfigMap{}
+	err := r.NonCachedAPIReader.Get(context.TODO(), types.NamespacedName{Namespace: key[0], Name: key[1]}, configMap)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to get support matrix ConfigMap")
+	}
+
+	data, ok := configMap.Data[supportmatrix.ConfigMapKey]
+	if !ok {
+		return nil, errors.Errorf("support matrix ConfigMap %s does not have a '%s' value", r.SupportMatrixConfigMap, supportmatrix.ConfigMapKey)
+	}
+
+	return supportmatrix.Parse([]byte(data))
+}
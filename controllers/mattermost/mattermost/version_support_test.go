@@ -0,0 +1,92 @@
+package mattermost
+
+import (
+	"testing"
+
+	blubr "github.com/mattermost/blubr"
+	mmv1beta "github.com/mattermost/mattermost-operator/apis/mattermost/v1beta1"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/tools/record"
+)
+
+func TestCheckVersionSupport(t *testing.T) {
+	logger := blubr.InitLogger()
+	recorder := record.NewFakeRecorder(10)
+	r := &MattermostReconciler{Recorder: recorder}
+
+	newMattermost := func(running, requested string) *mmv1beta.Mattermost {
+		return &mmv1beta.Mattermost{
+			ObjectMeta: metav1.ObjectMeta{Name: "mm", Namespace: "mm-namespace"},
+			Spec: mmv1beta.MattermostSpec{
+				Image:        "mattermost/mattermost-enterprise-edition",
+				Version:      requested,
+				UpdatePolicy: &mmv1beta.UpdatePolicy{},
+			},
+			Status: mmv1beta.MattermostStatus{Version: running},
+		}
+	}
+
+	t.Run("supported upgrade", func(t *testing.T) {
+		mattermost := newMattermost("5.37.1", "5.39.0")
+		status := mattermost.Status
+
+		assert.NoError(t, r.checkVersionSupport(mattermost, &status, logger))
+		assert.Empty(t, status.Conditions)
+	})
+
+	t.Run("unsupported upgrade rejected", func(t *testing.T) {
+		mattermost := newMattermost("5.31.3", "5.39.0")
+		status := mattermost.Status
+
+		assert.Error(t, r.checkVersionSupport(mattermost, &status, logger))
+		assert.True(t, meta.IsStatusConditionTrue(status.Conditions, mmv1beta.UnsupportedUpgradeCondition))
+	})
+
+	t.Run("new installation older than minimum", func(t *testing.T) {
+		mattermost := newMattermost("", "5.19.1")
+		status := mattermost.Status
+
+		assert.NoError(t, r.checkVersionSupport(mattermost, &status, logger))
+		assert.Empty(t, status.Conditions)
+	})
+
+	t.Run("unsupported upgrade allowed", func(t *testing.T) {
+		mattermost := newMattermost("5.31.3", "5.39.0")
+		mattermost.Spec.UpdatePolicy.AllowUnsupportedUpgrades = true
+		status := mattermost.Status
+
+		assert.NoError(t, r.checkVersionSupport(mattermost, &status, logger))
+		assert.True(t, meta.IsStatusConditionTrue(status.Conditions, mmv1beta.UnsupportedUpgradeCondition))
+	})
+
+	t.Run("condition cleared once supported", func(t *testing.T) {
+		mattermost := newMattermost("5.31.3", "5.37.1")
+		status := mattermost.Status
+		meta.SetStatusCondition(&status.Conditions, metav1.Condition{
+			Type:   mmv1beta.UnsupportedUpgradeCondition,
+			Status: metav1.ConditionTrue,
+			Reason: "UnsupportedVersion",
+		})
+
+		assert.NoError(t, r.checkVersionSupport(mattermost, &status, logger))
+		assert.True(t, meta.IsStatusConditionFalse(status.Conditions, mmv1beta.UnsupportedUpgradeCondition))
+	})
+
+	t.Run("running version end of life", func(t *testing.T) {
+		mattermost := newMattermost("5.24.2", "5.24.2")
+		mattermost.Spec.UpdatePolicy.AllowUnsupportedUpgrades = true
+		status := mattermost.Status
+
+		assert.NoError(t, r.checkVersionSupport(mattermost, &status, logger))
+		assert.True(t, meta.IsStatusConditionTrue(status.Conditions, mmv1beta.VersionUnsupportedCondition))
+		require.Len(t, recorder.Events, 1)
+		assert.Contains(t, <-recorder.Events, "VersionUnsupported")
+
+		mattermost.Status = status
+		assert.NoError(t, r.checkVersionSupport(mattermost, &status, logger))
+		assert.Empty(t, recorder.Events)
+	})
+}
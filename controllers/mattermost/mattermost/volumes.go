@@ -0,0 +1,107 @@
+package mattermost
+
+import (
+	"github.com/go-logr/logr"
+	mmv1beta "github.com/mattermost/mattermost-operator/apis/mattermost/v1beta1"
+	mattermostMinio "github.com/mattermost/mattermost-operator/pkg/components/minio"
+	mattermostmysql "github.com/mattermost/mattermost-operator/pkg/components/mysql"
+	mattermostApp "github.com/mattermost/mattermost-operator/pkg/mattermost"
+	"github.com/pkg/errors"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+)
+
+// statefulSetVolumes identifies the volumes created by a StatefulSet of an
+// operator managed dependency.
+type statefulSetVolumes struct {
+	component         string
+	namespace         string
+	statefulSetName   string
+	claimTemplateName string
+	size              resource.Quantity
+}
+
+// operatorManagedVolumes returns the volumes of the operator managed file
+// store and database.
+func operatorManagedVolumes(mattermost *mmv1beta.Mattermost) []statefulSetVolumes {
+	var volumes []statefulSetVolumes
+
+	if !mattermost.Spec.FileStore.IsExternal() {
+		minio := mattermostMinio.InstanceV1Beta(mattermost)
+		claimTemplate := minio.Spec.VolumeClaimTemplate
+
+		volumes = append(volumes, statefulSetVolumes{
+			component:         "Minio",
+			namespace:         minio.Namespace,
+			statefulSetName:   minio.Name,
+			claimTemplateName: claimTemplate.Name,
+			size:              claimTemplate.Spec.Resources.Requests[corev1.ResourceStorage],
+		})
+	}
+
+	if !mattermost.Spec.Database.IsExternal() && mattermost.Spec.Database.OperatorManaged.Type == "mysql" {
+		cluster := mattermostmysql.ClusterV1Beta(mattermost)
+
+		volumes = append(volumes, statefulSetVolumes{
+			component:         "MySQL",
+			namespace:         cluster.Namespace,
+			statefulSetName:   mattermostmysql.StatefulSetName(cluster),
+			claimTemplateName: mattermostmysql.DataVolumeClaimTemplateName,
+			size:              cluster.Spec.VolumeSpec.PersistentVolumeClaim.Resources.Requests[corev1.ResourceStorage],
+		})
+	}
+
+	return volumes
+}
+
+// checkVolumeExpansion expands the volumes of the operator managed file store
+// and database when their storage size is increased. It returns the status
+// of the volumes which are not yet resized.
+func (r *MattermostReconciler) checkVolumeExpansion(mattermost *mmv1beta.Mattermost, reqLogger logr.Logger) ([]mmv1beta.VolumeResizeStatus, error) {
+	reqLogger = reqLogger.WithValues("phase", "volumeExpansion")
+
+	var statuses []mmv1beta.VolumeResizeStatus
+	for _, volumes := range operatorManagedVolumes(mattermost) {
+		componentStatuses, err := r.Resources.ExpandStatefulSetVolumes(
+			volumes.namespace,
+			volumes.statefulSetName,
+			volumes.claimTemplateName,
+			volumes.size,
+			reqLogger,
+		)
+		if err != nil {
+			return nil, errors.Wrapf(err, "failed to expand %s volumes", volumes.component)
+		}
+		statuses = append(statuses, componentStatuses...)
+	}
+
+	return statuses, nil
+}
+
+// checkVeleroVolumes labels the volumes of the operator managed file store
+// and database to be excluded from Velero backups if requested, and removes
+// the label otherwise.
+func (r *MattermostReconciler) checkVeleroVolumes(mattermost *mmv1beta.Mattermost, reqLogger logr.Logger) error {
+	reqLogger = reqLogger.WithValues("phase", "veleroVolumes")
+
+	value := ""
+	if mattermostApp.VeleroVolumesExcluded(mattermost) {
+		value = "true"
+	}
+
+	for _, volumes := range operatorManagedVolumes(mattermost) {
+		err := r.Resources.SetStatefulSetVolumesLabel(
+			volumes.namespace,
+			volumes.statefulSetName,
+			volumes.claimTemplateName,
+			mattermostApp.VeleroExcludeLabel,
+			value,
+			reqLogger,
+		)
+		if err != nil {
+			return errors.Wrapf(err, "failed to label %s volumes", volumes.component)
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,199 @@
+package mattermostbackup
+
+import (
+	"context"
+	"time"
+
+	"github.com/go-logr/logr"
+	mmv1beta "github.com/mattermost/mattermost-operator/apis/mattermost/v1beta1"
+	mattermostApp "github.com/mattermost/mattermost-operator/pkg/mattermost"
+	"github.com/mattermost/mattermost-operator/pkg/namespaceselector"
+	"github.com/mattermost/mattermost-operator/pkg/resources"
+	"github.com/pkg/errors"
+	batchv1 "k8s.io/api/batch/v1"
+	k8sErrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/builder"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+)
+
+// backupRequeueDelay is the delay after which the state of running and
+// scheduled backups is checked again.
+const backupRequeueDelay = 30 * time.Second
+
+// MattermostBackupReconciler reconciles a MattermostBackup object
+type MattermostBackupReconciler struct {
+	client.Client
+	Log       logr.Logger
+	Scheme    *runtime.Scheme
+	Resources *resources.ResourceHelper
+	// Namespaces selects the namespaces of the MattermostBackups reconciled by the
+	// operator, all of them if nil.
+	Namespaces *namespaceselector.Selector
+}
+
+func NewMattermostBackupReconciler(mgr ctrl.Manager) *MattermostBackupReconciler {
+	return &MattermostBackupReconciler{
+		Client:    mgr.GetClient(),
+		Log:       ctrl.Log.WithName("controllers").WithName("MattermostBackup"),
+		Scheme:    mgr.GetScheme(),
+		Resources: resources.NewResourceHelper(mgr.GetClient(), mgr.GetScheme()),
+	}
+}
+
+func (r *MattermostBackupReconciler) SetupWithManager(mgr ctrl.Manager) error {
+	blder := ctrl.NewControllerManagedBy(mgr).
+		For(&mmv1beta.MattermostBackup{}, builder.WithPredicates(r.Namespaces.Predicate())).
+		Owns(&batchv1.Job{}).
+		Owns(resources.NewCronJob(resources.CronJobGroupVersionKind(mgr.GetRESTMapper())))
+	return r.Namespaces.Watch(blder, &mmv1beta.MattermostBackupList{}).Complete(r)
+}
+
+// Reconcile reads the state of the cluster for a MattermostBackup object and
+// runs the backup Job, or the CronJob for scheduled backups, reporting the
+// result of the last backup in the status.
+func (r *MattermostBackupReconciler) Reconcile(ctx context.Context, request ctrl.Request) (ctrl.Result, error) {
+	reqLogger := r.Log.WithValues("name", request.Name, "namespace", request.Namespace)
+	reqLogger.Info("Reconciling MattermostBackup")
+
+	backup := &mmv1beta.MattermostBackup{}
+	err := r.Client.Get(ctx, request.NamespacedName, backup)
+	if err != nil && k8sErrors.IsNotFound(err) {
+		// Request object not found, could have been deleted after reconcile
+		// request. Owned objects are automatically garbage collected.
+		return reconcile.Result{}, nil
+	} else if err != nil {
+		return reconcile.Result{}, err
+	}
+	selected, err := r.Namespaces.Selects(ctx, backup.Namespace)
+	if err != nil {
+		return reconcile.Result{}, err
+	} else if !selected {
+		reqLogger.V(1).Info("Skipping MattermostBackup of a namespace not selected")
+		return reconcile.Result{}, nil
+	}
+
+	status := backup.Status
+
+	err = r.checkBackupJobs(backup, reqLogger)
+	if err != nil {
+		status.Message = err.Error()
+		r.updateStatusAndLogError(backup, status, reqLogger)
+		return reconcile.Result{}, err
+	}
+	status.Message = ""
+
+	job, err := r.lastBackupJob(backup)
+	if err != nil {
+		r.updateStatusAndLogError(backup, status, reqLogger)
+		return reconcile.Result{}, err
+	}
+	if job != nil {
+		status.LastBackup = r.backupResult(job, reqLogger)
+		status.State = status.LastBackup.State
+		if status.State == mmv1beta.BackupCompleted {
+			status.LastSuccessfulBackup = status.LastBackup
+		}
+	}
+
+	err = r.updateStatus(backup, status, reqLogger)
+	if err != nil {
+		return reconcile.Result{}, err
+	}
+
+	// Jobs created by the CronJob are not owned by the MattermostBackup, so
+	// scheduled backups are checked periodically.
+	if backup.Spec.Schedule != "" || status.State == mmv1beta.BackupRunning {
+		return reconcile.Result{RequeueAfter: backupRequeueDelay}, nil
+	}
+
+	return reconcile.Result{}, nil
+}
+
+// checkBackupJobs ensures the Job taking the backup exists, or the CronJob if
+// the backup is scheduled.
+func (r *MattermostBackupReconciler) checkBackupJobs(backup *mmv1beta.MattermostBackup, reqLogger logr.Logger) error {
+	mattermost := &mmv1beta.Mattermost{}
+	err := r.Client.Get(context.TODO(), types.NamespacedName{Name: backup.Spec.MattermostName, Namespace: backup.Namespace}, mattermost)
+	if err != nil {
+		return errors.Wrap(err, "failed to get Mattermost installation")
+	}
+
+	db, err := r.Resources.ReadDatabaseConfig(mattermost)
+	if err != nil {
+		return errors.Wrap(err, "failed to read database configuration")
+	}
+
+	fileStore, err := r.Resources.ReadFileStoreInfo(mattermost)
+	if err != nil {
+		return errors.Wrap(err, "failed to read file store configuration")
+	}
+
+	destination, err := r.Resources.ReadBackupBucket(backup.Spec.Destination, backup.Namespace)
+	if err != nil {
+		return errors.Wrap(err, "failed to read backup destination")
+	}
+
+	if backup.Spec.Schedule == "" {
+		err = r.Resources.DeleteCronJob(types.NamespacedName{Name: mattermostApp.BackupJobName(backup), Namespace: backup.Namespace}, reqLogger)
+		if err != nil {
+			return err
+		}
+
+		desired, err := mattermostApp.GenerateBackupJobV1Beta(backup, mattermost, db, fileStore, destination)
+		if err != nil {
+			return errors.Wrap(err, "failed to generate backup job")
+		}
+
+		return r.Resources.CreateJobIfNotExists(backup, desired, reqLogger)
+	}
+
+	desired, err := mattermostApp.GenerateBackupCronJobV1Beta(backup, mattermost, db, fileStore, destination)
+	if err != nil {
+		return errors.Wrap(err, "failed to generate backup cron job")
+	}
+
+	return r.Resources.ApplyCronJob(desired, reqLogger)
+}
+
+// lastBackupJob returns the most recently created Job taking a backup, or nil
+// if no backup was started yet.
+func (r *MattermostBackupReconciler) lastBackupJob(backup *mmv1beta.MattermostBackup) (*batchv1.Job, error) {
+	jobs := &batchv1.JobList{}
+	err := r.Client.List(context.TODO(), jobs, client.InNamespace(backup.Namespace), client.MatchingLabels{mattermostApp.BackupLabel: backup.Name})
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to list backup jobs")
+	}
+
+	var last *batchv1.Job
+	for i := range jobs.Items {
+		if last == nil || last.CreationTimestamp.Before(&jobs.Items[i].CreationTimestamp) {
+			last = &jobs.Items[i]
+		}
+	}
+
+	return last, nil
+}
+
+func (r *MattermostBackupReconciler) backupResult(job *batchv1.Job, reqLogger logr.Logger) *mmv1beta.BackupResult {
+	result := &mmv1beta.BackupResult{
+		JobName:   job.Name,
+		State:     mmv1beta.BackupRunning,
+		StartTime: job.Status.StartTime,
+	}
+
+	if resources.JobConditionTrue(job, batchv1.JobFailed) {
+		result.State = mmv1beta.BackupFailed
+		result.CompletionTime = resources.JobConditionTime(job, batchv1.JobFailed)
+		result.Message = r.Resources.JobTerminationMessage(job, reqLogger)
+	} else if resources.JobConditionTrue(job, batchv1.JobComplete) {
+		result.State = mmv1beta.BackupCompleted
+		result.CompletionTime = job.Status.CompletionTime
+		result.Location = r.Resources.JobTerminationMessage(job, reqLogger)
+	}
+
+	return result
+}
@@ -0,0 +1,42 @@
+package mattermostbackup
+
+import (
+	"context"
+	"fmt"
+	"reflect"
+
+	"github.com/go-logr/logr"
+	mmv1beta "github.com/mattermost/mattermost-operator/apis/mattermost/v1beta1"
+	"github.com/mattermost/mattermost-operator/pkg/utils"
+	"github.com/pkg/errors"
+)
+
+func (r *MattermostBackupReconciler) updateStatus(backup *mmv1beta.MattermostBackup, status mmv1beta.MattermostBackupStatus, reqLogger logr.Logger) error {
+	// The status reflects the latest spec once it is updated.
+	status.ObservedGeneration = backup.Generation
+	if reflect.DeepEqual(backup.Status, status) {
+		return nil
+	}
+
+	if backup.Status.State != status.State {
+		reqLogger.Info(fmt.Sprintf("Updating MattermostBackup state from '%s' to '%s'", backup.Status.State, status.State))
+	}
+
+	err := utils.PatchStatus(context.TODO(), r.Client, backup, func() {
+		backup.Status = status
+	})
+	if err != nil {
+		return errors.Wrap(err, "failed to update the MattermostBackup status")
+	}
+
+	return nil
+}
+
+// updateStatusAndLogError updates the status. Any errors attempting this are
+// logged, but not returned.
+func (r *MattermostBackupReconciler) updateStatusAndLogError(backup *mmv1beta.MattermostBackup, status mmv1beta.MattermostBackupStatus, reqLogger logr.Logger) {
+	err := r.updateStatus(backup, status, reqLogger)
+	if err != nil {
+		reqLogger.Error(err, "Failed to update the MattermostBackup status")
+	}
+}
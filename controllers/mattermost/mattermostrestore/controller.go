@@ -0,0 +1,211 @@
+package mattermostrestore
+
+import (
+	"context"
+	"time"
+
+	"github.com/go-logr/logr"
+	mmv1beta "github.com/mattermost/mattermost-operator/apis/mattermost/v1beta1"
+	mattermostApp "github.com/mattermost/mattermost-operator/pkg/mattermost"
+	"github.com/mattermost/mattermost-operator/pkg/namespaceselector"
+	"github.com/mattermost/mattermost-operator/pkg/resources"
+	"github.com/pkg/errors"
+	appsv1 "k8s.io/api/apps/v1"
+	batchv1 "k8s.io/api/batch/v1"
+	k8sErrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/builder"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+)
+
+// scalingRequeueDelay is the delay after which the Mattermost deployment is
+// checked again while it is being scaled down.
+const scalingRequeueDelay = 5 * time.Second
+
+// MattermostRestoreReconciler reconciles a MattermostRestore object
+type MattermostRestoreReconciler struct {
+	client.Client
+	Log       logr.Logger
+	Scheme    *runtime.Scheme
+	Resources *resources.ResourceHelper
+	// Namespaces selects the namespaces of the MattermostRestores reconciled by the
+	// operator, all of them if nil.
+	Namespaces *namespaceselector.Selector
+}
+
+func NewMattermostRestoreReconciler(mgr ctrl.Manager) *MattermostRestoreReconciler {
+	return &MattermostRestoreReconciler{
+		Client:    mgr.GetClient(),
+		Log:       ctrl.Log.WithName("controllers").WithName("MattermostRestore"),
+		Scheme:    mgr.GetScheme(),
+		Resources: resources.NewResourceHelper(mgr.GetClient(), mgr.GetScheme()),
+	}
+}
+
+func (r *MattermostRestoreReconciler) SetupWithManager(mgr ctrl.Manager) error {
+	blder := ctrl.NewControllerManagedBy(mgr).
+		For(&mmv1beta.MattermostRestore{}, builder.WithPredicates(r.Namespaces.Predicate())).
+		Owns(&batchv1.Job{})
+	return r.Namespaces.Watch(blder, &mmv1beta.MattermostRestoreList{}).Complete(r)
+}
+
+// Reconcile reads the state of the cluster for a MattermostRestore object and
+// moves the restore to its next state: Mattermost is scaled down, the
+// database is restored and validated, and Mattermost is scaled back up.
+func (r *MattermostRestoreReconciler) Reconcile(ctx context.Context, request ctrl.Request) (ctrl.Result, error) {
+	reqLogger := r.Log.WithValues("name", request.Name, "namespace", request.Namespace)
+	reqLogger.Info("Reconciling MattermostRestore")
+
+	restore := &mmv1beta.MattermostRestore{}
+	err := r.Client.Get(ctx, request.NamespacedName, restore)
+	if err != nil && k8sErrors.IsNotFound(err) {
+		// Request object not found, could have been deleted after reconcile
+		// request. Owned objects are automatically garbage collected.
+		return reconcile.Result{}, nil
+	} else if err != nil {
+		return reconcile.Result{}, err
+	}
+	selected, err := r.Namespaces.Selects(ctx, restore.Namespace)
+	if err != nil {
+		return reconcile.Result{}, err
+	} else if !selected {
+		reqLogger.V(1).Info("Skipping MattermostRestore of a namespace not selected")
+		return reconcile.Result{}, nil
+	}
+
+	if restore.Status.State == mmv1beta.RestoreFinished || restore.Status.State == mmv1beta.RestoreFailed {
+		return reconcile.Result{}, nil
+	}
+
+	mattermost := &mmv1beta.Mattermost{}
+	err = r.Client.Get(ctx, types.NamespacedName{Name: restore.Spec.MattermostName, Namespace: restore.Namespace}, mattermost)
+	if err != nil {
+		return r.reportError(restore, errors.Wrap(err, "failed to get Mattermost installation"), reqLogger)
+	}
+
+	status := restore.Status
+	status.Message = ""
+
+	switch status.State {
+	case "":
+		// The Mattermost controller sets the replicas on the first
+		// reconciliation, a single replica is used until then.
+		status.OriginalReplicas = 1
+		if mattermost.Spec.Replicas != nil {
+			status.OriginalReplicas = *mattermost.Spec.Replicas
+		}
+		startTime := metav1.Now()
+		status.StartTime = &startTime
+		status.State = mmv1beta.RestoreScalingDown
+		reqLogger.Info("Starting restore, scaling down Mattermost", "originalReplicas", status.OriginalReplicas)
+	case mmv1beta.RestoreScalingDown:
+		scaledDown, err := r.scaleDown(mattermost, reqLogger)
+		if err != nil {
+			return r.reportError(restore, err, reqLogger)
+		}
+		if !scaledDown {
+			return reconcile.Result{RequeueAfter: scalingRequeueDelay}, nil
+		}
+		status.State = mmv1beta.RestoreRestoring
+	case mmv1beta.RestoreRestoring:
+		err = r.checkRestoreJob(restore, mattermost, &status, reqLogger)
+		if err != nil {
+			return r.reportError(restore, err, reqLogger)
+		}
+	case mmv1beta.RestoreScalingUp:
+		reqLogger.Info("Database restored, scaling up Mattermost", "replicas", status.OriginalReplicas)
+		mattermost.Spec.Replicas = &status.OriginalReplicas
+		err = r.Client.Update(ctx, mattermost)
+		if err != nil {
+			return r.reportError(restore, errors.Wrap(err, "failed to scale up Mattermost"), reqLogger)
+		}
+		completionTime := metav1.Now()
+		status.CompletionTime = &completionTime
+		status.State = mmv1beta.RestoreFinished
+	}
+
+	err = r.updateStatus(restore, status, reqLogger)
+	if err != nil {
+		return reconcile.Result{}, err
+	}
+
+	return reconcile.Result{}, nil
+}
+
+// scaleDown sets the Mattermost replicas to zero and returns whether all
+// Mattermost pods are stopped.
+func (r *MattermostRestoreReconciler) scaleDown(mattermost *mmv1beta.Mattermost, reqLogger logr.Logger) (bool, error) {
+	if mattermost.Spec.Replicas == nil || *mattermost.Spec.Replicas != 0 {
+		replicas := int32(0)
+		mattermost.Spec.Replicas = &replicas
+		err := r.Client.Update(context.TODO(), mattermost)
+		if err != nil {
+			return false, errors.Wrap(err, "failed to scale down Mattermost")
+		}
+		return false, nil
+	}
+
+	deployment := &appsv1.Deployment{}
+	err := r.Client.Get(context.TODO(), types.NamespacedName{Name: mattermost.Name, Namespace: mattermost.Namespace}, deployment)
+	if err != nil {
+		if k8sErrors.IsNotFound(err) {
+			return true, nil
+		}
+		return false, errors.Wrap(err, "failed to get Mattermost deployment")
+	}
+
+	if deployment.Spec.Replicas != nil && *deployment.Spec.Replicas != 0 || deployment.Status.Replicas != 0 {
+		reqLogger.Info("Waiting for Mattermost to scale down", "replicas", deployment.Status.Replicas)
+		return false, nil
+	}
+
+	return true, nil
+}
+
+// checkRestoreJob ensures the Job restoring the database exists and moves
+// the restore to the next state once the Job finished.
+func (r *MattermostRestoreReconciler) checkRestoreJob(restore *mmv1beta.MattermostRestore, mattermost *mmv1beta.Mattermost, status *mmv1beta.MattermostRestoreStatus, reqLogger logr.Logger) error {
+	status.JobName = mattermostApp.RestoreJobName(restore)
+
+	current := &batchv1.Job{}
+	err := r.Client.Get(context.TODO(), types.NamespacedName{Name: status.JobName, Namespace: restore.Namespace}, current)
+	if err != nil && k8sErrors.IsNotFound(err) {
+		db, err := r.Resources.ReadDatabaseConfig(mattermost)
+		if err != nil {
+			return errors.Wrap(err, "failed to read database configuration")
+		}
+
+		source, err := r.Resources.ReadBackupBucket(restore.Spec.Source, restore.Namespace)
+		if err != nil {
+			return errors.Wrap(err, "failed to read backup source")
+		}
+
+		desired, err := mattermostApp.GenerateRestoreJobV1Beta(restore, mattermost, db, source)
+		if err != nil {
+			return errors.Wrap(err, "failed to generate restore job")
+		}
+
+		return r.Resources.CreateJobIfNotExists(restore, desired, reqLogger)
+	} else if err != nil {
+		return errors.Wrap(err, "failed to get restore job")
+	}
+
+	if resources.JobConditionTrue(current, batchv1.JobFailed) {
+		reqLogger.Info("Restore job failed, Mattermost is left scaled down")
+		status.State = mmv1beta.RestoreFailed
+		status.CompletionTime = resources.JobConditionTime(current, batchv1.JobFailed)
+		status.Message = r.Resources.JobTerminationMessage(current, reqLogger)
+		return nil
+	}
+
+	if resources.JobConditionTrue(current, batchv1.JobComplete) {
+		status.State = mmv1beta.RestoreScalingUp
+		status.Message = r.Resources.JobTerminationMessage(current, reqLogger)
+	}
+
+	return nil
+}
@@ -0,0 +1,47 @@
+package mattermostrestore
+
+import (
+	"context"
+	"fmt"
+	"reflect"
+
+	"github.com/go-logr/logr"
+	mmv1beta "github.com/mattermost/mattermost-operator/apis/mattermost/v1beta1"
+	"github.com/mattermost/mattermost-operator/pkg/utils"
+	"github.com/pkg/errors"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+)
+
+func (r *MattermostRestoreReconciler) updateStatus(restore *mmv1beta.MattermostRestore, status mmv1beta.MattermostRestoreStatus, reqLogger logr.Logger) error {
+	// The status reflects the latest spec once it is updated.
+	status.ObservedGeneration = restore.Generation
+	if reflect.DeepEqual(restore.Status, status) {
+		return nil
+	}
+
+	if restore.Status.State != status.State {
+		reqLogger.Info(fmt.Sprintf("Updating MattermostRestore state from '%s' to '%s'", restore.Status.State, status.State))
+	}
+
+	err := utils.PatchStatus(context.TODO(), r.Client, restore, func() {
+		restore.Status = status
+	})
+	if err != nil {
+		return errors.Wrap(err, "failed to update the MattermostRestore status")
+	}
+
+	return nil
+}
+
+// reportError sets the error as the status message and returns it, so that
+// the restore is retried.
+func (r *MattermostRestoreReconciler) reportError(restore *mmv1beta.MattermostRestore, err error, reqLogger logr.Logger) (reconcile.Result, error) {
+	status := restore.Status
+	status.Message = err.Error()
+	statusErr := r.updateStatus(restore, status, reqLogger)
+	if statusErr != nil {
+		reqLogger.Error(statusErr, "Failed to update the MattermostRestore status")
+	}
+
+	return reconcile.Result{}, err
+}
@@ -12,10 +12,12 @@ import (
 	"github.com/go-logr/logr"
 	"k8s.io/apimachinery/pkg/runtime"
 	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/builder"
 	"sigs.k8s.io/controller-runtime/pkg/client"
 
 	mattermostv1alpha1 "github.com/mattermost/mattermost-operator/apis/mattermost/v1alpha1"
 	mattermostmysql "github.com/mattermost/mattermost-operator/pkg/components/mysql"
+	"github.com/mattermost/mattermost-operator/pkg/namespaceselector"
 
 	errrors "github.com/pkg/errors"
 	appsv1 "k8s.io/api/apps/v1"
@@ -27,6 +29,9 @@ type MattermostRestoreDBReconciler struct {
 	client.Client
 	Log    logr.Logger
 	Scheme *runtime.Scheme
+	// Namespaces selects the namespaces of the MattermostRestoreDBs
+	// reconciled by the operator, all of them if nil.
+	Namespaces *namespaceselector.Selector
 
 	state mattermostv1alpha1.RestoreState
 }
@@ -35,9 +40,9 @@ type MattermostRestoreDBReconciler struct {
 // +kubebuilder:rbac:groups=mattermost.com,resources=mattermostrestoredbs/status,verbs=get;update;patch
 
 func (r *MattermostRestoreDBReconciler) SetupWithManager(mgr ctrl.Manager) error {
-	return ctrl.NewControllerManagedBy(mgr).
-		For(&mattermostv1alpha1.MattermostRestoreDB{}).
-		Complete(r)
+	blder := ctrl.NewControllerManagedBy(mgr).
+		For(&mattermostv1alpha1.MattermostRestoreDB{}, builder.WithPredicates(r.Namespaces.Predicate()))
+	return r.Namespaces.Watch(blder, &mattermostv1alpha1.MattermostRestoreDBList{}).Complete(r)
 }
 
 func (r *MattermostRestoreDBReconciler) setRestoring() {
@@ -53,7 +58,7 @@ func (r *MattermostRestoreDBReconciler) setFailed() {
 }
 
 func (r *MattermostRestoreDBReconciler) Reconcile(ctx context.Context, request ctrl.Request) (ctrl.Result, error) {
-	reqLogger := r.Log.WithValues("Request.Namespace", request.Namespace, "Request.Name", request.Name)
+	reqLogger := r.Log.WithValues("name", request.Name, "namespace", request.Namespace)
 	reqLogger.Info("Reconciling MattermostRestoreDB")
 	// Fetch the MattermostRestoreDB instance
 	restoreMM := &mattermostv1alpha1.MattermostRestoreDB{}
@@ -69,6 +74,13 @@ func (r *MattermostRestoreDBReconciler) Reconcile(ctx context.Context, request c
 		r.setFailed()
 		return reconcile.Result{}, err
 	}
+	selected, err := r.Namespaces.Selects(context.TODO(), restoreMM.Namespace)
+	if err != nil {
+		return reconcile.Result{}, err
+	} else if !selected {
+		reqLogger.V(1).Info("Skipping MattermostRestoreDB of a namespace not selected")
+		return reconcile.Result{}, nil
+	}
 
 	// Check if this Mattermost ClusterInstallation exists
 	clusterInstallation := &mattermostv1alpha1.ClusterInstallation{}
@@ -9,12 +9,14 @@ import (
 
 	"github.com/go-logr/logr"
 	mattermostv1alpha1 "github.com/mattermost/mattermost-operator/apis/mattermost/v1alpha1"
+	"github.com/mattermost/mattermost-operator/pkg/utils"
 )
 
 func (r *MattermostRestoreDBReconciler) updateStatus(mattermost *mattermostv1alpha1.MattermostRestoreDB, status mattermostv1alpha1.MattermostRestoreDBStatus, reqLogger logr.Logger) error {
 	if !reflect.DeepEqual(mattermost.Status, status) {
-		mattermost.Status = status
-		err := r.Client.Status().Update(context.TODO(), mattermost)
+		err := utils.PatchStatus(context.TODO(), r.Client, mattermost, func() {
+			mattermost.Status = status
+		})
 		if err != nil {
 			reqLogger.Error(err, "failed to update the mattermostrestoredb status")
 			return err
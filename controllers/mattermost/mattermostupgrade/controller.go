@@ -0,0 +1,290 @@
+package mattermostupgrade
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/go-logr/logr"
+	mmv1beta "github.com/mattermost/mattermost-operator/apis/mattermost/v1beta1"
+	"github.com/mattermost/mattermost-operator/pkg/namespaceselector"
+	"github.com/pkg/errors"
+	k8sErrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+)
+
+// upgradeRequeueDelay is the delay after which the installations being
+// upgraded are checked again.
+const upgradeRequeueDelay = 30 * time.Second
+
+// MattermostUpgradeReconciler reconciles a MattermostUpgrade object
+type MattermostUpgradeReconciler struct {
+	client.Client
+	Log    logr.Logger
+	Scheme *runtime.Scheme
+	// Namespaces selects the namespaces of the Mattermosts upgraded by the
+	// operator, all of them if nil.
+	Namespaces *namespaceselector.Selector
+}
+
+func NewMattermostUpgradeReconciler(mgr ctrl.Manager) *MattermostUpgradeReconciler {
+	return &MattermostUpgradeReconciler{
+		Client: mgr.GetClient(),
+		Log:    ctrl.Log.WithName("controllers").WithName("MattermostUpgrade"),
+		Scheme: mgr.GetScheme(),
+	}
+}
+
+func (r *MattermostUpgradeReconciler) SetupWithManager(mgr ctrl.Manager) error {
+	return ctrl.NewControllerManagedBy(mgr).
+		For(&mmv1beta.MattermostUpgrade{}).
+		Complete(r)
+}
+
+// Reconcile reads the state of the cluster for a MattermostUpgrade object
+// and moves the rollout forward: the installations being upgraded are
+// checked, and the next batch of installations is upgraded once they are
+// done.
+func (r *MattermostUpgradeReconciler) Reconcile(ctx context.Context, request ctrl.Request) (ctrl.Result, error) {
+	reqLogger := r.Log.WithValues("name", request.Name)
+	reqLogger.Info("Reconciling MattermostUpgrade")
+
+	upgrade := &mmv1beta.MattermostUpgrade{}
+	err := r.Client.Get(ctx, request.NamespacedName, upgrade)
+	if err != nil && k8sErrors.IsNotFound(err) {
+		// Request object not found, could have been deleted after reconcile
+		// request. The upgraded installations are kept.
+		return reconcile.Result{}, nil
+	} else if err != nil {
+		return reconcile.Result{}, err
+	}
+
+	status := *upgrade.Status.DeepCopy()
+	if status.Version != upgrade.Spec.Version {
+		if status.Version != "" {
+			reqLogger.Info("Version changed, restarting the rollout", "from", status.Version, "to", upgrade.Spec.Version)
+		}
+		startTime := metav1.Now()
+		status = mmv1beta.MattermostUpgradeStatus{Version: upgrade.Spec.Version, StartTime: &startTime}
+	} else if rolloutFinished(status.State) && status.CompletionTime != nil && status.ObservedGeneration == upgrade.Generation {
+		return reconcile.Result{}, nil
+	}
+
+	selector, err := metav1.LabelSelectorAsSelector(&upgrade.Spec.Selector)
+	if err != nil {
+		return r.reportError(upgrade, errors.Wrap(err, "invalid selector"), reqLogger)
+	}
+	var mattermosts mmv1beta.MattermostList
+	err = r.Client.List(ctx, &mattermosts, client.MatchingLabelsSelector{Selector: selector})
+	if err != nil {
+		return r.reportError(upgrade, errors.Wrap(err, "failed to list Mattermosts"), reqLogger)
+	}
+
+	selected := map[types.NamespacedName]*mmv1beta.Mattermost{}
+	for i := range mattermosts.Items {
+		mattermost := &mattermosts.Items[i]
+		namespaceSelected, err := r.Namespaces.Selects(ctx, mattermost.Namespace)
+		if err != nil {
+			return r.reportError(upgrade, err, reqLogger)
+		} else if !namespaceSelected {
+			continue
+		}
+		selected[types.NamespacedName{Namespace: mattermost.Namespace, Name: mattermost.Name}] = mattermost
+	}
+	status.Installations = mergeInstallations(status.Installations, selected)
+
+	now := metav1.Now()
+	upgrading := 0
+	for i := range status.Installations {
+		installation := &status.Installations[i]
+		if installation.State != mmv1beta.InstallationUpgradeUpgrading {
+			continue
+		}
+		mattermost := selected[types.NamespacedName{Namespace: installation.Namespace, Name: installation.Name}]
+		checkInstallation(upgrade, mattermost, installation, now)
+		switch installation.State {
+		case mmv1beta.InstallationUpgradeUpgrading:
+			upgrading++
+		case mmv1beta.InstallationUpgradeUpgraded:
+			reqLogger.Info("Installation upgraded", "namespace", installation.Namespace, "installation", installation.Name)
+		case mmv1beta.InstallationUpgradeFailed:
+			reqLogger.Info("Installation failed to upgrade", "namespace", installation.Namespace, "installation", installation.Name, "reason", installation.Message)
+		}
+	}
+	countInstallations(&status)
+
+	var upgradeErr error
+	switch {
+	case status.Failed > upgrade.Spec.MaxFailures:
+		// The upgrades in progress are left running, no new installation is
+		// upgraded.
+		status.State = mmv1beta.MattermostUpgradeFailed
+	case upgrade.Spec.Paused:
+		status.State = mmv1beta.MattermostUpgradePaused
+	default:
+		status.State = mmv1beta.MattermostUpgradeUpgrading
+		// The installations upgraded before an error are recorded in the
+		// status before the error is returned.
+		for i := range status.Installations {
+			if upgrading >= upgrade.Spec.GetBatchSize() {
+				break
+			}
+			installation := &status.Installations[i]
+			if installation.State != mmv1beta.InstallationUpgradePending {
+				continue
+			}
+			mattermost := selected[types.NamespacedName{Namespace: installation.Namespace, Name: installation.Name}]
+			upgradeErr = r.startUpgrade(ctx, upgrade, mattermost, installation, reqLogger)
+			if upgradeErr != nil {
+				break
+			}
+			upgrading++
+		}
+		if upgrading == 0 && upgradeErr == nil {
+			status.State = mmv1beta.MattermostUpgradeCompleted
+		}
+	}
+	if rolloutFinished(status.State) && upgrading == 0 {
+		if status.CompletionTime == nil {
+			status.CompletionTime = &now
+		}
+	} else {
+		status.CompletionTime = nil
+	}
+
+	err = r.updateStatus(upgrade, status, reqLogger)
+	if err != nil {
+		return reconcile.Result{}, err
+	}
+	if upgradeErr != nil {
+		return r.reportError(upgrade, upgradeErr, reqLogger)
+	}
+
+	if upgrading > 0 {
+		return reconcile.Result{RequeueAfter: upgradeRequeueDelay}, nil
+	}
+	return reconcile.Result{}, nil
+}
+
+// startUpgrade sets the target version, and image, of the Mattermost.
+func (r *MattermostUpgradeReconciler) startUpgrade(ctx context.Context, upgrade *mmv1beta.MattermostUpgrade, mattermost *mmv1beta.Mattermost, installation *mmv1beta.InstallationUpgradeStatus, reqLogger logr.Logger) error {
+	reqLogger.Info("Upgrading installation", "namespace", mattermost.Namespace, "installation", mattermost.Name, "from", mattermost.Spec.Version, "to", upgrade.Spec.Version)
+
+	installation.FromVersion = mattermost.Spec.Version
+	if mattermost.Spec.Version != upgrade.Spec.Version || upgrade.Spec.Image != "" && mattermost.Spec.Image != upgrade.Spec.Image {
+		mattermost.Spec.Version = upgrade.Spec.Version
+		if upgrade.Spec.Image != "" {
+			mattermost.Spec.Image = upgrade.Spec.Image
+		}
+		err := r.Client.Update(ctx, mattermost)
+		if err != nil {
+			return errors.Wrapf(err, "failed to upgrade Mattermost %s/%s", mattermost.Namespace, mattermost.Name)
+		}
+	}
+
+	startTime := metav1.Now()
+	installation.State = mmv1beta.InstallationUpgradeUpgrading
+	installation.StartTime = &startTime
+	return nil
+}
+
+// checkInstallation moves the installation being upgraded to upgraded once
+// the Mattermost runs the new version stably, or to failed if its upgrade
+// was rolled back or did not complete within the timeout.
+func checkInstallation(upgrade *mmv1beta.MattermostUpgrade, mattermost *mmv1beta.Mattermost, installation *mmv1beta.InstallationUpgradeStatus, now metav1.Time) {
+	fail := func(message string) {
+		installation.State = mmv1beta.InstallationUpgradeFailed
+		installation.Message = message
+		installation.CompletionTime = &now
+	}
+
+	if mattermost == nil {
+		fail("the Mattermost was deleted or is no longer selected")
+		return
+	}
+	if mattermost.Spec.Version != upgrade.Spec.Version {
+		fail(fmt.Sprintf("the version of the Mattermost was changed to %s", mattermost.Spec.Version))
+		return
+	}
+
+	// Conditions set before the upgrade started are ignored.
+	condition := meta.FindStatusCondition(mattermost.Status.Conditions, mmv1beta.UpgradeFailedCondition)
+	if condition != nil && condition.Status == metav1.ConditionTrue && installation.StartTime != nil && !condition.LastTransitionTime.Before(installation.StartTime) {
+		fail(condition.Message)
+		return
+	}
+
+	// The idle deployment of a standby Mattermost is upgraded without pods.
+	if (mattermost.Status.State == mmv1beta.Stable || mattermost.Status.State == mmv1beta.StandingBy) &&
+		mattermost.Status.Version == upgrade.Spec.Version &&
+		mattermost.Status.ObservedGeneration >= mattermost.Generation {
+		installation.State = mmv1beta.InstallationUpgradeUpgraded
+		installation.CompletionTime = &now
+		return
+	}
+
+	if installation.StartTime != nil && now.Sub(installation.StartTime.Time) > upgrade.Spec.GetTimeout() {
+		fail(fmt.Sprintf("the Mattermost did not run %s stably within %s", upgrade.Spec.Version, upgrade.Spec.GetTimeout()))
+	}
+}
+
+// mergeInstallations adds the newly selected Mattermosts as pending, in
+// namespace and name order, and removes the pending installations which are
+// no longer selected.
+func mergeInstallations(installations []mmv1beta.InstallationUpgradeStatus, selected map[types.NamespacedName]*mmv1beta.Mattermost) []mmv1beta.InstallationUpgradeStatus {
+	known := map[types.NamespacedName]bool{}
+	merged := make([]mmv1beta.InstallationUpgradeStatus, 0, len(selected))
+	for _, installation := range installations {
+		key := types.NamespacedName{Namespace: installation.Namespace, Name: installation.Name}
+		if installation.State == mmv1beta.InstallationUpgradePending && selected[key] == nil {
+			continue
+		}
+		known[key] = true
+		merged = append(merged, installation)
+	}
+
+	var added []mmv1beta.InstallationUpgradeStatus
+	for key := range selected {
+		if !known[key] {
+			added = append(added, mmv1beta.InstallationUpgradeStatus{
+				Namespace: key.Namespace,
+				Name:      key.Name,
+				State:     mmv1beta.InstallationUpgradePending,
+			})
+		}
+	}
+	sort.Slice(added, func(i, j int) bool {
+		if added[i].Namespace != added[j].Namespace {
+			return added[i].Namespace < added[j].Namespace
+		}
+		return added[i].Name < added[j].Name
+	})
+
+	return append(merged, added...)
+}
+
+// countInstallations sets the counters of the status.
+func countInstallations(status *mmv1beta.MattermostUpgradeStatus) {
+	status.Total = int32(len(status.Installations))
+	status.Upgraded = 0
+	status.Failed = 0
+	for _, installation := range status.Installations {
+		switch installation.State {
+		case mmv1beta.InstallationUpgradeUpgraded:
+			status.Upgraded++
+		case mmv1beta.InstallationUpgradeFailed:
+			status.Failed++
+		}
+	}
+}
+
+func rolloutFinished(state mmv1beta.MattermostUpgradeState) bool {
+	return state == mmv1beta.MattermostUpgradeCompleted || state == mmv1beta.MattermostUpgradeFailed
+}
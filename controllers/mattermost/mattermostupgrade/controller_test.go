@@ -0,0 +1,192 @@
+package mattermostupgrade
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	blubr "github.com/mattermost/blubr"
+	mmv1beta "github.com/mattermost/mattermost-operator/apis/mattermost/v1beta1"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+)
+
+func TestReconcile(t *testing.T) {
+	s := runtime.NewScheme()
+	require.NoError(t, mmv1beta.AddToScheme(s))
+
+	newMattermost := func(namespace, name string, labels map[string]string) *mmv1beta.Mattermost {
+		return &mmv1beta.Mattermost{
+			ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: namespace, Labels: labels},
+			Spec:       mmv1beta.MattermostSpec{Image: "mattermost/mattermost-enterprise-edition", Version: "7.1.0"},
+			Status:     mmv1beta.MattermostStatus{State: mmv1beta.Stable, Version: "7.1.0"},
+		}
+	}
+	production := map[string]string{"environment": "production"}
+	upgrade := &mmv1beta.MattermostUpgrade{
+		ObjectMeta: metav1.ObjectMeta{Name: "production"},
+		Spec: mmv1beta.MattermostUpgradeSpec{
+			Selector:  metav1.LabelSelector{MatchLabels: production},
+			Version:   "7.8.0",
+			BatchSize: 2,
+		},
+	}
+
+	c := fake.NewFakeClientWithScheme(s,
+		upgrade,
+		newMattermost("team-b", "mm", production),
+		newMattermost("team-a", "mm-2", production),
+		newMattermost("team-a", "mm-1", production),
+		newMattermost("team-a", "dev", nil),
+	)
+	r := &MattermostUpgradeReconciler{Client: c, Scheme: s, Log: blubr.InitLogger()}
+	request := ctrl.Request{NamespacedName: types.NamespacedName{Name: "production"}}
+
+	reconcileUpgrade := func(t *testing.T) (ctrl.Result, *mmv1beta.MattermostUpgrade) {
+		result, err := r.Reconcile(context.TODO(), request)
+		require.NoError(t, err)
+		current := &mmv1beta.MattermostUpgrade{}
+		require.NoError(t, c.Get(context.TODO(), request.NamespacedName, current))
+		return result, current
+	}
+	getMattermost := func(t *testing.T, namespace, name string) *mmv1beta.Mattermost {
+		mattermost := &mmv1beta.Mattermost{}
+		require.NoError(t, c.Get(context.TODO(), types.NamespacedName{Namespace: namespace, Name: name}, mattermost))
+		return mattermost
+	}
+	installationStates := func(upgrade *mmv1beta.MattermostUpgrade) []mmv1beta.InstallationUpgradeState {
+		var states []mmv1beta.InstallationUpgradeState
+		for _, installation := range upgrade.Status.Installations {
+			states = append(states, installation.State)
+		}
+		return states
+	}
+
+	t.Run("first batch", func(t *testing.T) {
+		result, current := reconcileUpgrade(t)
+		assert.Equal(t, upgradeRequeueDelay, result.RequeueAfter)
+		assert.Equal(t, mmv1beta.MattermostUpgradeUpgrading, current.Status.State)
+		assert.Equal(t, "7.8.0", current.Status.Version)
+		assert.Equal(t, int32(3), current.Status.Total)
+
+		require.Len(t, current.Status.Installations, 3)
+		assert.Equal(t, "team-a", current.Status.Installations[0].Namespace)
+		assert.Equal(t, "mm-1", current.Status.Installations[0].Name)
+		assert.Equal(t, "7.1.0", current.Status.Installations[0].FromVersion)
+		assert.Equal(t, []mmv1beta.InstallationUpgradeState{
+			mmv1beta.InstallationUpgradeUpgrading,
+			mmv1beta.InstallationUpgradeUpgrading,
+			mmv1beta.InstallationUpgradePending,
+		}, installationStates(current))
+
+		assert.Equal(t, "7.8.0", getMattermost(t, "team-a", "mm-1").Spec.Version)
+		assert.Equal(t, "7.8.0", getMattermost(t, "team-a", "mm-2").Spec.Version)
+		assert.Equal(t, "7.1.0", getMattermost(t, "team-b", "mm").Spec.Version)
+		assert.Equal(t, "7.1.0", getMattermost(t, "team-a", "dev").Spec.Version)
+	})
+
+	t.Run("next batch", func(t *testing.T) {
+		mattermost := getMattermost(t, "team-a", "mm-1")
+		mattermost.Status.Version = "7.8.0"
+		require.NoError(t, c.Status().Update(context.TODO(), mattermost))
+
+		_, current := reconcileUpgrade(t)
+		assert.Equal(t, []mmv1beta.InstallationUpgradeState{
+			mmv1beta.InstallationUpgradeUpgraded,
+			mmv1beta.InstallationUpgradeUpgrading,
+			mmv1beta.InstallationUpgradeUpgrading,
+		}, installationStates(current))
+		assert.Equal(t, int32(1), current.Status.Upgraded)
+		assert.Equal(t, "7.8.0", getMattermost(t, "team-b", "mm").Spec.Version)
+	})
+
+	t.Run("failed installation", func(t *testing.T) {
+		mattermost := getMattermost(t, "team-a", "mm-2")
+		mattermost.Status.Conditions = []metav1.Condition{{
+			Type:               mmv1beta.UpgradeFailedCondition,
+			Status:             metav1.ConditionTrue,
+			Reason:             "ProgressDeadlineExceeded",
+			Message:            "rolled back to 7.1.0",
+			LastTransitionTime: metav1.NewTime(time.Now().Add(time.Minute)),
+		}}
+		require.NoError(t, c.Status().Update(context.TODO(), mattermost))
+
+		result, current := reconcileUpgrade(t)
+		assert.Equal(t, mmv1beta.MattermostUpgradeFailed, current.Status.State)
+		assert.Equal(t, int32(1), current.Status.Failed)
+		assert.Equal(t, "rolled back to 7.1.0", current.Status.Installations[1].Message)
+		// The upgrade in progress is still checked.
+		assert.Equal(t, upgradeRequeueDelay, result.RequeueAfter)
+		assert.Nil(t, current.Status.CompletionTime)
+	})
+
+	t.Run("tolerated failure", func(t *testing.T) {
+		current := &mmv1beta.MattermostUpgrade{}
+		require.NoError(t, c.Get(context.TODO(), request.NamespacedName, current))
+		current.Spec.MaxFailures = 1
+		require.NoError(t, c.Update(context.TODO(), current))
+
+		mattermost := getMattermost(t, "team-b", "mm")
+		mattermost.Status.Version = "7.8.0"
+		require.NoError(t, c.Status().Update(context.TODO(), mattermost))
+
+		result, current := reconcileUpgrade(t)
+		assert.Zero(t, result.RequeueAfter)
+		assert.Equal(t, mmv1beta.MattermostUpgradeCompleted, current.Status.State)
+		assert.Equal(t, int32(2), current.Status.Upgraded)
+		assert.Equal(t, int32(1), current.Status.Failed)
+		assert.NotNil(t, current.Status.CompletionTime)
+	})
+}
+
+func TestCheckInstallation(t *testing.T) {
+	upgrade := &mmv1beta.MattermostUpgrade{
+		Spec: mmv1beta.MattermostUpgradeSpec{Version: "7.8.0", Timeout: &metav1.Duration{Duration: time.Hour}},
+	}
+	start := metav1.NewTime(time.Now().Add(-2 * time.Hour))
+	mattermost := &mmv1beta.Mattermost{
+		Spec:   mmv1beta.MattermostSpec{Version: "7.8.0"},
+		Status: mmv1beta.MattermostStatus{State: mmv1beta.Reconciling, Version: "7.1.0"},
+	}
+
+	t.Run("timeout", func(t *testing.T) {
+		installation := &mmv1beta.InstallationUpgradeStatus{State: mmv1beta.InstallationUpgradeUpgrading, StartTime: &start}
+		checkInstallation(upgrade, mattermost, installation, metav1.Now())
+		assert.Equal(t, mmv1beta.InstallationUpgradeFailed, installation.State)
+		assert.Equal(t, "the Mattermost did not run 7.8.0 stably within 1h0m0s", installation.Message)
+	})
+
+	t.Run("stale condition", func(t *testing.T) {
+		stale := mattermost.DeepCopy()
+		stale.Status.Conditions = []metav1.Condition{{
+			Type:               mmv1beta.UpgradeFailedCondition,
+			Status:             metav1.ConditionTrue,
+			LastTransitionTime: metav1.NewTime(start.Add(-time.Minute)),
+		}}
+		installation := &mmv1beta.InstallationUpgradeStatus{State: mmv1beta.InstallationUpgradeUpgrading, StartTime: &start}
+		checkInstallation(upgrade, stale, installation, metav1.NewTime(start.Add(time.Minute)))
+		assert.Equal(t, mmv1beta.InstallationUpgradeUpgrading, installation.State)
+	})
+
+	t.Run("version changed", func(t *testing.T) {
+		changed := mattermost.DeepCopy()
+		changed.Spec.Version = "7.9.0"
+		installation := &mmv1beta.InstallationUpgradeStatus{State: mmv1beta.InstallationUpgradeUpgrading, StartTime: &start}
+		checkInstallation(upgrade, changed, installation, metav1.Now())
+		assert.Equal(t, mmv1beta.InstallationUpgradeFailed, installation.State)
+	})
+
+	t.Run("standby", func(t *testing.T) {
+		standby := mattermost.DeepCopy()
+		standby.Status.State = mmv1beta.StandingBy
+		standby.Status.Version = "7.8.0"
+		installation := &mmv1beta.InstallationUpgradeStatus{State: mmv1beta.InstallationUpgradeUpgrading, StartTime: &start}
+		checkInstallation(upgrade, standby, installation, metav1.NewTime(start.Add(time.Minute)))
+		assert.Equal(t, mmv1beta.InstallationUpgradeUpgraded, installation.State)
+	})
+}
@@ -0,0 +1,41 @@
+package mattermostupgrade
+
+import (
+	"context"
+	"fmt"
+	"reflect"
+
+	"github.com/go-logr/logr"
+	mmv1beta "github.com/mattermost/mattermost-operator/apis/mattermost/v1beta1"
+	"github.com/mattermost/mattermost-operator/pkg/utils"
+	"github.com/pkg/errors"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+)
+
+func (r *MattermostUpgradeReconciler) updateStatus(upgrade *mmv1beta.MattermostUpgrade, status mmv1beta.MattermostUpgradeStatus, reqLogger logr.Logger) error {
+	// The status reflects the latest spec once it is updated.
+	status.ObservedGeneration = upgrade.Generation
+	if reflect.DeepEqual(upgrade.Status, status) {
+		return nil
+	}
+
+	if upgrade.Status.State != status.State {
+		reqLogger.Info(fmt.Sprintf("Updating MattermostUpgrade state from '%s' to '%s'", upgrade.Status.State, status.State))
+	}
+
+	err := utils.PatchStatus(context.TODO(), r.Client, upgrade, func() {
+		upgrade.Status = status
+	})
+	if err != nil {
+		return errors.Wrap(err, "failed to update the MattermostUpgrade status")
+	}
+
+	return nil
+}
+
+// reportError logs the error and returns it, so that the rollout is retried.
+// The status is kept as the rollout may not have progressed.
+func (r *MattermostUpgradeReconciler) reportError(upgrade *mmv1beta.MattermostUpgrade, err error, reqLogger logr.Logger) (reconcile.Result, error) {
+	reqLogger.Error(err, "Failed to reconcile MattermostUpgrade")
+	return reconcile.Result{}, err
+}
@@ -3,7 +3,7 @@ module github.com/mattermost/mattermost-operator
 go 1.14
 
 require (
-	github.com/banzaicloud/k8s-objectmatcher v1.5.2
+	github.com/evanphx/json-patch v4.9.0+incompatible
 	github.com/go-logr/logr v0.4.0
 	github.com/go-openapi/jsonreference v0.19.4 // indirect
 	github.com/go-openapi/spec v0.19.3
@@ -14,9 +14,11 @@ require (
 	github.com/pborman/uuid v1.2.1
 	github.com/pkg/errors v0.9.1
 	github.com/presslabs/mysql-operator v0.5.0-rc.2
+	github.com/prometheus/client_golang v1.7.1
 	github.com/stretchr/testify v1.7.0
 	github.com/vrischmann/envconfig v1.3.0
 	golang.org/x/net v0.0.0-20201202161906-c7110b5ffcbb
+	golang.org/x/time v0.0.0-20200630173020-3af7569d3a1e
 	gopkg.in/yaml.v3 v3.0.0-20210107192922-496545a6307b // indirect
 	k8s.io/api v0.20.6
 	k8s.io/apimachinery v0.20.6
@@ -1,26 +1,53 @@
 package main
 
 import (
+	"context"
 	"flag"
 	"fmt"
+	"io/ioutil"
 	"os"
 	"runtime"
+	"strings"
 	"time"
 
 	"github.com/mattermost/mattermost-operator/controllers/mattermost/clusterinstallation"
+	"github.com/mattermost/mattermost-operator/controllers/mattermost/ecrcredentials"
 	"github.com/mattermost/mattermost-operator/controllers/mattermost/mattermost"
+	"github.com/mattermost/mattermost-operator/controllers/mattermost/mattermostbackup"
+	"github.com/mattermost/mattermost-operator/controllers/mattermost/mattermostrestore"
 	"github.com/mattermost/mattermost-operator/controllers/mattermost/mattermostrestoredb"
+	"github.com/mattermost/mattermost-operator/controllers/mattermost/mattermostupgrade"
+	"github.com/mattermost/mattermost-operator/pkg/diagnostics"
+	"github.com/mattermost/mattermost-operator/pkg/logging"
+	"github.com/mattermost/mattermost-operator/pkg/mattermost/releases"
+	"github.com/mattermost/mattermost-operator/pkg/metricsserver"
+	"github.com/mattermost/mattermost-operator/pkg/namespacecache"
+	"github.com/mattermost/mattermost-operator/pkg/namespaceselector"
+	"github.com/mattermost/mattermost-operator/pkg/olm"
+	"github.com/mattermost/mattermost-operator/pkg/orphansweep"
 	"github.com/mattermost/mattermost-operator/pkg/resources"
+	"github.com/mattermost/mattermost-operator/pkg/scopedcache"
+	"github.com/mattermost/mattermost-operator/pkg/sharding"
+	"github.com/mattermost/mattermost-operator/pkg/tracing"
+	"github.com/mattermost/mattermost-operator/pkg/utils"
+	"github.com/mattermost/mattermost-operator/pkg/webhookcert"
 
 	blubr "github.com/mattermost/blubr"
 	v1beta1Minio "github.com/minio/minio-operator/pkg/apis/miniocontroller/v1beta1"
 	v1alpha1MySQL "github.com/presslabs/mysql-operator/pkg/apis/mysql/v1alpha1"
 	"github.com/vrischmann/envconfig"
+	appsv1 "k8s.io/api/apps/v1"
+	batchv1 "k8s.io/api/batch/v1"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/labels"
 	k8sruntime "k8s.io/apimachinery/pkg/runtime"
 	utilruntime "k8s.io/apimachinery/pkg/util/runtime"
 	clientgoscheme "k8s.io/client-go/kubernetes/scheme"
 	_ "k8s.io/client-go/plugin/pkg/client/auth/gcp"
+	"k8s.io/client-go/tools/leaderelection/resourcelock"
 	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/cache"
+	"sigs.k8s.io/controller-runtime/pkg/client"
 	logf "sigs.k8s.io/controller-runtime/pkg/log"
 
 	mattermostcomv1alpha1 "github.com/mattermost/mattermost-operator/apis/mattermost/v1alpha1"
@@ -53,23 +80,116 @@ func init() {
 }
 
 type Config struct {
-	MaxReconcilingInstallations int           `envconfig:"default=20"`
-	RequeueOnLimitDelay         time.Duration `envconfig:"default=20s"`
+	MaxReconcilingInstallations   int           `envconfig:"default=20"`
+	RequeueOnLimitDelay           time.Duration `envconfig:"default=20s"`
+	MaxConcurrentUpgrades         int           `envconfig:"optional"`
+	PriorityTierDelay             time.Duration `envconfig:"default=10s"`
+	SupportMatrixConfigMap        string        `envconfig:"optional"`
+	GlobalDefaultsConfigMap       string        `envconfig:"optional"`
+	WatchNamespace                string        `envconfig:"optional"`
+	ReleasesFeedURL               string        `envconfig:"optional"`
+	ReleasesFeedRefreshInterval   time.Duration `envconfig:"default=1h"`
+	ImageRegistry                 string        `envconfig:"optional"`
+	CurlImage                     string        `envconfig:"optional"`
+	PostgresImage                 string        `envconfig:"optional"`
+	MinioClientImage              string        `envconfig:"optional"`
+	ECRCredentialsRefreshInterval time.Duration `envconfig:"optional"`
+	OrphanSweepInterval           time.Duration `envconfig:"optional"`
+	OrphanSweepDelete             bool          `envconfig:"optional"`
+	EnableWebhooks                bool          `envconfig:"optional"`
+	ManageWebhookCertificates     bool          `envconfig:"optional"`
+	WebhookServiceName            string        `envconfig:"default=webhook-service"`
+	WebhookCertSecret             string        `envconfig:"default=webhook-server-cert"`
+	OperatorNamespace             string        `envconfig:"optional"`
+	HealthCheckInterval           time.Duration `envconfig:"default=1m"`
+	HealthCheckFailureThreshold   int32         `envconfig:"default=3"`
+	MigrationVerificationTimeout  time.Duration `envconfig:"default=10m"`
+	NotificationWebhookURL        string        `envconfig:"optional"`
+	AuditHistoryLimit             int           `envconfig:"default=10"`
+	RevisionHistoryLimit          int           `envconfig:"default=10"`
+	MetricsSecureServing          bool          `envconfig:"optional"`
+	MetricsCertDir                string        `envconfig:"optional"`
+	MetricsClientCAFile           string        `envconfig:"optional"`
+	MetricsTokenAuthentication    bool          `envconfig:"default=true"`
+	MetricsServiceName            string        `envconfig:"default=mattermost-operator"`
+	TracingEndpoint               string        `envconfig:"OTEL_EXPORTER_OTLP_ENDPOINT,optional"`
+	TracingHeaders                string        `envconfig:"OTEL_EXPORTER_OTLP_HEADERS,optional"`
+	TracingServiceName            string        `envconfig:"OTEL_SERVICE_NAME,default=mattermost-operator"`
+	LogLevel                      string        `envconfig:"default=info"`
+	LabelScopedCache              bool          `envconfig:"optional"`
+	OperatorConditionName         string        `envconfig:"optional"`
 }
 
+// serviceAccountNamespaceFile is the file holding the namespace of the
+// operator pod.
+const serviceAccountNamespaceFile = "/var/run/secrets/kubernetes.io/serviceaccount/namespace"
+
 func main() {
+	if len(os.Args) > 1 && os.Args[1] == migrateHelmCommand {
+		os.Exit(migrateHelm(os.Args[2:]))
+	}
+	if len(os.Args) > 1 && os.Args[1] == renderCommand {
+		os.Exit(render(os.Args[2:]))
+	}
+
 	var metricsAddr string
+	var diagnosticsAddr string
 	var enableLeaderElection bool
+	var kubeAPIQPS float64
+	var kubeAPIBurst int
+	var kubeAPITimeout time.Duration
+	var mattermostWorkers int
+	var clusterInstallationWorkers int
+	var syncPeriod time.Duration
+	var errorBackoffBase time.Duration
+	var errorBackoffCap time.Duration
+	var errorBackoffJitter float64
+	var shard sharding.Shard
+	var leaseDuration time.Duration
+	var renewDeadline time.Duration
+	var retryPeriod time.Duration
+	var leaderElectionNamespace string
+	var leaderElectionResourceLock string
+	var namespaceSelector string
 	flag.StringVar(&metricsAddr, "metrics-addr", fmt.Sprintf("%s:%d", metricsHost, metricsPort), "The address the metric endpoint binds to.")
+	flag.StringVar(&diagnosticsAddr, "diagnostics-addr", "", "The address the pprof and expvar endpoints bind to, ie 127.0.0.1:6060. "+
+		"The endpoints are not authenticated and disabled by default.")
+	flag.Float64Var(&kubeAPIQPS, "kube-api-qps", 20, "The maximum queries per second of the operator to the Kubernetes API.")
+	flag.IntVar(&kubeAPIBurst, "kube-api-burst", 30, "The maximum burst of queries of the operator to the Kubernetes API.")
+	flag.DurationVar(&kubeAPITimeout, "kube-api-timeout", 0, "The timeout of the requests of the operator to the Kubernetes API, 0 for no timeout. "+
+		"Watches are restarted once it elapses.")
+	flag.IntVar(&mattermostWorkers, "mattermost-workers", 1, "The number of Mattermosts reconciled, and health checked, in parallel.")
+	flag.IntVar(&clusterInstallationWorkers, "clusterinstallation-workers", 1, "The number of ClusterInstallations reconciled in parallel.")
+	flag.DurationVar(&syncPeriod, "sync-period", 10*time.Hour, "The interval of the resyncs of the watched objects, reconciling the ClusterInstallations, backups and restores again.")
+	flag.DurationVar(&errorBackoffBase, "error-backoff-base", 5*time.Millisecond, "The delay of the first requeue of a failed Mattermost or ClusterInstallation reconciliation, doubled on each failure.")
+	flag.DurationVar(&errorBackoffCap, "error-backoff-cap", 1000*time.Second, "The maximum delay of the requeues of a failed Mattermost or ClusterInstallation reconciliation.")
+	flag.Float64Var(&errorBackoffJitter, "error-backoff-jitter", 0, "The factor up to which the requeue delays are raised randomly, ie 0.1, 0 for no jitter.")
+	flag.IntVar(&shard.Count, "shards", 1, "The number of shards the installations are spread among, each reconciled by its own replicas of the operator.")
+	flag.IntVar(&shard.ID, "shard", 0, "The shard of the installations reconciled by this replica, from 0 to the number of shards minus 1.")
+	flag.StringVar(&namespaceSelector, "namespace-selector", "", "The label selector of the namespaces watched by the operator, ie mattermost.com/managed=true. "+
+		"The namespaces labeled once the operator runs are picked up, all the namespaces are watched if empty.")
 	flag.BoolVar(&enableLeaderElection, "enable-leader-election", false,
 		"Enable leader election for controller manager. "+
 			"Enabling this will ensure there is only one active controller manager.")
+	flag.DurationVar(&leaseDuration, "leader-election-lease-duration", 15*time.Second, "The duration the other replicas wait before taking over the leadership of a leader which stopped renewing it.")
+	flag.DurationVar(&renewDeadline, "leader-election-renew-deadline", 10*time.Second, "The duration the leader retries renewing its leadership for before stepping down.")
+	flag.DurationVar(&retryPeriod, "leader-election-retry-period", 2*time.Second, "The interval of the attempts to acquire or renew the leadership.")
+	flag.StringVar(&leaderElectionNamespace, "leader-election-namespace", "", "The namespace of the leader election lock, the namespace of the operator by default.")
+	flag.StringVar(&leaderElectionResourceLock, "leader-election-resource-lock", resourcelock.ConfigMapsLeasesResourceLock,
+		"The objects the leader election lock is held with, ie leases to only use Lease objects once migrated from ConfigMaps.")
 	flag.Parse()
 
+	// The configuration is read first for the log level, its errors are
+	// logged once the logger is set up.
+	var config Config
+	err := envconfig.Init(&config)
+	verbosity, levelErr := logging.ParseLevel(config.LogLevel)
+
 	// Setup logging.
 	// This logger wraps logrus in a 'logr.Logger' interface. This is required
 	// for the deferred logging required by the various operator packages.
-	logger := blubr.InitLogger()
+	// Info logs more verbose than the log level are dropped.
+	logger := logging.NewLogger(blubr.InitLogger(), verbosity)
 	logger = logger.WithName("opr")
 	logf.SetLogger(logger)
 	ctrl.SetLogger(logger)
@@ -77,19 +197,120 @@ func main() {
 	logger.Info(fmt.Sprintf("Go Version: %s", runtime.Version()))
 	logger.Info(fmt.Sprintf("Go OS/Arch: %s/%s", runtime.GOOS, runtime.GOARCH))
 
-	var config Config
-	err := envconfig.Init(&config)
 	if err != nil {
 		logger.Error(err, "Unable to read environment configuration")
 		os.Exit(1)
 	}
+	if levelErr != nil {
+		logger.Error(levelErr, "Unable to parse log level")
+		os.Exit(1)
+	}
+
+	manageWebhookCertificates := config.EnableWebhooks && config.ManageWebhookCertificates
+	var webhookCertDir string
+	if manageWebhookCertificates {
+		webhookCertDir, err = ioutil.TempDir("", "k8s-webhook-server")
+		if err != nil {
+			logger.Error(err, "Unable to create webhook certificate directory")
+			os.Exit(1)
+		}
+	}
+
+	// The metrics are served by the secure metrics server instead of the
+	// manager.
+	managerMetricsAddr := metricsAddr
+	if config.MetricsSecureServing {
+		managerMetricsAddr = "0"
+	}
+
+	if kubeAPIQPS <= 0 || kubeAPIBurst <= 0 || kubeAPITimeout < 0 {
+		logger.Error(fmt.Errorf("qps %v, burst %d, timeout %s", kubeAPIQPS, kubeAPIBurst, kubeAPITimeout), "Invalid Kubernetes API client settings")
+		os.Exit(1)
+	}
+	if mattermostWorkers < 1 || clusterInstallationWorkers < 1 {
+		logger.Error(fmt.Errorf("mattermost workers %d, clusterinstallation workers %d", mattermostWorkers, clusterInstallationWorkers), "Invalid number of workers")
+		os.Exit(1)
+	}
+	if syncPeriod <= 0 || errorBackoffBase <= 0 || errorBackoffCap < errorBackoffBase || errorBackoffJitter < 0 {
+		logger.Error(fmt.Errorf("sync period %s, error backoff base %s, cap %s, jitter %v", syncPeriod, errorBackoffBase, errorBackoffCap, errorBackoffJitter), "Invalid requeue settings")
+		os.Exit(1)
+	}
+	if shard.Count < 1 || shard.ID < 0 || shard.ID >= shard.Count {
+		logger.Error(fmt.Errorf("shard %d of %d shards", shard.ID, shard.Count), "Invalid shard")
+		os.Exit(1)
+	}
+
+	// The leader renews its leadership before it expires, at least once.
+	if retryPeriod <= 0 || renewDeadline <= retryPeriod || leaseDuration <= renewDeadline {
+		logger.Error(fmt.Errorf("lease duration %s, renew deadline %s, retry period %s", leaseDuration, renewDeadline, retryPeriod), "Invalid leader election settings")
+		os.Exit(1)
+	}
+	if config.MaxConcurrentUpgrades < 0 {
+		logger.Error(fmt.Errorf("max concurrent upgrades %d", config.MaxConcurrentUpgrades), "Invalid upgrade settings")
+		os.Exit(1)
+	}
+	if config.PriorityTierDelay < 0 {
+		logger.Error(fmt.Errorf("priority tier delay %s", config.PriorityTierDelay), "Invalid priority settings")
+		os.Exit(1)
+	}
+	var namespaceLabelSelector labels.Selector
+	if namespaceSelector != "" {
+		namespaceLabelSelector, err = labels.Parse(namespaceSelector)
+		if err != nil {
+			logger.Error(err, "Invalid namespace selector")
+			os.Exit(1)
+		}
+		if config.WatchNamespace != "" {
+			logger.Error(fmt.Errorf("namespace selector %q, watched namespaces %q", namespaceSelector, config.WatchNamespace), "Invalid namespace settings")
+			os.Exit(1)
+		}
+	}
+
+	restConfig := ctrl.GetConfigOrDie()
+	restConfig.QPS = float32(kubeAPIQPS)
+	restConfig.Burst = kubeAPIBurst
+	restConfig.Timeout = kubeAPITimeout
 
-	mgr, err := ctrl.NewManager(ctrl.GetConfigOrDie(), ctrl.Options{
+	options := ctrl.Options{
 		Scheme:             scheme,
-		MetricsBindAddress: metricsAddr,
+		MetricsBindAddress: managerMetricsAddr,
 		LeaderElection:     enableLeaderElection,
 		LeaderElectionID:   "b78a986e.mattermost.com",
-	})
+		CertDir:            webhookCertDir,
+		SyncPeriod:         &syncPeriod,
+
+		LeaderElectionNamespace:    leaderElectionNamespace,
+		LeaderElectionResourceLock: leaderElectionResourceLock,
+		LeaseDuration:              &leaseDuration,
+		RenewDeadline:              &renewDeadline,
+		RetryPeriod:                &retryPeriod,
+	}
+	if shard.Count > 1 {
+		// Each shard elects its own leader, the shards run side by side.
+		options.LeaderElectionID = fmt.Sprintf("b78a986e-shard-%d.mattermost.com", shard.ID)
+		logger.Info("Reconciling a shard of the installations", "shard", shard.ID, "shards", shard.Count)
+	}
+	newCache := cache.New
+	switch namespaces := namespacecache.ParseNamespaces(config.WatchNamespace); len(namespaces) {
+	case 0:
+	case 1:
+		options.Namespace = namespaces[0]
+		logger.Info("Watching a single namespace", "namespace", namespaces[0])
+	default:
+		newCache = namespacecache.NewCacheFunc(namespaces)
+		options.NewCache = newCache
+		logger.Info("Watching a list of namespaces", "namespaces", namespaces)
+	}
+	if config.LabelScopedCache {
+		// Only the objects created by the operator are cached for these
+		// types, the client reads them from the API as the objects created
+		// by the users are not cached.
+		scopedObjects := []client.Object{&corev1.Secret{}, &corev1.Service{}, &appsv1.Deployment{}, &batchv1.Job{}}
+		options.NewCache = scopedcache.NewCacheFuncFrom(newCache, mmv1beta.ClusterResourceLabel, scopedObjects...)
+		options.ClientDisableCacheFor = scopedObjects
+	}
+
+	mgr, err := ctrl.NewManager(restConfig, options)
 	if err != nil {
 		logger.Error(err, "Unable to start manager")
 		os.Exit(1)
@@ -97,36 +318,204 @@ func main() {
 
 	logger.Info("Registering Components")
 
+	var namespaces *namespaceselector.Selector
+	if namespaceLabelSelector != nil {
+		namespaces = namespaceselector.NewSelector(mgr.GetClient(), namespaceLabelSelector)
+		logger.Info("Watching the selected namespaces", "selector", namespaceLabelSelector.String())
+	}
+
+	// The application health of the migrated Mattermosts is reported by
+	// the health checks only.
+	migrationVerificationTimeout := config.MigrationVerificationTimeout
+	if config.HealthCheckInterval <= 0 {
+		migrationVerificationTimeout = 0
+	}
 	if err = (&clusterinstallation.ClusterInstallationReconciler{
-		Client:              mgr.GetClient(),
-		NonCachedAPIReader:  mgr.GetAPIReader(),
-		Log:                 ctrl.Log.WithName("controllers").WithName("ClusterInstallation"),
-		Scheme:              mgr.GetScheme(),
-		MaxReconciling:      config.MaxReconcilingInstallations,
-		RequeueOnLimitDelay: config.RequeueOnLimitDelay,
-		Resources:           resources.NewResourceHelper(mgr.GetClient(), mgr.GetScheme()),
+		Client:                       mgr.GetClient(),
+		NonCachedAPIReader:           mgr.GetAPIReader(),
+		Log:                          ctrl.Log.WithName("controllers").WithName("ClusterInstallation"),
+		Scheme:                       mgr.GetScheme(),
+		MaxReconciling:               config.MaxReconcilingInstallations,
+		RequeueOnLimitDelay:          config.RequeueOnLimitDelay,
+		Resources:                    resources.NewResourceHelper(mgr.GetClient(), mgr.GetScheme()),
+		MaxConcurrentReconciles:      clusterInstallationWorkers,
+		RateLimiter:                  utils.NewRateLimiter(errorBackoffBase, errorBackoffCap, errorBackoffJitter),
+		Shard:                        shard,
+		Namespaces:                   namespaces,
+		MigrationVerificationTimeout: migrationVerificationTimeout,
 	}).SetupWithManager(mgr); err != nil {
 		logger.Error(err, "Unable to create controller", "controller", "ClusterInstallation")
 		os.Exit(1)
 	}
-	if err = (&mattermostrestoredb.MattermostRestoreDBReconciler{
-		Client: mgr.GetClient(),
-		Log:    ctrl.Log.WithName("controllers").WithName("MattermostRestoreDB"),
-		Scheme: mgr.GetScheme(),
-	}).SetupWithManager(mgr); err != nil {
-		logger.Error(err, "Unable to create controller", "controller", "MattermostRestoreDB")
-		os.Exit(1)
+	var releasesFeed *releases.Feed
+	if config.ReleasesFeedURL != "" {
+		releasesFeed = releases.NewFeed(config.ReleasesFeedURL, config.ReleasesFeedRefreshInterval)
 	}
-	if err = mattermost.NewMattermostReconciler(
+	mattermostReconciler := mattermost.NewMattermostReconciler(
 		mgr,
 		config.MaxReconcilingInstallations,
 		config.RequeueOnLimitDelay,
-	).
-		SetupWithManager(mgr); err != nil {
+		mattermost.MattermostReconcilerOptions{
+			SupportMatrixConfigMap:  config.SupportMatrixConfigMap,
+			GlobalDefaultsConfigMap: config.GlobalDefaultsConfigMap,
+			ReleasesFeed:            releasesFeed,
+			ImageRegistry:           config.ImageRegistry,
+			UtilityImages: mmv1beta.UtilityImages{
+				Curl:        config.CurlImage,
+				Postgres:    config.PostgresImage,
+				MinioClient: config.MinioClientImage,
+			},
+			HealthCheckInterval:     config.HealthCheckInterval,
+			HealthFailureThreshold:  config.HealthCheckFailureThreshold,
+			NotificationWebhookURL:  config.NotificationWebhookURL,
+			AuditHistoryLimit:       config.AuditHistoryLimit,
+			RevisionHistoryLimit:    config.RevisionHistoryLimit,
+			MaxConcurrentReconciles: mattermostWorkers,
+			RateLimiter:             utils.NewRateLimiter(errorBackoffBase, errorBackoffCap, errorBackoffJitter),
+			Shard:                   shard,
+			Namespaces:              namespaces,
+			MaxConcurrentUpgrades:   config.MaxConcurrentUpgrades,
+			PriorityTierDelay:       config.PriorityTierDelay,
+		},
+	)
+	if err = mattermostReconciler.SetupWithManager(mgr); err != nil {
 		logger.Error(err, "Unable to create controller", "controller", "Mattermost")
 		os.Exit(1)
 	}
 
+	// The backups, restores, fleet upgrades and the sweep of the orphaned
+	// resources are not sharded, they are handled by the first shard.
+	if shard.ID == 0 {
+		if err = (&mattermostrestoredb.MattermostRestoreDBReconciler{
+			Client:     mgr.GetClient(),
+			Log:        ctrl.Log.WithName("controllers").WithName("MattermostRestoreDB"),
+			Scheme:     mgr.GetScheme(),
+			Namespaces: namespaces,
+		}).SetupWithManager(mgr); err != nil {
+			logger.Error(err, "Unable to create controller", "controller", "MattermostRestoreDB")
+			os.Exit(1)
+		}
+		backupReconciler := mattermostbackup.NewMattermostBackupReconciler(mgr)
+		backupReconciler.Namespaces = namespaces
+		if err = backupReconciler.SetupWithManager(mgr); err != nil {
+			logger.Error(err, "Unable to create controller", "controller", "MattermostBackup")
+			os.Exit(1)
+		}
+		restoreReconciler := mattermostrestore.NewMattermostRestoreReconciler(mgr)
+		restoreReconciler.Namespaces = namespaces
+		if err = restoreReconciler.SetupWithManager(mgr); err != nil {
+			logger.Error(err, "Unable to create controller", "controller", "MattermostRestore")
+			os.Exit(1)
+		}
+		upgradeReconciler := mattermostupgrade.NewMattermostUpgradeReconciler(mgr)
+		upgradeReconciler.Namespaces = namespaces
+		if err = upgradeReconciler.SetupWithManager(mgr); err != nil {
+			logger.Error(err, "Unable to create controller", "controller", "MattermostUpgrade")
+			os.Exit(1)
+		}
+
+		if config.OrphanSweepInterval > 0 {
+			sweeper := orphansweep.NewSweeper(mgr, config.OrphanSweepInterval)
+			sweeper.Delete = config.OrphanSweepDelete
+			sweeper.Namespaces = namespacecache.ParseNamespaces(config.WatchNamespace)
+			sweeper.NamespaceSelector = namespaces
+			if err = mgr.Add(sweeper); err != nil {
+				logger.Error(err, "Unable to add orphaned resources sweeper")
+				os.Exit(1)
+			}
+		}
+	}
+
+	if config.ECRCredentialsRefreshInterval > 0 {
+		ecrCredentialsReconciler := ecrcredentials.NewECRCredentialsReconciler(mgr, config.ECRCredentialsRefreshInterval)
+		ecrCredentialsReconciler.Shard = shard
+		ecrCredentialsReconciler.Namespaces = namespaces
+		if err = ecrCredentialsReconciler.SetupWithManager(mgr); err != nil {
+			logger.Error(err, "Unable to create controller", "controller", "ECRCredentials")
+			os.Exit(1)
+		}
+	}
+
+	// OLM sets the name of the OperatorCondition of the operator it
+	// installed, which reports whether the operator can be replaced.
+	if config.OperatorConditionName != "" {
+		namespace, err := operatorNamespace(config.OperatorNamespace)
+		if err != nil {
+			logger.Error(err, "Unable to determine operator namespace")
+			os.Exit(1)
+		}
+		reporter := olm.NewUpgradeableReporter(mgr, namespace, config.OperatorConditionName)
+		reporter.Namespaces = namespacecache.ParseNamespaces(config.WatchNamespace)
+		if err = mgr.Add(reporter); err != nil {
+			logger.Error(err, "Unable to add upgrade readiness reporter")
+			os.Exit(1)
+		}
+	}
+
+	if config.EnableWebhooks {
+		if err = (&mmv1beta.Mattermost{}).SetupWebhookWithManager(mgr); err != nil {
+			logger.Error(err, "Unable to create webhook", "webhook", "Mattermost")
+			os.Exit(1)
+		}
+	}
+
+	if manageWebhookCertificates {
+		namespace, err := operatorNamespace(config.OperatorNamespace)
+		if err != nil {
+			logger.Error(err, "Unable to determine operator namespace")
+			os.Exit(1)
+		}
+		rotator := webhookcert.NewRotator(mgr, namespace, config.WebhookServiceName, config.WebhookCertSecret, webhookCertDir)
+		// The certificates are written before the webhook server starts,
+		// as it fails to start without them.
+		if err = rotator.EnsureCertificates(context.Background()); err != nil {
+			logger.Error(err, "Unable to generate webhook certificates")
+			os.Exit(1)
+		}
+		if err = mgr.Add(rotator); err != nil {
+			logger.Error(err, "Unable to add webhook certificate rotator")
+			os.Exit(1)
+		}
+	}
+
+	if config.MetricsSecureServing && metricsAddr != "0" {
+		metricsServer := metricsserver.NewServer(mgr, metricsAddr)
+		metricsServer.CertDir = config.MetricsCertDir
+		metricsServer.ClientCAFile = config.MetricsClientCAFile
+		metricsServer.DNSNames = []string{"localhost"}
+		if namespace, err := operatorNamespace(config.OperatorNamespace); err == nil {
+			metricsServer.DNSNames = append(webhookcert.DNSNames(config.MetricsServiceName, namespace), metricsServer.DNSNames...)
+		}
+		if !config.MetricsTokenAuthentication {
+			metricsServer.Authenticator = nil
+		}
+		if err = mgr.Add(metricsServer); err != nil {
+			logger.Error(err, "Unable to add metrics server")
+			os.Exit(1)
+		}
+	}
+
+	if diagnosticsAddr != "" {
+		if err = mgr.Add(diagnostics.NewServer(diagnosticsAddr)); err != nil {
+			logger.Error(err, "Unable to add diagnostics server")
+			os.Exit(1)
+		}
+	}
+
+	if config.TracingEndpoint != "" {
+		headers, err := tracing.ParseHeaders(config.TracingHeaders)
+		if err != nil {
+			logger.Error(err, "Unable to parse tracing headers")
+			os.Exit(1)
+		}
+		tracer := tracing.NewTracer(config.TracingEndpoint, headers, config.TracingServiceName, ctrl.Log.WithName("tracing"))
+		if err = mgr.Add(tracer); err != nil {
+			logger.Error(err, "Unable to add tracer")
+			os.Exit(1)
+		}
+		tracing.SetTracer(tracer)
+	}
+
 	// +kubebuilder:scaffold:builder
 
 	logger.Info("Starting manager")
@@ -135,3 +524,16 @@ func main() {
 		os.Exit(1)
 	}
 }
+
+// operatorNamespace returns the configured namespace of the operator,
+// defaulting to the namespace of its service account.
+func operatorNamespace(configured string) (string, error) {
+	if configured != "" {
+		return configured, nil
+	}
+	data, err := ioutil.ReadFile(serviceAccountNamespaceFile)
+	if err != nil {
+		return "", err
+	}
+	return strings.TrimSpace(string(data)), nil
+}
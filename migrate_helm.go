@@ -0,0 +1,100 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"os"
+
+	mmv1beta "github.com/mattermost/mattermost-operator/apis/mattermost/v1beta1"
+	"github.com/mattermost/mattermost-operator/pkg/helmmigration"
+	k8sErrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/runtime/serializer/json"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+// migrateHelmCommand is the subcommand migrating a Helm release of the
+// Mattermost charts to a Mattermost.
+const migrateHelmCommand = "migrate-helm"
+
+// migrateHelm maps the Helm release to a Mattermost adopting its resources,
+// and prints it along with its Secrets, or creates them. It returns the exit
+// code of the subcommand.
+func migrateHelm(args []string) int {
+	flags := flag.NewFlagSet(migrateHelmCommand, flag.ExitOnError)
+	namespace := flags.String("namespace", "", "The namespace of the Helm release.")
+	release := flags.String("release", "", "The name of the Helm release, required if the namespace has several releases of the Mattermost charts.")
+	name := flags.String("name", "", "The name of the Mattermost, the name of the resources of the release by default so that they are adopted.")
+	databaseSecret := flags.String("database-secret", "", "The Secret with the DB_CONNECTION_STRING of the database of the release, if it cannot be mapped from its values.")
+	fileStoreURL := flags.String("file-store-url", "", "The URL of the S3 file store of the release, if it cannot be mapped from its values.")
+	fileStoreBucket := flags.String("file-store-bucket", "", "The bucket of the S3 file store of the release.")
+	fileStoreSecret := flags.String("file-store-secret", "", "The Secret with the accesskey and secretkey of the S3 file store of the release.")
+	apply := flags.Bool("apply", false, "Create the Mattermost and its Secrets instead of printing them.")
+	_ = flags.Parse(args)
+
+	if *namespace == "" {
+		fmt.Fprintln(os.Stderr, "The namespace of the Helm release is required")
+		return 2
+	}
+	options := helmmigration.Options{Name: *name, DatabaseSecret: *databaseSecret}
+	if *fileStoreURL != "" {
+		options.FileStore = &mmv1beta.ExternalFileStore{URL: *fileStoreURL, Bucket: *fileStoreBucket, Secret: *fileStoreSecret}
+	}
+
+	config, err := ctrl.GetConfig()
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Unable to get the Kubernetes client configuration: %s\n", err)
+		return 1
+	}
+	c, err := client.New(config, client.Options{Scheme: scheme})
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Unable to create the Kubernetes client: %s\n", err)
+		return 1
+	}
+
+	ctx := context.Background()
+	helmRelease, err := helmmigration.FindRelease(ctx, c, *namespace, *release)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Unable to find the Helm release: %s\n", err)
+		return 1
+	}
+	migration, err := helmmigration.NewMigration(ctx, c, helmRelease, options)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Unable to migrate the Helm release %s: %s\n", helmRelease.Name, err)
+		return 1
+	}
+	for _, warning := range migration.Warnings {
+		fmt.Fprintf(os.Stderr, "Warning: %s\n", warning)
+	}
+
+	objects := []client.Object{}
+	for _, secret := range migration.Secrets {
+		objects = append(objects, secret)
+	}
+	objects = append(objects, migration.Mattermost)
+
+	serializer := json.NewSerializerWithOptions(json.DefaultMetaFactory, scheme, scheme, json.SerializerOptions{Yaml: true})
+	for _, obj := range objects {
+		if *apply {
+			kind := obj.GetObjectKind().GroupVersionKind().Kind
+			err = c.Create(ctx, obj)
+			if err != nil && k8sErrors.IsAlreadyExists(err) {
+				fmt.Fprintf(os.Stderr, "%s %s already exists\n", kind, obj.GetName())
+				continue
+			} else if err != nil {
+				fmt.Fprintf(os.Stderr, "Unable to create %s %s: %s\n", kind, obj.GetName(), err)
+				return 1
+			}
+			fmt.Fprintf(os.Stderr, "Created %s %s\n", kind, obj.GetName())
+			continue
+		}
+		fmt.Println("---")
+		err = serializer.Encode(obj, os.Stdout)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Unable to print %s: %s\n", obj.GetName(), err)
+			return 1
+		}
+	}
+	return 0
+}
@@ -10,10 +10,12 @@ import (
 	mattermostApp "github.com/mattermost/mattermost-operator/pkg/mattermost"
 
 	minioOperator "github.com/minio/minio-operator/pkg/apis/miniocontroller/v1beta1"
+	minioConstants "github.com/minio/minio-operator/pkg/constants"
 
 	corev1 "k8s.io/api/core/v1"
 	resource "k8s.io/apimachinery/pkg/api/resource"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
 )
 
 // Instance returns the Minio component to deploy
@@ -32,6 +34,7 @@ func Instance(mattermost *mattermostv1alpha1.ClusterInstallation) *minioOperator
 		mattermostApp.ClusterInstallationOwnerReference(mattermost),
 		mattermost.Spec.Minio.Replicas,
 		mattermost.Spec.Minio.StorageSize,
+		"",
 	)
 }
 
@@ -50,16 +53,53 @@ func Secret(mattermost *mattermostv1alpha1.ClusterInstallation) *corev1.Secret {
 
 // Instance returns the Minio component to deploy
 func InstanceV1Beta(mattermost *mmv1beta.Mattermost) *minioOperator.MinIOInstance {
-	minioName := fmt.Sprintf("%s-minio", mattermost.Name)
+	minioName := InstanceName(mattermost.Name)
 
-	return newMinioInstance(
+	instance := newMinioInstance(
 		minioName,
 		mattermost.Namespace,
 		mmv1beta.MattermostResourceLabels(mattermost.Name),
 		mattermostApp.MattermostOwnerReference(mattermost),
 		*mattermost.Spec.FileStore.OperatorManaged.Replicas,
 		mattermost.Spec.FileStore.OperatorManaged.StorageSize,
+		mattermost.Spec.FileStore.OperatorManaged.StorageClassName,
 	)
+
+	// The Minio operator picks its default image unless the image registry
+	// is set.
+	if mattermost.Spec.ImageRegistry != "" {
+		instance.Spec.Image = mattermost.ImageWithRegistry(minioConstants.DefaultMinIOImage)
+	}
+
+	// The metrics are scraped without authentication by the PodMonitor.
+	if mattermost.MonitoringEnabled() {
+		instance.Spec.Env = append(instance.Spec.Env, corev1.EnvVar{Name: "MINIO_PROMETHEUS_AUTH_TYPE", Value: "public"})
+	}
+
+	if mattermostApp.VeleroEnabled(mattermost) {
+		// The pod volumes are named after the volume claim template.
+		instance.Spec.Metadata = &metav1.ObjectMeta{
+			Annotations: mattermostApp.VeleroPodAnnotations(
+				mattermost,
+				mattermost.Spec.VeleroBackups.FileStore,
+				veleroDefaultHooks(),
+				containerName,
+				instance.Spec.VolumeClaimTemplate.Name,
+			),
+		}
+	}
+
+	return instance
+}
+
+// veleroDefaultHooks returns the Velero hooks flushing the file system
+// buffers to disk before the Minio pods are backed up.
+func veleroDefaultHooks() mmv1beta.VeleroHooks {
+	return mmv1beta.VeleroHooks{
+		PreHook: &mmv1beta.VeleroHook{
+			Command: []string{"/bin/sh", "-c", "sync"},
+		},
+	}
 }
 
 // Secret returns the secret name created to use together with Minio deployment
@@ -75,6 +115,15 @@ func SecretV1Beta(mattermost *mmv1beta.Mattermost) *corev1.Secret {
 	)
 }
 
+// containerName is the name of the Minio server container in the pods
+// created by the Minio operator.
+const containerName = "minio"
+
+// InstanceName returns the name of the Minio instance of the installation.
+func InstanceName(installationName string) string {
+	return fmt.Sprintf("%s-minio", installationName)
+}
+
 // DefaultMinioSecretName returns the default minio secret name based on
 // the provided installation name.
 func DefaultMinioSecretName(installationName string) string {
@@ -88,8 +137,9 @@ func newMinioInstance(
 	ownerRefs []metav1.OwnerReference,
 	replicas int32,
 	storageSize string,
+	storageClassName string,
 ) *minioOperator.MinIOInstance {
-	return &minioOperator.MinIOInstance{
+	instance := &minioOperator.MinIOInstance{
 		ObjectMeta: metav1.ObjectMeta{
 			Name:            name,
 			Namespace:       namespace,
@@ -117,6 +167,12 @@ func newMinioInstance(
 			},
 		},
 	}
+
+	if storageClassName != "" {
+		instance.Spec.VolumeClaimTemplate.Spec.StorageClassName = &storageClassName
+	}
+
+	return instance
 }
 
 func minioSecretData() map[string][]byte {
@@ -125,3 +181,21 @@ func minioSecretData() map[string][]byte {
 	data["secretkey"] = utils.New28ID()
 	return data
 }
+
+// PodMonitorV1Beta returns the PodMonitor scraping the metrics of the Minio
+// instance pods.
+func PodMonitorV1Beta(mattermost *mmv1beta.Mattermost) *unstructured.Unstructured {
+	name := InstanceName(mattermost.Name)
+	return mattermostApp.GeneratePodMonitorV1Beta(
+		mattermost,
+		name,
+		mmv1beta.FileStoreComponent,
+		map[string]string{minioConstants.InstanceLabel: name},
+		// The Minio server port is not named. Unstructured objects only
+		// hold JSON values, so the port is an int64.
+		map[string]interface{}{
+			"targetPort": int64(minioConstants.MinIOPort),
+			"path":       "/minio/prometheus/metrics",
+		},
+	)
+}
@@ -0,0 +1,41 @@
+package minio
+
+import (
+	"testing"
+
+	mmv1beta "github.com/mattermost/mattermost-operator/apis/mattermost/v1beta1"
+	"github.com/mattermost/mattermost-operator/pkg/utils"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func TestInstanceV1BetaStorageClass(t *testing.T) {
+	mattermost := &mmv1beta.Mattermost{
+		ObjectMeta: metav1.ObjectMeta{Name: "mm", Namespace: "mm-namespace"},
+		Spec: mmv1beta.MattermostSpec{
+			FileStore: mmv1beta.FileStore{
+				OperatorManaged: &mmv1beta.OperatorManagedMinio{
+					StorageSize: "50Gi",
+					Replicas:    utils.NewInt32(4),
+				},
+			},
+		},
+	}
+
+	t.Run("cluster default", func(t *testing.T) {
+		instance := InstanceV1Beta(mattermost)
+		require.NotNil(t, instance.Spec.VolumeClaimTemplate)
+		assert.Nil(t, instance.Spec.VolumeClaimTemplate.Spec.StorageClassName)
+	})
+
+	t.Run("storage class set", func(t *testing.T) {
+		mattermost := mattermost.DeepCopy()
+		mattermost.Spec.FileStore.OperatorManaged.StorageClassName = "standard-hdd"
+
+		instance := InstanceV1Beta(mattermost)
+		require.NotNil(t, instance.Spec.VolumeClaimTemplate.Spec.StorageClassName)
+		assert.Equal(t, "standard-hdd", *instance.Spec.VolumeClaimTemplate.Spec.StorageClassName)
+		assert.Equal(t, "50Gi", instance.Spec.VolumeClaimTemplate.Spec.Resources.Requests.Storage().String())
+	})
+}
@@ -16,6 +16,7 @@ import (
 	resource "k8s.io/apimachinery/pkg/api/resource"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
 	"k8s.io/apimachinery/pkg/runtime/schema"
 )
 
@@ -73,7 +74,7 @@ func Cluster(mattermost *mattermostv1alpha1.ClusterInstallation) *mysqlOperator.
 func ClusterV1Beta(mattermost *mmv1beta.Mattermost) *mysqlOperator.MysqlCluster {
 	mysql := &mysqlOperator.MysqlCluster{
 		ObjectMeta: metav1.ObjectMeta{
-			Name:            componentUtils.HashWithPrefix("db", mattermost.Name),
+			Name:            ClusterName(mattermost.Name),
 			Namespace:       mattermost.Namespace,
 			Labels:          mmv1beta.MattermostResourceLabels(mattermost.Name),
 			OwnerReferences: mattermostApp.MattermostOwnerReference(mattermost),
@@ -106,11 +107,79 @@ func ClusterV1Beta(mattermost *mmv1beta.Mattermost) *mysqlOperator.MysqlCluster
 		mysql.Spec.InitBucketSecretName = mattermost.Spec.Database.OperatorManaged.BackupRestoreSecretName
 	}
 
+	if storageClassName := mattermost.Spec.Database.OperatorManaged.StorageClassName; storageClassName != "" {
+		mysql.Spec.VolumeSpec.PersistentVolumeClaim.StorageClassName = &storageClassName
+	}
+
+	if mattermostApp.VeleroEnabled(mattermost) {
+		mysql.Spec.PodSpec.Annotations = mattermostApp.VeleroPodAnnotations(
+			mattermost,
+			mattermost.Spec.VeleroBackups.Database,
+			veleroDefaultHooks(),
+			containerName,
+			DataVolumeClaimTemplateName,
+		)
+	}
+
 	return mysql
 }
 
+// veleroDefaultHooks returns the Velero hooks flushing the tables to disk
+// before the database pods are backed up. The MySQL operator stores the
+// client credentials in the MySQL config directory.
+func veleroDefaultHooks() mmv1beta.VeleroHooks {
+	return mmv1beta.VeleroHooks{
+		PreHook: &mmv1beta.VeleroHook{
+			Command: []string{"/bin/sh", "-c", "mysql --defaults-extra-file=/etc/mysql/client.conf -e 'FLUSH TABLES'"},
+		},
+	}
+}
+
+// StatefulSetName returns the name of the StatefulSet created by the MySQL
+// operator for the cluster.
+func StatefulSetName(cluster *mysqlOperator.MysqlCluster) string {
+	return fmt.Sprintf("%s-mysql", cluster.Name)
+}
+
+const (
+	// DataVolumeClaimTemplateName is the name of the volume claim template
+	// used by the MySQL operator for the data volumes.
+	DataVolumeClaimTemplateName = "data"
+
+	// containerName is the name of the MySQL server container in the pods
+	// created by the MySQL operator.
+	containerName = "mysql"
+)
+
+// ClusterName returns the name of the MySQL cluster of the installation.
+func ClusterName(installationName string) string {
+	return componentUtils.HashWithPrefix("db", installationName)
+}
+
 // DefaultDatabaseSecretName returns the default database secret name based on
 // the provided installation name.
 func DefaultDatabaseSecretName(installationName string) string {
 	return fmt.Sprintf("%s-mysql-root-password", installationName)
 }
+
+const (
+	// clusterLabel is the label the MySQL operator sets on the pods of a
+	// MySQL cluster, with the name of the cluster.
+	clusterLabel = "mysql.presslabs.org/cluster"
+	// exporterPortName is the name of the port of the metrics exporter
+	// sidecar of the MySQL cluster pods.
+	exporterPortName = "prometheus"
+)
+
+// PodMonitorV1Beta returns the PodMonitor scraping the metrics exporter of
+// the MySQL cluster pods.
+func PodMonitorV1Beta(mattermost *mmv1beta.Mattermost) *unstructured.Unstructured {
+	name := ClusterName(mattermost.Name)
+	return mattermostApp.GeneratePodMonitorV1Beta(
+		mattermost,
+		name,
+		mmv1beta.DatabaseComponent,
+		map[string]string{clusterLabel: name},
+		map[string]interface{}{"port": exporterPortName, "path": "/metrics"},
+	)
+}
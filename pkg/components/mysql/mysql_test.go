@@ -0,0 +1,42 @@
+package mysql
+
+import (
+	"testing"
+
+	mmv1beta "github.com/mattermost/mattermost-operator/apis/mattermost/v1beta1"
+	"github.com/mattermost/mattermost-operator/pkg/utils"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func TestClusterV1BetaStorageClass(t *testing.T) {
+	mattermost := &mmv1beta.Mattermost{
+		ObjectMeta: metav1.ObjectMeta{Name: "mm", Namespace: "mm-namespace"},
+		Spec: mmv1beta.MattermostSpec{
+			Database: mmv1beta.Database{
+				OperatorManaged: &mmv1beta.OperatorManagedDatabase{
+					Type:        "mysql",
+					StorageSize: "50Gi",
+					Replicas:    utils.NewInt32(2),
+				},
+			},
+		},
+	}
+
+	t.Run("cluster default", func(t *testing.T) {
+		cluster := ClusterV1Beta(mattermost)
+		require.NotNil(t, cluster.Spec.VolumeSpec.PersistentVolumeClaim)
+		assert.Nil(t, cluster.Spec.VolumeSpec.PersistentVolumeClaim.StorageClassName)
+	})
+
+	t.Run("storage class set", func(t *testing.T) {
+		mattermost := mattermost.DeepCopy()
+		mattermost.Spec.Database.OperatorManaged.StorageClassName = "fast-ssd"
+
+		cluster := ClusterV1Beta(mattermost)
+		require.NotNil(t, cluster.Spec.VolumeSpec.PersistentVolumeClaim.StorageClassName)
+		assert.Equal(t, "fast-ssd", *cluster.Spec.VolumeSpec.PersistentVolumeClaim.StorageClassName)
+		assert.Equal(t, "50Gi", cluster.Spec.VolumeSpec.PersistentVolumeClaim.Resources.Requests.Storage().String())
+	})
+}
@@ -0,0 +1,78 @@
+// Package diagnostics serves the pprof profiles and the expvar variables of
+// the operator process.
+package diagnostics
+
+import (
+	"context"
+	"expvar"
+	"net"
+	"net/http"
+	"net/http/pprof"
+	"time"
+
+	"github.com/go-logr/logr"
+	"github.com/pkg/errors"
+	ctrl "sigs.k8s.io/controller-runtime"
+)
+
+const shutdownTimeout = 10 * time.Second
+
+// Server serves the pprof profiles under /debug/pprof/ and the expvar
+// variables under /debug/vars. It is not authenticated, therefore should be
+// bound to localhost and reached with a port-forward.
+type Server struct {
+	// Addr is the address the server binds to.
+	Addr string
+	Log  logr.Logger
+}
+
+// NewServer returns a Server bound to the address.
+func NewServer(addr string) *Server {
+	return &Server{
+		Addr: addr,
+		Log:  ctrl.Log.WithName("diagnostics"),
+	}
+}
+
+// Handler returns the handler of the diagnostics endpoints.
+func Handler() http.Handler {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/debug/pprof/", pprof.Index)
+	mux.HandleFunc("/debug/pprof/cmdline", pprof.Cmdline)
+	mux.HandleFunc("/debug/pprof/profile", pprof.Profile)
+	mux.HandleFunc("/debug/pprof/symbol", pprof.Symbol)
+	mux.HandleFunc("/debug/pprof/trace", pprof.Trace)
+	mux.Handle("/debug/vars", expvar.Handler())
+	return mux
+}
+
+// Start serves the diagnostics endpoints until the context is done.
+func (s *Server) Start(ctx context.Context) error {
+	listener, err := net.Listen("tcp", s.Addr)
+	if err != nil {
+		return errors.Wrapf(err, "failed to listen on diagnostics address %s", s.Addr)
+	}
+
+	server := &http.Server{Handler: Handler()}
+	errs := make(chan error, 1)
+	go func() {
+		s.Log.Info("Serving diagnostics", "addr", s.Addr)
+		errs <- server.Serve(listener)
+	}()
+
+	select {
+	case err = <-errs:
+		return errors.Wrap(err, "failed to serve diagnostics")
+	case <-ctx.Done():
+	}
+
+	shutdownCtx, cancel := context.WithTimeout(context.Background(), shutdownTimeout)
+	defer cancel()
+	return server.Shutdown(shutdownCtx)
+}
+
+// NeedLeaderElection implements manager.LeaderElectionRunnable. Every
+// replica serves its own diagnostics.
+func (s *Server) NeedLeaderElection() bool {
+	return false
+}
@@ -0,0 +1,392 @@
+package helmmigration
+
+import (
+	"context"
+	"fmt"
+	"net/url"
+	"strings"
+
+	mmv1beta "github.com/mattermost/mattermost-operator/apis/mattermost/v1beta1"
+	"github.com/pkg/errors"
+	corev1 "k8s.io/api/core/v1"
+	k8sErrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+// dataMountPath is where the charts mount the volume of the files.
+const dataMountPath = "/mattermost/data"
+
+// Options override the settings of the release the migration does not map.
+type Options struct {
+	// Name is the name of the Mattermost, the name of the resources of the
+	// release by default so that they are adopted.
+	Name string
+	// DatabaseSecret is the Secret with the DB_CONNECTION_STRING of the
+	// database of the release, used instead of the one mapped from its
+	// values.
+	DatabaseSecret string
+	// FileStore is the external file store of the release, used instead of
+	// the one mapped from its values.
+	FileStore *mmv1beta.ExternalFileStore
+}
+
+// Migration is the Mattermost mapped from a Helm release, reusing its
+// database and file store, along with the Secrets it references.
+type Migration struct {
+	Mattermost *mmv1beta.Mattermost
+	Secrets    []*corev1.Secret
+	// Warnings are the settings of the release which are not migrated.
+	Warnings []string
+
+	release *Release
+	values  map[string]interface{}
+	reader  client.Reader
+}
+
+// NewMigration maps the Helm release to a Mattermost adopting its
+// resources. The database and the file store of the release are reused, an
+// error is returned if they cannot be mapped and are not set in the options,
+// as the operator would otherwise create new ones.
+func NewMigration(ctx context.Context, reader client.Reader, release *Release, options Options) (*Migration, error) {
+	m := &Migration{
+		release: release,
+		values:  release.Values(),
+		reader:  reader,
+	}
+
+	name := options.Name
+	if name == "" {
+		name = m.fullName()
+	}
+	m.Mattermost = &mmv1beta.Mattermost{
+		TypeMeta: metav1.TypeMeta{APIVersion: mmv1beta.GroupVersion.String(), Kind: "Mattermost"},
+		ObjectMeta: metav1.ObjectMeta{
+			Name:        name,
+			Namespace:   release.Namespace,
+			Annotations: map[string]string{mmv1beta.AdoptResourcesAnnotation: "true"},
+		},
+	}
+
+	var app []string
+	switch release.Chart.Metadata.Name {
+	case TeamEditionChart:
+	case EnterpriseEditionChart:
+		app = []string{"mattermostApp"}
+	default:
+		return nil, fmt.Errorf("chart %s of release %s is not a Mattermost chart", release.Chart.Metadata.Name, release.Name)
+	}
+
+	err := m.mapApp(app)
+	if err != nil {
+		return nil, err
+	}
+	m.mapIngress()
+	m.mapLicense()
+
+	if options.DatabaseSecret != "" {
+		m.Mattermost.Spec.Database.External = &mmv1beta.ExternalDatabase{Secret: options.DatabaseSecret}
+	} else {
+		err = m.mapDatabase(ctx)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	if options.FileStore != nil {
+		m.Mattermost.Spec.FileStore.External = options.FileStore
+		m.removeFileStoreEnv()
+	} else {
+		err = m.mapFileStore()
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	return m, nil
+}
+
+// fullName returns the name of the resources of the release, like the
+// fullname template of the charts.
+func (m *Migration) fullName() string {
+	if override := lookupString(m.values, "fullnameOverride"); override != "" {
+		return truncateName(override)
+	}
+	name := lookupString(m.values, "nameOverride")
+	if name == "" {
+		name = m.release.Chart.Metadata.Name
+	}
+	if strings.Contains(m.release.Name, name) {
+		return truncateName(m.release.Name)
+	}
+	return truncateName(m.release.Name + "-" + name)
+}
+
+func truncateName(name string) string {
+	if len(name) > 63 {
+		name = name[:63]
+	}
+	return strings.TrimSuffix(name, "-")
+}
+
+// mapApp maps the image, replicas, scheduling and environment of the
+// Mattermost app servers, set under the keys of the app.
+func (m *Migration) mapApp(app []string) error {
+	spec := &m.Mattermost.Spec
+	keys := func(keys ...string) []string {
+		return append(append([]string{}, app...), keys...)
+	}
+
+	spec.Image = lookupString(m.values, keys("image", "repository")...)
+	spec.Version = lookupString(m.values, keys("image", "tag")...)
+	if spec.Version == "" {
+		spec.Version = m.release.Chart.Metadata.AppVersion
+	}
+
+	var replicas int32
+	found, err := lookupInto(m.values, &replicas, keys("replicaCount")...)
+	if err != nil {
+		return err
+	} else if found {
+		spec.Replicas = &replicas
+	}
+
+	for _, value := range []struct {
+		key    string
+		target interface{}
+	}{
+		{"resources", &spec.Scheduling.Resources},
+		{"nodeSelector", &spec.Scheduling.NodeSelector},
+		{"tolerations", &spec.Scheduling.Tolerations},
+		{"affinity", &spec.Scheduling.Affinity},
+		{"extraEnvVars", &spec.MattermostEnv},
+		{"extraEnv", &spec.MattermostEnv},
+	} {
+		_, err = lookupInto(m.values, value.target, keys(value.key)...)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// mapIngress maps the ingress of the release, its first host being the host
+// of the Mattermost.
+func (m *Migration) mapIngress() {
+	if !lookupBool(m.values, "ingress", "enabled") {
+		m.Mattermost.Spec.Ingress = &mmv1beta.Ingress{Enabled: false}
+		return
+	}
+
+	ingress := &mmv1beta.Ingress{Enabled: true}
+	var hosts []interface{}
+	_, _ = lookupInto(m.values, &hosts, "ingress", "hosts")
+	for _, host := range hosts {
+		switch host := host.(type) {
+		case string:
+			ingress.Host = host
+		case map[string]interface{}:
+			ingress.Host = lookupString(host, "host")
+		}
+		if ingress.Host != "" {
+			break
+		}
+	}
+	if len(hosts) > 1 {
+		m.warn("The ingress hosts other than %s are not migrated", ingress.Host)
+	}
+	if ingress.Host == "" {
+		if siteURL, err := url.Parse(lookupString(m.values, "global", "siteUrl")); err == nil {
+			ingress.Host = siteURL.Hostname()
+		}
+	}
+
+	_, _ = lookupInto(m.values, &ingress.Annotations, "ingress", "annotations")
+	var tls []struct {
+		SecretName string `json:"secretName"`
+	}
+	_, _ = lookupInto(m.values, &tls, "ingress", "tls")
+	if len(tls) > 0 {
+		ingress.TLSSecret = tls[0].SecretName
+	}
+	m.Mattermost.Spec.Ingress = ingress
+}
+
+// mapLicense maps the license of the Enterprise Edition to a Secret.
+func (m *Migration) mapLicense() {
+	license := lookupString(m.values, "global", "mattermostLicense")
+	if license == "" {
+		return
+	}
+	secret := m.newSecret("license", map[string]string{"license": license})
+	m.Mattermost.Spec.LicenseSecret = secret.Name
+}
+
+// mapDatabase maps the external database of the release, or its database
+// deployed by the MySQL subchart, to an external database.
+func (m *Migration) mapDatabase(ctx context.Context) error {
+	var connection string
+	switch {
+	case lookupBool(m.values, "externalDB", "enabled"):
+		connection = connectionString(
+			lookupString(m.values, "externalDB", "externalDriverType"),
+			lookupString(m.values, "externalDB", "externalConnectionString"),
+		)
+	case lookupString(m.values, "global", "features", "database", "external", "dataSource") != "" &&
+		!lookupBool(m.values, "global", "features", "database", "useInternal"):
+		connection = connectionString(
+			lookupString(m.values, "global", "features", "database", "external", "driver"),
+			lookupString(m.values, "global", "features", "database", "external", "dataSource"),
+		)
+	case m.release.Chart.Metadata.Name == TeamEditionChart && lookupBool(m.values, "mysql", "enabled"):
+		var err error
+		connection, err = m.mysqlSubchartConnectionString(ctx)
+		if err != nil {
+			return err
+		}
+		m.warn("The MySQL database of the release keeps running, it is not managed by the Operator")
+	}
+	if connection == "" {
+		return errors.New("the database of the release cannot be mapped, the Secret with its connection string is required")
+	}
+
+	secret := m.newSecret("database", map[string]string{"DB_CONNECTION_STRING": connection})
+	m.Mattermost.Spec.Database.External = &mmv1beta.ExternalDatabase{Secret: secret.Name}
+	return nil
+}
+
+// mysqlSubchartConnectionString returns the connection string of the
+// database deployed by the MySQL subchart of the Team Edition chart. The
+// generated password is read from the Secret of the subchart.
+func (m *Migration) mysqlSubchartConnectionString(ctx context.Context) (string, error) {
+	user := firstString(lookupString(m.values, "mysql", "mysqlUser"), lookupString(m.values, "mysql", "auth", "username"))
+	password := firstString(lookupString(m.values, "mysql", "mysqlPassword"), lookupString(m.values, "mysql", "auth", "password"))
+	database := firstString(lookupString(m.values, "mysql", "mysqlDatabase"), lookupString(m.values, "mysql", "auth", "database"))
+	host := fmt.Sprintf("%s-mysql", m.release.Name)
+	if user == "" || database == "" {
+		return "", errors.New("the user and database of the MySQL subchart are not set")
+	}
+
+	if password == "" {
+		secret := &corev1.Secret{}
+		err := m.reader.Get(ctx, types.NamespacedName{Namespace: m.release.Namespace, Name: host}, secret)
+		if err != nil && k8sErrors.IsNotFound(err) {
+			return "", errors.Errorf("the password of the MySQL subchart is not set and its Secret %s does not exist", host)
+		} else if err != nil {
+			return "", errors.Wrap(err, "failed to get the Secret of the MySQL subchart")
+		}
+		password = firstString(string(secret.Data["mysql-password"]), string(secret.Data["password"]))
+		if password == "" {
+			return "", errors.Errorf("the Secret %s of the MySQL subchart has no password", host)
+		}
+	}
+
+	return fmt.Sprintf("mysql://%s:%s@tcp(%s:3306)/%s?charset=utf8mb4,utf8&readTimeout=30s&writeTimeout=30s",
+		user, password, host, database), nil
+}
+
+// mapFileStore maps the S3 file store set in the environment of the release,
+// or the volume of its local file store, which is mounted in the Mattermost
+// pods.
+func (m *Migration) mapFileStore() error {
+	spec := &m.Mattermost.Spec
+	env := map[string]corev1.EnvVar{}
+	for _, envVar := range spec.MattermostEnv {
+		env[envVar.Name] = envVar
+	}
+
+	if env["MM_FILESETTINGS_DRIVERNAME"].Value == "amazons3" {
+		accessKey := env["MM_FILESETTINGS_AMAZONS3ACCESSKEYID"]
+		secretKey := env["MM_FILESETTINGS_AMAZONS3SECRETACCESSKEY"]
+		if accessKey.ValueFrom != nil || secretKey.ValueFrom != nil {
+			return errors.New("the credentials of the S3 file store of the release are read from Secrets, the external file store is required")
+		}
+		endpoint := firstString(env["MM_FILESETTINGS_AMAZONS3ENDPOINT"].Value, "s3.amazonaws.com")
+		secret := m.newSecret("filestore", map[string]string{"accesskey": accessKey.Value, "secretkey": secretKey.Value})
+		spec.FileStore.External = &mmv1beta.ExternalFileStore{
+			URL:    endpoint,
+			Bucket: env["MM_FILESETTINGS_AMAZONS3BUCKET"].Value,
+			Secret: secret.Name,
+		}
+		m.removeFileStoreEnv()
+		return nil
+	}
+
+	if m.release.Chart.Metadata.Name == TeamEditionChart && lookupBool(m.values, "persistence", "data", "enabled") {
+		claim := firstString(lookupString(m.values, "persistence", "data", "existingClaim"), m.fullName())
+		spec.Volumes = append(spec.Volumes, corev1.Volume{
+			Name: "mattermost-data",
+			VolumeSource: corev1.VolumeSource{
+				PersistentVolumeClaim: &corev1.PersistentVolumeClaimVolumeSource{ClaimName: claim},
+			},
+		})
+		spec.VolumeMounts = append(spec.VolumeMounts, corev1.VolumeMount{Name: "mattermost-data", MountPath: dataMountPath})
+		m.removeFileStoreEnv()
+		spec.MattermostEnv = append(spec.MattermostEnv,
+			corev1.EnvVar{Name: "MM_FILESETTINGS_DRIVERNAME", Value: "local"},
+			corev1.EnvVar{Name: "MM_FILESETTINGS_DIRECTORY", Value: dataMountPath + "/"},
+		)
+		m.warn("Mattermost keeps storing its files on the volume %s, the file store managed by the Operator is created but not used", claim)
+		if lookupBool(m.values, "persistence", "plugins", "enabled") {
+			m.warn("The volume of the plugins is not migrated, Mattermost extracts the plugins again from its file store")
+		}
+		return nil
+	}
+
+	return errors.New("the file store of the release cannot be mapped, the external file store is required")
+}
+
+// removeFileStoreEnv removes the file store settings from the environment of
+// the Mattermost, which the operator sets.
+func (m *Migration) removeFileStoreEnv() {
+	var env []corev1.EnvVar
+	for _, envVar := range m.Mattermost.Spec.MattermostEnv {
+		if !strings.HasPrefix(envVar.Name, "MM_FILESETTINGS_") {
+			env = append(env, envVar)
+		}
+	}
+	m.Mattermost.Spec.MattermostEnv = env
+}
+
+// newSecret adds a Secret of the Mattermost with the data.
+func (m *Migration) newSecret(suffix string, data map[string]string) *corev1.Secret {
+	secret := &corev1.Secret{
+		TypeMeta: metav1.TypeMeta{APIVersion: "v1", Kind: "Secret"},
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      fmt.Sprintf("%s-%s", m.Mattermost.Name, suffix),
+			Namespace: m.Mattermost.Namespace,
+		},
+		StringData: data,
+	}
+	m.Secrets = append(m.Secrets, secret)
+	return secret
+}
+
+func (m *Migration) warn(format string, args ...interface{}) {
+	m.Warnings = append(m.Warnings, fmt.Sprintf(format, args...))
+}
+
+// connectionString returns the connection string of the database, with the
+// scheme of its driver.
+func connectionString(driver, connection string) string {
+	if connection == "" || strings.Contains(connection, "://") {
+		return connection
+	}
+	switch driver {
+	case "postgres", "postgresql":
+		return "postgres://" + connection
+	case "mysql":
+		return "mysql://" + connection
+	}
+	return connection
+}
+
+func firstString(values ...string) string {
+	for _, value := range values {
+		if value != "" {
+			return value
+		}
+	}
+	return ""
+}
@@ -0,0 +1,204 @@
+// Package helmmigration maps the Helm releases of the Mattermost charts to
+// Mattermost resources, so that the installations deployed with Helm are
+// migrated under the management of the operator.
+package helmmigration
+
+import (
+	"bytes"
+	"compress/gzip"
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+
+	"github.com/pkg/errors"
+	corev1 "k8s.io/api/core/v1"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+const (
+	// TeamEditionChart is the chart of the Mattermost Team Edition.
+	TeamEditionChart = "mattermost-team-edition"
+	// EnterpriseEditionChart is the chart of the Mattermost Enterprise
+	// Edition.
+	EnterpriseEditionChart = "mattermost-enterprise-edition"
+
+	// releaseSecretType is the type of the Secrets Helm 3 stores the
+	// releases in.
+	releaseSecretType = "helm.sh/release.v1"
+)
+
+// Release is a Helm release, as stored by Helm 3.
+type Release struct {
+	Name      string `json:"name"`
+	Namespace string `json:"namespace"`
+	Version   int    `json:"version"`
+	Info      struct {
+		Status string `json:"status"`
+	} `json:"info"`
+	Chart struct {
+		Metadata struct {
+			Name       string `json:"name"`
+			Version    string `json:"version"`
+			AppVersion string `json:"appVersion"`
+		} `json:"metadata"`
+		// Values are the default values of the chart.
+		Values map[string]interface{} `json:"values"`
+	} `json:"chart"`
+	// Config are the values the release was installed or upgraded with.
+	Config map[string]interface{} `json:"config"`
+}
+
+// Values returns the values of the release, the default values of the chart
+// merged with the values it was installed or upgraded with.
+func (r *Release) Values() map[string]interface{} {
+	return mergeValues(r.Chart.Values, r.Config)
+}
+
+// FindRelease returns the last deployed revision of the Helm release in the
+// namespace, or of the only release of the Mattermost charts if the name is
+// empty.
+func FindRelease(ctx context.Context, reader client.Reader, namespace, name string) (*Release, error) {
+	labels := client.MatchingLabels{"owner": "helm", "status": "deployed"}
+	if name != "" {
+		labels["name"] = name
+	}
+	var secrets corev1.SecretList
+	err := reader.List(ctx, &secrets, client.InNamespace(namespace), labels)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to list the Helm releases")
+	}
+
+	releases := map[string]*Release{}
+	for _, secret := range secrets.Items {
+		if secret.Type != releaseSecretType {
+			continue
+		}
+		release, err := DecodeRelease(secret.Data["release"])
+		if err != nil {
+			return nil, errors.Wrapf(err, "failed to decode the Helm release Secret %s", secret.Name)
+		}
+		chart := release.Chart.Metadata.Name
+		if chart != TeamEditionChart && chart != EnterpriseEditionChart {
+			continue
+		}
+		if last, ok := releases[release.Name]; !ok || release.Version > last.Version {
+			releases[release.Name] = release
+		}
+	}
+
+	switch len(releases) {
+	case 0:
+		if name != "" {
+			return nil, fmt.Errorf("no deployed Helm release %s of the Mattermost charts in namespace %s", name, namespace)
+		}
+		return nil, fmt.Errorf("no deployed Helm release of the Mattermost charts in namespace %s", namespace)
+	case 1:
+		for _, release := range releases {
+			return release, nil
+		}
+	}
+	return nil, fmt.Errorf("%d Helm releases of the Mattermost charts in namespace %s, the release name is required", len(releases), namespace)
+}
+
+// DecodeRelease decodes the release of a Helm release Secret, base64
+// encoded and compressed with gzip by Helm.
+func DecodeRelease(data []byte) (*Release, error) {
+	decoded := make([]byte, base64.StdEncoding.DecodedLen(len(data)))
+	n, err := base64.StdEncoding.Decode(decoded, data)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to decode the release")
+	}
+	decoded = decoded[:n]
+
+	if bytes.HasPrefix(decoded, []byte{0x1f, 0x8b}) {
+		reader, err := gzip.NewReader(bytes.NewReader(decoded))
+		if err != nil {
+			return nil, errors.Wrap(err, "failed to decompress the release")
+		}
+		defer reader.Close()
+		decoded, err = ioutil.ReadAll(reader)
+		if err != nil {
+			return nil, errors.Wrap(err, "failed to decompress the release")
+		}
+	}
+
+	release := &Release{}
+	err = json.Unmarshal(decoded, release)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to unmarshal the release")
+	}
+	return release, nil
+}
+
+// mergeValues returns the values overridden by the overrides, merging the
+// nested maps like Helm.
+func mergeValues(values, overrides map[string]interface{}) map[string]interface{} {
+	merged := map[string]interface{}{}
+	for key, value := range values {
+		merged[key] = value
+	}
+	for key, override := range overrides {
+		overrideMap, isMap := override.(map[string]interface{})
+		valueMap, wasMap := merged[key].(map[string]interface{})
+		if isMap && wasMap {
+			merged[key] = mergeValues(valueMap, overrideMap)
+			continue
+		}
+		merged[key] = override
+	}
+	return merged
+}
+
+// lookup returns the value at the path of keys in the values, nil if it is
+// not set.
+func lookup(values map[string]interface{}, keys ...string) interface{} {
+	var value interface{} = values
+	for _, key := range keys {
+		object, ok := value.(map[string]interface{})
+		if !ok {
+			return nil
+		}
+		value = object[key]
+	}
+	return value
+}
+
+func lookupString(values map[string]interface{}, keys ...string) string {
+	switch value := lookup(values, keys...).(type) {
+	case string:
+		return value
+	case float64:
+		return fmt.Sprint(value)
+	}
+	return ""
+}
+
+func lookupBool(values map[string]interface{}, keys ...string) bool {
+	value, _ := lookup(values, keys...).(bool)
+	return value
+}
+
+func lookupMap(values map[string]interface{}, keys ...string) map[string]interface{} {
+	value, _ := lookup(values, keys...).(map[string]interface{})
+	return value
+}
+
+// lookupInto unmarshals the value at the path of keys into the target,
+// returning false if it is not set.
+func lookupInto(values map[string]interface{}, target interface{}, keys ...string) (bool, error) {
+	value := lookup(values, keys...)
+	if value == nil {
+		return false, nil
+	}
+	data, err := json.Marshal(value)
+	if err != nil {
+		return false, err
+	}
+	err = json.Unmarshal(data, target)
+	if err != nil {
+		return false, errors.Wrapf(err, "invalid value of %v", keys)
+	}
+	return true, nil
+}
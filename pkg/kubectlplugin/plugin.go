@@ -0,0 +1,270 @@
+// Package kubectlplugin implements the commands of the kubectl mattermost
+// plugin, operating the Mattermosts by their name instead of the label
+// selectors of their resources.
+package kubectlplugin
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"io"
+	"sync"
+	"text/tabwriter"
+	"time"
+
+	"github.com/go-logr/logr"
+	mmv1beta "github.com/mattermost/mattermost-operator/apis/mattermost/v1beta1"
+	mattermostApp "github.com/mattermost/mattermost-operator/pkg/mattermost"
+	"github.com/mattermost/mattermost-operator/pkg/mattermost/healthcheck"
+	"github.com/mattermost/mattermost-operator/version"
+	"github.com/pkg/errors"
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/kubernetes"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+// Plugin runs the commands against the Mattermosts of a namespace.
+type Plugin struct {
+	Client client.Client
+	// Clientset streams the logs of the pods.
+	Clientset kubernetes.Interface
+	Namespace string
+	Out       io.Writer
+	Logger    logr.Logger
+}
+
+// LogOptions are the options of the logs command.
+type LogOptions struct {
+	Follow    bool
+	Previous  bool
+	TailLines *int64
+}
+
+// Status prints the status of the Mattermost reported by the operator.
+func (p *Plugin) Status(ctx context.Context, name string) error {
+	mattermost, err := p.getMattermost(ctx, name)
+	if err != nil {
+		return err
+	}
+	status := mattermost.Status
+
+	w := tabwriter.NewWriter(p.Out, 0, 4, 2, ' ', 0)
+	fmt.Fprintf(w, "Name:\t%s\n", mattermost.Name)
+	fmt.Fprintf(w, "Namespace:\t%s\n", mattermost.Namespace)
+	fmt.Fprintf(w, "State:\t%s\n", status.State)
+	if mattermostApp.IsPaused(mattermost) {
+		fmt.Fprintf(w, "Paused:\ttrue\n")
+	}
+	fmt.Fprintf(w, "Version:\t%s\n", status.Version)
+	fmt.Fprintf(w, "Image:\t%s\n", status.Image)
+	fmt.Fprintf(w, "Replicas:\t%d ready and updated, %d running, %d desired\n", status.UpdatedReplicas, status.Replicas, status.DesiredReplicas)
+	fmt.Fprintf(w, "Endpoint:\t%s\n", status.Endpoint)
+	if status.ObservedGeneration != mattermost.Generation {
+		fmt.Fprintf(w, "Observed generation:\t%d of %d\n", status.ObservedGeneration, mattermost.Generation)
+	}
+	if status.LastError != nil {
+		fmt.Fprintf(w, "Last error:\t%s: %s\n", status.LastError.Reason, status.LastError.Message)
+	}
+	if len(status.Conditions) > 0 {
+		fmt.Fprintf(w, "Conditions:\n")
+		fmt.Fprintf(w, "  TYPE\tSTATUS\tREASON\tMESSAGE\n")
+		for _, condition := range status.Conditions {
+			fmt.Fprintf(w, "  %s\t%s\t%s\t%s\n", condition.Type, condition.Status, condition.Reason, condition.Message)
+		}
+	}
+	return w.Flush()
+}
+
+// Health checks the pods of the Mattermost the way the operator does, and
+// prints the results along with the last application health check of the
+// operator. Mattermost is healthy if its pods run the image of its
+// deployment, are ready and have no problem.
+func (p *Plugin) Health(ctx context.Context, name string) (bool, error) {
+	mattermost, err := p.getMattermost(ctx, name)
+	if err != nil {
+		return false, err
+	}
+
+	deployment := &appsv1.Deployment{}
+	err = p.Client.Get(ctx, types.NamespacedName{Namespace: mattermost.Namespace, Name: mattermost.GetProductionDeploymentName()}, deployment)
+	if err != nil {
+		return false, errors.Wrap(err, "failed to get the Mattermost deployment")
+	}
+	container := mmv1beta.GetMattermostAppContainerFromDeployment(deployment)
+	if container == nil {
+		return false, errors.Errorf("the deployment %s does not have the Mattermost container", deployment.Name)
+	}
+	replicas := int32(1)
+	if deployment.Spec.Replicas != nil {
+		replicas = *deployment.Spec.Replicas
+	}
+
+	listOptions := []client.ListOption{
+		client.InNamespace(mattermost.Namespace),
+		client.MatchingLabels(mattermost.MattermostLabels(deployment.Name)),
+	}
+	checker := healthcheck.NewHealthChecker(p.Client, listOptions, p.Logger)
+	rollout, err := checker.CheckPodsRollOut(container.Image)
+	if err != nil {
+		return false, errors.Wrap(err, "failed to check the Mattermost pods")
+	}
+	problems, err := checker.CheckPodProblems()
+	if err != nil {
+		return false, errors.Wrap(err, "failed to check the problems of the Mattermost pods")
+	}
+
+	healthy := rollout.UpdatedReplicas == replicas && rollout.Replicas == replicas
+	w := tabwriter.NewWriter(p.Out, 0, 4, 2, ' ', 0)
+	fmt.Fprintf(w, "Image:\t%s\n", container.Image)
+	fmt.Fprintf(w, "Pods:\t%d ready with the image, %d running, %d desired\n", rollout.UpdatedReplicas, rollout.Replicas, replicas)
+	for _, problem := range []*healthcheck.PodProblem{problems.CrashLoop, problems.ImagePull, problems.Unschedulable} {
+		if problem != nil {
+			healthy = false
+			fmt.Fprintf(w, "Problem:\t%s\n", problem)
+		}
+	}
+	if health := mattermost.Status.ApplicationHealth; health != nil && health.LastCheckTime != nil {
+		result := "passed"
+		if health.ConsecutiveFailures > 0 {
+			healthy = false
+			result = fmt.Sprintf("failed %d times in a row: %s", health.ConsecutiveFailures, health.Error)
+		}
+		fmt.Fprintf(w, "Application health:\t%s, checked at %s\n", result, health.LastCheckTime.UTC().Format(time.RFC3339))
+	}
+	if healthy {
+		fmt.Fprintf(w, "Healthy:\ttrue\n")
+	} else {
+		fmt.Fprintf(w, "Healthy:\tfalse\n")
+	}
+	return healthy, w.Flush()
+}
+
+// Restart requests a rolling restart of the Mattermost pods with its restart
+// annotation, so that the operator restarts them instead of reverting the
+// changes made to its deployment.
+func (p *Plugin) Restart(ctx context.Context, name string, restartTime time.Time) error {
+	mattermost, err := p.getMattermost(ctx, name)
+	if err != nil {
+		return err
+	}
+
+	patch := client.MergeFrom(mattermost.DeepCopy())
+	if mattermost.Annotations == nil {
+		mattermost.Annotations = map[string]string{}
+	}
+	mattermost.Annotations[mattermostApp.RestartAnnotation] = restartTime.UTC().Format(time.RFC3339)
+	err = p.Client.Patch(ctx, mattermost, patch)
+	if err != nil {
+		return errors.Wrap(err, "failed to request the restart of the Mattermost")
+	}
+
+	fmt.Fprintf(p.Out, "Restart of Mattermost %s requested\n", mattermost.Name)
+	if mattermostApp.IsPaused(mattermost) {
+		fmt.Fprintf(p.Out, "The reconciliation of Mattermost %s is paused, its pods are restarted once it is resumed\n", mattermost.Name)
+	}
+	return nil
+}
+
+// Logs prints the logs of the Mattermost containers of the pods of the
+// Mattermost, each line prefixed with the name of its pod.
+func (p *Plugin) Logs(ctx context.Context, name string, options LogOptions) error {
+	mattermost, err := p.getMattermost(ctx, name)
+	if err != nil {
+		return err
+	}
+
+	pods := &corev1.PodList{}
+	err = p.Client.List(ctx, pods,
+		client.InNamespace(mattermost.Namespace),
+		client.MatchingLabels(mattermost.MattermostLabels(mattermost.GetProductionDeploymentName())),
+	)
+	if err != nil {
+		return errors.Wrap(err, "failed to list the Mattermost pods")
+	}
+	if len(pods.Items) == 0 {
+		return errors.Errorf("Mattermost %s has no pods", mattermost.Name)
+	}
+
+	var lock sync.Mutex
+	var wg sync.WaitGroup
+	errs := make([]error, len(pods.Items))
+	for i, pod := range pods.Items {
+		wg.Add(1)
+		go func(i int, pod corev1.Pod) {
+			defer wg.Done()
+			errs[i] = p.streamLogs(ctx, pod.Name, options, &lock)
+		}(i, pod)
+	}
+	wg.Wait()
+
+	for _, err := range errs {
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// streamLogs prints the logs of the Mattermost container of the pod.
+func (p *Plugin) streamLogs(ctx context.Context, pod string, options LogOptions, lock *sync.Mutex) error {
+	stream, err := p.Clientset.CoreV1().Pods(p.Namespace).GetLogs(pod, &corev1.PodLogOptions{
+		Container: mmv1beta.MattermostAppContainerName,
+		Follow:    options.Follow,
+		Previous:  options.Previous,
+		TailLines: options.TailLines,
+	}).Stream(ctx)
+	if err != nil {
+		return errors.Wrapf(err, "failed to get the logs of pod %s", pod)
+	}
+	defer stream.Close()
+
+	scanner := bufio.NewScanner(stream)
+	scanner.Buffer(make([]byte, 64*1024), 1024*1024)
+	for scanner.Scan() {
+		lock.Lock()
+		fmt.Fprintf(p.Out, "[%s] %s\n", pod, scanner.Text())
+		lock.Unlock()
+	}
+	return errors.Wrapf(scanner.Err(), "failed to read the logs of pod %s", pod)
+}
+
+// Version prints the version of the Mattermost, the updates available to it
+// and the version of the plugin.
+func (p *Plugin) Version(ctx context.Context, name string) error {
+	mattermost, err := p.getMattermost(ctx, name)
+	if err != nil {
+		return err
+	}
+	status := mattermost.Status
+
+	w := tabwriter.NewWriter(p.Out, 0, 4, 2, ' ', 0)
+	fmt.Fprintf(w, "Running version:\t%s\n", status.Version)
+	fmt.Fprintf(w, "Desired version:\t%s\n", mattermost.Spec.Version)
+	if upgrade := status.Upgrade; upgrade != nil {
+		fmt.Fprintf(w, "Upgrade:\t%s from %s to %s\n", upgrade.State, upgrade.FromImage, upgrade.ToImage)
+	}
+	if pending := status.PendingUpdate; pending != nil {
+		fmt.Fprintf(w, "Pending update:\t%s (%s)\n", pending.Image, pending.Reason)
+	}
+	if available := status.AvailableUpdate; available != nil {
+		if available.LatestPatch != "" {
+			fmt.Fprintf(w, "Latest patch:\t%s\n", available.LatestPatch)
+		}
+		if available.LatestESR != "" {
+			fmt.Fprintf(w, "Latest ESR:\t%s\n", available.LatestESR)
+		}
+	}
+	fmt.Fprintf(w, "Plugin version:\t%s\n", version.GetVersion())
+	return w.Flush()
+}
+
+func (p *Plugin) getMattermost(ctx context.Context, name string) (*mmv1beta.Mattermost, error) {
+	mattermost := &mmv1beta.Mattermost{}
+	err := p.Client.Get(ctx, types.NamespacedName{Namespace: p.Namespace, Name: name}, mattermost)
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to get Mattermost %s", name)
+	}
+	return mattermost, nil
+}
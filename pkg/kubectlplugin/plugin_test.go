@@ -0,0 +1,132 @@
+package kubectlplugin
+
+import (
+	"bytes"
+	"context"
+	"testing"
+	"time"
+
+	blubr "github.com/mattermost/blubr"
+	mmv1beta "github.com/mattermost/mattermost-operator/apis/mattermost/v1beta1"
+	mattermostApp "github.com/mattermost/mattermost-operator/pkg/mattermost"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	clientgoscheme "k8s.io/client-go/kubernetes/scheme"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+)
+
+func TestPlugin(t *testing.T) {
+	s := runtime.NewScheme()
+	require.NoError(t, clientgoscheme.AddToScheme(s))
+	require.NoError(t, mmv1beta.AddToScheme(s))
+
+	image := "mattermost/mattermost-enterprise-edition:7.8.0"
+	replicas := int32(2)
+	mattermost := &mmv1beta.Mattermost{
+		ObjectMeta: metav1.ObjectMeta{Name: "chat", Namespace: "team", Generation: 2},
+		Spec:       mmv1beta.MattermostSpec{Image: "mattermost/mattermost-enterprise-edition", Version: "7.8.0"},
+		Status: mmv1beta.MattermostStatus{
+			State:              mmv1beta.Stable,
+			Version:            "7.8.0",
+			Image:              "mattermost/mattermost-enterprise-edition",
+			Endpoint:           "chat.example.com",
+			Replicas:           2,
+			UpdatedReplicas:    2,
+			DesiredReplicas:    2,
+			ObservedGeneration: 2,
+			AvailableUpdate:    &mmv1beta.AvailableUpdateStatus{LatestPatch: "7.8.2"},
+			Conditions: []metav1.Condition{
+				{Type: mmv1beta.ReadyCondition, Status: metav1.ConditionTrue, Reason: "Stable", Message: "The Mattermost is stable"},
+			},
+		},
+	}
+	deployment := &appsv1.Deployment{
+		ObjectMeta: metav1.ObjectMeta{Name: "chat", Namespace: "team"},
+		Spec: appsv1.DeploymentSpec{
+			Replicas: &replicas,
+			Template: corev1.PodTemplateSpec{Spec: corev1.PodSpec{
+				Containers: []corev1.Container{{Name: mmv1beta.MattermostAppContainerName, Image: image}},
+			}},
+		},
+	}
+	newPod := func(name string, ready corev1.ConditionStatus) *corev1.Pod {
+		return &corev1.Pod{
+			ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: "team", Labels: mattermost.MattermostLabels("chat")},
+			Spec:       corev1.PodSpec{Containers: []corev1.Container{{Name: mmv1beta.MattermostAppContainerName, Image: image}}},
+			Status: corev1.PodStatus{
+				Phase:      corev1.PodRunning,
+				Conditions: []corev1.PodCondition{{Type: corev1.PodReady, Status: ready}},
+			},
+		}
+	}
+
+	c := fake.NewClientBuilder().WithScheme(s).WithObjects(
+		mattermost, deployment, newPod("chat-1", corev1.ConditionTrue), newPod("chat-2", corev1.ConditionTrue),
+	).Build()
+	out := &bytes.Buffer{}
+	plugin := &Plugin{Client: c, Namespace: "team", Out: out, Logger: blubr.InitLogger()}
+
+	t.Run("status", func(t *testing.T) {
+		out.Reset()
+		require.NoError(t, plugin.Status(context.TODO(), "chat"))
+		assert.Contains(t, out.String(), "State:      stable")
+		assert.Contains(t, out.String(), "Replicas:   2 ready and updated, 2 running, 2 desired")
+		assert.Contains(t, out.String(), "Ready  True    Stable  The Mattermost is stable")
+		assert.NotContains(t, out.String(), "Observed generation")
+	})
+
+	t.Run("version", func(t *testing.T) {
+		out.Reset()
+		require.NoError(t, plugin.Version(context.TODO(), "chat"))
+		assert.Contains(t, out.String(), "Running version:  7.8.0")
+		assert.Contains(t, out.String(), "Latest patch:     7.8.2")
+	})
+
+	t.Run("healthy", func(t *testing.T) {
+		out.Reset()
+		healthy, err := plugin.Health(context.TODO(), "chat")
+		require.NoError(t, err)
+		assert.True(t, healthy)
+		assert.Contains(t, out.String(), "Pods:     2 ready with the image, 2 running, 2 desired")
+	})
+
+	t.Run("unhealthy", func(t *testing.T) {
+		pod := &corev1.Pod{}
+		require.NoError(t, c.Get(context.TODO(), types.NamespacedName{Namespace: "team", Name: "chat-2"}, pod))
+		pod.Status.Conditions[0].Status = corev1.ConditionFalse
+		pod.Status.ContainerStatuses = []corev1.ContainerStatus{{
+			Name:  mmv1beta.MattermostAppContainerName,
+			State: corev1.ContainerState{Waiting: &corev1.ContainerStateWaiting{Reason: "CrashLoopBackOff", Message: "back-off restarting"}},
+		}}
+		require.NoError(t, c.Status().Update(context.TODO(), pod))
+
+		out.Reset()
+		healthy, err := plugin.Health(context.TODO(), "chat")
+		require.NoError(t, err)
+		assert.False(t, healthy)
+		assert.Contains(t, out.String(), "pod chat-2 container mattermost: CrashLoopBackOff: back-off restarting")
+	})
+
+	t.Run("restart", func(t *testing.T) {
+		out.Reset()
+		restartTime := time.Date(2021, 6, 1, 10, 0, 0, 0, time.UTC)
+		require.NoError(t, plugin.Restart(context.TODO(), "chat", restartTime))
+
+		current := &mmv1beta.Mattermost{}
+		require.NoError(t, c.Get(context.TODO(), types.NamespacedName{Namespace: "team", Name: "chat"}, current))
+		requested, ok := mattermostApp.RestartRequestedAt(current)
+		assert.True(t, ok)
+		assert.Equal(t, restartTime, requested)
+		assert.Equal(t, "Restart of Mattermost chat requested\n", out.String())
+	})
+
+	t.Run("not found", func(t *testing.T) {
+		err := plugin.Status(context.TODO(), "missing")
+		assert.Contains(t, err.Error(), "failed to get Mattermost missing")
+	})
+}
@@ -0,0 +1,106 @@
+// Package logging filters the logs of the operator by verbosity, which can
+// be raised for a single installation.
+package logging
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/go-logr/logr"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// LogLevelAnnotation raises the verbosity of the logs of the reconciliations
+// of an installation, ie to "debug".
+const LogLevelAnnotation = "installation.mattermost.com/log-level"
+
+// levels are the names of the verbosity levels, higher levels log more.
+var levels = map[string]int{
+	"info":  0,
+	"debug": 1,
+	"trace": 2,
+}
+
+// ParseLevel returns the verbosity of the level name, or of its non-negative
+// number. An empty level is the info level.
+func ParseLevel(level string) (int, error) {
+	level = strings.ToLower(strings.TrimSpace(level))
+	if level == "" {
+		return 0, nil
+	}
+	if verbosity, ok := levels[level]; ok {
+		return verbosity, nil
+	}
+	verbosity, err := strconv.Atoi(level)
+	if err != nil || verbosity < 0 {
+		return 0, fmt.Errorf("invalid log level %q, expected info, debug, trace or a non-negative number", level)
+	}
+	return verbosity, nil
+}
+
+// levelLogger drops the info logs of a higher level than its verbosity.
+// Errors are always logged.
+type levelLogger struct {
+	sink      logr.Logger
+	verbosity int
+	level     int
+}
+
+// NewLogger returns a logger logging the info logs up to the verbosity to
+// the sink.
+func NewLogger(sink logr.Logger, verbosity int) logr.Logger {
+	return &levelLogger{sink: sink, verbosity: verbosity}
+}
+
+func (l *levelLogger) Enabled() bool {
+	return l.level <= l.verbosity && l.sink.Enabled()
+}
+
+func (l *levelLogger) Info(msg string, keysAndValues ...interface{}) {
+	if l.Enabled() {
+		l.sink.Info(msg, keysAndValues...)
+	}
+}
+
+func (l *levelLogger) Error(err error, msg string, keysAndValues ...interface{}) {
+	l.sink.Error(err, msg, keysAndValues...)
+}
+
+func (l *levelLogger) V(level int) logr.Logger {
+	return &levelLogger{sink: l.sink, verbosity: l.verbosity, level: l.level + level}
+}
+
+func (l *levelLogger) WithValues(keysAndValues ...interface{}) logr.Logger {
+	return &levelLogger{sink: l.sink.WithValues(keysAndValues...), verbosity: l.verbosity, level: l.level}
+}
+
+func (l *levelLogger) WithName(name string) logr.Logger {
+	return &levelLogger{sink: l.sink.WithName(name), verbosity: l.verbosity, level: l.level}
+}
+
+// WithVerbosity returns the logger with its verbosity raised to the given
+// one. Loggers which are not returned by NewLogger are returned unchanged.
+func WithVerbosity(log logr.Logger, verbosity int) logr.Logger {
+	l, ok := log.(*levelLogger)
+	if !ok || verbosity <= l.verbosity {
+		return log
+	}
+	return &levelLogger{sink: l.sink, verbosity: verbosity, level: l.level}
+}
+
+// ForObject returns the logger with its verbosity raised to the log level
+// annotation of the object, if any. An invalid annotation is logged and
+// ignored.
+func ForObject(log logr.Logger, obj metav1.Object) logr.Logger {
+	level, ok := obj.GetAnnotations()[LogLevelAnnotation]
+	if !ok {
+		return log
+	}
+	verbosity, err := ParseLevel(level)
+	if err != nil {
+		log.Error(err, "Ignoring the log level annotation", "annotation", LogLevelAnnotation)
+		return log
+	}
+	return WithVerbosity(log, verbosity)
+}
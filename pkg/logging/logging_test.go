@@ -0,0 +1,86 @@
+package logging
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/go-logr/logr"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// recordingLogger records the messages and values it logs.
+type recordingLogger struct {
+	values  []interface{}
+	records *[]string
+}
+
+func (l *recordingLogger) Enabled() bool { return true }
+
+func (l *recordingLogger) Info(msg string, _ ...interface{}) {
+	*l.records = append(*l.records, msg)
+}
+
+func (l *recordingLogger) Error(_ error, msg string, _ ...interface{}) {
+	*l.records = append(*l.records, msg)
+}
+
+func (l *recordingLogger) V(_ int) logr.Logger { return l }
+
+func (l *recordingLogger) WithValues(keysAndValues ...interface{}) logr.Logger {
+	return &recordingLogger{values: append(append([]interface{}{}, l.values...), keysAndValues...), records: l.records}
+}
+
+func (l *recordingLogger) WithName(_ string) logr.Logger { return l }
+
+func TestParseLevel(t *testing.T) {
+	for level, expected := range map[string]int{"": 0, "info": 0, "Debug": 1, " trace ": 2, "4": 4} {
+		verbosity, err := ParseLevel(level)
+		require.NoError(t, err, level)
+		assert.Equal(t, expected, verbosity, level)
+	}
+
+	for _, level := range []string{"verbose", "-1"} {
+		_, err := ParseLevel(level)
+		assert.Error(t, err, level)
+	}
+}
+
+func TestLogger(t *testing.T) {
+	var records []string
+	sink := &recordingLogger{records: &records}
+
+	log := NewLogger(sink, 0).WithValues("installation", "mm")
+	log.Info("info")
+	log.V(1).Info("debug")
+	log.V(1).Error(errors.New("failed"), "error")
+	assert.Equal(t, []string{"info", "error"}, records)
+	assert.False(t, log.V(1).Enabled())
+
+	records = nil
+	debug := WithVerbosity(log, 1)
+	debug.V(1).Info("debug")
+	debug.V(2).Info("trace")
+	debug.V(1).WithName("phase").V(1).Info("trace")
+	assert.Equal(t, []string{"debug"}, records)
+	assert.Equal(t, []interface{}{"installation", "mm"}, debug.(*levelLogger).sink.(*recordingLogger).values)
+
+	// The verbosity is only raised.
+	assert.Same(t, debug, WithVerbosity(debug, 0))
+	// Loggers not returned by NewLogger are returned unchanged.
+	assert.Same(t, sink, WithVerbosity(sink, 2))
+
+	t.Run("object annotation", func(t *testing.T) {
+		obj := &metav1.ObjectMeta{}
+		assert.Same(t, log, ForObject(log, obj))
+
+		obj.Annotations = map[string]string{LogLevelAnnotation: "trace"}
+		assert.True(t, ForObject(log, obj).V(2).Enabled())
+
+		records = nil
+		obj.Annotations[LogLevelAnnotation] = "verbose"
+		assert.Same(t, log, ForObject(log, obj))
+		assert.Equal(t, []string{"Ignoring the log level annotation"}, records)
+	})
+}
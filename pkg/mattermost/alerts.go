@@ -0,0 +1,139 @@
+package mattermost
+
+import (
+	"fmt"
+	"time"
+
+	mmv1beta "github.com/mattermost/mattermost-operator/apis/mattermost/v1beta1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+)
+
+// PrometheusRuleGVK is the kind of the PrometheusRules of the Prometheus
+// Operator.
+var PrometheusRuleGVK = schema.GroupVersionKind{
+	Group:   "monitoring.coreos.com",
+	Version: "v1",
+	Kind:    "PrometheusRule",
+}
+
+const (
+	alertSeverityWarning  = "warning"
+	alertSeverityCritical = "critical"
+)
+
+// alert is an alerting rule of the PrometheusRule.
+type alert struct {
+	name        string
+	expr        string
+	duration    time.Duration
+	severity    string
+	summary     string
+	description string
+}
+
+// GeneratePrometheusRuleV1Beta returns the PrometheusRule with the alerts of
+// the Mattermost. The alerts of the database and the file store are only
+// generated if they are operator managed, their metrics being scraped by
+// the PodMonitors.
+func GeneratePrometheusRuleV1Beta(mattermost *mmv1beta.Mattermost) *unstructured.Unstructured {
+	monitoring := mattermost.Spec.Monitoring
+	if monitoring == nil {
+		monitoring = &mmv1beta.Monitoring{}
+	}
+	alerts := monitoring.Alerts
+	if alerts == nil {
+		alerts = &mmv1beta.MonitoringAlerts{}
+	}
+
+	// The metrics of the operator are labeled with the namespace and the
+	// name of the installation, the scraped metrics with the installation
+	// label.
+	operatorSelector := fmt.Sprintf(`namespace=%q,name=%q`, mattermost.Namespace, mattermost.Name)
+	scrapedSelector := fmt.Sprintf(`namespace=%q,%s=%q`, mattermost.Namespace, InstallationMetricLabel, mattermost.Name)
+
+	rules := []alert{
+		{
+			name:        "MattermostReplicasUnavailable",
+			expr:        fmt.Sprintf("mattermost_operator_ready_replicas{%s} < mattermost_operator_desired_replicas{%s}", operatorSelector, operatorSelector),
+			duration:    alerts.GetReplicasUnavailableFor(),
+			severity:    alertSeverityWarning,
+			summary:     "Mattermost replicas are unavailable",
+			description: "{{ $value }} Mattermost app server replicas of the installation are ready, fewer than desired.",
+		},
+		{
+			name:        "MattermostHealthCheckFailing",
+			expr:        fmt.Sprintf("increase(mattermost_operator_health_check_failures_total{%s}[15m]) >= %d", operatorSelector, alerts.GetHealthCheckFailures()),
+			severity:    alertSeverityCritical,
+			summary:     "Mattermost application health checks are failing",
+			description: "{{ $value }} application health checks of the installation failed within 15 minutes, Mattermost does not reach its database or file store.",
+		},
+	}
+	if !mattermost.Spec.Database.IsExternal() {
+		rules = append(rules, alert{
+			name: "MattermostDatabaseConnectionsSaturated",
+			expr: fmt.Sprintf("100 * max(mysql_global_status_threads_connected{%s}) / max(mysql_global_variables_max_connections{%s}) > %d",
+				scrapedSelector, scrapedSelector, alerts.GetDatabaseConnectionsPercent()),
+			duration:    5 * time.Minute,
+			severity:    alertSeverityWarning,
+			summary:     "Mattermost database connections are saturated",
+			description: "{{ $value }}% of the maximum connections of the database of the installation are used.",
+		})
+	}
+	if !mattermost.Spec.FileStore.IsExternal() {
+		rules = append(rules, alert{
+			name:        "MattermostFileStoreDisksOffline",
+			expr:        fmt.Sprintf("max(minio_disks_offline{%s}) > 0", scrapedSelector),
+			duration:    5 * time.Minute,
+			severity:    alertSeverityCritical,
+			summary:     "Mattermost file store disks are offline",
+			description: "{{ $value }} disks of the file store of the installation are offline.",
+		})
+	}
+
+	var alertRules []interface{}
+	for _, rule := range rules {
+		labels := map[string]interface{}{
+			"severity":              rule.severity,
+			"namespace":             mattermost.Namespace,
+			InstallationMetricLabel: mattermost.Name,
+		}
+		for key, value := range alerts.Labels {
+			labels[key] = value
+		}
+
+		alertRule := map[string]interface{}{
+			"alert":  rule.name,
+			"expr":   rule.expr,
+			"labels": labels,
+			"annotations": map[string]interface{}{
+				"summary":     rule.summary,
+				"description": rule.description,
+			},
+		}
+		if rule.duration > 0 {
+			// Prometheus durations do not support the compound units of
+			// Go durations, ie 1m30s.
+			alertRule["for"] = fmt.Sprintf("%ds", int64(rule.duration.Seconds()))
+		}
+		alertRules = append(alertRules, alertRule)
+	}
+
+	prometheusRule := &unstructured.Unstructured{Object: map[string]interface{}{
+		"spec": map[string]interface{}{
+			"groups": []interface{}{
+				map[string]interface{}{
+					"name":  fmt.Sprintf("mattermost-%s-%s", mattermost.Namespace, mattermost.Name),
+					"rules": alertRules,
+				},
+			},
+		},
+	}}
+	prometheusRule.SetGroupVersionKind(PrometheusRuleGVK)
+	prometheusRule.SetName(mattermost.Name)
+	prometheusRule.SetNamespace(mattermost.Namespace)
+	prometheusRule.SetLabels(mergeStringMaps(mmv1beta.MattermostResourceLabels(mattermost.Name), monitoring.Labels))
+	prometheusRule.SetOwnerReferences(MattermostOwnerReference(mattermost))
+
+	return prometheusRule
+}
@@ -0,0 +1,71 @@
+package mattermost
+
+import (
+	"testing"
+	"time"
+
+	mmv1beta "github.com/mattermost/mattermost-operator/apis/mattermost/v1beta1"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+)
+
+func TestGeneratePrometheusRuleV1Beta(t *testing.T) {
+	// rules returns the alerting rules of the PrometheusRule by alert name.
+	rules := func(t *testing.T, mattermost *mmv1beta.Mattermost) map[string]map[string]interface{} {
+		prometheusRule := GeneratePrometheusRuleV1Beta(mattermost)
+		assert.Equal(t, PrometheusRuleGVK, prometheusRule.GroupVersionKind())
+
+		groups, _, err := unstructured.NestedSlice(prometheusRule.Object, "spec", "groups")
+		require.NoError(t, err)
+		require.Len(t, groups, 1)
+		rules := map[string]map[string]interface{}{}
+		for _, rule := range groups[0].(map[string]interface{})["rules"].([]interface{}) {
+			rules[rule.(map[string]interface{})["alert"].(string)] = rule.(map[string]interface{})
+		}
+		return rules
+	}
+
+	mattermost := &mmv1beta.Mattermost{
+		ObjectMeta: metav1.ObjectMeta{Name: "mm", Namespace: "mm-namespace"},
+		Spec: mmv1beta.MattermostSpec{
+			Monitoring: &mmv1beta.Monitoring{
+				Enabled: true,
+				Alerts: &mmv1beta.MonitoringAlerts{
+					Enabled:                true,
+					ReplicasUnavailableFor: &metav1.Duration{Duration: 90 * time.Second},
+					HealthCheckFailures:    5,
+					Labels:                 map[string]string{"team": "chat"},
+				},
+			},
+		},
+	}
+
+	generated := rules(t, mattermost)
+	require.Len(t, generated, 4)
+
+	replicas := generated["MattermostReplicasUnavailable"]
+	assert.Equal(t, "90s", replicas["for"])
+	assert.Equal(t, `mattermost_operator_ready_replicas{namespace="mm-namespace",name="mm"} < mattermost_operator_desired_replicas{namespace="mm-namespace",name="mm"}`, replicas["expr"])
+	assert.Equal(t, map[string]interface{}{
+		"severity":              "warning",
+		"namespace":             "mm-namespace",
+		InstallationMetricLabel: "mm",
+		"team":                  "chat",
+	}, replicas["labels"])
+
+	assert.Contains(t, generated["MattermostHealthCheckFailing"]["expr"], ">= 5")
+	assert.Contains(t, generated["MattermostDatabaseConnectionsSaturated"]["expr"], "> 80")
+	assert.NotContains(t, generated["MattermostHealthCheckFailing"], "for")
+
+	t.Run("external database and file store", func(t *testing.T) {
+		mattermost.Spec.Database = mmv1beta.Database{External: &mmv1beta.ExternalDatabase{Secret: "database"}}
+		mattermost.Spec.FileStore = mmv1beta.FileStore{External: &mmv1beta.ExternalFileStore{URL: "s3.amazonaws.com", Bucket: "mattermost", Secret: "file-store"}}
+
+		generated := rules(t, mattermost)
+		assert.Len(t, generated, 2)
+		assert.NotContains(t, generated, "MattermostDatabaseConnectionsSaturated")
+		assert.NotContains(t, generated, "MattermostFileStoreDisksOffline")
+	})
+}
@@ -0,0 +1,78 @@
+package announcement
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+// Banner is the announcement banner shown to all the users of Mattermost.
+type Banner struct {
+	Enabled bool
+	Text    string
+}
+
+// configPatch is the patch of the announcement settings of the Mattermost
+// config.
+type configPatch struct {
+	AnnouncementSettings announcementSettings `json:"AnnouncementSettings"`
+}
+
+type announcementSettings struct {
+	EnableBanner         bool    `json:"EnableBanner"`
+	BannerText           *string `json:"BannerText,omitempty"`
+	AllowBannerDismissal *bool   `json:"AllowBannerDismissal,omitempty"`
+}
+
+// Client sets the announcement banner of Mattermost through the admin API.
+type Client struct {
+	HTTPClient *http.Client
+}
+
+// NewClient returns an admin API client.
+func NewClient() *Client {
+	return &Client{
+		HTTPClient: &http.Client{Timeout: 10 * time.Second},
+	}
+}
+
+// SetBanner enables the banner, which cannot be dismissed, with its text or
+// disables it on the Mattermost served at the URL. The text of a disabled
+// banner is kept. The access token must have the manage_system permission.
+func (c *Client) SetBanner(ctx context.Context, url, token string, banner Banner) error {
+	patch := configPatch{AnnouncementSettings: announcementSettings{EnableBanner: banner.Enabled}}
+	if banner.Enabled {
+		allowDismissal := false
+		patch.AnnouncementSettings.BannerText = &banner.Text
+		patch.AnnouncementSettings.AllowBannerDismissal = &allowDismissal
+	}
+	body, err := json.Marshal(patch)
+	if err != nil {
+		return errors.Wrap(err, "failed to encode config patch")
+	}
+
+	endpoint := strings.TrimSuffix(url, "/") + "/api/v4/config/patch"
+	req, err := http.NewRequestWithContext(ctx, http.MethodPut, endpoint, bytes.NewReader(body))
+	if err != nil {
+		return errors.Wrap(err, "failed to create config patch request")
+	}
+	req.Header.Set("Authorization", "Bearer "+token)
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := c.HTTPClient.Do(req)
+	if err != nil {
+		return errors.Wrap(err, "failed to send config patch request")
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("Mattermost responded with status code %d to the config patch request", resp.StatusCode)
+	}
+	return nil
+}
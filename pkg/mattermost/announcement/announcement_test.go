@@ -0,0 +1,46 @@
+package announcement
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSetBanner(t *testing.T) {
+	var patches []map[string]map[string]interface{}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Authorization") != "Bearer token" {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		assert.Equal(t, http.MethodPut, r.Method)
+		assert.Equal(t, "/api/v4/config/patch", r.URL.Path)
+		patch := map[string]map[string]interface{}{}
+		require.NoError(t, json.NewDecoder(r.Body).Decode(&patch))
+		patches = append(patches, patch)
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer server.Close()
+
+	client := NewClient()
+	err := client.SetBanner(context.Background(), server.URL+"/", "token", Banner{Enabled: true, Text: "Under maintenance"})
+	require.NoError(t, err)
+	err = client.SetBanner(context.Background(), server.URL, "token", Banner{})
+	require.NoError(t, err)
+
+	require.Len(t, patches, 2)
+	assert.Equal(t, map[string]interface{}{
+		"EnableBanner":         true,
+		"BannerText":           "Under maintenance",
+		"AllowBannerDismissal": false,
+	}, patches[0]["AnnouncementSettings"])
+	assert.Equal(t, map[string]interface{}{"EnableBanner": false}, patches[1]["AnnouncementSettings"])
+
+	err = client.SetBanner(context.Background(), server.URL, "invalid", Banner{})
+	assert.EqualError(t, err, "Mattermost responded with status code 401 to the config patch request")
+}
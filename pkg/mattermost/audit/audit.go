@@ -0,0 +1,146 @@
+package audit
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/pkg/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+const (
+	// RevisionsKey is the key of the revisions in the audit ConfigMap.
+	RevisionsKey = "revisions.json"
+	// FieldsKey is the key of the fields of the last audited spec in the
+	// audit ConfigMap.
+	FieldsKey = "fields.json"
+
+	// maxValueLength limits the length of the values reported in the
+	// changes.
+	maxValueLength = 64
+)
+
+// Revision is an audited revision of a spec.
+type Revision struct {
+	// Generation is the generation of the spec.
+	Generation int64 `json:"generation"`
+	// Time is the time the spec was changed.
+	Time metav1.Time `json:"time"`
+	// Author is the field manager which last changed the spec, ie kubectl.
+	Author string `json:"author,omitempty"`
+	// Changes summarize the changes of the fields of the spec.
+	Changes []string `json:"changes"`
+}
+
+// Fields flattens the spec to its fields, keyed by their path. Scalars are
+// kept as JSON values, lists are replaced by their hash so that their
+// content, ie environment variables, is not stored.
+func Fields(spec interface{}) (map[string]string, error) {
+	data, err := json.Marshal(spec)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to marshal spec")
+	}
+
+	var value interface{}
+	decoder := json.NewDecoder(bytes.NewReader(data))
+	decoder.UseNumber()
+	err = decoder.Decode(&value)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to unmarshal spec")
+	}
+
+	fields := map[string]string{}
+	err = flatten("spec", value, fields)
+	if err != nil {
+		return nil, err
+	}
+	return fields, nil
+}
+
+func flatten(path string, value interface{}, fields map[string]string) error {
+	switch typed := value.(type) {
+	case map[string]interface{}:
+		for key, child := range typed {
+			err := flatten(path+"."+key, child, fields)
+			if err != nil {
+				return err
+			}
+		}
+		return nil
+	case []interface{}:
+		data, err := json.Marshal(typed)
+		if err != nil {
+			return errors.Wrapf(err, "failed to marshal %s", path)
+		}
+		fields[path] = fmt.Sprintf("sha256:%x", sha256.Sum256(data))[:19]
+		return nil
+	default:
+		data, err := json.Marshal(typed)
+		if err != nil {
+			return errors.Wrapf(err, "failed to marshal %s", path)
+		}
+		fields[path] = string(data)
+		return nil
+	}
+}
+
+// Changes summarizes the changes between the fields of two specs, sorted by
+// path.
+func Changes(previous, current map[string]string) []string {
+	var changes []string
+	for path, value := range current {
+		previousValue, ok := previous[path]
+		switch {
+		case !ok:
+			changes = append(changes, fmt.Sprintf("%s: set to %s", path, displayValue(value)))
+		case previousValue != value:
+			if isHash(value) {
+				changes = append(changes, fmt.Sprintf("%s: changed", path))
+				continue
+			}
+			changes = append(changes, fmt.Sprintf("%s: %s -> %s", path, displayValue(previousValue), displayValue(value)))
+		}
+	}
+	for path := range previous {
+		if _, ok := current[path]; !ok {
+			changes = append(changes, fmt.Sprintf("%s: removed", path))
+		}
+	}
+
+	sort.Strings(changes)
+	return changes
+}
+
+func isHash(value string) bool {
+	return strings.HasPrefix(value, "sha256:")
+}
+
+func displayValue(value string) string {
+	if isHash(value) {
+		return "a list"
+	}
+	if len(value) > maxValueLength {
+		return value[:maxValueLength] + "..."
+	}
+	return value
+}
+
+// SpecAuthor returns the field manager which last changed the spec, and
+// when, from the managed fields of the object.
+func SpecAuthor(managedFields []metav1.ManagedFieldsEntry) (string, *metav1.Time) {
+	var author string
+	var changeTime *metav1.Time
+	for _, entry := range managedFields {
+		if entry.FieldsV1 == nil || !bytes.Contains(entry.FieldsV1.Raw, []byte(`"f:spec"`)) {
+			continue
+		}
+		if changeTime == nil || (entry.Time != nil && entry.Time.After(changeTime.Time)) {
+			author, changeTime = entry.Manager, entry.Time
+		}
+	}
+	return author, changeTime
+}
@@ -0,0 +1,68 @@
+package audit
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func TestChanges(t *testing.T) {
+	type env struct {
+		Name  string `json:"name"`
+		Value string `json:"value"`
+	}
+	type spec struct {
+		Version     string            `json:"version,omitempty"`
+		Replicas    int32             `json:"replicas,omitempty"`
+		Annotations map[string]string `json:"annotations,omitempty"`
+		Env         []env             `json:"env,omitempty"`
+	}
+
+	previous, err := Fields(spec{
+		Version:     "6.0.0",
+		Replicas:    2,
+		Annotations: map[string]string{"team": "chat"},
+		Env:         []env{{Name: "MM_SECRET", Value: "secret"}},
+	})
+	require.NoError(t, err)
+	assert.Equal(t, `"6.0.0"`, previous["spec.version"])
+	assert.NotContains(t, previous["spec.env"], "secret")
+
+	current, err := Fields(spec{
+		Version:  "6.1.0",
+		Replicas: 2,
+		Env:      []env{{Name: "MM_SECRET", Value: "rotated"}},
+	})
+	require.NoError(t, err)
+
+	assert.Equal(t, []string{
+		"spec.annotations.team: removed",
+		"spec.env: changed",
+		`spec.version: "6.0.0" -> "6.1.0"`,
+	}, Changes(previous, current))
+	assert.Empty(t, Changes(current, current))
+	assert.Equal(t, []string{
+		"spec.env: set to a list",
+		`spec.version: set to "6.1.0"`,
+	}, Changes(map[string]string{"spec.replicas": "2"}, current))
+}
+
+func TestSpecAuthor(t *testing.T) {
+	earlier := metav1.NewTime(time.Date(2021, 5, 1, 0, 0, 0, 0, time.UTC))
+	later := metav1.NewTime(time.Date(2021, 5, 2, 0, 0, 0, 0, time.UTC))
+
+	author, changeTime := SpecAuthor([]metav1.ManagedFieldsEntry{
+		{Manager: "kubectl-client-side-apply", Time: &earlier, FieldsV1: &metav1.FieldsV1{Raw: []byte(`{"f:spec":{"f:version":{}}}`)}},
+		{Manager: "kubectl-edit", Time: &later, FieldsV1: &metav1.FieldsV1{Raw: []byte(`{"f:spec":{"f:replicas":{}}}`)}},
+		{Manager: "mattermost-operator", Time: &later, FieldsV1: &metav1.FieldsV1{Raw: []byte(`{"f:status":{"f:state":{}}}`)}},
+	})
+	assert.Equal(t, "kubectl-edit", author)
+	assert.Equal(t, &later, changeTime)
+
+	author, changeTime = SpecAuthor(nil)
+	assert.Empty(t, author)
+	assert.Nil(t, changeTime)
+}
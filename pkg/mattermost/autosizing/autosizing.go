@@ -0,0 +1,147 @@
+package autosizing
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+// activeUsersMetric is the row of the standard analytics with the monthly
+// active users.
+const activeUsersMetric = "monthly_active_users"
+
+// maxResponseSize limits the size of the analytics read from Mattermost.
+const maxResponseSize = 1 << 20
+
+// Size is a Mattermost size auto-sizing selects from.
+type Size struct {
+	// Name is the name of the size, ie 5000users.
+	Name string
+	// Users is the number of users the size is planned for.
+	Users int64
+}
+
+// Sizes are the sizes auto-sizing selects from, from the smallest.
+var Sizes = []Size{
+	{Name: "100users", Users: 100},
+	{Name: "1000users", Users: 1000},
+	{Name: "5000users", Users: 5000},
+	{Name: "10000users", Users: 10000},
+	{Name: "25000users", Users: 25000},
+}
+
+// Policy defines the sizes auto-sizing selects from and when it changes
+// the size.
+type Policy struct {
+	MinSize string
+	MaxSize string
+	// ScaleUpThreshold is the percentage of the users of the size above
+	// which a larger size is selected.
+	ScaleUpThreshold int32
+	// ScaleDownThreshold is the percentage of the users of the size below
+	// which a smaller size is selected.
+	ScaleDownThreshold int32
+}
+
+// DesiredSize returns the size for the active users, given the current size.
+// A larger size is selected once the active users exceed the scale up
+// threshold of the current size. A smaller size is selected once they fall
+// below the scale down threshold, and only if they fit under the scale up
+// threshold of the smaller size, so that the size does not flap.
+func DesiredSize(activeUsers int64, current string, policy Policy) (string, error) {
+	minIndex, maxIndex := sizeIndex(policy.MinSize), sizeIndex(policy.MaxSize)
+	if minIndex < 0 || maxIndex < 0 || minIndex > maxIndex {
+		return "", fmt.Errorf("invalid auto-sizing bounds %s to %s", policy.MinSize, policy.MaxSize)
+	}
+	if policy.ScaleDownThreshold >= policy.ScaleUpThreshold {
+		return "", errors.New("auto-sizing scale down threshold must be lower than the scale up threshold")
+	}
+
+	fit := maxIndex
+	for i := minIndex; i <= maxIndex; i++ {
+		if activeUsers*100 <= int64(policy.ScaleUpThreshold)*Sizes[i].Users {
+			fit = i
+			break
+		}
+	}
+
+	currentIndex := sizeIndex(current)
+	if currentIndex < minIndex || currentIndex > maxIndex {
+		return Sizes[fit].Name, nil
+	}
+	if fit > currentIndex {
+		return Sizes[fit].Name, nil
+	}
+	if fit < currentIndex && activeUsers*100 < int64(policy.ScaleDownThreshold)*Sizes[currentIndex].Users {
+		return Sizes[fit].Name, nil
+	}
+	return current, nil
+}
+
+func sizeIndex(name string) int {
+	for i, size := range Sizes {
+		if size.Name == name {
+			return i
+		}
+	}
+	return -1
+}
+
+// Client queries the active users of Mattermost through the admin API.
+type Client struct {
+	HTTPClient *http.Client
+}
+
+// NewClient returns an admin API client.
+func NewClient() *Client {
+	return &Client{
+		HTTPClient: &http.Client{Timeout: 30 * time.Second},
+	}
+}
+
+// ActiveUsers returns the monthly active users of the Mattermost served at
+// the URL. The access token must have the manage_system permission.
+func (c *Client) ActiveUsers(ctx context.Context, url, token string) (int64, error) {
+	endpoint := strings.TrimSuffix(url, "/") + "/api/v4/analytics/old?name=standard"
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, endpoint, nil)
+	if err != nil {
+		return 0, errors.Wrap(err, "failed to create analytics request")
+	}
+	req.Header.Set("Authorization", "Bearer "+token)
+
+	resp, err := c.HTTPClient.Do(req)
+	if err != nil {
+		return 0, errors.Wrap(err, "failed to send analytics request")
+	}
+	defer resp.Body.Close()
+
+	data, err := ioutil.ReadAll(io.LimitReader(resp.Body, maxResponseSize))
+	if err != nil {
+		return 0, errors.Wrap(err, "failed to read analytics")
+	}
+	if resp.StatusCode != http.StatusOK {
+		return 0, fmt.Errorf("Mattermost responded with status code %d to the analytics request", resp.StatusCode)
+	}
+
+	rows := []struct {
+		Name  string  `json:"name"`
+		Value float64 `json:"value"`
+	}{}
+	err = json.Unmarshal(data, &rows)
+	if err != nil {
+		return 0, errors.Wrap(err, "failed to decode analytics")
+	}
+	for _, row := range rows {
+		if row.Name == activeUsersMetric {
+			return int64(row.Value), nil
+		}
+	}
+	return 0, fmt.Errorf("Mattermost analytics do not report %s", activeUsersMetric)
+}
@@ -0,0 +1,75 @@
+package autosizing
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestDesiredSize(t *testing.T) {
+	policy := Policy{
+		MinSize:            "100users",
+		MaxSize:            "10000users",
+		ScaleUpThreshold:   80,
+		ScaleDownThreshold: 40,
+	}
+
+	for _, tc := range []struct {
+		name        string
+		activeUsers int64
+		current     string
+		expected    string
+	}{
+		{name: "initial size", activeUsers: 900, current: "", expected: "5000users"},
+		{name: "below scale up threshold", activeUsers: 800, current: "1000users", expected: "1000users"},
+		{name: "above scale up threshold", activeUsers: 801, current: "1000users", expected: "5000users"},
+		{name: "above largest size", activeUsers: 30000, current: "5000users", expected: "10000users"},
+		{name: "above scale down threshold", activeUsers: 2000, current: "5000users", expected: "5000users"},
+		{name: "below scale down threshold", activeUsers: 700, current: "5000users", expected: "1000users"},
+		{name: "below scale down threshold but not fitting", activeUsers: 1500, current: "10000users", expected: "5000users"},
+		{name: "below smallest size", activeUsers: 10, current: "1000users", expected: "100users"},
+		{name: "current size out of bounds", activeUsers: 10, current: "25000users", expected: "100users"},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			size, err := DesiredSize(tc.activeUsers, tc.current, policy)
+			require.NoError(t, err)
+			assert.Equal(t, tc.expected, size)
+		})
+	}
+
+	t.Run("invalid bounds", func(t *testing.T) {
+		_, err := DesiredSize(10, "", Policy{MinSize: "5000users", MaxSize: "1000users", ScaleUpThreshold: 80, ScaleDownThreshold: 40})
+		require.Error(t, err)
+	})
+
+	t.Run("invalid thresholds", func(t *testing.T) {
+		_, err := DesiredSize(10, "", Policy{MinSize: "100users", MaxSize: "1000users", ScaleUpThreshold: 40, ScaleDownThreshold: 40})
+		require.Error(t, err)
+	})
+}
+
+func TestActiveUsers(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Authorization") != "Bearer token" {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		assert.Equal(t, "/api/v4/analytics/old", r.URL.Path)
+		assert.Equal(t, "standard", r.URL.Query().Get("name"))
+		_, _ = w.Write([]byte(`[{"name": "daily_active_users", "value": 120}, {"name": "monthly_active_users", "value": 1234}]`))
+	}))
+	defer server.Close()
+
+	client := NewClient()
+
+	activeUsers, err := client.ActiveUsers(context.TODO(), server.URL+"/", "token")
+	require.NoError(t, err)
+	assert.Equal(t, int64(1234), activeUsers)
+
+	_, err = client.ActiveUsers(context.TODO(), server.URL, "invalid")
+	require.Error(t, err)
+}
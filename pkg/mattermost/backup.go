@@ -0,0 +1,253 @@
+package mattermost
+
+import (
+	"errors"
+	"fmt"
+	"path"
+
+	mmv1beta "github.com/mattermost/mattermost-operator/apis/mattermost/v1beta1"
+	"github.com/mattermost/mattermost-operator/pkg/components/utils"
+	batchv1 "k8s.io/api/batch/v1"
+	batchv1beta1 "k8s.io/api/batch/v1beta1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+)
+
+const (
+	// BackupLabel is the label holding the name of the MattermostBackup on
+	// the Jobs taking the backups.
+	BackupLabel = "installation.mattermost.com/backup"
+
+	databaseDumpContainerName = "dump-database"
+	backupContainerName       = "upload-backup"
+	backupVolumeName          = "backup"
+	backupVolumeMountPath     = "/backup"
+)
+
+// BackupJobName returns the name of the Job, or CronJob for scheduled
+// backups, taking the backups of the MattermostBackup.
+func BackupJobName(backup *mmv1beta.MattermostBackup) string {
+	return fmt.Sprintf("%s-backup", backup.Name)
+}
+
+// BackupJobLabels returns the labels of the Jobs taking the backups of the
+// MattermostBackup.
+func BackupJobLabels(backup *mmv1beta.MattermostBackup) map[string]string {
+	labels := mmv1beta.MattermostResourceLabels(backup.Spec.MattermostName)
+	labels[BackupLabel] = backup.Name
+	return labels
+}
+
+// MattermostBackupOwnerReference returns the owner reference of resources
+// created for the MattermostBackup.
+func MattermostBackupOwnerReference(backup *mmv1beta.MattermostBackup) []metav1.OwnerReference {
+	return []metav1.OwnerReference{
+		*metav1.NewControllerRef(backup, schema.GroupVersionKind{
+			Group:   mmv1beta.GroupVersion.Group,
+			Version: mmv1beta.GroupVersion.Version,
+			Kind:    "MattermostBackup",
+		}),
+	}
+}
+
+// PreUpgradeBackupName returns the name of the MattermostBackup taken before
+// Mattermost is upgraded to the image.
+func PreUpgradeBackupName(mattermost *mmv1beta.Mattermost, image string) string {
+	return fmt.Sprintf("%s-pre-upgrade-%s", mattermost.Name, utils.HashedName(image))
+}
+
+// GeneratePreUpgradeBackupV1Beta returns the MattermostBackup taking a single
+// backup of the Mattermost installation before it is upgraded to the image.
+func GeneratePreUpgradeBackupV1Beta(mattermost *mmv1beta.Mattermost, image string) *mmv1beta.MattermostBackup {
+	return &mmv1beta.MattermostBackup{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:            PreUpgradeBackupName(mattermost, image),
+			Namespace:       mattermost.Namespace,
+			Labels:          mmv1beta.MattermostResourceLabels(mattermost.Name),
+			OwnerReferences: MattermostOwnerReference(mattermost),
+		},
+		Spec: mmv1beta.MattermostBackupSpec{
+			MattermostName: mattermost.Name,
+			Destination:    *mattermost.Spec.UpdatePolicy.BackupDestination,
+		},
+	}
+}
+
+// NewBackupDestinationInfo returns the file store info of the bucket the
+// backups are uploaded to.
+func NewBackupDestinationInfo(destination mmv1beta.BackupDestination, secret corev1.Secret) (*FileStoreInfo, error) {
+	if destination.URL == "" {
+		return nil, errors.New("backup destination URL is empty")
+	}
+	if destination.Bucket == "" {
+		return nil, errors.New("backup destination bucket is empty")
+	}
+	if _, ok := secret.Data[fileStoreSecretAccessKey]; !ok {
+		return nil, fmt.Errorf("backup destination Secret %s does not have an '%s' value", secret.Name, fileStoreSecretAccessKey)
+	}
+	if _, ok := secret.Data[fileStoreSecretSecretKey]; !ok {
+		return nil, fmt.Errorf("backup destination Secret %s does not have an '%s' value", secret.Name, fileStoreSecretSecretKey)
+	}
+
+	return &FileStoreInfo{
+		secretName: secret.Name,
+		bucketName: destination.Bucket,
+		url:        destination.URL,
+		useS3SSL:   true,
+	}, nil
+}
+
+// GenerateBackupJobV1Beta returns the Job taking a single backup of the
+// Mattermost installation.
+func GenerateBackupJobV1Beta(backup *mmv1beta.MattermostBackup, mattermost *mmv1beta.Mattermost, db DatabaseConfig, fileStore, destination *FileStoreInfo) (*batchv1.Job, error) {
+	jobSpec, err := backupJobSpec(backup, mattermost, db, fileStore, destination)
+	if err != nil {
+		return nil, err
+	}
+
+	return &batchv1.Job{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:            BackupJobName(backup),
+			Namespace:       backup.Namespace,
+			Labels:          BackupJobLabels(backup),
+			OwnerReferences: MattermostBackupOwnerReference(backup),
+		},
+		Spec: *jobSpec,
+	}, nil
+}
+
+// GenerateBackupCronJobV1Beta returns the CronJob taking the backups of the
+// Mattermost installation on the schedule of the MattermostBackup.
+func GenerateBackupCronJobV1Beta(backup *mmv1beta.MattermostBackup, mattermost *mmv1beta.Mattermost, db DatabaseConfig, fileStore, destination *FileStoreInfo) (*batchv1beta1.CronJob, error) {
+	jobSpec, err := backupJobSpec(backup, mattermost, db, fileStore, destination)
+	if err != nil {
+		return nil, err
+	}
+
+	return &batchv1beta1.CronJob{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:            BackupJobName(backup),
+			Namespace:       backup.Namespace,
+			Labels:          BackupJobLabels(backup),
+			OwnerReferences: MattermostBackupOwnerReference(backup),
+		},
+		Spec: batchv1beta1.CronJobSpec{
+			Schedule:          backup.Spec.Schedule,
+			ConcurrencyPolicy: batchv1beta1.ForbidConcurrent,
+			JobTemplate: batchv1beta1.JobTemplateSpec{
+				ObjectMeta: metav1.ObjectMeta{
+					Labels: BackupJobLabels(backup),
+				},
+				Spec: *jobSpec,
+			},
+		},
+	}, nil
+}
+
+// backupJobSpec returns the spec of the Job taking a backup. The database is
+// dumped before the files are copied, so that all files referenced by the
+// dump are part of the backup.
+func backupJobSpec(backup *mmv1beta.MattermostBackup, mattermost *mmv1beta.Mattermost, db DatabaseConfig, fileStore, destination *FileStoreInfo) (*batchv1.JobSpec, error) {
+	backoffLimit := int32(3)
+	volumeMounts := []corev1.VolumeMount{
+		{
+			Name:      backupVolumeName,
+			MountPath: backupVolumeMountPath,
+		},
+	}
+
+	dumpContainer, err := db.DumpContainer(mattermost, path.Join(backupVolumeMountPath, "database.sql"))
+	if err != nil {
+		return nil, err
+	}
+	dumpContainer.VolumeMounts = volumeMounts
+
+	jobSpec := &batchv1.JobSpec{
+		BackoffLimit: &backoffLimit,
+		Template: corev1.PodTemplateSpec{
+			ObjectMeta: metav1.ObjectMeta{
+				Labels: map[string]string{"app": BackupJobName(backup)},
+			},
+			Spec: corev1.PodSpec{
+				RestartPolicy:  corev1.RestartPolicyNever,
+				InitContainers: []corev1.Container{*dumpContainer},
+				Containers: []corev1.Container{
+					{
+						Name:            backupContainerName,
+						Image:           mattermost.Spec.UtilityImages.GetMinioClient(),
+						ImagePullPolicy: corev1.PullIfNotPresent,
+						Command: []string{
+							"/bin/sh", "-c",
+							backupCommand(backup, fileStore, destination),
+						},
+						Env: []corev1.EnvVar{
+							{
+								Name:      "SOURCE_ACCESS_KEY",
+								ValueFrom: EnvSourceFromSecret(fileStore.secretName, fileStoreSecretAccessKey),
+							},
+							{
+								Name:      "SOURCE_SECRET_KEY",
+								ValueFrom: EnvSourceFromSecret(fileStore.secretName, fileStoreSecretSecretKey),
+							},
+							{
+								Name:      "TARGET_ACCESS_KEY",
+								ValueFrom: EnvSourceFromSecret(destination.secretName, fileStoreSecretAccessKey),
+							},
+							{
+								Name:      "TARGET_SECRET_KEY",
+								ValueFrom: EnvSourceFromSecret(destination.secretName, fileStoreSecretSecretKey),
+							},
+						},
+						VolumeMounts:             volumeMounts,
+						TerminationMessagePolicy: corev1.TerminationMessageFallbackToLogsOnError,
+					},
+				},
+				Volumes: []corev1.Volume{
+					{
+						Name: backupVolumeName,
+						VolumeSource: corev1.VolumeSource{
+							EmptyDir: &corev1.EmptyDirVolumeSource{},
+						},
+					},
+				},
+			},
+		},
+	}
+	setPodImageRegistry(mattermost, &jobSpec.Template.Spec)
+	SetJobPodSettings(mattermost, &jobSpec.Template.Spec)
+
+	return jobSpec, nil
+}
+
+// backupCommand returns the shell script uploading the database dump and
+// the files to a new timestamped directory in the destination bucket. The
+// location of the backup is written to the termination log to be reported
+// in the MattermostBackup status.
+func backupCommand(backup *mmv1beta.MattermostBackup, fileStore, destination *FileStoreInfo) string {
+	backupsPath := path.Join(destination.bucketName, backup.Spec.Destination.Prefix, backup.Name)
+
+	command := fmt.Sprintf("mc config host add source %s $(SOURCE_ACCESS_KEY) $(SOURCE_SECRET_KEY) && "+
+		"mc config host add target %s $(TARGET_ACCESS_KEY) $(TARGET_SECRET_KEY) && "+
+		"backups=%s && "+
+		"backup=$backups/`date -u +%%Y%%m%%d%%H%%M%%S` && "+
+		"mc cp %s \"target/$backup/database.sql\" && "+
+		"mc mirror %s \"target/$backup/files\" && ",
+		shellQuote(fileStore.endpoint()), shellQuote(destination.endpoint()),
+		shellQuote(backupsPath),
+		shellQuote(path.Join(backupVolumeMountPath, "database.sql")),
+		shellQuote(fmt.Sprintf("source/%s", fileStore.bucketName)),
+	)
+
+	if backup.Spec.Retention != nil {
+		// Backup directories are named after their timestamp, the newest
+		// ones are kept.
+		command += fmt.Sprintf("mc ls \"target/$backups/\" | while read -r entry; do echo \"${entry##* }\"; done | "+
+			"sort -r | tail -n +%d | "+
+			"while read -r expired; do mc rm --recursive --force \"target/$backups/$expired\"; done && ",
+			backup.Spec.Retention.KeepLast+1,
+		)
+	}
+
+	return command + fmt.Sprintf("echo %s\"/$backup\" > /dev/termination-log", shellQuote(destination.endpoint()))
+}
@@ -0,0 +1,150 @@
+package mattermost
+
+import (
+	"testing"
+
+	mmv1beta "github.com/mattermost/mattermost-operator/apis/mattermost/v1beta1"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func TestGenerateBackupJob(t *testing.T) {
+	mattermost := &mmv1beta.Mattermost{
+		ObjectMeta: metav1.ObjectMeta{Name: "mm-test", Namespace: "mm-namespace"},
+	}
+	backup := &mmv1beta.MattermostBackup{
+		ObjectMeta: metav1.ObjectMeta{Name: "nightly", Namespace: "mm-namespace"},
+		Spec: mmv1beta.MattermostBackupSpec{
+			MattermostName: "mm-test",
+			Destination: mmv1beta.BackupDestination{
+				URL:    "s3.amazonaws.com",
+				Bucket: "backups",
+				Prefix: "mattermost",
+				Secret: "backup-secret",
+			},
+		},
+	}
+
+	db, err := NewMySQLDBConfig(corev1.Secret{
+		ObjectMeta: metav1.ObjectMeta{Name: "db-secret"},
+		Data: map[string][]byte{
+			"ROOT_PASSWORD": []byte("root"),
+			"USER":          []byte("user"),
+			"PASSWORD":      []byte("password"),
+			"DATABASE":      []byte("mattermost"),
+		},
+	})
+	require.NoError(t, err)
+
+	fileStore := NewOperatorManagedFileStoreInfo(mattermost, "minio-secret", "mm-test-minio-hl-svc.mm-namespace.svc.cluster.local:9000")
+
+	destination, err := NewBackupDestinationInfo(backup.Spec.Destination, corev1.Secret{
+		ObjectMeta: metav1.ObjectMeta{Name: "backup-secret"},
+		Data: map[string][]byte{
+			"accesskey": []byte("access"),
+			"secretkey": []byte("secret"),
+		},
+	})
+	require.NoError(t, err)
+
+	t.Run("single backup", func(t *testing.T) {
+		job, err := GenerateBackupJobV1Beta(backup, mattermost, db, fileStore, destination)
+		require.NoError(t, err)
+		assert.Equal(t, "nightly-backup", job.Name)
+		assert.Equal(t, "nightly", job.Labels[BackupLabel])
+
+		podSpec := job.Spec.Template.Spec
+		require.Len(t, podSpec.InitContainers, 1)
+		assert.Contains(t, podSpec.InitContainers[0].Command[2], "mysqldump --single-transaction --host db-")
+		assert.Contains(t, podSpec.InitContainers[0].Command[2], "--result-file /backup/database.sql")
+
+		require.Len(t, podSpec.Containers, 1)
+		command := podSpec.Containers[0].Command[2]
+		assert.Contains(t, command, "backups='backups/mattermost/nightly'")
+		assert.Contains(t, command, "mc cp '/backup/database.sql' \"target/$backup/database.sql\"")
+		assert.Contains(t, command, "mc mirror 'source/mm-test' \"target/$backup/files\"")
+		assert.Contains(t, command, "echo 'https://s3.amazonaws.com'\"/$backup\" > /dev/termination-log")
+		assert.NotContains(t, command, "mc rm")
+	})
+
+	t.Run("scheduled backup with retention", func(t *testing.T) {
+		scheduled := backup.DeepCopy()
+		scheduled.Spec.Schedule = "0 3 * * *"
+		scheduled.Spec.Retention = &mmv1beta.BackupRetention{KeepLast: 7}
+
+		cronJob, err := GenerateBackupCronJobV1Beta(scheduled, mattermost, db, fileStore, destination)
+		require.NoError(t, err)
+		assert.Equal(t, "0 3 * * *", cronJob.Spec.Schedule)
+		assert.Equal(t, "nightly", cronJob.Spec.JobTemplate.Labels[BackupLabel])
+
+		command := cronJob.Spec.JobTemplate.Spec.Template.Spec.Containers[0].Command[2]
+		assert.Contains(t, command, "sort -r | tail -n +8")
+		assert.Contains(t, command, "mc rm --recursive --force \"target/$backups/$expired\"")
+	})
+
+	t.Run("image registry", func(t *testing.T) {
+		mirrored := mattermost.DeepCopy()
+		mirrored.Spec.ImageRegistry = "registry.example.com/mirror"
+
+		job, err := GenerateBackupJobV1Beta(backup, mirrored, db, fileStore, destination)
+		require.NoError(t, err)
+
+		podSpec := job.Spec.Template.Spec
+		assert.Equal(t, "registry.example.com/mirror/mysql:5.7", podSpec.InitContainers[0].Image)
+		assert.Equal(t, "registry.example.com/mirror/minio/mc:RELEASE.2021-06-13T17-48-22Z", podSpec.Containers[0].Image)
+	})
+
+	t.Run("unsupported external database", func(t *testing.T) {
+		externalDB := &ExternalDBConfig{secretName: "db-secret", dbType: "unknown"}
+
+		_, err := GenerateBackupJobV1Beta(backup, mattermost, externalDB, fileStore, destination)
+		assert.Error(t, err)
+	})
+}
+
+func TestGeneratePreUpgradeBackup(t *testing.T) {
+	destination := mmv1beta.BackupDestination{
+		URL:    "s3.amazonaws.com",
+		Bucket: "backups",
+		Secret: "backup-secret",
+	}
+	mattermost := &mmv1beta.Mattermost{
+		ObjectMeta: metav1.ObjectMeta{Name: "mm-test", Namespace: "mm-namespace"},
+		Spec: mmv1beta.MattermostSpec{
+			UpdatePolicy: &mmv1beta.UpdatePolicy{
+				BackupBeforeUpgrade: true,
+				BackupDestination:   &destination,
+			},
+		},
+	}
+
+	backup := GeneratePreUpgradeBackupV1Beta(mattermost, "mattermost/mattermost-enterprise-edition:5.37.1")
+	assert.Equal(t, PreUpgradeBackupName(mattermost, "mattermost/mattermost-enterprise-edition:5.37.1"), backup.Name)
+	assert.NotEqual(t, PreUpgradeBackupName(mattermost, "mattermost/mattermost-enterprise-edition:5.38.0"), backup.Name)
+	assert.Equal(t, "mm-namespace", backup.Namespace)
+	assert.Equal(t, "mm-test", backup.Spec.MattermostName)
+	assert.Equal(t, destination, backup.Spec.Destination)
+	assert.Empty(t, backup.Spec.Schedule)
+	require.Len(t, backup.OwnerReferences, 1)
+	assert.Equal(t, "mm-test", backup.OwnerReferences[0].Name)
+}
+
+func TestNewBackupDestinationInfo(t *testing.T) {
+	secret := corev1.Secret{
+		ObjectMeta: metav1.ObjectMeta{Name: "backup-secret"},
+		Data:       map[string][]byte{"accesskey": []byte("access")},
+	}
+
+	_, err := NewBackupDestinationInfo(mmv1beta.BackupDestination{URL: "s3.amazonaws.com", Bucket: "backups"}, secret)
+	assert.Error(t, err)
+
+	secret.Data["secretkey"] = []byte("secret")
+	_, err = NewBackupDestinationInfo(mmv1beta.BackupDestination{URL: "s3.amazonaws.com"}, secret)
+	assert.Error(t, err)
+
+	destination, err := NewBackupDestinationInfo(mmv1beta.BackupDestination{URL: "s3.amazonaws.com", Bucket: "backups"}, secret)
+	require.NoError(t, err)
+	assert.Equal(t, "https://s3.amazonaws.com/backups", destination.Location())
+}
@@ -0,0 +1,93 @@
+package mattermost
+
+import (
+	"crypto/sha256"
+	"fmt"
+	"strings"
+
+	mmv1beta "github.com/mattermost/mattermost-operator/apis/mattermost/v1beta1"
+	batchv1 "k8s.io/api/batch/v1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+const (
+	// BucketConfigurationHashAnnotation holds the hash of the bucket
+	// configuration applied by the bucket configuration Job, so that the Job
+	// only runs again when the configuration changes.
+	BucketConfigurationHashAnnotation = "installation.mattermost.com/bucket-configuration-hash"
+
+	bucketConfigurationContainerName = "configure-bucket"
+)
+
+// BucketConfigurationJobName returns the name of the Job configuring the
+// encryption and lifecycle of the external file store bucket.
+func BucketConfigurationJobName(mattermost *mmv1beta.Mattermost) string {
+	return fmt.Sprintf("%s-configure-bucket", mattermost.Name)
+}
+
+// GenerateBucketConfigurationJobV1Beta returns the Job configuring the
+// encryption and lifecycle of the external file store bucket once, instead
+// of on every start of the Mattermost pods. It returns nil if the file store
+// is operator managed or there is nothing to configure.
+func GenerateBucketConfigurationJobV1Beta(mattermost *mmv1beta.Mattermost, fileStore *FileStoreInfo) *batchv1.Job {
+	external, ok := fileStore.config.(*ExternalFileStore)
+	if !ok {
+		return nil
+	}
+	bucketCommands := external.bucketCommands()
+	if len(bucketCommands) == 0 {
+		return nil
+	}
+
+	command := fmt.Sprintf("mc config host add externalstore %s $(FILESTORE_ACCESS_KEY) $(FILESTORE_SECRET_KEY) && %s",
+		shellQuote(fileStore.endpoint()), strings.Join(bucketCommands, " && "))
+
+	backoffLimit := int32(3)
+	name := BucketConfigurationJobName(mattermost)
+
+	job := &batchv1.Job{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:            name,
+			Namespace:       mattermost.Namespace,
+			Labels:          mmv1beta.MattermostResourceLabels(mattermost.Name),
+			OwnerReferences: MattermostOwnerReference(mattermost),
+			Annotations: map[string]string{
+				BucketConfigurationHashAnnotation: fmt.Sprintf("%x", sha256.Sum256([]byte(command))),
+			},
+		},
+		Spec: batchv1.JobSpec{
+			BackoffLimit: &backoffLimit,
+			Template: corev1.PodTemplateSpec{
+				ObjectMeta: metav1.ObjectMeta{
+					Labels: map[string]string{"app": name},
+				},
+				Spec: corev1.PodSpec{
+					RestartPolicy: corev1.RestartPolicyNever,
+					Containers: []corev1.Container{
+						{
+							Name:            bucketConfigurationContainerName,
+							Image:           mattermost.ImageWithRegistry(mattermost.Spec.UtilityImages.GetMinioClient()),
+							ImagePullPolicy: corev1.PullIfNotPresent,
+							Command:         []string{"/bin/sh", "-c", command},
+							Env: []corev1.EnvVar{
+								{
+									Name:      "FILESTORE_ACCESS_KEY",
+									ValueFrom: EnvSourceFromSecret(external.secretName, fileStoreSecretAccessKey),
+								},
+								{
+									Name:      "FILESTORE_SECRET_KEY",
+									ValueFrom: EnvSourceFromSecret(external.secretName, fileStoreSecretSecretKey),
+								},
+							},
+							TerminationMessagePolicy: corev1.TerminationMessageFallbackToLogsOnError,
+						},
+					},
+				},
+			},
+		},
+	}
+	SetJobPodSettings(mattermost, &job.Spec.Template.Spec)
+
+	return job
+}
@@ -0,0 +1,104 @@
+package mattermost
+
+import (
+	"strconv"
+
+	mmv1beta "github.com/mattermost/mattermost-operator/apis/mattermost/v1beta1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/intstr"
+)
+
+const (
+	// clusterName is the name of the Mattermost cluster the app servers of
+	// an installation join.
+	clusterName = "production"
+	// clusterGossipPort is the port the app servers of the cluster gossip on,
+	// over TCP and UDP.
+	clusterGossipPort = 8074
+
+	// ClusterJoinedCondition is the readiness gate of the pods of clustered
+	// app servers, true once the app server joined the cluster.
+	ClusterJoinedCondition corev1.PodConditionType = "installation.mattermost.com/cluster-joined"
+)
+
+// clusterEnvVars returns the ClusterSettings enabling Mattermost clustering.
+func clusterEnvVars() []corev1.EnvVar {
+	return []corev1.EnvVar{
+		{
+			Name:  "MM_CLUSTERSETTINGS_ENABLE",
+			Value: "true",
+		},
+		{
+			Name:  "MM_CLUSTERSETTINGS_CLUSTERNAME",
+			Value: clusterName,
+		},
+	}
+}
+
+func clusterGossipEnvVar() corev1.EnvVar {
+	return corev1.EnvVar{
+		Name:  "MM_CLUSTERSETTINGS_GOSSIPPORT",
+		Value: strconv.Itoa(clusterGossipPort),
+	}
+}
+
+func clusterGossipPorts() []corev1.ContainerPort {
+	return []corev1.ContainerPort{
+		{
+			ContainerPort: clusterGossipPort,
+			Name:          "gossip",
+			Protocol:      corev1.ProtocolTCP,
+		},
+		{
+			ContainerPort: clusterGossipPort,
+			Name:          "gossip-udp",
+			Protocol:      corev1.ProtocolUDP,
+		},
+	}
+}
+
+// GenerateClusterServiceV1Beta returns the headless Service the app servers
+// of the Mattermost cluster discover each other through to gossip. Pods are
+// published before they are ready, as they join the cluster while starting.
+func GenerateClusterServiceV1Beta(mattermost *mmv1beta.Mattermost, selectorName string) *corev1.Service {
+	name := mattermost.ClusterServiceName()
+	return &corev1.Service{
+		ObjectMeta: metav1.ObjectMeta{
+			Labels:          mattermost.MattermostLabels(name),
+			Name:            name,
+			Namespace:       mattermost.Namespace,
+			OwnerReferences: MattermostOwnerReference(mattermost),
+		},
+		Spec: corev1.ServiceSpec{
+			Selector:                 mmv1beta.MattermostSelectorLabels(selectorName),
+			ClusterIP:                corev1.ClusterIPNone,
+			PublishNotReadyAddresses: true,
+			Ports: []corev1.ServicePort{
+				{
+					Name:       "gossip",
+					Port:       clusterGossipPort,
+					Protocol:   corev1.ProtocolTCP,
+					TargetPort: intstr.FromString("gossip"),
+				},
+				{
+					Name:       "gossip-udp",
+					Port:       clusterGossipPort,
+					Protocol:   corev1.ProtocolUDP,
+					TargetPort: intstr.FromString("gossip-udp"),
+				},
+			},
+		},
+	}
+}
+
+// clusterReadinessGates returns the readiness gates of the pods of the
+// deployment, holding the app servers unready until they joined the cluster.
+// Only pods of the production deployment, gossiping through the cluster
+// Service, are gated.
+func clusterReadinessGates(mattermost *mmv1beta.Mattermost, deploymentName string) []corev1.PodReadinessGate {
+	if !mattermost.ClusterReadinessGateEnabled() || deploymentName != mattermost.GetProductionDeploymentName() {
+		return nil
+	}
+	return []corev1.PodReadinessGate{{ConditionType: ClusterJoinedCondition}}
+}
@@ -0,0 +1,70 @@
+package mattermost
+
+import (
+	"testing"
+
+	mmv1beta "github.com/mattermost/mattermost-operator/apis/mattermost/v1beta1"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func TestClustering_V1Beta(t *testing.T) {
+	newMattermost := func(replicas int32, licenseSecret string) *mmv1beta.Mattermost {
+		return &mmv1beta.Mattermost{
+			ObjectMeta: metav1.ObjectMeta{Name: "foo", Namespace: "bar"},
+			Spec: mmv1beta.MattermostSpec{
+				Replicas:      &replicas,
+				LicenseSecret: licenseSecret,
+			},
+		}
+	}
+
+	t.Run("licensed multi-replica install", func(t *testing.T) {
+		mattermost := newMattermost(2, "license")
+
+		deployment := GenerateDeploymentV1Beta(mattermost, &MySQLDBConfig{}, NewOperatorManagedFileStoreInfo(mattermost, "minio-secret", "http://minio"), "", "", "service-account", "")
+		container := mmv1beta.GetMattermostAppContainerFromDeployment(deployment)
+		require.NotNil(t, container)
+
+		assertEnvVarEqual(t, "MM_CLUSTERSETTINGS_ENABLE", "true", container.Env)
+		assertEnvVarEqual(t, "MM_CLUSTERSETTINGS_CLUSTERNAME", "production", container.Env)
+		assertEnvVarEqual(t, "MM_CLUSTERSETTINGS_GOSSIPPORT", "8074", container.Env)
+		assert.Contains(t, container.Ports, corev1.ContainerPort{ContainerPort: 8074, Name: "gossip", Protocol: corev1.ProtocolTCP})
+		assert.Contains(t, container.Ports, corev1.ContainerPort{ContainerPort: 8074, Name: "gossip-udp", Protocol: corev1.ProtocolUDP})
+
+		assert.Empty(t, deployment.Spec.Template.Spec.ReadinessGates)
+
+		mattermost.Spec.ClusterReadinessGate = &mmv1beta.ClusterReadinessGate{Enabled: true, AccessTokenSecret: "admin-token"}
+		deployment = GenerateDeploymentV1Beta(mattermost, &MySQLDBConfig{}, NewOperatorManagedFileStoreInfo(mattermost, "minio-secret", "http://minio"), "foo", "", "service-account", "")
+		assert.Equal(t, []corev1.PodReadinessGate{{ConditionType: ClusterJoinedCondition}}, deployment.Spec.Template.Spec.ReadinessGates)
+
+		service := GenerateClusterServiceV1Beta(mattermost, mattermost.GetProductionDeploymentName())
+		assert.Equal(t, "foo-cluster", service.Name)
+		assert.Equal(t, "bar", service.Namespace)
+		assert.Equal(t, corev1.ClusterIPNone, service.Spec.ClusterIP)
+		assert.True(t, service.Spec.PublishNotReadyAddresses)
+		assert.Equal(t, mmv1beta.MattermostSelectorLabels("foo"), service.Spec.Selector)
+		assert.Len(t, service.Spec.Ports, 2)
+	})
+
+	for _, tc := range []struct {
+		name       string
+		mattermost *mmv1beta.Mattermost
+	}{
+		{name: "single replica", mattermost: newMattermost(1, "license")},
+		{name: "no license", mattermost: newMattermost(2, "")},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			deployment := GenerateDeploymentV1Beta(tc.mattermost, &MySQLDBConfig{}, NewOperatorManagedFileStoreInfo(tc.mattermost, "minio-secret", "http://minio"), "", "", "service-account", "")
+			container := mmv1beta.GetMattermostAppContainerFromDeployment(deployment)
+			require.NotNil(t, container)
+
+			for _, env := range container.Env {
+				assert.NotContains(t, env.Name, "MM_CLUSTERSETTINGS")
+			}
+			assert.Len(t, container.Ports, 2)
+		})
+	}
+}
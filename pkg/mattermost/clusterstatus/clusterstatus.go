@@ -0,0 +1,94 @@
+package clusterstatus
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+// maxResponseSize limits the size of the cluster status read from
+// Mattermost.
+const maxResponseSize = 1 << 20
+
+// Node is an app server of the Mattermost cluster.
+type Node struct {
+	ID        string `json:"id"`
+	Version   string `json:"version"`
+	Hostname  string `json:"hostname"`
+	IPAddress string `json:"ipaddress"`
+}
+
+// HasPeers returns true if the cluster status reported by the app server
+// with the hostname and IP address lists another app server, that is the
+// app server joined the cluster.
+func HasPeers(nodes []Node, hostname, ipAddress string) bool {
+	for _, node := range nodes {
+		if node.Hostname == hostname || hostIP(node.IPAddress) == ipAddress {
+			continue
+		}
+		return true
+	}
+	return false
+}
+
+// hostIP strips the port of the address of a node, ie 10.0.0.1:8074.
+func hostIP(address string) string {
+	host, _, err := net.SplitHostPort(address)
+	if err != nil {
+		return address
+	}
+	return host
+}
+
+// Client queries the cluster status of Mattermost through the admin API.
+type Client struct {
+	HTTPClient *http.Client
+}
+
+// NewClient returns an admin API client.
+func NewClient() *Client {
+	return &Client{
+		HTTPClient: &http.Client{Timeout: 10 * time.Second},
+	}
+}
+
+// Nodes returns the cluster status reported by the Mattermost app server
+// served at the URL. The access token must have the manage_system
+// permission.
+func (c *Client) Nodes(ctx context.Context, url, token string) ([]Node, error) {
+	endpoint := strings.TrimSuffix(url, "/") + "/api/v4/cluster/status"
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, endpoint, nil)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to create cluster status request")
+	}
+	req.Header.Set("Authorization", "Bearer "+token)
+
+	resp, err := c.HTTPClient.Do(req)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to send cluster status request")
+	}
+	defer resp.Body.Close()
+
+	data, err := ioutil.ReadAll(io.LimitReader(resp.Body, maxResponseSize))
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to read cluster status")
+	}
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("Mattermost responded with status code %d to the cluster status request", resp.StatusCode)
+	}
+
+	nodes := []Node{}
+	err = json.Unmarshal(data, &nodes)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to decode cluster status")
+	}
+	return nodes, nil
+}
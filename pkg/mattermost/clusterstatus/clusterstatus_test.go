@@ -0,0 +1,43 @@
+package clusterstatus
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestHasPeers(t *testing.T) {
+	self := Node{Hostname: "mm-abc", IPAddress: "10.0.0.1"}
+	peer := Node{Hostname: "mm-def", IPAddress: "10.0.0.2:8074"}
+
+	assert.False(t, HasPeers(nil, "mm-abc", "10.0.0.1"))
+	assert.False(t, HasPeers([]Node{self}, "mm-abc", "10.0.0.1"))
+	assert.False(t, HasPeers([]Node{{IPAddress: "10.0.0.1:8074"}}, "mm-abc", "10.0.0.1"))
+	assert.True(t, HasPeers([]Node{self, peer}, "mm-abc", "10.0.0.1"))
+	assert.True(t, HasPeers([]Node{peer}, "mm-abc", "10.0.0.1"))
+}
+
+func TestNodes(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Authorization") != "Bearer token" {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		assert.Equal(t, "/api/v4/cluster/status", r.URL.Path)
+		_, _ = w.Write([]byte(`[{"id": "a", "version": "5.37.1", "hostname": "mm-abc", "ipaddress": "10.0.0.1"}]`))
+	}))
+	defer server.Close()
+
+	client := NewClient()
+
+	nodes, err := client.Nodes(context.TODO(), server.URL+"/", "token")
+	require.NoError(t, err)
+	assert.Equal(t, []Node{{ID: "a", Version: "5.37.1", Hostname: "mm-abc", IPAddress: "10.0.0.1"}}, nodes)
+
+	_, err = client.Nodes(context.TODO(), server.URL, "invalid")
+	require.Error(t, err)
+}
@@ -28,4 +28,10 @@ const (
 	// Recommended not to be too high in order to have not too many extra pods
 	// over requested `Replicas` number.
 	defaultMaxSurge = 1
+	// inPlaceUpdateMinReadySeconds is how long new pods must stay ready
+	// before the rolling update proceeds when new images are rolled out in
+	// place, without the update check job.
+	// More details:
+	// https://kubernetes.io/docs/concepts/workloads/controllers/deployment/#min-ready-seconds
+	inPlaceUpdateMinReadySeconds = 30
 )
@@ -61,6 +61,17 @@ func TestNewExternalDBInfo(t *testing.T) {
 		assert.Equal(t, "postgres:13", initContainers[0].Image)
 	})
 
+	t.Run("with utility image override", func(t *testing.T) {
+		mattermost.Spec.UtilityImages = &mmv1beta.UtilityImages{Postgres: "registry.example.com/postgres:13.12"}
+		defer func() { mattermost.Spec.UtilityImages = nil }()
+		config, err := NewExternalDBConfig(mattermost, secret)
+		require.NoError(t, err)
+
+		initContainers := config.InitContainers(mattermost)
+		assert.Equal(t, 1, len(initContainers))
+		assert.Equal(t, "registry.example.com/postgres:13.12", initContainers[0].Image)
+	})
+
 	t.Run("with disabled DB readiness check", func(t *testing.T) {
 		mattermost.Spec.Database.DisableReadinessCheck = true
 		config, err := NewExternalDBConfig(mattermost, secret)
@@ -86,7 +86,7 @@ func (m *MySQLDBConfig) InitContainers(mattermost *mmv1beta.Mattermost) []corev1
 	return []corev1.Container{
 		{
 			Name:            "init-check-operator-mysql",
-			Image:           "appropriate/curl:latest",
+			Image:           mattermost.Spec.UtilityImages.GetCurl(),
 			ImagePullPolicy: corev1.PullIfNotPresent,
 			Command: []string{
 				"sh", "-c",
@@ -97,3 +97,62 @@ func (m *MySQLDBConfig) InitContainers(mattermost *mmv1beta.Mattermost) []corev1
 		},
 	}
 }
+
+// DumpContainer returns the container writing a dump of the database to the
+// given path.
+func (m *MySQLDBConfig) DumpContainer(mattermost *mmv1beta.Mattermost, dumpPath string) (*corev1.Container, error) {
+	mysqlName := utils.HashWithPrefix("db", mattermost.Name)
+
+	return &corev1.Container{
+		Name:            databaseDumpContainerName,
+		Image:           "mysql:5.7",
+		ImagePullPolicy: corev1.PullIfNotPresent,
+		Command: []string{
+			"/bin/sh", "-c",
+			fmt.Sprintf("mysqldump --single-transaction --host %s-mysql-master.%s --user root --result-file %s $(MYSQL_DATABASE)",
+				mysqlName, mattermost.Namespace, dumpPath,
+			),
+		},
+		Env: []corev1.EnvVar{
+			{
+				Name:      "MYSQL_PWD",
+				ValueFrom: EnvSourceFromSecret(m.secretName, "ROOT_PASSWORD"),
+			},
+			{
+				Name:      "MYSQL_DATABASE",
+				ValueFrom: EnvSourceFromSecret(m.secretName, "DATABASE"),
+			},
+		},
+	}, nil
+}
+
+// RestoreContainer returns the container restoring the database from the
+// dump at the given path and validating the result.
+func (m *MySQLDBConfig) RestoreContainer(mattermost *mmv1beta.Mattermost, dumpPath string) (*corev1.Container, error) {
+	mysql := fmt.Sprintf("mysql --host %s-mysql-master.%s --user root $(MYSQL_DATABASE)",
+		utils.HashWithPrefix("db", mattermost.Name), mattermost.Namespace,
+	)
+
+	return &corev1.Container{
+		Name:            databaseRestoreContainerName,
+		Image:           "mysql:5.7",
+		ImagePullPolicy: corev1.PullIfNotPresent,
+		Command: []string{
+			"/bin/sh", "-c",
+			fmt.Sprintf("%s < %s && %s",
+				mysql, dumpPath,
+				databaseValidationScript(fmt.Sprintf("%s --batch --skip-column-names --execute", mysql)),
+			),
+		},
+		Env: []corev1.EnvVar{
+			{
+				Name:      "MYSQL_PWD",
+				ValueFrom: EnvSourceFromSecret(m.secretName, "ROOT_PASSWORD"),
+			},
+			{
+				Name:      "MYSQL_DATABASE",
+				ValueFrom: EnvSourceFromSecret(m.secretName, "DATABASE"),
+			},
+		},
+	}, nil
+}
@@ -0,0 +1,40 @@
+package mattermost
+
+import (
+	"time"
+
+	mmv1beta "github.com/mattermost/mattermost-operator/apis/mattermost/v1beta1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+const (
+	// ECRCredentialsRefreshedAtAnnotation holds when the ECR image pull
+	// Secret was last refreshed.
+	ECRCredentialsRefreshedAtAnnotation = "installation.mattermost.com/ecr-credentials-refreshed-at"
+	// ECRCredentialsExpiresAtAnnotation holds when the ECR authorization
+	// token of the image pull Secret expires.
+	ECRCredentialsExpiresAtAnnotation = "installation.mattermost.com/ecr-credentials-expires-at"
+)
+
+// GenerateECRPullSecretV1Beta returns the image pull Secret with the Docker
+// config of the ECR registry, annotated with the refresh and expiry times
+// of the authorization token.
+func GenerateECRPullSecretV1Beta(mattermost *mmv1beta.Mattermost, dockerConfig []byte, refreshedAt, expiresAt time.Time) *corev1.Secret {
+	return &corev1.Secret{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:            mattermost.ECRPullSecretName(),
+			Namespace:       mattermost.Namespace,
+			Labels:          mmv1beta.MattermostResourceLabels(mattermost.Name),
+			OwnerReferences: MattermostOwnerReference(mattermost),
+			Annotations: map[string]string{
+				ECRCredentialsRefreshedAtAnnotation: refreshedAt.UTC().Format(time.RFC3339),
+				ECRCredentialsExpiresAtAnnotation:   expiresAt.UTC().Format(time.RFC3339),
+			},
+		},
+		Type: corev1.SecretTypeDockerConfigJson,
+		Data: map[string][]byte{
+			corev1.DockerConfigJsonKey: dockerConfig,
+		},
+	}
+}
@@ -3,11 +3,12 @@ package mattermost
 import (
 	"strconv"
 
+	mmv1beta "github.com/mattermost/mattermost-operator/apis/mattermost/v1beta1"
 	corev1 "k8s.io/api/core/v1"
 )
 
-func generalMattermostEnvVars(siteURL string) []corev1.EnvVar {
-	return []corev1.EnvVar{
+func generalMattermostEnvVars(siteURL string, clustering bool) []corev1.EnvVar {
+	envVars := []corev1.EnvVar{
 		{
 			Name:  "MM_SERVICESETTINGS_SITEURL",
 			Value: siteURL,
@@ -24,26 +25,22 @@ func generalMattermostEnvVars(siteURL string) []corev1.EnvVar {
 			Name:  "MM_METRICSSETTINGS_LISTENADDRESS",
 			Value: ":8067",
 		},
-		{
-			Name:  "MM_CLUSTERSETTINGS_ENABLE",
-			Value: "true",
-		},
-		{
-			Name:  "MM_CLUSTERSETTINGS_CLUSTERNAME",
-			Value: "production",
-		},
-		{
-			Name:  "MM_INSTALL_TYPE",
-			Value: "kubernetes-operator",
-		},
 	}
+	if clustering {
+		envVars = append(envVars, clusterEnvVars()...)
+	}
+
+	return append(envVars, corev1.EnvVar{
+		Name:  "MM_INSTALL_TYPE",
+		Value: "kubernetes-operator",
+	})
 }
 
 func fileStoreEnvVars(fileStore *FileStoreInfo) []corev1.EnvVar {
 	minioAccessEnv := EnvSourceFromSecret(fileStore.secretName, fileStoreSecretAccessKey)
 	minioSecretEnv := EnvSourceFromSecret(fileStore.secretName, fileStoreSecretSecretKey)
 
-	return []corev1.EnvVar{
+	envs := []corev1.EnvVar{
 		{
 			Name:  "MM_FILESETTINGS_DRIVERNAME",
 			Value: "amazons3",
@@ -69,6 +66,17 @@ func fileStoreEnvVars(fileStore *FileStoreInfo) []corev1.EnvVar {
 			Value: strconv.FormatBool(fileStore.useS3SSL),
 		},
 	}
+
+	if fileStore.encryption != nil {
+		// With SSE-KMS objects are encrypted by the bucket default encryption,
+		// requesting SSE-S3 from Mattermost would override the KMS key.
+		envs = append(envs, corev1.EnvVar{
+			Name:  "MM_FILESETTINGS_AMAZONS3SSE",
+			Value: strconv.FormatBool(fileStore.encryption.Mode == mmv1beta.SSES3),
+		})
+	}
+
+	return envs
 }
 
 func elasticSearchEnvVars(host, user, password string) []corev1.EnvVar {
@@ -0,0 +1,172 @@
+package mattermost
+
+import (
+	"errors"
+	"fmt"
+	"path"
+
+	mattermostv1alpha1 "github.com/mattermost/mattermost-operator/apis/mattermost/v1alpha1"
+	mmv1beta "github.com/mattermost/mattermost-operator/apis/mattermost/v1beta1"
+	appsv1 "k8s.io/api/apps/v1"
+	batchv1 "k8s.io/api/batch/v1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+const (
+	// ExportIDAnnotation holds the identifier of the export run by the
+	// export Job.
+	ExportIDAnnotation = "installation.mattermost.com/export-id"
+
+	exportContainerName       = "export-workspace"
+	uploadExportContainerName = "upload-export"
+	exportVolumeName          = "export"
+	exportVolumeMountPath     = "/export"
+)
+
+// ExportJobName returns the name of the Job exporting the workspace of the
+// Mattermost installation.
+func ExportJobName(mattermost *mmv1beta.Mattermost) string {
+	return fmt.Sprintf("%s-export", mattermost.Name)
+}
+
+// ExportURL returns the URL of the export archive in the destination bucket.
+func ExportURL(mattermost *mmv1beta.Mattermost, destination *FileStoreInfo) string {
+	return fmt.Sprintf("%s/%s", destination.endpoint(), exportObjectPath(mattermost, destination))
+}
+
+func exportObjectPath(mattermost *mmv1beta.Mattermost, destination *FileStoreInfo) string {
+	export := mattermost.Spec.Jobs.Export
+	return path.Join(destination.bucketName, export.Destination.Prefix, fmt.Sprintf("%s-%s.tar.gz", mattermost.Name, export.ID))
+}
+
+// GenerateExportJobV1Beta returns the Job exporting the workspace with the
+// Mattermost bulk export and uploading the archive to the destination. The
+// export runs with the configuration of the Mattermost deployment.
+func GenerateExportJobV1Beta(mattermost *mmv1beta.Mattermost, deployment *appsv1.Deployment, destination *FileStoreInfo) (*batchv1.Job, error) {
+	backoffLimit := int32(3)
+	name := ExportJobName(mattermost)
+	volumeMount := corev1.VolumeMount{
+		Name:      exportVolumeName,
+		MountPath: exportVolumeMountPath,
+	}
+
+	podSpec := deployment.Spec.Template.Spec.DeepCopy()
+	exportContainer, err := mattermostCommandContainer(podSpec, exportContainerName, exportCommand(mattermost.Spec.Jobs.Export))
+	if err != nil {
+		return nil, err
+	}
+	exportContainer.VolumeMounts = append(exportContainer.VolumeMounts, volumeMount)
+
+	podSpec.RestartPolicy = corev1.RestartPolicyNever
+	podSpec.InitContainers = append(podSpec.InitContainers, *exportContainer)
+	podSpec.Containers = []corev1.Container{
+		{
+			Name:            uploadExportContainerName,
+			Image:           mattermost.Spec.UtilityImages.GetMinioClient(),
+			ImagePullPolicy: corev1.PullIfNotPresent,
+			Command: []string{
+				"/bin/sh", "-c",
+				uploadExportCommand(mattermost, destination),
+			},
+			Env: []corev1.EnvVar{
+				{
+					Name:      "TARGET_ACCESS_KEY",
+					ValueFrom: EnvSourceFromSecret(destination.secretName, fileStoreSecretAccessKey),
+				},
+				{
+					Name:      "TARGET_SECRET_KEY",
+					ValueFrom: EnvSourceFromSecret(destination.secretName, fileStoreSecretSecretKey),
+				},
+			},
+			VolumeMounts:             []corev1.VolumeMount{volumeMount},
+			TerminationMessagePolicy: corev1.TerminationMessageFallbackToLogsOnError,
+		},
+	}
+	podSpec.Volumes = append(podSpec.Volumes, corev1.Volume{
+		Name: exportVolumeName,
+		VolumeSource: corev1.VolumeSource{
+			EmptyDir: &corev1.EmptyDirVolumeSource{},
+		},
+	})
+	setPodImageRegistry(mattermost, podSpec)
+	SetJobPodSettings(mattermost, podSpec)
+
+	return &batchv1.Job{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:            name,
+			Namespace:       mattermost.Namespace,
+			Labels:          mmv1beta.MattermostResourceLabels(mattermost.Name),
+			OwnerReferences: MattermostOwnerReference(mattermost),
+			Annotations: map[string]string{
+				ExportIDAnnotation: mattermost.Spec.Jobs.Export.ID,
+			},
+		},
+		Spec: batchv1.JobSpec{
+			BackoffLimit: &backoffLimit,
+			Template: corev1.PodTemplateSpec{
+				ObjectMeta: metav1.ObjectMeta{
+					Labels: map[string]string{"app": name},
+				},
+				Spec: *podSpec,
+			},
+		},
+	}, nil
+}
+
+// mattermostCommandContainer returns a copy of the Mattermost container of
+// the deployment pod spec running the shell command instead of the server,
+// so that the command runs with the configuration of the deployment.
+func mattermostCommandContainer(podSpec *corev1.PodSpec, name, command string) (*corev1.Container, error) {
+	index, found := FindContainer(mattermostv1alpha1.MattermostAppContainerName, podSpec.Containers)
+	if !found {
+		return nil, errors.New("Mattermost container not found in deployment")
+	}
+
+	container := podSpec.Containers[index].DeepCopy()
+	// Image variants with the Mattermost binary outside of the PATH run it
+	// with an absolute path.
+	if len(container.Command) > 0 && path.IsAbs(container.Command[0]) {
+		command = fmt.Sprintf("export PATH=%s:$PATH && %s", shellQuote(path.Dir(container.Command[0])), command)
+	}
+	container.Name = name
+	container.Command = []string{"/bin/sh", "-c", command}
+	container.Ports = nil
+	container.LivenessProbe = nil
+	container.ReadinessProbe = nil
+
+	return container, nil
+}
+
+// exportCommand returns the shell script exporting all teams of the
+// workspace and archiving the export.
+func exportCommand(export *mmv1beta.ExportJob) string {
+	workspacePath := path.Join(exportVolumeMountPath, "workspace")
+
+	exportFlags := "--all-teams"
+	if export.IncludeAttachments {
+		exportFlags += " --attachments"
+	}
+
+	return fmt.Sprintf("mkdir -p %s && "+
+		"mattermost export bulk %s %s && "+
+		"tar -czf %s -C %s .",
+		shellQuote(workspacePath),
+		shellQuote(path.Join(workspacePath, "export.jsonl")), exportFlags,
+		shellQuote(path.Join(exportVolumeMountPath, "export.tar.gz")), shellQuote(workspacePath),
+	)
+}
+
+// uploadExportCommand returns the shell script uploading the export archive
+// to the destination. The URL of the archive is written to the termination
+// log to be reported in the Mattermost status.
+func uploadExportCommand(mattermost *mmv1beta.Mattermost, destination *FileStoreInfo) string {
+	return fmt.Sprintf("mc config host add target %s $(TARGET_ACCESS_KEY) $(TARGET_SECRET_KEY) && "+
+		"mc cp %s %s && "+
+		"echo %s > /dev/termination-log",
+		shellQuote(destination.endpoint()),
+		shellQuote(path.Join(exportVolumeMountPath, "export.tar.gz")),
+		shellQuote(fmt.Sprintf("target/%s", exportObjectPath(mattermost, destination))),
+		shellQuote(ExportURL(mattermost, destination)),
+	)
+}
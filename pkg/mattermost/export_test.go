@@ -0,0 +1,98 @@
+package mattermost
+
+import (
+	"strings"
+	"testing"
+
+	mmv1beta "github.com/mattermost/mattermost-operator/apis/mattermost/v1beta1"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func TestGenerateExportJob(t *testing.T) {
+	mattermost := &mmv1beta.Mattermost{
+		ObjectMeta: metav1.ObjectMeta{Name: "mm-test", Namespace: "mm-namespace"},
+		Spec: mmv1beta.MattermostSpec{
+			LicenseSecret: "license-secret",
+			Jobs: &mmv1beta.Jobs{
+				Export: &mmv1beta.ExportJob{
+					ID: "2021-06-01",
+					Destination: mmv1beta.BackupDestination{
+						URL:    "s3.amazonaws.com",
+						Bucket: "exports",
+						Prefix: "mattermost",
+						Secret: "export-secret",
+					},
+				},
+			},
+		},
+	}
+
+	db := &ExternalDBConfig{secretName: "db-secret"}
+	fileStore := NewOperatorManagedFileStoreInfo(mattermost, "minio-secret", "mm-test-minio-hl-svc.mm-namespace.svc.cluster.local:9000")
+	deployment := GenerateDeploymentV1Beta(mattermost, db, fileStore, "mm-test", "mm.example.com", "mm-test", "mattermost/mattermost-enterprise-edition:5.35.0")
+
+	destination, err := NewBackupDestinationInfo(mattermost.Spec.Jobs.Export.Destination, corev1.Secret{
+		ObjectMeta: metav1.ObjectMeta{Name: "export-secret"},
+		Data: map[string][]byte{
+			"accesskey": []byte("access"),
+			"secretkey": []byte("secret"),
+		},
+	})
+	require.NoError(t, err)
+
+	assert.Equal(t, "https://s3.amazonaws.com/exports/mattermost/mm-test-2021-06-01.tar.gz", ExportURL(mattermost, destination))
+
+	t.Run("without attachments", func(t *testing.T) {
+		job, err := GenerateExportJobV1Beta(mattermost, deployment, destination)
+		require.NoError(t, err)
+		assert.Equal(t, "mm-test-export", job.Name)
+		assert.Equal(t, "2021-06-01", job.Annotations[ExportIDAnnotation])
+
+		podSpec := job.Spec.Template.Spec
+		assert.Equal(t, corev1.RestartPolicyNever, podSpec.RestartPolicy)
+
+		exportContainer := podSpec.InitContainers[len(podSpec.InitContainers)-1]
+		assert.Equal(t, exportContainerName, exportContainer.Name)
+		assert.Equal(t, "mattermost/mattermost-enterprise-edition:5.35.0", exportContainer.Image)
+		assert.Equal(t, deployment.Spec.Template.Spec.Containers[0].Env, exportContainer.Env)
+		assert.Nil(t, exportContainer.ReadinessProbe)
+		assert.Nil(t, exportContainer.LivenessProbe)
+		assert.Contains(t, exportContainer.Command[2], "mattermost export bulk '/export/workspace/export.jsonl' --all-teams && ")
+		assert.NotContains(t, exportContainer.Command[2], "--attachments")
+
+		// The license volume of the deployment is kept.
+		assert.Len(t, podSpec.Volumes, len(deployment.Spec.Template.Spec.Volumes)+1)
+
+		require.Len(t, podSpec.Containers, 1)
+		command := podSpec.Containers[0].Command[2]
+		assert.Contains(t, command, "mc cp '/export/export.tar.gz' 'target/exports/mattermost/mm-test-2021-06-01.tar.gz'")
+		assert.Contains(t, command, "echo 'https://s3.amazonaws.com/exports/mattermost/mm-test-2021-06-01.tar.gz' > /dev/termination-log")
+	})
+
+	t.Run("with attachments", func(t *testing.T) {
+		withAttachments := mattermost.DeepCopy()
+		withAttachments.Spec.Jobs.Export.IncludeAttachments = true
+
+		job, err := GenerateExportJobV1Beta(withAttachments, deployment, destination)
+		require.NoError(t, err)
+
+		initContainers := job.Spec.Template.Spec.InitContainers
+		assert.Contains(t, initContainers[len(initContainers)-1].Command[2], "--all-teams --attachments")
+	})
+
+	t.Run("image variant outside of the PATH", func(t *testing.T) {
+		ubi := mattermost.DeepCopy()
+		ubi.Spec.ImageVariant = mmv1beta.ImageVariantUBI
+		ubiDeployment := GenerateDeploymentV1Beta(ubi, db, fileStore, "mm-test", "mm.example.com", "mm-test", ubi.GetImageName())
+		assert.Equal(t, []string{"/opt/mattermost/bin/mattermost"}, ubiDeployment.Spec.Template.Spec.Containers[0].Command)
+
+		job, err := GenerateExportJobV1Beta(ubi, ubiDeployment, destination)
+		require.NoError(t, err)
+
+		initContainers := job.Spec.Template.Spec.InitContainers
+		assert.True(t, strings.HasPrefix(initContainers[len(initContainers)-1].Command[2], "export PATH='/opt/mattermost/bin':$PATH && mkdir -p "))
+	})
+}
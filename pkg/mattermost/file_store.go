@@ -1,8 +1,10 @@
 package mattermost
 
 import (
+	"encoding/json"
 	"errors"
 	"fmt"
+	"strings"
 
 	mmv1beta "github.com/mattermost/mattermost-operator/apis/mattermost/v1beta1"
 	corev1 "k8s.io/api/core/v1"
@@ -18,30 +20,58 @@ type FileStoreInfo struct {
 	bucketName string
 	url        string
 	useS3SSL   bool
+	encryption *mmv1beta.FileStoreEncryption
 	config     FileStoreConfig
 }
 
-type ExternalFileStore struct{}
+type ExternalFileStore struct {
+	secretName string
+	bucketName string
+	url        string
+	encryption *mmv1beta.FileStoreEncryption
+	lifecycle  *mmv1beta.FileStoreLifecycle
+}
 
-func (e *ExternalFileStore) InitContainers(_ *mmv1beta.Mattermost) []corev1.Container {
+func (e *ExternalFileStore) InitContainers(mattermost *mmv1beta.Mattermost) []corev1.Container {
 	return []corev1.Container{}
 }
 
+// bucketCommands returns the mc commands configuring the encryption and
+// lifecycle of the bucket, none if both are left as they are.
+func (e *ExternalFileStore) bucketCommands() []string {
+	bucketPath := fmt.Sprintf("externalstore/%s", e.bucketName)
+
+	var bucketCommands []string
+	if e.encryption != nil {
+		bucketCommands = append(bucketCommands, bucketEncryptionCommand(bucketPath, e.encryption))
+	}
+	if e.lifecycle != nil {
+		bucketCommands = append(bucketCommands, bucketLifecycleCommand(bucketPath, e.lifecycle))
+	}
+	return bucketCommands
+}
+
 type OperatorManagedMinioConfig struct {
 	secretName string
 	minioURL   string
+	lifecycle  *mmv1beta.FileStoreLifecycle
 }
 
 func (e *OperatorManagedMinioConfig) InitContainers(mattermost *mmv1beta.Mattermost) []corev1.Container {
+	bucketCommand := fmt.Sprintf("mc config host add localminio http://%s $(MINIO_ACCESS_KEY) $(MINIO_SECRET_KEY) && mc mb localminio/%s -q -p", e.minioURL, mattermost.Name)
+	if e.lifecycle != nil {
+		bucketCommand = fmt.Sprintf("%s && %s", bucketCommand, bucketLifecycleCommand(fmt.Sprintf("localminio/%s", mattermost.Name), e.lifecycle))
+	}
+
 	initContainers := []corev1.Container{
 		// Create the init container to create the MinIO bucket
 		{
 			Name:            "create-minio-bucket",
-			Image:           "minio/mc:latest",
+			Image:           mattermost.Spec.UtilityImages.GetMinioClient(),
 			ImagePullPolicy: corev1.PullIfNotPresent,
 			Command: []string{
 				"/bin/sh", "-c",
-				fmt.Sprintf("mc config host add localminio http://%s $(MINIO_ACCESS_KEY) $(MINIO_SECRET_KEY) && mc mb localminio/%s -q -p", e.minioURL, mattermost.Name),
+				bucketCommand,
 			},
 			Env: []corev1.EnvVar{
 				{
@@ -57,7 +87,7 @@ func (e *OperatorManagedMinioConfig) InitContainers(mattermost *mmv1beta.Matterm
 		// Create the init container to check that MinIO is up and running
 		{
 			Name:            "init-check-minio",
-			Image:           "appropriate/curl:latest",
+			Image:           mattermost.Spec.UtilityImages.GetCurl(),
 			ImagePullPolicy: corev1.PullIfNotPresent,
 			Command: []string{
 				"sh", "-c",
@@ -91,12 +121,21 @@ func NewExternalFileStoreInfo(mattermost *mmv1beta.Mattermost, secret corev1.Sec
 		return nil, fmt.Errorf("external filestore Secret %s does not have an 'secretkey' value", secret.Name)
 	}
 
+	encryption := mattermost.Spec.FileStore.External.ServerSideEncryption
+
 	return &FileStoreInfo{
 		secretName: secret.Name,
 		bucketName: bucket,
 		url:        url,
 		useS3SSL:   true,
-		config:     &ExternalFileStore{},
+		encryption: encryption,
+		config: &ExternalFileStore{
+			secretName: secret.Name,
+			bucketName: bucket,
+			url:        url,
+			encryption: encryption,
+			lifecycle:  mattermost.Spec.FileStore.Lifecycle,
+		},
 	}, nil
 }
 
@@ -106,6 +145,131 @@ func NewOperatorManagedFileStoreInfo(mattermost *mmv1beta.Mattermost, secret, mi
 		bucketName: mattermost.Name,
 		url:        minioURL,
 		useS3SSL:   false,
-		config:     &OperatorManagedMinioConfig{minioURL: minioURL, secretName: secret},
+		config: &OperatorManagedMinioConfig{
+			minioURL:   minioURL,
+			secretName: secret,
+			lifecycle:  mattermost.Spec.FileStore.Lifecycle,
+		},
+	}
+}
+
+// ValidateFileStore checks that the file store settings can be applied. The
+// operator managed MinIO has no server-side encryption, as no KMS is
+// configured for it, the encryption is only set for external file stores.
+func ValidateFileStore(fileStore mmv1beta.FileStore) error {
+	for _, external := range []*mmv1beta.ExternalFileStore{fileStore.External, fileStore.MigrateTo} {
+		if external == nil || external.ServerSideEncryption == nil {
+			continue
+		}
+		encryption := external.ServerSideEncryption
+		if encryption.Mode == mmv1beta.SSEKMS && encryption.KMSKeyID == "" {
+			return errors.New("file store SSE-KMS encryption requires a KMS key ID")
+		}
+	}
+
+	if err := ValidateFileStoreLifecycle(fileStore.Lifecycle); err != nil {
+		return fmt.Errorf("invalid file store lifecycle: %w", err)
+	}
+	return nil
+}
+
+// bucketEncryptionCommand returns the mc command setting the default
+// encryption of the bucket at the given alias path.
+func bucketEncryptionCommand(bucketPath string, encryption *mmv1beta.FileStoreEncryption) string {
+	if encryption.Mode == mmv1beta.SSEKMS {
+		return fmt.Sprintf("mc encrypt set sse-kms %s %s", shellQuote(encryption.KMSKeyID), bucketPath)
 	}
+	return fmt.Sprintf("mc encrypt set sse-s3 %s", bucketPath)
+}
+
+// ValidateFileStoreLifecycle checks that the lifecycle rules can be applied
+// to the file store bucket.
+func ValidateFileStoreLifecycle(lifecycle *mmv1beta.FileStoreLifecycle) error {
+	if lifecycle == nil {
+		return nil
+	}
+	if len(lifecycle.Rules) == 0 {
+		return errors.New("file store lifecycle requires at least one rule")
+	}
+
+	ruleIDs := map[string]bool{}
+	for _, rule := range lifecycle.Rules {
+		if rule.ID == "" {
+			return errors.New("file store lifecycle rule ID is empty")
+		}
+		if ruleIDs[rule.ID] {
+			return fmt.Errorf("file store lifecycle rule ID %s is not unique", rule.ID)
+		}
+		ruleIDs[rule.ID] = true
+
+		if rule.ExpirationDays == nil && rule.TransitionDays == nil {
+			return fmt.Errorf("file store lifecycle rule %s must define an expiration or a transition", rule.ID)
+		}
+		if (rule.TransitionDays == nil) != (rule.TransitionStorageClass == "") {
+			return fmt.Errorf("file store lifecycle rule %s must define both transition days and storage class", rule.ID)
+		}
+		if rule.ExpirationDays != nil && rule.TransitionDays != nil && *rule.ExpirationDays <= *rule.TransitionDays {
+			return fmt.Errorf("file store lifecycle rule %s must expire objects after transitioning them", rule.ID)
+		}
+	}
+
+	return nil
+}
+
+type bucketLifecycleConfig struct {
+	Rules []bucketLifecycleRule `json:"Rules"`
+}
+
+type bucketLifecycleRule struct {
+	ID         string                     `json:"ID"`
+	Status     string                     `json:"Status"`
+	Filter     bucketLifecycleFilter      `json:"Filter"`
+	Expiration *bucketLifecycleExpiration `json:"Expiration,omitempty"`
+	Transition *bucketLifecycleTransition `json:"Transition,omitempty"`
+}
+
+type bucketLifecycleFilter struct {
+	Prefix string `json:"Prefix"`
+}
+
+type bucketLifecycleExpiration struct {
+	Days int32 `json:"Days"`
+}
+
+type bucketLifecycleTransition struct {
+	Days         int32  `json:"Days"`
+	StorageClass string `json:"StorageClass"`
+}
+
+// bucketLifecycleCommand returns the mc command replacing the lifecycle
+// configuration of the bucket at the given alias path.
+func bucketLifecycleCommand(bucketPath string, lifecycle *mmv1beta.FileStoreLifecycle) string {
+	config := bucketLifecycleConfig{Rules: make([]bucketLifecycleRule, 0, len(lifecycle.Rules))}
+	for _, rule := range lifecycle.Rules {
+		bucketRule := bucketLifecycleRule{
+			ID:     rule.ID,
+			Status: "Enabled",
+			Filter: bucketLifecycleFilter{Prefix: rule.Prefix},
+		}
+		if rule.ExpirationDays != nil {
+			bucketRule.Expiration = &bucketLifecycleExpiration{Days: *rule.ExpirationDays}
+		}
+		if rule.TransitionDays != nil {
+			bucketRule.Transition = &bucketLifecycleTransition{
+				Days:         *rule.TransitionDays,
+				StorageClass: rule.TransitionStorageClass,
+			}
+		}
+		config.Rules = append(config.Rules, bucketRule)
+	}
+
+	// Marshaling cannot fail as the configuration only holds strings and integers.
+	configJSON, _ := json.Marshal(config)
+
+	return fmt.Sprintf("echo %s | mc ilm import %s", shellQuote(string(configJSON)), bucketPath)
+}
+
+// shellQuote quotes the value as a single shell word.
+func shellQuote(value string) string {
+	return "'" + strings.ReplaceAll(value, "'", `'"'"'`) + "'"
 }
@@ -0,0 +1,152 @@
+package mattermost
+
+import (
+	"fmt"
+	"strings"
+
+	mmv1beta "github.com/mattermost/mattermost-operator/apis/mattermost/v1beta1"
+	batchv1 "k8s.io/api/batch/v1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+const (
+	// FileStoreMigrationTargetAnnotation holds the location of the file store
+	// the migration Job copies the files to.
+	FileStoreMigrationTargetAnnotation = "installation.mattermost.com/file-store-migration-target"
+
+	// FileStoreMigrationContainerName is the name of the container of the
+	// migration Job, whose logs report the progress of the copy.
+	FileStoreMigrationContainerName = "migrate-file-store"
+)
+
+// FileStoreMigrationJobName returns the name of the Job migrating the files
+// of the Mattermost installation.
+func FileStoreMigrationJobName(mattermost *mmv1beta.Mattermost) string {
+	return fmt.Sprintf("%s-file-store-migration", mattermost.Name)
+}
+
+// Location returns the URL of the file store bucket.
+func (fs *FileStoreInfo) Location() string {
+	return fmt.Sprintf("%s/%s", fs.endpoint(), fs.bucketName)
+}
+
+// GenerateFileStoreMigrationJobV1Beta returns the Job copying all files from
+// the source to the target file store. The Job fails if objects of the source
+// are missing from the target or differ after the copy.
+func GenerateFileStoreMigrationJobV1Beta(mattermost *mmv1beta.Mattermost, source, target *FileStoreInfo) *batchv1.Job {
+	backoffLimit := int32(3)
+	name := FileStoreMigrationJobName(mattermost)
+
+	job := &batchv1.Job{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:            name,
+			Namespace:       mattermost.Namespace,
+			Labels:          mmv1beta.MattermostResourceLabels(mattermost.Name),
+			OwnerReferences: MattermostOwnerReference(mattermost),
+			Annotations: map[string]string{
+				FileStoreMigrationTargetAnnotation: target.Location(),
+			},
+		},
+		Spec: batchv1.JobSpec{
+			BackoffLimit: &backoffLimit,
+			Template: corev1.PodTemplateSpec{
+				ObjectMeta: metav1.ObjectMeta{
+					Labels: map[string]string{"app": name},
+				},
+				Spec: corev1.PodSpec{
+					RestartPolicy: corev1.RestartPolicyNever,
+					Containers: []corev1.Container{
+						{
+							Name:            FileStoreMigrationContainerName,
+							Image:           mattermost.ImageWithRegistry(mattermost.Spec.UtilityImages.GetMinioClient()),
+							ImagePullPolicy: corev1.PullIfNotPresent,
+							Command: []string{
+								"/bin/sh", "-c",
+								fileStoreMigrationCommand(source, target),
+							},
+							Env: []corev1.EnvVar{
+								{
+									Name:      "SOURCE_ACCESS_KEY",
+									ValueFrom: EnvSourceFromSecret(source.secretName, fileStoreSecretAccessKey),
+								},
+								{
+									Name:      "SOURCE_SECRET_KEY",
+									ValueFrom: EnvSourceFromSecret(source.secretName, fileStoreSecretSecretKey),
+								},
+								{
+									Name:      "TARGET_ACCESS_KEY",
+									ValueFrom: EnvSourceFromSecret(target.secretName, fileStoreSecretAccessKey),
+								},
+								{
+									Name:      "TARGET_SECRET_KEY",
+									ValueFrom: EnvSourceFromSecret(target.secretName, fileStoreSecretSecretKey),
+								},
+							},
+							TerminationMessagePolicy: corev1.TerminationMessageReadFile,
+						},
+					},
+				},
+			},
+		},
+	}
+	SetJobPodSettings(mattermost, &job.Spec.Template.Spec)
+
+	return job
+}
+
+// fileStoreMigrationCommand returns the shell script mirroring the source
+// bucket to the target bucket. The progress of the copy is logged as
+// "copied <copied> of <total> objects" after each object. The copy is verified
+// with mc diff: objects of the source missing from the target or differing in
+// size fail the Job, objects only in the target are ignored, so that a failed
+// mirror is caught by the verification. The result of the verification is
+// written to the termination log to be reported in the Mattermost status.
+func fileStoreMigrationCommand(source, target *FileStoreInfo) string {
+	sourcePath := fmt.Sprintf("source/%s", source.bucketName)
+	targetPath := fmt.Sprintf("target/%s", target.bucketName)
+
+	return fmt.Sprintf("mc config host add source %s $(SOURCE_ACCESS_KEY) $(SOURCE_SECRET_KEY) && "+
+		"mc config host add target %s $(TARGET_ACCESS_KEY) $(TARGET_SECRET_KEY) && "+
+		"total=`mc ls --recursive %s | wc -l` && "+
+		"echo \"copied 0 of $total objects\" && "+
+		"mc mirror --overwrite --json %s %s | { copied=0; while read -r line; do "+
+		"case \"$line\" in *'\"status\":\"success\"'*) copied=$((copied+1)); echo \"copied $copied of $total objects\";; esac; "+
+		"done; } && "+
+		"mc diff %s %s > /tmp/diff && "+
+		"missing=`grep -v '^>' /tmp/diff | wc -l` && "+
+		"if [ \"$missing\" -ne 0 ]; then "+
+		"echo \"$missing objects of the source are missing or differ in the target\" | tee /dev/termination-log; exit 1; "+
+		"fi && "+
+		"source_count=`mc ls --recursive %s | wc -l` && "+
+		"echo \"copied and verified $source_count objects\" | tee /dev/termination-log",
+		shellQuote(source.endpoint()), shellQuote(target.endpoint()),
+		shellQuote(sourcePath),
+		shellQuote(sourcePath), shellQuote(targetPath),
+		shellQuote(sourcePath), shellQuote(targetPath),
+		shellQuote(sourcePath),
+	)
+}
+
+// ParseFileStoreMigrationProgress returns the number of copied and total
+// objects from the last progress line of the logs of the migration Job. It
+// returns false if the logs have no progress line.
+func ParseFileStoreMigrationProgress(logs string) (int64, int64, bool) {
+	lines := strings.Split(strings.TrimSpace(logs), "\n")
+	for i := len(lines) - 1; i >= 0; i-- {
+		var copied, total int64
+		_, err := fmt.Sscanf(lines[i], "copied %d of %d objects", &copied, &total)
+		if err == nil {
+			return copied, total, true
+		}
+	}
+	return 0, 0, false
+}
+
+func (fs *FileStoreInfo) endpoint() string {
+	scheme := "http"
+	if fs.useS3SSL {
+		scheme = "https"
+	}
+	return fmt.Sprintf("%s://%s", scheme, fs.url)
+}
@@ -0,0 +1,70 @@
+package mattermost
+
+import (
+	"testing"
+
+	mmv1beta "github.com/mattermost/mattermost-operator/apis/mattermost/v1beta1"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func TestGenerateFileStoreMigrationJob(t *testing.T) {
+	mattermost := &mmv1beta.Mattermost{
+		ObjectMeta: metav1.ObjectMeta{Name: "mm-test", Namespace: "mm-namespace"},
+	}
+
+	source := NewOperatorManagedFileStoreInfo(mattermost, "minio-secret", "mm-test-minio-hl-svc.mm-namespace.svc.cluster.local:9000")
+	target := &FileStoreInfo{
+		secretName: "s3-secret",
+		bucketName: "s3-bucket",
+		url:        "s3.amazonaws.com",
+		useS3SSL:   true,
+		config:     &ExternalFileStore{},
+	}
+
+	job := GenerateFileStoreMigrationJobV1Beta(mattermost, source, target)
+	assert.Equal(t, "mm-test-file-store-migration", job.Name)
+	assert.Equal(t, "mm-namespace", job.Namespace)
+	assert.Equal(t, "https://s3.amazonaws.com/s3-bucket", job.Annotations[FileStoreMigrationTargetAnnotation])
+
+	require.Len(t, job.Spec.Template.Spec.Containers, 1)
+	container := job.Spec.Template.Spec.Containers[0]
+	require.Len(t, container.Command, 3)
+	assert.Contains(t, container.Command[2], "mc config host add source 'http://mm-test-minio-hl-svc.mm-namespace.svc.cluster.local:9000' $(SOURCE_ACCESS_KEY) $(SOURCE_SECRET_KEY)")
+	assert.Contains(t, container.Command[2], "mc config host add target 'https://s3.amazonaws.com' $(TARGET_ACCESS_KEY) $(TARGET_SECRET_KEY)")
+	assert.Contains(t, container.Command[2], "total=`mc ls --recursive 'source/mm-test' | wc -l`")
+	assert.Contains(t, container.Command[2], "mc mirror --overwrite --json 'source/mm-test' 'target/s3-bucket'")
+	assert.Contains(t, container.Command[2], `echo "copied $copied of $total objects"`)
+	// Objects only in the target, ie already there before the migration,
+	// do not fail the verification.
+	assert.Contains(t, container.Command[2], "mc diff 'source/mm-test' 'target/s3-bucket' > /tmp/diff")
+	assert.Contains(t, container.Command[2], "grep -v '^>' /tmp/diff")
+	assert.NotContains(t, container.Command[2], "mc ls --recursive 'target/s3-bucket'")
+
+	secretRefs := map[string]string{}
+	for _, env := range container.Env {
+		secretRefs[env.Name] = env.ValueFrom.SecretKeyRef.Name
+	}
+	assert.Equal(t, map[string]string{
+		"SOURCE_ACCESS_KEY": "minio-secret",
+		"SOURCE_SECRET_KEY": "minio-secret",
+		"TARGET_ACCESS_KEY": "s3-secret",
+		"TARGET_SECRET_KEY": "s3-secret",
+	}, secretRefs)
+}
+
+func TestParseFileStoreMigrationProgress(t *testing.T) {
+	copied, total, found := ParseFileStoreMigrationProgress("Added `source` successfully.\ncopied 0 of 12 objects\ncopied 1 of 12 objects\ncopied 2 of 12 objects\n")
+	assert.True(t, found)
+	assert.Equal(t, int64(2), copied)
+	assert.Equal(t, int64(12), total)
+
+	copied, total, found = ParseFileStoreMigrationProgress("copied 5 of 12 objects\nmc: <ERROR> Failed to copy.")
+	assert.True(t, found)
+	assert.Equal(t, int64(5), copied)
+	assert.Equal(t, int64(12), total)
+
+	_, _, found = ParseFileStoreMigrationProgress("Added `source` successfully.")
+	assert.False(t, found)
+}
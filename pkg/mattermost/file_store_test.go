@@ -4,6 +4,7 @@ import (
 	"testing"
 
 	mmv1beta "github.com/mattermost/mattermost-operator/apis/mattermost/v1beta1"
+	"github.com/mattermost/mattermost-operator/pkg/utils"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 	corev1 "k8s.io/api/core/v1"
@@ -64,3 +65,206 @@ func TestFileStore(t *testing.T) {
 		assert.Equal(t, true, fileStore.useS3SSL)
 	})
 }
+
+func TestBucketConfigurationJob(t *testing.T) {
+	mattermost := &mmv1beta.Mattermost{
+		ObjectMeta: metav1.ObjectMeta{Name: "mm-test", Namespace: "mm-namespace"},
+		Spec: mmv1beta.MattermostSpec{
+			FileStore: mmv1beta.FileStore{
+				External: &mmv1beta.ExternalFileStore{
+					URL:    "s3.amazonaws.com",
+					Bucket: "test-bucket",
+					Secret: "external-file-store",
+					ServerSideEncryption: &mmv1beta.FileStoreEncryption{
+						Mode:     mmv1beta.SSEKMS,
+						KMSKeyID: "key'; rm -rf /",
+					},
+				},
+			},
+		},
+	}
+	secret := corev1.Secret{
+		ObjectMeta: metav1.ObjectMeta{Name: "external-file-store"},
+		Data: map[string][]byte{
+			"accesskey": []byte("key"),
+			"secretkey": []byte("secret"),
+		},
+	}
+
+	fileStore, err := NewExternalFileStoreInfo(mattermost, secret)
+	require.NoError(t, err)
+	assert.Empty(t, fileStore.config.InitContainers(mattermost))
+
+	job := GenerateBucketConfigurationJobV1Beta(mattermost, fileStore)
+	require.NotNil(t, job)
+	container := job.Spec.Template.Spec.Containers[0]
+	assert.Equal(t, mmv1beta.DefaultMinioClientImage, container.Image)
+	assert.Contains(t, container.Command[2], `mc encrypt set sse-kms 'key'"'"'; rm -rf /' externalstore/test-bucket`)
+	hash := job.Annotations[BucketConfigurationHashAnnotation]
+	assert.NotEmpty(t, hash)
+
+	t.Run("hash changes with the configuration", func(t *testing.T) {
+		mattermost := mattermost.DeepCopy()
+		mattermost.Spec.FileStore.External.ServerSideEncryption = &mmv1beta.FileStoreEncryption{Mode: mmv1beta.SSES3}
+		fileStore, err := NewExternalFileStoreInfo(mattermost, secret)
+		require.NoError(t, err)
+
+		job := GenerateBucketConfigurationJobV1Beta(mattermost, fileStore)
+		require.NotNil(t, job)
+		assert.NotEqual(t, hash, job.Annotations[BucketConfigurationHashAnnotation])
+	})
+
+	t.Run("nothing to configure", func(t *testing.T) {
+		mattermost := mattermost.DeepCopy()
+		mattermost.Spec.FileStore.External.ServerSideEncryption = nil
+		fileStore, err := NewExternalFileStoreInfo(mattermost, secret)
+		require.NoError(t, err)
+		assert.Nil(t, GenerateBucketConfigurationJobV1Beta(mattermost, fileStore))
+	})
+
+	t.Run("operator managed Minio", func(t *testing.T) {
+		fileStore := NewOperatorManagedFileStoreInfo(mattermost, "file-store-secret", "minio:9000")
+		assert.Nil(t, GenerateBucketConfigurationJobV1Beta(mattermost, fileStore))
+	})
+}
+
+func TestValidateFileStore(t *testing.T) {
+	external := &mmv1beta.ExternalFileStore{URL: "s3.amazonaws.com", Bucket: "test-bucket"}
+	withEncryption := func(encryption mmv1beta.FileStoreEncryption) *mmv1beta.ExternalFileStore {
+		store := external.DeepCopy()
+		store.ServerSideEncryption = &encryption
+		return store
+	}
+
+	assert.NoError(t, ValidateFileStore(mmv1beta.FileStore{}))
+	assert.NoError(t, ValidateFileStore(mmv1beta.FileStore{External: external}))
+	assert.NoError(t, ValidateFileStore(mmv1beta.FileStore{
+		External: withEncryption(mmv1beta.FileStoreEncryption{Mode: mmv1beta.SSES3}),
+	}))
+	assert.NoError(t, ValidateFileStore(mmv1beta.FileStore{
+		External: withEncryption(mmv1beta.FileStoreEncryption{Mode: mmv1beta.SSEKMS, KMSKeyID: "key-id"}),
+	}))
+	assert.Error(t, ValidateFileStore(mmv1beta.FileStore{
+		External: withEncryption(mmv1beta.FileStoreEncryption{Mode: mmv1beta.SSEKMS}),
+	}))
+	assert.Error(t, ValidateFileStore(mmv1beta.FileStore{
+		MigrateTo: withEncryption(mmv1beta.FileStoreEncryption{Mode: mmv1beta.SSEKMS}),
+	}))
+	assert.Error(t, ValidateFileStore(mmv1beta.FileStore{
+		Lifecycle: &mmv1beta.FileStoreLifecycle{},
+	}))
+}
+
+func TestFileStoreLifecycle(t *testing.T) {
+	mattermost := &mmv1beta.Mattermost{
+		ObjectMeta: metav1.ObjectMeta{Name: "mm-test"},
+		Spec: mmv1beta.MattermostSpec{
+			FileStore: mmv1beta.FileStore{
+				Lifecycle: &mmv1beta.FileStoreLifecycle{
+					Rules: []mmv1beta.FileStoreLifecycleRule{
+						{
+							ID:             "expire-temp",
+							Prefix:         "temp/",
+							ExpirationDays: utils.NewInt32(1),
+						},
+						{
+							ID:                     "archive-exports",
+							Prefix:                 "export/",
+							TransitionDays:         utils.NewInt32(30),
+							TransitionStorageClass: "GLACIER",
+						},
+					},
+				},
+			},
+		},
+	}
+
+	expectedImport := `echo '{"Rules":[` +
+		`{"ID":"expire-temp","Status":"Enabled","Filter":{"Prefix":"temp/"},"Expiration":{"Days":1}},` +
+		`{"ID":"archive-exports","Status":"Enabled","Filter":{"Prefix":"export/"},"Transition":{"Days":30,"StorageClass":"GLACIER"}}` +
+		`]}' | mc ilm import`
+
+	t.Run("operator managed Minio", func(t *testing.T) {
+		fileStore := NewOperatorManagedFileStoreInfo(mattermost, "file-store-secret", "minio:9000")
+		initContainers := fileStore.config.InitContainers(mattermost)
+		require.Equal(t, 2, len(initContainers))
+		assert.Contains(t, initContainers[0].Command[2], expectedImport+" localminio/mm-test")
+	})
+
+	t.Run("external file store", func(t *testing.T) {
+		mattermost := mattermost.DeepCopy()
+		mattermost.Spec.FileStore.External = &mmv1beta.ExternalFileStore{
+			URL:    "s3.amazonaws.com",
+			Bucket: "test-bucket",
+			Secret: "external-file-store",
+		}
+		secret := corev1.Secret{
+			ObjectMeta: metav1.ObjectMeta{Name: "external-file-store"},
+			Data: map[string][]byte{
+				"accesskey": []byte("key"),
+				"secretkey": []byte("secret"),
+			},
+		}
+
+		fileStore, err := NewExternalFileStoreInfo(mattermost, secret)
+		require.NoError(t, err)
+		assert.Empty(t, fileStore.config.InitContainers(mattermost))
+
+		job := GenerateBucketConfigurationJobV1Beta(mattermost, fileStore)
+		require.NotNil(t, job)
+		assert.Equal(t, "mm-test-configure-bucket", job.Name)
+		assert.Contains(t, job.Spec.Template.Spec.Containers[0].Command[2], expectedImport+" externalstore/test-bucket")
+	})
+
+	t.Run("validate", func(t *testing.T) {
+		for _, testCase := range []struct {
+			name  string
+			rules []mmv1beta.FileStoreLifecycleRule
+			err   string
+		}{
+			{
+				name:  "valid rules",
+				rules: mattermost.Spec.FileStore.Lifecycle.Rules,
+			},
+			{
+				name:  "no rules",
+				rules: []mmv1beta.FileStoreLifecycleRule{},
+				err:   "file store lifecycle requires at least one rule",
+			},
+			{
+				name: "duplicated rule ID",
+				rules: []mmv1beta.FileStoreLifecycleRule{
+					{ID: "rule", ExpirationDays: utils.NewInt32(1)},
+					{ID: "rule", ExpirationDays: utils.NewInt32(2)},
+				},
+				err: "file store lifecycle rule ID rule is not unique",
+			},
+			{
+				name:  "no action",
+				rules: []mmv1beta.FileStoreLifecycleRule{{ID: "rule"}},
+				err:   "file store lifecycle rule rule must define an expiration or a transition",
+			},
+			{
+				name:  "transition without storage class",
+				rules: []mmv1beta.FileStoreLifecycleRule{{ID: "rule", TransitionDays: utils.NewInt32(1)}},
+				err:   "file store lifecycle rule rule must define both transition days and storage class",
+			},
+			{
+				name: "expiration before transition",
+				rules: []mmv1beta.FileStoreLifecycleRule{
+					{ID: "rule", ExpirationDays: utils.NewInt32(10), TransitionDays: utils.NewInt32(30), TransitionStorageClass: "GLACIER"},
+				},
+				err: "file store lifecycle rule rule must expire objects after transitioning them",
+			},
+		} {
+			t.Run(testCase.name, func(t *testing.T) {
+				err := ValidateFileStoreLifecycle(&mmv1beta.FileStoreLifecycle{Rules: testCase.rules})
+				if testCase.err == "" {
+					assert.NoError(t, err)
+				} else {
+					assert.EqualError(t, err, testCase.err)
+				}
+			})
+		}
+	})
+}
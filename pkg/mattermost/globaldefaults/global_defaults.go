@@ -0,0 +1,103 @@
+package globaldefaults
+
+import (
+	"bytes"
+
+	mmv1beta "github.com/mattermost/mattermost-operator/apis/mattermost/v1beta1"
+	"github.com/pkg/errors"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/util/yaml"
+)
+
+// ConfigMapKey is the key of the global defaults in the ConfigMap configured
+// for the operator.
+const ConfigMapKey = "defaults"
+
+// Defaults are the platform-wide defaults of the Mattermosts, applied before
+// the defaults of the operator to the values the Mattermosts do not set.
+type Defaults struct {
+	// Image is the Mattermost image of the Mattermosts which do not set an
+	// image, an edition or an image variant.
+	Image string `json:"image,omitempty"`
+	// ImageRegistry is the registry of the images of the Mattermosts which
+	// do not set one.
+	ImageRegistry string `json:"imageRegistry,omitempty"`
+	// IngressAnnotations are added to the Ingress annotations of the
+	// Mattermosts, which take precedence.
+	IngressAnnotations map[string]string `json:"ingressAnnotations,omitempty"`
+	// ResourceLabels are added to the resource labels of the Mattermosts,
+	// which take precedence.
+	ResourceLabels map[string]string `json:"resourceLabels,omitempty"`
+	// NodeSelector of the Mattermost pods of the Mattermosts which do not set
+	// one.
+	NodeSelector map[string]string `json:"nodeSelector,omitempty"`
+	// Affinity of the Mattermost pods of the Mattermosts which do not set
+	// one.
+	Affinity *corev1.Affinity `json:"affinity,omitempty"`
+	// Tolerations of the Mattermost pods of the Mattermosts which do not set
+	// any.
+	Tolerations []corev1.Toleration `json:"tolerations,omitempty"`
+}
+
+// Parse parses global defaults in YAML or JSON.
+func Parse(data []byte) (*Defaults, error) {
+	defaults := &Defaults{}
+	err := yaml.NewYAMLOrJSONDecoder(bytes.NewReader(data), 4096).Decode(defaults)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to parse global defaults")
+	}
+	return defaults, nil
+}
+
+// Apply sets the global defaults to the values the Mattermost does not set.
+// The values set by the Mattermost are kept, even if they are the defaults
+// of the operator.
+func (d *Defaults) Apply(mattermost *mmv1beta.Mattermost) {
+	spec := &mattermost.Spec
+
+	if d.Image != "" && spec.Edition == "" && spec.ImageVariant == "" && spec.Image == "" {
+		spec.Image = d.Image
+	}
+	if spec.ImageRegistry == "" {
+		spec.ImageRegistry = d.ImageRegistry
+	}
+
+	if len(d.IngressAnnotations) > 0 {
+		if spec.Ingress != nil {
+			spec.Ingress.Annotations = merge(spec.Ingress.Annotations, d.IngressAnnotations)
+		} else {
+			spec.IngressAnnotations = merge(spec.IngressAnnotations, d.IngressAnnotations)
+		}
+	}
+	spec.ResourceLabels = merge(spec.ResourceLabels, d.ResourceLabels)
+
+	if len(spec.Scheduling.NodeSelector) == 0 && len(d.NodeSelector) > 0 {
+		spec.Scheduling.NodeSelector = merge(nil, d.NodeSelector)
+	}
+	if spec.Scheduling.Affinity == nil && d.Affinity != nil {
+		spec.Scheduling.Affinity = d.Affinity.DeepCopy()
+	}
+	if len(spec.Scheduling.Tolerations) == 0 && len(d.Tolerations) > 0 {
+		spec.Scheduling.Tolerations = make([]corev1.Toleration, len(d.Tolerations))
+		for i := range d.Tolerations {
+			d.Tolerations[i].DeepCopyInto(&spec.Scheduling.Tolerations[i])
+		}
+	}
+}
+
+// merge adds the defaults missing from the values, the values are returned
+// as is if there are no defaults.
+func merge(values, defaults map[string]string) map[string]string {
+	if len(defaults) == 0 {
+		return values
+	}
+	if values == nil {
+		values = make(map[string]string, len(defaults))
+	}
+	for key, value := range defaults {
+		if _, ok := values[key]; !ok {
+			values[key] = value
+		}
+	}
+	return values
+}
@@ -0,0 +1,91 @@
+package globaldefaults
+
+import (
+	"testing"
+
+	mmv1beta "github.com/mattermost/mattermost-operator/apis/mattermost/v1beta1"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	corev1 "k8s.io/api/core/v1"
+)
+
+func TestParse(t *testing.T) {
+	defaults, err := Parse([]byte(`
+image: registry.example.com/mattermost/mattermost-enterprise-edition
+imageRegistry: registry.example.com
+ingressAnnotations:
+  cert-manager.io/cluster-issuer: letsencrypt
+resourceLabels:
+  team: platform
+nodeSelector:
+  pool: mattermost
+tolerations:
+- key: dedicated
+  operator: Equal
+  value: mattermost
+  effect: NoSchedule
+`))
+	require.NoError(t, err)
+	assert.Equal(t, "registry.example.com", defaults.ImageRegistry)
+	assert.Equal(t, map[string]string{"team": "platform"}, defaults.ResourceLabels)
+	require.Len(t, defaults.Tolerations, 1)
+	assert.Equal(t, corev1.TaintEffectNoSchedule, defaults.Tolerations[0].Effect)
+
+	_, err = Parse([]byte("image: [invalid"))
+	assert.Error(t, err)
+}
+
+func TestApply(t *testing.T) {
+	defaults := &Defaults{
+		Image:              "registry.example.com/mattermost/mattermost-enterprise-edition",
+		ImageRegistry:      "registry.example.com",
+		IngressAnnotations: map[string]string{"cert-manager.io/cluster-issuer": "letsencrypt", "owner": "platform"},
+		ResourceLabels:     map[string]string{"team": "platform"},
+		NodeSelector:       map[string]string{"pool": "mattermost"},
+		Tolerations:        []corev1.Toleration{{Key: "dedicated", Operator: corev1.TolerationOpEqual, Value: "mattermost"}},
+	}
+
+	t.Run("missing values", func(t *testing.T) {
+		mattermost := &mmv1beta.Mattermost{}
+		defaults.Apply(mattermost)
+		assert.Equal(t, defaults.Image, mattermost.Spec.Image)
+		assert.Equal(t, "registry.example.com", mattermost.Spec.ImageRegistry)
+		assert.Equal(t, defaults.IngressAnnotations, mattermost.Spec.IngressAnnotations)
+		assert.Equal(t, defaults.ResourceLabels, mattermost.Spec.ResourceLabels)
+		assert.Equal(t, defaults.NodeSelector, mattermost.Spec.Scheduling.NodeSelector)
+		assert.Equal(t, defaults.Tolerations, mattermost.Spec.Scheduling.Tolerations)
+
+		mattermost.Spec.ResourceLabels["team"] = "chat"
+		assert.Equal(t, "platform", defaults.ResourceLabels["team"])
+	})
+
+	t.Run("values set by the Mattermost", func(t *testing.T) {
+		mattermost := &mmv1beta.Mattermost{
+			Spec: mmv1beta.MattermostSpec{
+				Image:          "mattermost/mattermost-team-edition",
+				ImageRegistry:  "mirror.example.com",
+				Ingress:        &mmv1beta.Ingress{Annotations: map[string]string{"owner": "chat"}},
+				ResourceLabels: map[string]string{"team": "chat"},
+				Scheduling:     mmv1beta.Scheduling{NodeSelector: map[string]string{"pool": "shared"}},
+			},
+		}
+		defaults.Apply(mattermost)
+		assert.Equal(t, "mattermost/mattermost-team-edition", mattermost.Spec.Image)
+		assert.Equal(t, "mirror.example.com", mattermost.Spec.ImageRegistry)
+		assert.Equal(t, map[string]string{"cert-manager.io/cluster-issuer": "letsencrypt", "owner": "chat"}, mattermost.Spec.Ingress.Annotations)
+		assert.Equal(t, map[string]string{"team": "chat"}, mattermost.Spec.ResourceLabels)
+		assert.Equal(t, map[string]string{"pool": "shared"}, mattermost.Spec.Scheduling.NodeSelector)
+	})
+
+	t.Run("default image set by the Mattermost", func(t *testing.T) {
+		mattermost := &mmv1beta.Mattermost{Spec: mmv1beta.MattermostSpec{Image: mmv1beta.DefaultMattermostImage}}
+		defaults.Apply(mattermost)
+		assert.Equal(t, mmv1beta.DefaultMattermostImage, mattermost.Spec.Image)
+	})
+
+	t.Run("default image of the edition", func(t *testing.T) {
+		mattermost := &mmv1beta.Mattermost{Spec: mmv1beta.MattermostSpec{Edition: mmv1beta.EditionTeam}}
+		defaults.Apply(mattermost)
+		assert.Empty(t, mattermost.Spec.Image)
+	})
+}
@@ -0,0 +1,41 @@
+package mattermost
+
+import (
+	"fmt"
+	"strconv"
+
+	corev1 "k8s.io/api/core/v1"
+)
+
+// goMemoryLimitPercent is the share of the memory limit of the container set
+// as the soft memory limit of the Go runtime, leaving headroom for the memory
+// the Go runtime does not manage.
+const goMemoryLimitPercent = 90
+
+// goRuntimeEnvVars returns the GOMEMLIMIT and GOMAXPROCS environment
+// variables derived from the memory and CPU limits of the container. The Go
+// runtime is otherwise not aware of the limits, it lets the heap grow past
+// the memory limit and schedules on all the CPUs of the node. Both can be
+// overridden in the Mattermost env.
+func goRuntimeEnvVars(resources corev1.ResourceRequirements) []corev1.EnvVar {
+	var envVars []corev1.EnvVar
+
+	if memory, ok := resources.Limits[corev1.ResourceMemory]; ok {
+		if limit := memory.Value() * goMemoryLimitPercent / 100 >> 20; limit > 0 {
+			envVars = append(envVars, corev1.EnvVar{
+				Name:  "GOMEMLIMIT",
+				Value: fmt.Sprintf("%dMiB", limit),
+			})
+		}
+	}
+
+	if cpu, ok := resources.Limits[corev1.ResourceCPU]; ok && !cpu.IsZero() {
+		procs := (cpu.MilliValue() + 999) / 1000
+		envVars = append(envVars, corev1.EnvVar{
+			Name:  "GOMAXPROCS",
+			Value: strconv.FormatInt(procs, 10),
+		})
+	}
+
+	return envVars
+}
@@ -0,0 +1,73 @@
+package mattermost
+
+import (
+	"testing"
+
+	mmv1beta "github.com/mattermost/mattermost-operator/apis/mattermost/v1beta1"
+	"github.com/stretchr/testify/assert"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func TestGoRuntimeEnvVars(t *testing.T) {
+	for _, tc := range []struct {
+		name     string
+		limits   corev1.ResourceList
+		expected []corev1.EnvVar
+	}{
+		{
+			name:     "no limits",
+			expected: nil,
+		},
+		{
+			name: "memory and CPU limits",
+			limits: corev1.ResourceList{
+				corev1.ResourceMemory: resource.MustParse("4Gi"),
+				corev1.ResourceCPU:    resource.MustParse("1500m"),
+			},
+			expected: []corev1.EnvVar{
+				{Name: "GOMEMLIMIT", Value: "3686MiB"},
+				{Name: "GOMAXPROCS", Value: "2"},
+			},
+		},
+		{
+			name: "fractional CPU limit",
+			limits: corev1.ResourceList{
+				corev1.ResourceCPU: resource.MustParse("200m"),
+			},
+			expected: []corev1.EnvVar{
+				{Name: "GOMAXPROCS", Value: "1"},
+			},
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			assert.Equal(t, tc.expected, goRuntimeEnvVars(corev1.ResourceRequirements{Limits: tc.limits}))
+		})
+	}
+
+	t.Run("overridden in the Mattermost env", func(t *testing.T) {
+		mattermost := &mmv1beta.Mattermost{
+			ObjectMeta: metav1.ObjectMeta{Name: "mm-test", Namespace: "mm-namespace"},
+			Spec: mmv1beta.MattermostSpec{
+				MattermostEnv: []corev1.EnvVar{{Name: "GOMEMLIMIT", Value: "2GiB"}},
+				Scheduling: mmv1beta.Scheduling{
+					Resources: corev1.ResourceRequirements{
+						Limits: corev1.ResourceList{
+							corev1.ResourceMemory: resource.MustParse("4Gi"),
+							corev1.ResourceCPU:    resource.MustParse("2"),
+						},
+					},
+				},
+			},
+		}
+		fileStore := &FileStoreInfo{config: &OperatorManagedMinioConfig{}}
+
+		deployment := GenerateDeploymentV1Beta(mattermost, &MySQLDBConfig{}, fileStore, "mm-test", "", "", "image")
+
+		env := deployment.Spec.Template.Spec.Containers[0].Env
+		assert.Contains(t, env, corev1.EnvVar{Name: "GOMEMLIMIT", Value: "2GiB"})
+		assert.NotContains(t, env, corev1.EnvVar{Name: "GOMEMLIMIT", Value: "3686MiB"})
+		assert.Contains(t, env, corev1.EnvVar{Name: "GOMAXPROCS", Value: "2"})
+	})
+}
@@ -0,0 +1,75 @@
+package healthcheck
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+// StatusOK is the status Mattermost reports for a reachable dependency.
+const StatusOK = "OK"
+
+// maxPingResponseSize limits the size of the ping response read from
+// Mattermost.
+const maxPingResponseSize = 1 << 20
+
+// ServerStatus is the status of the database and the file store of a
+// Mattermost app server, checked with the credentials it is configured with.
+type ServerStatus struct {
+	// DatabaseStatus is OK if the app server wrote and read a value back
+	// from the database.
+	DatabaseStatus string `json:"database_status"`
+	// FileStoreStatus is OK if the app server reached the bucket of the
+	// file store.
+	FileStoreStatus string `json:"filestore_status"`
+}
+
+// ConnectivityClient checks the connectivity of Mattermost to its database
+// and file store through the ping API.
+type ConnectivityClient struct {
+	HTTPClient *http.Client
+}
+
+// NewConnectivityClient returns a ping API client.
+func NewConnectivityClient() *ConnectivityClient {
+	return &ConnectivityClient{
+		HTTPClient: &http.Client{Timeout: 30 * time.Second},
+	}
+}
+
+// ServerStatus returns the status of the database and the file store
+// reported by the Mattermost app server served at the URL.
+func (c *ConnectivityClient) ServerStatus(ctx context.Context, url string) (ServerStatus, error) {
+	endpoint := strings.TrimSuffix(url, "/") + "/api/v4/system/ping?get_server_status=true"
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, endpoint, nil)
+	if err != nil {
+		return ServerStatus{}, errors.Wrap(err, "failed to create ping request")
+	}
+
+	resp, err := c.HTTPClient.Do(req)
+	if err != nil {
+		return ServerStatus{}, errors.Wrap(err, "failed to send ping request")
+	}
+	defer resp.Body.Close()
+
+	data, err := ioutil.ReadAll(io.LimitReader(resp.Body, maxPingResponseSize))
+	if err != nil {
+		return ServerStatus{}, errors.Wrap(err, "failed to read ping response")
+	}
+
+	// Mattermost responds with an error status code when a dependency is
+	// unhealthy, the status of the dependencies is still reported.
+	status := ServerStatus{}
+	err = json.Unmarshal(data, &status)
+	if err != nil || status.DatabaseStatus == "" || status.FileStoreStatus == "" {
+		return ServerStatus{}, fmt.Errorf("Mattermost responded with status code %d without the server status to the ping request", resp.StatusCode)
+	}
+	return status, nil
+}
@@ -0,0 +1,46 @@
+package healthcheck
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestServerStatus(t *testing.T) {
+	response := `{"status": "OK", "database_status": "OK", "filestore_status": "OK"}`
+	statusCode := http.StatusOK
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "/api/v4/system/ping", r.URL.Path)
+		assert.Equal(t, "true", r.URL.Query().Get("get_server_status"))
+		w.WriteHeader(statusCode)
+		_, _ = w.Write([]byte(response))
+	}))
+	defer server.Close()
+
+	client := NewConnectivityClient()
+
+	status, err := client.ServerStatus(context.TODO(), server.URL+"/")
+	require.NoError(t, err)
+	assert.Equal(t, ServerStatus{DatabaseStatus: StatusOK, FileStoreStatus: StatusOK}, status)
+
+	t.Run("unhealthy file store", func(t *testing.T) {
+		response = `{"status": "UNHEALTHY", "database_status": "OK", "filestore_status": "UNHEALTHY"}`
+		statusCode = http.StatusInternalServerError
+
+		status, err := client.ServerStatus(context.TODO(), server.URL)
+		require.NoError(t, err)
+		assert.Equal(t, ServerStatus{DatabaseStatus: StatusOK, FileStoreStatus: "UNHEALTHY"}, status)
+	})
+
+	t.Run("no server status", func(t *testing.T) {
+		response = `{"status": "OK"}`
+		statusCode = http.StatusOK
+
+		_, err := client.ServerStatus(context.TODO(), server.URL)
+		require.Error(t, err)
+	})
+}
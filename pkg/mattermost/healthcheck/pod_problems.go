@@ -0,0 +1,97 @@
+package healthcheck
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/pkg/errors"
+	corev1 "k8s.io/api/core/v1"
+)
+
+// PodProblem is a problem preventing a pod from becoming ready.
+type PodProblem struct {
+	// Pod is the name of the pod.
+	Pod string
+	// Container is the name of the container, empty if the problem is not
+	// specific to a container.
+	Container string
+	// Reason is the reason reported by Kubernetes, ie CrashLoopBackOff.
+	Reason string
+	// Message is the message reported by Kubernetes.
+	Message string
+}
+
+// String returns the problem as a message naming the offending pod and
+// container.
+func (p PodProblem) String() string {
+	prefix := fmt.Sprintf("pod %s", p.Pod)
+	if p.Container != "" {
+		prefix = fmt.Sprintf("%s container %s", prefix, p.Container)
+	}
+	if p.Message == "" {
+		return fmt.Sprintf("%s: %s", prefix, p.Reason)
+	}
+	return fmt.Sprintf("%s: %s: %s", prefix, p.Reason, p.Message)
+}
+
+// PodProblems are the first problems of each kind found in the pods.
+type PodProblems struct {
+	// CrashLoop is a container restarted repeatedly after crashing.
+	CrashLoop *PodProblem
+	// ImagePull is a container whose image cannot be pulled.
+	ImagePull *PodProblem
+	// Unschedulable is a pod which cannot be scheduled on a node.
+	Unschedulable *PodProblem
+}
+
+// imagePullReasons are the waiting reasons of containers whose image cannot
+// be pulled.
+var imagePullReasons = map[string]bool{
+	"ImagePullBackOff":  true,
+	"ErrImagePull":      true,
+	"ErrImageNeverPull": true,
+	"InvalidImageName":  true,
+}
+
+// CheckPodProblems returns the problems of the pods preventing them from
+// becoming ready. Terminating pods are skipped.
+func (hc *HealthChecker) CheckPodProblems() (PodProblems, error) {
+	pods := &corev1.PodList{}
+	err := hc.apiReader.List(context.TODO(), pods, hc.listOptions...)
+	if err != nil {
+		return PodProblems{}, errors.Wrap(err, "unable to get pod list")
+	}
+
+	problems := PodProblems{}
+	for _, pod := range pods.Items {
+		if pod.DeletionTimestamp != nil {
+			continue
+		}
+
+		for _, condition := range pod.Status.Conditions {
+			if problems.Unschedulable == nil &&
+				condition.Type == corev1.PodScheduled &&
+				condition.Status == corev1.ConditionFalse &&
+				condition.Reason == corev1.PodReasonUnschedulable {
+				problems.Unschedulable = &PodProblem{Pod: pod.Name, Reason: condition.Reason, Message: condition.Message}
+			}
+		}
+
+		containerStatuses := append(pod.Status.InitContainerStatuses, pod.Status.ContainerStatuses...)
+		for _, containerStatus := range containerStatuses {
+			waiting := containerStatus.State.Waiting
+			if waiting == nil {
+				continue
+			}
+			problem := &PodProblem{Pod: pod.Name, Container: containerStatus.Name, Reason: waiting.Reason, Message: waiting.Message}
+			if problems.CrashLoop == nil && waiting.Reason == "CrashLoopBackOff" {
+				problems.CrashLoop = problem
+			}
+			if problems.ImagePull == nil && imagePullReasons[waiting.Reason] {
+				problems.ImagePull = problem
+			}
+		}
+	}
+
+	return problems, nil
+}
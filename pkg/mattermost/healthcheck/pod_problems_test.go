@@ -0,0 +1,74 @@
+package healthcheck
+
+import (
+	"testing"
+
+	"github.com/go-logr/logr"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+)
+
+func TestCheckPodProblems(t *testing.T) {
+	labels := map[string]string{"app": "mattermost"}
+	newPod := func(name string, status corev1.PodStatus) *corev1.Pod {
+		return &corev1.Pod{
+			ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: "mm", Labels: labels},
+			Status:     status,
+		}
+	}
+	waiting := func(container, reason, message string) corev1.ContainerStatus {
+		return corev1.ContainerStatus{
+			Name:  container,
+			State: corev1.ContainerState{Waiting: &corev1.ContainerStateWaiting{Reason: reason, Message: message}},
+		}
+	}
+
+	pods := []runtime.Object{
+		newPod("healthy", corev1.PodStatus{
+			ContainerStatuses: []corev1.ContainerStatus{{Name: "mattermost", State: corev1.ContainerState{Running: &corev1.ContainerStateRunning{}}}},
+		}),
+		newPod("crashing", corev1.PodStatus{
+			InitContainerStatuses: []corev1.ContainerStatus{waiting("init-check-database", "CrashLoopBackOff", "back-off 5m0s restarting failed container")},
+		}),
+		newPod("pulling", corev1.PodStatus{
+			ContainerStatuses: []corev1.ContainerStatus{waiting("mattermost", "ErrImagePull", "manifest unknown")},
+		}),
+		newPod("pending", corev1.PodStatus{
+			Conditions: []corev1.PodCondition{{
+				Type:    corev1.PodScheduled,
+				Status:  corev1.ConditionFalse,
+				Reason:  corev1.PodReasonUnschedulable,
+				Message: "0/3 nodes are available: 3 Insufficient cpu.",
+			}},
+		}),
+		newPod("other", corev1.PodStatus{
+			ContainerStatuses: []corev1.ContainerStatus{waiting("mattermost", "CrashLoopBackOff", "")},
+		}),
+	}
+	pods[4].(*corev1.Pod).Labels = map[string]string{"app": "other"}
+
+	listOptions := []client.ListOption{client.InNamespace("mm"), client.MatchingLabels(labels)}
+	healthChecker := NewHealthChecker(fake.NewFakeClient(pods...), listOptions, logr.Discard())
+
+	problems, err := healthChecker.CheckPodProblems()
+	require.NoError(t, err)
+	require.NotNil(t, problems.CrashLoop)
+	assert.Equal(t, "pod crashing container init-check-database: CrashLoopBackOff: back-off 5m0s restarting failed container", problems.CrashLoop.String())
+	require.NotNil(t, problems.ImagePull)
+	assert.Equal(t, PodProblem{Pod: "pulling", Container: "mattermost", Reason: "ErrImagePull", Message: "manifest unknown"}, *problems.ImagePull)
+	require.NotNil(t, problems.Unschedulable)
+	assert.Equal(t, "pod pending: Unschedulable: 0/3 nodes are available: 3 Insufficient cpu.", problems.Unschedulable.String())
+
+	t.Run("no problems", func(t *testing.T) {
+		healthChecker := NewHealthChecker(fake.NewFakeClient(pods[0]), listOptions, logr.Discard())
+
+		problems, err := healthChecker.CheckPodProblems()
+		require.NoError(t, err)
+		assert.Equal(t, PodProblems{}, problems)
+	})
+}
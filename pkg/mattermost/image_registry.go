@@ -0,0 +1,21 @@
+package mattermost
+
+import (
+	mmv1beta "github.com/mattermost/mattermost-operator/apis/mattermost/v1beta1"
+	corev1 "k8s.io/api/core/v1"
+)
+
+// setImageRegistry pulls the images of the containers created by the
+// operator from the image registry of the Mattermost.
+func setImageRegistry(mattermost *mmv1beta.Mattermost, containers []corev1.Container) {
+	for i := range containers {
+		containers[i].Image = mattermost.ImageWithRegistry(containers[i].Image)
+	}
+}
+
+// setPodImageRegistry pulls the images of all containers of the pod from the
+// image registry of the Mattermost.
+func setPodImageRegistry(mattermost *mmv1beta.Mattermost, podSpec *corev1.PodSpec) {
+	setImageRegistry(mattermost, podSpec.InitContainers)
+	setImageRegistry(mattermost, podSpec.Containers)
+}
@@ -0,0 +1,161 @@
+package mattermost
+
+import (
+	"fmt"
+	"path"
+
+	mmv1beta "github.com/mattermost/mattermost-operator/apis/mattermost/v1beta1"
+	appsv1 "k8s.io/api/apps/v1"
+	batchv1 "k8s.io/api/batch/v1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+const (
+	// ImportIDAnnotation holds the identifier of the import run by the
+	// import Job.
+	ImportIDAnnotation = "installation.mattermost.com/import-id"
+
+	// DownloadImportContainerName is the name of the container downloading
+	// the export archive.
+	DownloadImportContainerName = "download-import"
+	// ValidateImportContainerName is the name of the container validating
+	// the export.
+	ValidateImportContainerName = "validate-import"
+	// ImportContainerName is the name of the container importing the export.
+	ImportContainerName = "import-workspace"
+
+	importVolumeName      = "import"
+	importVolumeMountPath = "/import"
+)
+
+// ImportJobName returns the name of the Job importing a workspace into the
+// Mattermost installation.
+func ImportJobName(mattermost *mmv1beta.Mattermost) string {
+	return fmt.Sprintf("%s-import", mattermost.Name)
+}
+
+// ImportArchiveURL returns the URL of the export archive in the source bucket.
+func ImportArchiveURL(mattermost *mmv1beta.Mattermost, source *FileStoreInfo) string {
+	return fmt.Sprintf("%s/%s", source.endpoint(), importObjectPath(mattermost, source))
+}
+
+func importObjectPath(mattermost *mmv1beta.Mattermost, source *FileStoreInfo) string {
+	importJob := mattermost.Spec.Jobs.Import
+	return path.Join(source.bucketName, importJob.Source.Prefix, importJob.Archive)
+}
+
+// GenerateImportJobV1Beta returns the Job downloading the export archive from
+// the source, validating the export and importing it with the Mattermost bulk
+// import. The import runs with the configuration of the Mattermost deployment.
+func GenerateImportJobV1Beta(mattermost *mmv1beta.Mattermost, deployment *appsv1.Deployment, source *FileStoreInfo) (*batchv1.Job, error) {
+	backoffLimit := int32(1)
+	name := ImportJobName(mattermost)
+	volumeMount := corev1.VolumeMount{
+		Name:      importVolumeName,
+		MountPath: importVolumeMountPath,
+	}
+	archivePath := path.Join(importVolumeMountPath, "archive.tar.gz")
+	workspacePath := path.Join(importVolumeMountPath, "workspace")
+
+	podSpec := deployment.Spec.Template.Spec.DeepCopy()
+
+	validateContainer, err := mattermostCommandContainer(podSpec, ValidateImportContainerName, fmt.Sprintf(
+		"mkdir -p %s && tar -xzf %s -C %s && %s --validate",
+		shellQuote(workspacePath), shellQuote(archivePath), shellQuote(workspacePath),
+		importCommand(workspacePath),
+	))
+	if err != nil {
+		return nil, err
+	}
+	validateContainer.VolumeMounts = append(validateContainer.VolumeMounts, volumeMount)
+	validateContainer.TerminationMessagePolicy = corev1.TerminationMessageFallbackToLogsOnError
+
+	importContainer, err := mattermostCommandContainer(podSpec, ImportContainerName, fmt.Sprintf(
+		"%s --apply && echo %s > /dev/termination-log",
+		importCommand(workspacePath),
+		shellQuote(fmt.Sprintf("imported %s", ImportArchiveURL(mattermost, source))),
+	))
+	if err != nil {
+		return nil, err
+	}
+	importContainer.VolumeMounts = append(importContainer.VolumeMounts, volumeMount)
+	importContainer.TerminationMessagePolicy = corev1.TerminationMessageFallbackToLogsOnError
+
+	// The archive is downloaded before the database checks of the deployment
+	// run, the export is validated before anything is imported.
+	downloadContainer := corev1.Container{
+		Name:            DownloadImportContainerName,
+		Image:           mattermost.Spec.UtilityImages.GetMinioClient(),
+		ImagePullPolicy: corev1.PullIfNotPresent,
+		Command: []string{
+			"/bin/sh", "-c",
+			fmt.Sprintf("mc config host add source %s $(SOURCE_ACCESS_KEY) $(SOURCE_SECRET_KEY) && mc cp %s %s",
+				shellQuote(source.endpoint()),
+				shellQuote(fmt.Sprintf("source/%s", importObjectPath(mattermost, source))),
+				shellQuote(archivePath),
+			),
+		},
+		Env: []corev1.EnvVar{
+			{
+				Name:      "SOURCE_ACCESS_KEY",
+				ValueFrom: EnvSourceFromSecret(source.secretName, fileStoreSecretAccessKey),
+			},
+			{
+				Name:      "SOURCE_SECRET_KEY",
+				ValueFrom: EnvSourceFromSecret(source.secretName, fileStoreSecretSecretKey),
+			},
+		},
+		VolumeMounts:             []corev1.VolumeMount{volumeMount},
+		TerminationMessagePolicy: corev1.TerminationMessageFallbackToLogsOnError,
+	}
+
+	initContainers := []corev1.Container{downloadContainer}
+	initContainers = append(initContainers, podSpec.InitContainers...)
+	initContainers = append(initContainers, *validateContainer)
+
+	podSpec.RestartPolicy = corev1.RestartPolicyNever
+	podSpec.InitContainers = initContainers
+	podSpec.Containers = []corev1.Container{*importContainer}
+	podSpec.Volumes = append(podSpec.Volumes, corev1.Volume{
+		Name: importVolumeName,
+		VolumeSource: corev1.VolumeSource{
+			EmptyDir: &corev1.EmptyDirVolumeSource{},
+		},
+	})
+	setPodImageRegistry(mattermost, podSpec)
+	SetJobPodSettings(mattermost, podSpec)
+
+	return &batchv1.Job{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:            name,
+			Namespace:       mattermost.Namespace,
+			Labels:          mmv1beta.MattermostResourceLabels(mattermost.Name),
+			OwnerReferences: MattermostOwnerReference(mattermost),
+			Annotations: map[string]string{
+				ImportIDAnnotation: mattermost.Spec.Jobs.Import.ID,
+			},
+		},
+		Spec: batchv1.JobSpec{
+			BackoffLimit: &backoffLimit,
+			Template: corev1.PodTemplateSpec{
+				ObjectMeta: metav1.ObjectMeta{
+					Labels: map[string]string{"app": name},
+				},
+				Spec: *podSpec,
+			},
+		},
+	}, nil
+}
+
+// importCommand returns the Mattermost bulk import of the JSONL file of the
+// extracted export. It runs from the export directory, so that the paths of
+// the attachments are resolved relative to it.
+func importCommand(workspacePath string) string {
+	return fmt.Sprintf("cd %s && "+
+		"file=`ls *.jsonl | head -n 1` && "+
+		"if [ -z \"$file\" ]; then echo 'no JSONL file found in the export archive'; exit 1; fi && "+
+		"mattermost import bulk \"$file\"",
+		shellQuote(workspacePath),
+	)
+}
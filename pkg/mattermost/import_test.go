@@ -0,0 +1,75 @@
+package mattermost
+
+import (
+	"testing"
+
+	mmv1beta "github.com/mattermost/mattermost-operator/apis/mattermost/v1beta1"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func TestGenerateImportJob(t *testing.T) {
+	mattermost := &mmv1beta.Mattermost{
+		ObjectMeta: metav1.ObjectMeta{Name: "mm-new", Namespace: "mm-namespace"},
+		Spec: mmv1beta.MattermostSpec{
+			Jobs: &mmv1beta.Jobs{
+				Import: &mmv1beta.ImportJob{
+					ID: "seed",
+					Source: mmv1beta.BackupDestination{
+						URL:    "s3.amazonaws.com",
+						Bucket: "exports",
+						Prefix: "mattermost",
+						Secret: "import-secret",
+					},
+					Archive: "mm-test-2021-06-01.tar.gz",
+				},
+			},
+		},
+	}
+
+	db := &ExternalDBConfig{secretName: "db-secret"}
+	fileStore := NewOperatorManagedFileStoreInfo(mattermost, "minio-secret", "mm-new-minio-hl-svc.mm-namespace.svc.cluster.local:9000")
+	deployment := GenerateDeploymentV1Beta(mattermost, db, fileStore, "mm-new", "mm.example.com", "mm-new", "mattermost/mattermost-enterprise-edition:5.35.0")
+
+	source, err := NewBackupDestinationInfo(mattermost.Spec.Jobs.Import.Source, corev1.Secret{
+		ObjectMeta: metav1.ObjectMeta{Name: "import-secret"},
+		Data: map[string][]byte{
+			"accesskey": []byte("access"),
+			"secretkey": []byte("secret"),
+		},
+	})
+	require.NoError(t, err)
+
+	assert.Equal(t, "https://s3.amazonaws.com/exports/mattermost/mm-test-2021-06-01.tar.gz", ImportArchiveURL(mattermost, source))
+
+	job, err := GenerateImportJobV1Beta(mattermost, deployment, source)
+	require.NoError(t, err)
+	assert.Equal(t, "mm-new-import", job.Name)
+	assert.Equal(t, "seed", job.Annotations[ImportIDAnnotation])
+
+	podSpec := job.Spec.Template.Spec
+	assert.Equal(t, corev1.RestartPolicyNever, podSpec.RestartPolicy)
+
+	// The archive is downloaded first and validated last, after the
+	// database checks of the deployment.
+	initContainers := podSpec.InitContainers
+	require.Len(t, initContainers, len(deployment.Spec.Template.Spec.InitContainers)+2)
+	assert.Equal(t, DownloadImportContainerName, initContainers[0].Name)
+	assert.Contains(t, initContainers[0].Command[2], "mc cp 'source/exports/mattermost/mm-test-2021-06-01.tar.gz' '/import/archive.tar.gz'")
+
+	validateContainer := initContainers[len(initContainers)-1]
+	assert.Equal(t, ValidateImportContainerName, validateContainer.Name)
+	assert.Equal(t, "mattermost/mattermost-enterprise-edition:5.35.0", validateContainer.Image)
+	assert.Contains(t, validateContainer.Command[2], "tar -xzf '/import/archive.tar.gz' -C '/import/workspace'")
+	assert.Contains(t, validateContainer.Command[2], "mattermost import bulk \"$file\" --validate")
+
+	require.Len(t, podSpec.Containers, 1)
+	importContainer := podSpec.Containers[0]
+	assert.Equal(t, ImportContainerName, importContainer.Name)
+	assert.Equal(t, deployment.Spec.Template.Spec.Containers[0].Env, importContainer.Env)
+	assert.Nil(t, importContainer.ReadinessProbe)
+	assert.Contains(t, importContainer.Command[2], "mattermost import bulk \"$file\" --apply")
+	assert.Equal(t, corev1.TerminationMessageFallbackToLogsOnError, importContainer.TerminationMessagePolicy)
+}
@@ -0,0 +1,55 @@
+package mattermost
+
+import (
+	mmv1beta "github.com/mattermost/mattermost-operator/apis/mattermost/v1beta1"
+	batchv1 "k8s.io/api/batch/v1"
+	corev1 "k8s.io/api/core/v1"
+)
+
+// SetJobPodSettings applies the settings of the Mattermost all Jobs created
+// by the operator inherit, so that they run in private registry and proxied
+// clusters: the image pull Secrets, the outbound HTTP proxy, the trusted CA
+// bundle and the utility resources. Settings the pod already has are kept,
+// so that pods copied from the Mattermost deployment are not changed.
+func SetJobPodSettings(mattermost *mmv1beta.Mattermost, podSpec *corev1.PodSpec) {
+	setPodImagePullSecrets(mattermost, podSpec)
+	setPodProxyEnv(mattermost, podSpec)
+	setPodTrustedCABundle(mattermost, podSpec)
+	setPodUtilityResources(mattermost, podSpec)
+}
+
+// SetJobPolicy applies the retries and the lifetime set in the policy to the
+// job, keeping the defaults of the job for the values not set.
+func SetJobPolicy(policy *mmv1beta.JobPolicy, jobSpec *batchv1.JobSpec) {
+	if policy == nil {
+		return
+	}
+	if policy.BackoffLimit != nil {
+		jobSpec.BackoffLimit = policy.BackoffLimit
+	}
+	if policy.ActiveDeadlineSeconds != nil {
+		jobSpec.ActiveDeadlineSeconds = policy.ActiveDeadlineSeconds
+	}
+	if policy.TTLSecondsAfterFinished != nil {
+		jobSpec.TTLSecondsAfterFinished = policy.TTLSecondsAfterFinished
+	}
+}
+
+// setPodImagePullSecrets adds the image pull Secrets of the Mattermost the
+// pod does not use yet.
+func setPodImagePullSecrets(mattermost *mmv1beta.Mattermost, podSpec *corev1.PodSpec) {
+	for _, secret := range mattermost.GetImagePullSecrets() {
+		if !hasImagePullSecret(podSpec.ImagePullSecrets, secret.Name) {
+			podSpec.ImagePullSecrets = append(podSpec.ImagePullSecrets, secret)
+		}
+	}
+}
+
+func hasImagePullSecret(secrets []corev1.LocalObjectReference, name string) bool {
+	for _, secret := range secrets {
+		if secret.Name == name {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,76 @@
+package mattermost
+
+import (
+	"testing"
+
+	mmv1beta "github.com/mattermost/mattermost-operator/apis/mattermost/v1beta1"
+	"github.com/stretchr/testify/assert"
+	batchv1 "k8s.io/api/batch/v1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func TestSetJobPodSettings(t *testing.T) {
+	mattermost := &mmv1beta.Mattermost{
+		ObjectMeta: metav1.ObjectMeta{Name: "mm-test", Namespace: "mm-namespace"},
+		Spec: mmv1beta.MattermostSpec{
+			ImagePullSecrets: []corev1.LocalObjectReference{{Name: "registry-credentials"}},
+			Proxy:            &mmv1beta.Proxy{HTTPSProxy: "http://proxy:3128"},
+			TrustedCABundle:  &mmv1beta.TrustedCABundle{ConfigMap: "internal-ca"},
+			UpdatePolicy: &mmv1beta.UpdatePolicy{
+				PostUpgradeChecks: &mmv1beta.PostUpgradeChecks{
+					Enabled: true,
+					Image:   mmv1beta.DefaultPostUpgradeChecksImage,
+				},
+			},
+		},
+	}
+	assertJobPodSettings := func(t *testing.T, podSpec corev1.PodSpec) {
+		assert.Equal(t, []corev1.LocalObjectReference{{Name: "registry-credentials"}}, podSpec.ImagePullSecrets)
+		assert.Len(t, volumesNamed(podSpec.Volumes, "trusted-ca-bundle"), 1)
+		for _, container := range append(podSpec.InitContainers, podSpec.Containers...) {
+			assertEnvVarEqual(t, "HTTPS_PROXY", "http://proxy:3128", container.Env)
+			assert.Len(t, volumeMountsNamed(container.VolumeMounts, "trusted-ca-bundle"), 1, container.Name)
+			assert.NotEmpty(t, container.Resources.Requests, container.Name)
+		}
+	}
+
+	t.Run("post upgrade checks job", func(t *testing.T) {
+		job := GeneratePostUpgradeChecksJobV1Beta(mattermost, "mattermost/mattermost-enterprise-edition:5.37.1")
+		assertJobPodSettings(t, job.Spec.Template.Spec)
+	})
+
+	t.Run("pod copied from the deployment", func(t *testing.T) {
+		deployment := GenerateDeploymentV1Beta(mattermost, &MySQLDBConfig{}, &FileStoreInfo{config: &OperatorManagedMinioConfig{}}, "mm-test", "", "", "image")
+		podSpec := deployment.Spec.Template.Spec.DeepCopy()
+
+		SetJobPodSettings(mattermost, podSpec)
+		SetJobPodSettings(mattermost, podSpec)
+		assertJobPodSettings(t, *podSpec)
+	})
+}
+
+func TestSetJobPolicy(t *testing.T) {
+	backoffLimit := int32(10)
+	jobSpec := &batchv1.JobSpec{BackoffLimit: &backoffLimit}
+
+	SetJobPolicy(nil, jobSpec)
+	assert.Equal(t, int32(10), *jobSpec.BackoffLimit)
+
+	policyBackoffLimit := int32(0)
+	activeDeadlineSeconds := int64(600)
+	SetJobPolicy(&mmv1beta.JobPolicy{BackoffLimit: &policyBackoffLimit, ActiveDeadlineSeconds: &activeDeadlineSeconds}, jobSpec)
+	assert.Equal(t, int32(0), *jobSpec.BackoffLimit)
+	assert.Equal(t, int64(600), *jobSpec.ActiveDeadlineSeconds)
+	assert.Nil(t, jobSpec.TTLSecondsAfterFinished)
+}
+
+func volumeMountsNamed(volumeMounts []corev1.VolumeMount, name string) []corev1.VolumeMount {
+	var named []corev1.VolumeMount
+	for _, volumeMount := range volumeMounts {
+		if volumeMount.Name == name {
+			named = append(named, volumeMount)
+		}
+	}
+	return named
+}
@@ -0,0 +1,33 @@
+package mattermost
+
+import (
+	mmv1beta "github.com/mattermost/mattermost-operator/apis/mattermost/v1beta1"
+	corev1 "k8s.io/api/core/v1"
+)
+
+const (
+	// readOnlyDatabaseOptions makes the sessions of the PostgreSQL driver of
+	// Mattermost read-only. The MySQL driver does not read the variable.
+	readOnlyDatabaseOptions = "-c default_transaction_read_only=on"
+	// maintenancePageAnnotation is the ingress annotation redirecting the
+	// requests to the maintenance page.
+	maintenancePageAnnotation = "nginx.ingress.kubernetes.io/temporal-redirect"
+)
+
+// maintenanceModeEnvVars returns the environment variables of the read-only
+// database of the Mattermost in maintenance mode.
+func maintenanceModeEnvVars(mattermost *mmv1beta.Mattermost) []corev1.EnvVar {
+	if !mattermost.MaintenanceModeEnabled() || !mattermost.Spec.MaintenanceMode.ReadOnlyDatabase {
+		return nil
+	}
+	return []corev1.EnvVar{{Name: "PGOPTIONS", Value: readOnlyDatabaseOptions}}
+}
+
+// setMaintenancePage redirects the requests of the ingress to the maintenance
+// page of the Mattermost in maintenance mode.
+func setMaintenancePage(mattermost *mmv1beta.Mattermost, annotations map[string]string) {
+	if !mattermost.MaintenanceModeEnabled() || mattermost.Spec.MaintenanceMode.PageURL == "" {
+		return
+	}
+	annotations[maintenancePageAnnotation] = mattermost.Spec.MaintenanceMode.PageURL
+}
@@ -0,0 +1,40 @@
+package mattermost
+
+import (
+	"testing"
+
+	mmv1beta "github.com/mattermost/mattermost-operator/apis/mattermost/v1beta1"
+	"github.com/stretchr/testify/assert"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func TestMaintenanceMode(t *testing.T) {
+	mattermost := &mmv1beta.Mattermost{
+		ObjectMeta: metav1.ObjectMeta{Name: "mm-test", Namespace: "mm-namespace"},
+		Spec: mmv1beta.MattermostSpec{
+			MaintenanceMode: &mmv1beta.MaintenanceMode{
+				ReadOnlyDatabase: true,
+				PageURL:          "https://status.example.com",
+			},
+		},
+	}
+	fileStore := &FileStoreInfo{config: &OperatorManagedMinioConfig{}}
+
+	deployment := GenerateDeploymentV1Beta(mattermost, &MySQLDBConfig{}, fileStore, "mm-test", "", "", "image")
+	assert.NotContains(t, deployment.Spec.Template.Spec.Containers[0].Env, corev1.EnvVar{Name: "PGOPTIONS", Value: readOnlyDatabaseOptions})
+	ingress := GenerateIngressV1Beta(mattermost, "mm-test", "mm.example.com")
+	assert.NotContains(t, ingress.Annotations, maintenancePageAnnotation)
+
+	mattermost.Spec.MaintenanceMode.Enabled = true
+	deployment = GenerateDeploymentV1Beta(mattermost, &MySQLDBConfig{}, fileStore, "mm-test", "", "", "image")
+	assert.Contains(t, deployment.Spec.Template.Spec.Containers[0].Env, corev1.EnvVar{Name: "PGOPTIONS", Value: readOnlyDatabaseOptions})
+	ingress = GenerateIngressV1Beta(mattermost, "mm-test", "mm.example.com")
+	assert.Equal(t, "https://status.example.com", ingress.Annotations[maintenancePageAnnotation])
+
+	mattermost.Spec.MaintenanceMode.ReadOnlyDatabase = false
+	mattermost.Spec.MaintenanceMode.PageURL = ""
+	assert.Empty(t, maintenanceModeEnvVars(mattermost))
+	ingress = GenerateIngressV1Beta(mattermost, "mm-test", "mm.example.com")
+	assert.NotContains(t, ingress.Annotations, maintenancePageAnnotation)
+}
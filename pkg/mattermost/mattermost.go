@@ -138,7 +138,7 @@ func GenerateDeployment(mattermost *mattermostv1alpha1.ClusterInstallation, dbIn
 		}
 
 		if dbInfo.HasDatabaseCheckURL() {
-			dbCheckContainer := getDBCheckInitContainer(dbInfo.SecretName, dbInfo.ExternalDBType)
+			dbCheckContainer := getDBCheckInitContainer(dbInfo.SecretName, dbInfo.ExternalDBType, nil)
 			if dbCheckContainer != nil {
 				initContainers = append(initContainers, *dbCheckContainer)
 			}
@@ -308,7 +308,7 @@ func GenerateDeployment(mattermost *mattermostv1alpha1.ClusterInstallation, dbIn
 	}
 
 	siteURL := fmt.Sprintf("https://%s", ingressName)
-	envVarGeneral := generalMattermostEnvVars(siteURL)
+	envVarGeneral := generalMattermostEnvVars(siteURL, true)
 
 	valueSize := strconv.Itoa(defaultMaxFileSize * sizeMB)
 	if !mattermost.Spec.UseServiceLoadBalancer {
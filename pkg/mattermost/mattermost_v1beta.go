@@ -22,6 +22,8 @@ import (
 type DatabaseConfig interface {
 	EnvVars(mattermost *mmv1beta.Mattermost) []corev1.EnvVar
 	InitContainers(mattermost *mmv1beta.Mattermost) []corev1.Container
+	DumpContainer(mattermost *mmv1beta.Mattermost, dumpPath string) (*corev1.Container, error)
+	RestoreContainer(mattermost *mmv1beta.Mattermost, dumpPath string) (*corev1.Container, error)
 }
 
 type FileStoreConfig interface {
@@ -29,7 +31,7 @@ type FileStoreConfig interface {
 }
 
 // GenerateServiceV1Beta returns the service for the Mattermost app.
-func GenerateServiceV1Beta(mattermost *mmv1beta.Mattermost) *corev1.Service {
+func GenerateServiceV1Beta(mattermost *mmv1beta.Mattermost, serviceName, selectorName string) *corev1.Service {
 	baseAnnotations := map[string]string{
 		"service.alpha.kubernetes.io/tolerate-unready-endpoints": "true",
 	}
@@ -38,13 +40,25 @@ func GenerateServiceV1Beta(mattermost *mmv1beta.Mattermost) *corev1.Service {
 		// Create a LoadBalancer service with additional annotations provided in
 		// the Mattermost Spec. The LoadBalancer is directly accessible from
 		// outside the cluster thus exposes ports 80 and 443.
-		service := newServiceV1Beta(mattermost, mergeStringMaps(baseAnnotations, mattermost.Spec.ServiceAnnotations))
-		return configureMattermostLoadBalancerService(service)
+		service := newServiceV1Beta(mattermost, serviceName, selectorName,
+			mergeStringMaps(baseAnnotations, mattermost.Spec.ServiceAnnotations),
+		)
+		service = configureMattermostLoadBalancerService(service)
+		// The metrics are exposed through the load balancer only if they
+		// are scraped by the ServiceMonitor.
+		if mattermost.AppMonitoringEnabled() {
+			service.Spec.Ports = append(service.Spec.Ports, corev1.ServicePort{
+				Port:       8067,
+				Name:       "metrics",
+				TargetPort: intstr.FromString("metrics"),
+			})
+		}
+		return service
 	}
 
 	// Create a headless service which is not directly accessible from outside
 	// the cluster and thus exposes a custom port.
-	service := newServiceV1Beta(mattermost, baseAnnotations)
+	service := newServiceV1Beta(mattermost, serviceName, selectorName, baseAnnotations)
 	return configureMattermostService(service)
 }
 
@@ -85,7 +99,7 @@ func configureMattermostService(service *corev1.Service) *corev1.Service {
 }
 
 // GenerateIngressV1Beta returns the ingress for the Mattermost app.
-func GenerateIngressV1Beta(mattermost *mmv1beta.Mattermost) *networkingv1.Ingress {
+func GenerateIngressV1Beta(mattermost *mmv1beta.Mattermost, name, host string) *networkingv1.Ingress {
 	ingressAnnotations := map[string]string{
 		"kubernetes.io/ingress.class":                 "nginx",
 		"nginx.ingress.kubernetes.io/proxy-body-size": "1000M",
@@ -93,35 +107,23 @@ func GenerateIngressV1Beta(mattermost *mmv1beta.Mattermost) *networkingv1.Ingres
 	for k, v := range mattermost.GetIngresAnnotations() {
 		ingressAnnotations[k] = v
 	}
+	setMaintenancePage(mattermost, ingressAnnotations)
 
 	ingress := &networkingv1.Ingress{
 		ObjectMeta: metav1.ObjectMeta{
-			Name:            mattermost.Name,
+			Name:            name,
 			Namespace:       mattermost.Namespace,
-			Labels:          mattermost.MattermostLabels(mattermost.Name),
+			Labels:          mattermost.MattermostLabels(name),
 			OwnerReferences: MattermostOwnerReference(mattermost),
 			Annotations:     ingressAnnotations,
 		},
 		Spec: networkingv1.IngressSpec{
 			Rules: []networkingv1.IngressRule{
 				{
-					Host: mattermost.GetIngressHost(),
+					Host: host,
 					IngressRuleValue: networkingv1.IngressRuleValue{
 						HTTP: &networkingv1.HTTPIngressRuleValue{
-							Paths: []networkingv1.HTTPIngressPath{
-								{
-									Path: "/",
-									Backend: networkingv1.IngressBackend{
-										Service: &networkingv1.IngressServiceBackend{
-											Name: mattermost.Name,
-											Port: networkingv1.ServiceBackendPort{
-												Number: 8065,
-											},
-										},
-									},
-									PathType: &defaultIngressPathType,
-								},
-							},
+							Paths: ingressPathsV1Beta(mattermost, name),
 						},
 					},
 				},
@@ -132,7 +134,7 @@ func GenerateIngressV1Beta(mattermost *mmv1beta.Mattermost) *networkingv1.Ingres
 	if mattermost.GetIngressTLSSecret() != "" {
 		ingress.Spec.TLS = []networkingv1.IngressTLS{
 			{
-				Hosts:      []string{mattermost.GetIngressHost()},
+				Hosts:      []string{host},
 				SecretName: mattermost.GetIngressTLSSecret(),
 			},
 		}
@@ -141,6 +143,58 @@ func GenerateIngressV1Beta(mattermost *mmv1beta.Mattermost) *networkingv1.Ingres
 	return ingress
 }
 
+// ingressPathsV1Beta returns the paths of the Mattermost ingress rule, the
+// Mattermost path routed to the service followed by the extra paths.
+func ingressPathsV1Beta(mattermost *mmv1beta.Mattermost, name string) []networkingv1.HTTPIngressPath {
+	pathType := networkingv1.PathType(mattermost.GetIngressPathType())
+	paths := []networkingv1.HTTPIngressPath{
+		ingressPath(mattermost.GetIngressPath(), pathType, name, 8065),
+	}
+
+	for _, extraPath := range mattermost.GetIngressExtraPaths() {
+		extraPathType := pathType
+		if extraPath.PathType != "" {
+			extraPathType = networkingv1.PathType(extraPath.PathType)
+		}
+		service := name
+		if extraPath.Service != "" {
+			service = extraPath.Service
+		}
+		port := int32(8065)
+		if extraPath.Port != 0 {
+			port = extraPath.Port
+		}
+		paths = append(paths, ingressPath(extraPath.Path, extraPathType, service, port))
+	}
+
+	return paths
+}
+
+func ingressPath(path string, pathType networkingv1.PathType, service string, port int32) networkingv1.HTTPIngressPath {
+	return networkingv1.HTTPIngressPath{
+		Path: path,
+		Backend: networkingv1.IngressBackend{
+			Service: &networkingv1.IngressServiceBackend{
+				Name: service,
+				Port: networkingv1.ServiceBackendPort{
+					Number: port,
+				},
+			},
+		},
+		PathType: &pathType,
+	}
+}
+
+// GenerateCanaryIngressV1Beta returns the ingress routing the configured share
+// of the requests to the Mattermost ingress host to the canary deployment.
+func GenerateCanaryIngressV1Beta(mattermost *mmv1beta.Mattermost) *networkingv1.Ingress {
+	ingress := GenerateIngressV1Beta(mattermost, mattermost.Spec.Canary.Deployment.Name, mattermost.GetIngressHost())
+	ingress.Annotations["nginx.ingress.kubernetes.io/canary"] = "true"
+	ingress.Annotations["nginx.ingress.kubernetes.io/canary-weight"] = strconv.Itoa(int(mattermost.Spec.Canary.Weight))
+
+	return ingress
+}
+
 // GenerateDeploymentV1Beta returns the deployment for Mattermost app.
 func GenerateDeploymentV1Beta(mattermost *mmv1beta.Mattermost, db DatabaseConfig, fileStore *FileStoreInfo, deploymentName, ingressName, serviceAccountName, containerImage string) *appsv1.Deployment {
 	// DB
@@ -150,6 +204,9 @@ func GenerateDeploymentV1Beta(mattermost *mmv1beta.Mattermost, db DatabaseConfig
 	// File Store
 	envVarFileStore := fileStoreEnvVars(fileStore)
 	initContainers = append(initContainers, fileStore.config.InitContainers(mattermost)...)
+	setImageRegistry(mattermost, initContainers)
+	setProxyEnv(mattermost, initContainers)
+	setUtilityResources(mattermost, initContainers)
 
 	// Extensions
 	if mattermost.Spec.PodExtensions.InitContainers != nil {
@@ -173,7 +230,7 @@ func GenerateDeploymentV1Beta(mattermost *mmv1beta.Mattermost, db DatabaseConfig
 
 	// General settings
 	siteURL := fmt.Sprintf("https://%s", ingressName)
-	envVarGeneral := generalMattermostEnvVars(siteURL)
+	envVarGeneral := generalMattermostEnvVars(siteURL, mattermost.ClusteringEnabled())
 
 	// Determine max file size
 	bodySize := strconv.Itoa(defaultMaxFileSize * sizeMB)
@@ -199,12 +256,38 @@ func GenerateDeploymentV1Beta(mattermost *mmv1beta.Mattermost, db DatabaseConfig
 		podAnnotations = annotations
 	}
 
+	// Trusted CA bundle
+	if volume, vMount, ok := trustedCABundleConfig(mattermost); ok {
+		volumeMounts = append(volumeMounts, vMount)
+		volumes = append(volumes, volume)
+	}
+
 	// Concat EnvVars
 	envVars := []corev1.EnvVar{}
 	envVars = append(envVars, envVarDB...)
 	envVars = append(envVars, envVarFileStore...)
 	envVars = append(envVars, envVarES...)
 	envVars = append(envVars, envVarGeneral...)
+	envVars = append(envVars, proxyEnvVars(mattermost)...)
+	envVars = append(envVars, goRuntimeEnvVars(mattermost.Spec.Scheduling.Resources)...)
+	envVars = append(envVars, maintenanceModeEnvVars(mattermost)...)
+
+	ports := []corev1.ContainerPort{
+		{
+			ContainerPort: 8065,
+			Name:          "app",
+		},
+		{
+			ContainerPort: 8067,
+			Name:          "metrics",
+		},
+	}
+
+	// Cluster gossip
+	if mattermost.ClusteringEnabled() {
+		envVars = append(envVars, clusterGossipEnvVar())
+		ports = append(ports, clusterGossipPorts()...)
+	}
 
 	// Merge our custom env vars in.
 	envVars = mergeEnvVars(envVars, mattermost.Spec.MattermostEnv)
@@ -214,6 +297,11 @@ func GenerateDeploymentV1Beta(mattermost *mmv1beta.Mattermost, db DatabaseConfig
 
 	liveness, readiness := setProbes(mattermost.Spec.Probes.LivenessProbe, mattermost.Spec.Probes.ReadinessProbe)
 
+	var minReadySeconds int32
+	if mattermost.InPlaceUpdateEnabled() {
+		minReadySeconds = inPlaceUpdateMinReadySeconds
+	}
+
 	return &appsv1.Deployment{
 		ObjectMeta: metav1.ObjectMeta{
 			Name:            deploymentName,
@@ -230,6 +318,7 @@ func GenerateDeploymentV1Beta(mattermost *mmv1beta.Mattermost, db DatabaseConfig
 				},
 			},
 			RevisionHistoryLimit: pkgUtils.NewInt32(defaultRevHistoryLimit),
+			MinReadySeconds:      minReadySeconds,
 			Replicas:             mattermost.Spec.Replicas,
 			Selector: &metav1.LabelSelector{
 				MatchLabels: mmv1beta.MattermostSelectorLabels(deploymentName),
@@ -241,6 +330,7 @@ func GenerateDeploymentV1Beta(mattermost *mmv1beta.Mattermost, db DatabaseConfig
 				},
 				Spec: corev1.PodSpec{
 					ServiceAccountName: serviceAccountName,
+					ReadinessGates:     clusterReadinessGates(mattermost, deploymentName),
 					InitContainers:     initContainers,
 					Containers: []corev1.Container{
 						{
@@ -248,25 +338,16 @@ func GenerateDeploymentV1Beta(mattermost *mmv1beta.Mattermost, db DatabaseConfig
 							Image:                    containerImage,
 							ImagePullPolicy:          mattermost.Spec.ImagePullPolicy,
 							TerminationMessagePolicy: corev1.TerminationMessageFallbackToLogsOnError,
-							Command:                  []string{"mattermost"},
+							Command:                  mattermost.GetMattermostCommand(),
 							Env:                      envVars,
-							Ports: []corev1.ContainerPort{
-								{
-									ContainerPort: 8065,
-									Name:          "app",
-								},
-								{
-									ContainerPort: 8067,
-									Name:          "metrics",
-								},
-							},
-							ReadinessProbe: readiness,
-							LivenessProbe:  liveness,
-							VolumeMounts:   volumeMounts,
-							Resources:      mattermost.Spec.Scheduling.Resources,
+							Ports:                    ports,
+							ReadinessProbe:           readiness,
+							LivenessProbe:            liveness,
+							VolumeMounts:             volumeMounts,
+							Resources:                mattermost.Spec.Scheduling.Resources,
 						},
 					},
-					ImagePullSecrets: mattermost.Spec.ImagePullSecrets,
+					ImagePullSecrets: mattermost.GetImagePullSecrets(),
 					Volumes:          volumes,
 					Affinity:         mattermost.Spec.Scheduling.Affinity,
 					NodeSelector:     mattermost.Spec.Scheduling.NodeSelector,
@@ -351,17 +432,17 @@ func MattermostOwnerReference(mattermost *mmv1beta.Mattermost) []metav1.OwnerRef
 
 // newService returns semi-finished service with common parts filled.
 // Returned service is expected to be completed by the caller.
-func newServiceV1Beta(mattermost *mmv1beta.Mattermost, annotations map[string]string) *corev1.Service {
+func newServiceV1Beta(mattermost *mmv1beta.Mattermost, serviceName, selectorName string, annotations map[string]string) *corev1.Service {
 	return &corev1.Service{
 		ObjectMeta: metav1.ObjectMeta{
-			Labels:          mattermost.MattermostLabels(mattermost.Name),
-			Name:            mattermost.Name,
+			Labels:          mattermost.MattermostLabels(serviceName),
+			Name:            serviceName,
 			Namespace:       mattermost.Namespace,
 			OwnerReferences: MattermostOwnerReference(mattermost),
 			Annotations:     annotations,
 		},
 		Spec: corev1.ServiceSpec{
-			Selector: mmv1beta.MattermostSelectorLabels(mattermost.Name),
+			Selector: mmv1beta.MattermostSelectorLabels(selectorName),
 		},
 	}
 }
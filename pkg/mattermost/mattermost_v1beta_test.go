@@ -11,6 +11,7 @@ import (
 	"github.com/mattermost/mattermost-operator/pkg/utils"
 	appsv1 "k8s.io/api/apps/v1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/intstr"
 
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
@@ -54,7 +55,7 @@ func TestGenerateService_V1Beta(t *testing.T) {
 				Spec: tt.spec,
 			}
 
-			service := GenerateServiceV1Beta(mattermost)
+			service := GenerateServiceV1Beta(mattermost, mattermost.Name, mattermost.Name)
 			require.NotNil(t, service)
 
 			if mattermost.Spec.UseServiceLoadBalancer {
@@ -92,9 +93,10 @@ func TestGenerateIngress_V1Beta(t *testing.T) {
 				Spec: tt.spec,
 			}
 
-			ingress := GenerateIngressV1Beta(mattermost)
+			ingress := GenerateIngressV1Beta(mattermost, mattermost.Name, mattermost.GetIngressHost())
 			require.NotNil(t, ingress)
 
+			assert.Equal(t, "/", ingress.Spec.Rules[0].HTTP.Paths[0].Path)
 			assert.Equal(t, networkingv1.PathTypeImplementationSpecific, *ingress.Spec.Rules[0].HTTP.Paths[0].PathType)
 
 			if mattermost.Spec.UseIngressTLS {
@@ -106,6 +108,68 @@ func TestGenerateIngress_V1Beta(t *testing.T) {
 	}
 }
 
+func TestGenerateIngress_V1Beta_Paths(t *testing.T) {
+	mattermost := &mmv1beta.Mattermost{
+		ObjectMeta: metav1.ObjectMeta{Name: "mm", Namespace: "mm-namespace"},
+		Spec: mmv1beta.MattermostSpec{
+			Ingress: &mmv1beta.Ingress{
+				Enabled:  true,
+				Host:     "mm.example.com",
+				Path:     "/chat",
+				PathType: mmv1beta.IngressPathTypePrefix,
+				ExtraPaths: []mmv1beta.IngressPath{
+					{Path: "/api/v4/websocket", PathType: mmv1beta.IngressPathTypeExact},
+					{Path: "/plugins/calls", Service: "calls", Port: 8443},
+				},
+			},
+		},
+	}
+
+	ingress := GenerateIngressV1Beta(mattermost, mattermost.Name, mattermost.GetIngressHost())
+	require.NotNil(t, ingress)
+
+	paths := ingress.Spec.Rules[0].HTTP.Paths
+	require.Len(t, paths, 3)
+	assert.Equal(t, "/chat", paths[0].Path)
+	assert.Equal(t, networkingv1.PathTypePrefix, *paths[0].PathType)
+	assert.Equal(t, "mm", paths[0].Backend.Service.Name)
+	assert.Equal(t, int32(8065), paths[0].Backend.Service.Port.Number)
+
+	assert.Equal(t, "/api/v4/websocket", paths[1].Path)
+	assert.Equal(t, networkingv1.PathTypeExact, *paths[1].PathType)
+	assert.Equal(t, "mm", paths[1].Backend.Service.Name)
+	assert.Equal(t, int32(8065), paths[1].Backend.Service.Port.Number)
+
+	assert.Equal(t, "/plugins/calls", paths[2].Path)
+	assert.Equal(t, networkingv1.PathTypePrefix, *paths[2].PathType)
+	assert.Equal(t, "calls", paths[2].Backend.Service.Name)
+	assert.Equal(t, int32(8443), paths[2].Backend.Service.Port.Number)
+}
+
+func TestGenerateCanaryIngress_V1Beta(t *testing.T) {
+	mattermost := &mmv1beta.Mattermost{
+		ObjectMeta: metav1.ObjectMeta{Name: "mm", Namespace: "mm-namespace"},
+		Spec: mmv1beta.MattermostSpec{
+			IngressName: "mm.example.com",
+			Canary: &mmv1beta.Canary{
+				Enabled:    true,
+				Weight:     25,
+				Deployment: mmv1beta.AppDeployment{Name: "mm-canary"},
+			},
+		},
+	}
+
+	ingress := GenerateCanaryIngressV1Beta(mattermost)
+	require.NotNil(t, ingress)
+
+	assert.Equal(t, "mm-canary", ingress.Name)
+	assert.Equal(t, "mm.example.com", ingress.Spec.Rules[0].Host)
+	assert.Equal(t, "mm-canary", ingress.Spec.Rules[0].HTTP.Paths[0].Backend.Service.Name)
+	assert.Equal(t, "true", ingress.Annotations["nginx.ingress.kubernetes.io/canary"])
+	assert.Equal(t, "25", ingress.Annotations["nginx.ingress.kubernetes.io/canary-weight"])
+	assert.Equal(t, "nginx", ingress.Annotations["kubernetes.io/ingress.class"])
+}
+
 func TestGenerateDeployment_V1Beta(t *testing.T) {
 	tests := []struct {
 		name            string
@@ -115,7 +179,72 @@ func TestGenerateDeployment_V1Beta(t *testing.T) {
 		want            *appsv1.Deployment
 		requiredEnv     []string
 		requiredEnvVals map[string]string
+		absentEnv       []string
 	}{
+		{
+			name: "licensed multi-replica",
+			spec: mmv1beta.MattermostSpec{
+				LicenseSecret: "license-secret",
+				Replicas:      utils.NewInt32(3),
+			},
+			want: &appsv1.Deployment{
+				Spec: appsv1.DeploymentSpec{
+					Template: corev1.PodTemplateSpec{
+						Spec: corev1.PodSpec{
+							Volumes: []corev1.Volume{
+								{
+									Name: "mattermost-license",
+									VolumeSource: corev1.VolumeSource{
+										Secret: &corev1.SecretVolumeSource{
+											SecretName: "license-secret",
+										},
+									},
+								},
+							},
+						},
+					},
+				},
+			},
+			requiredEnvVals: map[string]string{
+				"MM_CLUSTERSETTINGS_ENABLE":      "true",
+				"MM_CLUSTERSETTINGS_CLUSTERNAME": "production",
+				"MM_CLUSTERSETTINGS_GOSSIPPORT":  "8074",
+			},
+		},
+		{
+			name: "unlicensed multi-replica",
+			spec: mmv1beta.MattermostSpec{
+				Replicas: utils.NewInt32(3),
+			},
+			want:      &appsv1.Deployment{},
+			absentEnv: []string{"MM_CLUSTERSETTINGS_ENABLE", "MM_CLUSTERSETTINGS_CLUSTERNAME", "MM_CLUSTERSETTINGS_GOSSIPPORT"},
+		},
+		{
+			name: "licensed single replica",
+			spec: mmv1beta.MattermostSpec{
+				LicenseSecret: "license-secret",
+				Replicas:      utils.NewInt32(1),
+			},
+			want: &appsv1.Deployment{
+				Spec: appsv1.DeploymentSpec{
+					Template: corev1.PodTemplateSpec{
+						Spec: corev1.PodSpec{
+							Volumes: []corev1.Volume{
+								{
+									Name: "mattermost-license",
+									VolumeSource: corev1.VolumeSource{
+										Secret: &corev1.SecretVolumeSource{
+											SecretName: "license-secret",
+										},
+									},
+								},
+							},
+						},
+					},
+				},
+			},
+			absentEnv: []string{"MM_CLUSTERSETTINGS_ENABLE", "MM_CLUSTERSETTINGS_CLUSTERNAME", "MM_CLUSTERSETTINGS_GOSSIPPORT"},
+		},
 		{
 			name: "has license",
 			spec: mmv1beta.MattermostSpec{
@@ -200,6 +329,34 @@ func TestGenerateDeployment_V1Beta(t *testing.T) {
 			want:            &appsv1.Deployment{},
 			requiredEnvVals: map[string]string{"MM_FILESETTINGS_AMAZONS3SSL": "false"},
 		},
+		{
+			name: "external file store with SSE-KMS encryption",
+			spec: mmv1beta.MattermostSpec{},
+			fileStore: &FileStoreInfo{
+				secretName: "file-store-secret",
+				bucketName: "file-store-bucket",
+				url:        "s3.amazon.com",
+				useS3SSL:   true,
+				encryption: &mmv1beta.FileStoreEncryption{Mode: mmv1beta.SSEKMS, KMSKeyID: "key-id"},
+				config:     &ExternalFileStore{},
+			},
+			want:            &appsv1.Deployment{},
+			requiredEnvVals: map[string]string{"MM_FILESETTINGS_AMAZONS3SSE": "false"},
+		},
+		{
+			name: "external file store with SSE-S3 encryption",
+			spec: mmv1beta.MattermostSpec{},
+			fileStore: &FileStoreInfo{
+				secretName: "file-store-secret",
+				bucketName: "file-store-bucket",
+				url:        "s3.amazon.com",
+				useS3SSL:   true,
+				encryption: &mmv1beta.FileStoreEncryption{Mode: mmv1beta.SSES3},
+				config:     &ExternalFileStore{},
+			},
+			want:            &appsv1.Deployment{},
+			requiredEnvVals: map[string]string{"MM_FILESETTINGS_AMAZONS3SSE": "true"},
+		},
 		{
 			name: "override envs set by default with ones in MM spec",
 			spec: mmv1beta.MattermostSpec{
@@ -450,8 +607,10 @@ func TestGenerateDeployment_V1Beta(t *testing.T) {
 			assertEnvVarExists(t, "MM_METRICSSETTINGS_LISTENADDRESS", mattermostAppContainer.Env)
 			assertEnvVarExists(t, "MM_METRICSSETTINGS_ENABLE", mattermostAppContainer.Env)
 			assertEnvVarExists(t, "MM_PLUGINSETTINGS_ENABLEUPLOADS", mattermostAppContainer.Env)
-			assertEnvVarExists(t, "MM_CLUSTERSETTINGS_ENABLE", mattermostAppContainer.Env)
-			assertEnvVarExists(t, "MM_CLUSTERSETTINGS_CLUSTERNAME", mattermostAppContainer.Env)
+			if mattermost.ClusteringEnabled() {
+				assertEnvVarExists(t, "MM_CLUSTERSETTINGS_ENABLE", mattermostAppContainer.Env)
+				assertEnvVarExists(t, "MM_CLUSTERSETTINGS_CLUSTERNAME", mattermostAppContainer.Env)
+			}
 			assertEnvVarExists(t, "MM_FILESETTINGS_MAXFILESIZE", mattermostAppContainer.Env)
 			assertEnvVarExists(t, "MM_INSTALL_TYPE", mattermostAppContainer.Env)
 
@@ -459,6 +618,10 @@ func TestGenerateDeployment_V1Beta(t *testing.T) {
 				assertEnvVarExists(t, env, mattermostAppContainer.Env)
 			}
 
+			for _, env := range tt.absentEnv {
+				assertEnvVarNotExists(t, env, mattermostAppContainer.Env)
+			}
+
 			for env, val := range tt.requiredEnvVals {
 				assertEnvVarEqual(t, env, val, mattermostAppContainer.Env)
 			}
@@ -502,9 +665,13 @@ func TestGenerateDeployment_V1Beta(t *testing.T) {
 				ImagePullPolicy: corev1.PullIfNotPresent,
 				Command:         []string{"sh", "-c", "until pg_isready --dbname=\"$DB_CONNECTION_CHECK_URL\"; do echo waiting for database; sleep 5; done;"},
 				Env:             []corev1.EnvVar{{Name: "DB_CONNECTION_CHECK_URL", Value: "", ValueFrom: EnvSourceFromSecret("secret", "DB_CONNECTION_CHECK_URL")}},
+				Resources:       (&mmv1beta.Mattermost{}).GetUtilityResources(),
 			},
 		}
 
+		mirroredExternalPostgresInitContainers := []corev1.Container{*defaultExternalPostgresInitContainers[0].DeepCopy()}
+		mirroredExternalPostgresInitContainers[0].Image = "registry.example.com/mirror/postgres:13"
+
 		for _, testCase := range []struct {
 			description            string
 			mmSpec                 mmv1beta.MattermostSpec
@@ -568,6 +735,20 @@ func TestGenerateDeployment_V1Beta(t *testing.T) {
 				dbConfig:               &ExternalDBConfig{dbType: database.PostgreSQLDatabase, secretName: "secret", hasDBCheckURL: true},
 				expectedInitContainers: defaultExternalPostgresInitContainers,
 			},
+			{
+				description: "image registry with custom init container",
+				mmSpec: mmv1beta.MattermostSpec{
+					ImageRegistry: "registry.example.com/mirror",
+					Database: mmv1beta.Database{
+						External: &mmv1beta.ExternalDatabase{},
+					},
+					PodExtensions: mmv1beta.PodExtensions{
+						InitContainers: customInitContainers,
+					},
+				},
+				dbConfig:               &ExternalDBConfig{dbType: database.PostgreSQLDatabase, secretName: "secret", hasDBCheckURL: true},
+				expectedInitContainers: append(mirroredExternalPostgresInitContainers, customInitContainers...),
+			},
 			{
 				description: "nil init containers slice",
 				mmSpec: mmv1beta.MattermostSpec{
@@ -593,6 +774,18 @@ func TestGenerateDeployment_V1Beta(t *testing.T) {
 	})
 }
 
+func TestGenerateDeployment_V1BetaInPlaceUpdate(t *testing.T) {
+	mattermost := &mmv1beta.Mattermost{}
+
+	deployment := GenerateDeploymentV1Beta(mattermost, &MySQLDBConfig{}, &FileStoreInfo{config: &ExternalFileStore{}}, "", "", "", "image")
+	assert.Equal(t, int32(0), deployment.Spec.MinReadySeconds)
+
+	mattermost.Spec.UpdatePolicy = &mmv1beta.UpdatePolicy{SkipVersionCheckJob: true}
+	deployment = GenerateDeploymentV1Beta(mattermost, &MySQLDBConfig{}, &FileStoreInfo{config: &ExternalFileStore{}}, "", "", "", "image")
+	assert.Equal(t, int32(inPlaceUpdateMinReadySeconds), deployment.Spec.MinReadySeconds)
+	assert.Equal(t, intstr.FromInt(0), *deployment.Spec.Strategy.RollingUpdate.MaxUnavailable)
+}
+
 func TestGenerateRBACResources_V1Beta(t *testing.T) {
 	roleName := "role"
 	saName := "service-account"
@@ -641,6 +834,15 @@ func fixVolumeMount() corev1.VolumeMount {
 	}
 }
 
+func assertEnvVarNotExists(t *testing.T, name string, env []corev1.EnvVar) {
+	for _, e := range env {
+		if e.Name == name {
+			assert.Fail(t, fmt.Sprintf("unexpected env var %s", name))
+			return
+		}
+	}
+}
+
 func assertEnvVarEqual(t *testing.T, name, val string, env []corev1.EnvVar) {
 	for _, e := range env {
 		if e.Name == name {
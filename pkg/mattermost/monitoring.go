@@ -0,0 +1,170 @@
+package mattermost
+
+import (
+	mmv1beta "github.com/mattermost/mattermost-operator/apis/mattermost/v1beta1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+)
+
+const (
+	// basicAuthUsernameKey and basicAuthPasswordKey are the keys of the
+	// credentials in the basic authentication Secret of the monitoring.
+	basicAuthUsernameKey = "username"
+	basicAuthPasswordKey = "password"
+)
+
+// ServiceMonitorGVK is the kind of the ServiceMonitors of the Prometheus
+// Operator. Its API is not vendored, so ServiceMonitors are handled as
+// unstructured objects.
+var ServiceMonitorGVK = schema.GroupVersionKind{
+	Group:   "monitoring.coreos.com",
+	Version: "v1",
+	Kind:    "ServiceMonitor",
+}
+
+// PodMonitorGVK is the kind of the PodMonitors of the Prometheus Operator.
+var PodMonitorGVK = schema.GroupVersionKind{
+	Group:   "monitoring.coreos.com",
+	Version: "v1",
+	Kind:    "PodMonitor",
+}
+
+const (
+	// InstallationMetricLabel and ComponentMetricLabel are the labels set on
+	// the metrics scraped from the components of an installation, so that
+	// its whole stack is selected by the same labels.
+	InstallationMetricLabel = "mattermost_installation"
+	ComponentMetricLabel    = "mattermost_component"
+)
+
+// GenerateServiceMonitorV1Beta returns the ServiceMonitor scraping the
+// metrics port of the Mattermost Service.
+func GenerateServiceMonitorV1Beta(mattermost *mmv1beta.Mattermost) *unstructured.Unstructured {
+	monitoring := mattermost.Spec.Monitoring
+	if monitoring == nil {
+		monitoring = &mmv1beta.Monitoring{}
+	}
+
+	endpoint := map[string]interface{}{
+		"port": "metrics",
+		"path": "/metrics",
+	}
+	if monitoring.Interval != "" {
+		endpoint["interval"] = monitoring.Interval
+	}
+	endpoint["relabelings"] = relabelings(mattermost, mmv1beta.AppComponent)
+	if monitoring.BasicAuthSecret != "" {
+		endpoint["basicAuth"] = map[string]interface{}{
+			"username": secretKeySelector(monitoring.BasicAuthSecret, basicAuthUsernameKey),
+			"password": secretKeySelector(monitoring.BasicAuthSecret, basicAuthPasswordKey),
+		}
+	}
+
+	matchLabels := map[string]interface{}{}
+	for key, value := range mmv1beta.MattermostResourceLabels(mattermost.Name) {
+		matchLabels[key] = value
+	}
+
+	serviceMonitor := &unstructured.Unstructured{Object: map[string]interface{}{
+		"spec": map[string]interface{}{
+			"endpoints": []interface{}{endpoint},
+			"selector": map[string]interface{}{
+				"matchLabels": matchLabels,
+			},
+			"namespaceSelector": map[string]interface{}{
+				"matchNames": []interface{}{mattermost.Namespace},
+			},
+		},
+	}}
+	serviceMonitor.SetGroupVersionKind(ServiceMonitorGVK)
+	serviceMonitor.SetName(mattermost.Name)
+	serviceMonitor.SetNamespace(mattermost.Namespace)
+	serviceMonitor.SetLabels(mergeStringMaps(mattermost.MattermostLabels(mattermost.Name), monitoring.Labels))
+	serviceMonitor.SetOwnerReferences(MattermostOwnerReference(mattermost))
+
+	return serviceMonitor
+}
+
+// GeneratePodMonitorV1Beta returns the PodMonitor scraping the endpoint of
+// the pods of an operator managed component matching the labels.
+func GeneratePodMonitorV1Beta(mattermost *mmv1beta.Mattermost, name, component string, podLabels map[string]string, endpoint map[string]interface{}) *unstructured.Unstructured {
+	monitoring := mattermost.Spec.Monitoring
+	if monitoring == nil {
+		monitoring = &mmv1beta.Monitoring{}
+	}
+
+	if monitoring.Interval != "" {
+		endpoint["interval"] = monitoring.Interval
+	}
+	endpoint["relabelings"] = relabelings(mattermost, component)
+
+	matchLabels := map[string]interface{}{}
+	for key, value := range podLabels {
+		matchLabels[key] = value
+	}
+
+	podMonitor := &unstructured.Unstructured{Object: map[string]interface{}{
+		"spec": map[string]interface{}{
+			"podMetricsEndpoints": []interface{}{endpoint},
+			"selector": map[string]interface{}{
+				"matchLabels": matchLabels,
+			},
+			"namespaceSelector": map[string]interface{}{
+				"matchNames": []interface{}{mattermost.Namespace},
+			},
+		},
+	}}
+	podMonitor.SetGroupVersionKind(PodMonitorGVK)
+	podMonitor.SetName(name)
+	podMonitor.SetNamespace(mattermost.Namespace)
+	podMonitor.SetLabels(mergeStringMaps(mmv1beta.MattermostResourceLabels(mattermost.Name), monitoring.Labels))
+	podMonitor.SetOwnerReferences(MattermostOwnerReference(mattermost))
+
+	return podMonitor
+}
+
+// relabelings returns the relabelings setting the installation and the
+// component labels, followed by the relabelings of the monitoring.
+func relabelings(mattermost *mmv1beta.Mattermost, component string) []interface{} {
+	relabelings := []interface{}{
+		map[string]interface{}{"targetLabel": InstallationMetricLabel, "replacement": mattermost.Name},
+		map[string]interface{}{"targetLabel": ComponentMetricLabel, "replacement": component},
+	}
+	if mattermost.Spec.Monitoring != nil {
+		for _, relabeling := range mattermost.Spec.Monitoring.Relabelings {
+			relabelings = append(relabelings, relabelConfig(relabeling))
+		}
+	}
+	return relabelings
+}
+
+func relabelConfig(relabeling mmv1beta.RelabelConfig) map[string]interface{} {
+	config := map[string]interface{}{}
+	if len(relabeling.SourceLabels) > 0 {
+		var sourceLabels []interface{}
+		for _, label := range relabeling.SourceLabels {
+			sourceLabels = append(sourceLabels, label)
+		}
+		config["sourceLabels"] = sourceLabels
+	}
+	fields := map[string]string{
+		"separator":   relabeling.Separator,
+		"targetLabel": relabeling.TargetLabel,
+		"regex":       relabeling.Regex,
+		"replacement": relabeling.Replacement,
+		"action":      relabeling.Action,
+	}
+	for key, value := range fields {
+		if value != "" {
+			config[key] = value
+		}
+	}
+	return config
+}
+
+func secretKeySelector(name, key string) map[string]interface{} {
+	return map[string]interface{}{
+		"name": name,
+		"key":  key,
+	}
+}
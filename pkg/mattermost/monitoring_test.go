@@ -0,0 +1,104 @@
+package mattermost
+
+import (
+	"testing"
+
+	mmv1beta "github.com/mattermost/mattermost-operator/apis/mattermost/v1beta1"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+)
+
+func TestGenerateServiceMonitorV1Beta(t *testing.T) {
+	mattermost := &mmv1beta.Mattermost{
+		ObjectMeta: metav1.ObjectMeta{Name: "mm", Namespace: "mm-namespace"},
+		Spec: mmv1beta.MattermostSpec{
+			LicenseSecret: "license",
+			Monitoring: &mmv1beta.Monitoring{
+				Enabled:         true,
+				Interval:        "30s",
+				BasicAuthSecret: "metrics-auth",
+				Labels:          map[string]string{"release": "prometheus"},
+				Relabelings: []mmv1beta.RelabelConfig{
+					{SourceLabels: []string{"__meta_kubernetes_pod_node_name"}, TargetLabel: "node"},
+				},
+			},
+		},
+	}
+
+	serviceMonitor := GenerateServiceMonitorV1Beta(mattermost)
+	assert.Equal(t, ServiceMonitorGVK, serviceMonitor.GroupVersionKind())
+	assert.Equal(t, "mm", serviceMonitor.GetName())
+	assert.Equal(t, "prometheus", serviceMonitor.GetLabels()["release"])
+	assert.Len(t, serviceMonitor.GetOwnerReferences(), 1)
+
+	endpoints, found, err := unstructured.NestedSlice(serviceMonitor.Object, "spec", "endpoints")
+	require.NoError(t, err)
+	require.True(t, found)
+	require.Len(t, endpoints, 1)
+	endpoint := endpoints[0].(map[string]interface{})
+	assert.Equal(t, "metrics", endpoint["port"])
+	assert.Equal(t, "30s", endpoint["interval"])
+	assert.Equal(t, []interface{}{
+		map[string]interface{}{"targetLabel": InstallationMetricLabel, "replacement": "mm"},
+		map[string]interface{}{"targetLabel": ComponentMetricLabel, "replacement": mmv1beta.AppComponent},
+		map[string]interface{}{"sourceLabels": []interface{}{"__meta_kubernetes_pod_node_name"}, "targetLabel": "node"},
+	}, endpoint["relabelings"])
+
+	password, _, err := unstructured.NestedString(endpoint, "basicAuth", "password", "key")
+	require.NoError(t, err)
+	assert.Equal(t, "password", password)
+
+	matchLabels, _, err := unstructured.NestedStringMap(serviceMonitor.Object, "spec", "selector", "matchLabels")
+	require.NoError(t, err)
+	assert.Equal(t, mmv1beta.MattermostResourceLabels("mm"), matchLabels)
+}
+
+func TestGeneratePodMonitorV1Beta(t *testing.T) {
+	mattermost := &mmv1beta.Mattermost{
+		ObjectMeta: metav1.ObjectMeta{Name: "mm", Namespace: "mm-namespace"},
+		Spec: mmv1beta.MattermostSpec{
+			Monitoring: &mmv1beta.Monitoring{Enabled: true, Interval: "1m"},
+		},
+	}
+
+	podMonitor := GeneratePodMonitorV1Beta(mattermost, "db-mm", mmv1beta.DatabaseComponent, map[string]string{"cluster": "db-mm"}, map[string]interface{}{"port": "prometheus"})
+	assert.Equal(t, PodMonitorGVK, podMonitor.GroupVersionKind())
+	assert.Equal(t, "db-mm", podMonitor.GetName())
+
+	endpoints, _, err := unstructured.NestedSlice(podMonitor.Object, "spec", "podMetricsEndpoints")
+	require.NoError(t, err)
+	require.Len(t, endpoints, 1)
+	endpoint := endpoints[0].(map[string]interface{})
+	assert.Equal(t, "1m", endpoint["interval"])
+	assert.Contains(t, endpoint["relabelings"], map[string]interface{}{"targetLabel": ComponentMetricLabel, "replacement": mmv1beta.DatabaseComponent})
+
+	matchLabels, _, err := unstructured.NestedStringMap(podMonitor.Object, "spec", "selector", "matchLabels")
+	require.NoError(t, err)
+	assert.Equal(t, map[string]string{"cluster": "db-mm"}, matchLabels)
+}
+
+func TestGenerateServiceV1BetaMetricsPort(t *testing.T) {
+	hasMetricsPort := func(service *corev1.Service) bool {
+		for _, port := range service.Spec.Ports {
+			if port.Name == "metrics" {
+				return true
+			}
+		}
+		return false
+	}
+
+	mattermost := &mmv1beta.Mattermost{
+		ObjectMeta: metav1.ObjectMeta{Name: "mm", Namespace: "mm-namespace"},
+		Spec: mmv1beta.MattermostSpec{
+			UseServiceLoadBalancer: true,
+			Monitoring:             &mmv1beta.Monitoring{Enabled: true},
+		},
+	}
+	assert.False(t, hasMetricsPort(GenerateServiceV1Beta(mattermost, "mm", "mm")), "monitoring requires a license")
+
+	mattermost.Spec.LicenseSecret = "license"
+	assert.True(t, hasMetricsPort(GenerateServiceV1Beta(mattermost, "mm", "mm")))
+}
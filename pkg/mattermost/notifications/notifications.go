@@ -0,0 +1,61 @@
+package notifications
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+// maxResponseSize limits the size of the webhook response read.
+const maxResponseSize = 1 << 16
+
+// message is the payload of the incoming webhooks of Mattermost and Slack.
+type message struct {
+	Text string `json:"text"`
+}
+
+// Client posts notifications to incoming webhooks, ie of Mattermost or
+// Slack.
+type Client struct {
+	HTTPClient *http.Client
+}
+
+// NewClient returns an incoming webhook client.
+func NewClient() *Client {
+	return &Client{
+		HTTPClient: &http.Client{Timeout: 10 * time.Second},
+	}
+}
+
+// Notify posts the text to the incoming webhook at the URL.
+func (c *Client) Notify(ctx context.Context, url, text string) error {
+	payload, err := json.Marshal(message{Text: text})
+	if err != nil {
+		return errors.Wrap(err, "failed to marshal notification")
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, url, bytes.NewReader(payload))
+	if err != nil {
+		return errors.Wrap(err, "failed to create notification request")
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := c.HTTPClient.Do(req)
+	if err != nil {
+		return errors.Wrap(err, "failed to send notification")
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		body, _ := ioutil.ReadAll(io.LimitReader(resp.Body, maxResponseSize))
+		return fmt.Errorf("webhook responded with status code %d to the notification: %s", resp.StatusCode, string(body))
+	}
+	return nil
+}
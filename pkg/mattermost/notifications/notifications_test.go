@@ -0,0 +1,40 @@
+package notifications
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestNotify(t *testing.T) {
+	var received message
+	statusCode := http.StatusOK
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, http.MethodPost, r.Method)
+		assert.Equal(t, "application/json", r.Header.Get("Content-Type"))
+		assert.NoError(t, json.NewDecoder(r.Body).Decode(&received))
+		w.WriteHeader(statusCode)
+		_, _ = w.Write([]byte("invalid_payload"))
+	}))
+	defer server.Close()
+
+	client := NewClient()
+
+	err := client.Notify(context.TODO(), server.URL+"/hooks/abc", "Upgrade started")
+	require.NoError(t, err)
+	assert.Equal(t, "Upgrade started", received.Text)
+
+	t.Run("rejected", func(t *testing.T) {
+		statusCode = http.StatusBadRequest
+
+		err := client.Notify(context.TODO(), server.URL+"/hooks/abc", "Upgrade started")
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "400")
+		assert.Contains(t, err.Error(), "invalid_payload")
+	})
+}
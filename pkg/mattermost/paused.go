@@ -0,0 +1,16 @@
+package mattermost
+
+import (
+	mmv1beta "github.com/mattermost/mattermost-operator/apis/mattermost/v1beta1"
+)
+
+// PausedAnnotation pauses the reconciliation of an installation when set to
+// "true", ie during incident response: the operator neither reconciles its
+// resources nor reverts the changes made to them until it is removed.
+const PausedAnnotation = "mattermost.com/paused"
+
+// IsPaused returns true if the reconciliation of the Mattermost is paused by
+// its paused annotation.
+func IsPaused(mattermost *mmv1beta.Mattermost) bool {
+	return mattermost.Annotations[PausedAnnotation] == "true"
+}
@@ -0,0 +1,65 @@
+package mattermost
+
+import (
+	"crypto/sha256"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	mmv1beta "github.com/mattermost/mattermost-operator/apis/mattermost/v1beta1"
+	appsv1 "k8s.io/api/apps/v1"
+)
+
+// PodTemplateHashAnnotation holds the hash of the pod template last applied
+// to the Mattermost deployment.
+const PodTemplateHashAnnotation = "installation.mattermost.com/pod-template-hash"
+
+// RestartedAtAnnotation holds the time the Mattermost pods were last
+// restarted by the operator.
+const RestartedAtAnnotation = "installation.mattermost.com/restarted-at"
+
+// RestartAnnotation requests a rolling restart of the Mattermost pods when
+// set to a time in the RFC 3339 format, ie by `kubectl mattermost restart`.
+// The pods are restarted once for each time.
+const RestartAnnotation = "mattermost.com/restart"
+
+// SetPodTemplateHash annotates the deployment with the hash of its pod
+// template, so that changes restarting the pods can be told apart from
+// changes of the deployment only.
+func SetPodTemplateHash(deployment *appsv1.Deployment) error {
+	template, err := json.Marshal(deployment.Spec.Template)
+	if err != nil {
+		return fmt.Errorf("failed to marshal pod template: %w", err)
+	}
+
+	if deployment.Annotations == nil {
+		deployment.Annotations = map[string]string{}
+	}
+	deployment.Annotations[PodTemplateHashAnnotation] = fmt.Sprintf("%x", sha256.Sum256(template))
+
+	return nil
+}
+
+// SetRestartedAt annotates the pod template of the deployment with the
+// restart time, so that its pods are replaced with a rolling restart when the
+// time changes.
+func SetRestartedAt(deployment *appsv1.Deployment, restartTime time.Time) {
+	if deployment.Spec.Template.Annotations == nil {
+		deployment.Spec.Template.Annotations = map[string]string{}
+	}
+	deployment.Spec.Template.Annotations[RestartedAtAnnotation] = restartTime.UTC().Format(time.RFC3339)
+}
+
+// RestartRequestedAt returns the time of the rolling restart requested by the
+// restart annotation of the Mattermost, if it is set to a valid time.
+func RestartRequestedAt(mattermost *mmv1beta.Mattermost) (time.Time, bool) {
+	value, ok := mattermost.Annotations[RestartAnnotation]
+	if !ok {
+		return time.Time{}, false
+	}
+	restartTime, err := time.Parse(time.RFC3339, value)
+	if err != nil {
+		return time.Time{}, false
+	}
+	return restartTime, true
+}
@@ -0,0 +1,25 @@
+package mattermost
+
+import (
+	"testing"
+	"time"
+
+	mmv1beta "github.com/mattermost/mattermost-operator/apis/mattermost/v1beta1"
+	"github.com/stretchr/testify/assert"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func TestRestartRequestedAt(t *testing.T) {
+	mattermost := &mmv1beta.Mattermost{}
+	_, ok := RestartRequestedAt(mattermost)
+	assert.False(t, ok)
+
+	mattermost.ObjectMeta = metav1.ObjectMeta{Annotations: map[string]string{RestartAnnotation: "2021-06-01T10:00:00Z"}}
+	restartTime, ok := RestartRequestedAt(mattermost)
+	assert.True(t, ok)
+	assert.Equal(t, time.Date(2021, 6, 1, 10, 0, 0, 0, time.UTC), restartTime)
+
+	mattermost.Annotations[RestartAnnotation] = "now"
+	_, ok = RestartRequestedAt(mattermost)
+	assert.False(t, ok)
+}
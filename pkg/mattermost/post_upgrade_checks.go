@@ -0,0 +1,131 @@
+package mattermost
+
+import (
+	"fmt"
+	"strings"
+
+	mmv1beta "github.com/mattermost/mattermost-operator/apis/mattermost/v1beta1"
+	batchv1 "k8s.io/api/batch/v1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+const (
+	// PostUpgradeChecksImageAnnotation holds the Mattermost image checked by
+	// the post-upgrade checks Job.
+	PostUpgradeChecksImageAnnotation = "installation.mattermost.com/post-upgrade-checks-image"
+
+	// PostUpgradeChecksContainerName is the name of the container running the
+	// post-upgrade checks.
+	PostUpgradeChecksContainerName = "post-upgrade-checks"
+
+	probeAccountSecretUsernameKey = "username"
+	probeAccountSecretPasswordKey = "password"
+)
+
+// PostUpgradeChecksJobName returns the name of the Job testing Mattermost
+// after an upgrade.
+func PostUpgradeChecksJobName(mattermost *mmv1beta.Mattermost) string {
+	return fmt.Sprintf("%s-post-upgrade-checks", mattermost.Name)
+}
+
+// GeneratePostUpgradeChecksJobV1Beta returns the Job testing the Mattermost
+// installation once it runs the image. By default the Job pings the
+// Mattermost service, logs in with the probe account and posts to the health
+// channel.
+func GeneratePostUpgradeChecksJobV1Beta(mattermost *mmv1beta.Mattermost, image string) *batchv1.Job {
+	checks := mattermost.Spec.UpdatePolicy.PostUpgradeChecks
+	backoffLimit := int32(0)
+	name := PostUpgradeChecksJobName(mattermost)
+
+	env := []corev1.EnvVar{
+		{
+			Name:  "MM_SERVICE_URL",
+			Value: ServiceURL(mattermost),
+		},
+		{
+			Name:  "MM_IMAGE",
+			Value: image,
+		},
+	}
+	if checks.ProbeAccountSecret != "" {
+		env = append(env,
+			corev1.EnvVar{
+				Name:      "PROBE_USERNAME",
+				ValueFrom: EnvSourceFromSecret(checks.ProbeAccountSecret, probeAccountSecretUsernameKey),
+			},
+			corev1.EnvVar{
+				Name:      "PROBE_PASSWORD",
+				ValueFrom: EnvSourceFromSecret(checks.ProbeAccountSecret, probeAccountSecretPasswordKey),
+			},
+		)
+	}
+	if checks.HealthChannel != "" {
+		channel := strings.SplitN(checks.HealthChannel, "/", 2)
+		env = append(env,
+			corev1.EnvVar{Name: "HEALTH_TEAM", Value: channel[0]},
+			corev1.EnvVar{Name: "HEALTH_CHANNEL", Value: channel[1]},
+		)
+	}
+
+	command := checks.Command
+	if len(command) == 0 {
+		command = []string{"/bin/sh", "-c", postUpgradeChecksCommand}
+	}
+
+	job := &batchv1.Job{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:            name,
+			Namespace:       mattermost.Namespace,
+			Labels:          mmv1beta.MattermostResourceLabels(mattermost.Name),
+			OwnerReferences: MattermostOwnerReference(mattermost),
+			Annotations: map[string]string{
+				PostUpgradeChecksImageAnnotation: image,
+			},
+		},
+		Spec: batchv1.JobSpec{
+			BackoffLimit: &backoffLimit,
+			Template: corev1.PodTemplateSpec{
+				ObjectMeta: metav1.ObjectMeta{
+					Labels: map[string]string{"app": name},
+				},
+				Spec: corev1.PodSpec{
+					RestartPolicy: corev1.RestartPolicyNever,
+					Containers: []corev1.Container{
+						{
+							Name:                     PostUpgradeChecksContainerName,
+							Image:                    mattermost.ImageWithRegistry(checks.Image),
+							ImagePullPolicy:          corev1.PullIfNotPresent,
+							Command:                  command,
+							Env:                      env,
+							TerminationMessagePolicy: corev1.TerminationMessageFallbackToLogsOnError,
+						},
+					},
+				},
+			},
+		},
+	}
+	SetJobPodSettings(mattermost, &job.Spec.Template.Spec)
+
+	return job
+}
+
+// postUpgradeChecksCommand pings Mattermost, and if the probe account is set
+// logs in and posts to the health channel. Failures are reported in the
+// termination message of the container.
+const postUpgradeChecksCommand = `fail() { echo "$1" | tee /dev/termination-log; exit 1; }
+curl -sf "$MM_SERVICE_URL/api/v4/system/ping" > /dev/null || fail "ping of $MM_SERVICE_URL failed"
+echo "ping of $MM_SERVICE_URL succeeded"
+[ -n "$PROBE_USERNAME" ] || exit 0
+
+login=$(printf '{"login_id":"%s","password":"%s"}' "$PROBE_USERNAME" "$PROBE_PASSWORD")
+token=$(curl -sfi -H 'Content-Type: application/json' -d "$login" "$MM_SERVICE_URL/api/v4/users/login" | grep -i '^token:' | cut -d ' ' -f 2 | tr -d '\r')
+[ -n "$token" ] || fail "login of probe account $PROBE_USERNAME failed"
+echo "login of probe account $PROBE_USERNAME succeeded"
+[ -n "$HEALTH_CHANNEL" ] || exit 0
+
+channel_id=$(curl -sf -H "Authorization: Bearer $token" "$MM_SERVICE_URL/api/v4/teams/name/$HEALTH_TEAM/channels/name/$HEALTH_CHANNEL" | grep -o '"id":"[^"]*"' | head -n 1 | cut -d '"' -f 4)
+[ -n "$channel_id" ] || fail "health channel $HEALTH_TEAM/$HEALTH_CHANNEL not found"
+post=$(printf '{"channel_id":"%s","message":"Post-upgrade checks of %s passed"}' "$channel_id" "$MM_IMAGE")
+curl -sf -H "Authorization: Bearer $token" -H 'Content-Type: application/json' -d "$post" "$MM_SERVICE_URL/api/v4/posts" > /dev/null || fail "post to health channel $HEALTH_TEAM/$HEALTH_CHANNEL failed"
+echo "post to health channel $HEALTH_TEAM/$HEALTH_CHANNEL succeeded"`
@@ -0,0 +1,50 @@
+package mattermost
+
+import (
+	"testing"
+
+	mmv1beta "github.com/mattermost/mattermost-operator/apis/mattermost/v1beta1"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func TestGeneratePostUpgradeChecksJob(t *testing.T) {
+	mattermost := &mmv1beta.Mattermost{
+		ObjectMeta: metav1.ObjectMeta{Name: "mm-test", Namespace: "mm-namespace"},
+		Spec: mmv1beta.MattermostSpec{
+			UpdatePolicy: &mmv1beta.UpdatePolicy{
+				PostUpgradeChecks: &mmv1beta.PostUpgradeChecks{
+					Enabled:            true,
+					ProbeAccountSecret: "probe-account",
+					HealthChannel:      "ops/upgrades",
+					Image:              mmv1beta.DefaultPostUpgradeChecksImage,
+				},
+			},
+		},
+	}
+
+	job := GeneratePostUpgradeChecksJobV1Beta(mattermost, "mattermost/mattermost-enterprise-edition:5.37.1")
+	assert.Equal(t, "mm-test-post-upgrade-checks", job.Name)
+	assert.Equal(t, "mattermost/mattermost-enterprise-edition:5.37.1", job.Annotations[PostUpgradeChecksImageAnnotation])
+	assert.Equal(t, int32(0), *job.Spec.BackoffLimit)
+
+	podSpec := job.Spec.Template.Spec
+	assert.Equal(t, corev1.RestartPolicyNever, podSpec.RestartPolicy)
+	require.Len(t, podSpec.Containers, 1)
+	container := podSpec.Containers[0]
+	assert.Equal(t, mmv1beta.DefaultPostUpgradeChecksImage, container.Image)
+	assert.Contains(t, container.Command[2], "/api/v4/system/ping")
+	assert.Contains(t, container.Env, corev1.EnvVar{Name: "MM_SERVICE_URL", Value: "http://mm-test.mm-namespace.svc.cluster.local:8065"})
+	assert.Contains(t, container.Env, corev1.EnvVar{Name: "PROBE_USERNAME", ValueFrom: EnvSourceFromSecret("probe-account", "username")})
+	assert.Contains(t, container.Env, corev1.EnvVar{Name: "HEALTH_TEAM", Value: "ops"})
+	assert.Contains(t, container.Env, corev1.EnvVar{Name: "HEALTH_CHANNEL", Value: "upgrades"})
+
+	t.Run("custom command", func(t *testing.T) {
+		mattermost.Spec.UpdatePolicy.PostUpgradeChecks.Command = []string{"/smoke-test.sh"}
+
+		job := GeneratePostUpgradeChecksJobV1Beta(mattermost, "mattermost/mattermost-enterprise-edition:5.37.1")
+		assert.Equal(t, []string{"/smoke-test.sh"}, job.Spec.Template.Spec.Containers[0].Command)
+	})
+}
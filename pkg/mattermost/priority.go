@@ -0,0 +1,51 @@
+package mattermost
+
+import (
+	"fmt"
+
+	mmv1beta "github.com/mattermost/mattermost-operator/apis/mattermost/v1beta1"
+)
+
+// PriorityAnnotation sets the priority tier of an installation, reconciled
+// before the installations of lower tiers when the operator is saturated or
+// restarting.
+const PriorityAnnotation = "mattermost.com/priority"
+
+// Priority is the priority tier of an installation.
+type Priority string
+
+const (
+	// PriorityProduction is the highest tier, the default one.
+	PriorityProduction Priority = "production"
+	// PriorityStaging is reconciled after the production tier.
+	PriorityStaging Priority = "staging"
+	// PriorityDev is the lowest tier.
+	PriorityDev Priority = "dev"
+)
+
+// Rank returns the rank of the tier, 0 for the highest one.
+func (p Priority) Rank() int {
+	switch p {
+	case PriorityStaging:
+		return 1
+	case PriorityDev:
+		return 2
+	default:
+		return 0
+	}
+}
+
+// GetPriority returns the priority tier set by the priority annotation of
+// the Mattermost, the production tier if it is not set.
+func GetPriority(mattermost *mmv1beta.Mattermost) (Priority, error) {
+	value, ok := mattermost.Annotations[PriorityAnnotation]
+	if !ok {
+		return PriorityProduction, nil
+	}
+	switch priority := Priority(value); priority {
+	case PriorityProduction, PriorityStaging, PriorityDev:
+		return priority, nil
+	default:
+		return PriorityProduction, fmt.Errorf("invalid priority %q, expected %s, %s or %s", value, PriorityProduction, PriorityStaging, PriorityDev)
+	}
+}
@@ -0,0 +1,28 @@
+package mattermost
+
+import (
+	"testing"
+
+	mmv1beta "github.com/mattermost/mattermost-operator/apis/mattermost/v1beta1"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestGetPriority(t *testing.T) {
+	mattermost := &mmv1beta.Mattermost{}
+	priority, err := GetPriority(mattermost)
+	require.NoError(t, err)
+	assert.Equal(t, PriorityProduction, priority)
+	assert.Equal(t, 0, priority.Rank())
+
+	mattermost.Annotations = map[string]string{PriorityAnnotation: "dev"}
+	priority, err = GetPriority(mattermost)
+	require.NoError(t, err)
+	assert.Equal(t, PriorityDev, priority)
+	assert.Equal(t, 2, priority.Rank())
+
+	mattermost.Annotations[PriorityAnnotation] = "critical"
+	priority, err = GetPriority(mattermost)
+	assert.Error(t, err)
+	assert.Equal(t, PriorityProduction, priority)
+}
@@ -0,0 +1,76 @@
+package mattermost
+
+import (
+	"strings"
+
+	mmv1beta "github.com/mattermost/mattermost-operator/apis/mattermost/v1beta1"
+	corev1 "k8s.io/api/core/v1"
+)
+
+// defaultNoProxy are the destinations never proxied, so that the services
+// of the cluster are reached directly. The services of the namespace of the
+// Mattermost, ie the Minio service, are not proxied either.
+var defaultNoProxy = []string{"localhost", "127.0.0.1", ".svc", ".cluster.local"}
+
+// proxyEnvVars returns the environment variables configuring the outbound
+// HTTP proxy of the Mattermost. They are set in upper and lower case, as
+// tools differ in the variables they read.
+func proxyEnvVars(mattermost *mmv1beta.Mattermost) []corev1.EnvVar {
+	proxy := mattermost.Spec.Proxy
+	if proxy == nil || (proxy.HTTPProxy == "" && proxy.HTTPSProxy == "") {
+		return nil
+	}
+
+	noProxy := append(append([]string{}, defaultNoProxy...), mattermost.Namespace)
+	if proxy.NoProxy != "" {
+		noProxy = append(noProxy, proxy.NoProxy)
+	}
+
+	var envVars []corev1.EnvVar
+	for _, proxyVar := range []struct {
+		name  string
+		value string
+	}{
+		{name: "HTTP_PROXY", value: proxy.HTTPProxy},
+		{name: "HTTPS_PROXY", value: proxy.HTTPSProxy},
+		{name: "NO_PROXY", value: strings.Join(noProxy, ",")},
+	} {
+		if proxyVar.value == "" {
+			continue
+		}
+		envVars = append(envVars,
+			corev1.EnvVar{Name: proxyVar.name, Value: proxyVar.value},
+			corev1.EnvVar{Name: strings.ToLower(proxyVar.name), Value: proxyVar.value},
+		)
+	}
+
+	return envVars
+}
+
+// setProxyEnv configures the outbound HTTP proxy of the Mattermost in the
+// containers, keeping the proxy variables the containers already set.
+func setProxyEnv(mattermost *mmv1beta.Mattermost, containers []corev1.Container) {
+	for _, envVar := range proxyEnvVars(mattermost) {
+		for i := range containers {
+			if !hasEnvVar(containers[i].Env, envVar.Name) {
+				containers[i].Env = append(containers[i].Env, envVar)
+			}
+		}
+	}
+}
+
+func hasEnvVar(envVars []corev1.EnvVar, name string) bool {
+	for _, envVar := range envVars {
+		if envVar.Name == name {
+			return true
+		}
+	}
+	return false
+}
+
+// setPodProxyEnv configures the outbound HTTP proxy of the Mattermost in all
+// containers of the pod.
+func setPodProxyEnv(mattermost *mmv1beta.Mattermost, podSpec *corev1.PodSpec) {
+	setProxyEnv(mattermost, podSpec.InitContainers)
+	setProxyEnv(mattermost, podSpec.Containers)
+}
@@ -0,0 +1,51 @@
+package mattermost
+
+import (
+	"testing"
+
+	mmv1beta "github.com/mattermost/mattermost-operator/apis/mattermost/v1beta1"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func TestProxyEnvVars(t *testing.T) {
+	mattermost := &mmv1beta.Mattermost{
+		ObjectMeta: metav1.ObjectMeta{Name: "mm-test", Namespace: "mm-namespace"},
+	}
+	assert.Empty(t, proxyEnvVars(mattermost))
+
+	mattermost.Spec.Proxy = &mmv1beta.Proxy{
+		HTTPSProxy: "http://proxy.example.com:3128",
+		NoProxy:    "10.0.0.0/8,.example.com",
+	}
+	assert.Equal(t, []corev1.EnvVar{
+		{Name: "HTTPS_PROXY", Value: "http://proxy.example.com:3128"},
+		{Name: "https_proxy", Value: "http://proxy.example.com:3128"},
+		{Name: "NO_PROXY", Value: "localhost,127.0.0.1,.svc,.cluster.local,mm-namespace,10.0.0.0/8,.example.com"},
+		{Name: "no_proxy", Value: "localhost,127.0.0.1,.svc,.cluster.local,mm-namespace,10.0.0.0/8,.example.com"},
+	}, proxyEnvVars(mattermost))
+
+	t.Run("deployment", func(t *testing.T) {
+		proxied := mattermost.DeepCopy()
+		proxied.Spec.MattermostEnv = []corev1.EnvVar{{Name: "NO_PROXY", Value: "*"}}
+		fileStore := &FileStoreInfo{config: &OperatorManagedMinioConfig{}}
+
+		deployment := GenerateDeploymentV1Beta(proxied, &MySQLDBConfig{}, fileStore, "mm-test", "", "", "image")
+
+		podSpec := deployment.Spec.Template.Spec
+		require.NotEmpty(t, podSpec.InitContainers)
+		for _, container := range podSpec.InitContainers {
+			assert.Contains(t, container.Env, corev1.EnvVar{Name: "https_proxy", Value: "http://proxy.example.com:3128"}, container.Name)
+		}
+		env := podSpec.Containers[0].Env
+		assert.Contains(t, env, corev1.EnvVar{Name: "HTTPS_PROXY", Value: "http://proxy.example.com:3128"})
+		assert.Contains(t, env, corev1.EnvVar{Name: "NO_PROXY", Value: "*"}, "Mattermost env overrides the proxy")
+	})
+
+	t.Run("job", func(t *testing.T) {
+		job := GenerateFileStoreMigrationJobV1Beta(mattermost, &FileStoreInfo{config: &ExternalFileStore{}}, &FileStoreInfo{config: &ExternalFileStore{}})
+		assert.Contains(t, job.Spec.Template.Spec.Containers[0].Env, corev1.EnvVar{Name: "HTTPS_PROXY", Value: "http://proxy.example.com:3128"})
+	})
+}
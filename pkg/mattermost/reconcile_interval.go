@@ -0,0 +1,27 @@
+package mattermost
+
+import (
+	"fmt"
+	"time"
+
+	mmv1beta "github.com/mattermost/mattermost-operator/apis/mattermost/v1beta1"
+)
+
+// ReconcileIntervalAnnotation sets the interval of the periodic
+// reconciliations of an installation, ie "1h" for a stable production
+// installation or "1m" for a development one.
+const ReconcileIntervalAnnotation = "mattermost.com/reconcile-interval"
+
+// ReconcileInterval returns the interval set by the reconcile interval
+// annotation of the Mattermost, 0 if it is not set.
+func ReconcileInterval(mattermost *mmv1beta.Mattermost) (time.Duration, error) {
+	value, ok := mattermost.Annotations[ReconcileIntervalAnnotation]
+	if !ok {
+		return 0, nil
+	}
+	interval, err := time.ParseDuration(value)
+	if err != nil || interval <= 0 {
+		return 0, fmt.Errorf("invalid reconcile interval %q, expected a positive duration", value)
+	}
+	return interval, nil
+}
@@ -0,0 +1,28 @@
+package mattermost
+
+import (
+	"testing"
+	"time"
+
+	mmv1beta "github.com/mattermost/mattermost-operator/apis/mattermost/v1beta1"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestReconcileInterval(t *testing.T) {
+	mattermost := &mmv1beta.Mattermost{}
+	interval, err := ReconcileInterval(mattermost)
+	require.NoError(t, err)
+	assert.Zero(t, interval)
+
+	mattermost.Annotations = map[string]string{ReconcileIntervalAnnotation: "1h"}
+	interval, err = ReconcileInterval(mattermost)
+	require.NoError(t, err)
+	assert.Equal(t, time.Hour, interval)
+
+	for _, value := range []string{"hourly", "0s", "-5m"} {
+		mattermost.Annotations[ReconcileIntervalAnnotation] = value
+		_, err = ReconcileInterval(mattermost)
+		assert.Error(t, err, value)
+	}
+}
@@ -0,0 +1,203 @@
+package releases
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	mmv1beta "github.com/mattermost/mattermost-operator/apis/mattermost/v1beta1"
+	"github.com/mattermost/mattermost-operator/pkg/mattermost/supportmatrix"
+	"github.com/pkg/errors"
+	"k8s.io/apimachinery/pkg/util/yaml"
+)
+
+// Release is a Mattermost release published in the releases feed.
+type Release struct {
+	// Version is the release number, ie 5.37.1.
+	Version string `json:"version"`
+	// ESR is set for the releases, including the patch releases, of an
+	// Extended Support Release.
+	ESR bool `json:"esr,omitempty"`
+}
+
+type releaseList struct {
+	Releases []Release `json:"releases"`
+}
+
+// Parse parses the releases feed, a list of releases under the 'releases'
+// key in YAML or JSON.
+func Parse(data []byte) ([]Release, error) {
+	list := &releaseList{}
+	err := yaml.NewYAMLOrJSONDecoder(bytes.NewReader(data), 4096).Decode(list)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to parse releases feed")
+	}
+	return list.Releases, nil
+}
+
+// Feed fetches the releases feed and caches it for the refresh interval.
+type Feed struct {
+	url             string
+	refreshInterval time.Duration
+	httpClient      *http.Client
+
+	lock      sync.Mutex
+	releases  []Release
+	fetchedAt time.Time
+}
+
+// NewFeed returns the releases feed served at the URL.
+func NewFeed(url string, refreshInterval time.Duration) *Feed {
+	return &Feed{
+		url:             url,
+		refreshInterval: refreshInterval,
+		httpClient:      &http.Client{Timeout: 30 * time.Second},
+	}
+}
+
+// RefreshInterval returns how long the releases are cached.
+func (f *Feed) RefreshInterval() time.Duration {
+	return f.refreshInterval
+}
+
+// FetchTime returns when the feed was last fetched.
+func (f *Feed) FetchTime() time.Time {
+	f.lock.Lock()
+	defer f.lock.Unlock()
+	return f.fetchedAt
+}
+
+// Releases returns the releases in the feed, fetching the feed again once
+// the refresh interval elapsed.
+func (f *Feed) Releases(ctx context.Context) ([]Release, error) {
+	f.lock.Lock()
+	defer f.lock.Unlock()
+
+	if f.releases != nil && time.Since(f.fetchedAt) < f.refreshInterval {
+		return f.releases, nil
+	}
+
+	releases, err := f.fetch(ctx)
+	if err != nil {
+		return nil, err
+	}
+	f.releases = releases
+	f.fetchedAt = time.Now()
+
+	return releases, nil
+}
+
+func (f *Feed) fetch(ctx context.Context) ([]Release, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, f.url, nil)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to create releases feed request")
+	}
+
+	resp, err := f.httpClient.Do(req)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to fetch releases feed")
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("failed to fetch releases feed: status code %d", resp.StatusCode)
+	}
+
+	data, err := ioutil.ReadAll(resp.Body)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to read releases feed")
+	}
+
+	return Parse(data)
+}
+
+// ChannelVersions returns the versions of the channel Mattermost can be
+// upgraded to from the current version, newest first. Pre-releases and
+// upgrades not supported by the support matrix are left out.
+func ChannelVersions(releases []Release, channel mmv1beta.UpdateChannel, current string, matrix *supportmatrix.SupportMatrix) []string {
+	currentVersion, err := supportmatrix.ParseVersion(current)
+	if err != nil {
+		return nil
+	}
+
+	type candidate struct {
+		name    string
+		version supportmatrix.Version
+	}
+	var candidates []candidate
+	for _, release := range releases {
+		if strings.ContainsAny(release.Version, "-+") {
+			continue
+		}
+		version, err := supportmatrix.ParseVersion(release.Version)
+		if err != nil || !currentVersion.Less(version) {
+			continue
+		}
+
+		switch channel {
+		case mmv1beta.UpdateChannelPatchOnly:
+			if !version.SameMinor(currentVersion) {
+				continue
+			}
+		case mmv1beta.UpdateChannelESR:
+			if !release.ESR {
+				continue
+			}
+		case mmv1beta.UpdateChannelLatest:
+		default:
+			continue
+		}
+
+		if matrix != nil && matrix.ValidateUpgrade(current, release.Version) != nil {
+			continue
+		}
+		candidates = append(candidates, candidate{name: release.Version, version: version})
+	}
+
+	sort.SliceStable(candidates, func(i, j int) bool {
+		return candidates[j].version.Less(candidates[i].version)
+	})
+
+	versions := make([]string, 0, len(candidates))
+	for _, c := range candidates {
+		versions = append(versions, c.name)
+	}
+	return versions
+}
+
+// AvailableUpdates returns the latest patch release of the minor release of
+// the current version and the latest Extended Support Release, if they are
+// newer than the current version.
+func AvailableUpdates(releases []Release, current string) (latestPatch, latestESR string) {
+	currentVersion, err := supportmatrix.ParseVersion(current)
+	if err != nil {
+		return "", ""
+	}
+
+	patch, esr := currentVersion, currentVersion
+	for _, release := range releases {
+		if strings.ContainsAny(release.Version, "-+") {
+			continue
+		}
+		version, err := supportmatrix.ParseVersion(release.Version)
+		if err != nil {
+			continue
+		}
+		if version.SameMinor(currentVersion) && patch.Less(version) {
+			patch = version
+			latestPatch = release.Version
+		}
+		if release.ESR && esr.Less(version) {
+			esr = version
+			latestESR = release.Version
+		}
+	}
+
+	return latestPatch, latestESR
+}
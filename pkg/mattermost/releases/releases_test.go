@@ -0,0 +1,84 @@
+package releases
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	mmv1beta "github.com/mattermost/mattermost-operator/apis/mattermost/v1beta1"
+	"github.com/mattermost/mattermost-operator/pkg/mattermost/supportmatrix"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestChannelVersions(t *testing.T) {
+	releases := []Release{
+		{Version: "5.31.3", ESR: true},
+		{Version: "5.31.4", ESR: true},
+		{Version: "5.36.0"},
+		{Version: "5.36.1"},
+		{Version: "5.37.0", ESR: true},
+		{Version: "5.37.1", ESR: true},
+		{Version: "5.38.0"},
+		{Version: "5.39.0-rc1"},
+	}
+
+	for _, tc := range []struct {
+		name     string
+		channel  mmv1beta.UpdateChannel
+		current  string
+		expected []string
+	}{
+		{name: "patch-only", channel: mmv1beta.UpdateChannelPatchOnly, current: "5.36.0", expected: []string{"5.36.1"}},
+		{name: "esr", channel: mmv1beta.UpdateChannelESR, current: "5.31.3", expected: []string{"5.37.1", "5.37.0", "5.31.4"}},
+		{name: "latest", channel: mmv1beta.UpdateChannelLatest, current: "5.37.0", expected: []string{"5.38.0", "5.37.1"}},
+		{name: "latest stops at required upgrade", channel: mmv1beta.UpdateChannelLatest, current: "5.36.1", expected: []string{"5.37.1", "5.37.0"}},
+		{name: "up to date", channel: mmv1beta.UpdateChannelLatest, current: "5.38.0", expected: []string{}},
+		{name: "digest", channel: mmv1beta.UpdateChannelLatest, current: "sha256:dd15a51ac7dafd213744d1ef23394e7532f71a90f477c969b94600e46da5a0cf", expected: nil},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			versions := ChannelVersions(releases, tc.channel, tc.current, &supportmatrix.Default)
+			assert.Equal(t, tc.expected, versions)
+		})
+	}
+}
+
+func TestAvailableUpdates(t *testing.T) {
+	releases := []Release{
+		{Version: "5.31.3", ESR: true},
+		{Version: "5.36.0"},
+		{Version: "5.36.1"},
+		{Version: "5.36.2-rc1"},
+		{Version: "5.37.1", ESR: true},
+		{Version: "5.38.0"},
+	}
+
+	latestPatch, latestESR := AvailableUpdates(releases, "5.36.0")
+	assert.Equal(t, "5.36.1", latestPatch)
+	assert.Equal(t, "5.37.1", latestESR)
+
+	latestPatch, latestESR = AvailableUpdates(releases, "5.38.0")
+	assert.Empty(t, latestPatch)
+	assert.Empty(t, latestESR)
+}
+
+func TestFeed(t *testing.T) {
+	requests := 0
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests++
+		_, _ = w.Write([]byte(`{"releases": [{"version": "5.37.1", "esr": true}, {"version": "5.38.0"}]}`))
+	}))
+	defer server.Close()
+
+	feed := NewFeed(server.URL, time.Hour)
+	releases, err := feed.Releases(context.Background())
+	require.NoError(t, err)
+	assert.Equal(t, []Release{{Version: "5.37.1", ESR: true}, {Version: "5.38.0"}}, releases)
+
+	_, err = feed.Releases(context.Background())
+	require.NoError(t, err)
+	assert.Equal(t, 1, requests)
+	assert.False(t, feed.FetchTime().IsZero())
+}
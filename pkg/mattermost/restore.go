@@ -0,0 +1,133 @@
+package mattermost
+
+import (
+	"fmt"
+	"path"
+
+	mmv1beta "github.com/mattermost/mattermost-operator/apis/mattermost/v1beta1"
+	batchv1 "k8s.io/api/batch/v1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+)
+
+const (
+	databaseRestoreContainerName = "restore-database"
+	downloadBackupContainerName  = "download-backup"
+
+	// databaseValidationQuery reads the version of the Mattermost schema. It
+	// only returns a value if the database is a Mattermost database.
+	databaseValidationQuery = "SELECT Value FROM Systems WHERE Name = 'Version'"
+)
+
+// RestoreJobName returns the name of the Job restoring the database of the
+// MattermostRestore.
+func RestoreJobName(restore *mmv1beta.MattermostRestore) string {
+	return fmt.Sprintf("%s-restore", restore.Name)
+}
+
+// MattermostRestoreOwnerReference returns the owner reference of resources
+// created for the MattermostRestore.
+func MattermostRestoreOwnerReference(restore *mmv1beta.MattermostRestore) []metav1.OwnerReference {
+	return []metav1.OwnerReference{
+		*metav1.NewControllerRef(restore, schema.GroupVersionKind{
+			Group:   mmv1beta.GroupVersion.Group,
+			Version: mmv1beta.GroupVersion.Version,
+			Kind:    "MattermostRestore",
+		}),
+	}
+}
+
+// GenerateRestoreJobV1Beta returns the Job downloading the database dump of
+// the backup and restoring it. The Job fails if the restored database is not
+// a Mattermost database.
+func GenerateRestoreJobV1Beta(restore *mmv1beta.MattermostRestore, mattermost *mmv1beta.Mattermost, db DatabaseConfig, source *FileStoreInfo) (*batchv1.Job, error) {
+	backoffLimit := int32(1)
+	name := RestoreJobName(restore)
+	dumpPath := path.Join(backupVolumeMountPath, "database.sql")
+	volumeMounts := []corev1.VolumeMount{
+		{
+			Name:      backupVolumeName,
+			MountPath: backupVolumeMountPath,
+		},
+	}
+
+	restoreContainer, err := db.RestoreContainer(mattermost, dumpPath)
+	if err != nil {
+		return nil, err
+	}
+	restoreContainer.VolumeMounts = volumeMounts
+	restoreContainer.TerminationMessagePolicy = corev1.TerminationMessageFallbackToLogsOnError
+
+	backupDumpPath := path.Join(source.bucketName, restore.Spec.Source.Prefix, restore.Spec.Backup, "database.sql")
+
+	job := &batchv1.Job{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:            name,
+			Namespace:       restore.Namespace,
+			Labels:          mmv1beta.MattermostResourceLabels(mattermost.Name),
+			OwnerReferences: MattermostRestoreOwnerReference(restore),
+		},
+		Spec: batchv1.JobSpec{
+			BackoffLimit: &backoffLimit,
+			Template: corev1.PodTemplateSpec{
+				ObjectMeta: metav1.ObjectMeta{
+					Labels: map[string]string{"app": name},
+				},
+				Spec: corev1.PodSpec{
+					RestartPolicy: corev1.RestartPolicyNever,
+					InitContainers: []corev1.Container{
+						{
+							Name:            downloadBackupContainerName,
+							Image:           mattermost.Spec.UtilityImages.GetMinioClient(),
+							ImagePullPolicy: corev1.PullIfNotPresent,
+							Command: []string{
+								"/bin/sh", "-c",
+								fmt.Sprintf("mc config host add source %s $(SOURCE_ACCESS_KEY) $(SOURCE_SECRET_KEY) && mc cp %s %s",
+									shellQuote(source.endpoint()), shellQuote("source/"+backupDumpPath), shellQuote(dumpPath),
+								),
+							},
+							Env: []corev1.EnvVar{
+								{
+									Name:      "SOURCE_ACCESS_KEY",
+									ValueFrom: EnvSourceFromSecret(source.secretName, fileStoreSecretAccessKey),
+								},
+								{
+									Name:      "SOURCE_SECRET_KEY",
+									ValueFrom: EnvSourceFromSecret(source.secretName, fileStoreSecretSecretKey),
+								},
+							},
+							VolumeMounts: volumeMounts,
+						},
+					},
+					Containers: []corev1.Container{*restoreContainer},
+					Volumes: []corev1.Volume{
+						{
+							Name: backupVolumeName,
+							VolumeSource: corev1.VolumeSource{
+								EmptyDir: &corev1.EmptyDirVolumeSource{},
+							},
+						},
+					},
+				},
+			},
+		},
+	}
+	setPodImageRegistry(mattermost, &job.Spec.Template.Spec)
+	SetJobPodSettings(mattermost, &job.Spec.Template.Spec)
+	SetJobPolicy(restore.Spec.JobPolicy, &job.Spec)
+
+	return job, nil
+}
+
+// databaseValidationScript returns the shell script checking that the
+// restored database is a Mattermost database with the given query command.
+// The restored version is written to the termination log to be reported in
+// the MattermostRestore status.
+func databaseValidationScript(queryCommand string) string {
+	return fmt.Sprintf("version=`%s \"%s\"` && "+
+		"if [ -z \"$version\" ]; then echo \"restored database does not contain a Mattermost schema version\" | tee /dev/termination-log; exit 1; fi && "+
+		"echo \"restored database of Mattermost $version\" > /dev/termination-log",
+		queryCommand, databaseValidationQuery,
+	)
+}
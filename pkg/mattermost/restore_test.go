@@ -0,0 +1,96 @@
+package mattermost
+
+import (
+	"testing"
+
+	mmv1beta "github.com/mattermost/mattermost-operator/apis/mattermost/v1beta1"
+	"github.com/mattermost/mattermost-operator/pkg/database"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func TestGenerateRestoreJob(t *testing.T) {
+	mattermost := &mmv1beta.Mattermost{
+		ObjectMeta: metav1.ObjectMeta{Name: "mm-test", Namespace: "mm-namespace"},
+	}
+	restore := &mmv1beta.MattermostRestore{
+		ObjectMeta: metav1.ObjectMeta{Name: "rollback", Namespace: "mm-namespace"},
+		Spec: mmv1beta.MattermostRestoreSpec{
+			MattermostName: "mm-test",
+			Source: mmv1beta.BackupDestination{
+				URL:    "s3.amazonaws.com",
+				Bucket: "backups",
+				Prefix: "mattermost",
+				Secret: "backup-secret",
+			},
+			Backup: "nightly/20210601030000",
+		},
+	}
+
+	source, err := NewBackupDestinationInfo(restore.Spec.Source, corev1.Secret{
+		ObjectMeta: metav1.ObjectMeta{Name: "backup-secret"},
+		Data: map[string][]byte{
+			"accesskey": []byte("access"),
+			"secretkey": []byte("secret"),
+		},
+	})
+	require.NoError(t, err)
+
+	for _, testCase := range []struct {
+		description    string
+		db             DatabaseConfig
+		image          string
+		restoreCommand string
+	}{
+		{
+			description:    "external PostgreSQL",
+			db:             &ExternalDBConfig{secretName: "db-secret", dbType: database.PostgreSQLDatabase},
+			image:          "postgres:13",
+			restoreCommand: "psql --dbname=\"$DB_CONNECTION_STRING\" --set ON_ERROR_STOP=1 --quiet --file=/backup/database.sql",
+		},
+		{
+			description:    "external MySQL",
+			db:             &ExternalDBConfig{secretName: "db-secret", dbType: database.MySQLDatabase},
+			image:          "mysql:5.7",
+			restoreCommand: "mysql --host \"$host\" --port \"$port\" --user \"$user\" \"$dbname\" < /backup/database.sql",
+		},
+		{
+			description:    "operator managed MySQL",
+			db:             &MySQLDBConfig{secretName: "db-secret"},
+			image:          "mysql:5.7",
+			restoreCommand: "--user root $(MYSQL_DATABASE) < /backup/database.sql",
+		},
+	} {
+		t.Run(testCase.description, func(t *testing.T) {
+			job, err := GenerateRestoreJobV1Beta(restore, mattermost, testCase.db, source)
+			require.NoError(t, err)
+			assert.Equal(t, "rollback-restore", job.Name)
+
+			podSpec := job.Spec.Template.Spec
+			require.Len(t, podSpec.InitContainers, 1)
+			assert.Contains(t, podSpec.InitContainers[0].Command[2], "mc cp 'source/backups/mattermost/nightly/20210601030000/database.sql' '/backup/database.sql'")
+
+			require.Len(t, podSpec.Containers, 1)
+			container := podSpec.Containers[0]
+			assert.Equal(t, testCase.image, container.Image)
+			assert.Contains(t, container.Command[2], testCase.restoreCommand)
+			assert.Contains(t, container.Command[2], databaseValidationQuery)
+			assert.Equal(t, corev1.TerminationMessageFallbackToLogsOnError, container.TerminationMessagePolicy)
+		})
+	}
+
+	t.Run("job policy", func(t *testing.T) {
+		backoffLimit := int32(3)
+		ttl := int32(3600)
+		restore := restore.DeepCopy()
+		restore.Spec.JobPolicy = &mmv1beta.JobPolicy{BackoffLimit: &backoffLimit, TTLSecondsAfterFinished: &ttl}
+
+		job, err := GenerateRestoreJobV1Beta(restore, mattermost, &MySQLDBConfig{secretName: "db-secret"}, source)
+		require.NoError(t, err)
+		assert.Equal(t, int32(3), *job.Spec.BackoffLimit)
+		assert.Equal(t, int32(3600), *job.Spec.TTLSecondsAfterFinished)
+		assert.Nil(t, job.Spec.ActiveDeadlineSeconds)
+	})
+}
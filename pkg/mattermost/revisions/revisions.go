@@ -0,0 +1,116 @@
+package revisions
+
+import (
+	mmv1beta "github.com/mattermost/mattermost-operator/apis/mattermost/v1beta1"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/equality"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// RevisionsKey is the key of the revisions in the revision history Secret.
+const RevisionsKey = "revisions.json"
+
+// Settings are the settings of a Mattermost restored by a rollback.
+type Settings struct {
+	Image              string            `json:"image,omitempty"`
+	Version            string            `json:"version,omitempty"`
+	MattermostEnv      []corev1.EnvVar   `json:"mattermostEnv,omitempty"`
+	IngressName        string            `json:"ingressName,omitempty"`
+	IngressAnnotations map[string]string `json:"ingressAnnotations,omitempty"`
+	UseIngressTLS      bool              `json:"useIngressTLS,omitempty"`
+	Ingress            *mmv1beta.Ingress `json:"ingress,omitempty"`
+}
+
+// Revision is the settings of a successful reconciliation of a Mattermost.
+type Revision struct {
+	// Number identifies the revision, it is incremented for every revision.
+	Number int64 `json:"number"`
+	// Generation is the generation of the spec of the revision.
+	Generation int64 `json:"generation"`
+	// Time is the time the revision was reconciled.
+	Time     metav1.Time `json:"time"`
+	Settings Settings    `json:"settings"`
+}
+
+// FromSpec returns the settings of the spec.
+func FromSpec(spec mmv1beta.MattermostSpec) Settings {
+	settings := Settings{
+		Image:              spec.Image,
+		Version:            spec.Version,
+		MattermostEnv:      copyEnv(spec.MattermostEnv),
+		IngressName:        spec.IngressName,
+		IngressAnnotations: copyAnnotations(spec.IngressAnnotations),
+		UseIngressTLS:      spec.UseIngressTLS,
+	}
+	if spec.Ingress != nil {
+		settings.Ingress = spec.Ingress.DeepCopy()
+	}
+	return settings
+}
+
+// Apply restores the settings in the spec.
+func (s Settings) Apply(spec *mmv1beta.MattermostSpec) {
+	spec.Image = s.Image
+	spec.Version = s.Version
+	spec.MattermostEnv = copyEnv(s.MattermostEnv)
+	spec.IngressName = s.IngressName
+	spec.IngressAnnotations = copyAnnotations(s.IngressAnnotations)
+	spec.UseIngressTLS = s.UseIngressTLS
+	spec.Ingress = nil
+	if s.Ingress != nil {
+		spec.Ingress = s.Ingress.DeepCopy()
+	}
+}
+
+func copyEnv(env []corev1.EnvVar) []corev1.EnvVar {
+	if len(env) == 0 {
+		return nil
+	}
+	copied := make([]corev1.EnvVar, 0, len(env))
+	for _, envVar := range env {
+		copied = append(copied, *envVar.DeepCopy())
+	}
+	return copied
+}
+
+func copyAnnotations(annotations map[string]string) map[string]string {
+	if len(annotations) == 0 {
+		return nil
+	}
+	copied := make(map[string]string, len(annotations))
+	for key, value := range annotations {
+		copied[key] = value
+	}
+	return copied
+}
+
+// Record prepends a revision of the settings to the revisions, newest first,
+// keeping at most limit revisions. The revisions are returned unchanged, with
+// false, if the settings match the latest revision.
+func Record(revisions []Revision, settings Settings, generation int64, now metav1.Time, limit int) ([]Revision, bool) {
+	number := int64(1)
+	if len(revisions) > 0 {
+		if equality.Semantic.DeepEqual(revisions[0].Settings, settings) {
+			return revisions, false
+		}
+		number = revisions[0].Number + 1
+	}
+
+	revision := Revision{Number: number, Generation: generation, Time: now, Settings: settings}
+	revisions = append([]Revision{revision}, revisions...)
+	if len(revisions) > limit {
+		revisions = revisions[:limit]
+	}
+	return revisions, true
+}
+
+// Find returns the revision with the number, or false if it is not in the
+// revisions.
+func Find(revisions []Revision, number int64) (Revision, bool) {
+	for _, revision := range revisions {
+		if revision.Number == number {
+			return revision, true
+		}
+	}
+	return Revision{}, false
+}
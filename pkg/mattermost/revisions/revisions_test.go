@@ -0,0 +1,77 @@
+package revisions
+
+import (
+	"testing"
+
+	mmv1beta "github.com/mattermost/mattermost-operator/apis/mattermost/v1beta1"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func TestRecord(t *testing.T) {
+	now := metav1.Now()
+	spec := mmv1beta.MattermostSpec{
+		Image:         "mattermost/mattermost-enterprise-edition",
+		Version:       "6.0.0",
+		MattermostEnv: []corev1.EnvVar{{Name: "MM_LOGSETTINGS_ENABLECONSOLE", Value: "true"}},
+		Ingress:       &mmv1beta.Ingress{Enabled: true, Host: "chat.example.com"},
+	}
+
+	revisions, recorded := Record(nil, FromSpec(spec), 1, now, 2)
+	require.True(t, recorded)
+	require.Len(t, revisions, 1)
+	assert.Equal(t, int64(1), revisions[0].Number)
+
+	t.Run("same settings", func(t *testing.T) {
+		spec.Replicas = new(int32)
+		unchanged, recorded := Record(revisions, FromSpec(spec), 2, now, 2)
+		assert.False(t, recorded)
+		assert.Equal(t, revisions, unchanged)
+	})
+
+	t.Run("limit", func(t *testing.T) {
+		spec.Version = "6.1.0"
+		revisions, recorded = Record(revisions, FromSpec(spec), 3, now, 2)
+		require.True(t, recorded)
+		spec.Ingress.Host = "mattermost.example.com"
+		revisions, recorded = Record(revisions, FromSpec(spec), 4, now, 2)
+		require.True(t, recorded)
+
+		require.Len(t, revisions, 2)
+		assert.Equal(t, int64(3), revisions[0].Number)
+		assert.Equal(t, int64(2), revisions[1].Number)
+		_, found := Find(revisions, 1)
+		assert.False(t, found)
+	})
+}
+
+func TestApply(t *testing.T) {
+	previous := mmv1beta.MattermostSpec{
+		Image:              "mattermost/mattermost-enterprise-edition",
+		Version:            "6.0.0",
+		IngressName:        "chat.example.com",
+		IngressAnnotations: map[string]string{"kubernetes.io/ingress.class": "nginx"},
+	}
+	settings := FromSpec(previous)
+
+	spec := mmv1beta.MattermostSpec{
+		Image:         "mattermost/mattermost-team-edition",
+		Version:       "6.1.0",
+		Replicas:      new(int32),
+		MattermostEnv: []corev1.EnvVar{{Name: "MM_SERVICESETTINGS_SITEURL", Value: "https://chat.example.com"}},
+		Ingress:       &mmv1beta.Ingress{Enabled: true, Host: "chat.example.com"},
+	}
+	settings.Apply(&spec)
+
+	assert.Equal(t, previous.Image, spec.Image)
+	assert.Equal(t, previous.Version, spec.Version)
+	assert.Nil(t, spec.MattermostEnv)
+	assert.Nil(t, spec.Ingress)
+	assert.Equal(t, previous.IngressAnnotations, spec.IngressAnnotations)
+	assert.NotNil(t, spec.Replicas, "settings out of the revisions are kept")
+
+	spec.IngressAnnotations["kubernetes.io/ingress.class"] = "traefik"
+	assert.Equal(t, "nginx", settings.IngressAnnotations["kubernetes.io/ingress.class"])
+}
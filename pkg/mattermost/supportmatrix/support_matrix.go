@@ -0,0 +1,157 @@
+package supportmatrix
+
+import (
+	"bytes"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/pkg/errors"
+	"k8s.io/apimachinery/pkg/util/yaml"
+)
+
+// ConfigMapKey is the key of the support matrix in the ConfigMap overriding
+// the default one.
+const ConfigMapKey = "supportMatrix"
+
+// SupportMatrix defines the Mattermost versions and upgrade paths supported
+// by the operator.
+type SupportMatrix struct {
+	// MinimumVersion is the oldest Mattermost version supported.
+	MinimumVersion string `json:"minimumVersion"`
+	// RequiredUpgradeStops are the minor versions, ie 5.37, with mandatory
+	// migrations. Upgrades cannot skip them.
+	RequiredUpgradeStops []string `json:"requiredUpgradeStops,omitempty"`
+}
+
+// Default is the support matrix used if none is configured. The Extended
+// Support Releases contain mandatory migrations.
+var Default = SupportMatrix{
+	MinimumVersion:       "5.25.0",
+	RequiredUpgradeStops: []string{"5.31", "5.37"},
+}
+
+// Parse parses a support matrix in YAML or JSON.
+func Parse(data []byte) (*SupportMatrix, error) {
+	matrix := &SupportMatrix{}
+	err := yaml.NewYAMLOrJSONDecoder(bytes.NewReader(data), 4096).Decode(matrix)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to parse support matrix")
+	}
+
+	if _, err = ParseVersion(matrix.MinimumVersion); err != nil {
+		return nil, errors.Wrap(err, "invalid minimumVersion")
+	}
+	for _, stop := range matrix.RequiredUpgradeStops {
+		if _, err = ParseVersion(stop); err != nil {
+			return nil, errors.Wrap(err, "invalid requiredUpgradeStops")
+		}
+	}
+
+	return matrix, nil
+}
+
+// ValidateUpgrade returns an error if Mattermost cannot be upgraded from the
+// running version to the requested one. The running version is empty on new
+// installations. New installations and installations kept on their running
+// version are not upgrades and are accepted whatever their version, running
+// versions out of support are reported by ValidateVersion. Versions which are
+// not release numbers, ie digests, cannot be validated and are accepted.
+func (m *SupportMatrix) ValidateUpgrade(from, to string) error {
+	if from == "" || from == to {
+		return nil
+	}
+
+	toVersion, err := ParseVersion(to)
+	if err != nil {
+		return nil
+	}
+
+	if err = m.supported(to, toVersion); err != nil {
+		return err
+	}
+
+	fromVersion, err := ParseVersion(from)
+	if err != nil {
+		return nil
+	}
+
+	for _, s := range m.RequiredUpgradeStops {
+		stop, err := ParseVersion(s)
+		if err != nil {
+			continue
+		}
+		if fromVersion.MinorLess(stop) && stop.MinorLess(toVersion) {
+			return fmt.Errorf("upgrade from Mattermost %s to %s skips %s which contains mandatory migrations, upgrade to %s first", from, to, s, s)
+		}
+	}
+
+	return nil
+}
+
+// ValidateVersion returns an error if the version is older than the minimum
+// supported version. Versions which are not release numbers are accepted.
+func (m *SupportMatrix) ValidateVersion(version string) error {
+	v, err := ParseVersion(version)
+	if err != nil {
+		return nil
+	}
+	return m.supported(version, v)
+}
+
+func (m *SupportMatrix) supported(name string, version Version) error {
+	minimum, err := ParseVersion(m.MinimumVersion)
+	if err == nil && version.Less(minimum) {
+		return fmt.Errorf("Mattermost %s is older than the minimum supported version %s", name, m.MinimumVersion)
+	}
+	return nil
+}
+
+// Version is a Mattermost release number.
+type Version struct {
+	Major, Minor, Patch int
+}
+
+// ParseVersion parses release numbers as 'major.minor' or
+// 'major.minor.patch', ignoring a 'v' prefix and pre-release suffixes.
+func ParseVersion(value string) (Version, error) {
+	value = strings.TrimPrefix(value, "v")
+	if i := strings.IndexAny(value, "-+"); i >= 0 {
+		value = value[:i]
+	}
+
+	parts := strings.Split(value, ".")
+	if len(parts) < 2 || len(parts) > 3 {
+		return Version{}, fmt.Errorf("%q is not a release version", value)
+	}
+
+	numbers := make([]int, 3)
+	for i, part := range parts {
+		number, err := strconv.Atoi(part)
+		if err != nil || number < 0 {
+			return Version{}, fmt.Errorf("%q is not a release version", value)
+		}
+		numbers[i] = number
+	}
+
+	return Version{Major: numbers[0], Minor: numbers[1], Patch: numbers[2]}, nil
+}
+
+// MinorLess reports whether the minor release of v is older than the one of
+// other.
+func (v Version) MinorLess(other Version) bool {
+	return v.Major < other.Major || (v.Major == other.Major && v.Minor < other.Minor)
+}
+
+// SameMinor reports whether v and other are releases of the same minor.
+func (v Version) SameMinor(other Version) bool {
+	return v.Major == other.Major && v.Minor == other.Minor
+}
+
+// Less reports whether v is older than other.
+func (v Version) Less(other Version) bool {
+	if !v.SameMinor(other) {
+		return v.MinorLess(other)
+	}
+	return v.Patch < other.Patch
+}
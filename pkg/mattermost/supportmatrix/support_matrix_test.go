@@ -0,0 +1,57 @@
+package supportmatrix
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestValidateUpgrade(t *testing.T) {
+	for _, tc := range []struct {
+		name  string
+		from  string
+		to    string
+		valid bool
+	}{
+		{name: "new installation", from: "", to: "5.37.1", valid: true},
+		{name: "patch upgrade", from: "5.37.0", to: "5.37.1", valid: true},
+		{name: "upgrade to required stop", from: "5.31.3", to: "5.37.0", valid: true},
+		{name: "upgrade from required stop", from: "5.37.1", to: "5.39.0", valid: true},
+		{name: "upgrade skipping required stop", from: "5.31.3", to: "5.38.0", valid: false},
+		{name: "upgrade skipping several required stops", from: "5.26.0", to: "5.39.0", valid: false},
+		{name: "new installation older than minimum", from: "", to: "5.19.1", valid: true},
+		{name: "running version older than minimum", from: "5.19.1", to: "5.19.1", valid: true},
+		{name: "upgrade to version older than minimum", from: "5.20.0", to: "5.24.2", valid: false},
+		{name: "digest", from: "5.26.0", to: "sha256:dd15a51ac7dafd213744d1ef23394e7532f71a90f477c969b94600e46da5a0cf", valid: true},
+		{name: "running digest", from: "sha256:dd15a51ac7dafd213744d1ef23394e7532f71a90f477c969b94600e46da5a0cf", to: "5.39.0", valid: true},
+		{name: "release candidate", from: "5.31.3", to: "5.38.0-rc1", valid: false},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			err := Default.ValidateUpgrade(tc.from, tc.to)
+			if tc.valid {
+				assert.NoError(t, err)
+			} else {
+				assert.Error(t, err)
+			}
+		})
+	}
+}
+
+func TestValidateVersion(t *testing.T) {
+	assert.NoError(t, Default.ValidateVersion("5.25.0"))
+	assert.NoError(t, Default.ValidateVersion("5.37.1"))
+	assert.NoError(t, Default.ValidateVersion("sha256:dd15a51ac7dafd213744d1ef23394e7532f71a90f477c969b94600e46da5a0cf"))
+	assert.Error(t, Default.ValidateVersion("5.24.2"))
+}
+
+func TestParse(t *testing.T) {
+	matrix, err := Parse([]byte("minimumVersion: 5.31.0\nrequiredUpgradeStops:\n- \"5.37\"\n- \"6.3\"\n"))
+	require.NoError(t, err)
+	assert.Equal(t, "5.31.0", matrix.MinimumVersion)
+	assert.Equal(t, []string{"5.37", "6.3"}, matrix.RequiredUpgradeStops)
+	assert.Error(t, matrix.ValidateUpgrade("5.37.1", "6.4.0"))
+
+	_, err = Parse([]byte("minimumVersion: latest\n"))
+	assert.Error(t, err)
+}
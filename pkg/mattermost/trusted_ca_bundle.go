@@ -0,0 +1,86 @@
+package mattermost
+
+import (
+	mmv1beta "github.com/mattermost/mattermost-operator/apis/mattermost/v1beta1"
+	corev1 "k8s.io/api/core/v1"
+)
+
+const (
+	trustedCABundleVolumeName = "trusted-ca-bundle"
+	// trustedCABundleMountPath is in the system trust directory, where Go
+	// programs, ie Mattermost and the MinIO client, load CA certificates from
+	// in addition to the system CA bundle.
+	trustedCABundleMountPath = "/etc/ssl/certs/mattermost-trusted-ca-bundle.crt"
+)
+
+// trustedCABundleConfig returns the volume and the volume mount of the
+// trusted CA bundle of the Mattermost, false if no bundle is configured.
+func trustedCABundleConfig(mattermost *mmv1beta.Mattermost) (corev1.Volume, corev1.VolumeMount, bool) {
+	bundle := mattermost.Spec.TrustedCABundle
+	if bundle == nil || bundle.ConfigMap == "" {
+		return corev1.Volume{}, corev1.VolumeMount{}, false
+	}
+
+	key := bundle.Key
+	if key == "" {
+		key = mmv1beta.DefaultTrustedCABundleKey
+	}
+
+	volume := corev1.Volume{
+		Name: trustedCABundleVolumeName,
+		VolumeSource: corev1.VolumeSource{
+			ConfigMap: &corev1.ConfigMapVolumeSource{
+				LocalObjectReference: corev1.LocalObjectReference{Name: bundle.ConfigMap},
+				Items: []corev1.KeyToPath{
+					{Key: key, Path: key},
+				},
+			},
+		},
+	}
+	volumeMount := corev1.VolumeMount{
+		Name:      trustedCABundleVolumeName,
+		MountPath: trustedCABundleMountPath,
+		SubPath:   key,
+		ReadOnly:  true,
+	}
+	return volume, volumeMount, true
+}
+
+// setPodTrustedCABundle mounts the trusted CA bundle of the Mattermost in all
+// containers of the pod. Pods copied from the Mattermost deployment already
+// mounting the bundle are left as is.
+func setPodTrustedCABundle(mattermost *mmv1beta.Mattermost, podSpec *corev1.PodSpec) {
+	volume, volumeMount, ok := trustedCABundleConfig(mattermost)
+	if !ok {
+		return
+	}
+
+	if !hasVolume(podSpec.Volumes, volume.Name) {
+		podSpec.Volumes = append(podSpec.Volumes, volume)
+	}
+	for _, containers := range [][]corev1.Container{podSpec.InitContainers, podSpec.Containers} {
+		for i := range containers {
+			if !hasVolumeMount(containers[i].VolumeMounts, volumeMount.Name) {
+				containers[i].VolumeMounts = append(containers[i].VolumeMounts, volumeMount)
+			}
+		}
+	}
+}
+
+func hasVolume(volumes []corev1.Volume, name string) bool {
+	for _, volume := range volumes {
+		if volume.Name == name {
+			return true
+		}
+	}
+	return false
+}
+
+func hasVolumeMount(volumeMounts []corev1.VolumeMount, name string) bool {
+	for _, volumeMount := range volumeMounts {
+		if volumeMount.Name == name {
+			return true
+		}
+	}
+	return false
+}
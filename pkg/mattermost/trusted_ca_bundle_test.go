@@ -0,0 +1,84 @@
+package mattermost
+
+import (
+	"testing"
+
+	mmv1beta "github.com/mattermost/mattermost-operator/apis/mattermost/v1beta1"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func TestTrustedCABundle(t *testing.T) {
+	mattermost := &mmv1beta.Mattermost{
+		ObjectMeta: metav1.ObjectMeta{Name: "mm-test", Namespace: "mm-namespace"},
+		Spec: mmv1beta.MattermostSpec{
+			TrustedCABundle: &mmv1beta.TrustedCABundle{ConfigMap: "internal-ca"},
+			Jobs: &mmv1beta.Jobs{
+				Export: &mmv1beta.ExportJob{ID: "2021-06-01"},
+			},
+		},
+	}
+	expectedMount := corev1.VolumeMount{
+		Name:      "trusted-ca-bundle",
+		MountPath: "/etc/ssl/certs/mattermost-trusted-ca-bundle.crt",
+		SubPath:   "ca-bundle.crt",
+		ReadOnly:  true,
+	}
+
+	fileStore := &FileStoreInfo{config: &OperatorManagedMinioConfig{}}
+	deployment := GenerateDeploymentV1Beta(mattermost, &MySQLDBConfig{}, fileStore, "mm-test", "", "", "image")
+
+	t.Run("deployment", func(t *testing.T) {
+		podSpec := deployment.Spec.Template.Spec
+		assert.Contains(t, podSpec.Volumes, corev1.Volume{
+			Name: "trusted-ca-bundle",
+			VolumeSource: corev1.VolumeSource{
+				ConfigMap: &corev1.ConfigMapVolumeSource{
+					LocalObjectReference: corev1.LocalObjectReference{Name: "internal-ca"},
+					Items:                []corev1.KeyToPath{{Key: "ca-bundle.crt", Path: "ca-bundle.crt"}},
+				},
+			},
+		})
+		assert.Contains(t, podSpec.Containers[0].VolumeMounts, expectedMount)
+	})
+
+	t.Run("job copying the deployment", func(t *testing.T) {
+		job, err := GenerateExportJobV1Beta(mattermost, deployment, &FileStoreInfo{config: &ExternalFileStore{}})
+		require.NoError(t, err)
+
+		podSpec := job.Spec.Template.Spec
+		assert.Len(t, volumesNamed(podSpec.Volumes, "trusted-ca-bundle"), 1)
+		for _, container := range append(podSpec.InitContainers, podSpec.Containers...) {
+			assert.Contains(t, container.VolumeMounts, expectedMount, container.Name)
+		}
+	})
+
+	t.Run("custom key", func(t *testing.T) {
+		withKey := mattermost.DeepCopy()
+		withKey.Spec.TrustedCABundle.Key = "ca.pem"
+
+		job := GenerateFileStoreMigrationJobV1Beta(withKey, &FileStoreInfo{config: &ExternalFileStore{}}, &FileStoreInfo{config: &ExternalFileStore{}})
+		mounts := job.Spec.Template.Spec.Containers[0].VolumeMounts
+		require.NotEmpty(t, mounts)
+		assert.Equal(t, "ca.pem", mounts[len(mounts)-1].SubPath)
+	})
+
+	t.Run("no bundle", func(t *testing.T) {
+		podSpec := &corev1.PodSpec{Containers: []corev1.Container{{Name: "test"}}}
+		setPodTrustedCABundle(&mmv1beta.Mattermost{}, podSpec)
+		assert.Empty(t, podSpec.Volumes)
+		assert.Empty(t, podSpec.Containers[0].VolumeMounts)
+	})
+}
+
+func volumesNamed(volumes []corev1.Volume, name string) []corev1.Volume {
+	var named []corev1.Volume
+	for _, volume := range volumes {
+		if volume.Name == name {
+			named = append(named, volume)
+		}
+	}
+	return named
+}
@@ -1,6 +1,11 @@
 package mattermost
 
-import corev1 "k8s.io/api/core/v1"
+import (
+	"fmt"
+
+	mmv1beta "github.com/mattermost/mattermost-operator/apis/mattermost/v1beta1"
+	corev1 "k8s.io/api/core/v1"
+)
 
 func EnvSourceFromSecret(secretName, key string) *corev1.EnvVarSource {
 	return &corev1.EnvVarSource{
@@ -30,3 +35,13 @@ func RemoveContainer(name string, containers []corev1.Container) []corev1.Contai
 
 	return containers
 }
+
+// ServiceURL returns the in-cluster URL of the Mattermost service.
+func ServiceURL(mattermost *mmv1beta.Mattermost) string {
+	return fmt.Sprintf("http://%s.%s.svc.cluster.local:8065", mattermost.Name, mattermost.Namespace)
+}
+
+// PodURL returns the URL of the Mattermost app server of the pod.
+func PodURL(pod *corev1.Pod) string {
+	return fmt.Sprintf("http://%s:8065", pod.Status.PodIP)
+}
@@ -0,0 +1,25 @@
+package mattermost
+
+import (
+	mmv1beta "github.com/mattermost/mattermost-operator/apis/mattermost/v1beta1"
+	corev1 "k8s.io/api/core/v1"
+)
+
+// setUtilityResources sets the utility resources of the Mattermost on the
+// containers without resource requests and limits, so that they are admitted
+// in namespaces with a LimitRange requiring them.
+func setUtilityResources(mattermost *mmv1beta.Mattermost, containers []corev1.Container) {
+	for i := range containers {
+		if len(containers[i].Resources.Requests) > 0 || len(containers[i].Resources.Limits) > 0 {
+			continue
+		}
+		containers[i].Resources = mattermost.GetUtilityResources()
+	}
+}
+
+// setPodUtilityResources sets the utility resources of the Mattermost on all
+// containers of the pod without resource requests and limits.
+func setPodUtilityResources(mattermost *mmv1beta.Mattermost, podSpec *corev1.PodSpec) {
+	setUtilityResources(mattermost, podSpec.InitContainers)
+	setUtilityResources(mattermost, podSpec.Containers)
+}
@@ -0,0 +1,45 @@
+package mattermost
+
+import (
+	"testing"
+
+	mmv1beta "github.com/mattermost/mattermost-operator/apis/mattermost/v1beta1"
+	"github.com/stretchr/testify/assert"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+)
+
+func TestSetUtilityResources(t *testing.T) {
+	appResources := corev1.ResourceRequirements{
+		Limits: corev1.ResourceList{corev1.ResourceMemory: resource.MustParse("4Gi")},
+	}
+
+	t.Run("default resources", func(t *testing.T) {
+		mattermost := &mmv1beta.Mattermost{}
+		podSpec := &corev1.PodSpec{
+			InitContainers: []corev1.Container{{Name: "init-check-database"}},
+			Containers:     []corev1.Container{{Name: "mattermost", Resources: appResources}},
+		}
+
+		setPodUtilityResources(mattermost, podSpec)
+		assert.Equal(t, resource.MustParse("50m"), podSpec.InitContainers[0].Resources.Requests[corev1.ResourceCPU])
+		assert.Equal(t, resource.MustParse("64Mi"), podSpec.InitContainers[0].Resources.Requests[corev1.ResourceMemory])
+		assert.Equal(t, resource.MustParse("500m"), podSpec.InitContainers[0].Resources.Limits[corev1.ResourceCPU])
+		assert.Equal(t, resource.MustParse("512Mi"), podSpec.InitContainers[0].Resources.Limits[corev1.ResourceMemory])
+		assert.Equal(t, appResources, podSpec.Containers[0].Resources)
+	})
+
+	t.Run("custom resources", func(t *testing.T) {
+		utilityResources := corev1.ResourceRequirements{
+			Requests: corev1.ResourceList{corev1.ResourceCPU: resource.MustParse("10m")},
+		}
+		mattermost := &mmv1beta.Mattermost{
+			Spec: mmv1beta.MattermostSpec{UtilityResources: &utilityResources},
+		}
+		containers := []corev1.Container{{Name: "init-check-minio"}, {Name: "custom", Resources: appResources}}
+
+		setUtilityResources(mattermost, containers)
+		assert.Equal(t, utilityResources, containers[0].Resources)
+		assert.Equal(t, appResources, containers[1].Resources)
+	})
+}
@@ -0,0 +1,81 @@
+package mattermost
+
+import (
+	"encoding/json"
+	"strings"
+
+	mmv1beta "github.com/mattermost/mattermost-operator/apis/mattermost/v1beta1"
+)
+
+const (
+	// VeleroExcludeLabel is the label excluding resources from Velero backups.
+	VeleroExcludeLabel = "velero.io/exclude-from-backup"
+
+	veleroBackupVolumesAnnotation         = "backup.velero.io/backup-volumes"
+	veleroBackupVolumesExcludesAnnotation = "backup.velero.io/backup-volumes-excludes"
+	veleroPreHookPrefix                   = "pre.hook.backup.velero.io/"
+	veleroPostHookPrefix                  = "post.hook.backup.velero.io/"
+)
+
+// VeleroEnabled returns true if Velero backup hooks and annotations should be
+// added to the operator managed dependencies of the installation.
+func VeleroEnabled(mattermost *mmv1beta.Mattermost) bool {
+	return mattermost.Spec.VeleroBackups != nil && mattermost.Spec.VeleroBackups.Enabled
+}
+
+// VeleroVolumesExcluded returns true if the volumes of the operator managed
+// dependencies should be excluded from Velero backups.
+func VeleroVolumesExcluded(mattermost *mmv1beta.Mattermost) bool {
+	return VeleroEnabled(mattermost) && mattermost.Spec.VeleroBackups.VolumeBackupMode == mmv1beta.VeleroVolumeBackupNone
+}
+
+// VeleroPodAnnotations returns the Velero backup annotations of the pods of
+// an operator managed dependency. The hooks are run in the given container,
+// defaultHooks are used if no hooks are configured for the dependency.
+// The volumes are annotated according to the volume backup mode. It returns
+// nil if Velero backups are not enabled.
+func VeleroPodAnnotations(mattermost *mmv1beta.Mattermost, hooks *mmv1beta.VeleroHooks, defaultHooks mmv1beta.VeleroHooks, container string, volumes ...string) map[string]string {
+	if !VeleroEnabled(mattermost) {
+		return nil
+	}
+
+	if hooks == nil {
+		hooks = &defaultHooks
+	}
+
+	annotations := map[string]string{}
+	addVeleroHookAnnotations(annotations, veleroPreHookPrefix, hooks.PreHook, container)
+	addVeleroHookAnnotations(annotations, veleroPostHookPrefix, hooks.PostHook, container)
+
+	if len(volumes) > 0 {
+		switch mattermost.Spec.VeleroBackups.VolumeBackupMode {
+		case mmv1beta.VeleroPodVolumeBackup:
+			annotations[veleroBackupVolumesAnnotation] = strings.Join(volumes, ",")
+		default:
+			// Snapshotted and excluded volumes must not be copied if Velero
+			// backs up all pod volumes by default.
+			annotations[veleroBackupVolumesExcludesAnnotation] = strings.Join(volumes, ",")
+		}
+	}
+
+	return annotations
+}
+
+func addVeleroHookAnnotations(annotations map[string]string, prefix string, hook *mmv1beta.VeleroHook, container string) {
+	if hook == nil || len(hook.Command) == 0 {
+		return
+	}
+
+	// Velero expects the command as a JSON array, marshaling a slice of
+	// strings cannot fail.
+	command, _ := json.Marshal(hook.Command)
+
+	annotations[prefix+"container"] = container
+	annotations[prefix+"command"] = string(command)
+	if hook.Timeout != "" {
+		annotations[prefix+"timeout"] = hook.Timeout
+	}
+	if hook.OnError != "" {
+		annotations[prefix+"on-error"] = hook.OnError
+	}
+}
@@ -0,0 +1,77 @@
+package mattermost
+
+import (
+	"testing"
+
+	mmv1beta "github.com/mattermost/mattermost-operator/apis/mattermost/v1beta1"
+	"github.com/stretchr/testify/assert"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func TestVeleroPodAnnotations(t *testing.T) {
+	defaultHooks := mmv1beta.VeleroHooks{
+		PreHook: &mmv1beta.VeleroHook{Command: []string{"/bin/sh", "-c", "sync"}},
+	}
+
+	newMattermost := func(velero *mmv1beta.VeleroBackups) *mmv1beta.Mattermost {
+		return &mmv1beta.Mattermost{
+			ObjectMeta: metav1.ObjectMeta{Name: "mm-test", Namespace: "mm-namespace"},
+			Spec:       mmv1beta.MattermostSpec{VeleroBackups: velero},
+		}
+	}
+
+	t.Run("disabled", func(t *testing.T) {
+		assert.Nil(t, VeleroPodAnnotations(newMattermost(nil), nil, defaultHooks, "minio", "data"))
+		assert.Nil(t, VeleroPodAnnotations(newMattermost(&mmv1beta.VeleroBackups{}), nil, defaultHooks, "minio", "data"))
+	})
+
+	t.Run("default hooks with snapshots", func(t *testing.T) {
+		mattermost := newMattermost(&mmv1beta.VeleroBackups{Enabled: true})
+
+		assert.Equal(t, map[string]string{
+			"pre.hook.backup.velero.io/container":      "minio",
+			"pre.hook.backup.velero.io/command":        `["/bin/sh","-c","sync"]`,
+			"backup.velero.io/backup-volumes-excludes": "data",
+		}, VeleroPodAnnotations(mattermost, nil, defaultHooks, "minio", "data"))
+		assert.False(t, VeleroVolumesExcluded(mattermost))
+	})
+
+	t.Run("custom hooks with pod volume backups", func(t *testing.T) {
+		mattermost := newMattermost(&mmv1beta.VeleroBackups{
+			Enabled:          true,
+			VolumeBackupMode: mmv1beta.VeleroPodVolumeBackup,
+		})
+		hooks := &mmv1beta.VeleroHooks{
+			PreHook: &mmv1beta.VeleroHook{
+				Command: []string{"fsfreeze", "--freeze", "/export"},
+				Timeout: "1m",
+				OnError: "Fail",
+			},
+			PostHook: &mmv1beta.VeleroHook{
+				Command: []string{"fsfreeze", "--unfreeze", "/export"},
+			},
+		}
+
+		assert.Equal(t, map[string]string{
+			"pre.hook.backup.velero.io/container":  "minio",
+			"pre.hook.backup.velero.io/command":    `["fsfreeze","--freeze","/export"]`,
+			"pre.hook.backup.velero.io/timeout":    "1m",
+			"pre.hook.backup.velero.io/on-error":   "Fail",
+			"post.hook.backup.velero.io/container": "minio",
+			"post.hook.backup.velero.io/command":   `["fsfreeze","--unfreeze","/export"]`,
+			"backup.velero.io/backup-volumes":      "data",
+		}, VeleroPodAnnotations(mattermost, hooks, defaultHooks, "minio", "data"))
+	})
+
+	t.Run("hooks disabled with excluded volumes", func(t *testing.T) {
+		mattermost := newMattermost(&mmv1beta.VeleroBackups{
+			Enabled:          true,
+			VolumeBackupMode: mmv1beta.VeleroVolumeBackupNone,
+		})
+
+		assert.Equal(t, map[string]string{
+			"backup.velero.io/backup-volumes-excludes": "data",
+		}, VeleroPodAnnotations(mattermost, &mmv1beta.VeleroHooks{}, defaultHooks, "minio", "data"))
+		assert.True(t, VeleroVolumesExcluded(mattermost))
+	})
+}
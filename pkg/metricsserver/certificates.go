@@ -0,0 +1,62 @@
+package metricsserver
+
+import (
+	"crypto/tls"
+	"os"
+	"sync"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+// certificateLoader serves the certificate of the files, reloading them once
+// they are modified, ie when the Secret they are mounted from is updated.
+type certificateLoader struct {
+	certFile string
+	keyFile  string
+
+	mu      sync.Mutex
+	modTime time.Time
+	cert    *tls.Certificate
+}
+
+// GetCertificate implements tls.Config.GetCertificate.
+func (l *certificateLoader) GetCertificate(_ *tls.ClientHelloInfo) (*tls.Certificate, error) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	modTime, err := l.latestModTime()
+	if err != nil {
+		return nil, err
+	}
+	if l.cert != nil && modTime.Equal(l.modTime) {
+		return l.cert, nil
+	}
+
+	cert, err := tls.LoadX509KeyPair(l.certFile, l.keyFile)
+	if err != nil {
+		// The previous certificate is served while the files are being
+		// written.
+		if l.cert != nil {
+			return l.cert, nil
+		}
+		return nil, errors.Wrap(err, "failed to load metrics serving certificate")
+	}
+	l.cert = &cert
+	l.modTime = modTime
+	return l.cert, nil
+}
+
+func (l *certificateLoader) latestModTime() (time.Time, error) {
+	var latest time.Time
+	for _, file := range []string{l.certFile, l.keyFile} {
+		info, err := os.Stat(file)
+		if err != nil {
+			return time.Time{}, errors.Wrap(err, "failed to read metrics serving certificate")
+		}
+		if info.ModTime().After(latest) {
+			latest = info.ModTime()
+		}
+	}
+	return latest, nil
+}
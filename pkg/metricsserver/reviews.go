@@ -0,0 +1,69 @@
+package metricsserver
+
+import (
+	"context"
+
+	"github.com/pkg/errors"
+	authenticationv1 "k8s.io/api/authentication/v1"
+	authorizationv1 "k8s.io/api/authorization/v1"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+// TokenReviewAuthenticator authenticates bearer tokens with TokenReviews.
+type TokenReviewAuthenticator struct {
+	Client client.Client
+	// Audiences are the audiences the token must be issued for, empty
+	// accepts the audiences of the API server.
+	Audiences []string
+}
+
+// AuthenticateToken returns the user the token belongs to.
+func (a *TokenReviewAuthenticator) AuthenticateToken(ctx context.Context, token string) (authenticationv1.UserInfo, bool, error) {
+	review := &authenticationv1.TokenReview{
+		Spec: authenticationv1.TokenReviewSpec{
+			Token:     token,
+			Audiences: a.Audiences,
+		},
+	}
+	err := a.Client.Create(ctx, review)
+	if err != nil {
+		return authenticationv1.UserInfo{}, false, errors.Wrap(err, "failed to create token review")
+	}
+	return review.Status.User, review.Status.Authenticated, nil
+}
+
+// SubjectAccessReviewAuthorizer authorizes users with SubjectAccessReviews
+// of the non-resource path, ie granted by a ClusterRole allowing to get the
+// /metrics non-resource URL.
+type SubjectAccessReviewAuthorizer struct {
+	Client client.Client
+}
+
+// Authorize returns true if the user is allowed the verb on the path.
+func (a *SubjectAccessReviewAuthorizer) Authorize(ctx context.Context, user authenticationv1.UserInfo, verb, path string) (bool, error) {
+	var extra map[string]authorizationv1.ExtraValue
+	if len(user.Extra) > 0 {
+		extra = make(map[string]authorizationv1.ExtraValue, len(user.Extra))
+		for key, value := range user.Extra {
+			extra[key] = authorizationv1.ExtraValue(value)
+		}
+	}
+
+	review := &authorizationv1.SubjectAccessReview{
+		Spec: authorizationv1.SubjectAccessReviewSpec{
+			User:   user.Username,
+			UID:    user.UID,
+			Groups: user.Groups,
+			Extra:  extra,
+			NonResourceAttributes: &authorizationv1.NonResourceAttributes{
+				Path: path,
+				Verb: verb,
+			},
+		},
+	}
+	err := a.Client.Create(ctx, review)
+	if err != nil {
+		return false, errors.Wrap(err, "failed to create subject access review")
+	}
+	return review.Status.Allowed, nil
+}
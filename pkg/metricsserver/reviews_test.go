@@ -0,0 +1,80 @@
+package metricsserver
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	authenticationv1 "k8s.io/api/authentication/v1"
+	authorizationv1 "k8s.io/api/authorization/v1"
+	"k8s.io/client-go/kubernetes/scheme"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+)
+
+// reviewClient answers the reviews it is sent like the API server.
+type reviewClient struct {
+	client.Client
+	tokens  map[string]authenticationv1.UserInfo
+	allowed map[string]bool
+
+	accessReview authorizationv1.SubjectAccessReviewSpec
+}
+
+func (c *reviewClient) Create(_ context.Context, obj client.Object, _ ...client.CreateOption) error {
+	switch review := obj.(type) {
+	case *authenticationv1.TokenReview:
+		review.Status.User, review.Status.Authenticated = c.tokens[review.Spec.Token]
+	case *authorizationv1.SubjectAccessReview:
+		c.accessReview = review.Spec
+		review.Status.Allowed = c.allowed[review.Spec.User]
+	}
+	return nil
+}
+
+func TestReviews(t *testing.T) {
+	user := authenticationv1.UserInfo{
+		Username: "system:serviceaccount:monitoring:prometheus",
+		UID:      "uid",
+		Groups:   []string{"system:serviceaccounts"},
+		Extra:    map[string]authenticationv1.ExtraValue{"authentication.kubernetes.io/pod-name": {"prometheus-0"}},
+	}
+	c := &reviewClient{
+		Client:  fake.NewFakeClientWithScheme(scheme.Scheme),
+		tokens:  map[string]authenticationv1.UserInfo{"token": user},
+		allowed: map[string]bool{user.Username: true},
+	}
+
+	t.Run("token review", func(t *testing.T) {
+		authenticator := &TokenReviewAuthenticator{Client: c}
+
+		authenticated, ok, err := authenticator.AuthenticateToken(context.Background(), "token")
+		require.NoError(t, err)
+		assert.True(t, ok)
+		assert.Equal(t, user, authenticated)
+
+		_, ok, err = authenticator.AuthenticateToken(context.Background(), "invalid")
+		require.NoError(t, err)
+		assert.False(t, ok)
+	})
+
+	t.Run("subject access review", func(t *testing.T) {
+		authorizer := &SubjectAccessReviewAuthorizer{Client: c}
+
+		allowed, err := authorizer.Authorize(context.Background(), user, "get", MetricsPath)
+		require.NoError(t, err)
+		assert.True(t, allowed)
+		assert.Equal(t, authorizationv1.SubjectAccessReviewSpec{
+			User:                  user.Username,
+			UID:                   user.UID,
+			Groups:                user.Groups,
+			Extra:                 map[string]authorizationv1.ExtraValue{"authentication.kubernetes.io/pod-name": {"prometheus-0"}},
+			NonResourceAttributes: &authorizationv1.NonResourceAttributes{Path: MetricsPath, Verb: "get"},
+		}, c.accessReview)
+
+		allowed, err = authorizer.Authorize(context.Background(), authenticationv1.UserInfo{Username: "other"}, "get", MetricsPath)
+		require.NoError(t, err)
+		assert.False(t, allowed)
+	})
+}
@@ -0,0 +1,214 @@
+package metricsserver
+
+import (
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"io/ioutil"
+	"net"
+	"net/http"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/go-logr/logr"
+	"github.com/mattermost/mattermost-operator/pkg/webhookcert"
+	"github.com/pkg/errors"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+	authenticationv1 "k8s.io/api/authentication/v1"
+	ctrl "sigs.k8s.io/controller-runtime"
+	ctrlmetrics "sigs.k8s.io/controller-runtime/pkg/metrics"
+)
+
+const (
+	// MetricsPath is the path the metrics are served at.
+	MetricsPath = "/metrics"
+
+	shutdownTimeout = 10 * time.Second
+)
+
+// TokenAuthenticator authenticates the bearer token of a request.
+type TokenAuthenticator interface {
+	AuthenticateToken(ctx context.Context, token string) (authenticationv1.UserInfo, bool, error)
+}
+
+// Authorizer authorizes an authenticated user to request a path.
+type Authorizer interface {
+	Authorize(ctx context.Context, user authenticationv1.UserInfo, verb, path string) (bool, error)
+}
+
+// Server serves the metrics of the operator over HTTPS, in place of the
+// plain HTTP endpoint of the manager. Clients authenticate with a bearer
+// token or a client certificate and are authorized to get the metrics path.
+type Server struct {
+	// Addr is the address the server binds to.
+	Addr    string
+	Handler http.Handler
+	// CertDir is the directory holding the tls.crt and tls.key serving
+	// certificate, reloaded when they change. A self-signed certificate for
+	// the DNS names is generated when it is empty.
+	CertDir  string
+	DNSNames []string
+	// ClientCAFile is the CA bundle client certificates are verified
+	// against. Clients are authenticated as the common name and the
+	// organizations of their certificate. Empty disables client
+	// certificates.
+	ClientCAFile string
+	// Authenticator authenticates bearer tokens, nil disables them.
+	Authenticator TokenAuthenticator
+	// Authorizer authorizes the authenticated clients, nil allows all of
+	// them.
+	Authorizer Authorizer
+	Log        logr.Logger
+}
+
+// NewServer returns a Server serving the metrics registry of the manager,
+// authenticating bearer tokens with TokenReviews and authorizing clients
+// with SubjectAccessReviews.
+func NewServer(mgr ctrl.Manager, addr string) *Server {
+	return &Server{
+		Addr:          addr,
+		Handler:       promhttp.HandlerFor(ctrlmetrics.Registry, promhttp.HandlerOpts{ErrorHandling: promhttp.HTTPErrorOnError}),
+		Authenticator: &TokenReviewAuthenticator{Client: mgr.GetClient()},
+		Authorizer:    &SubjectAccessReviewAuthorizer{Client: mgr.GetClient()},
+		Log:           ctrl.Log.WithName("metricsserver"),
+	}
+}
+
+// Start serves the metrics until the context is done.
+func (s *Server) Start(ctx context.Context) error {
+	tlsConfig, err := s.tlsConfig()
+	if err != nil {
+		return err
+	}
+
+	listener, err := net.Listen("tcp", s.Addr)
+	if err != nil {
+		return errors.Wrapf(err, "failed to listen on metrics address %s", s.Addr)
+	}
+
+	server := &http.Server{Handler: s.mux()}
+	errs := make(chan error, 1)
+	go func() {
+		s.Log.Info("Serving metrics over HTTPS", "addr", s.Addr)
+		errs <- server.Serve(tls.NewListener(listener, tlsConfig))
+	}()
+
+	select {
+	case err = <-errs:
+		return errors.Wrap(err, "failed to serve metrics")
+	case <-ctx.Done():
+	}
+
+	shutdownCtx, cancel := context.WithTimeout(context.Background(), shutdownTimeout)
+	defer cancel()
+	return server.Shutdown(shutdownCtx)
+}
+
+// NeedLeaderElection implements manager.LeaderElectionRunnable. Every
+// replica serves its own metrics.
+func (s *Server) NeedLeaderElection() bool {
+	return false
+}
+
+func (s *Server) mux() *http.ServeMux {
+	mux := http.NewServeMux()
+	mux.Handle(MetricsPath, s)
+	return mux
+}
+
+// ServeHTTP serves the request to authenticated and authorized clients.
+func (s *Server) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	user, ok, err := s.authenticate(r)
+	if err != nil {
+		s.Log.Error(err, "Failed to authenticate metrics request")
+		http.Error(w, "Internal Server Error", http.StatusInternalServerError)
+		return
+	}
+	if !ok {
+		http.Error(w, "Unauthorized", http.StatusUnauthorized)
+		return
+	}
+
+	if s.Authorizer != nil {
+		allowed, err := s.Authorizer.Authorize(r.Context(), user, strings.ToLower(r.Method), r.URL.Path)
+		if err != nil {
+			s.Log.Error(err, "Failed to authorize metrics request", "user", user.Username)
+			http.Error(w, "Internal Server Error", http.StatusInternalServerError)
+			return
+		}
+		if !allowed {
+			http.Error(w, "Forbidden", http.StatusForbidden)
+			return
+		}
+	}
+
+	s.Handler.ServeHTTP(w, r)
+}
+
+// authenticate returns the user of the verified client certificate or of
+// the bearer token of the request.
+func (s *Server) authenticate(r *http.Request) (authenticationv1.UserInfo, bool, error) {
+	if r.TLS != nil && len(r.TLS.VerifiedChains) > 0 {
+		cert := r.TLS.VerifiedChains[0][0]
+		return authenticationv1.UserInfo{
+			Username: cert.Subject.CommonName,
+			Groups:   cert.Subject.Organization,
+		}, cert.Subject.CommonName != "", nil
+	}
+
+	if s.Authenticator == nil {
+		return authenticationv1.UserInfo{}, false, nil
+	}
+	header := r.Header.Get("Authorization")
+	const prefix = "bearer "
+	if len(header) <= len(prefix) || !strings.EqualFold(header[:len(prefix)], prefix) {
+		return authenticationv1.UserInfo{}, false, nil
+	}
+	return s.Authenticator.AuthenticateToken(r.Context(), strings.TrimSpace(header[len(prefix):]))
+}
+
+// tlsConfig returns the TLS configuration serving the certificate of the
+// certificate directory, or a generated one, and verifying the client
+// certificates given against the client CA.
+func (s *Server) tlsConfig() (*tls.Config, error) {
+	config := &tls.Config{MinVersion: tls.VersionTLS12}
+
+	if s.CertDir != "" {
+		loader := &certificateLoader{
+			certFile: filepath.Join(s.CertDir, webhookcert.CertKey),
+			keyFile:  filepath.Join(s.CertDir, webhookcert.KeyKey),
+		}
+		if _, err := loader.GetCertificate(nil); err != nil {
+			return nil, err
+		}
+		config.GetCertificate = loader.GetCertificate
+	} else {
+		certificates, _, err := webhookcert.Renew(webhookcert.Certificates{}, s.DNSNames, time.Now())
+		if err != nil {
+			return nil, errors.Wrap(err, "failed to generate metrics serving certificate")
+		}
+		cert, err := tls.X509KeyPair(certificates.Cert, certificates.Key)
+		if err != nil {
+			return nil, errors.Wrap(err, "failed to load metrics serving certificate")
+		}
+		config.Certificates = []tls.Certificate{cert}
+	}
+
+	if s.ClientCAFile != "" {
+		data, err := ioutil.ReadFile(s.ClientCAFile)
+		if err != nil {
+			return nil, errors.Wrap(err, "failed to read metrics client CA")
+		}
+		pool := x509.NewCertPool()
+		if !pool.AppendCertsFromPEM(data) {
+			return nil, errors.Errorf("no certificate found in metrics client CA %s", s.ClientCAFile)
+		}
+		config.ClientCAs = pool
+		// Clients without a certificate can still authenticate with a
+		// bearer token.
+		config.ClientAuth = tls.VerifyClientCertIfGiven
+	}
+
+	return config, nil
+}
@@ -0,0 +1,195 @@
+package metricsserver
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"io/ioutil"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	blubr "github.com/mattermost/blubr"
+	"github.com/mattermost/mattermost-operator/pkg/webhookcert"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	authenticationv1 "k8s.io/api/authentication/v1"
+)
+
+type fakeAuthenticator map[string]authenticationv1.UserInfo
+
+func (a fakeAuthenticator) AuthenticateToken(_ context.Context, token string) (authenticationv1.UserInfo, bool, error) {
+	user, ok := a[token]
+	return user, ok, nil
+}
+
+type fakeAuthorizer map[string]bool
+
+func (a fakeAuthorizer) Authorize(_ context.Context, user authenticationv1.UserInfo, verb, path string) (bool, error) {
+	return a[user.Username] && verb == "get" && path == MetricsPath, nil
+}
+
+func TestServer(t *testing.T) {
+	dir, err := ioutil.TempDir("", "metricsserver")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	caPEM, clientCert := generateClientCertificate(t, "prometheus")
+	clientCAFile := filepath.Join(dir, "client-ca.crt")
+	require.NoError(t, ioutil.WriteFile(clientCAFile, caPEM, 0600))
+
+	s := &Server{
+		Handler: http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			_, _ = w.Write([]byte("metrics"))
+		}),
+		DNSNames:     []string{"localhost"},
+		ClientCAFile: clientCAFile,
+		Authenticator: fakeAuthenticator{
+			"reader-token": {Username: "system:serviceaccount:monitoring:prometheus"},
+			"other-token":  {Username: "system:serviceaccount:default:default"},
+		},
+		Authorizer: fakeAuthorizer{
+			"system:serviceaccount:monitoring:prometheus": true,
+			"prometheus": true,
+		},
+		Log: blubr.InitLogger(),
+	}
+	tlsConfig, err := s.tlsConfig()
+	require.NoError(t, err)
+
+	server := httptest.NewUnstartedServer(s.mux())
+	server.TLS = tlsConfig
+	server.StartTLS()
+	defer server.Close()
+
+	get := func(t *testing.T, token string, certificates ...tls.Certificate) int {
+		client := &http.Client{Transport: &http.Transport{TLSClientConfig: &tls.Config{
+			InsecureSkipVerify: true,
+			Certificates:       certificates,
+		}}}
+		req, err := http.NewRequest(http.MethodGet, server.URL+MetricsPath, nil)
+		require.NoError(t, err)
+		if token != "" {
+			req.Header.Set("Authorization", "Bearer "+token)
+		}
+		resp, err := client.Do(req)
+		require.NoError(t, err)
+		defer resp.Body.Close()
+		return resp.StatusCode
+	}
+
+	t.Run("unauthenticated", func(t *testing.T) {
+		assert.Equal(t, http.StatusUnauthorized, get(t, ""))
+		assert.Equal(t, http.StatusUnauthorized, get(t, "invalid-token"))
+	})
+
+	t.Run("bearer token", func(t *testing.T) {
+		assert.Equal(t, http.StatusOK, get(t, "reader-token"))
+		assert.Equal(t, http.StatusForbidden, get(t, "other-token"))
+	})
+
+	t.Run("client certificate", func(t *testing.T) {
+		assert.Equal(t, http.StatusOK, get(t, "", clientCert))
+	})
+
+	t.Run("client certificate of another CA", func(t *testing.T) {
+		_, otherCert := generateClientCertificate(t, "prometheus")
+		client := &http.Client{Transport: &http.Transport{TLSClientConfig: &tls.Config{
+			InsecureSkipVerify: true,
+			Certificates:       []tls.Certificate{otherCert},
+		}}}
+		_, err := client.Get(server.URL + MetricsPath)
+		assert.Error(t, err)
+	})
+
+	t.Run("token authentication disabled", func(t *testing.T) {
+		authenticator := s.Authenticator
+		s.Authenticator = nil
+		defer func() { s.Authenticator = authenticator }()
+
+		assert.Equal(t, http.StatusUnauthorized, get(t, "reader-token"))
+		assert.Equal(t, http.StatusOK, get(t, "", clientCert))
+	})
+}
+
+func TestCertificateLoader(t *testing.T) {
+	dir, err := ioutil.TempDir("", "metricsserver")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	loader := &certificateLoader{
+		certFile: filepath.Join(dir, webhookcert.CertKey),
+		keyFile:  filepath.Join(dir, webhookcert.KeyKey),
+	}
+	_, err = loader.GetCertificate(nil)
+	require.Error(t, err)
+
+	now := time.Now()
+	writeCertificates := func(t *testing.T, modTime time.Time) {
+		certificates, _, err := webhookcert.Renew(webhookcert.Certificates{}, []string{"localhost"}, now)
+		require.NoError(t, err)
+		require.NoError(t, ioutil.WriteFile(loader.keyFile, certificates.Key, 0600))
+		require.NoError(t, ioutil.WriteFile(loader.certFile, certificates.Cert, 0600))
+		require.NoError(t, os.Chtimes(loader.keyFile, modTime, modTime))
+		require.NoError(t, os.Chtimes(loader.certFile, modTime, modTime))
+	}
+
+	writeCertificates(t, now.Add(-time.Hour))
+	first, err := loader.GetCertificate(nil)
+	require.NoError(t, err)
+	cached, err := loader.GetCertificate(nil)
+	require.NoError(t, err)
+	assert.Same(t, first, cached)
+
+	writeCertificates(t, now)
+	renewed, err := loader.GetCertificate(nil)
+	require.NoError(t, err)
+	assert.NotEqual(t, first.Certificate, renewed.Certificate)
+}
+
+// generateClientCertificate returns the PEM encoded CA and a client
+// certificate it signed for the common name.
+func generateClientCertificate(t *testing.T, commonName string) ([]byte, tls.Certificate) {
+	caKey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+	caTemplate := &x509.Certificate{
+		SerialNumber:          big.NewInt(1),
+		Subject:               pkix.Name{CommonName: "client-ca"},
+		NotBefore:             time.Now().Add(-time.Hour),
+		NotAfter:              time.Now().Add(time.Hour),
+		KeyUsage:              x509.KeyUsageCertSign,
+		BasicConstraintsValid: true,
+		IsCA:                  true,
+	}
+	caDER, err := x509.CreateCertificate(rand.Reader, caTemplate, caTemplate, caKey.Public(), caKey)
+	require.NoError(t, err)
+	caCert, err := x509.ParseCertificate(caDER)
+	require.NoError(t, err)
+
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+	template := &x509.Certificate{
+		SerialNumber: big.NewInt(2),
+		Subject:      pkix.Name{CommonName: commonName, Organization: []string{"monitoring"}},
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     time.Now().Add(time.Hour),
+		KeyUsage:     x509.KeyUsageDigitalSignature,
+		ExtKeyUsage:  []x509.ExtKeyUsage{x509.ExtKeyUsageClientAuth},
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, caCert, key.Public(), caKey)
+	require.NoError(t, err)
+
+	return pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: caDER}), tls.Certificate{
+		Certificate: [][]byte{der},
+		PrivateKey:  key,
+	}
+}
@@ -0,0 +1,158 @@
+// Package namespacecache restricts the cache of the manager to a list of
+// namespaces, ie the namespaces of the tenants managed by the operator.
+package namespacecache
+
+import (
+	"context"
+	"strings"
+
+	"github.com/pkg/errors"
+	"k8s.io/apimachinery/pkg/api/meta"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/client-go/rest"
+	"sigs.k8s.io/controller-runtime/pkg/cache"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/apiutil"
+)
+
+// ParseNamespaces parses a comma-separated list of namespaces, ignoring the
+// blanks and duplicates. All the namespaces are watched if the list is
+// empty.
+func ParseNamespaces(value string) []string {
+	var namespaces []string
+	seen := map[string]bool{}
+	for _, namespace := range strings.Split(value, ",") {
+		namespace = strings.TrimSpace(namespace)
+		if namespace == "" || seen[namespace] {
+			continue
+		}
+		seen[namespace] = true
+		namespaces = append(namespaces, namespace)
+	}
+	return namespaces
+}
+
+// NewCacheFunc returns a function creating a cache which only caches the
+// namespaced objects of the given namespaces. The cluster-scoped objects are
+// cached once for the whole cluster, instead of once per namespace.
+func NewCacheFunc(namespaces []string) cache.NewCacheFunc {
+	return func(config *rest.Config, opts cache.Options) (cache.Cache, error) {
+		if opts.Scheme == nil {
+			return nil, errors.New("the scheme of the namespace cache is not set")
+		}
+		if opts.Mapper == nil {
+			mapper, err := apiutil.NewDynamicRESTMapper(config)
+			if err != nil {
+				return nil, errors.Wrap(err, "failed to create the REST mapper of the namespace cache")
+			}
+			opts.Mapper = mapper
+		}
+
+		namespacedCache, err := cache.MultiNamespacedCacheBuilder(namespaces)(config, opts)
+		if err != nil {
+			return nil, err
+		}
+
+		clusterOpts := opts
+		clusterOpts.Namespace = ""
+		clusterCache, err := cache.New(config, clusterOpts)
+		if err != nil {
+			return nil, err
+		}
+
+		return &namespaceCache{Cache: namespacedCache, cluster: clusterCache, mapper: opts.Mapper, scheme: opts.Scheme}, nil
+	}
+}
+
+// namespaceCache serves the namespaced types from the cache of the
+// namespaces and the cluster-scoped types from the cluster cache.
+type namespaceCache struct {
+	cache.Cache
+	cluster cache.Cache
+	mapper  meta.RESTMapper
+	scheme  *runtime.Scheme
+}
+
+var _ cache.Cache = &namespaceCache{}
+
+func (c *namespaceCache) cacheFor(obj runtime.Object) (cache.Cache, error) {
+	gvk, err := apiutil.GVKForObject(obj, c.scheme)
+	if err != nil {
+		return nil, err
+	}
+	return c.cacheForKind(gvk)
+}
+
+func (c *namespaceCache) cacheForKind(gvk schema.GroupVersionKind) (cache.Cache, error) {
+	gvk.Kind = strings.TrimSuffix(gvk.Kind, "List")
+	mapping, err := c.mapper.RESTMapping(gvk.GroupKind(), gvk.Version)
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to get the scope of %s", gvk)
+	}
+	if mapping.Scope.Name() == meta.RESTScopeNameRoot {
+		return c.cluster, nil
+	}
+	return c.Cache, nil
+}
+
+func (c *namespaceCache) Get(ctx context.Context, key client.ObjectKey, obj client.Object) error {
+	target, err := c.cacheFor(obj)
+	if err != nil {
+		return err
+	}
+	return target.Get(ctx, key, obj)
+}
+
+func (c *namespaceCache) List(ctx context.Context, list client.ObjectList, opts ...client.ListOption) error {
+	target, err := c.cacheFor(list)
+	if err != nil {
+		return err
+	}
+	return target.List(ctx, list, opts...)
+}
+
+func (c *namespaceCache) GetInformer(ctx context.Context, obj client.Object) (cache.Informer, error) {
+	target, err := c.cacheFor(obj)
+	if err != nil {
+		return nil, err
+	}
+	return target.GetInformer(ctx, obj)
+}
+
+func (c *namespaceCache) GetInformerForKind(ctx context.Context, gvk schema.GroupVersionKind) (cache.Informer, error) {
+	target, err := c.cacheForKind(gvk)
+	if err != nil {
+		return nil, err
+	}
+	return target.GetInformerForKind(ctx, gvk)
+}
+
+func (c *namespaceCache) IndexField(ctx context.Context, obj client.Object, field string, extractValue client.IndexerFunc) error {
+	target, err := c.cacheFor(obj)
+	if err != nil {
+		return err
+	}
+	return target.IndexField(ctx, obj, field, extractValue)
+}
+
+// Start starts both caches, blocking until the context is done.
+func (c *namespaceCache) Start(ctx context.Context) error {
+	errs := make(chan error, 2)
+	for _, target := range []cache.Cache{c.Cache, c.cluster} {
+		go func(target cache.Cache) {
+			errs <- target.Start(ctx)
+		}(target)
+	}
+
+	for i := 0; i < 2; i++ {
+		if err := <-errs; err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (c *namespaceCache) WaitForCacheSync(ctx context.Context) bool {
+	return c.Cache.WaitForCacheSync(ctx) && c.cluster.WaitForCacheSync(ctx)
+}
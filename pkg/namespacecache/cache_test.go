@@ -0,0 +1,65 @@
+package namespacecache
+
+import (
+	"context"
+	"testing"
+
+	mmv1beta "github.com/mattermost/mattermost-operator/apis/mattermost/v1beta1"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/meta"
+	"k8s.io/apimachinery/pkg/runtime"
+	"sigs.k8s.io/controller-runtime/pkg/cache"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+// fakeCache records the objects it was asked for.
+type fakeCache struct {
+	cache.Cache
+	name string
+	got  *[]string
+}
+
+func (c *fakeCache) Get(_ context.Context, key client.ObjectKey, _ client.Object) error {
+	*c.got = append(*c.got, c.name+"/"+key.Name)
+	return nil
+}
+
+func (c *fakeCache) List(_ context.Context, _ client.ObjectList, _ ...client.ListOption) error {
+	*c.got = append(*c.got, c.name+"/list")
+	return nil
+}
+
+func TestNamespaceCacheRouting(t *testing.T) {
+	scheme := runtime.NewScheme()
+	require.NoError(t, corev1.AddToScheme(scheme))
+	require.NoError(t, mmv1beta.AddToScheme(scheme))
+
+	mapper := meta.NewDefaultRESTMapper(nil)
+	mapper.Add(corev1.SchemeGroupVersion.WithKind("Secret"), meta.RESTScopeNamespace)
+	mapper.Add(mmv1beta.GroupVersion.WithKind("Mattermost"), meta.RESTScopeNamespace)
+	mapper.Add(mmv1beta.GroupVersion.WithKind("MattermostTemplate"), meta.RESTScopeRoot)
+
+	var got []string
+	c := &namespaceCache{
+		Cache:   &fakeCache{name: "namespaces", got: &got},
+		cluster: &fakeCache{name: "cluster", got: &got},
+		mapper:  mapper,
+		scheme:  scheme,
+	}
+
+	require.NoError(t, c.Get(context.Background(), client.ObjectKey{Namespace: "team-a", Name: "secret"}, &corev1.Secret{}))
+	require.NoError(t, c.Get(context.Background(), client.ObjectKey{Name: "production"}, &mmv1beta.MattermostTemplate{}))
+	require.NoError(t, c.List(context.Background(), &mmv1beta.MattermostList{}))
+	require.NoError(t, c.List(context.Background(), &mmv1beta.MattermostTemplateList{}))
+	assert.Equal(t, []string{"namespaces/secret", "cluster/production", "namespaces/list", "cluster/list"}, got)
+
+	assert.Error(t, c.Get(context.Background(), client.ObjectKey{Name: "unknown"}, &corev1.ConfigMap{}))
+}
+
+func TestParseNamespaces(t *testing.T) {
+	assert.Empty(t, ParseNamespaces(""))
+	assert.Equal(t, []string{"team-a"}, ParseNamespaces("team-a"))
+	assert.Equal(t, []string{"team-a", "team-b"}, ParseNamespaces(" team-a, team-b,,team-a "))
+}
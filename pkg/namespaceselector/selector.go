@@ -0,0 +1,128 @@
+// Package namespaceselector restricts the operator to the namespaces whose
+// labels match a selector, ie mattermost.com/managed=true. The namespaces
+// are read from the cache, so that the namespaces labeled once the operator
+// runs are picked up without a restart.
+package namespaceselector
+
+import (
+	"context"
+
+	"github.com/go-logr/logr"
+	"github.com/pkg/errors"
+	corev1 "k8s.io/api/core/v1"
+	k8sErrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/meta"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/types"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/builder"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/event"
+	"sigs.k8s.io/controller-runtime/pkg/handler"
+	"sigs.k8s.io/controller-runtime/pkg/predicate"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+	"sigs.k8s.io/controller-runtime/pkg/source"
+)
+
+// Selector selects the namespaces whose labels match. The nil Selector
+// selects all the namespaces.
+type Selector struct {
+	Reader   client.Reader
+	Selector labels.Selector
+	Log      logr.Logger
+}
+
+// NewSelector returns a Selector reading the namespaces with the reader,
+// usually the cached client of the manager.
+func NewSelector(reader client.Reader, selector labels.Selector) *Selector {
+	return &Selector{
+		Reader:   reader,
+		Selector: selector,
+		Log:      ctrl.Log.WithName("namespaceselector"),
+	}
+}
+
+// Selects returns whether the namespace is selected. The namespaces which
+// do not exist are not selected.
+func (s *Selector) Selects(ctx context.Context, namespace string) (bool, error) {
+	if s == nil {
+		return true, nil
+	}
+	ns := &corev1.Namespace{}
+	err := s.Reader.Get(ctx, types.NamespacedName{Name: namespace}, ns)
+	if err != nil && k8sErrors.IsNotFound(err) {
+		return false, nil
+	} else if err != nil {
+		return false, errors.Wrapf(err, "failed to get namespace %s", namespace)
+	}
+	return s.Selector.Matches(labels.Set(ns.Labels)), nil
+}
+
+// Predicate filters out the events of the objects of the namespaces which
+// are not selected. The events of the objects whose namespace cannot be
+// read are let through, their reconciliation checks it again.
+func (s *Selector) Predicate() predicate.Predicate {
+	return predicate.NewPredicateFuncs(func(obj client.Object) bool {
+		selected, err := s.Selects(context.TODO(), obj.GetNamespace())
+		return selected || err != nil
+	})
+}
+
+// Watch adds a watch of the namespaces to the builder, enqueuing the objects
+// of the type of the list once their namespace gets selected. The builder
+// is returned unchanged by the nil Selector.
+func (s *Selector) Watch(blder *builder.Builder, list client.ObjectList) *builder.Builder {
+	if s == nil {
+		return blder
+	}
+	return blder.Watches(
+		&source.Kind{Type: &corev1.Namespace{}},
+		handler.EnqueueRequestsFromMapFunc(s.objectsOf(list)),
+		builder.WithPredicates(s.selectedPredicate()),
+	)
+}
+
+// selectedPredicate lets through the updates of the namespaces which select
+// them. The create events are filtered out: the objects of a new namespace
+// get their own events, as do all the objects once the operator starts.
+func (s *Selector) selectedPredicate() predicate.Predicate {
+	matches := func(obj client.Object) bool {
+		return s.Selector.Matches(labels.Set(obj.GetLabels()))
+	}
+	return predicate.Funcs{
+		CreateFunc: func(event.CreateEvent) bool { return false },
+		UpdateFunc: func(e event.UpdateEvent) bool {
+			return !matches(e.ObjectOld) && matches(e.ObjectNew)
+		},
+		DeleteFunc:  func(event.DeleteEvent) bool { return false },
+		GenericFunc: func(event.GenericEvent) bool { return false },
+	}
+}
+
+// objectsOf returns the requests of the objects of the type of the list in
+// the namespace.
+func (s *Selector) objectsOf(list client.ObjectList) handler.MapFunc {
+	return func(namespace client.Object) []reconcile.Request {
+		objects := list.DeepCopyObject().(client.ObjectList)
+		err := s.Reader.List(context.TODO(), objects, client.InNamespace(namespace.GetName()))
+		if err != nil {
+			s.Log.Error(err, "Failed to list the objects of the selected namespace", "namespace", namespace.GetName())
+			return nil
+		}
+		items, err := meta.ExtractList(objects)
+		if err != nil {
+			s.Log.Error(err, "Failed to list the objects of the selected namespace", "namespace", namespace.GetName())
+			return nil
+		}
+
+		var requests []reconcile.Request
+		for _, item := range items {
+			obj, ok := item.(client.Object)
+			if !ok {
+				continue
+			}
+			requests = append(requests, reconcile.Request{NamespacedName: types.NamespacedName{Namespace: obj.GetNamespace(), Name: obj.GetName()}})
+		}
+		return requests
+	}
+}
@@ -0,0 +1,72 @@
+package namespaceselector
+
+import (
+	"context"
+	"testing"
+
+	mmv1beta "github.com/mattermost/mattermost-operator/apis/mattermost/v1beta1"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+	"sigs.k8s.io/controller-runtime/pkg/event"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+)
+
+func TestSelector(t *testing.T) {
+	s := runtime.NewScheme()
+	require.NoError(t, corev1.AddToScheme(s))
+	require.NoError(t, mmv1beta.AddToScheme(s))
+
+	managed := map[string]string{"mattermost.com/managed": "true"}
+	c := fake.NewFakeClientWithScheme(s,
+		&corev1.Namespace{ObjectMeta: metav1.ObjectMeta{Name: "team-a", Labels: managed}},
+		&corev1.Namespace{ObjectMeta: metav1.ObjectMeta{Name: "team-b"}},
+		&mmv1beta.Mattermost{ObjectMeta: metav1.ObjectMeta{Namespace: "team-b", Name: "mm-1"}},
+		&mmv1beta.Mattermost{ObjectMeta: metav1.ObjectMeta{Namespace: "team-b", Name: "mm-2"}},
+		&mmv1beta.Mattermost{ObjectMeta: metav1.ObjectMeta{Namespace: "team-c", Name: "mm"}},
+	)
+	selector := NewSelector(c, labels.SelectorFromSet(managed))
+
+	t.Run("selects", func(t *testing.T) {
+		for namespace, expected := range map[string]bool{"team-a": true, "team-b": false, "missing": false} {
+			selected, err := selector.Selects(context.TODO(), namespace)
+			require.NoError(t, err)
+			assert.Equal(t, expected, selected, namespace)
+		}
+
+		// The nil Selector selects all the namespaces.
+		var all *Selector
+		selected, err := all.Selects(context.TODO(), "team-b")
+		require.NoError(t, err)
+		assert.True(t, selected)
+		assert.True(t, all.Predicate().Create(event.CreateEvent{Object: &mmv1beta.Mattermost{ObjectMeta: metav1.ObjectMeta{Namespace: "team-b"}}}))
+	})
+
+	t.Run("predicate", func(t *testing.T) {
+		p := selector.Predicate()
+		assert.True(t, p.Create(event.CreateEvent{Object: &mmv1beta.Mattermost{ObjectMeta: metav1.ObjectMeta{Namespace: "team-a", Name: "mm"}}}))
+		assert.False(t, p.Create(event.CreateEvent{Object: &mmv1beta.Mattermost{ObjectMeta: metav1.ObjectMeta{Namespace: "team-b", Name: "mm"}}}))
+	})
+
+	t.Run("newly selected namespace", func(t *testing.T) {
+		unlabeled := &corev1.Namespace{ObjectMeta: metav1.ObjectMeta{Name: "team-b"}}
+		labeled := &corev1.Namespace{ObjectMeta: metav1.ObjectMeta{Name: "team-b", Labels: managed}}
+
+		p := selector.selectedPredicate()
+		assert.True(t, p.Update(event.UpdateEvent{ObjectOld: unlabeled, ObjectNew: labeled}))
+		assert.False(t, p.Update(event.UpdateEvent{ObjectOld: labeled, ObjectNew: labeled}))
+		assert.False(t, p.Update(event.UpdateEvent{ObjectOld: labeled, ObjectNew: unlabeled}))
+		assert.False(t, p.Create(event.CreateEvent{Object: labeled}))
+
+		requests := selector.objectsOf(&mmv1beta.MattermostList{})(labeled)
+		assert.ElementsMatch(t, []reconcile.Request{
+			{NamespacedName: types.NamespacedName{Namespace: "team-b", Name: "mm-1"}},
+			{NamespacedName: types.NamespacedName{Namespace: "team-b", Name: "mm-2"}},
+		}, requests)
+	})
+}
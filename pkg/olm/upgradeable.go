@@ -0,0 +1,248 @@
+// Package olm reports the upgrade readiness of the operator to the Operator
+// Lifecycle Manager through its OperatorCondition, so that OLM does not
+// replace the operator while installations are upgraded or migrated.
+package olm
+
+import (
+	"context"
+	"fmt"
+	"reflect"
+	"strings"
+	"time"
+
+	"github.com/go-logr/logr"
+	mmv1beta "github.com/mattermost/mattermost-operator/apis/mattermost/v1beta1"
+	"github.com/pkg/errors"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/types"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+const (
+	// UpgradeableCondition is the type of the OperatorCondition condition
+	// read by OLM before it replaces the operator.
+	UpgradeableCondition = "Upgradeable"
+
+	// DefaultInterval is how often the upgrade readiness is reported.
+	DefaultInterval = 30 * time.Second
+)
+
+// OperatorConditionGVK is the kind of the OperatorCondition created by OLM
+// for the operator. The OLM API is not a dependency of the operator, the
+// OperatorCondition is read and updated as an unstructured object.
+var OperatorConditionGVK = schema.GroupVersionKind{
+	Group:   "operators.coreos.com",
+	Version: "v2",
+	Kind:    "OperatorCondition",
+}
+
+// UpgradeableReporter periodically sets the Upgradeable condition of the
+// OperatorCondition of the operator, false while installations are
+// upgraded, migrated, relocated, imported or restored.
+type UpgradeableReporter struct {
+	Client client.Client
+	// Reader lists the Mattermosts, fleet upgrades and restores.
+	Reader client.Reader
+	Log    logr.Logger
+	// Name and Namespace are the name and namespace of the
+	// OperatorCondition, set by OLM in the OPERATOR_CONDITION_NAME
+	// environment variable of the operator and its namespace.
+	Name      string
+	Namespace string
+	// Interval is how often the condition is reported.
+	Interval time.Duration
+	// Namespaces are the namespaces of the installations, all of them if
+	// empty.
+	Namespaces []string
+}
+
+// NewUpgradeableReporter returns an UpgradeableReporter updating the
+// OperatorCondition every DefaultInterval.
+func NewUpgradeableReporter(mgr ctrl.Manager, namespace, name string) *UpgradeableReporter {
+	return &UpgradeableReporter{
+		Client:    mgr.GetClient(),
+		Reader:    mgr.GetAPIReader(),
+		Log:       ctrl.Log.WithName("olm"),
+		Name:      name,
+		Namespace: namespace,
+		Interval:  DefaultInterval,
+	}
+}
+
+// Start reports the upgrade readiness every interval until the context is
+// done.
+func (r *UpgradeableReporter) Start(ctx context.Context) error {
+	ticker := time.NewTicker(r.Interval)
+	defer ticker.Stop()
+
+	for {
+		err := r.Report(ctx)
+		if err != nil {
+			r.Log.Error(err, "Failed to report upgrade readiness")
+		}
+
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-ticker.C:
+		}
+	}
+}
+
+// NeedLeaderElection implements manager.LeaderElectionRunnable. Only the
+// leader, which runs the upgrades, reports them.
+func (r *UpgradeableReporter) NeedLeaderElection() bool {
+	return true
+}
+
+// Report sets the Upgradeable condition of the OperatorCondition from the
+// operations in progress. The OperatorCondition is only updated when the
+// condition changes.
+func (r *UpgradeableReporter) Report(ctx context.Context) error {
+	operations, err := r.operationsInProgress(ctx)
+	if err != nil {
+		return err
+	}
+
+	condition := metav1.Condition{
+		Type:    UpgradeableCondition,
+		Status:  metav1.ConditionTrue,
+		Reason:  "NoOperationsInProgress",
+		Message: "No installation is upgraded or migrated",
+	}
+	if len(operations) > 0 {
+		condition.Status = metav1.ConditionFalse
+		condition.Reason = "OperationsInProgress"
+		condition.Message = fmt.Sprintf("Waiting for %s", strings.Join(operations, ", "))
+	}
+
+	operatorCondition := &unstructured.Unstructured{}
+	operatorCondition.SetGroupVersionKind(OperatorConditionGVK)
+	err = r.Client.Get(ctx, types.NamespacedName{Namespace: r.Namespace, Name: r.Name}, operatorCondition)
+	if err != nil {
+		return errors.Wrap(err, "failed to get the OperatorCondition")
+	}
+
+	conditions, err := specConditions(operatorCondition)
+	if err != nil {
+		return err
+	}
+	updated := append([]metav1.Condition{}, conditions...)
+	meta.SetStatusCondition(&updated, condition)
+	if reflect.DeepEqual(conditions, updated) {
+		return nil
+	}
+
+	items := make([]interface{}, 0, len(updated))
+	for i := range updated {
+		item, err := runtime.DefaultUnstructuredConverter.ToUnstructured(&updated[i])
+		if err != nil {
+			return errors.Wrap(err, "failed to convert the OperatorCondition conditions")
+		}
+		items = append(items, item)
+	}
+	err = unstructured.SetNestedSlice(operatorCondition.Object, items, "spec", "conditions")
+	if err != nil {
+		return errors.Wrap(err, "failed to set the OperatorCondition conditions")
+	}
+
+	r.Log.Info("Reporting upgrade readiness", "upgradeable", condition.Status, "message", condition.Message)
+	err = r.Client.Update(ctx, operatorCondition)
+	if err != nil {
+		return errors.Wrap(err, "failed to update the OperatorCondition")
+	}
+	return nil
+}
+
+// specConditions returns the conditions of the spec of the OperatorCondition,
+// the conditions set by the operator.
+func specConditions(operatorCondition *unstructured.Unstructured) ([]metav1.Condition, error) {
+	items, _, err := unstructured.NestedSlice(operatorCondition.Object, "spec", "conditions")
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to read the OperatorCondition conditions")
+	}
+	conditions := make([]metav1.Condition, 0, len(items))
+	for _, item := range items {
+		object, ok := item.(map[string]interface{})
+		if !ok {
+			return nil, errors.Errorf("invalid OperatorCondition condition %v", item)
+		}
+		var condition metav1.Condition
+		err = runtime.DefaultUnstructuredConverter.FromUnstructured(object, &condition)
+		if err != nil {
+			return nil, errors.Wrap(err, "failed to read the OperatorCondition conditions")
+		}
+		conditions = append(conditions, condition)
+	}
+	return conditions, nil
+}
+
+// operationsInProgress returns the upgrades and migrations of the
+// installations which would be interrupted by the replacement of the
+// operator.
+func (r *UpgradeableReporter) operationsInProgress(ctx context.Context) ([]string, error) {
+	namespaces := r.Namespaces
+	if len(namespaces) == 0 {
+		namespaces = []string{""}
+	}
+
+	var operations []string
+	for _, namespace := range namespaces {
+		var mattermosts mmv1beta.MattermostList
+		err := r.Reader.List(ctx, &mattermosts, client.InNamespace(namespace))
+		if err != nil {
+			return nil, errors.Wrap(err, "failed to list Mattermosts")
+		}
+		for _, mattermost := range mattermosts.Items {
+			operation := mattermostOperation(mattermost.Status)
+			if operation != "" {
+				operations = append(operations, fmt.Sprintf("the %s of Mattermost %s/%s", operation, mattermost.Namespace, mattermost.Name))
+			}
+		}
+
+		var upgrades mmv1beta.MattermostUpgradeList
+		err = r.Reader.List(ctx, &upgrades, client.InNamespace(namespace))
+		if err != nil {
+			return nil, errors.Wrap(err, "failed to list MattermostUpgrades")
+		}
+		for _, upgrade := range upgrades.Items {
+			if upgrade.Status.State == mmv1beta.MattermostUpgradeUpgrading {
+				operations = append(operations, fmt.Sprintf("the fleet upgrade %s/%s", upgrade.Namespace, upgrade.Name))
+			}
+		}
+
+		var restores mmv1beta.MattermostRestoreList
+		err = r.Reader.List(ctx, &restores, client.InNamespace(namespace))
+		if err != nil {
+			return nil, errors.Wrap(err, "failed to list MattermostRestores")
+		}
+		for _, restore := range restores.Items {
+			switch restore.Status.State {
+			case mmv1beta.RestoreScalingDown, mmv1beta.RestoreRestoring, mmv1beta.RestoreScalingUp:
+				operations = append(operations, fmt.Sprintf("the restore %s/%s", restore.Namespace, restore.Name))
+			}
+		}
+	}
+	return operations, nil
+}
+
+// mattermostOperation returns the operation in progress of the Mattermost
+// interrupted by the replacement of the operator, or an empty string.
+func mattermostOperation(status mmv1beta.MattermostStatus) string {
+	switch {
+	case status.Upgrade != nil && status.Upgrade.State == mmv1beta.UpgradeInProgress:
+		return "upgrade"
+	case status.FileStoreMigration != nil && status.FileStoreMigration.State == mmv1beta.FileStoreMigrationSyncing:
+		return "file store migration"
+	case status.Relocation != nil && status.Relocation.Phase != "" && status.Relocation.Phase != mmv1beta.RelocationFailed:
+		return "relocation"
+	case status.Import != nil && status.Import.State != "" && status.Import.State != mmv1beta.ImportCompleted && status.Import.State != mmv1beta.ImportFailed:
+		return "import"
+	}
+	return ""
+}
@@ -0,0 +1,121 @@
+package olm
+
+import (
+	"context"
+	"testing"
+
+	blubr "github.com/mattermost/blubr"
+	mmv1beta "github.com/mattermost/mattermost-operator/apis/mattermost/v1beta1"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	clientgoscheme "k8s.io/client-go/kubernetes/scheme"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+)
+
+func TestReport(t *testing.T) {
+	s := runtime.NewScheme()
+	require.NoError(t, clientgoscheme.AddToScheme(s))
+	require.NoError(t, mmv1beta.AddToScheme(s))
+	s.AddKnownTypeWithName(OperatorConditionGVK, &unstructured.Unstructured{})
+
+	key := types.NamespacedName{Namespace: "operators", Name: "mattermost-operator.v1.20.0"}
+	operatorCondition := &unstructured.Unstructured{}
+	operatorCondition.SetGroupVersionKind(OperatorConditionGVK)
+	operatorCondition.SetNamespace(key.Namespace)
+	operatorCondition.SetName(key.Name)
+
+	upgrading := &mmv1beta.Mattermost{ObjectMeta: metav1.ObjectMeta{Namespace: "team-a", Name: "mm"}}
+	upgrading.Status.Upgrade = &mmv1beta.UpgradeStatus{State: mmv1beta.UpgradeInProgress}
+	c := fake.NewClientBuilder().WithScheme(s).WithObjects(operatorCondition, upgrading).Build()
+	reporter := &UpgradeableReporter{
+		Client:    c,
+		Reader:    c,
+		Log:       blubr.InitLogger(),
+		Name:      key.Name,
+		Namespace: key.Namespace,
+	}
+	upgradeable := func(t *testing.T) *metav1.Condition {
+		updated := &unstructured.Unstructured{}
+		updated.SetGroupVersionKind(OperatorConditionGVK)
+		require.NoError(t, c.Get(context.TODO(), key, updated))
+		conditions, err := specConditions(updated)
+		require.NoError(t, err)
+		condition := meta.FindStatusCondition(conditions, UpgradeableCondition)
+		require.NotNil(t, condition)
+		return condition
+	}
+
+	t.Run("upgrade in progress", func(t *testing.T) {
+		require.NoError(t, reporter.Report(context.TODO()))
+		condition := upgradeable(t)
+		assert.Equal(t, metav1.ConditionFalse, condition.Status)
+		assert.Equal(t, "OperationsInProgress", condition.Reason)
+		assert.Equal(t, "Waiting for the upgrade of Mattermost team-a/mm", condition.Message)
+	})
+
+	t.Run("upgrade completed", func(t *testing.T) {
+		upgrading.Status.Upgrade.State = mmv1beta.UpgradeCompleted
+		require.NoError(t, c.Status().Update(context.TODO(), upgrading))
+
+		require.NoError(t, reporter.Report(context.TODO()))
+		condition := upgradeable(t)
+		assert.Equal(t, metav1.ConditionTrue, condition.Status)
+		assert.Equal(t, "NoOperationsInProgress", condition.Reason)
+	})
+
+	t.Run("OperatorCondition not found", func(t *testing.T) {
+		missing := *reporter
+		missing.Name = "other"
+		assert.Error(t, missing.Report(context.TODO()))
+	})
+}
+
+func TestOperationsInProgress(t *testing.T) {
+	s := runtime.NewScheme()
+	require.NoError(t, clientgoscheme.AddToScheme(s))
+	require.NoError(t, mmv1beta.AddToScheme(s))
+
+	objects := []client.Object{
+		&mmv1beta.Mattermost{
+			ObjectMeta: metav1.ObjectMeta{Namespace: "team-a", Name: "stable"},
+			Status:     mmv1beta.MattermostStatus{Upgrade: &mmv1beta.UpgradeStatus{State: mmv1beta.UpgradeRolledBack}},
+		},
+		&mmv1beta.Mattermost{
+			ObjectMeta: metav1.ObjectMeta{Namespace: "team-a", Name: "migrating"},
+			Status:     mmv1beta.MattermostStatus{FileStoreMigration: &mmv1beta.FileStoreMigrationStatus{State: mmv1beta.FileStoreMigrationSyncing}},
+		},
+		&mmv1beta.Mattermost{
+			ObjectMeta: metav1.ObjectMeta{Namespace: "team-b", Name: "importing"},
+			Status:     mmv1beta.MattermostStatus{Import: &mmv1beta.ImportStatus{State: mmv1beta.ImportImporting}},
+		},
+		&mmv1beta.MattermostUpgrade{
+			ObjectMeta: metav1.ObjectMeta{Namespace: "team-a", Name: "fleet"},
+			Status:     mmv1beta.MattermostUpgradeStatus{State: mmv1beta.MattermostUpgradeUpgrading},
+		},
+		&mmv1beta.MattermostRestore{
+			ObjectMeta: metav1.ObjectMeta{Namespace: "team-a", Name: "restore"},
+			Status:     mmv1beta.MattermostRestoreStatus{State: mmv1beta.RestoreFinished},
+		},
+	}
+	c := fake.NewClientBuilder().WithScheme(s).WithObjects(objects...).Build()
+	reporter := &UpgradeableReporter{Client: c, Reader: c, Log: blubr.InitLogger()}
+
+	operations, err := reporter.operationsInProgress(context.TODO())
+	require.NoError(t, err)
+	assert.ElementsMatch(t, []string{
+		"the file store migration of Mattermost team-a/migrating",
+		"the import of Mattermost team-b/importing",
+		"the fleet upgrade team-a/fleet",
+	}, operations)
+
+	reporter.Namespaces = []string{"team-b"}
+	operations, err = reporter.operationsInProgress(context.TODO())
+	require.NoError(t, err)
+	assert.Equal(t, []string{"the import of Mattermost team-b/importing"}, operations)
+}
@@ -0,0 +1,193 @@
+// Package orphansweep finds the resources labeled with the name of a
+// Mattermost which no longer exists, ie leftovers of failed migrations or of
+// renamed installations, and reports or deletes them.
+package orphansweep
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/go-logr/logr"
+	mmv1beta "github.com/mattermost/mattermost-operator/apis/mattermost/v1beta1"
+	"github.com/mattermost/mattermost-operator/pkg/namespaceselector"
+	"github.com/pkg/errors"
+	"github.com/prometheus/client_golang/prometheus"
+	appsv1 "k8s.io/api/apps/v1"
+	batchv1 "k8s.io/api/batch/v1"
+	corev1 "k8s.io/api/core/v1"
+	networkingv1 "k8s.io/api/networking/v1"
+	k8sErrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/meta"
+	"k8s.io/apimachinery/pkg/types"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/metrics"
+)
+
+var orphanedResourcesGauge = prometheus.NewGaugeVec(
+	prometheus.GaugeOpts{
+		Name: "mattermost_operator_orphaned_resources",
+		Help: "Number of resources labeled with the name of a Mattermost which no longer exists, found by the last sweep.",
+	},
+	[]string{"namespace", "kind"},
+)
+
+func init() {
+	metrics.Registry.MustRegister(orphanedResourcesGauge)
+}
+
+// kind is a type of resource swept.
+type kind struct {
+	name string
+	list func() client.ObjectList
+}
+
+// kinds are the types of the resources created by the operator for the
+// Mattermosts. The data of the operator managed databases and file stores is
+// not swept, their Secrets excepted.
+var kinds = []kind{
+	{"Deployment", func() client.ObjectList { return &appsv1.DeploymentList{} }},
+	{"Service", func() client.ObjectList { return &corev1.ServiceList{} }},
+	{"Ingress", func() client.ObjectList { return &networkingv1.IngressList{} }},
+	{"Job", func() client.ObjectList { return &batchv1.JobList{} }},
+	{"ConfigMap", func() client.ObjectList { return &corev1.ConfigMapList{} }},
+	{"Secret", func() client.ObjectList { return &corev1.SecretList{} }},
+}
+
+// Sweeper periodically looks for the resources carrying the resource label
+// of a Mattermost which no longer exists in their namespace.
+type Sweeper struct {
+	Client client.Client
+	// Reader lists the labeled resources, from the API as the resources of
+	// these types are not all cached.
+	Reader client.Reader
+	Log    logr.Logger
+	// Interval is how often the resources are swept.
+	Interval time.Duration
+	// Delete deletes the orphaned resources, they are only reported
+	// otherwise.
+	Delete bool
+	// Namespaces are the namespaces swept, all of them if empty.
+	Namespaces []string
+	// NamespaceSelector selects the namespaces swept, all of them if nil.
+	NamespaceSelector *namespaceselector.Selector
+}
+
+// NewSweeper returns a Sweeper reporting the orphaned resources every
+// interval.
+func NewSweeper(mgr ctrl.Manager, interval time.Duration) *Sweeper {
+	return &Sweeper{
+		Client:   mgr.GetClient(),
+		Reader:   mgr.GetAPIReader(),
+		Log:      ctrl.Log.WithName("orphansweep"),
+		Interval: interval,
+	}
+}
+
+// Start sweeps the resources every interval until the context is done.
+func (s *Sweeper) Start(ctx context.Context) error {
+	ticker := time.NewTicker(s.Interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-ticker.C:
+			err := s.Sweep(ctx)
+			if err != nil {
+				s.Log.Error(err, "Failed to sweep orphaned resources")
+			}
+		}
+	}
+}
+
+// NeedLeaderElection implements manager.LeaderElectionRunnable. Only the
+// leader deletes the orphaned resources.
+func (s *Sweeper) NeedLeaderElection() bool {
+	return true
+}
+
+// Sweep reports, or deletes, the orphaned resources. Resources owned by an
+// object other than a Mattermost are left to the garbage collector, and the
+// resources annotated as orphaned on purpose are kept.
+func (s *Sweeper) Sweep(ctx context.Context) error {
+	namespaces := s.Namespaces
+	if len(namespaces) == 0 {
+		namespaces = []string{""}
+	}
+
+	counts := map[[2]string]float64{}
+	for _, namespace := range namespaces {
+		for _, kind := range kinds {
+			list := kind.list()
+			err := s.Reader.List(ctx, list, client.InNamespace(namespace), client.HasLabels{mmv1beta.ClusterResourceLabel})
+			if err != nil {
+				return errors.Wrapf(err, "failed to list %ss", kind.name)
+			}
+			items, err := meta.ExtractList(list)
+			if err != nil {
+				return errors.Wrapf(err, "failed to list %ss", kind.name)
+			}
+
+			for _, item := range items {
+				obj, ok := item.(client.Object)
+				if !ok {
+					continue
+				}
+				orphaned, err := s.orphaned(ctx, obj)
+				if err != nil {
+					return err
+				}
+				if !orphaned {
+					continue
+				}
+				counts[[2]string{obj.GetNamespace(), kind.name}]++
+
+				logger := s.Log.WithValues("namespace", obj.GetNamespace(), "kind", kind.name, "name", obj.GetName(), "mattermost", obj.GetLabels()[mmv1beta.ClusterResourceLabel])
+				if !s.Delete {
+					logger.Info("Found orphaned resource")
+					continue
+				}
+				logger.Info("Deleting orphaned resource")
+				err = s.Client.Delete(ctx, obj, client.PropagationPolicy("Background"))
+				if err != nil && !k8sErrors.IsNotFound(err) {
+					return errors.Wrapf(err, "failed to delete %s %s/%s", kind.name, obj.GetNamespace(), obj.GetName())
+				}
+			}
+		}
+	}
+
+	orphanedResourcesGauge.Reset()
+	for key, count := range counts {
+		orphanedResourcesGauge.WithLabelValues(key[0], key[1]).Set(count)
+	}
+	return nil
+}
+
+// orphaned returns true if the resource is labeled with the name of a
+// Mattermost which does not exist in its namespace.
+func (s *Sweeper) orphaned(ctx context.Context, obj client.Object) (bool, error) {
+	if obj.GetAnnotations()[mmv1beta.OrphanedAnnotation] == "true" {
+		return false, nil
+	}
+	for _, owner := range obj.GetOwnerReferences() {
+		if owner.Kind != "Mattermost" {
+			return false, nil
+		}
+	}
+	selected, err := s.NamespaceSelector.Selects(ctx, obj.GetNamespace())
+	if err != nil || !selected {
+		return false, err
+	}
+
+	name := obj.GetLabels()[mmv1beta.ClusterResourceLabel]
+	err = s.Client.Get(ctx, types.NamespacedName{Namespace: obj.GetNamespace(), Name: name}, &mmv1beta.Mattermost{})
+	if err != nil && k8sErrors.IsNotFound(err) {
+		return true, nil
+	} else if err != nil {
+		return false, errors.Wrap(err, fmt.Sprintf("failed to get Mattermost %s/%s", obj.GetNamespace(), name))
+	}
+	return false, nil
+}
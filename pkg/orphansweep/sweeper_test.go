@@ -0,0 +1,91 @@
+package orphansweep
+
+import (
+	"context"
+	"testing"
+
+	blubr "github.com/mattermost/blubr"
+	mmv1beta "github.com/mattermost/mattermost-operator/apis/mattermost/v1beta1"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	k8sErrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	clientgoscheme "k8s.io/client-go/kubernetes/scheme"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+)
+
+func TestSweep(t *testing.T) {
+	s := runtime.NewScheme()
+	require.NoError(t, clientgoscheme.AddToScheme(s))
+	require.NoError(t, mmv1beta.AddToScheme(s))
+
+	labeled := func(mattermost string) metav1.ObjectMeta {
+		return metav1.ObjectMeta{
+			Namespace: "team-a",
+			Labels:    map[string]string{mmv1beta.ClusterResourceLabel: mattermost},
+		}
+	}
+	newObjects := func() []runtime.Object {
+		existing := &appsv1.Deployment{ObjectMeta: labeled("mm")}
+		existing.Name = "mm"
+		orphaned := &appsv1.Deployment{ObjectMeta: labeled("old")}
+		orphaned.Name = "old"
+		orphanedService := &corev1.Service{ObjectMeta: labeled("old")}
+		orphanedService.Name = "old"
+		kept := &corev1.Secret{ObjectMeta: labeled("old")}
+		kept.Name = "old-db"
+		kept.Annotations = map[string]string{mmv1beta.OrphanedAnnotation: "true"}
+		owned := &corev1.ConfigMap{ObjectMeta: labeled("old")}
+		owned.Name = "old-owned"
+		owned.OwnerReferences = []metav1.OwnerReference{{Kind: "MattermostBackup", Name: "backup"}}
+		unlabeled := &corev1.Service{ObjectMeta: metav1.ObjectMeta{Namespace: "team-a", Name: "other"}}
+
+		return []runtime.Object{
+			&mmv1beta.Mattermost{ObjectMeta: metav1.ObjectMeta{Namespace: "team-a", Name: "mm"}},
+			existing, orphaned, orphanedService, kept, owned, unlabeled,
+		}
+	}
+	exists := func(t *testing.T, c client.Client, obj client.Object, name string) bool {
+		err := c.Get(context.TODO(), types.NamespacedName{Namespace: "team-a", Name: name}, obj)
+		if k8sErrors.IsNotFound(err) {
+			return false
+		}
+		require.NoError(t, err)
+		return true
+	}
+
+	t.Run("report", func(t *testing.T) {
+		c := fake.NewFakeClientWithScheme(s, newObjects()...)
+		sweeper := &Sweeper{Client: c, Reader: c, Log: blubr.InitLogger()}
+		require.NoError(t, sweeper.Sweep(context.TODO()))
+
+		assert.True(t, exists(t, c, &appsv1.Deployment{}, "old"))
+		assert.True(t, exists(t, c, &corev1.Service{}, "old"))
+	})
+
+	t.Run("delete", func(t *testing.T) {
+		c := fake.NewFakeClientWithScheme(s, newObjects()...)
+		sweeper := &Sweeper{Client: c, Reader: c, Log: blubr.InitLogger(), Delete: true}
+		require.NoError(t, sweeper.Sweep(context.TODO()))
+
+		assert.False(t, exists(t, c, &appsv1.Deployment{}, "old"))
+		assert.False(t, exists(t, c, &corev1.Service{}, "old"))
+		assert.True(t, exists(t, c, &appsv1.Deployment{}, "mm"))
+		assert.True(t, exists(t, c, &corev1.Secret{}, "old-db"))
+		assert.True(t, exists(t, c, &corev1.ConfigMap{}, "old-owned"))
+		assert.True(t, exists(t, c, &corev1.Service{}, "other"))
+	})
+
+	t.Run("other namespace", func(t *testing.T) {
+		c := fake.NewFakeClientWithScheme(s, newObjects()...)
+		sweeper := &Sweeper{Client: c, Reader: c, Log: blubr.InitLogger(), Delete: true, Namespaces: []string{"team-b"}}
+		require.NoError(t, sweeper.Sweep(context.TODO()))
+
+		assert.True(t, exists(t, c, &appsv1.Deployment{}, "old"))
+	})
+}
@@ -0,0 +1,139 @@
+package registry
+
+import (
+	"context"
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/asn1"
+	"encoding/base64"
+	"encoding/json"
+	"encoding/pem"
+	"fmt"
+	"math/big"
+	"strings"
+
+	"github.com/pkg/errors"
+)
+
+const (
+	cosignSignatureAnnotation = "dev.cosignproject.cosign/signature"
+	cosignPayloadMediaType    = "application/vnd.dev.cosign.simplesigning.v1+json"
+	ociManifestMediaType      = "application/vnd.oci.image.manifest.v1+json"
+)
+
+type ociManifest struct {
+	Layers []ociDescriptor `json:"layers"`
+}
+
+type ociDescriptor struct {
+	MediaType   string            `json:"mediaType"`
+	Digest      string            `json:"digest"`
+	Annotations map[string]string `json:"annotations,omitempty"`
+}
+
+type simpleSigningPayload struct {
+	Critical struct {
+		Image struct {
+			DockerManifestDigest string `json:"docker-manifest-digest"`
+		} `json:"image"`
+		Type string `json:"type"`
+	} `json:"critical"`
+}
+
+type ecdsaSignature struct {
+	R, S *big.Int
+}
+
+// ParsePublicKeys parses the PEM encoded ECDSA public keys used by cosign.
+func ParsePublicKeys(data []byte) ([]crypto.PublicKey, error) {
+	var keys []crypto.PublicKey
+	for {
+		var block *pem.Block
+		block, data = pem.Decode(data)
+		if block == nil {
+			break
+		}
+		key, err := x509.ParsePKIXPublicKey(block.Bytes)
+		if err != nil {
+			return nil, errors.Wrap(err, "failed to parse public key")
+		}
+		if _, ok := key.(*ecdsa.PublicKey); !ok {
+			return nil, fmt.Errorf("unsupported public key type %T, only ECDSA keys are supported", key)
+		}
+		keys = append(keys, key)
+	}
+
+	if len(keys) == 0 {
+		return nil, errors.New("no PEM encoded public key found")
+	}
+	return keys, nil
+}
+
+// VerifyCosignSignature verifies that the image digest is signed by one of
+// the keys with cosign. The signatures are stored by cosign in the
+// sha256-<hash>.sig tag of the repository.
+func (c *Client) VerifyCosignSignature(ctx context.Context, ref Reference, digest string, keys []crypto.PublicKey, keychain Keychain) error {
+	signatureTag := strings.Replace(digest, ":", "-", 1) + ".sig"
+	body, _, err := c.manifest(ctx, ref, signatureTag, []string{ociManifestMediaType}, keychain)
+	if err == ErrNotFound {
+		return fmt.Errorf("no cosign signature found for %s", digest)
+	}
+	if err != nil {
+		return errors.Wrap(err, "failed to get cosign signatures")
+	}
+
+	manifest := ociManifest{}
+	err = json.Unmarshal(body, &manifest)
+	if err != nil {
+		return errors.Wrap(err, "failed to parse cosign signatures")
+	}
+
+	for _, layer := range manifest.Layers {
+		encoded, ok := layer.Annotations[cosignSignatureAnnotation]
+		if !ok || layer.MediaType != cosignPayloadMediaType {
+			continue
+		}
+		signature, err := base64.StdEncoding.DecodeString(encoded)
+		if err != nil {
+			continue
+		}
+
+		payload, err := c.blob(ctx, ref, layer.Digest, keychain)
+		if err != nil {
+			return errors.Wrap(err, "failed to get cosign signature payload")
+		}
+		if verifyPayload(payload, signature, digest, keys) {
+			return nil
+		}
+	}
+
+	return fmt.Errorf("no cosign signature of %s verified by the keys", digest)
+}
+
+// verifyPayload verifies that the payload signs the digest and that the
+// signature is from one of the keys.
+func verifyPayload(payload, signature []byte, digest string, keys []crypto.PublicKey) bool {
+	signed := simpleSigningPayload{}
+	if err := json.Unmarshal(payload, &signed); err != nil {
+		return false
+	}
+	if signed.Critical.Image.DockerManifestDigest != digest {
+		return false
+	}
+
+	parsed := ecdsaSignature{}
+	if _, err := asn1.Unmarshal(signature, &parsed); err != nil || parsed.R == nil || parsed.S == nil {
+		return false
+	}
+
+	hash := sha256.Sum256(payload)
+	for _, key := range keys {
+		ecdsaKey, ok := key.(*ecdsa.PublicKey)
+		if ok && ecdsa.Verify(ecdsaKey, hash[:], parsed.R, parsed.S) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,212 @@
+package registry
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"regexp"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+const (
+	// ecrTarget is the target of the ECR GetAuthorizationToken action.
+	ecrTarget = "AmazonEC2ContainerRegistry_V20150921.GetAuthorizationToken"
+
+	awsDateFormat = "20060102T150405Z"
+)
+
+// ecrEndpoint returns the ECR API endpoint of the region.
+var ecrEndpoint = func(region string) string {
+	endpoint := fmt.Sprintf("https://api.ecr.%s.amazonaws.com/", region)
+	if strings.HasPrefix(region, "cn-") {
+		endpoint = fmt.Sprintf("https://api.ecr.%s.amazonaws.com.cn/", region)
+	}
+	return endpoint
+}
+
+// ecrRegistryPattern matches the hosts of ECR private registries, ie
+// 123456789012.dkr.ecr.us-east-1.amazonaws.com.
+var ecrRegistryPattern = regexp.MustCompile(`^[0-9]{12}\.dkr\.ecr(-fips)?\.([a-z0-9-]+)\.amazonaws\.com(\.cn)?$`)
+
+// AWSCredentials are the AWS credentials requesting ECR authorization
+// tokens.
+type AWSCredentials struct {
+	AccessKeyID     string
+	SecretAccessKey string
+	// SessionToken is set for temporary credentials.
+	SessionToken string
+}
+
+// ECRAuthorization is the authorization to pull images from the ECR
+// registries of an AWS account.
+type ECRAuthorization struct {
+	// Registry is the host of the ECR registry.
+	Registry  string
+	Username  string
+	Password  string
+	ExpiresAt time.Time
+}
+
+// ECRRegion returns the region of the ECR registry, false if the registry is
+// not an ECR private registry.
+func ECRRegion(registry string) (string, bool) {
+	match := ecrRegistryPattern.FindStringSubmatch(registry)
+	if match == nil {
+		return "", false
+	}
+	return match[2], true
+}
+
+// ECRAuthorization requests an authorization token of the ECR registry of
+// the AWS account of the credentials in the region.
+func (c *Client) ECRAuthorization(ctx context.Context, region string, credentials AWSCredentials) (*ECRAuthorization, error) {
+	if credentials.AccessKeyID == "" || credentials.SecretAccessKey == "" {
+		return nil, errors.New("AWS access key ID and secret access key are required")
+	}
+
+	body := []byte("{}")
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, ecrEndpoint(region), bytes.NewReader(body))
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to create ECR request")
+	}
+	req.Header.Set("Content-Type", "application/x-amz-json-1.1")
+	req.Header.Set("X-Amz-Target", ecrTarget)
+	signV4(req, body, credentials, region, "ecr", time.Now())
+
+	resp, err := c.HTTPClient.Do(req)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to send ECR request")
+	}
+	defer resp.Body.Close()
+
+	data, err := ioutil.ReadAll(io.LimitReader(resp.Body, maxResponseSize))
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to read ECR response")
+	}
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("ECR responded with status code %d: %s", resp.StatusCode, strings.TrimSpace(string(data)))
+	}
+
+	tokenResponse := struct {
+		AuthorizationData []struct {
+			AuthorizationToken string  `json:"authorizationToken"`
+			ExpiresAt          float64 `json:"expiresAt"`
+			ProxyEndpoint      string  `json:"proxyEndpoint"`
+		} `json:"authorizationData"`
+	}{}
+	err = json.Unmarshal(data, &tokenResponse)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to decode ECR authorization token")
+	}
+	if len(tokenResponse.AuthorizationData) == 0 {
+		return nil, errors.New("ECR returned no authorization token")
+	}
+
+	authorizationData := tokenResponse.AuthorizationData[0]
+	decoded, err := base64.StdEncoding.DecodeString(authorizationData.AuthorizationToken)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to decode ECR authorization token")
+	}
+	userPassword := strings.SplitN(string(decoded), ":", 2)
+	if len(userPassword) != 2 {
+		return nil, errors.New("invalid ECR authorization token")
+	}
+
+	return &ECRAuthorization{
+		Registry:  normalizeRegistry(authorizationData.ProxyEndpoint),
+		Username:  userPassword[0],
+		Password:  userPassword[1],
+		ExpiresAt: time.Unix(int64(authorizationData.ExpiresAt), 0),
+	}, nil
+}
+
+// DockerConfigJSON returns the Docker config, in the .dockerconfigjson
+// format, with the credentials of the ECR registry.
+func (a *ECRAuthorization) DockerConfigJSON() ([]byte, error) {
+	auth := base64.StdEncoding.EncodeToString([]byte(a.Username + ":" + a.Password))
+	return json.Marshal(map[string]map[string]dockerConfigEntry{
+		"auths": {
+			a.Registry: {Username: a.Username, Password: a.Password, Auth: auth},
+		},
+	})
+}
+
+// signV4 signs the request with the AWS Signature Version 4. All headers
+// set on the request are signed.
+func signV4(req *http.Request, body []byte, credentials AWSCredentials, region, service string, now time.Time) {
+	amzDate := now.UTC().Format(awsDateFormat)
+	date := amzDate[:8]
+
+	req.Header.Set("X-Amz-Date", amzDate)
+	if credentials.SessionToken != "" {
+		req.Header.Set("X-Amz-Security-Token", credentials.SessionToken)
+	}
+
+	headers := map[string]string{"host": req.URL.Host}
+	for name, values := range req.Header {
+		headers[strings.ToLower(name)] = strings.Join(values, ",")
+	}
+	names := make([]string, 0, len(headers))
+	for name := range headers {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	var canonicalHeaders strings.Builder
+	for _, name := range names {
+		fmt.Fprintf(&canonicalHeaders, "%s:%s\n", name, strings.TrimSpace(headers[name]))
+	}
+	signedHeaders := strings.Join(names, ";")
+
+	path := req.URL.EscapedPath()
+	if path == "" {
+		path = "/"
+	}
+	canonicalRequest := strings.Join([]string{
+		req.Method,
+		path,
+		req.URL.Query().Encode(),
+		canonicalHeaders.String(),
+		signedHeaders,
+		hexSHA256(body),
+	}, "\n")
+
+	scope := fmt.Sprintf("%s/%s/%s/aws4_request", date, region, service)
+	stringToSign := strings.Join([]string{
+		"AWS4-HMAC-SHA256",
+		amzDate,
+		scope,
+		hexSHA256([]byte(canonicalRequest)),
+	}, "\n")
+
+	key := hmacSHA256([]byte("AWS4"+credentials.SecretAccessKey), date)
+	key = hmacSHA256(key, region)
+	key = hmacSHA256(key, service)
+	key = hmacSHA256(key, "aws4_request")
+
+	req.Header.Set("Authorization", fmt.Sprintf(
+		"AWS4-HMAC-SHA256 Credential=%s/%s, SignedHeaders=%s, Signature=%x",
+		credentials.AccessKeyID, scope, signedHeaders, hmacSHA256(key, stringToSign),
+	))
+}
+
+func hmacSHA256(key []byte, data string) []byte {
+	mac := hmac.New(sha256.New, key)
+	mac.Write([]byte(data))
+	return mac.Sum(nil)
+}
+
+func hexSHA256(data []byte) string {
+	return fmt.Sprintf("%x", sha256.Sum256(data))
+}
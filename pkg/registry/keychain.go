@@ -0,0 +1,85 @@
+package registry
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"strings"
+
+	"github.com/pkg/errors"
+)
+
+// DockerConfigKeychain resolves the credentials of registries from Docker
+// configs, as stored in image pull Secrets.
+type DockerConfigKeychain struct {
+	credentials map[string]Credentials
+}
+
+type dockerConfigEntry struct {
+	Username string `json:"username,omitempty"`
+	Password string `json:"password,omitempty"`
+	Auth     string `json:"auth,omitempty"`
+}
+
+// NewDockerConfigKeychain returns a keychain with the credentials of the
+// Docker configs, in the .dockerconfigjson or legacy .dockercfg format. The
+// credentials of the first config win.
+func NewDockerConfigKeychain(configs ...[]byte) (*DockerConfigKeychain, error) {
+	keychain := &DockerConfigKeychain{credentials: map[string]Credentials{}}
+
+	for _, config := range configs {
+		auths := map[string]dockerConfigEntry{}
+		dockerConfig := struct {
+			Auths map[string]dockerConfigEntry `json:"auths"`
+		}{}
+		err := json.Unmarshal(config, &dockerConfig)
+		if err == nil && dockerConfig.Auths != nil {
+			auths = dockerConfig.Auths
+		} else if err = json.Unmarshal(config, &auths); err != nil {
+			return nil, errors.Wrap(err, "failed to parse Docker config")
+		}
+
+		for server, entry := range auths {
+			credentials := Credentials{Username: entry.Username, Password: entry.Password}
+			if entry.Auth != "" {
+				decoded, err := base64.StdEncoding.DecodeString(entry.Auth)
+				if err != nil {
+					return nil, errors.Wrapf(err, "failed to decode Docker config auth of %s", server)
+				}
+				userPassword := strings.SplitN(string(decoded), ":", 2)
+				if len(userPassword) != 2 {
+					return nil, errors.Errorf("invalid Docker config auth of %s", server)
+				}
+				credentials = Credentials{Username: userPassword[0], Password: userPassword[1]}
+			}
+
+			host := normalizeRegistry(server)
+			if _, ok := keychain.credentials[host]; !ok {
+				keychain.credentials[host] = credentials
+			}
+		}
+	}
+
+	return keychain, nil
+}
+
+// Resolve returns the credentials of the registry.
+func (k *DockerConfigKeychain) Resolve(registry string) *Credentials {
+	credentials, ok := k.credentials[normalizeRegistry(registry)]
+	if !ok {
+		return nil
+	}
+	return &credentials
+}
+
+// normalizeRegistry returns the host of the registry server of Docker
+// configs, ie https://index.docker.io/v1/.
+func normalizeRegistry(server string) string {
+	host := strings.TrimPrefix(strings.TrimPrefix(server, "https://"), "http://")
+	host = strings.SplitN(host, "/", 2)[0]
+
+	switch host {
+	case "docker.io", dockerHubAPIHost:
+		return DockerHubRegistry
+	}
+	return host
+}
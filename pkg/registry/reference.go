@@ -0,0 +1,80 @@
+package registry
+
+import (
+	"fmt"
+	"strings"
+)
+
+const (
+	// DockerHubRegistry is the registry of images without a registry host.
+	DockerHubRegistry = "index.docker.io"
+
+	dockerHubAPIHost = "registry-1.docker.io"
+)
+
+// Reference is a reference to an image in a registry.
+type Reference struct {
+	// Registry is the host of the registry.
+	Registry string
+	// Repository is the path of the image in the registry.
+	Repository string
+	// Tag is the tag of the image, empty if referenced by digest.
+	Tag string
+	// Digest is the digest of the image, empty if referenced by tag.
+	Digest string
+}
+
+// ParseReference parses an image name, ie mattermost/mattermost-team-edition:5.37.1
+// or registry.example.com/mattermost@sha256:dd15a5.... Images without a tag
+// or digest reference the latest tag.
+func ParseReference(image string) (Reference, error) {
+	ref := Reference{}
+
+	name := image
+	if i := strings.LastIndex(name, "@"); i >= 0 {
+		ref.Digest = name[i+1:]
+		name = name[:i]
+		if !strings.HasPrefix(ref.Digest, "sha256:") {
+			return Reference{}, fmt.Errorf("image %s has an unsupported digest", image)
+		}
+	} else if i := strings.LastIndex(name, ":"); i > strings.LastIndex(name, "/") {
+		ref.Tag = name[i+1:]
+		name = name[:i]
+	} else {
+		ref.Tag = "latest"
+	}
+
+	parts := strings.SplitN(name, "/", 2)
+	if len(parts) == 2 && (strings.ContainsAny(parts[0], ".:") || parts[0] == "localhost") {
+		ref.Registry = parts[0]
+		ref.Repository = parts[1]
+	} else {
+		ref.Registry = DockerHubRegistry
+		ref.Repository = name
+		if !strings.Contains(name, "/") {
+			ref.Repository = "library/" + name
+		}
+	}
+
+	if ref.Repository == "" || ref.Repository != strings.ToLower(ref.Repository) {
+		return Reference{}, fmt.Errorf("image %s has an invalid repository", image)
+	}
+
+	return ref, nil
+}
+
+// apiHost returns the host serving the registry API.
+func (r Reference) apiHost() string {
+	if r.Registry == DockerHubRegistry {
+		return dockerHubAPIHost
+	}
+	return r.Registry
+}
+
+// reference returns the tag or the digest referencing the image.
+func (r Reference) reference() string {
+	if r.Digest != "" {
+		return r.Digest
+	}
+	return r.Tag
+}
@@ -0,0 +1,297 @@
+package registry
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+// manifestMediaTypes are the manifests accepted when resolving a tag, image
+// indexes first so that multi-architecture images resolve to the index.
+var manifestMediaTypes = []string{
+	"application/vnd.docker.distribution.manifest.list.v2+json",
+	"application/vnd.oci.image.index.v1+json",
+	"application/vnd.docker.distribution.manifest.v2+json",
+	"application/vnd.oci.image.manifest.v1+json",
+}
+
+// maxResponseSize limits the size of manifests and blobs read from the
+// registry.
+const maxResponseSize = 4 << 20
+
+// Credentials authenticate to a registry.
+type Credentials struct {
+	Username string
+	Password string
+}
+
+// Keychain returns the credentials of the registry, nil for anonymous access.
+type Keychain interface {
+	Resolve(registry string) *Credentials
+}
+
+// Client queries images in registries implementing the Docker Registry HTTP
+// API V2.
+type Client struct {
+	HTTPClient *http.Client
+}
+
+// NewClient returns a registry client.
+func NewClient() *Client {
+	return &Client{
+		HTTPClient: &http.Client{Timeout: 30 * time.Second},
+	}
+}
+
+// Digest resolves the image to the digest of its manifest. Images referenced
+// by digest are returned as is.
+func (c *Client) Digest(ctx context.Context, ref Reference, keychain Keychain) (string, error) {
+	if ref.Digest != "" {
+		return ref.Digest, nil
+	}
+
+	resp, err := c.do(ctx, http.MethodHead, ref, manifestPath(ref, ref.Tag), manifestMediaTypes, keychain)
+	if err != nil {
+		return "", err
+	}
+	resp.Body.Close()
+
+	if digest := resp.Header.Get("Docker-Content-Digest"); digest != "" {
+		return digest, nil
+	}
+
+	// Registries are not required to return the digest on HEAD requests.
+	_, digest, err := c.manifest(ctx, ref, ref.Tag, manifestMediaTypes, keychain)
+	return digest, err
+}
+
+// manifest returns the manifest of the tag or digest and its digest.
+func (c *Client) manifest(ctx context.Context, ref Reference, reference string, mediaTypes []string, keychain Keychain) ([]byte, string, error) {
+	resp, err := c.do(ctx, http.MethodGet, ref, manifestPath(ref, reference), mediaTypes, keychain)
+	if err != nil {
+		return nil, "", err
+	}
+	defer resp.Body.Close()
+
+	body, err := ioutil.ReadAll(io.LimitReader(resp.Body, maxResponseSize))
+	if err != nil {
+		return nil, "", errors.Wrap(err, "failed to read manifest")
+	}
+
+	return body, fmt.Sprintf("sha256:%x", sha256.Sum256(body)), nil
+}
+
+// blob returns the blob with the digest, verifying its content.
+func (c *Client) blob(ctx context.Context, ref Reference, digest string, keychain Keychain) ([]byte, error) {
+	resp, err := c.do(ctx, http.MethodGet, ref, fmt.Sprintf("/v2/%s/blobs/%s", ref.Repository, digest), nil, keychain)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	body, err := ioutil.ReadAll(io.LimitReader(resp.Body, maxResponseSize))
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to read blob")
+	}
+	if fmt.Sprintf("sha256:%x", sha256.Sum256(body)) != digest {
+		return nil, fmt.Errorf("blob %s does not match its digest", digest)
+	}
+
+	return body, nil
+}
+
+// do sends the request to the registry, authenticating as requested by the
+// registry. Not found errors are returned as ErrNotFound.
+func (c *Client) do(ctx context.Context, method string, ref Reference, path string, accept []string, keychain Keychain) (*http.Response, error) {
+	endpoint := fmt.Sprintf("https://%s%s", ref.apiHost(), path)
+
+	var credentials *Credentials
+	if keychain != nil {
+		credentials = keychain.Resolve(ref.Registry)
+	}
+
+	resp, err := c.send(ctx, method, endpoint, accept, "")
+	if err != nil {
+		return nil, err
+	}
+
+	if resp.StatusCode == http.StatusUnauthorized {
+		challenge := resp.Header.Get("WWW-Authenticate")
+		resp.Body.Close()
+
+		authorization, err := c.authorize(ctx, challenge, ref, credentials)
+		if err != nil {
+			return nil, err
+		}
+		resp, err = c.send(ctx, method, endpoint, accept, authorization)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	if resp.StatusCode == http.StatusNotFound {
+		resp.Body.Close()
+		return nil, ErrNotFound
+	}
+	if resp.StatusCode != http.StatusOK {
+		resp.Body.Close()
+		return nil, fmt.Errorf("registry %s responded with status code %d to %s %s", ref.Registry, resp.StatusCode, method, path)
+	}
+
+	return resp, nil
+}
+
+func (c *Client) send(ctx context.Context, method, endpoint string, accept []string, authorization string) (*http.Response, error) {
+	req, err := http.NewRequestWithContext(ctx, method, endpoint, nil)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to create registry request")
+	}
+	if len(accept) > 0 {
+		req.Header.Set("Accept", strings.Join(accept, ", "))
+	}
+	if authorization != "" {
+		req.Header.Set("Authorization", authorization)
+	}
+
+	resp, err := c.HTTPClient.Do(req)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to send registry request")
+	}
+	return resp, nil
+}
+
+// authorize returns the Authorization header answering the challenge of the
+// registry, fetching a bearer token if requested.
+func (c *Client) authorize(ctx context.Context, challenge string, ref Reference, credentials *Credentials) (string, error) {
+	scheme, params := parseChallenge(challenge)
+
+	switch strings.ToLower(scheme) {
+	case "basic":
+		if credentials == nil {
+			return "", fmt.Errorf("registry %s requires credentials", ref.Registry)
+		}
+		return "Basic " + basicAuth(credentials), nil
+	case "bearer":
+		token, err := c.token(ctx, params, ref, credentials)
+		if err != nil {
+			return "", err
+		}
+		return "Bearer " + token, nil
+	default:
+		return "", fmt.Errorf("registry %s requested unsupported authentication %q", ref.Registry, scheme)
+	}
+}
+
+// token fetches a bearer token from the token server of the registry.
+func (c *Client) token(ctx context.Context, params map[string]string, ref Reference, credentials *Credentials) (string, error) {
+	realm, err := url.Parse(params["realm"])
+	if err != nil || realm.Host == "" {
+		return "", fmt.Errorf("registry %s returned an invalid token realm", ref.Registry)
+	}
+
+	query := realm.Query()
+	if service := params["service"]; service != "" {
+		query.Set("service", service)
+	}
+	scope := params["scope"]
+	if scope == "" {
+		scope = fmt.Sprintf("repository:%s:pull", ref.Repository)
+	}
+	query.Set("scope", scope)
+	realm.RawQuery = query.Encode()
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, realm.String(), nil)
+	if err != nil {
+		return "", errors.Wrap(err, "failed to create token request")
+	}
+	if credentials != nil {
+		req.Header.Set("Authorization", "Basic "+basicAuth(credentials))
+	}
+
+	resp, err := c.HTTPClient.Do(req)
+	if err != nil {
+		return "", errors.Wrap(err, "failed to fetch registry token")
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return "", fmt.Errorf("token server of registry %s responded with status code %d", ref.Registry, resp.StatusCode)
+	}
+
+	tokenResponse := struct {
+		Token       string `json:"token"`
+		AccessToken string `json:"access_token"`
+	}{}
+	err = json.NewDecoder(io.LimitReader(resp.Body, maxResponseSize)).Decode(&tokenResponse)
+	if err != nil {
+		return "", errors.Wrap(err, "failed to decode registry token")
+	}
+
+	if tokenResponse.Token != "" {
+		return tokenResponse.Token, nil
+	}
+	if tokenResponse.AccessToken != "" {
+		return tokenResponse.AccessToken, nil
+	}
+	return "", fmt.Errorf("token server of registry %s returned no token", ref.Registry)
+}
+
+// parseChallenge parses a WWW-Authenticate header, ie
+// Bearer realm="https://auth.docker.io/token",service="registry.docker.io".
+func parseChallenge(challenge string) (string, map[string]string) {
+	params := map[string]string{}
+
+	parts := strings.SplitN(strings.TrimSpace(challenge), " ", 2)
+	if len(parts) < 2 {
+		return parts[0], params
+	}
+
+	for _, param := range splitParams(parts[1]) {
+		kv := strings.SplitN(param, "=", 2)
+		if len(kv) != 2 {
+			continue
+		}
+		params[strings.ToLower(strings.TrimSpace(kv[0]))] = strings.Trim(strings.TrimSpace(kv[1]), `"`)
+	}
+
+	return parts[0], params
+}
+
+// splitParams splits the challenge parameters on commas outside of quotes,
+// as scopes may contain commas.
+func splitParams(value string) []string {
+	var params []string
+	quoted := false
+	start := 0
+	for i, r := range value {
+		switch {
+		case r == '"':
+			quoted = !quoted
+		case r == ',' && !quoted:
+			params = append(params, value[start:i])
+			start = i + 1
+		}
+	}
+	return append(params, value[start:])
+}
+
+func basicAuth(credentials *Credentials) string {
+	return base64.StdEncoding.EncodeToString([]byte(credentials.Username + ":" + credentials.Password))
+}
+
+func manifestPath(ref Reference, reference string) string {
+	return fmt.Sprintf("/v2/%s/manifests/%s", ref.Repository, reference)
+}
+
+// ErrNotFound is returned if the image is not found in the registry.
+var ErrNotFound = errors.New("not found in the registry")
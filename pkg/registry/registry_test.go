@@ -0,0 +1,241 @@
+package registry
+
+import (
+	"context"
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/asn1"
+	"encoding/base64"
+	"encoding/json"
+	"encoding/pem"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseReference(t *testing.T) {
+	for _, tc := range []struct {
+		image    string
+		expected Reference
+	}{
+		{
+			image:    "mattermost/mattermost-enterprise-edition:5.37.1",
+			expected: Reference{Registry: DockerHubRegistry, Repository: "mattermost/mattermost-enterprise-edition", Tag: "5.37.1"},
+		},
+		{
+			image:    "busybox",
+			expected: Reference{Registry: DockerHubRegistry, Repository: "library/busybox", Tag: "latest"},
+		},
+		{
+			image:    "registry.example.com:5000/team/mattermost@sha256:dd15a51ac7dafd213744d1ef23394e7532f71a90f477c969b94600e46da5a0cf",
+			expected: Reference{Registry: "registry.example.com:5000", Repository: "team/mattermost", Digest: "sha256:dd15a51ac7dafd213744d1ef23394e7532f71a90f477c969b94600e46da5a0cf"},
+		},
+		{
+			image:    "localhost/mattermost:5.37.1",
+			expected: Reference{Registry: "localhost", Repository: "mattermost", Tag: "5.37.1"},
+		},
+	} {
+		t.Run(tc.image, func(t *testing.T) {
+			ref, err := ParseReference(tc.image)
+			require.NoError(t, err)
+			assert.Equal(t, tc.expected, ref)
+		})
+	}
+
+	_, err := ParseReference("Mattermost:5.37.1")
+	assert.Error(t, err)
+}
+
+func TestDockerConfigKeychain(t *testing.T) {
+	keychain, err := NewDockerConfigKeychain(
+		[]byte(`{"auths": {"https://index.docker.io/v1/": {"auth": "dXNlcjpwYXNzd29yZA=="}}}`),
+		[]byte(`{"registry.example.com": {"username": "robot", "password": "secret"}}`),
+	)
+	require.NoError(t, err)
+
+	assert.Equal(t, &Credentials{Username: "user", Password: "password"}, keychain.Resolve(DockerHubRegistry))
+	assert.Equal(t, &Credentials{Username: "robot", Password: "secret"}, keychain.Resolve("registry.example.com"))
+	assert.Nil(t, keychain.Resolve("quay.io"))
+}
+
+// fakeRegistry serves a signed image behind token authentication.
+type fakeRegistry struct {
+	manifests map[string][]byte
+	blobs     map[string][]byte
+}
+
+func (f *fakeRegistry) handler(t *testing.T) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/token" {
+			username, password, ok := r.BasicAuth()
+			if !ok || username != "user" || password != "password" {
+				w.WriteHeader(http.StatusUnauthorized)
+				return
+			}
+			assert.Equal(t, "repository:mattermost/mm:pull", r.URL.Query().Get("scope"))
+			_, _ = w.Write([]byte(`{"token": "test-token"}`))
+			return
+		}
+
+		if r.Header.Get("Authorization") != "Bearer test-token" {
+			w.Header().Set("WWW-Authenticate", fmt.Sprintf(`Bearer realm="https://%s/token",service="test",scope="repository:mattermost/mm:pull"`, r.Host))
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+
+		if reference := strings.TrimPrefix(r.URL.Path, "/v2/mattermost/mm/manifests/"); reference != r.URL.Path {
+			manifest, ok := f.manifests[reference]
+			if !ok {
+				w.WriteHeader(http.StatusNotFound)
+				return
+			}
+			w.Header().Set("Docker-Content-Digest", digestOf(manifest))
+			if r.Method == http.MethodGet {
+				_, _ = w.Write(manifest)
+			}
+			return
+		}
+
+		if blob, ok := f.blobs[strings.TrimPrefix(r.URL.Path, "/v2/mattermost/mm/blobs/")]; ok {
+			_, _ = w.Write(blob)
+			return
+		}
+		w.WriteHeader(http.StatusNotFound)
+	}
+}
+
+func digestOf(data []byte) string {
+	return fmt.Sprintf("sha256:%x", sha256.Sum256(data))
+}
+
+func signImage(t *testing.T, registry *fakeRegistry, digest string, key *ecdsa.PrivateKey) {
+	payload := []byte(fmt.Sprintf(`{"critical":{"identity":{"docker-reference":"mattermost/mm"},"image":{"docker-manifest-digest":%q},"type":"cosign container image signature"},"optional":null}`, digest))
+	hash := sha256.Sum256(payload)
+	r, s, err := ecdsa.Sign(rand.Reader, key, hash[:])
+	require.NoError(t, err)
+	signature, err := asn1.Marshal(ecdsaSignature{R: r, S: s})
+	require.NoError(t, err)
+
+	manifest, err := json.Marshal(ociManifest{Layers: []ociDescriptor{{
+		MediaType:   cosignPayloadMediaType,
+		Digest:      digestOf(payload),
+		Annotations: map[string]string{cosignSignatureAnnotation: base64.StdEncoding.EncodeToString(signature)},
+	}}})
+	require.NoError(t, err)
+
+	registry.blobs[digestOf(payload)] = payload
+	registry.manifests[strings.Replace(digest, ":", "-", 1)+".sig"] = manifest
+}
+
+func publicKeys(t *testing.T, key *ecdsa.PrivateKey) []crypto.PublicKey {
+	der, err := x509.MarshalPKIXPublicKey(&key.PublicKey)
+	require.NoError(t, err)
+	keys, err := ParsePublicKeys(pem.EncodeToMemory(&pem.Block{Type: "PUBLIC KEY", Bytes: der}))
+	require.NoError(t, err)
+	return keys
+}
+
+func TestClient(t *testing.T) {
+	imageManifest := []byte(`{"schemaVersion": 2, "mediaType": "application/vnd.docker.distribution.manifest.v2+json"}`)
+	registry := &fakeRegistry{
+		manifests: map[string][]byte{"5.37.1": imageManifest},
+		blobs:     map[string][]byte{},
+	}
+	server := httptest.NewTLSServer(registry.handler(t))
+	defer server.Close()
+
+	client := &Client{HTTPClient: server.Client()}
+	keychain := &DockerConfigKeychain{credentials: map[string]Credentials{
+		strings.TrimPrefix(server.URL, "https://"): {Username: "user", Password: "password"},
+	}}
+	ref, err := ParseReference(strings.TrimPrefix(server.URL, "https://") + "/mattermost/mm:5.37.1")
+	require.NoError(t, err)
+
+	digest, err := client.Digest(context.Background(), ref, keychain)
+	require.NoError(t, err)
+	assert.Equal(t, digestOf(imageManifest), digest)
+
+	signingKey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+	otherKey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+
+	err = client.VerifyCosignSignature(context.Background(), ref, digest, publicKeys(t, signingKey), keychain)
+	assert.Error(t, err, "image not signed yet")
+
+	signImage(t, registry, digest, signingKey)
+
+	err = client.VerifyCosignSignature(context.Background(), ref, digest, publicKeys(t, signingKey), keychain)
+	assert.NoError(t, err)
+
+	err = client.VerifyCosignSignature(context.Background(), ref, digest, publicKeys(t, otherKey), keychain)
+	assert.Error(t, err)
+
+	_, err = client.Digest(context.Background(), ref, nil)
+	assert.Error(t, err, "credentials required")
+}
+
+func TestSignV4(t *testing.T) {
+	// get-vanilla of the AWS Signature Version 4 test suite.
+	req, err := http.NewRequest(http.MethodGet, "https://example.amazonaws.com/", nil)
+	require.NoError(t, err)
+
+	credentials := AWSCredentials{AccessKeyID: "AKIDEXAMPLE", SecretAccessKey: "wJalrXUtnFEMI/K7MDENG+bPxRfiCYEXAMPLEKEY"}
+	signV4(req, nil, credentials, "us-east-1", "service", time.Date(2015, 8, 30, 12, 36, 0, 0, time.UTC))
+
+	assert.Equal(t, "AWS4-HMAC-SHA256 Credential=AKIDEXAMPLE/20150830/us-east-1/service/aws4_request, "+
+		"SignedHeaders=host;x-amz-date, Signature=5fa00fa31553b73ebf1942676e86291e8372ff2a2260956d9b8aae1d763fbf31",
+		req.Header.Get("Authorization"))
+}
+
+func TestECRAuthorization(t *testing.T) {
+	region, ok := ECRRegion("123456789012.dkr.ecr.eu-west-1.amazonaws.com")
+	assert.True(t, ok)
+	assert.Equal(t, "eu-west-1", region)
+	_, ok = ECRRegion("registry.example.com")
+	assert.False(t, ok)
+
+	server := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, ecrTarget, r.Header.Get("X-Amz-Target"))
+		assert.Equal(t, "session", r.Header.Get("X-Amz-Security-Token"))
+		assert.Contains(t, r.Header.Get("Authorization"), "Credential=AKID/")
+		assert.Contains(t, r.Header.Get("Authorization"), "/eu-west-1/ecr/aws4_request")
+
+		fmt.Fprintf(w, `{"authorizationData": [{"authorizationToken": "%s", "expiresAt": 1.6e9, "proxyEndpoint": "https://123456789012.dkr.ecr.eu-west-1.amazonaws.com"}]}`,
+			base64.StdEncoding.EncodeToString([]byte("AWS:token")))
+	}))
+	defer server.Close()
+
+	defaultEndpoint := ecrEndpoint
+	ecrEndpoint = func(string) string { return server.URL }
+	defer func() { ecrEndpoint = defaultEndpoint }()
+
+	client := &Client{HTTPClient: server.Client()}
+	authorization, err := client.ECRAuthorization(context.Background(), "eu-west-1", AWSCredentials{AccessKeyID: "AKID", SecretAccessKey: "secret", SessionToken: "session"})
+	require.NoError(t, err)
+	assert.Equal(t, &ECRAuthorization{
+		Registry:  "123456789012.dkr.ecr.eu-west-1.amazonaws.com",
+		Username:  "AWS",
+		Password:  "token",
+		ExpiresAt: time.Unix(1600000000, 0),
+	}, authorization)
+
+	dockerConfig, err := authorization.DockerConfigJSON()
+	require.NoError(t, err)
+	keychain, err := NewDockerConfigKeychain(dockerConfig)
+	require.NoError(t, err)
+	assert.Equal(t, &Credentials{Username: "AWS", Password: "token"}, keychain.Resolve("123456789012.dkr.ecr.eu-west-1.amazonaws.com"))
+
+	_, err = client.ECRAuthorization(context.Background(), "eu-west-1", AWSCredentials{})
+	assert.Error(t, err)
+}
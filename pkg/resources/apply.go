@@ -0,0 +1,163 @@
+package resources
+
+import (
+	"context"
+	"encoding/json"
+
+	"github.com/go-logr/logr"
+	"github.com/pkg/errors"
+	k8sErrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/apiutil"
+)
+
+// FieldManager is the manager of the fields applied by the operator.
+const FieldManager = "mattermost-operator"
+
+// sharedFieldPaths are the fields of the resources that the operator leaves
+// to the other managers taking them over, ie the replicas of a deployment
+// scaled by a HorizontalPodAutoscaler. The annotations, except the desired
+// state hash, are shared too.
+var sharedFieldPaths = [][]string{
+	{"spec", "replicas"},
+}
+
+// apply applies the desired resource with a server-side apply, the operator
+// then only owns the fields it sets. The shared fields taken over by other
+// managers are not applied.
+func (r *ResourceHelper) apply(desired Object, reqLogger logr.Logger) error {
+	gvk, err := apiutil.GVKForObject(desired, r.scheme)
+	if err != nil {
+		return errors.Wrap(err, "failed to get the kind of the resource")
+	}
+
+	current, ok := desired.DeepCopyObject().(Object)
+	if !ok {
+		return errors.New("failed to copy the resource")
+	}
+	err = r.client.Get(context.TODO(), client.ObjectKeyFromObject(desired), current)
+	if err != nil && !k8sErrors.IsNotFound(err) {
+		return errors.Wrap(err, "failed to get the current resource")
+	}
+	applied := desired
+	if err == nil {
+		applied, err = yieldSharedFields(current, desired, gvk)
+		if err != nil {
+			return err
+		}
+	}
+	return r.patch(applied, gvk, reqLogger)
+}
+
+// patch applies the resource with a server-side apply. The fields conflicting
+// with the ones of other managers are fields the operator must own, the shared
+// fields being yielded before: the conflict is logged and the fields are taken
+// over.
+//
+// A copy of the resource is applied, the resource is not changed by the
+// response of the API server.
+func (r *ResourceHelper) patch(resource Object, gvk schema.GroupVersionKind, reqLogger logr.Logger) error {
+	applied, ok := resource.DeepCopyObject().(Object)
+	if !ok {
+		return errors.New("failed to copy the resource")
+	}
+	applied.GetObjectKind().SetGroupVersionKind(gvk)
+	applied.SetResourceVersion("")
+	applied.SetManagedFields(nil)
+
+	err := r.client.Patch(context.TODO(), applied, client.Apply, client.FieldOwner(FieldManager))
+	if k8sErrors.IsConflict(err) {
+		reqLogger.Info("Taking over the fields of the resource managed by others", "name", resource.GetName(), "kind", gvk.Kind, "namespace", resource.GetNamespace(), "conflict", err.Error())
+		err = r.client.Patch(context.TODO(), applied, client.Apply, client.FieldOwner(FieldManager), client.ForceOwnership)
+	}
+	return err
+}
+
+// yieldSharedFields returns the desired resource without the shared fields
+// and annotations owned by other managers but not by the operator, which then
+// keep their current value.
+func yieldSharedFields(current, desired Object, gvk schema.GroupVersionKind) (Object, error) {
+	owners, err := parseManagedFields(current)
+	if err != nil {
+		return nil, err
+	}
+	paths := append([][]string{}, sharedFieldPaths...)
+	for key := range desired.GetAnnotations() {
+		if key != DesiredStateHashAnnotation {
+			paths = append(paths, []string{"metadata", "annotations", key})
+		}
+	}
+	var yielded [][]string
+	for _, path := range paths {
+		if owners.ownedByOthers(path) {
+			yielded = append(yielded, path)
+		}
+	}
+	if len(yielded) == 0 {
+		return desired, nil
+	}
+
+	fields, err := runtime.DefaultUnstructuredConverter.ToUnstructured(desired)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to convert the desired resource")
+	}
+	for _, path := range yielded {
+		unstructured.RemoveNestedField(fields, path...)
+	}
+
+	yieldedObject := &unstructured.Unstructured{Object: fields}
+	yieldedObject.SetGroupVersionKind(gvk)
+	return yieldedObject, nil
+}
+
+// fieldOwners are the fields owned by the operator and by the other managers
+// of a resource.
+type fieldOwners struct {
+	operator []map[string]interface{}
+	others   []map[string]interface{}
+}
+
+func parseManagedFields(current Object) (*fieldOwners, error) {
+	owners := &fieldOwners{}
+	for _, entry := range current.GetManagedFields() {
+		if entry.FieldsV1 == nil {
+			continue
+		}
+		var fields map[string]interface{}
+		err := json.Unmarshal(entry.FieldsV1.Raw, &fields)
+		if err != nil {
+			return nil, errors.Wrapf(err, "failed to parse the fields managed by %s", entry.Manager)
+		}
+		if entry.Manager == FieldManager {
+			owners.operator = append(owners.operator, fields)
+		} else {
+			owners.others = append(owners.others, fields)
+		}
+	}
+	return owners, nil
+}
+
+// ownedByOthers returns true if the field is owned by other managers but not
+// by the operator.
+func (o *fieldOwners) ownedByOthers(path []string) bool {
+	return ownsField(o.others, path) && !ownsField(o.operator, path)
+}
+
+func ownsField(managed []map[string]interface{}, path []string) bool {
+	for _, fields := range managed {
+		found := true
+		for _, key := range path {
+			fields, found = fields["f:"+key].(map[string]interface{})
+			if !found {
+				break
+			}
+		}
+		if found {
+			return true
+		}
+	}
+	return false
+}
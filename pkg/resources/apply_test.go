@@ -0,0 +1,115 @@
+package resources
+
+import (
+	"context"
+	"testing"
+
+	blubr "github.com/mattermost/blubr"
+	resourcesfake "github.com/mattermost/mattermost-operator/pkg/resources/fake"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	appsv1 "k8s.io/api/apps/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/utils/pointer"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+)
+
+func managedFields(manager, fields string) metav1.ManagedFieldsEntry {
+	return metav1.ManagedFieldsEntry{
+		Manager:    manager,
+		Operation:  metav1.ManagedFieldsOperationUpdate,
+		FieldsType: "FieldsV1",
+		FieldsV1:   &metav1.FieldsV1{Raw: []byte(fields)},
+	}
+}
+
+func TestYieldSharedFields(t *testing.T) {
+	gvk := appsv1.SchemeGroupVersion.WithKind("Deployment")
+	desired := &appsv1.Deployment{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:        "mm",
+			Namespace:   "ns",
+			Annotations: map[string]string{"example.com/key": "value", "owner": "mattermost", DesiredStateHashAnnotation: "hash"},
+		},
+		Spec: appsv1.DeploymentSpec{Replicas: pointer.Int32Ptr(1)},
+	}
+
+	t.Run("fields owned by the operator", func(t *testing.T) {
+		current := desired.DeepCopy()
+		current.ManagedFields = []metav1.ManagedFieldsEntry{
+			managedFields(FieldManager, `{"f:metadata":{"f:annotations":{"f:example.com/key":{}}},"f:spec":{"f:replicas":{}}}`),
+			managedFields("kube-controller-manager", `{"f:spec":{"f:replicas":{}}}`),
+		}
+
+		yielded, err := yieldSharedFields(current, desired, gvk)
+		require.NoError(t, err)
+		assert.Equal(t, desired, yielded)
+	})
+
+	t.Run("fields taken over by others", func(t *testing.T) {
+		current := desired.DeepCopy()
+		current.ManagedFields = []metav1.ManagedFieldsEntry{
+			managedFields(FieldManager, `{"f:metadata":{"f:annotations":{"f:owner":{}}},"f:spec":{"f:template":{}}}`),
+			managedFields("kube-controller-manager", `{"f:spec":{"f:replicas":{}}}`),
+			managedFields("injector", `{"f:metadata":{"f:annotations":{"f:example.com/key":{},"f:owner":{},"f:mattermost.com/desired-state-hash":{}}}}`),
+		}
+
+		yielded, err := yieldSharedFields(current, desired, gvk)
+		require.NoError(t, err)
+		u, ok := yielded.(*unstructured.Unstructured)
+		require.True(t, ok)
+		assert.Equal(t, gvk, u.GroupVersionKind())
+		_, found, _ := unstructured.NestedFieldNoCopy(u.Object, "spec", "replicas")
+		assert.False(t, found)
+		assert.Equal(t, map[string]string{"owner": "mattermost", DesiredStateHashAnnotation: "hash"}, u.GetAnnotations())
+		assert.Equal(t, int32(1), *desired.Spec.Replicas)
+	})
+}
+
+func TestUpdateSharedFields(t *testing.T) {
+	logger := blubr.InitLogger()
+	scheme := runtime.NewScheme()
+	require.NoError(t, appsv1.AddToScheme(scheme))
+	c := fake.NewFakeClientWithScheme(scheme)
+	helper := NewResourceHelper(resourcesfake.NewApplyClient(c), scheme)
+	key := types.NamespacedName{Namespace: "ns", Name: "mm"}
+
+	generate := func() *appsv1.Deployment {
+		return &appsv1.Deployment{
+			ObjectMeta: metav1.ObjectMeta{Name: key.Name, Namespace: key.Namespace},
+			Spec:       appsv1.DeploymentSpec{Replicas: pointer.Int32Ptr(1)},
+		}
+	}
+	getCurrent := func(t *testing.T) *appsv1.Deployment {
+		current := &appsv1.Deployment{}
+		require.NoError(t, c.Get(context.TODO(), key, current))
+		return current
+	}
+
+	require.NoError(t, helper.Create(&appsv1.Deployment{}, generate(), logger))
+
+	// The replicas scaled by an autoscaler are not reverted.
+	current := getCurrent(t)
+	current.Spec.Replicas = pointer.Int32Ptr(4)
+	current.ManagedFields = []metav1.ManagedFieldsEntry{
+		managedFields("kube-controller-manager", `{"f:spec":{"f:replicas":{}}}`),
+	}
+	require.NoError(t, c.Update(context.TODO(), current))
+
+	require.NoError(t, helper.Update(getCurrent(t), generate(), logger))
+	assert.Equal(t, current.ResourceVersion, getCurrent(t).ResourceVersion)
+	assert.Equal(t, int32(4), *getCurrent(t).Spec.Replicas)
+
+	// The replicas owned by the operator are reverted.
+	current = getCurrent(t)
+	current.ManagedFields = []metav1.ManagedFieldsEntry{
+		managedFields(FieldManager, `{"f:spec":{"f:replicas":{}}}`),
+	}
+	require.NoError(t, c.Update(context.TODO(), current))
+
+	require.NoError(t, helper.Update(getCurrent(t), generate(), logger))
+	assert.Equal(t, int32(1), *getCurrent(t).Spec.Replicas)
+}
@@ -9,22 +9,19 @@ import (
 
 	"github.com/pkg/errors"
 
-	objectMatcher "github.com/banzaicloud/k8s-objectmatcher/patch"
 	"github.com/go-logr/logr"
 	appsv1 "k8s.io/api/apps/v1"
+	batchv1 "k8s.io/api/batch/v1"
 	corev1 "k8s.io/api/core/v1"
 	networkingv1 "k8s.io/api/networking/v1"
 	k8sErrors "k8s.io/apimachinery/pkg/api/errors"
 	v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/runtime"
 	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client/apiutil"
 	"sigs.k8s.io/controller-runtime/pkg/controller/controllerutil"
 )
 
-const lastAppliedConfig = "mattermost.com/last-applied"
-
-var defaultAnnotator = objectMatcher.NewAnnotator(lastAppliedConfig)
-
 // Object combines the interfaces that all Kubernetes objects must implement.
 type Object interface {
 	runtime.Object
@@ -44,15 +41,14 @@ func NewResourceHelper(client client.Client, scheme *runtime.Scheme) *ResourceHe
 	}
 }
 
-// Create creates the provided resource and sets the owner
+// Create creates the provided resource with a server-side apply and sets the owner
 func (r *ResourceHelper) Create(owner v1.Object, desired Object, reqLogger logr.Logger) error {
-	// adding the last applied annotation to use the object matcher later
-	// see: https://github.com/banzaicloud/k8s-objectmatcher
-	err := defaultAnnotator.SetLastAppliedAnnotation(desired)
+	// adding the desired state hash to skip the updates not changing it
+	_, err := setDesiredStateHash(desired)
 	if err != nil {
-		return errors.Wrap(err, "failed to apply annotation to the resource")
+		return err
 	}
-	err = r.client.Create(context.TODO(), desired)
+	err = r.apply(desired, reqLogger)
 	if err != nil {
 		return errors.Wrap(err, "failed to create resource")
 	}
@@ -60,24 +56,56 @@ func (r *ResourceHelper) Create(owner v1.Object, desired Object, reqLogger logr.
 	return controllerutil.SetControllerReference(owner, desired, r.scheme)
 }
 
+// Update applies the resource when the hash of the desired state differs from
+// the one applied last, or when the fields set by the operator were changed
+// by others, which are then reverted.
 func (r *ResourceHelper) Update(current, desired Object, reqLogger logr.Logger) error {
-	patchResult, err := objectMatcher.NewPatchMaker(defaultAnnotator).Calculate(current, desired)
+	return r.UpdateIgnoringFields(current, desired, nil, reqLogger)
+}
+
+// UpdateIgnoringFields updates the resource like Update, keeping the current
+// value of the ignored fields, given as JSONPaths, whose changes made by
+// others are never reverted.
+func (r *ResourceHelper) UpdateIgnoringFields(current, desired Object, ignoreFields []string, reqLogger logr.Logger) error {
+	hash, err := setDesiredStateHash(desired)
 	if err != nil {
-		return errors.Wrap(err, "failed to determine if resources differ")
+		return err
+	}
+
+	gvk, err := apiutil.GVKForObject(desired, r.scheme)
+	if err != nil {
+		return errors.Wrap(err, "failed to get the kind of the resource")
 	}
-	if !patchResult.IsEmpty() {
-		if err := defaultAnnotator.SetLastAppliedAnnotation(desired); err != nil {
-			return errors.Wrap(err, "failed to apply annotation to the resource")
-		}
 
-		reqLogger.Info("Updating resource", "name", desired.GetName(), "kind", desired.GetObjectKind(), "namespace", desired.GetNamespace(), "patch", string(patchResult.Patch))
+	applied := desired
+	if len(ignoreFields) > 0 {
+		applied, err = r.keepIgnoredFields(current, desired, ignoreFields)
+		if err != nil {
+			return errors.Wrap(err, "failed to update resource")
+		}
+	}
+	applied, err = yieldSharedFields(current, applied, gvk)
+	if err != nil {
+		return errors.Wrap(err, "failed to update resource")
+	}
 
-		// Resource version is required for the update, but need to be set after
-		// the last applied annotation to avoid unnecessary diffs
-		desired.SetResourceVersion(current.GetResourceVersion())
-		return r.client.Update(context.TODO(), desired)
+	if current.GetAnnotations()[DesiredStateHashAnnotation] == hash {
+		matches, err := matchesDesiredState(current, applied)
+		if err != nil {
+			return errors.Wrap(err, "failed to compare resource")
+		}
+		if matches {
+			return nil
+		}
+		reqLogger.Info("Reverting the changes made to the resource by others", "name", desired.GetName(), "kind", desired.GetObjectKind(), "namespace", desired.GetNamespace())
+	} else {
+		reqLogger.Info("Updating resource", "name", desired.GetName(), "kind", desired.GetObjectKind(), "namespace", desired.GetNamespace(), "hash", hash)
 	}
 
+	err = r.patch(applied, gvk, reqLogger)
+	if err != nil {
+		return errors.Wrap(err, "failed to update resource")
+	}
 	return nil
 }
 
@@ -160,6 +188,19 @@ func (r *ResourceHelper) CreateRoleIfNotExists(owner v1.Object, role *rbacv1.Rol
 	return nil
 }
 
+func (r *ResourceHelper) CreateJobIfNotExists(owner v1.Object, job *batchv1.Job, reqLogger logr.Logger) error {
+	foundJob := &batchv1.Job{}
+	err := r.client.Get(context.TODO(), types.NamespacedName{Name: job.Name, Namespace: job.Namespace}, foundJob)
+	if err != nil && k8sErrors.IsNotFound(err) {
+		reqLogger.Info("Creating job", "name", job.Name)
+		return r.Create(owner, job, reqLogger)
+	} else if err != nil {
+		return errors.Wrap(err, "failed to check if job exists")
+	}
+
+	return nil
+}
+
 func (r *ResourceHelper) DeleteIngress(key types.NamespacedName, reqLogger logr.Logger) error {
 	foundIngress := &networkingv1.Ingress{}
 	err := r.client.Get(context.TODO(), key, foundIngress)
@@ -176,3 +217,37 @@ func (r *ResourceHelper) DeleteIngress(key types.NamespacedName, reqLogger logr.
 	}
 	return nil
 }
+
+func (r *ResourceHelper) DeleteService(key types.NamespacedName, reqLogger logr.Logger) error {
+	foundService := &corev1.Service{}
+	err := r.client.Get(context.TODO(), key, foundService)
+	if err != nil && k8sErrors.IsNotFound(err) {
+		return nil
+	} else if err != nil {
+		return errors.Wrap(err, "failed to check if service exists")
+	}
+
+	reqLogger.Info("Deleting service", "name", foundService.Name)
+	err = r.client.Delete(context.TODO(), foundService)
+	if err != nil {
+		return errors.Wrap(err, "failed to delete service")
+	}
+	return nil
+}
+
+func (r *ResourceHelper) DeleteDeployment(key types.NamespacedName, reqLogger logr.Logger) error {
+	foundDeployment := &appsv1.Deployment{}
+	err := r.client.Get(context.TODO(), key, foundDeployment)
+	if err != nil && k8sErrors.IsNotFound(err) {
+		return nil
+	} else if err != nil {
+		return errors.Wrap(err, "failed to check if deployment exists")
+	}
+
+	reqLogger.Info("Deleting deployment", "name", foundDeployment.Name)
+	err = r.client.Delete(context.TODO(), foundDeployment, client.PropagationPolicy(v1.DeletePropagationBackground))
+	if err != nil {
+		return errors.Wrap(err, "failed to delete deployment")
+	}
+	return nil
+}
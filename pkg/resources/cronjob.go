@@ -0,0 +1,154 @@
+package resources
+
+import (
+	"context"
+
+	"github.com/go-logr/logr"
+	"github.com/pkg/errors"
+	batchv1beta1 "k8s.io/api/batch/v1beta1"
+	k8sErrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/meta"
+	v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+// CronJobV1 is the batch/v1 CronJob served since Kubernetes 1.21. The
+// batch/v1beta1 CronJob was removed in Kubernetes 1.25.
+var CronJobV1 = schema.GroupVersionKind{Group: "batch", Version: "v1", Kind: "CronJob"}
+
+// CronJobV1Beta1 is the batch/v1beta1 CronJob of the clusters older than
+// Kubernetes 1.21.
+var CronJobV1Beta1 = batchv1beta1.SchemeGroupVersion.WithKind("CronJob")
+
+// CronJobGroupVersionKind returns the batch/v1 CronJob if the cluster serves
+// it, and the batch/v1beta1 CronJob otherwise, or if the mapper is nil.
+func CronJobGroupVersionKind(mapper meta.RESTMapper) schema.GroupVersionKind {
+	if mapper == nil {
+		return CronJobV1Beta1
+	}
+	_, err := mapper.RESTMapping(CronJobV1.GroupKind(), CronJobV1.Version)
+	if err != nil {
+		return CronJobV1Beta1
+	}
+	return CronJobV1
+}
+
+// NewCronJob returns an empty CronJob of the version, to get or watch the
+// CronJobs. The batch/v1 CronJobs are unstructured, as the API of the
+// operator predates them; their fields are the ones of batch/v1beta1.
+func NewCronJob(gvk schema.GroupVersionKind) client.Object {
+	if gvk == CronJobV1Beta1 {
+		return &batchv1beta1.CronJob{}
+	}
+	cronJob := &unstructured.Unstructured{}
+	cronJob.SetGroupVersionKind(gvk)
+	return cronJob
+}
+
+// convertCronJob returns the CronJob as the version, the fields of the
+// batch/v1beta1 and batch/v1 CronJobs being the same.
+func convertCronJob(cronJob *batchv1beta1.CronJob, gvk schema.GroupVersionKind) (Object, error) {
+	if gvk == CronJobV1Beta1 {
+		return cronJob, nil
+	}
+	object, err := runtime.DefaultUnstructuredConverter.ToUnstructured(cronJob)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to convert the cron job")
+	}
+	delete(object, "status")
+	converted := &unstructured.Unstructured{Object: object}
+	converted.SetGroupVersionKind(gvk)
+	return converted, nil
+}
+
+// convertCronJobToV1Beta1 returns the CronJob of any version as a
+// batch/v1beta1 CronJob.
+func convertCronJobToV1Beta1(cronJob client.Object) (*batchv1beta1.CronJob, error) {
+	u, ok := cronJob.(*unstructured.Unstructured)
+	if !ok {
+		converted, ok := cronJob.(*batchv1beta1.CronJob)
+		if !ok {
+			return nil, errors.Errorf("unexpected cron job type %T", cronJob)
+		}
+		return converted, nil
+	}
+	converted := &batchv1beta1.CronJob{}
+	err := runtime.DefaultUnstructuredConverter.FromUnstructured(u.UnstructuredContent(), converted)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to convert the cron job")
+	}
+	return converted, nil
+}
+
+// cronJobGroupVersionKind returns the version of the CronJobs served by the
+// cluster of the client.
+func (r *ResourceHelper) cronJobGroupVersionKind() schema.GroupVersionKind {
+	return CronJobGroupVersionKind(r.client.RESTMapper())
+}
+
+// ApplyCronJob creates the CronJob, or updates it when its desired state
+// changed, as the latest version of the CronJobs served by the cluster.
+func (r *ResourceHelper) ApplyCronJob(cronJob *batchv1beta1.CronJob, reqLogger logr.Logger) error {
+	gvk := r.cronJobGroupVersionKind()
+	hash, err := setDesiredStateHash(cronJob)
+	if err != nil {
+		return err
+	}
+
+	current := NewCronJob(gvk)
+	err = r.client.Get(context.TODO(), types.NamespacedName{Name: cronJob.Name, Namespace: cronJob.Namespace}, current)
+	switch {
+	case err != nil && k8sErrors.IsNotFound(err):
+		reqLogger.Info("Creating cron job", "name", cronJob.Name, "version", gvk.GroupVersion().String())
+	case err != nil:
+		return errors.Wrap(err, "failed to check if cron job exists")
+	case current.GetAnnotations()[DesiredStateHashAnnotation] == hash:
+		currentCronJob, err := convertCronJobToV1Beta1(current)
+		if err != nil {
+			return err
+		}
+		matches, err := matchesDesiredState(currentCronJob, cronJob)
+		if err != nil {
+			return errors.Wrap(err, "failed to compare cron job")
+		}
+		if matches {
+			return nil
+		}
+		reqLogger.Info("Reverting the changes made to the cron job by others", "name", cronJob.Name, "version", gvk.GroupVersion().String())
+	default:
+		reqLogger.Info("Updating cron job", "name", cronJob.Name, "version", gvk.GroupVersion().String(), "hash", hash)
+	}
+
+	applied, err := convertCronJob(cronJob, gvk)
+	if err != nil {
+		return err
+	}
+	err = r.apply(applied, reqLogger)
+	if err != nil {
+		return errors.Wrap(err, "failed to apply cron job")
+	}
+	return nil
+}
+
+// DeleteCronJob deletes the CronJob, with the Jobs it created, if it exists.
+func (r *ResourceHelper) DeleteCronJob(key types.NamespacedName, reqLogger logr.Logger) error {
+	foundCronJob := NewCronJob(r.cronJobGroupVersionKind())
+	err := r.client.Get(context.TODO(), key, foundCronJob)
+	if err != nil && k8sErrors.IsNotFound(err) {
+		return nil
+	} else if err != nil {
+		return errors.Wrap(err, "failed to check if cron job exists")
+	}
+
+	reqLogger.Info("Deleting cron job", "name", foundCronJob.GetName())
+	err = r.client.Delete(context.TODO(), foundCronJob, client.PropagationPolicy(v1.DeletePropagationBackground))
+	if err != nil {
+		return errors.Wrap(err, "failed to delete cron job")
+	}
+
+	return nil
+}
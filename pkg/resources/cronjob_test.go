@@ -0,0 +1,110 @@
+package resources
+
+import (
+	"context"
+	"testing"
+
+	blubr "github.com/mattermost/blubr"
+	resourcesfake "github.com/mattermost/mattermost-operator/pkg/resources/fake"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	batchv1beta1 "k8s.io/api/batch/v1beta1"
+	k8sErrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+)
+
+// mapperClient is a client whose RESTMapper maps the given kinds, the fake
+// client having none.
+type mapperClient struct {
+	client.Client
+	mapper meta.RESTMapper
+}
+
+func (c *mapperClient) RESTMapper() meta.RESTMapper {
+	return c.mapper
+}
+
+func newMapper(gvks ...schema.GroupVersionKind) meta.RESTMapper {
+	mapper := meta.NewDefaultRESTMapper(nil)
+	for _, gvk := range gvks {
+		mapper.Add(gvk, meta.RESTScopeNamespace)
+	}
+	return mapper
+}
+
+func TestCronJobGroupVersionKind(t *testing.T) {
+	assert.Equal(t, CronJobV1Beta1, CronJobGroupVersionKind(nil))
+	assert.Equal(t, CronJobV1Beta1, CronJobGroupVersionKind(newMapper(CronJobV1Beta1)))
+	assert.Equal(t, CronJobV1, CronJobGroupVersionKind(newMapper(CronJobV1Beta1, CronJobV1)))
+}
+
+func TestApplyCronJob(t *testing.T) {
+	logger := blubr.InitLogger()
+	key := types.NamespacedName{Namespace: "ns", Name: "backup"}
+	generate := func(schedule string) *batchv1beta1.CronJob {
+		return &batchv1beta1.CronJob{
+			ObjectMeta: metav1.ObjectMeta{Name: key.Name, Namespace: key.Namespace},
+			Spec: batchv1beta1.CronJobSpec{
+				Schedule:          schedule,
+				ConcurrencyPolicy: batchv1beta1.ForbidConcurrent,
+			},
+		}
+	}
+
+	t.Run("batch/v1beta1", func(t *testing.T) {
+		scheme := runtime.NewScheme()
+		require.NoError(t, batchv1beta1.AddToScheme(scheme))
+		c := fake.NewFakeClientWithScheme(scheme)
+		helper := NewResourceHelper(resourcesfake.NewApplyClient(c), scheme)
+
+		require.NoError(t, helper.ApplyCronJob(generate("0 1 * * *"), logger))
+		cronJob := &batchv1beta1.CronJob{}
+		require.NoError(t, c.Get(context.TODO(), key, cronJob))
+		assert.Equal(t, "0 1 * * *", cronJob.Spec.Schedule)
+
+		require.NoError(t, helper.ApplyCronJob(generate("0 2 * * *"), logger))
+		require.NoError(t, c.Get(context.TODO(), key, cronJob))
+		assert.Equal(t, "0 2 * * *", cronJob.Spec.Schedule)
+
+		require.NoError(t, helper.DeleteCronJob(key, logger))
+		err := c.Get(context.TODO(), key, cronJob)
+		assert.True(t, k8sErrors.IsNotFound(err))
+	})
+
+	t.Run("batch/v1", func(t *testing.T) {
+		scheme := runtime.NewScheme()
+		require.NoError(t, batchv1beta1.AddToScheme(scheme))
+		scheme.AddKnownTypeWithName(CronJobV1, &unstructured.Unstructured{})
+		c := fake.NewFakeClientWithScheme(scheme)
+		helper := NewResourceHelper(&mapperClient{
+			Client: resourcesfake.NewApplyClient(c),
+			mapper: newMapper(CronJobV1Beta1, CronJobV1),
+		}, scheme)
+
+		require.NoError(t, helper.ApplyCronJob(generate("0 1 * * *"), logger))
+		cronJob := NewCronJob(CronJobV1).(*unstructured.Unstructured)
+		require.NoError(t, c.Get(context.TODO(), key, cronJob))
+		assert.Equal(t, "batch/v1", cronJob.GetAPIVersion())
+		schedule, _, err := unstructured.NestedString(cronJob.Object, "spec", "schedule")
+		require.NoError(t, err)
+		assert.Equal(t, "0 1 * * *", schedule)
+		policy, _, err := unstructured.NestedString(cronJob.Object, "spec", "concurrencyPolicy")
+		require.NoError(t, err)
+		assert.Equal(t, "Forbid", policy)
+		assert.NotEmpty(t, cronJob.GetAnnotations()[DesiredStateHashAnnotation])
+
+		err = c.Get(context.TODO(), key, &batchv1beta1.CronJob{})
+		assert.True(t, k8sErrors.IsNotFound(err))
+
+		require.NoError(t, helper.DeleteCronJob(key, logger))
+		err = c.Get(context.TODO(), key, NewCronJob(CronJobV1))
+		assert.True(t, k8sErrors.IsNotFound(err))
+	})
+}
@@ -0,0 +1,45 @@
+// Package fake provides a client for the tests of the resources applied
+// with server-side apply, which the fake client of controller-runtime does
+// not support.
+package fake
+
+import (
+	"context"
+
+	k8sErrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+// applyClient creates or replaces the objects of the apply patches.
+type applyClient struct {
+	client.Client
+}
+
+// NewApplyClient returns the client with the apply patches creating the
+// objects, or replacing the existing ones. The fields of other managers are
+// therefore not kept.
+func NewApplyClient(c client.Client) client.Client {
+	return &applyClient{Client: c}
+}
+
+func (c *applyClient) Patch(ctx context.Context, obj client.Object, patch client.Patch, opts ...client.PatchOption) error {
+	if patch.Type() != types.ApplyPatchType {
+		return c.Client.Patch(ctx, obj, patch, opts...)
+	}
+
+	current, ok := obj.DeepCopyObject().(client.Object)
+	if !ok {
+		return k8sErrors.NewBadRequest("the applied object can not be copied")
+	}
+	err := c.Client.Get(ctx, client.ObjectKeyFromObject(obj), current)
+	if k8sErrors.IsNotFound(err) {
+		return c.Client.Create(ctx, obj)
+	}
+	if err != nil {
+		return err
+	}
+
+	obj.SetResourceVersion(current.GetResourceVersion())
+	return c.Client.Update(ctx, obj)
+}
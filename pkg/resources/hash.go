@@ -0,0 +1,79 @@
+package resources
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"reflect"
+
+	"github.com/pkg/errors"
+	"k8s.io/apimachinery/pkg/api/equality"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+)
+
+// DesiredStateHashAnnotation holds the hash of the generated resource last
+// applied by the operator.
+const DesiredStateHashAnnotation = "mattermost.com/desired-state-hash"
+
+// setDesiredStateHash annotates the resource with the hash of its generated
+// state and returns the hash. The annotation itself, the kind and the metadata
+// set by the API server are excluded from the hash.
+func setDesiredStateHash(desired Object) (string, error) {
+	annotations := desired.GetAnnotations()
+	delete(annotations, DesiredStateHashAnnotation)
+
+	generated, ok := desired.DeepCopyObject().(Object)
+	if !ok {
+		return "", errors.New("failed to copy the resource")
+	}
+	generated.GetObjectKind().SetGroupVersionKind(schema.GroupVersionKind{})
+	generated.SetResourceVersion("")
+	generated.SetUID("")
+	generated.SetSelfLink("")
+	generated.SetGeneration(0)
+	generated.SetCreationTimestamp(metav1.Time{})
+	generated.SetManagedFields(nil)
+
+	data, err := json.Marshal(generated)
+	if err != nil {
+		return "", errors.Wrap(err, "failed to marshal the resource")
+	}
+	sum := sha256.Sum256(data)
+	hash := hex.EncodeToString(sum[:])
+
+	if annotations == nil {
+		annotations = map[string]string{}
+	}
+	annotations[DesiredStateHashAnnotation] = hash
+	desired.SetAnnotations(annotations)
+	return hash, nil
+}
+
+// matchesDesiredState returns true if the fields set in the desired resource
+// have the same value in the current one, so that the changes made to them by
+// others are detected even though the desired state did not change. The
+// fields left empty in the desired resource, ie defaulted by the API server,
+// are not compared. A desired resource converted to unstructured is compared
+// as the type of the current resource.
+func matchesDesiredState(current, desired Object) (bool, error) {
+	compared, ok := desired.DeepCopyObject().(Object)
+	if !ok {
+		return false, errors.New("failed to copy the resource")
+	}
+	if u, ok := desired.(*unstructured.Unstructured); ok {
+		compared, ok = reflect.New(reflect.TypeOf(current).Elem()).Interface().(Object)
+		if !ok {
+			return false, errors.New("failed to create the resource")
+		}
+		err := runtime.DefaultUnstructuredConverter.FromUnstructured(u.UnstructuredContent(), compared)
+		if err != nil {
+			return false, errors.Wrap(err, "failed to convert the desired resource")
+		}
+	}
+	compared.GetObjectKind().SetGroupVersionKind(schema.GroupVersionKind{})
+
+	return equality.Semantic.DeepDerivative(compared, current), nil
+}
@@ -0,0 +1,71 @@
+package resources
+
+import (
+	"context"
+	"testing"
+
+	blubr "github.com/mattermost/blubr"
+	resourcesfake "github.com/mattermost/mattermost-operator/pkg/resources/fake"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+)
+
+func TestUpdateDesiredStateHash(t *testing.T) {
+	logger := blubr.InitLogger()
+	scheme := runtime.NewScheme()
+	require.NoError(t, corev1.AddToScheme(scheme))
+	c := fake.NewFakeClientWithScheme(scheme)
+	helper := NewResourceHelper(resourcesfake.NewApplyClient(c), scheme)
+
+	owner := &corev1.ConfigMap{ObjectMeta: metav1.ObjectMeta{Name: "owner", Namespace: "ns", UID: "uid"}}
+	generate := func(port int32) *corev1.Service {
+		return &corev1.Service{
+			ObjectMeta: metav1.ObjectMeta{Name: "mm", Namespace: "ns", Annotations: map[string]string{"key": "value"}},
+			Spec:       corev1.ServiceSpec{Ports: []corev1.ServicePort{{Port: port}}},
+		}
+	}
+	fetch := func() *corev1.Service {
+		current := &corev1.Service{}
+		require.NoError(t, c.Get(context.Background(), types.NamespacedName{Name: "mm", Namespace: "ns"}, current))
+		return current
+	}
+
+	require.NoError(t, helper.Create(owner, generate(8065), logger))
+	current := fetch()
+	hash := current.Annotations[DesiredStateHashAnnotation]
+	assert.NotEmpty(t, hash)
+	assert.Equal(t, "value", current.Annotations["key"])
+
+	t.Run("unchanged desired state", func(t *testing.T) {
+		require.NoError(t, helper.Update(current, generate(8065), logger))
+		assert.Equal(t, current.ResourceVersion, fetch().ResourceVersion)
+	})
+
+	t.Run("changed live state", func(t *testing.T) {
+		modified := fetch()
+		modified.Spec.Ports[0].Port = 9000
+		modified.Spec.ClusterIP = "10.0.0.1"
+		require.NoError(t, c.Update(context.Background(), modified))
+
+		require.NoError(t, helper.Update(fetch(), generate(8065), logger))
+		reverted := fetch()
+		assert.Equal(t, int32(8065), reverted.Spec.Ports[0].Port)
+		assert.Equal(t, hash, reverted.Annotations[DesiredStateHashAnnotation])
+
+		require.NoError(t, helper.Update(reverted, generate(8065), logger))
+		assert.Equal(t, reverted.ResourceVersion, fetch().ResourceVersion)
+	})
+
+	t.Run("changed desired state", func(t *testing.T) {
+		require.NoError(t, helper.Update(current, generate(8066), logger))
+		updated := fetch()
+		assert.NotEqual(t, current.ResourceVersion, updated.ResourceVersion)
+		assert.NotEqual(t, hash, updated.Annotations[DesiredStateHashAnnotation])
+		assert.Equal(t, int32(8066), updated.Spec.Ports[0].Port)
+	})
+}
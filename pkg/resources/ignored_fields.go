@@ -0,0 +1,93 @@
+package resources
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/pkg/errors"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client/apiutil"
+)
+
+// ParseFieldPath parses the JSONPath of an object field, ie '.spec.replicas'
+// or ".metadata.annotations['example.com/key']", into the keys of its path.
+// The leading dot is optional. List items and wildcards are not supported.
+func ParseFieldPath(path string) ([]string, error) {
+	var keys []string
+	rest := path
+	if !strings.HasPrefix(rest, ".") && !strings.HasPrefix(rest, "[") {
+		rest = "." + rest
+	}
+
+	for rest != "" {
+		switch rest[0] {
+		case '.':
+			end := strings.IndexAny(rest[1:], ".[")
+			if end < 0 {
+				end = len(rest) - 1
+			}
+			key := rest[1 : end+1]
+			if key == "" || key == "*" {
+				return nil, fmt.Errorf("invalid field path %q", path)
+			}
+			keys = append(keys, key)
+			rest = rest[end+1:]
+		case '[':
+			if len(rest) < 2 || (rest[1] != '\'' && rest[1] != '"') {
+				return nil, fmt.Errorf("invalid field path %q, only quoted object keys are supported between brackets", path)
+			}
+			end := strings.IndexByte(rest[2:], rest[1])
+			if end < 0 || len(rest) < end+4 || rest[end+3] != ']' {
+				return nil, fmt.Errorf("invalid field path %q", path)
+			}
+			keys = append(keys, rest[2:end+2])
+			rest = rest[end+4:]
+		default:
+			return nil, fmt.Errorf("invalid field path %q", path)
+		}
+	}
+	if len(keys) == 0 {
+		return nil, fmt.Errorf("invalid field path %q", path)
+	}
+	return keys, nil
+}
+
+// keepIgnoredFields returns the desired resource with the current value of
+// the ignored fields, which is then applied as is. The ignored fields not
+// set in the current resource are not applied.
+func (r *ResourceHelper) keepIgnoredFields(current, desired Object, ignoreFields []string) (Object, error) {
+	gvk, err := apiutil.GVKForObject(desired, r.scheme)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to get the kind of the resource")
+	}
+	currentFields, err := runtime.DefaultUnstructuredConverter.ToUnstructured(current)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to convert the current resource")
+	}
+	desiredFields, err := runtime.DefaultUnstructuredConverter.ToUnstructured(desired)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to convert the desired resource")
+	}
+
+	for _, field := range ignoreFields {
+		path, err := ParseFieldPath(field)
+		if err != nil {
+			return nil, err
+		}
+		// A path through a field which is not an object is not found.
+		value, found, _ := unstructured.NestedFieldNoCopy(currentFields, path...)
+		if !found {
+			unstructured.RemoveNestedField(desiredFields, path...)
+			continue
+		}
+		err = unstructured.SetNestedField(desiredFields, runtime.DeepCopyJSONValue(value), path...)
+		if err != nil {
+			return nil, errors.Wrapf(err, "failed to keep the ignored field %s", field)
+		}
+	}
+
+	kept := &unstructured.Unstructured{Object: desiredFields}
+	kept.SetGroupVersionKind(gvk)
+	return kept, nil
+}
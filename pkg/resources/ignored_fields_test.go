@@ -0,0 +1,93 @@
+package resources
+
+import (
+	"context"
+	"testing"
+
+	blubr "github.com/mattermost/blubr"
+	resourcesfake "github.com/mattermost/mattermost-operator/pkg/resources/fake"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/utils/pointer"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+)
+
+func TestParseFieldPath(t *testing.T) {
+	for _, testCase := range []struct {
+		path string
+		keys []string
+	}{
+		{path: ".spec.replicas", keys: []string{"spec", "replicas"}},
+		{path: "spec.replicas", keys: []string{"spec", "replicas"}},
+		{path: ".metadata.annotations['example.com/key']", keys: []string{"metadata", "annotations", "example.com/key"}},
+		{path: `.metadata.labels["app.kubernetes.io/name"]`, keys: []string{"metadata", "labels", "app.kubernetes.io/name"}},
+		{path: "['spec']['replicas']", keys: []string{"spec", "replicas"}},
+	} {
+		t.Run(testCase.path, func(t *testing.T) {
+			keys, err := ParseFieldPath(testCase.path)
+			require.NoError(t, err)
+			assert.Equal(t, testCase.keys, keys)
+		})
+	}
+
+	for _, path := range []string{"", ".", ".spec..replicas", ".spec.containers[0]", ".spec.*", ".metadata.annotations['key"} {
+		t.Run(path, func(t *testing.T) {
+			_, err := ParseFieldPath(path)
+			assert.Error(t, err)
+		})
+	}
+}
+
+func TestUpdateIgnoringFields(t *testing.T) {
+	logger := blubr.InitLogger()
+	scheme := runtime.NewScheme()
+	require.NoError(t, appsv1.AddToScheme(scheme))
+	c := fake.NewFakeClientWithScheme(scheme)
+	helper := NewResourceHelper(resourcesfake.NewApplyClient(c), scheme)
+	key := types.NamespacedName{Namespace: "ns", Name: "mm"}
+
+	generate := func(image string) *appsv1.Deployment {
+		deployment := &appsv1.Deployment{
+			ObjectMeta: metav1.ObjectMeta{Name: key.Name, Namespace: key.Namespace, Annotations: map[string]string{"key": "value"}},
+			Spec:       appsv1.DeploymentSpec{Replicas: pointer.Int32Ptr(1)},
+		}
+		deployment.Spec.Template.Spec.Containers = []corev1.Container{{Name: "mattermost", Image: image}}
+		return deployment
+	}
+	getCurrent := func(t *testing.T) *appsv1.Deployment {
+		current := &appsv1.Deployment{}
+		require.NoError(t, c.Get(context.TODO(), key, current))
+		return current
+	}
+	ignoreFields := []string{".spec.replicas", ".metadata.annotations['scaler.example.com/state']", ".metadata.annotations['missing']"}
+
+	require.NoError(t, helper.Create(&appsv1.Deployment{}, generate("mattermost:7.1"), logger))
+
+	current := getCurrent(t)
+	current.Spec.Replicas = pointer.Int32Ptr(5)
+	current.Annotations["scaler.example.com/state"] = "scaled"
+	require.NoError(t, c.Update(context.TODO(), current))
+
+	require.NoError(t, helper.UpdateIgnoringFields(getCurrent(t), generate("mattermost:7.8"), ignoreFields, logger))
+	current = getCurrent(t)
+	assert.Equal(t, "mattermost:7.8", current.Spec.Template.Spec.Containers[0].Image)
+	assert.Equal(t, int32(5), *current.Spec.Replicas)
+	assert.Equal(t, "scaled", current.Annotations["scaler.example.com/state"])
+	assert.Equal(t, "value", current.Annotations["key"])
+
+	current.Spec.Replicas = pointer.Int32Ptr(3)
+	require.NoError(t, c.Update(context.TODO(), current))
+	require.NoError(t, helper.UpdateIgnoringFields(getCurrent(t), generate("mattermost:7.8"), ignoreFields, logger))
+	assert.Equal(t, current.ResourceVersion, getCurrent(t).ResourceVersion)
+
+	require.NoError(t, helper.UpdateIgnoringFields(getCurrent(t), generate("mattermost:7.9"), nil, logger))
+	assert.Equal(t, int32(1), *getCurrent(t).Spec.Replicas)
+
+	err := helper.UpdateIgnoringFields(getCurrent(t), generate("mattermost:7.10"), []string{".spec.containers[0]"}, logger)
+	assert.Error(t, err)
+}
@@ -0,0 +1,81 @@
+package resources
+
+import (
+	"context"
+
+	mmv1beta "github.com/mattermost/mattermost-operator/apis/mattermost/v1beta1"
+	mattermostMinio "github.com/mattermost/mattermost-operator/pkg/components/minio"
+	mattermostmysql "github.com/mattermost/mattermost-operator/pkg/components/mysql"
+	mattermostApp "github.com/mattermost/mattermost-operator/pkg/mattermost"
+	"github.com/pkg/errors"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/types"
+)
+
+// ReadDatabaseConfig returns the configuration of the database used by the
+// Mattermost installation. Unlike the Mattermost controller, it never creates
+// resources of the installation.
+func (r *ResourceHelper) ReadDatabaseConfig(mattermost *mmv1beta.Mattermost) (mattermostApp.DatabaseConfig, error) {
+	if mattermost.Spec.Database.IsExternal() {
+		secret, err := r.readSecret(mattermost.Spec.Database.External.Secret, mattermost.Namespace)
+		if err != nil {
+			return nil, errors.Wrap(err, "failed to get external db Secret")
+		}
+		return mattermostApp.NewExternalDBConfig(mattermost, *secret)
+	}
+
+	if mattermost.Spec.Database.OperatorManaged == nil || mattermost.Spec.Database.OperatorManaged.Type != "mysql" {
+		return nil, errors.New("only external and operator managed MySQL databases are supported")
+	}
+
+	secret, err := r.readSecret(mattermostmysql.DefaultDatabaseSecretName(mattermost.Name), mattermost.Namespace)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to get MySQL database Secret")
+	}
+
+	return mattermostApp.NewMySQLDBConfig(*secret)
+}
+
+// ReadFileStoreInfo returns the file store used by the Mattermost
+// installation.
+func (r *ResourceHelper) ReadFileStoreInfo(mattermost *mmv1beta.Mattermost) (*mattermostApp.FileStoreInfo, error) {
+	if mattermost.Spec.FileStore.IsExternal() {
+		secret, err := r.readSecret(mattermost.Spec.FileStore.External.Secret, mattermost.Namespace)
+		if err != nil {
+			return nil, errors.Wrap(err, "failed to get external file store Secret")
+		}
+		return mattermostApp.NewExternalFileStoreInfo(mattermost, *secret)
+	}
+
+	url, err := r.GetMinioService(mattermost.Name, mattermost.Namespace)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to get Minio URL")
+	}
+
+	return mattermostApp.NewOperatorManagedFileStoreInfo(mattermost, mattermostMinio.DefaultMinioSecretName(mattermost.Name), url), nil
+}
+
+// ReadBackupBucket returns the file store info of the bucket holding the
+// backups.
+func (r *ResourceHelper) ReadBackupBucket(bucket mmv1beta.BackupDestination, namespace string) (*mattermostApp.FileStoreInfo, error) {
+	secret, err := r.readSecret(bucket.Secret, namespace)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to get backup bucket Secret")
+	}
+
+	return mattermostApp.NewBackupDestinationInfo(bucket, *secret)
+}
+
+func (r *ResourceHelper) readSecret(name, namespace string) (*corev1.Secret, error) {
+	if name == "" {
+		return nil, errors.New("secret name is empty")
+	}
+
+	secret := &corev1.Secret{}
+	err := r.client.Get(context.TODO(), types.NamespacedName{Name: name, Namespace: namespace}, secret)
+	if err != nil {
+		return nil, err
+	}
+
+	return secret, nil
+}
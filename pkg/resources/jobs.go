@@ -0,0 +1,94 @@
+package resources
+
+import (
+	"context"
+
+	"github.com/go-logr/logr"
+	batchv1 "k8s.io/api/batch/v1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+// JobTerminationMessage returns the termination message of the last
+// terminated container, or init container, of the Job pods.
+func (r *ResourceHelper) JobTerminationMessage(job *batchv1.Job, reqLogger logr.Logger) string {
+	pods := &corev1.PodList{}
+	err := r.client.List(context.TODO(), pods, client.InNamespace(job.Namespace), client.MatchingLabels{"job-name": job.Name})
+	if err != nil {
+		reqLogger.Error(err, "Unable to list job pods", "job", job.Name)
+		return ""
+	}
+
+	var message string
+	var finishedAt metav1.Time
+	for _, pod := range pods.Items {
+		statuses := append(pod.Status.InitContainerStatuses, pod.Status.ContainerStatuses...)
+		for _, containerStatus := range statuses {
+			terminated := containerStatus.State.Terminated
+			if terminated == nil || terminated.Message == "" {
+				continue
+			}
+			if message == "" || finishedAt.Before(&terminated.FinishedAt) {
+				message = terminated.Message
+				finishedAt = terminated.FinishedAt
+			}
+		}
+	}
+
+	return message
+}
+
+// JobConditionTrue returns whether the Job has the condition of the given
+// type set to true.
+func JobConditionTrue(job *batchv1.Job, conditionType batchv1.JobConditionType) bool {
+	for _, condition := range job.Status.Conditions {
+		if condition.Type == conditionType && condition.Status == corev1.ConditionTrue {
+			return true
+		}
+	}
+	return false
+}
+
+// JobConditionTime returns the last transition time of the Job condition of
+// the given type.
+func JobConditionTime(job *batchv1.Job, conditionType batchv1.JobConditionType) *metav1.Time {
+	for _, condition := range job.Status.Conditions {
+		if condition.Type == conditionType {
+			return condition.LastTransitionTime.DeepCopy()
+		}
+	}
+	return nil
+}
+
+// JobActiveContainer returns the name of the first container, or init
+// container, of the newest Job pod which did not complete successfully. It
+// returns an empty string if the Job has no pods.
+func (r *ResourceHelper) JobActiveContainer(job *batchv1.Job, reqLogger logr.Logger) string {
+	pods := &corev1.PodList{}
+	err := r.client.List(context.TODO(), pods, client.InNamespace(job.Namespace), client.MatchingLabels{"job-name": job.Name})
+	if err != nil {
+		reqLogger.Error(err, "Unable to list job pods", "job", job.Name)
+		return ""
+	}
+
+	var newest *corev1.Pod
+	for i := range pods.Items {
+		if newest == nil || newest.CreationTimestamp.Before(&pods.Items[i].CreationTimestamp) {
+			newest = &pods.Items[i]
+		}
+	}
+	if newest == nil {
+		return ""
+	}
+
+	statuses := append(newest.Status.InitContainerStatuses, newest.Status.ContainerStatuses...)
+	for _, containerStatus := range statuses {
+		terminated := containerStatus.State.Terminated
+		if terminated == nil || terminated.ExitCode != 0 {
+			return containerStatus.Name
+		}
+	}
+
+	return ""
+}
@@ -0,0 +1,31 @@
+package resources
+
+import (
+	"context"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/client-go/kubernetes"
+)
+
+// PodLogs reads the logs of pods, which are not served by the
+// controller-runtime client.
+type PodLogs struct {
+	clientset kubernetes.Interface
+}
+
+// NewPodLogs returns a PodLogs reading the logs with the clientset.
+func NewPodLogs(clientset kubernetes.Interface) *PodLogs {
+	return &PodLogs{clientset: clientset}
+}
+
+// TailLogs returns the last lines of the logs of the pod container.
+func (p *PodLogs) TailLogs(ctx context.Context, namespace, pod, container string, lines int64) (string, error) {
+	logs, err := p.clientset.CoreV1().Pods(namespace).GetLogs(pod, &corev1.PodLogOptions{
+		Container: container,
+		TailLines: &lines,
+	}).DoRaw(ctx)
+	if err != nil {
+		return "", err
+	}
+	return string(logs), nil
+}
@@ -0,0 +1,74 @@
+package resources
+
+import (
+	"context"
+
+	"github.com/go-logr/logr"
+	"github.com/pkg/errors"
+	corev1 "k8s.io/api/core/v1"
+	k8sErrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/types"
+)
+
+// volumeSnapshotGVK is the kind of the CSI VolumeSnapshots. The snapshot API
+// is not part of the core Kubernetes API, so snapshots are handled as
+// unstructured objects.
+var volumeSnapshotGVK = schema.GroupVersionKind{
+	Group:   "snapshot.storage.k8s.io",
+	Version: "v1",
+	Kind:    "VolumeSnapshot",
+}
+
+// CreateVolumeSnapshotIfNotExists creates the VolumeSnapshot of the
+// PersistentVolumeClaim if it does not exist yet. It returns whether the
+// snapshot is ready to use.
+// The snapshot is not owned by the installation, so that it is kept even if
+// the installation is removed.
+func (r *ResourceHelper) CreateVolumeSnapshotIfNotExists(name string, claim *corev1.PersistentVolumeClaim, snapshotClassName string, labels map[string]string, reqLogger logr.Logger) (bool, error) {
+	snapshot := &unstructured.Unstructured{}
+	snapshot.SetGroupVersionKind(volumeSnapshotGVK)
+
+	err := r.client.Get(context.TODO(), types.NamespacedName{Name: name, Namespace: claim.Namespace}, snapshot)
+	if err != nil && k8sErrors.IsNotFound(err) {
+		reqLogger.Info("Creating volume snapshot", "name", name, "claim", claim.Name)
+		return false, r.client.Create(context.TODO(), newVolumeSnapshot(name, claim, snapshotClassName, labels))
+	} else if err != nil {
+		return false, errors.Wrap(err, "failed to check if volume snapshot exists")
+	}
+
+	errorMessage, _, err := unstructured.NestedString(snapshot.Object, "status", "error", "message")
+	if err != nil {
+		return false, errors.Wrap(err, "failed to read volume snapshot error")
+	}
+	if errorMessage != "" {
+		return false, errors.Errorf("volume snapshot %s failed: %s", name, errorMessage)
+	}
+
+	ready, _, err := unstructured.NestedBool(snapshot.Object, "status", "readyToUse")
+	if err != nil {
+		return false, errors.Wrap(err, "failed to read volume snapshot status")
+	}
+
+	return ready, nil
+}
+
+func newVolumeSnapshot(name string, claim *corev1.PersistentVolumeClaim, snapshotClassName string, labels map[string]string) *unstructured.Unstructured {
+	spec := map[string]interface{}{
+		"source": map[string]interface{}{
+			"persistentVolumeClaimName": claim.Name,
+		},
+	}
+	if snapshotClassName != "" {
+		spec["volumeSnapshotClassName"] = snapshotClassName
+	}
+
+	snapshot := &unstructured.Unstructured{Object: map[string]interface{}{"spec": spec}}
+	snapshot.SetGroupVersionKind(volumeSnapshotGVK)
+	snapshot.SetName(name)
+	snapshot.SetNamespace(claim.Namespace)
+	snapshot.SetLabels(labels)
+
+	return snapshot
+}
@@ -3,6 +3,7 @@ package resources
 import (
 	"context"
 
+	mmv1beta "github.com/mattermost/mattermost-operator/apis/mattermost/v1beta1"
 	mattermostApp "github.com/mattermost/mattermost-operator/pkg/mattermost"
 	"github.com/pkg/errors"
 	appsv1 "k8s.io/api/apps/v1"
@@ -19,8 +20,9 @@ const UpdateJobName = "mattermost-update-check"
 func (r *ResourceHelper) LaunchMattermostUpdateJob(
 	jobNamespace string,
 	baseDeployment *appsv1.Deployment,
+	mattermost *mmv1beta.Mattermost,
 ) error {
-	job := PrepareMattermostJobTemplate(UpdateJobName, jobNamespace, baseDeployment)
+	job := PrepareMattermostUpdateJob(jobNamespace, baseDeployment, mattermost)
 
 	err := r.client.Create(context.TODO(), job)
 	if err != nil && !k8sErrors.IsAlreadyExists(err) {
@@ -34,13 +36,14 @@ func (r *ResourceHelper) LaunchMattermostUpdateJob(
 func (r *ResourceHelper) RestartMattermostUpdateJob(
 	currentJob *batchv1.Job,
 	deployment *appsv1.Deployment,
+	mattermost *mmv1beta.Mattermost,
 ) error {
 	err := r.client.Delete(context.TODO(), currentJob, k8sClient.PropagationPolicy(metav1.DeletePropagationBackground))
 	if err != nil && !k8sErrors.IsNotFound(err) {
 		return errors.Wrapf(err, "failed to delete outdated update job")
 	}
 
-	job := PrepareMattermostJobTemplate(UpdateJobName, currentJob.Namespace, deployment)
+	job := PrepareMattermostUpdateJob(currentJob.Namespace, deployment, mattermost)
 
 	err = r.client.Create(context.TODO(), job)
 	if err != nil {
@@ -64,6 +67,39 @@ func (r *ResourceHelper) FetchMattermostUpdateJob(namespace string) (*batchv1.Jo
 	return job, err
 }
 
+// PrepareMattermostUpdateJob returns the update job of the deployment. Jobs
+// of v1beta1 Mattermosts inherit their job settings, with the pod settings
+// of the deployment overridden by the update check settings.
+func PrepareMattermostUpdateJob(namespace string, baseDeployment *appsv1.Deployment, mattermost *mmv1beta.Mattermost) *batchv1.Job {
+	job := PrepareMattermostJobTemplate(UpdateJobName, namespace, baseDeployment)
+	if mattermost == nil {
+		return job
+	}
+	mattermostApp.SetJobPodSettings(mattermost, &job.Spec.Template.Spec)
+
+	updateCheck := mattermost.GetUpdateCheckJob()
+	if updateCheck == nil {
+		return job
+	}
+
+	podSpec := &job.Spec.Template.Spec
+	if updateCheck.Resources != nil {
+		for i := range podSpec.Containers {
+			podSpec.Containers[i].Resources = *updateCheck.Resources.DeepCopy()
+		}
+	}
+	if updateCheck.NodeSelector != nil {
+		podSpec.NodeSelector = updateCheck.NodeSelector
+	}
+	if updateCheck.Tolerations != nil {
+		podSpec.Tolerations = updateCheck.Tolerations
+	}
+	podSpec.ImagePullSecrets = append(podSpec.ImagePullSecrets, updateCheck.ImagePullSecrets...)
+	mattermostApp.SetJobPolicy(&updateCheck.JobPolicy, &job.Spec)
+
+	return job
+}
+
 func PrepareMattermostJobTemplate(name, namespace string, baseDeployment *appsv1.Deployment) *batchv1.Job {
 	backoffLimit := int32(10)
 
@@ -0,0 +1,173 @@
+package resources
+
+import (
+	"context"
+	"strings"
+
+	"github.com/go-logr/logr"
+	mmv1beta "github.com/mattermost/mattermost-operator/apis/mattermost/v1beta1"
+	"github.com/pkg/errors"
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	storagev1 "k8s.io/api/storage/v1"
+	k8sErrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+// ListStatefulSetVolumes returns the PersistentVolumeClaims created by the
+// StatefulSet from the volume claim template with the given name. It returns
+// no claims if the StatefulSet does not exist.
+func (r *ResourceHelper) ListStatefulSetVolumes(namespace, statefulSetName, claimTemplateName string) ([]corev1.PersistentVolumeClaim, error) {
+	statefulSet := &appsv1.StatefulSet{}
+	err := r.client.Get(context.TODO(), types.NamespacedName{Name: statefulSetName, Namespace: namespace}, statefulSet)
+	if err != nil {
+		if k8sErrors.IsNotFound(err) {
+			return nil, nil
+		}
+		return nil, errors.Wrap(err, "failed to get StatefulSet")
+	}
+
+	selector, err := metav1.LabelSelectorAsSelector(statefulSet.Spec.Selector)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to parse StatefulSet selector")
+	}
+
+	claimList := &corev1.PersistentVolumeClaimList{}
+	err = r.client.List(context.TODO(), claimList, client.InNamespace(namespace), client.MatchingLabelsSelector{Selector: selector})
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to list PersistentVolumeClaims")
+	}
+
+	var claims []corev1.PersistentVolumeClaim
+	claimPrefix := claimTemplateName + "-" + statefulSetName + "-"
+	for _, claim := range claimList.Items {
+		if strings.HasPrefix(claim.Name, claimPrefix) {
+			claims = append(claims, claim)
+		}
+	}
+
+	return claims, nil
+}
+
+// SetStatefulSetVolumesLabel sets the label on the PersistentVolumeClaims
+// created by the StatefulSet from the volume claim template with the given
+// name. The label is removed if the value is empty.
+func (r *ResourceHelper) SetStatefulSetVolumesLabel(namespace, statefulSetName, claimTemplateName, key, value string, reqLogger logr.Logger) error {
+	claims, err := r.ListStatefulSetVolumes(namespace, statefulSetName, claimTemplateName)
+	if err != nil {
+		return err
+	}
+
+	for i := range claims {
+		claim := &claims[i]
+		if current, ok := claim.Labels[key]; (value == "" && !ok) || (value != "" && current == value) {
+			continue
+		}
+
+		if value == "" {
+			reqLogger.Info("Removing volume label", "name", claim.Name, "label", key)
+			delete(claim.Labels, key)
+		} else {
+			reqLogger.Info("Setting volume label", "name", claim.Name, "label", key, "value", value)
+			if claim.Labels == nil {
+				claim.Labels = map[string]string{}
+			}
+			claim.Labels[key] = value
+		}
+
+		err = r.client.Update(context.TODO(), claim)
+		if err != nil {
+			return errors.Wrapf(err, "failed to update PersistentVolumeClaim %s", claim.Name)
+		}
+	}
+
+	return nil
+}
+
+// ExpandStatefulSetVolumes requests the given storage size for the
+// PersistentVolumeClaims created by the StatefulSet from the volume claim
+// template with the given name. Volumes can only be expanded if their
+// StorageClass allows it, requests to shrink volumes are ignored.
+// It returns the status of the volumes which are not yet resized.
+func (r *ResourceHelper) ExpandStatefulSetVolumes(namespace, statefulSetName, claimTemplateName string, size resource.Quantity, reqLogger logr.Logger) ([]mmv1beta.VolumeResizeStatus, error) {
+	claims, err := r.ListStatefulSetVolumes(namespace, statefulSetName, claimTemplateName)
+	if err != nil {
+		return nil, err
+	}
+
+	var statuses []mmv1beta.VolumeResizeStatus
+	for i := range claims {
+		status, err := r.expandVolume(&claims[i], size, reqLogger)
+		if err != nil {
+			return nil, errors.Wrapf(err, "failed to expand PersistentVolumeClaim %s", claims[i].Name)
+		}
+		if status != nil {
+			statuses = append(statuses, *status)
+		}
+	}
+
+	return statuses, nil
+}
+
+func (r *ResourceHelper) expandVolume(claim *corev1.PersistentVolumeClaim, size resource.Quantity, reqLogger logr.Logger) (*mmv1beta.VolumeResizeStatus, error) {
+	requested := claim.Spec.Resources.Requests[corev1.ResourceStorage]
+	capacity := claim.Status.Capacity[corev1.ResourceStorage]
+
+	status := &mmv1beta.VolumeResizeStatus{
+		Name:          claim.Name,
+		RequestedSize: size.String(),
+		Capacity:      capacity.String(),
+		State:         mmv1beta.VolumeResizing,
+	}
+
+	if size.Cmp(requested) < 0 {
+		reqLogger.Info("Volumes cannot be shrunk, ignoring the requested storage size", "name", claim.Name, "size", size.String())
+	}
+	if size.Cmp(requested) <= 0 {
+		if capacity.Cmp(requested) < 0 {
+			// Expansion requested earlier is still in progress.
+			status.RequestedSize = requested.String()
+			return status, nil
+		}
+		return nil, nil
+	}
+
+	allowed, err := r.volumeExpansionAllowed(claim)
+	if err != nil {
+		return nil, err
+	}
+	if !allowed {
+		reqLogger.Info("StorageClass of the volume does not allow volume expansion", "name", claim.Name)
+		status.State = mmv1beta.VolumeResizeUnsupported
+		return status, nil
+	}
+
+	reqLogger.Info("Expanding volume", "name", claim.Name, "from", requested.String(), "to", size.String())
+	if claim.Spec.Resources.Requests == nil {
+		claim.Spec.Resources.Requests = corev1.ResourceList{}
+	}
+	claim.Spec.Resources.Requests[corev1.ResourceStorage] = size
+	err = r.client.Update(context.TODO(), claim)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to update PersistentVolumeClaim")
+	}
+
+	return status, nil
+}
+
+func (r *ResourceHelper) volumeExpansionAllowed(claim *corev1.PersistentVolumeClaim) (bool, error) {
+	if claim.Spec.StorageClassName == nil || *claim.Spec.StorageClassName == "" {
+		return false, nil
+	}
+
+	storageClass := &storagev1.StorageClass{}
+	err := r.client.Get(context.TODO(), types.NamespacedName{Name: *claim.Spec.StorageClassName}, storageClass)
+	if err != nil {
+		return false, errors.Wrap(err, "failed to get StorageClass")
+	}
+
+	return storageClass.AllowVolumeExpansion != nil && *storageClass.AllowVolumeExpansion, nil
+}
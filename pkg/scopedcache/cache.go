@@ -0,0 +1,168 @@
+// Package scopedcache restricts the cache of the manager to the objects
+// carrying a label for some types, ie the Secrets of the cluster.
+package scopedcache
+
+import (
+	"context"
+	"net/http"
+	"strings"
+
+	"github.com/pkg/errors"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/client-go/rest"
+	"k8s.io/client-go/transport"
+	"sigs.k8s.io/controller-runtime/pkg/cache"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/apiutil"
+)
+
+// NewCacheFunc returns a function creating a cache which only caches the
+// objects of the given types matching the label selector, ie a label key.
+// Other types are cached entirely.
+//
+// Objects of the scoped types which do not match the selector are not found
+// in the cache, they must be read with the API reader or a client bypassing
+// the cache for these types.
+func NewCacheFunc(selector string, objs ...client.Object) cache.NewCacheFunc {
+	return NewCacheFuncFrom(cache.New, selector, objs...)
+}
+
+// NewCacheFuncFrom is like NewCacheFunc, the underlying caches being created
+// with newCache, ie to restrict them to some namespaces.
+func NewCacheFuncFrom(newCache cache.NewCacheFunc, selector string, objs ...client.Object) cache.NewCacheFunc {
+	return func(config *rest.Config, opts cache.Options) (cache.Cache, error) {
+		if opts.Scheme == nil {
+			return nil, errors.New("the scheme of the scoped cache is not set")
+		}
+
+		kinds := map[schema.GroupVersionKind]bool{}
+		for _, obj := range objs {
+			gvk, err := apiutil.GVKForObject(obj, opts.Scheme)
+			if err != nil {
+				return nil, errors.Wrap(err, "failed to get the kind of a scoped object")
+			}
+			kinds[gvk] = true
+		}
+
+		defaultCache, err := newCache(config, opts)
+		if err != nil {
+			return nil, err
+		}
+
+		// The informers of the scoped cache only list and watch, the label
+		// selector is therefore added to all its requests.
+		scopedConfig := rest.CopyConfig(config)
+		scopedConfig.WrapTransport = transport.Wrappers(config.WrapTransport, func(rt http.RoundTripper) http.RoundTripper {
+			return &selectorRoundTripper{selector: selector, delegate: rt}
+		})
+		labelCache, err := newCache(scopedConfig, opts)
+		if err != nil {
+			return nil, err
+		}
+
+		return &scopedCache{Cache: defaultCache, scoped: labelCache, kinds: kinds, scheme: opts.Scheme}, nil
+	}
+}
+
+// selectorRoundTripper adds the label selector to the requests.
+type selectorRoundTripper struct {
+	selector string
+	delegate http.RoundTripper
+}
+
+func (rt *selectorRoundTripper) RoundTrip(req *http.Request) (*http.Response, error) {
+	req = req.Clone(req.Context())
+	query := req.URL.Query()
+	selector := rt.selector
+	if current := query.Get("labelSelector"); current != "" {
+		selector = current + "," + selector
+	}
+	query.Set("labelSelector", selector)
+	req.URL.RawQuery = query.Encode()
+	return rt.delegate.RoundTrip(req)
+}
+
+// scopedCache serves the scoped types from the scoped cache and the other
+// types from the default cache.
+type scopedCache struct {
+	cache.Cache
+	scoped cache.Cache
+	kinds  map[schema.GroupVersionKind]bool
+	scheme *runtime.Scheme
+}
+
+var _ cache.Cache = &scopedCache{}
+
+func (c *scopedCache) cacheFor(obj runtime.Object) (cache.Cache, error) {
+	gvk, err := apiutil.GVKForObject(obj, c.scheme)
+	if err != nil {
+		return nil, err
+	}
+	return c.cacheForKind(gvk), nil
+}
+
+func (c *scopedCache) cacheForKind(gvk schema.GroupVersionKind) cache.Cache {
+	gvk.Kind = strings.TrimSuffix(gvk.Kind, "List")
+	if c.kinds[gvk] {
+		return c.scoped
+	}
+	return c.Cache
+}
+
+func (c *scopedCache) Get(ctx context.Context, key client.ObjectKey, obj client.Object) error {
+	target, err := c.cacheFor(obj)
+	if err != nil {
+		return err
+	}
+	return target.Get(ctx, key, obj)
+}
+
+func (c *scopedCache) List(ctx context.Context, list client.ObjectList, opts ...client.ListOption) error {
+	target, err := c.cacheFor(list)
+	if err != nil {
+		return err
+	}
+	return target.List(ctx, list, opts...)
+}
+
+func (c *scopedCache) GetInformer(ctx context.Context, obj client.Object) (cache.Informer, error) {
+	target, err := c.cacheFor(obj)
+	if err != nil {
+		return nil, err
+	}
+	return target.GetInformer(ctx, obj)
+}
+
+func (c *scopedCache) GetInformerForKind(ctx context.Context, gvk schema.GroupVersionKind) (cache.Informer, error) {
+	return c.cacheForKind(gvk).GetInformerForKind(ctx, gvk)
+}
+
+func (c *scopedCache) IndexField(ctx context.Context, obj client.Object, field string, extractValue client.IndexerFunc) error {
+	target, err := c.cacheFor(obj)
+	if err != nil {
+		return err
+	}
+	return target.IndexField(ctx, obj, field, extractValue)
+}
+
+// Start starts both caches, blocking until the context is done.
+func (c *scopedCache) Start(ctx context.Context) error {
+	errs := make(chan error, 2)
+	for _, target := range []cache.Cache{c.Cache, c.scoped} {
+		go func(target cache.Cache) {
+			errs <- target.Start(ctx)
+		}(target)
+	}
+
+	for i := 0; i < 2; i++ {
+		if err := <-errs; err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (c *scopedCache) WaitForCacheSync(ctx context.Context) bool {
+	return c.Cache.WaitForCacheSync(ctx) && c.scoped.WaitForCacheSync(ctx)
+}
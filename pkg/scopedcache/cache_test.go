@@ -0,0 +1,128 @@
+package scopedcache
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/meta"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/client-go/kubernetes/scheme"
+	"k8s.io/client-go/rest"
+	"sigs.k8s.io/controller-runtime/pkg/cache"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+const selector = "installation.mattermost.com/resource"
+
+// fakeCache records the objects it was asked for.
+type fakeCache struct {
+	cache.Cache
+	name string
+	got  *[]string
+}
+
+func (c *fakeCache) Get(_ context.Context, key client.ObjectKey, _ client.Object) error {
+	*c.got = append(*c.got, c.name+"/"+key.Name)
+	return nil
+}
+
+func (c *fakeCache) List(_ context.Context, _ client.ObjectList, _ ...client.ListOption) error {
+	*c.got = append(*c.got, c.name+"/list")
+	return nil
+}
+
+func TestScopedCacheRouting(t *testing.T) {
+	var got []string
+	c := &scopedCache{
+		Cache:  &fakeCache{name: "default", got: &got},
+		scoped: &fakeCache{name: "scoped", got: &got},
+		kinds:  map[schema.GroupVersionKind]bool{{Version: "v1", Kind: "Secret"}: true},
+		scheme: scheme.Scheme,
+	}
+
+	require.NoError(t, c.Get(context.Background(), client.ObjectKey{Name: "secret"}, &corev1.Secret{}))
+	require.NoError(t, c.Get(context.Background(), client.ObjectKey{Name: "configmap"}, &corev1.ConfigMap{}))
+	require.NoError(t, c.List(context.Background(), &corev1.SecretList{}))
+	require.NoError(t, c.List(context.Background(), &appsv1.DeploymentList{}))
+	assert.Equal(t, []string{"scoped/secret", "default/configmap", "scoped/list", "default/list"}, got)
+}
+
+func TestNewCacheFunc(t *testing.T) {
+	var mu sync.Mutex
+	selectors := map[string][]string{}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		selectors[r.URL.Path] = append(selectors[r.URL.Path], r.URL.Query().Get("labelSelector"))
+		mu.Unlock()
+
+		w.Header().Set("Content-Type", "application/json")
+		if r.URL.Query().Get("watch") == "true" {
+			// The watch ends without events, the reflector watches again.
+			return
+		}
+		kind := "SecretList"
+		if r.URL.Path == "/api/v1/configmaps" {
+			kind = "ConfigMapList"
+		}
+		_, _ = w.Write([]byte(`{"kind":"` + kind + `","apiVersion":"v1","metadata":{"resourceVersion":"1"},"items":[]}`))
+	}))
+	defer server.Close()
+
+	mapper := meta.NewDefaultRESTMapper(nil)
+	mapper.Add(schema.GroupVersionKind{Version: "v1", Kind: "Secret"}, meta.RESTScopeNamespace)
+	mapper.Add(schema.GroupVersionKind{Version: "v1", Kind: "ConfigMap"}, meta.RESTScopeNamespace)
+
+	newCache := NewCacheFunc(selector, &corev1.Secret{})
+	c, err := newCache(&rest.Config{Host: server.URL}, cache.Options{Scheme: scheme.Scheme, Mapper: mapper})
+	require.NoError(t, err)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	_, err = c.GetInformer(ctx, &corev1.Secret{})
+	require.NoError(t, err)
+	_, err = c.GetInformer(ctx, &corev1.ConfigMap{})
+	require.NoError(t, err)
+	go func() {
+		_ = c.Start(ctx)
+	}()
+	require.True(t, c.WaitForCacheSync(ctx))
+
+	require.Eventually(t, func() bool {
+		mu.Lock()
+		defer mu.Unlock()
+		return len(selectors["/api/v1/secrets"]) > 0 && len(selectors["/api/v1/configmaps"]) > 0
+	}, 5*time.Second, 50*time.Millisecond)
+
+	mu.Lock()
+	defer mu.Unlock()
+	assert.Equal(t, selector, selectors["/api/v1/secrets"][0])
+	assert.Empty(t, selectors["/api/v1/configmaps"][0])
+}
+
+func TestSelectorRoundTripper(t *testing.T) {
+	var query string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		query = r.URL.Query().Get("labelSelector")
+	}))
+	defer server.Close()
+
+	client := &http.Client{Transport: &selectorRoundTripper{selector: selector, delegate: http.DefaultTransport}}
+
+	resp, err := client.Get(server.URL + "/api/v1/secrets?labelSelector=app%3Dmattermost")
+	require.NoError(t, err)
+	resp.Body.Close()
+	assert.Equal(t, "app=mattermost,"+selector, query)
+
+	resp, err = client.Get(server.URL + "/api/v1/secrets")
+	require.NoError(t, err)
+	resp.Body.Close()
+	assert.Equal(t, selector, query)
+}
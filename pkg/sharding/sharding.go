@@ -0,0 +1,54 @@
+// Package sharding spreads the installations among the replicas of the
+// operator, each replica only reconciling the installations of its shard.
+package sharding
+
+import (
+	"hash/fnv"
+	"strconv"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/predicate"
+)
+
+// ShardLabel assigns an installation to a shard explicitly, ie "2". The
+// installations without a valid shard label are assigned by the hash of
+// their namespace and name.
+const ShardLabel = "mattermost.com/shard"
+
+// Shard is the shard of a replica of the operator, from 0 to Count-1. The
+// zero Shard, as any Shard with a Count of 1 or less, owns all the
+// installations.
+type Shard struct {
+	ID    int
+	Count int
+}
+
+// Owns returns whether the object belongs to the shard.
+func (s Shard) Owns(obj metav1.Object) bool {
+	if s.Count <= 1 {
+		return true
+	}
+	return s.Of(obj) == s.ID
+}
+
+// Of returns the shard of the object: the one of its shard label if it is in
+// the range of the shards, the hash of its namespace and name otherwise.
+func (s Shard) Of(obj metav1.Object) int {
+	if s.Count <= 1 {
+		return 0
+	}
+	if id, err := strconv.Atoi(obj.GetLabels()[ShardLabel]); err == nil && id >= 0 && id < s.Count {
+		return id
+	}
+	hash := fnv.New32a()
+	_, _ = hash.Write([]byte(obj.GetNamespace() + "/" + obj.GetName()))
+	return int(hash.Sum32() % uint32(s.Count))
+}
+
+// Predicate filters out the events of the objects of other shards.
+func (s Shard) Predicate() predicate.Predicate {
+	return predicate.NewPredicateFuncs(func(obj client.Object) bool {
+		return s.Owns(obj)
+	})
+}
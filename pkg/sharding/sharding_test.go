@@ -0,0 +1,67 @@
+package sharding
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"sigs.k8s.io/controller-runtime/pkg/event"
+)
+
+func TestShard(t *testing.T) {
+	t.Run("single shard", func(t *testing.T) {
+		obj := &metav1.ObjectMeta{Namespace: "ns", Name: "mm", Labels: map[string]string{ShardLabel: "3"}}
+		assert.True(t, Shard{}.Owns(obj))
+		assert.True(t, Shard{ID: 0, Count: 1}.Owns(obj))
+	})
+
+	t.Run("hashed installations", func(t *testing.T) {
+		shards := []Shard{{ID: 0, Count: 3}, {ID: 1, Count: 3}, {ID: 2, Count: 3}}
+		counts := make([]int, 3)
+		for i := 0; i < 300; i++ {
+			obj := &metav1.ObjectMeta{Namespace: "ns", Name: fmt.Sprintf("mm-%d", i)}
+			owners := 0
+			for _, shard := range shards {
+				if shard.Owns(obj) {
+					owners++
+					counts[shard.ID]++
+				}
+			}
+			assert.Equal(t, 1, owners, obj.Name)
+			// The shard of an installation is stable.
+			assert.Equal(t, shards[0].Of(obj), shards[1].Of(obj))
+		}
+		for _, count := range counts {
+			assert.NotZero(t, count)
+		}
+	})
+
+	t.Run("shard label", func(t *testing.T) {
+		shard := Shard{ID: 2, Count: 3}
+		obj := &metav1.ObjectMeta{Namespace: "ns", Name: "mm", Labels: map[string]string{ShardLabel: "2"}}
+		assert.True(t, shard.Owns(obj))
+
+		obj.Labels[ShardLabel] = "1"
+		assert.False(t, shard.Owns(obj))
+
+		// Invalid labels fall back to the hash.
+		hashed := &metav1.ObjectMeta{Namespace: "ns", Name: "mm"}
+		for _, label := range []string{"3", "-1", "first"} {
+			obj.Labels[ShardLabel] = label
+			assert.Equal(t, shard.Of(hashed), shard.Of(obj), label)
+		}
+	})
+
+	t.Run("predicate", func(t *testing.T) {
+		shard := Shard{ID: 1, Count: 2}
+		owned := &metav1.PartialObjectMetadata{ObjectMeta: metav1.ObjectMeta{Name: "mm", Labels: map[string]string{ShardLabel: "1"}}}
+		other := &metav1.PartialObjectMetadata{ObjectMeta: metav1.ObjectMeta{Name: "mm", Labels: map[string]string{ShardLabel: "0"}}}
+
+		p := shard.Predicate()
+		assert.True(t, p.Create(event.CreateEvent{Object: owned}))
+		assert.False(t, p.Create(event.CreateEvent{Object: other}))
+		assert.True(t, p.Update(event.UpdateEvent{ObjectOld: other, ObjectNew: owned}))
+		assert.False(t, p.Update(event.UpdateEvent{ObjectOld: owned, ObjectNew: other}))
+	})
+}
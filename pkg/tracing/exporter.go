@@ -0,0 +1,288 @@
+package tracing
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/go-logr/logr"
+	"github.com/pkg/errors"
+	"github.com/prometheus/client_golang/prometheus"
+	"sigs.k8s.io/controller-runtime/pkg/metrics"
+)
+
+const (
+	// maxQueueSize is the number of finished spans kept until they are
+	// exported, newer spans are dropped once it is reached.
+	maxQueueSize = 2048
+	// maxBatchSize is the maximum number of spans exported at once.
+	maxBatchSize = 512
+
+	// OTLP span kind and status codes.
+	spanKindInternal = 1
+	statusCodeError  = 2
+
+	droppedReasonQueueFull    = "queue_full"
+	droppedReasonExportFailed = "export_failed"
+)
+
+var droppedSpansCounter = prometheus.NewCounterVec(
+	prometheus.CounterOpts{
+		Name: "mattermost_operator_tracing_dropped_spans_total",
+		Help: "Number of spans dropped without being exported, by reason.",
+	},
+	[]string{"reason"},
+)
+
+func init() {
+	metrics.Registry.MustRegister(droppedSpansCounter)
+}
+
+// Tracer exports the finished spans in batches to the traces endpoint of an
+// OTLP/HTTP collector, encoded in JSON.
+//
+// It is a minimal exporter rather than the OpenTelemetry SDK: it only speaks
+// OTLP/HTTP with JSON, and does not retry. Spans are dropped when the queue is
+// full or their export fails, and counted by the
+// mattermost_operator_tracing_dropped_spans_total metric.
+type Tracer struct {
+	// Endpoint is the base URL of the collector, the spans are sent to its
+	// /v1/traces path.
+	Endpoint string
+	// Headers are sent with every export, ie to authenticate.
+	Headers     map[string]string
+	ServiceName string
+	HTTPClient  *http.Client
+	// FlushInterval is how often the queued spans are exported.
+	FlushInterval time.Duration
+	Log           logr.Logger
+
+	mu    sync.Mutex
+	queue []*Span
+}
+
+// NewTracer returns a Tracer exporting the spans of the service to the
+// collector every five seconds.
+func NewTracer(endpoint string, headers map[string]string, serviceName string, log logr.Logger) *Tracer {
+	return &Tracer{
+		Endpoint:      endpoint,
+		Headers:       headers,
+		ServiceName:   serviceName,
+		HTTPClient:    &http.Client{Timeout: 10 * time.Second},
+		FlushInterval: 5 * time.Second,
+		Log:           log,
+	}
+}
+
+// ParseHeaders parses headers in the comma separated key=value format of
+// OTEL_EXPORTER_OTLP_HEADERS.
+func ParseHeaders(value string) (map[string]string, error) {
+	headers := map[string]string{}
+	for _, pair := range strings.Split(value, ",") {
+		if strings.TrimSpace(pair) == "" {
+			continue
+		}
+		parts := strings.SplitN(pair, "=", 2)
+		if len(parts) != 2 || strings.TrimSpace(parts[0]) == "" {
+			return nil, fmt.Errorf("invalid header %q, expected key=value", pair)
+		}
+		headers[strings.TrimSpace(parts[0])] = strings.TrimSpace(parts[1])
+	}
+	return headers, nil
+}
+
+// Start exports the queued spans every flush interval until the context is
+// done, then exports the remaining spans.
+func (t *Tracer) Start(ctx context.Context) error {
+	ticker := time.NewTicker(t.FlushInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			flushCtx, cancel := context.WithTimeout(context.Background(), t.HTTPClient.Timeout)
+			defer cancel()
+			t.flush(flushCtx)
+			return nil
+		case <-ticker.C:
+			t.flush(ctx)
+		}
+	}
+}
+
+// NeedLeaderElection implements manager.LeaderElectionRunnable. Every
+// replica exports its own spans.
+func (t *Tracer) NeedLeaderElection() bool {
+	return false
+}
+
+func (t *Tracer) enqueue(span *Span) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	if len(t.queue) >= maxQueueSize {
+		droppedSpansCounter.WithLabelValues(droppedReasonQueueFull).Inc()
+		return
+	}
+	t.queue = append(t.queue, span)
+}
+
+// flush exports the queued spans in batches. Batches failing to export are
+// dropped.
+func (t *Tracer) flush(ctx context.Context) {
+	t.mu.Lock()
+	spans := t.queue
+	t.queue = nil
+	t.mu.Unlock()
+
+	for len(spans) > 0 {
+		batch := spans
+		if len(batch) > maxBatchSize {
+			batch = batch[:maxBatchSize]
+		}
+		spans = spans[len(batch):]
+
+		err := t.Export(ctx, batch)
+		if err != nil {
+			t.Log.Error(err, "Failed to export spans", "spans", len(batch))
+			droppedSpansCounter.WithLabelValues(droppedReasonExportFailed).Add(float64(len(batch)))
+		}
+	}
+}
+
+// Export sends the spans to the collector.
+func (t *Tracer) Export(ctx context.Context, spans []*Span) error {
+	data, err := json.Marshal(t.request(spans))
+	if err != nil {
+		return errors.Wrap(err, "failed to encode spans")
+	}
+
+	endpoint := strings.TrimSuffix(t.Endpoint, "/") + "/v1/traces"
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, endpoint, bytes.NewReader(data))
+	if err != nil {
+		return errors.Wrap(err, "failed to create export request")
+	}
+	req.Header.Set("Content-Type", "application/json")
+	for key, value := range t.Headers {
+		req.Header.Set(key, value)
+	}
+
+	resp, err := t.HTTPClient.Do(req)
+	if err != nil {
+		return errors.Wrap(err, "failed to send spans")
+	}
+	defer resp.Body.Close()
+	_, _ = io.Copy(ioutil.Discard, resp.Body)
+
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return fmt.Errorf("collector responded with status code %d", resp.StatusCode)
+	}
+	return nil
+}
+
+// The OTLP JSON encoding of the spans. IDs are hex encoded and 64 bit
+// integers are strings.
+type exportRequest struct {
+	ResourceSpans []resourceSpans `json:"resourceSpans"`
+}
+
+type resourceSpans struct {
+	Resource   resource     `json:"resource"`
+	ScopeSpans []scopeSpans `json:"scopeSpans"`
+}
+
+type resource struct {
+	Attributes []keyValue `json:"attributes"`
+}
+
+type scopeSpans struct {
+	Scope scope      `json:"scope"`
+	Spans []spanData `json:"spans"`
+}
+
+type scope struct {
+	Name string `json:"name"`
+}
+
+type spanData struct {
+	TraceID           string     `json:"traceId"`
+	SpanID            string     `json:"spanId"`
+	ParentSpanID      string     `json:"parentSpanId,omitempty"`
+	Name              string     `json:"name"`
+	Kind              int        `json:"kind"`
+	StartTimeUnixNano string     `json:"startTimeUnixNano"`
+	EndTimeUnixNano   string     `json:"endTimeUnixNano"`
+	Attributes        []keyValue `json:"attributes,omitempty"`
+	Status            *status    `json:"status,omitempty"`
+}
+
+type keyValue struct {
+	Key   string         `json:"key"`
+	Value attributeValue `json:"value"`
+}
+
+type attributeValue struct {
+	StringValue *string `json:"stringValue,omitempty"`
+	IntValue    *string `json:"intValue,omitempty"`
+	BoolValue   *bool   `json:"boolValue,omitempty"`
+}
+
+type status struct {
+	Code    int    `json:"code"`
+	Message string `json:"message,omitempty"`
+}
+
+func (t *Tracer) request(spans []*Span) exportRequest {
+	data := make([]spanData, 0, len(spans))
+	for _, span := range spans {
+		span.mu.Lock()
+		item := spanData{
+			TraceID:           span.TraceID,
+			SpanID:            span.SpanID,
+			ParentSpanID:      span.ParentID,
+			Name:              span.Name,
+			Kind:              spanKindInternal,
+			StartTimeUnixNano: strconv.FormatInt(span.Start.UnixNano(), 10),
+			EndTimeUnixNano:   strconv.FormatInt(span.End.UnixNano(), 10),
+			Attributes:        keyValues(span.Attributes),
+		}
+		if span.Err != nil {
+			item.Status = &status{Code: statusCodeError, Message: span.Err.Error()}
+		}
+		span.mu.Unlock()
+		data = append(data, item)
+	}
+
+	return exportRequest{ResourceSpans: []resourceSpans{{
+		Resource:   resource{Attributes: keyValues([]Attribute{String("service.name", t.ServiceName)})},
+		ScopeSpans: []scopeSpans{{Scope: scope{Name: t.ServiceName}, Spans: data}},
+	}}}
+}
+
+func keyValues(attributes []Attribute) []keyValue {
+	values := make([]keyValue, 0, len(attributes))
+	for _, attribute := range attributes {
+		value := attributeValue{}
+		switch v := attribute.Value.(type) {
+		case string:
+			value.StringValue = &v
+		case int64:
+			s := strconv.FormatInt(v, 10)
+			value.IntValue = &s
+		case bool:
+			value.BoolValue = &v
+		default:
+			s := fmt.Sprint(v)
+			value.StringValue = &s
+		}
+		values = append(values, keyValue{Key: attribute.Key, Value: value})
+	}
+	return values
+}
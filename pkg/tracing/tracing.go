@@ -0,0 +1,140 @@
+// Package tracing records the spans of the reconciliations and exports them
+// to an OpenTelemetry collector with a minimal OTLP/HTTP JSON exporter.
+package tracing
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"sync"
+	"time"
+)
+
+type spanContextKey struct{}
+
+var (
+	tracerMu sync.RWMutex
+	tracer   *Tracer
+)
+
+// SetTracer sets the tracer the spans are recorded with, nil disables
+// tracing.
+func SetTracer(t *Tracer) {
+	tracerMu.Lock()
+	defer tracerMu.Unlock()
+	tracer = t
+}
+
+func currentTracer() *Tracer {
+	tracerMu.RLock()
+	defer tracerMu.RUnlock()
+	return tracer
+}
+
+// Attribute is a key value pair describing a span. Values are strings,
+// int64 or booleans.
+type Attribute struct {
+	Key   string
+	Value interface{}
+}
+
+// String returns a string attribute.
+func String(key, value string) Attribute {
+	return Attribute{Key: key, Value: value}
+}
+
+// Int returns an integer attribute.
+func Int(key string, value int64) Attribute {
+	return Attribute{Key: key, Value: value}
+}
+
+// Bool returns a boolean attribute.
+func Bool(key string, value bool) Attribute {
+	return Attribute{Key: key, Value: value}
+}
+
+// Span is an operation of a trace. The methods of a nil Span, returned
+// while tracing is disabled, do nothing.
+type Span struct {
+	tracer *Tracer
+
+	TraceID    string
+	SpanID     string
+	ParentID   string
+	Name       string
+	Start      time.Time
+	End        time.Time
+	Attributes []Attribute
+	Err        error
+
+	mu    sync.Mutex
+	ended bool
+}
+
+// Start starts a span, the child of the span of the context if any. The
+// returned context holds the new span.
+func Start(ctx context.Context, name string, attributes ...Attribute) (context.Context, *Span) {
+	t := currentTracer()
+	if t == nil {
+		return ctx, nil
+	}
+
+	span := &Span{
+		tracer:     t,
+		SpanID:     randomID(8),
+		Name:       name,
+		Start:      time.Now(),
+		Attributes: attributes,
+	}
+	if parent := FromContext(ctx); parent != nil {
+		span.TraceID = parent.TraceID
+		span.ParentID = parent.SpanID
+	} else {
+		span.TraceID = randomID(16)
+	}
+
+	return context.WithValue(ctx, spanContextKey{}, span), span
+}
+
+// FromContext returns the span of the context, nil if there is none.
+func FromContext(ctx context.Context) *Span {
+	span, _ := ctx.Value(spanContextKey{}).(*Span)
+	return span
+}
+
+// SetAttributes adds attributes to the span.
+func (s *Span) SetAttributes(attributes ...Attribute) {
+	if s == nil {
+		return
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.Attributes = append(s.Attributes, attributes...)
+}
+
+// Finish ends the span, failed with the error if it is not nil, and queues
+// it for export. Only the first call has an effect.
+func (s *Span) Finish(err error) {
+	if s == nil {
+		return
+	}
+	s.mu.Lock()
+	if s.ended {
+		s.mu.Unlock()
+		return
+	}
+	s.ended = true
+	s.End = time.Now()
+	s.Err = err
+	s.mu.Unlock()
+
+	s.tracer.enqueue(s)
+}
+
+func randomID(size int) string {
+	id := make([]byte, size)
+	// crypto/rand does not fail on the supported platforms, an ID of zeros
+	// is only rejected by the collector.
+	_, _ = rand.Read(id)
+	return hex.EncodeToString(id)
+}
@@ -0,0 +1,141 @@
+package tracing
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	blubr "github.com/mattermost/blubr"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"sigs.k8s.io/controller-runtime/pkg/metrics"
+)
+
+func TestStartDisabled(t *testing.T) {
+	SetTracer(nil)
+
+	ctx, span := Start(context.Background(), "Reconcile")
+	assert.Nil(t, span)
+	assert.Nil(t, FromContext(ctx))
+
+	// The methods of the nil span do nothing.
+	span.SetAttributes(String("key", "value"))
+	span.Finish(errors.New("failed"))
+}
+
+func TestTracer(t *testing.T) {
+	var requests []exportRequest
+	collector := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "/v1/traces", r.URL.Path)
+		assert.Equal(t, "application/json", r.Header.Get("Content-Type"))
+		assert.Equal(t, "Bearer token", r.Header.Get("Authorization"))
+
+		data, err := ioutil.ReadAll(r.Body)
+		require.NoError(t, err)
+		request := exportRequest{}
+		require.NoError(t, json.Unmarshal(data, &request))
+		requests = append(requests, request)
+	}))
+	defer collector.Close()
+
+	headers, err := ParseHeaders("Authorization=Bearer token, ")
+	require.NoError(t, err)
+	tracer := NewTracer(collector.URL+"/", headers, "mattermost-operator", blubr.InitLogger())
+	SetTracer(tracer)
+	defer SetTracer(nil)
+
+	ctx, root := Start(context.Background(), "Reconcile", String("mattermost.name", "mm"))
+	require.NotNil(t, root)
+	assert.Same(t, root, FromContext(ctx))
+	_, step := Start(ctx, "CheckDatabase", Bool("external", true))
+	step.Finish(errors.New("database not ready"))
+	step.Finish(nil)
+	root.SetAttributes(Int("attempt", 2))
+	root.Finish(nil)
+
+	tracer.flush(context.Background())
+	tracer.flush(context.Background())
+	require.Len(t, requests, 1)
+
+	resourceSpans := requests[0].ResourceSpans
+	require.Len(t, resourceSpans, 1)
+	assert.Equal(t, "service.name", resourceSpans[0].Resource.Attributes[0].Key)
+	assert.Equal(t, "mattermost-operator", *resourceSpans[0].Resource.Attributes[0].Value.StringValue)
+	spans := resourceSpans[0].ScopeSpans[0].Spans
+	require.Len(t, spans, 2)
+
+	child, parent := spans[0], spans[1]
+	assert.Equal(t, "CheckDatabase", child.Name)
+	assert.Equal(t, "Reconcile", parent.Name)
+	assert.Len(t, parent.TraceID, 32)
+	assert.Len(t, parent.SpanID, 16)
+	assert.Equal(t, parent.TraceID, child.TraceID)
+	assert.Equal(t, parent.SpanID, child.ParentSpanID)
+	assert.Empty(t, parent.ParentSpanID)
+
+	require.NotNil(t, child.Status)
+	assert.Equal(t, statusCodeError, child.Status.Code)
+	assert.Equal(t, "database not ready", child.Status.Message)
+	assert.Nil(t, parent.Status)
+	assert.True(t, *child.Attributes[0].Value.BoolValue)
+	assert.Equal(t, "mm", *parent.Attributes[0].Value.StringValue)
+	assert.Equal(t, "2", *parent.Attributes[1].Value.IntValue)
+	assert.NotEmpty(t, parent.StartTimeUnixNano)
+	assert.NotEmpty(t, parent.EndTimeUnixNano)
+}
+
+func TestParseHeaders(t *testing.T) {
+	headers, err := ParseHeaders("")
+	require.NoError(t, err)
+	assert.Empty(t, headers)
+
+	headers, err = ParseHeaders("api-key=secret,x-tenant = mattermost")
+	require.NoError(t, err)
+	assert.Equal(t, map[string]string{"api-key": "secret", "x-tenant": "mattermost"}, headers)
+
+	_, err = ParseHeaders("api-key")
+	require.Error(t, err)
+}
+
+func TestDroppedSpans(t *testing.T) {
+	dropped := func(t *testing.T, reason string) float64 {
+		families, err := metrics.Registry.Gather()
+		require.NoError(t, err)
+		for _, family := range families {
+			if family.GetName() != "mattermost_operator_tracing_dropped_spans_total" {
+				continue
+			}
+			for _, metric := range family.GetMetric() {
+				if metric.GetLabel()[0].GetValue() == reason {
+					return metric.GetCounter().GetValue()
+				}
+			}
+		}
+		return 0
+	}
+
+	collector := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusServiceUnavailable)
+	}))
+	defer collector.Close()
+
+	tracer := NewTracer(collector.URL, nil, "mattermost-operator", blubr.InitLogger())
+	SetTracer(tracer)
+	defer SetTracer(nil)
+
+	queueFull := dropped(t, droppedReasonQueueFull)
+	exportFailed := dropped(t, droppedReasonExportFailed)
+
+	for i := 0; i < maxQueueSize+1; i++ {
+		_, span := Start(context.Background(), "Reconcile")
+		span.Finish(nil)
+	}
+	assert.Equal(t, queueFull+1, dropped(t, droppedReasonQueueFull))
+
+	tracer.flush(context.Background())
+	assert.Equal(t, exportFailed+maxQueueSize, dropped(t, droppedReasonExportFailed))
+}
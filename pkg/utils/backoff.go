@@ -0,0 +1,45 @@
+package utils
+
+import (
+	"time"
+
+	"golang.org/x/time/rate"
+	"k8s.io/apimachinery/pkg/util/wait"
+	"k8s.io/client-go/util/workqueue"
+	"sigs.k8s.io/controller-runtime/pkg/ratelimiter"
+)
+
+// NewRateLimiter returns the rate limiter of the controllers with the
+// failures of an object requeued with an exponential backoff, from the base
+// delay up to the max delay. The delays are raised by up to the jitter
+// factor, ie 0.1, to spread the requeues of objects failing together. The
+// requeues of all the objects are limited as with the default rate limiter.
+func NewRateLimiter(base, maxDelay time.Duration, jitter float64) ratelimiter.RateLimiter {
+	return workqueue.NewMaxOfRateLimiter(
+		&jitterRateLimiter{
+			RateLimiter: workqueue.NewItemExponentialFailureRateLimiter(base, maxDelay),
+			maxDelay:    maxDelay,
+			jitter:      jitter,
+		},
+		&workqueue.BucketRateLimiter{Limiter: rate.NewLimiter(rate.Limit(10), 100)},
+	)
+}
+
+// jitterRateLimiter adds a jitter to the delays of the rate limiter.
+type jitterRateLimiter struct {
+	workqueue.RateLimiter
+	maxDelay time.Duration
+	jitter   float64
+}
+
+func (r *jitterRateLimiter) When(item interface{}) time.Duration {
+	delay := r.RateLimiter.When(item)
+	if r.jitter <= 0 {
+		return delay
+	}
+	delay = wait.Jitter(delay, r.jitter)
+	if delay > r.maxDelay {
+		return r.maxDelay
+	}
+	return delay
+}
@@ -0,0 +1,35 @@
+package utils
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestNewRateLimiter(t *testing.T) {
+	t.Run("exponential backoff", func(t *testing.T) {
+		limiter := NewRateLimiter(time.Second, 5*time.Second, 0)
+		for _, expected := range []time.Duration{time.Second, 2 * time.Second, 4 * time.Second, 5 * time.Second, 5 * time.Second} {
+			assert.Equal(t, expected, limiter.When("mm"))
+		}
+		assert.Equal(t, 5, limiter.NumRequeues("mm"))
+		assert.Equal(t, time.Second, limiter.When("other"))
+
+		limiter.Forget("mm")
+		assert.Equal(t, 0, limiter.NumRequeues("mm"))
+		assert.Equal(t, time.Second, limiter.When("mm"))
+	})
+
+	t.Run("jitter", func(t *testing.T) {
+		limiter := NewRateLimiter(time.Second, 3*time.Second, 0.5)
+		for _, base := range []time.Duration{time.Second, 2 * time.Second} {
+			delay := limiter.When("mm")
+			assert.True(t, delay >= base && delay <= base+base/2, delay)
+		}
+		// The jitter does not exceed the max delay.
+		for i := 0; i < 3; i++ {
+			assert.Equal(t, 3*time.Second, limiter.When("mm"))
+		}
+	})
+}
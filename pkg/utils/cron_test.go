@@ -0,0 +1,71 @@
+package utils
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseCronSchedule(t *testing.T) {
+	for _, tc := range []struct {
+		name     string
+		schedule string
+		valid    bool
+	}{
+		{name: "every minute", schedule: "* * * * *", valid: true},
+		{name: "lists, ranges and steps", schedule: "*/15 2-4,22 1-7 */2 6,0", valid: true},
+		{name: "sunday as 7", schedule: "* * * * 7", valid: true},
+		{name: "too few fields", schedule: "* * * *", valid: false},
+		{name: "minute out of range", schedule: "60 * * * *", valid: false},
+		{name: "day of month out of range", schedule: "* * 0 * *", valid: false},
+		{name: "reversed range", schedule: "* 4-2 * * *", valid: false},
+		{name: "invalid step", schedule: "*/0 * * * *", valid: false},
+		{name: "invalid value", schedule: "* * * jan *", valid: false},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			_, err := ParseCronSchedule(tc.schedule)
+			if tc.valid {
+				assert.NoError(t, err)
+			} else {
+				assert.Error(t, err)
+			}
+		})
+	}
+}
+
+func TestCronSchedule(t *testing.T) {
+	// Saturday and Sunday from 2:00 to 4:59.
+	schedule, err := ParseCronSchedule("* 2-4 * * 6,0")
+	require.NoError(t, err)
+
+	friday := time.Date(2021, time.June, 4, 3, 0, 0, 0, time.UTC)
+	saturday := time.Date(2021, time.June, 5, 3, 30, 0, 0, time.UTC)
+	sunday := time.Date(2021, time.June, 6, 4, 59, 0, 0, time.UTC)
+
+	assert.False(t, schedule.Matches(friday))
+	assert.True(t, schedule.Matches(saturday))
+	assert.True(t, schedule.Matches(sunday))
+	assert.False(t, schedule.Matches(sunday.Add(time.Minute)))
+
+	assert.Equal(t, time.Date(2021, time.June, 5, 2, 0, 0, 0, time.UTC), schedule.Next(friday))
+	assert.Equal(t, saturday.Add(time.Minute), schedule.Next(saturday))
+	assert.Equal(t, time.Date(2021, time.June, 12, 2, 0, 0, 0, time.UTC), schedule.Next(sunday))
+
+	t.Run("day of month or day of week", func(t *testing.T) {
+		// Midnight on the 1st of the month and on Mondays.
+		schedule, err := ParseCronSchedule("0 0 1 * 1")
+		require.NoError(t, err)
+
+		assert.Equal(t, time.Date(2021, time.June, 7, 0, 0, 0, 0, time.UTC), schedule.Next(friday))
+		assert.Equal(t, time.Date(2021, time.July, 1, 0, 0, 0, 0, time.UTC), schedule.Next(time.Date(2021, time.June, 28, 0, 0, 0, 0, time.UTC)))
+	})
+
+	t.Run("never matching", func(t *testing.T) {
+		schedule, err := ParseCronSchedule("0 0 31 2 *")
+		require.NoError(t, err)
+
+		assert.True(t, schedule.Next(friday).IsZero())
+	})
+}
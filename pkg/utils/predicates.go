@@ -0,0 +1,29 @@
+package utils
+
+import (
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/predicate"
+)
+
+// SpecChangedPredicate filters out the updates of an object not changing its
+// spec, labels or annotations, ie the updates of its status and the resyncs.
+func SpecChangedPredicate() predicate.Predicate {
+	return predicate.Or(
+		predicate.GenerationChangedPredicate{},
+		predicate.LabelChangedPredicate{},
+		predicate.AnnotationChangedPredicate{},
+	)
+}
+
+// OwnedResourcePredicate filters out the events of the owned resources not
+// carrying the label, and the resyncs of the resources. The status updates
+// of the resources are kept, ie the progress of a rollout.
+func OwnedResourcePredicate(label string) predicate.Predicate {
+	return predicate.And(
+		predicate.NewPredicateFuncs(func(obj client.Object) bool {
+			_, ok := obj.GetLabels()[label]
+			return ok
+		}),
+		predicate.ResourceVersionChangedPredicate{},
+	)
+}
@@ -0,0 +1,41 @@
+package utils
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	appsv1 "k8s.io/api/apps/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"sigs.k8s.io/controller-runtime/pkg/event"
+)
+
+func TestSpecChangedPredicate(t *testing.T) {
+	old := &appsv1.Deployment{ObjectMeta: metav1.ObjectMeta{Name: "mm", Generation: 1, ResourceVersion: "1"}}
+	changed := func(modify func(deployment *appsv1.Deployment)) bool {
+		deployment := old.DeepCopy()
+		deployment.ResourceVersion = "2"
+		modify(deployment)
+		return SpecChangedPredicate().Update(event.UpdateEvent{ObjectOld: old, ObjectNew: deployment})
+	}
+
+	assert.False(t, changed(func(d *appsv1.Deployment) {}), "resync")
+	assert.False(t, changed(func(d *appsv1.Deployment) { d.Status.ReadyReplicas = 1 }), "status")
+	assert.True(t, changed(func(d *appsv1.Deployment) { d.Generation = 2 }), "spec")
+	assert.True(t, changed(func(d *appsv1.Deployment) { d.Labels = map[string]string{"key": "value"} }), "labels")
+	assert.True(t, changed(func(d *appsv1.Deployment) { d.Annotations = map[string]string{"key": "value"} }), "annotations")
+}
+
+func TestOwnedResourcePredicate(t *testing.T) {
+	labeled := &appsv1.Deployment{ObjectMeta: metav1.ObjectMeta{Name: "mm", ResourceVersion: "1", Labels: map[string]string{"owner": "mm"}}}
+	unlabeled := &appsv1.Deployment{ObjectMeta: metav1.ObjectMeta{Name: "other", ResourceVersion: "1"}}
+	updated := labeled.DeepCopy()
+	updated.ResourceVersion = "2"
+	updated.Status.ReadyReplicas = 1
+
+	p := OwnedResourcePredicate("owner")
+	assert.True(t, p.Create(event.CreateEvent{Object: labeled}))
+	assert.False(t, p.Create(event.CreateEvent{Object: unlabeled}))
+	assert.True(t, p.Delete(event.DeleteEvent{Object: labeled}))
+	assert.True(t, p.Update(event.UpdateEvent{ObjectOld: labeled, ObjectNew: updated}), "status")
+	assert.False(t, p.Update(event.UpdateEvent{ObjectOld: labeled, ObjectNew: labeled.DeepCopy()}), "resync")
+}
@@ -0,0 +1,42 @@
+package utils
+
+import (
+	"context"
+	"reflect"
+
+	"github.com/pkg/errors"
+	"k8s.io/client-go/util/retry"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+// PatchStatus patches the status of the object with the changes made by the
+// mutate function. The merge patch carries the resourceVersion of the object,
+// so that the changes made to the status meanwhile, ie to its conditions, are
+// not overwritten: on conflicts, the object is fetched again and mutated
+// again, a few times.
+func PatchStatus(ctx context.Context, c client.Client, obj client.Object, mutate func()) error {
+	refetch := false
+	return retry.RetryOnConflict(retry.DefaultBackoff, func() error {
+		if refetch {
+			// The object is fetched in a new object as the fields missing
+			// from the response would keep their changed values.
+			fetched, ok := reflect.New(reflect.TypeOf(obj).Elem()).Interface().(client.Object)
+			if !ok {
+				return errors.New("failed to create the object")
+			}
+			err := c.Get(ctx, client.ObjectKeyFromObject(obj), fetched)
+			if err != nil {
+				return err
+			}
+			reflect.ValueOf(obj).Elem().Set(reflect.ValueOf(fetched).Elem())
+		}
+		refetch = true
+
+		base, ok := obj.DeepCopyObject().(client.Object)
+		if !ok {
+			return errors.New("failed to copy the object")
+		}
+		mutate()
+		return c.Status().Patch(ctx, obj, client.MergeFromWithOptions(base, client.MergeFromWithOptimisticLock{}))
+	})
+}
@@ -0,0 +1,88 @@
+package utils
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	corev1 "k8s.io/api/core/v1"
+	k8sErrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+)
+
+// conflictingClient fails the first status patches with a conflict.
+type conflictingClient struct {
+	client.Client
+	conflicts int
+}
+
+func (c *conflictingClient) Status() client.StatusWriter {
+	return &conflictingStatusWriter{StatusWriter: c.Client.Status(), client: c}
+}
+
+type conflictingStatusWriter struct {
+	client.StatusWriter
+	client *conflictingClient
+}
+
+func (w *conflictingStatusWriter) Patch(ctx context.Context, obj client.Object, patch client.Patch, opts ...client.PatchOption) error {
+	if w.client.conflicts > 0 {
+		w.client.conflicts--
+		return k8sErrors.NewConflict(schema.GroupResource{Resource: "pods"}, obj.GetName(), nil)
+	}
+	return w.StatusWriter.Patch(ctx, obj, patch, opts...)
+}
+
+func TestPatchStatus(t *testing.T) {
+	pod := &corev1.Pod{ObjectMeta: metav1.ObjectMeta{Name: "mm", Namespace: "ns"}}
+	key := types.NamespacedName{Name: "mm", Namespace: "ns"}
+
+	patchPhase := func(c client.Client, phase corev1.PodPhase) error {
+		current := &corev1.Pod{}
+		require.NoError(t, c.Get(context.Background(), key, current))
+		return PatchStatus(context.Background(), c, current, func() {
+			current.Status.Phase = phase
+		})
+	}
+
+	t.Run("retried conflicts", func(t *testing.T) {
+		c := &conflictingClient{Client: fake.NewFakeClient(pod.DeepCopy()), conflicts: 2}
+		require.NoError(t, patchPhase(c, corev1.PodRunning))
+
+		found := &corev1.Pod{}
+		require.NoError(t, c.Get(context.Background(), key, found))
+		assert.Equal(t, corev1.PodRunning, found.Status.Phase)
+	})
+
+	t.Run("too many conflicts", func(t *testing.T) {
+		c := &conflictingClient{Client: fake.NewFakeClient(pod.DeepCopy()), conflicts: 10}
+		err := patchPhase(c, corev1.PodRunning)
+		assert.True(t, k8sErrors.IsConflict(err))
+	})
+
+	t.Run("concurrent status change kept", func(t *testing.T) {
+		c := fake.NewFakeClient(pod.DeepCopy())
+		current := &corev1.Pod{}
+		require.NoError(t, c.Get(context.Background(), key, current))
+
+		concurrent := current.DeepCopy()
+		concurrent.Status.Conditions = append(concurrent.Status.Conditions, corev1.PodCondition{Type: corev1.PodReady, Status: corev1.ConditionTrue})
+		require.NoError(t, c.Status().Update(context.Background(), concurrent))
+
+		err := PatchStatus(context.Background(), c, current, func() {
+			current.Status.Conditions = append(current.Status.Conditions, corev1.PodCondition{Type: corev1.ContainersReady, Status: corev1.ConditionTrue})
+		})
+		require.NoError(t, err)
+
+		found := &corev1.Pod{}
+		require.NoError(t, c.Get(context.Background(), key, found))
+		require.Len(t, found.Status.Conditions, 2)
+		assert.Equal(t, corev1.PodReady, found.Status.Conditions[0].Type)
+		assert.Equal(t, corev1.ContainersReady, found.Status.Conditions[1].Type)
+	})
+}
@@ -0,0 +1,256 @@
+package webhookcert
+
+import (
+	"bytes"
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"fmt"
+	"math/big"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+const (
+	// CACertKey is the key of the CA bundle, the CA signing the serving
+	// certificate first, followed by the previous CA until it expires.
+	CACertKey = "ca.crt"
+	// CAKeyKey is the key of the private key of the CA.
+	CAKeyKey = "ca.key"
+	// CertKey is the key of the serving certificate.
+	CertKey = "tls.crt"
+	// KeyKey is the key of the private key of the serving certificate.
+	KeyKey = "tls.key"
+
+	caValidity   = 10 * 365 * 24 * time.Hour
+	certValidity = 365 * 24 * time.Hour
+	// The certificates are renewed once less than a tenth of their validity
+	// remains, so that the replicas pick up the new certificate before the
+	// old one expires.
+	caRenewBefore   = caValidity / 10
+	certRenewBefore = certValidity / 10
+)
+
+// Certificates are the PEM encoded certificates of the webhook server.
+type Certificates struct {
+	// CABundle are the CAs the API server trusts, the CA signing the
+	// serving certificate first.
+	CABundle []byte
+	CAKey    []byte
+	Cert     []byte
+	Key      []byte
+}
+
+// DNSNames returns the DNS names of the webhook service.
+func DNSNames(service, namespace string) []string {
+	return []string{
+		service,
+		fmt.Sprintf("%s.%s", service, namespace),
+		fmt.Sprintf("%s.%s.svc", service, namespace),
+		fmt.Sprintf("%s.%s.svc.cluster.local", service, namespace),
+	}
+}
+
+// Renew returns the certificates, renewing the CA and the serving
+// certificate if they are invalid or about to expire. The returned boolean
+// reports whether the certificates changed.
+func Renew(current Certificates, dnsNames []string, now time.Time) (Certificates, bool, error) {
+	renewed := current
+	changed := false
+
+	caCerts := parseCertificates(current.CABundle)
+	caKey, err := parsePrivateKey(current.CAKey)
+	if len(caCerts) == 0 || err != nil || !signerMatches(caCerts[0], caKey) || expiresBefore(caCerts[0], now.Add(caRenewBefore)) {
+		var caCert *x509.Certificate
+		caCert, caKey, err = generateCA(now)
+		if err != nil {
+			return Certificates{}, false, errors.Wrap(err, "failed to generate webhook CA")
+		}
+		caCerts = append([]*x509.Certificate{caCert}, caCerts...)
+		renewed.CAKey, err = encodePrivateKey(caKey)
+		if err != nil {
+			return Certificates{}, false, err
+		}
+		changed = true
+	}
+
+	bundle := encodeCertificates(unexpired(caCerts, now))
+	if !bytes.Equal(bundle, current.CABundle) {
+		renewed.CABundle = bundle
+		changed = true
+	}
+
+	if !certificateValid(current.Cert, current.Key, caCerts[0], dnsNames, now.Add(certRenewBefore)) {
+		renewed.Cert, renewed.Key, err = generateServingCert(caCerts[0], caKey, dnsNames, now)
+		if err != nil {
+			return Certificates{}, false, errors.Wrap(err, "failed to generate webhook serving certificate")
+		}
+		changed = true
+	}
+
+	return renewed, changed, nil
+}
+
+// certificateValid checks that the serving certificate matches its key, is
+// signed by the CA, covers the DNS names and is valid until the given time.
+func certificateValid(certPEM, keyPEM []byte, ca *x509.Certificate, dnsNames []string, until time.Time) bool {
+	keyPair, err := tls.X509KeyPair(certPEM, keyPEM)
+	if err != nil {
+		return false
+	}
+	cert, err := x509.ParseCertificate(keyPair.Certificate[0])
+	if err != nil {
+		return false
+	}
+	if expiresBefore(cert, until) || cert.CheckSignatureFrom(ca) != nil {
+		return false
+	}
+	for _, name := range dnsNames {
+		if cert.VerifyHostname(name) != nil {
+			return false
+		}
+	}
+	return true
+}
+
+func generateCA(now time.Time) (*x509.Certificate, crypto.Signer, error) {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		return nil, nil, err
+	}
+	serial, err := serialNumber()
+	if err != nil {
+		return nil, nil, err
+	}
+
+	template := &x509.Certificate{
+		SerialNumber:          serial,
+		Subject:               pkix.Name{CommonName: "mattermost-operator-webhook-ca"},
+		NotBefore:             now.Add(-time.Hour).UTC(),
+		NotAfter:              now.Add(caValidity).UTC(),
+		KeyUsage:              x509.KeyUsageCertSign | x509.KeyUsageDigitalSignature | x509.KeyUsageKeyEncipherment,
+		BasicConstraintsValid: true,
+		IsCA:                  true,
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, template, key.Public(), key)
+	if err != nil {
+		return nil, nil, err
+	}
+	cert, err := x509.ParseCertificate(der)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	return cert, key, nil
+}
+
+func generateServingCert(ca *x509.Certificate, caKey crypto.Signer, dnsNames []string, now time.Time) ([]byte, []byte, error) {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		return nil, nil, err
+	}
+	serial, err := serialNumber()
+	if err != nil {
+		return nil, nil, err
+	}
+
+	template := &x509.Certificate{
+		SerialNumber: serial,
+		Subject:      pkix.Name{CommonName: dnsNames[0]},
+		DNSNames:     dnsNames,
+		NotBefore:    now.Add(-time.Hour).UTC(),
+		NotAfter:     now.Add(certValidity).UTC(),
+		KeyUsage:     x509.KeyUsageDigitalSignature | x509.KeyUsageKeyEncipherment,
+		ExtKeyUsage:  []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth},
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, ca, key.Public(), caKey)
+	if err != nil {
+		return nil, nil, err
+	}
+	keyPEM, err := encodePrivateKey(key)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	return pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der}), keyPEM, nil
+}
+
+func serialNumber() (*big.Int, error) {
+	return rand.Int(rand.Reader, new(big.Int).Lsh(big.NewInt(1), 128))
+}
+
+func expiresBefore(cert *x509.Certificate, t time.Time) bool {
+	return cert.NotAfter.Before(t)
+}
+
+func signerMatches(cert *x509.Certificate, key crypto.Signer) bool {
+	publicKey, ok := key.Public().(interface{ Equal(crypto.PublicKey) bool })
+	return ok && publicKey.Equal(cert.PublicKey)
+}
+
+func unexpired(certs []*x509.Certificate, now time.Time) []*x509.Certificate {
+	valid := make([]*x509.Certificate, 0, len(certs))
+	for _, cert := range certs {
+		if !expiresBefore(cert, now) {
+			valid = append(valid, cert)
+		}
+	}
+	return valid
+}
+
+func parseCertificates(data []byte) []*x509.Certificate {
+	var certs []*x509.Certificate
+	for {
+		var block *pem.Block
+		block, data = pem.Decode(data)
+		if block == nil {
+			return certs
+		}
+		if block.Type != "CERTIFICATE" {
+			continue
+		}
+		cert, err := x509.ParseCertificate(block.Bytes)
+		if err != nil {
+			continue
+		}
+		certs = append(certs, cert)
+	}
+}
+
+func encodeCertificates(certs []*x509.Certificate) []byte {
+	var buffer bytes.Buffer
+	for _, cert := range certs {
+		_ = pem.Encode(&buffer, &pem.Block{Type: "CERTIFICATE", Bytes: cert.Raw})
+	}
+	return buffer.Bytes()
+}
+
+func parsePrivateKey(data []byte) (crypto.Signer, error) {
+	block, _ := pem.Decode(data)
+	if block == nil {
+		return nil, errors.New("no PEM encoded private key")
+	}
+	key, err := x509.ParsePKCS8PrivateKey(block.Bytes)
+	if err != nil {
+		return nil, err
+	}
+	signer, ok := key.(crypto.Signer)
+	if !ok {
+		return nil, errors.New("unsupported private key")
+	}
+	return signer, nil
+}
+
+func encodePrivateKey(key crypto.Signer) ([]byte, error) {
+	der, err := x509.MarshalPKCS8PrivateKey(key)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to encode private key")
+	}
+	return pem.EncodeToMemory(&pem.Block{Type: "PRIVATE KEY", Bytes: der}), nil
+}
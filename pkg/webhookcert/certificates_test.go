@@ -0,0 +1,98 @@
+package webhookcert
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRenew(t *testing.T) {
+	now := time.Date(2021, 6, 1, 0, 0, 0, 0, time.UTC)
+	dnsNames := DNSNames("webhook-service", "mattermost-operator")
+
+	generated, changed, err := Renew(Certificates{}, dnsNames, now)
+	require.NoError(t, err)
+	assert.True(t, changed)
+
+	t.Run("should generate a serving certificate signed by the CA", func(t *testing.T) {
+		keyPair, err := tls.X509KeyPair(generated.Cert, generated.Key)
+		require.NoError(t, err)
+		cert, err := x509.ParseCertificate(keyPair.Certificate[0])
+		require.NoError(t, err)
+
+		pool := x509.NewCertPool()
+		require.True(t, pool.AppendCertsFromPEM(generated.CABundle))
+		_, err = cert.Verify(x509.VerifyOptions{
+			DNSName:     "webhook-service.mattermost-operator.svc",
+			Roots:       pool,
+			CurrentTime: now,
+		})
+		assert.NoError(t, err)
+	})
+
+	t.Run("should keep valid certificates", func(t *testing.T) {
+		renewed, changed, err := Renew(generated, dnsNames, now.Add(30*24*time.Hour))
+		require.NoError(t, err)
+		assert.False(t, changed)
+		assert.Equal(t, generated, renewed)
+	})
+
+	t.Run("should renew the serving certificate before it expires", func(t *testing.T) {
+		renewed, changed, err := Renew(generated, dnsNames, now.Add(certValidity-certRenewBefore/2))
+		require.NoError(t, err)
+		assert.True(t, changed)
+		assert.Equal(t, generated.CABundle, renewed.CABundle)
+		assert.Equal(t, generated.CAKey, renewed.CAKey)
+		assert.NotEqual(t, generated.Cert, renewed.Cert)
+		assert.NotEqual(t, generated.Key, renewed.Key)
+	})
+
+	t.Run("should renew the serving certificate if the DNS names changed", func(t *testing.T) {
+		renewed, changed, err := Renew(generated, DNSNames("other-service", "mattermost-operator"), now)
+		require.NoError(t, err)
+		assert.True(t, changed)
+		assert.Equal(t, generated.CABundle, renewed.CABundle)
+		assert.NotEqual(t, generated.Cert, renewed.Cert)
+	})
+
+	t.Run("should renew the CA before it expires and keep trusting the previous one", func(t *testing.T) {
+		renewed, changed, err := Renew(generated, dnsNames, now.Add(caValidity-caRenewBefore/2))
+		require.NoError(t, err)
+		assert.True(t, changed)
+		assert.NotEqual(t, generated.CAKey, renewed.CAKey)
+		assert.NotEqual(t, generated.Cert, renewed.Cert)
+
+		bundle := parseCertificates(renewed.CABundle)
+		require.Len(t, bundle, 2)
+		assert.Equal(t, parseCertificates(generated.CABundle)[0], bundle[1])
+	})
+
+	t.Run("should drop the expired CAs from the bundle", func(t *testing.T) {
+		renewed, _, err := Renew(generated, dnsNames, now.Add(caValidity-caRenewBefore/2))
+		require.NoError(t, err)
+
+		renewed, changed, err := Renew(renewed, dnsNames, now.Add(caValidity+time.Hour))
+		require.NoError(t, err)
+		assert.True(t, changed)
+		assert.Len(t, parseCertificates(renewed.CABundle), 1)
+	})
+
+	t.Run("should regenerate invalid certificates", func(t *testing.T) {
+		renewed, changed, err := Renew(Certificates{
+			CABundle: []byte("invalid"),
+			CAKey:    generated.Key,
+			Cert:     generated.Cert,
+			Key:      generated.CAKey,
+		}, dnsNames, now)
+		require.NoError(t, err)
+		assert.True(t, changed)
+
+		_, err = tls.X509KeyPair(renewed.Cert, renewed.Key)
+		assert.NoError(t, err)
+		assert.Len(t, parseCertificates(renewed.CABundle), 1)
+	})
+}
@@ -0,0 +1,267 @@
+package webhookcert
+
+import (
+	"bytes"
+	"context"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/go-logr/logr"
+	"github.com/pkg/errors"
+	admissionregistrationv1 "k8s.io/api/admissionregistration/v1"
+	corev1 "k8s.io/api/core/v1"
+	k8sErrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+const (
+	// MutatingWebhookConfigurationName is the name of the mutating webhook
+	// configuration of the operator.
+	MutatingWebhookConfigurationName = "mutating-webhook-configuration"
+	// ValidatingWebhookConfigurationName is the name of the validating
+	// webhook configuration of the operator.
+	ValidatingWebhookConfigurationName = "validating-webhook-configuration"
+
+	// saveAttempts is how many times the Secret is saved when another
+	// replica saved it concurrently.
+	saveAttempts = 3
+)
+
+// Rotator generates the serving certificate of the webhook server, stores it
+// in a Secret shared by the operator replicas, injects its CA in the webhook
+// configurations and renews it before it expires.
+type Rotator struct {
+	Client client.Client
+	// Reader reads the Secret and the webhook configurations, as the
+	// certificates are written before the cache of the manager is started.
+	Reader client.Reader
+	Log    logr.Logger
+	// Secret is the Secret storing the certificates.
+	Secret types.NamespacedName
+	// DNSNames are the DNS names of the webhook service.
+	DNSNames []string
+	// CertDir is the directory the webhook server reads its certificate
+	// from.
+	CertDir string
+	// MutatingWebhookConfigurations and ValidatingWebhookConfigurations are
+	// the webhook configurations the CA is injected in.
+	MutatingWebhookConfigurations   []string
+	ValidatingWebhookConfigurations []string
+	// CheckInterval is how often the certificates are checked.
+	CheckInterval time.Duration
+
+	now func() time.Time
+}
+
+// NewRotator returns a Rotator storing the certificates of the webhook
+// service in the Secret, both in the namespace of the operator.
+func NewRotator(mgr ctrl.Manager, namespace, serviceName, secretName, certDir string) *Rotator {
+	return &Rotator{
+		Client:                          mgr.GetClient(),
+		Reader:                          mgr.GetAPIReader(),
+		Log:                             ctrl.Log.WithName("webhookcert"),
+		Secret:                          types.NamespacedName{Name: secretName, Namespace: namespace},
+		DNSNames:                        DNSNames(serviceName, namespace),
+		CertDir:                         certDir,
+		MutatingWebhookConfigurations:   []string{MutatingWebhookConfigurationName},
+		ValidatingWebhookConfigurations: []string{ValidatingWebhookConfigurationName},
+		CheckInterval:                   time.Hour,
+		now:                             time.Now,
+	}
+}
+
+// Start renews the certificates every check interval until the context is
+// done.
+func (r *Rotator) Start(ctx context.Context) error {
+	ticker := time.NewTicker(r.CheckInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-ticker.C:
+			err := r.EnsureCertificates(ctx)
+			if err != nil {
+				r.Log.Error(err, "Failed to renew webhook certificates")
+			}
+		}
+	}
+}
+
+// NeedLeaderElection implements manager.LeaderElectionRunnable. Every
+// replica serves the webhooks, therefore writes the certificates.
+func (r *Rotator) NeedLeaderElection() bool {
+	return false
+}
+
+// EnsureCertificates renews the certificates if needed, injects the CA in
+// the webhook configurations and writes the serving certificate to the
+// certificate directory.
+func (r *Rotator) EnsureCertificates(ctx context.Context) error {
+	certificates, err := r.renewSecret(ctx)
+	if err != nil {
+		return err
+	}
+
+	err = r.injectCABundle(ctx, certificates.CABundle)
+	if err != nil {
+		return err
+	}
+
+	return r.writeCertificates(certificates)
+}
+
+// renewSecret renews the certificates stored in the Secret, reading them
+// again if another replica saved the Secret concurrently.
+func (r *Rotator) renewSecret(ctx context.Context) (Certificates, error) {
+	var err error
+	for attempt := 0; attempt < saveAttempts; attempt++ {
+		secret := &corev1.Secret{}
+		err = r.Reader.Get(ctx, r.Secret, secret)
+		if err != nil && !k8sErrors.IsNotFound(err) {
+			return Certificates{}, errors.Wrap(err, "failed to get webhook certificates secret")
+		}
+		exists := err == nil
+
+		current := Certificates{
+			CABundle: secret.Data[CACertKey],
+			CAKey:    secret.Data[CAKeyKey],
+			Cert:     secret.Data[CertKey],
+			Key:      secret.Data[KeyKey],
+		}
+		var renewed Certificates
+		var changed bool
+		renewed, changed, err = Renew(current, r.DNSNames, r.now())
+		if err != nil {
+			return Certificates{}, err
+		}
+		if !changed {
+			return current, nil
+		}
+
+		secret.Data = map[string][]byte{
+			CACertKey: renewed.CABundle,
+			CAKeyKey:  renewed.CAKey,
+			CertKey:   renewed.Cert,
+			KeyKey:    renewed.Key,
+		}
+		if exists {
+			err = r.Client.Update(ctx, secret)
+		} else {
+			secret.ObjectMeta = metav1.ObjectMeta{Name: r.Secret.Name, Namespace: r.Secret.Namespace}
+			secret.Type = corev1.SecretTypeTLS
+			err = r.Client.Create(ctx, secret)
+		}
+		if k8sErrors.IsConflict(err) || k8sErrors.IsAlreadyExists(err) {
+			continue
+		}
+		if err != nil {
+			return Certificates{}, errors.Wrap(err, "failed to save webhook certificates secret")
+		}
+
+		r.Log.Info("Renewed webhook certificates", "secret", r.Secret.Name)
+		return renewed, nil
+	}
+
+	return Certificates{}, errors.Wrap(err, "failed to save webhook certificates secret")
+}
+
+// injectCABundle sets the CA bundle of the webhooks of the webhook
+// configurations. Missing webhook configurations are skipped.
+func (r *Rotator) injectCABundle(ctx context.Context, caBundle []byte) error {
+	for _, name := range r.MutatingWebhookConfigurations {
+		configuration := &admissionregistrationv1.MutatingWebhookConfiguration{}
+		err := r.Reader.Get(ctx, types.NamespacedName{Name: name}, configuration)
+		if k8sErrors.IsNotFound(err) {
+			r.Log.Info("Skipping missing mutating webhook configuration", "name", name)
+			continue
+		}
+		if err != nil {
+			return errors.Wrapf(err, "failed to get mutating webhook configuration %s", name)
+		}
+
+		changed := false
+		for i := range configuration.Webhooks {
+			changed = setCABundle(&configuration.Webhooks[i].ClientConfig, caBundle) || changed
+		}
+		if !changed {
+			continue
+		}
+		err = r.Client.Update(ctx, configuration)
+		if err != nil {
+			return errors.Wrapf(err, "failed to inject CA in mutating webhook configuration %s", name)
+		}
+	}
+
+	for _, name := range r.ValidatingWebhookConfigurations {
+		configuration := &admissionregistrationv1.ValidatingWebhookConfiguration{}
+		err := r.Reader.Get(ctx, types.NamespacedName{Name: name}, configuration)
+		if k8sErrors.IsNotFound(err) {
+			r.Log.Info("Skipping missing validating webhook configuration", "name", name)
+			continue
+		}
+		if err != nil {
+			return errors.Wrapf(err, "failed to get validating webhook configuration %s", name)
+		}
+
+		changed := false
+		for i := range configuration.Webhooks {
+			changed = setCABundle(&configuration.Webhooks[i].ClientConfig, caBundle) || changed
+		}
+		if !changed {
+			continue
+		}
+		err = r.Client.Update(ctx, configuration)
+		if err != nil {
+			return errors.Wrapf(err, "failed to inject CA in validating webhook configuration %s", name)
+		}
+	}
+
+	return nil
+}
+
+func setCABundle(clientConfig *admissionregistrationv1.WebhookClientConfig, caBundle []byte) bool {
+	if bytes.Equal(clientConfig.CABundle, caBundle) {
+		return false
+	}
+	clientConfig.CABundle = caBundle
+	return true
+}
+
+// writeCertificates writes the serving certificate to the certificate
+// directory if it changed. The webhook server reloads it once written.
+func (r *Rotator) writeCertificates(certificates Certificates) error {
+	err := os.MkdirAll(r.CertDir, 0700)
+	if err != nil {
+		return errors.Wrap(err, "failed to create webhook certificate directory")
+	}
+
+	// The key is written first, so that the webhook server keeps serving
+	// the previous certificate until both match.
+	files := []struct {
+		name string
+		data []byte
+	}{
+		{name: KeyKey, data: certificates.Key},
+		{name: CertKey, data: certificates.Cert},
+	}
+	for _, file := range files {
+		path := filepath.Join(r.CertDir, file.name)
+		current, err := ioutil.ReadFile(path)
+		if err == nil && bytes.Equal(current, file.data) {
+			continue
+		}
+		err = ioutil.WriteFile(path, file.data, 0600)
+		if err != nil {
+			return errors.Wrapf(err, "failed to write webhook certificate file %s", file.name)
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,101 @@
+package webhookcert
+
+import (
+	"context"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	blubr "github.com/mattermost/blubr"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	admissionregistrationv1 "k8s.io/api/admissionregistration/v1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/kubernetes/scheme"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+)
+
+func TestRotator(t *testing.T) {
+	certDir, err := ioutil.TempDir("", "webhookcert")
+	require.NoError(t, err)
+	defer os.RemoveAll(certDir)
+
+	mutating := &admissionregistrationv1.MutatingWebhookConfiguration{
+		ObjectMeta: metav1.ObjectMeta{Name: MutatingWebhookConfigurationName},
+		Webhooks:   []admissionregistrationv1.MutatingWebhook{{Name: "mmattermost.kb.io"}},
+	}
+	validating := &admissionregistrationv1.ValidatingWebhookConfiguration{
+		ObjectMeta: metav1.ObjectMeta{Name: ValidatingWebhookConfigurationName},
+		Webhooks:   []admissionregistrationv1.ValidatingWebhook{{Name: "vmattermost.kb.io"}},
+	}
+	c := fake.NewFakeClientWithScheme(scheme.Scheme, mutating, validating)
+
+	now := time.Date(2021, 6, 1, 0, 0, 0, 0, time.UTC)
+	rotator := &Rotator{
+		Client:                          c,
+		Reader:                          c,
+		Log:                             blubr.InitLogger(),
+		Secret:                          types.NamespacedName{Name: "webhook-server-cert", Namespace: "mattermost-operator"},
+		DNSNames:                        DNSNames("webhook-service", "mattermost-operator"),
+		CertDir:                         certDir,
+		MutatingWebhookConfigurations:   []string{MutatingWebhookConfigurationName},
+		ValidatingWebhookConfigurations: []string{ValidatingWebhookConfigurationName, "missing"},
+		CheckInterval:                   time.Hour,
+		now:                             func() time.Time { return now },
+	}
+
+	assertCertificates := func(t *testing.T) *corev1.Secret {
+		secret := &corev1.Secret{}
+		err := c.Get(context.Background(), rotator.Secret, secret)
+		require.NoError(t, err)
+		assert.Equal(t, corev1.SecretTypeTLS, secret.Type)
+
+		err = c.Get(context.Background(), types.NamespacedName{Name: MutatingWebhookConfigurationName}, mutating)
+		require.NoError(t, err)
+		assert.Equal(t, secret.Data[CACertKey], mutating.Webhooks[0].ClientConfig.CABundle)
+		err = c.Get(context.Background(), types.NamespacedName{Name: ValidatingWebhookConfigurationName}, validating)
+		require.NoError(t, err)
+		assert.Equal(t, secret.Data[CACertKey], validating.Webhooks[0].ClientConfig.CABundle)
+
+		cert, err := ioutil.ReadFile(filepath.Join(certDir, CertKey))
+		require.NoError(t, err)
+		assert.Equal(t, secret.Data[CertKey], cert)
+		key, err := ioutil.ReadFile(filepath.Join(certDir, KeyKey))
+		require.NoError(t, err)
+		assert.Equal(t, secret.Data[KeyKey], key)
+
+		return secret
+	}
+
+	var generated *corev1.Secret
+
+	t.Run("should generate certificates", func(t *testing.T) {
+		err := rotator.EnsureCertificates(context.Background())
+		require.NoError(t, err)
+
+		generated = assertCertificates(t)
+	})
+
+	t.Run("should keep valid certificates", func(t *testing.T) {
+		err := rotator.EnsureCertificates(context.Background())
+		require.NoError(t, err)
+
+		secret := assertCertificates(t)
+		assert.Equal(t, generated.Data, secret.Data)
+	})
+
+	t.Run("should renew the certificates before they expire", func(t *testing.T) {
+		now = now.Add(certValidity - certRenewBefore/2)
+
+		err := rotator.EnsureCertificates(context.Background())
+		require.NoError(t, err)
+
+		secret := assertCertificates(t)
+		assert.Equal(t, generated.Data[CACertKey], secret.Data[CACertKey])
+		assert.NotEqual(t, generated.Data[CertKey], secret.Data[CertKey])
+	})
+}
@@ -0,0 +1,125 @@
+package main
+
+import (
+	"bufio"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+
+	mmv1beta "github.com/mattermost/mattermost-operator/apis/mattermost/v1beta1"
+	mattermostcontroller "github.com/mattermost/mattermost-operator/controllers/mattermost/mattermost"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/runtime/serializer/json"
+	utilyaml "k8s.io/apimachinery/pkg/util/yaml"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/apiutil"
+)
+
+// renderCommand is the subcommand printing the resources the operator
+// creates for a Mattermost, without a cluster.
+const renderCommand = "render"
+
+// render validates the Mattermost of the manifest and prints the resources
+// generated for it, or its validation errors. It returns the exit code of
+// the subcommand.
+func render(args []string) int {
+	flags := flag.NewFlagSet(renderCommand, flag.ExitOnError)
+	filename := flags.String("f", "-", "The manifest of the Mattermost, along with the Secrets it references, - for the standard input.")
+	namespace := flags.String("namespace", "default", "The namespace of the Mattermost, if it is not set in the manifest.")
+	imageRegistry := flags.String("image-registry", "", "The image registry of the operator.")
+	_ = flags.Parse(args)
+
+	input := os.Stdin
+	if *filename != "-" {
+		file, err := os.Open(*filename)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Unable to open the manifest: %s\n", err)
+			return 1
+		}
+		defer file.Close()
+		input = file
+	}
+
+	// Unknown and duplicate fields are rejected, the way the API server
+	// prunes them from the stored Mattermost.
+	decoder := json.NewSerializerWithOptions(json.DefaultMetaFactory, scheme, scheme, json.SerializerOptions{Yaml: true, Strict: true})
+	var mattermost *mmv1beta.Mattermost
+	options := mattermostcontroller.RenderOptions{ImageRegistry: *imageRegistry}
+	reader := utilyaml.NewYAMLReader(bufio.NewReader(input))
+	for {
+		document, err := reader.Read()
+		if err == io.EOF {
+			break
+		} else if err != nil {
+			fmt.Fprintf(os.Stderr, "Unable to read the manifest: %s\n", err)
+			return 1
+		}
+		// Empty documents, ie comments only, are skipped.
+		data, err := utilyaml.ToJSON(document)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Invalid manifest: %s\n", err)
+			return 1
+		}
+		if string(data) == "null" {
+			continue
+		}
+		obj, _, err := decoder.Decode(document, nil, nil)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Invalid manifest: %s\n", err)
+			return 1
+		}
+		switch obj := obj.(type) {
+		case *mmv1beta.Mattermost:
+			if mattermost != nil {
+				fmt.Fprintln(os.Stderr, "The manifest has several Mattermosts")
+				return 2
+			}
+			mattermost = obj
+		case *corev1.Secret:
+			// The string data is merged into the data by the API server.
+			for key, value := range obj.StringData {
+				if obj.Data == nil {
+					obj.Data = map[string][]byte{}
+				}
+				obj.Data[key] = []byte(value)
+			}
+			options.Secrets = append(options.Secrets, *obj)
+		default:
+			fmt.Fprintf(os.Stderr, "Warning: skipping %s, only the Mattermost and its Secrets are read\n", obj.GetObjectKind().GroupVersionKind().Kind)
+		}
+	}
+	if mattermost == nil {
+		fmt.Fprintln(os.Stderr, "The manifest has no Mattermost")
+		return 2
+	}
+	if mattermost.Namespace == "" {
+		mattermost.Namespace = *namespace
+	}
+
+	result, err := mattermostcontroller.Render(mattermost, options)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Invalid Mattermost %s: %s\n", mattermost.Name, err)
+		return 1
+	}
+	for _, warning := range result.Warnings {
+		fmt.Fprintf(os.Stderr, "Warning: %s\n", warning)
+	}
+
+	serializer := json.NewSerializerWithOptions(json.DefaultMetaFactory, scheme, scheme, json.SerializerOptions{Yaml: true})
+	for _, obj := range append([]client.Object{result.Mattermost}, result.Objects...) {
+		gvk, err := apiutil.GVKForObject(obj, scheme)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Unable to print %s: %s\n", obj.GetName(), err)
+			return 1
+		}
+		obj.GetObjectKind().SetGroupVersionKind(gvk)
+		fmt.Println("---")
+		err = serializer.Encode(obj, os.Stdout)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Unable to print %s: %s\n", obj.GetName(), err)
+			return 1
+		}
+	}
+	return 0
+}
@@ -77,7 +77,9 @@ func SetupTest() error {
 		return err
 	}
 
-	return nil
+	// The CRD bases installed above do not include the validation rules
+	// patched in the deployed CRD.
+	return installMattermostValidationRules(k8sClient)
 }
 
 func Cleanup() error {
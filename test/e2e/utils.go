@@ -2,9 +2,15 @@ package e2e
 
 import (
 	"context"
+	"io/ioutil"
+	"path/filepath"
 	"testing"
 	"time"
 
+	jsonpatch "github.com/evanphx/json-patch"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/util/yaml"
+
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/client-go/kubernetes"
 
@@ -136,3 +142,47 @@ func waitForDeletion(t *testing.T, dynclient client.Client, obj client.Object, r
 	t.Logf("%s %s was deleted\n", kind, key)
 	return nil
 }
+
+// installMattermostValidationRules updates the installed Mattermost CRD with
+// the validation rules that kustomize patches into the deployed CRD.
+func installMattermostValidationRules(dynclient client.Client) error {
+	crdDir := filepath.Join("..", "..", "config", "crd")
+	crdData, err := ioutil.ReadFile(filepath.Join(crdDir, "bases", "installation.mattermost.com_mattermosts.yaml"))
+	if err != nil {
+		return err
+	}
+	patchData, err := ioutil.ReadFile(filepath.Join(crdDir, "patches", "validation_in_mattermosts.yaml"))
+	if err != nil {
+		return err
+	}
+
+	crdJSON, err := yaml.ToJSON(crdData)
+	if err != nil {
+		return err
+	}
+	patchJSON, err := yaml.ToJSON(patchData)
+	if err != nil {
+		return err
+	}
+	patch, err := jsonpatch.DecodePatch(patchJSON)
+	if err != nil {
+		return err
+	}
+	crdJSON, err = patch.Apply(crdJSON)
+	if err != nil {
+		return err
+	}
+
+	crd := &unstructured.Unstructured{}
+	err = crd.UnmarshalJSON(crdJSON)
+	if err != nil {
+		return err
+	}
+	existing := crd.DeepCopy()
+	err = dynclient.Get(context.TODO(), client.ObjectKeyFromObject(crd), existing)
+	if err != nil {
+		return err
+	}
+	crd.SetResourceVersion(existing.GetResourceVersion())
+	return dynclient.Update(context.TODO(), crd)
+}
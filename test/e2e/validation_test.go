@@ -0,0 +1,247 @@
+package e2e
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"strings"
+	"testing"
+
+	operator "github.com/mattermost/mattermost-operator/apis/mattermost/v1beta1"
+	mattermostApp "github.com/mattermost/mattermost-operator/pkg/mattermost"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes"
+	"k8s.io/client-go/util/retry"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+// TestMattermostValidationRules tests the CEL validation rules of the
+// Mattermost CRD, see config/crd/patches/validation_in_mattermosts.yaml.
+func TestMattermostValidationRules(t *testing.T) {
+	k8sTypedClient, err := kubernetes.NewForConfig(cfg)
+	require.NoError(t, err)
+	serverVersion, err := k8sTypedClient.Discovery().ServerVersion()
+	require.NoError(t, err)
+	minor, err := strconv.Atoi(strings.TrimSuffix(serverVersion.Minor, "+"))
+	require.NoError(t, err)
+	if serverVersion.Major == "1" && minor < 25 {
+		t.Skipf("CEL validation rules require k8s 1.25 or later, the server runs %s", serverVersion.GitVersion)
+	}
+
+	newSpec := func() operator.MattermostSpec {
+		return operator.MattermostSpec{
+			Version: "5.37.1",
+			Ingress: &operator.Ingress{Enabled: true, Host: "validation.mattermost.dev"},
+		}
+	}
+
+	t.Run("create", func(t *testing.T) {
+		for _, tc := range []struct {
+			name   string
+			modify func(spec *operator.MattermostSpec)
+			err    string
+		}{
+			{
+				name:   "valid",
+				modify: func(spec *operator.MattermostSpec) {},
+			},
+			{
+				name:   "version minor release",
+				modify: func(spec *operator.MattermostSpec) { spec.Version = "5.37" },
+			},
+			{
+				name:   "version latest",
+				modify: func(spec *operator.MattermostSpec) { spec.Version = "latest" },
+			},
+			{
+				name:   "version pre-release",
+				modify: func(spec *operator.MattermostSpec) { spec.Version = "5.38.0-rc1" },
+			},
+			{
+				name: "version digest",
+				modify: func(spec *operator.MattermostSpec) {
+					spec.Version = "sha256:" + strings.Repeat("0123456789abcdef", 4)
+				},
+			},
+			{
+				name:   "version not an image tag",
+				modify: func(spec *operator.MattermostSpec) { spec.Version = "-5.37.1" },
+				err:    "version must be an image tag",
+			},
+			{
+				name:   "version invalid digest",
+				modify: func(spec *operator.MattermostSpec) { spec.Version = "sha256:0123" },
+				err:    "version must be an image tag",
+			},
+			{
+				name: "external database",
+				modify: func(spec *operator.MattermostSpec) {
+					spec.Database.External = &operator.ExternalDatabase{Secret: "database"}
+				},
+			},
+			{
+				name: "external database without secret",
+				modify: func(spec *operator.MattermostSpec) {
+					spec.Database.External = &operator.ExternalDatabase{}
+				},
+				err: "database.external.secret required, but not set",
+			},
+			{
+				name: "external file store",
+				modify: func(spec *operator.MattermostSpec) {
+					spec.FileStore.External = &operator.ExternalFileStore{URL: "s3.amazonaws.com", Bucket: "mattermost"}
+				},
+			},
+			{
+				name: "external file store without bucket",
+				modify: func(spec *operator.MattermostSpec) {
+					spec.FileStore.External = &operator.ExternalFileStore{URL: "s3.amazonaws.com"}
+				},
+				err: "fileStore.external.url and fileStore.external.bucket required, but not set",
+			},
+			{
+				name: "external file store without url",
+				modify: func(spec *operator.MattermostSpec) {
+					spec.FileStore.External = &operator.ExternalFileStore{Bucket: "mattermost"}
+				},
+				err: "fileStore.external.url and fileStore.external.bucket required, but not set",
+			},
+			{
+				name: "ingress with ingress name",
+				modify: func(spec *operator.MattermostSpec) {
+					spec.Ingress.Host = ""
+					spec.IngressName = "validation.mattermost.dev"
+				},
+			},
+			{
+				name:   "ingress without host",
+				modify: func(spec *operator.MattermostSpec) { spec.Ingress.Host = "" },
+				err:    "ingress.host required, but not set",
+			},
+			{
+				name: "disabled ingress with TLS secret",
+				modify: func(spec *operator.MattermostSpec) {
+					spec.Ingress = &operator.Ingress{Enabled: false, TLSSecret: "tls"}
+				},
+				err: "ingress.tlsSecret requires the ingress to be enabled",
+			},
+			{
+				name: "canary and disabled blueGreen",
+				modify: func(spec *operator.MattermostSpec) {
+					spec.Canary = &operator.Canary{Enabled: true, Deployment: operator.AppDeployment{Version: "5.38.0"}}
+					spec.BlueGreen = &operator.BlueGreen{Enabled: false, ProductionDeployment: operator.BlueName}
+				},
+			},
+			{
+				name: "canary and blueGreen",
+				modify: func(spec *operator.MattermostSpec) {
+					spec.Canary = &operator.Canary{Enabled: true, Deployment: operator.AppDeployment{Version: "5.38.0"}}
+					spec.BlueGreen = &operator.BlueGreen{Enabled: true, ProductionDeployment: operator.BlueName}
+				},
+				err: "canary and blueGreen cannot be enabled at the same time",
+			},
+		} {
+			t.Run(tc.name, func(t *testing.T) {
+				mattermost := &operator.Mattermost{
+					ObjectMeta: metav1.ObjectMeta{Name: "test-validation", Namespace: mmNamespace},
+					Spec:       newSpec(),
+				}
+				tc.modify(&mattermost.Spec)
+
+				err := k8sClient.Create(context.TODO(), mattermost, client.DryRunAll)
+				assertValidationError(t, tc.err, err)
+			})
+		}
+	})
+
+	t.Run("update", func(t *testing.T) {
+		for i, tc := range []struct {
+			name   string
+			old    func(spec *operator.MattermostSpec)
+			modify func(spec *operator.MattermostSpec)
+			err    string
+		}{
+			{
+				name:   "version from minor release",
+				old:    func(spec *operator.MattermostSpec) { spec.Version = "5.37" },
+				modify: func(spec *operator.MattermostSpec) { spec.Version = "5.38" },
+			},
+			{
+				name: "database backend with allowMigration",
+				old:  func(spec *operator.MattermostSpec) {},
+				modify: func(spec *operator.MattermostSpec) {
+					spec.Database.External = &operator.ExternalDatabase{Secret: "database"}
+					spec.Database.AllowMigration = true
+				},
+			},
+			{
+				name: "database backend",
+				old:  func(spec *operator.MattermostSpec) {},
+				modify: func(spec *operator.MattermostSpec) {
+					spec.Database.External = &operator.ExternalDatabase{Secret: "database"}
+				},
+				err: "database backend cannot be changed",
+			},
+			{
+				name: "file store backend after migration",
+				old: func(spec *operator.MattermostSpec) {
+					spec.FileStore.MigrateTo = &operator.ExternalFileStore{URL: "s3.amazonaws.com", Bucket: "mattermost"}
+				},
+				modify: func(spec *operator.MattermostSpec) {
+					spec.FileStore = operator.FileStore{
+						External: &operator.ExternalFileStore{URL: "s3.amazonaws.com", Bucket: "mattermost"},
+					}
+				},
+			},
+			{
+				name: "file store backend",
+				old:  func(spec *operator.MattermostSpec) {},
+				modify: func(spec *operator.MattermostSpec) {
+					spec.FileStore.External = &operator.ExternalFileStore{URL: "s3.amazonaws.com", Bucket: "mattermost"}
+				},
+				err: "file store backend cannot be changed",
+			},
+		} {
+			t.Run(tc.name, func(t *testing.T) {
+				// The reconciliation is paused so that the Mattermost is
+				// only changed by the test.
+				mattermost := &operator.Mattermost{
+					ObjectMeta: metav1.ObjectMeta{
+						Name:        fmt.Sprintf("test-validation-%d", i),
+						Namespace:   mmNamespace,
+						Annotations: map[string]string{mattermostApp.PausedAnnotation: "true"},
+					},
+					Spec: newSpec(),
+				}
+				tc.old(&mattermost.Spec)
+
+				err := k8sClient.Create(context.TODO(), mattermost)
+				require.NoError(t, err)
+				defer func() {
+					assert.NoError(t, k8sClient.Delete(context.TODO(), mattermost))
+				}()
+
+				err = retry.RetryOnConflict(retry.DefaultRetry, func() error {
+					err := k8sClient.Get(context.TODO(), client.ObjectKeyFromObject(mattermost), mattermost)
+					if err != nil {
+						return err
+					}
+					tc.modify(&mattermost.Spec)
+					return k8sClient.Update(context.TODO(), mattermost, client.DryRunAll)
+				})
+				assertValidationError(t, tc.err, err)
+			})
+		}
+	})
+}
+
+func assertValidationError(t *testing.T, expected string, err error) {
+	if expected == "" {
+		assert.NoError(t, err)
+		return
+	}
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), expected)
+}